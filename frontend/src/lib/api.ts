@@ -215,7 +215,7 @@ class APIClient {
     return this.request<LearningPlan>(`/api/plan/${planId}/replan`, {
       method: 'POST',
       body: JSON.stringify({
-        completed_lessons: completedLessons,
+        completed_resources: completedLessons,
         feedback,
       }),
     })
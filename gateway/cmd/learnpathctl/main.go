@@ -0,0 +1,308 @@
+// learnpathctl is a command-line client for the gateway API
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/amirhf/learnpath-gateway/pkg/sdk"
+)
+
+const usage = `Usage: learnpathctl [flags] <command> [args]
+
+Commands:
+  search <query>                        Search learning resources
+  plan create --goal G --hours N        Create a learning plan
+  plan get <plan-id>                    Show a plan
+  plan list <user-id>                   List a user's plans
+  quiz take <resource-id>...            Generate a quiz and answer it interactively
+  ingest <file>                         Ingest the URLs in file, one per line ("-" for stdin)
+
+Flags:
+`
+
+// cli holds the global flags shared by every command
+type cli struct {
+	client *sdk.Client
+	json   bool
+	in     *bufio.Reader
+	out    io.Writer
+}
+
+func main() {
+	global := flag.NewFlagSet("learnpathctl", flag.ExitOnError)
+	baseURL := global.String("url", envOr("LEARNPATH_URL", "http://localhost:8080"), "gateway URL (LEARNPATH_URL)")
+	token := global.String("token", os.Getenv("LEARNPATH_TOKEN"), "bearer token (LEARNPATH_TOKEN)")
+	asJSON := global.Bool("json", false, "print raw JSON responses")
+	global.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		global.PrintDefaults()
+	}
+	global.Parse(os.Args[1:])
+	if global.NArg() == 0 {
+		global.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c := &cli{
+		client: sdk.New(sdk.Options{BaseURL: *baseURL, Token: *token, UserAgent: "learnpathctl"}),
+		json:   *asJSON,
+		in:     bufio.NewReader(os.Stdin),
+		out:    os.Stdout,
+	}
+	if err := c.run(ctx, global.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	command, args := args[0], args[1:]
+	switch command {
+	case "search":
+		return c.search(ctx, args)
+	case "plan":
+		if len(args) == 0 {
+			return errors.New("plan: expected create, get or list")
+		}
+		switch args[0] {
+		case "create":
+			return c.planCreate(ctx, args[1:])
+		case "get":
+			return c.planGet(ctx, args[1:])
+		case "list":
+			return c.planList(ctx, args[1:])
+		}
+		return fmt.Errorf("plan: unknown subcommand %q", args[0])
+	case "quiz":
+		if len(args) == 0 || args[0] != "take" {
+			return errors.New("quiz: expected take")
+		}
+		return c.quizTake(ctx, args[1:])
+	case "ingest":
+		return c.ingest(ctx, args)
+	}
+	return fmt.Errorf("unknown command %q", command)
+}
+
+func (c *cli) search(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	topK := fs.Int("top-k", 10, "number of results")
+	rerank := fs.Bool("rerank", false, "rerank results")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("search: query is required")
+	}
+
+	resp, err := c.client.Search(ctx, sdk.SearchRequest{Query: strings.Join(fs.Args(), " "), TopK: *topK, Rerank: *rerank})
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(resp)
+	}
+	for i, r := range resp.Results {
+		fmt.Fprintf(c.out, "%2d. %s (%.2f)\n    %s\n    id: %s\n", i+1, r.Title, r.Score, r.URL, r.ResourceID)
+	}
+	fmt.Fprintf(c.out, "%d results\n", resp.TotalFound)
+	return nil
+}
+
+func (c *cli) planCreate(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("plan create", flag.ExitOnError)
+	goal := fs.String("goal", "", "learning goal")
+	hours := fs.Int("hours", 0, "total time budget in hours")
+	perWeek := fs.Int("per-week", 5, "hours per week")
+	skills := fs.String("skills", "", "comma-separated current skills")
+	quiz := fs.Bool("quiz", false, "generate a quiz with the plan")
+	fs.Parse(args)
+	if *goal == "" || *hours <= 0 {
+		return errors.New("plan create: --goal and --hours are required")
+	}
+
+	req := sdk.PlanRequest{Goal: *goal, TimeBudgetHours: *hours, HoursPerWeek: *perWeek, GenerateQuiz: *quiz}
+	if *skills != "" {
+		req.CurrentSkills = strings.Split(*skills, ",")
+	}
+	resp, err := c.client.CreatePlan(ctx, req)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(resp)
+	}
+	c.printPlan(&resp.LearningPath)
+	if resp.Quiz != nil {
+		fmt.Fprintf(c.out, "\nQuiz %s: %d questions\n", resp.Quiz.QuizID, resp.Quiz.TotalQuestions)
+	}
+	return nil
+}
+
+func (c *cli) planGet(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("plan get: expected a plan ID")
+	}
+	plan, err := c.client.GetPlan(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(plan)
+	}
+	c.printPlan(plan)
+	return nil
+}
+
+func (c *cli) planList(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("plan list: expected a user ID")
+	}
+	resp, err := c.client.ListUserPlans(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(resp)
+	}
+	for _, p := range resp.Plans {
+		fmt.Fprintf(c.out, "%s  %-40s %6.1fh  %s\n", p.PlanID, p.Goal, p.TotalHours, p.CreatedAt.Format("2006-01-02"))
+	}
+	fmt.Fprintf(c.out, "%d plans\n", resp.Total)
+	return nil
+}
+
+func (c *cli) printPlan(plan *sdk.Plan) {
+	fmt.Fprintf(c.out, "Plan %s: %s\n%.1f hours over %d weeks\n", plan.PlanID, plan.Goal, plan.TotalHours, plan.EstimatedWeeks)
+	for _, m := range plan.Milestones {
+		fmt.Fprintf(c.out, "\n%d. %s (%.1fh)\n", m.Order, m.Title, m.EstimatedHours)
+		for _, r := range m.Resources {
+			fmt.Fprintf(c.out, "   - %s [%d min]\n     %s\n", r.Title, r.DurationMin, r.URL)
+		}
+	}
+}
+
+func (c *cli) quizTake(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("quiz take", flag.ExitOnError)
+	num := fs.Int("n", 5, "number of questions")
+	difficulty := fs.String("difficulty", "medium", "easy, medium or hard")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("quiz take: at least one resource ID is required")
+	}
+
+	quiz, err := c.client.GenerateQuiz(ctx, sdk.QuizRequest{ResourceIDs: fs.Args(), NumQuestions: *num, Difficulty: *difficulty})
+	if err != nil {
+		return err
+	}
+
+	submission := sdk.QuizSubmission{QuizID: quiz.QuizID}
+	for i, q := range quiz.Questions {
+		fmt.Fprintf(c.out, "\n%d/%d. %s\n", i+1, len(quiz.Questions), q.QuestionText)
+		for _, o := range q.Options {
+			fmt.Fprintf(c.out, "   %s) %s\n", o.OptionID, o.Text)
+		}
+		answer, err := c.ask(q)
+		if err != nil {
+			return err
+		}
+		submission.Answers = append(submission.Answers, sdk.Answer{QuestionID: q.QuestionID, SelectedOptionID: answer})
+	}
+
+	result, err := c.client.SubmitQuiz(ctx, submission)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(result)
+	}
+	fmt.Fprintf(c.out, "\nScore: %.0f%% (%d/%d)\n", result.Score, result.CorrectAnswers, result.TotalQuestions)
+	for _, r := range result.Results {
+		if r.Correct {
+			continue
+		}
+		fmt.Fprintf(c.out, "  %s: answered %q, correct %q. %s\n", r.QuestionID, r.SelectedOptionID, r.CorrectOptionID, r.Explanation)
+	}
+	return nil
+}
+
+// ask reads an option ID for q, prompting until the answer is valid
+func (c *cli) ask(q sdk.Question) (string, error) {
+	for {
+		fmt.Fprint(c.out, "> ")
+		line, err := c.in.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		for _, o := range q.Options {
+			if strings.EqualFold(o.OptionID, answer) {
+				return o.OptionID, nil
+			}
+		}
+		if err != nil {
+			return "", fmt.Errorf("quiz take: no answer for %s: %w", q.QuestionID, err)
+		}
+		fmt.Fprintln(c.out, "Enter one of the option IDs above")
+	}
+}
+
+func (c *cli) ingest(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("ingest: expected a file of URLs")
+	}
+	var r io.Reader = c.in
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var urls []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			urls = append(urls, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(urls) == 0 {
+		return errors.New("ingest: no URLs found")
+	}
+
+	resp, err := c.client.Ingest(ctx, urls)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(resp)
+	}
+	fmt.Fprintf(c.out, "%s (%d URLs)\n", resp.Message, resp.Count)
+	return nil
+}
+
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
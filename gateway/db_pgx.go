@@ -0,0 +1,6 @@
+//go:build pgx
+
+package main
+
+// Registers the pgx driver used by the Postgres datastore
+import _ "github.com/jackc/pgx/v5/stdlib"
@@ -0,0 +1,240 @@
+package abuse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+)
+
+// Options configures a Detector
+type Options struct {
+	Threshold int           // Failed requests per window that earn a ban; 0 disables detection
+	Window    time.Duration // Length of each counting window
+	BaseBan   time.Duration // First ban; each repeat offense doubles it
+	MaxBan    time.Duration
+	// OffenseTTL is how long past offenses count towards longer bans
+	OffenseTTL time.Duration
+}
+
+// Ban is a caller's temporary ban from the expensive endpoints
+type Ban struct {
+	Key      string    `json:"key"` // "user:<id>" or "ip:<address>"
+	Until    time.Time `json:"until"`
+	Offenses int       `json:"offenses"`
+	Failures int       `json:"failures"` // Failed requests in the window that earned it
+}
+
+// indexKey lists the keys of bans, so they can be listed without scanning
+// the store
+const indexKey = "abuse:bans"
+
+// Detector counts each caller's failed (4xx) requests and bans callers
+// who fail too often within a window, for exponentially longer on each
+// repeat offense. State lives in the shared store, so bans hold across
+// replicas.
+type Detector struct {
+	store storage.KeyValue
+
+	mu   sync.RWMutex
+	opts Options
+
+	indexMu sync.Mutex // Serialises this replica's index updates
+}
+
+// New creates a detector
+func New(store storage.KeyValue, opts Options) *Detector {
+	d := &Detector{store: store}
+	d.Configure(opts)
+	return d
+}
+
+// Configure updates the thresholds. Bans already issued keep their length.
+func (d *Detector) Configure(opts Options) {
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+	if opts.BaseBan <= 0 {
+		opts.BaseBan = time.Minute
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.opts = opts
+}
+
+func (d *Detector) options() Options {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.opts
+}
+
+// Enabled reports whether failures are counted at all
+func (d *Detector) Enabled() bool {
+	return d.options().Threshold > 0
+}
+
+// Record counts a failed request by key, banning the caller once the
+// window's count reaches the threshold. It returns the new ban, if any.
+func (d *Detector) Record(ctx context.Context, key string) (*Ban, error) {
+	opts := d.options()
+	if opts.Threshold <= 0 {
+		return nil, nil
+	}
+	start := time.Now().Truncate(opts.Window)
+	count, err := d.store.Incr(ctx, "abuse:failures:"+key+":"+strconv.FormatInt(start.Unix(), 10), opts.Window)
+	if err != nil {
+		return nil, err
+	}
+	// Only the request that crosses the threshold bans
+	if count != int64(opts.Threshold) {
+		return nil, nil
+	}
+
+	offenses, err := d.store.Incr(ctx, "abuse:offenses:"+key, opts.OffenseTTL)
+	if err != nil {
+		return nil, err
+	}
+	length := banLength(opts, int(offenses))
+	ban := &Ban{Key: key, Until: time.Now().Add(length).UTC(), Offenses: int(offenses), Failures: int(count)}
+	data, err := json.Marshal(ban)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.store.Set(ctx, banKey(key), data, length); err != nil {
+		return nil, err
+	}
+	if err := d.updateIndex(ctx, func(keys map[string]bool) { keys[key] = true }); err != nil {
+		log.Printf("abuse: failed to index ban of %s: %v", key, err)
+	}
+	return ban, nil
+}
+
+// banLength doubles the base ban for each earlier offense, up to MaxBan
+func banLength(opts Options, offenses int) time.Duration {
+	length := opts.BaseBan
+	for i := 1; i < offenses && (opts.MaxBan <= 0 || length < opts.MaxBan); i++ {
+		length *= 2
+	}
+	if opts.MaxBan > 0 && length > opts.MaxBan {
+		length = opts.MaxBan
+	}
+	return length
+}
+
+// Banned returns the ban on key, or nil if there is none
+func (d *Detector) Banned(ctx context.Context, key string) (*Ban, error) {
+	data, ok, err := d.store.Get(ctx, banKey(key))
+	if err != nil || !ok {
+		return nil, err
+	}
+	var ban Ban
+	if err := json.Unmarshal(data, &ban); err != nil {
+		return nil, fmt.Errorf("abuse: corrupt ban of %s: %w", key, err)
+	}
+	if !ban.Until.After(time.Now()) {
+		return nil, nil
+	}
+	return &ban, nil
+}
+
+// List returns the bans in force, soonest to end first
+func (d *Detector) List(ctx context.Context) ([]Ban, error) {
+	keys, err := d.index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bans := []Ban{}
+	var expired []string
+	for key := range keys {
+		ban, err := d.Banned(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if ban == nil {
+			expired = append(expired, key)
+			continue
+		}
+		bans = append(bans, *ban)
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Until.Before(bans[j].Until) })
+
+	if len(expired) > 0 {
+		err := d.updateIndex(ctx, func(keys map[string]bool) {
+			for _, key := range expired {
+				delete(keys, key)
+			}
+		})
+		if err != nil {
+			log.Printf("abuse: failed to prune ban index: %v", err)
+		}
+	}
+	return bans, nil
+}
+
+// Lift ends the ban on key and forgets its past offenses. It reports
+// whether there was a ban.
+func (d *Detector) Lift(ctx context.Context, key string) (bool, error) {
+	ban, err := d.Banned(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if err := d.store.Delete(ctx, banKey(key)); err != nil {
+		return false, err
+	}
+	if err := d.store.Delete(ctx, "abuse:offenses:"+key); err != nil {
+		return false, err
+	}
+	if err := d.updateIndex(ctx, func(keys map[string]bool) { delete(keys, key) }); err != nil {
+		log.Printf("abuse: failed to unindex ban of %s: %v", key, err)
+	}
+	return ban != nil, nil
+}
+
+func banKey(key string) string {
+	return "abuse:ban:" + key
+}
+
+func (d *Detector) index(ctx context.Context) (map[string]bool, error) {
+	keys := map[string]bool{}
+	data, ok, err := d.store.Get(ctx, indexKey)
+	if err != nil || !ok {
+		return keys, err
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return keys, nil // Rebuilt by the next ban
+	}
+	for _, key := range list {
+		keys[key] = true
+	}
+	return keys, nil
+}
+
+// updateIndex rewrites the index, which List prunes of ended bans.
+// Replicas updating it at the same moment may lose an entry; the ban
+// itself still holds, it just isn't listed.
+func (d *Detector) updateIndex(ctx context.Context, update func(keys map[string]bool)) error {
+	d.indexMu.Lock()
+	defer d.indexMu.Unlock()
+	keys, err := d.index(ctx)
+	if err != nil {
+		return err
+	}
+	update(keys)
+	list := make([]string, 0, len(keys))
+	for key := range keys {
+		list = append(list, key)
+	}
+	sort.Strings(list)
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	return d.store.Set(ctx, indexKey, data, 0)
+}
@@ -0,0 +1,183 @@
+// Package accesslog ships one entry per request to pluggable sinks: stdout,
+// a file, Loki or any HTTP endpoint taking JSON.
+package accesslog
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+)
+
+// Entry is one served request
+type Entry struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Route     string    `json:"route,omitempty"` // Matched pattern, e.g. /api/plan/:id
+	Status    int       `json:"status"`
+	LatencyMS float64   `json:"latency_ms"`
+	Bytes     int       `json:"bytes"`
+	ClientIP  string    `json:"client_ip"`
+	UserID    string    `json:"user_id,omitempty"`
+	TenantID  string    `json:"tenant_id,omitempty"`
+}
+
+// Text formats the entry the way the gateway has always logged requests
+func (e Entry) Text() string {
+	latency := time.Duration(e.LatencyMS * float64(time.Millisecond))
+	return fmt.Sprintf("%s [%s] %s %s %d %v", e.Time.Format("2006/01/02 15:04:05"), e.RequestID, e.Method, e.Path, e.Status, latency)
+}
+
+// Sink receives batches of entries
+type Sink interface {
+	Write(ctx context.Context, entries []Entry) error
+}
+
+// Logger queues entries and writes them to every sink in batches of
+// BatchSize, or every FlushInterval. When the queue is full new entries are
+// dropped rather than slowing requests down.
+type Logger struct {
+	cfg     config.AccessLogConfig
+	sinks   []Sink
+	queue   chan Entry
+	dropped atomic.Int64
+
+	mu     sync.Mutex
+	random *rand.Rand
+}
+
+// New creates a logger for the configured sinks
+func New(cfg config.AccessLogConfig) (*Logger, error) {
+	l := &Logger{
+		cfg:    cfg,
+		queue:  make(chan Entry, max(cfg.BufferSize, 1)),
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, name := range cfg.Sinks {
+		sink, err := newSink(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		l.sinks = append(l.sinks, sink)
+	}
+	return l, nil
+}
+
+func newSink(cfg config.AccessLogConfig, name string) (Sink, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "stdout":
+		return Stdout(cfg.Format), nil
+	case "file":
+		if cfg.File == "" {
+			return nil, fmt.Errorf("accesslog: the file sink needs ACCESS_LOG_FILE")
+		}
+		return NewFile(cfg.File, cfg.Format)
+	case "loki":
+		if cfg.LokiURL == "" {
+			return nil, fmt.Errorf("accesslog: the loki sink needs ACCESS_LOG_LOKI_URL")
+		}
+		return NewLoki(cfg.LokiURL, cfg.Timeout), nil
+	case "http":
+		if cfg.HTTPURL == "" {
+			return nil, fmt.Errorf("accesslog: the http sink needs ACCESS_LOG_HTTP_URL")
+		}
+		return NewHTTP(cfg.HTTPURL, cfg.Timeout), nil
+	}
+	return nil, fmt.Errorf("accesslog: unknown sink %q", name)
+}
+
+// Log queues an entry, unless it is sampled out. Server errors are always
+// kept; other entries are kept at the rate of the longest Sampling prefix
+// matching their path.
+func (l *Logger) Log(entry Entry) {
+	if l == nil || len(l.sinks) == 0 || !l.sampled(entry) {
+		return
+	}
+	select {
+	case l.queue <- entry:
+	default:
+		l.dropped.Add(1)
+	}
+}
+
+func (l *Logger) sampled(entry Entry) bool {
+	if entry.Status >= 500 {
+		return true
+	}
+	rate, longest := 1.0, -1
+	for prefix, r := range l.cfg.Sampling {
+		if strings.HasPrefix(entry.Path, prefix) && len(prefix) > longest {
+			rate, longest = r, len(prefix)
+		}
+	}
+	if rate >= 1 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.random.Float64() < rate
+}
+
+// Run writes queued entries until ctx is done, then flushes what is left
+func (l *Logger) Run(ctx context.Context) {
+	if l == nil || len(l.sinks) == 0 {
+		return
+	}
+	ticker := time.NewTicker(max(l.cfg.FlushInterval, 10*time.Millisecond))
+	defer ticker.Stop()
+
+	batchSize := max(l.cfg.BatchSize, 1)
+	batch := make([]Entry, 0, batchSize)
+	flush := func() {
+		if n := l.dropped.Swap(0); n > 0 {
+			log.Printf("accesslog: queue full, dropped %d entries", n)
+		}
+		if len(batch) == 0 {
+			return
+		}
+		l.write(batch)
+		batch = make([]Entry, 0, batchSize)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case entry := <-l.queue:
+					batch = append(batch, entry)
+				default:
+					flush()
+					return
+				}
+			}
+		case entry := <-l.queue:
+			batch = append(batch, entry)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// write hands a batch to every sink; a failing sink doesn't hold up the
+// others and its entries are lost
+func (l *Logger) write(batch []Entry) {
+	// Sinks may be slow; they get their own deadline, not the caller's
+	ctx := context.Background()
+	for _, sink := range l.sinks {
+		if err := sink.Write(ctx, batch); err != nil {
+			log.Printf("accesslog: %T failed, dropped %d entries: %v", sink, len(batch), err)
+		}
+	}
+}
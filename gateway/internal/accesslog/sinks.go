@@ -0,0 +1,145 @@
+package accesslog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// writerSink writes entries as lines of text or JSON
+type writerSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format string
+}
+
+// Stdout writes entries to standard output as text or JSON lines
+func Stdout(format string) Sink {
+	return &writerSink{w: os.Stdout, format: format}
+}
+
+// NewFile appends entries to path as text or JSON lines
+func NewFile(path, format string) (Sink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("accesslog: open %s: %w", path, err)
+	}
+	return &writerSink{w: f, format: format}, nil
+}
+
+func (s *writerSink) Write(_ context.Context, entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := bufio.NewWriter(s.w)
+	for _, entry := range entries {
+		if err := writeLine(w, entry, s.format); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func writeLine(w io.Writer, entry Entry, format string) error {
+	if format == "json" {
+		return json.NewEncoder(w).Encode(entry)
+	}
+	_, err := io.WriteString(w, entry.Text()+"\n")
+	return err
+}
+
+// HTTP posts each batch as a JSON array of entries
+type HTTP struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTP creates a sink posting to url
+func NewHTTP(url string, timeout time.Duration) *HTTP {
+	return &HTTP{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (h *HTTP) Write(ctx context.Context, entries []Entry) error {
+	payload, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return post(ctx, h.client, h.url, payload)
+}
+
+// Loki pushes entries to Grafana Loki as JSON lines, in one stream per
+// status class so the labels stay few
+type Loki struct {
+	url    string
+	client *http.Client
+}
+
+// NewLoki creates a sink pushing to the Loki at baseURL
+func NewLoki(baseURL string, timeout time.Duration) *Loki {
+	return &Loki{
+		url:    strings.TrimRight(baseURL, "/") + "/loki/api/v1/push",
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+func (l *Loki) Write(ctx context.Context, entries []Entry) error {
+	streams := map[string]*lokiStream{}
+	var order []string
+	for _, entry := range entries {
+		class := strconv.Itoa(entry.Status/100) + "xx"
+		stream, ok := streams[class]
+		if !ok {
+			stream = &lokiStream{Stream: map[string]string{"app": "learnpath-gateway", "status": class}}
+			streams[class] = stream
+			order = append(order, class)
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(entry.Time.UnixNano(), 10), string(line)})
+	}
+
+	push := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, class := range order {
+		push.Streams = append(push.Streams, streams[class])
+	}
+	payload, err := json.Marshal(push)
+	if err != nil {
+		return err
+	}
+	return post(ctx, l.client, l.url, payload)
+}
+
+func post(ctx context.Context, client *http.Client, url string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d from %s", resp.StatusCode, url)
+	}
+	return nil
+}
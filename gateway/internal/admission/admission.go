@@ -0,0 +1,249 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Class is a request priority; lower values are admitted first.
+type Class int
+
+// Priority classes, highest priority first
+const (
+	Interactive Class = iota // Search and reads a user is waiting on
+	Planning                 // Plan creation and replanning
+	Background               // Content ingestion
+	numClasses
+)
+
+func (c Class) String() string {
+	switch c {
+	case Interactive:
+		return "interactive"
+	case Planning:
+		return "planning"
+	case Background:
+		return "background"
+	}
+	return "unknown"
+}
+
+// Errors returned by Acquire when a request is shed
+var (
+	ErrQueueFull    = errors.New("admission queue full")
+	ErrQueueTimeout = errors.New("timed out waiting for admission")
+	ErrSaturated    = errors.New("backends saturated")
+)
+
+// Options configures a Controller
+type Options struct {
+	MaxInFlight  int           // Requests served at once; 0 disables admission control
+	MaxQueue     int           // Requests waiting across all classes
+	QueueTimeout time.Duration // Longest a request waits for a slot
+	// While backends report saturation, classes below Interactive are shed
+	// immediately for this long
+	SaturationCooloff time.Duration
+	RetryAfter        time.Duration // Hint sent to shed clients
+}
+
+// Controller admits requests up to a concurrency limit and queues the rest by
+// priority. When the queue is full a new request displaces a queued request
+// of lower priority, or is shed.
+type Controller struct {
+	mu             sync.Mutex
+	opts           Options
+	inFlight       int
+	queues         [numClasses][]*waiter
+	saturatedUntil time.Time
+	shed           [numClasses]int64
+}
+
+type waiter struct {
+	ready chan error // Receives nil when admitted, or the reason it was shed
+}
+
+// New creates a controller
+func New(opts Options) *Controller {
+	return &Controller{opts: opts}
+}
+
+// Configure updates the limits. Requests already admitted are unaffected.
+func (c *Controller) Configure(opts Options) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.opts = opts
+	c.dispatch()
+}
+
+// Acquire waits for a slot for a request of the given class. On success the
+// returned function must be called once the request completes.
+func (c *Controller) Acquire(ctx context.Context, class Class) (func(), error) {
+	c.mu.Lock()
+
+	if c.opts.MaxInFlight <= 0 {
+		c.mu.Unlock()
+		return func() {}, nil
+	}
+
+	if class > Interactive && time.Now().Before(c.saturatedUntil) {
+		c.shed[class]++
+		c.mu.Unlock()
+		return nil, ErrSaturated
+	}
+
+	if c.inFlight < c.opts.MaxInFlight && c.queued() == 0 {
+		c.inFlight++
+		c.mu.Unlock()
+		return c.release, nil
+	}
+
+	if c.queued() >= c.opts.MaxQueue && !c.evictBelow(class) {
+		c.shed[class]++
+		c.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+
+	w := &waiter{ready: make(chan error, 1)}
+	c.queues[class] = append(c.queues[class], w)
+	timeout := c.opts.QueueTimeout
+	c.mu.Unlock()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case err := <-w.ready:
+		if err != nil {
+			return nil, err
+		}
+		return c.release, nil
+	case <-expired:
+		return c.abandon(class, w, ErrQueueTimeout)
+	case <-ctx.Done():
+		return c.abandon(class, w, ctx.Err())
+	}
+}
+
+// abandon removes a waiter that gave up. If it was admitted in the meantime
+// the slot is handed on.
+func (c *Controller) abandon(class Class, w *waiter, reason error) (func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, queued := range c.queues[class] {
+		if queued == w {
+			c.queues[class] = append(c.queues[class][:i], c.queues[class][i+1:]...)
+			c.shed[class]++
+			return nil, reason
+		}
+	}
+
+	// Already dequeued: either admitted or displaced
+	if err := <-w.ready; err != nil {
+		return nil, err
+	}
+	c.inFlight--
+	c.dispatch()
+	return nil, reason
+}
+
+func (c *Controller) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight--
+	c.dispatch()
+}
+
+// dispatch admits queued requests, highest priority first, while slots are
+// free. Must be called with mu held.
+func (c *Controller) dispatch() {
+	for class := range c.queues {
+		for len(c.queues[class]) > 0 && (c.opts.MaxInFlight <= 0 || c.inFlight < c.opts.MaxInFlight) {
+			w := c.queues[class][0]
+			c.queues[class] = c.queues[class][1:]
+			c.inFlight++
+			w.ready <- nil
+		}
+	}
+}
+
+// evictBelow sheds the newest queued request with a lower priority than
+// class, reporting whether one was found. Must be called with mu held.
+func (c *Controller) evictBelow(class Class) bool {
+	for lower := numClasses - 1; lower > class; lower-- {
+		queue := c.queues[lower]
+		if len(queue) == 0 {
+			continue
+		}
+		w := queue[len(queue)-1]
+		c.queues[lower] = queue[:len(queue)-1]
+		c.shed[lower]++
+		w.ready <- ErrQueueFull
+		return true
+	}
+	return false
+}
+
+func (c *Controller) queued() int {
+	n := 0
+	for _, queue := range c.queues {
+		n += len(queue)
+	}
+	return n
+}
+
+// RetryAfter returns how long shed clients should wait before retrying
+func (c *Controller) RetryAfter() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.opts.RetryAfter
+}
+
+// Limit returns the number of requests admitted at once
+func (c *Controller) Limit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.opts.MaxInFlight
+}
+
+// ReportSaturation records that a backend rejected or timed out a request, so
+// lower-priority work is shed for the cool-off period.
+func (c *Controller) ReportSaturation() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.saturatedUntil = time.Now().Add(c.opts.SaturationCooloff)
+}
+
+// Snapshot describes the controller's state for metrics
+type Snapshot struct {
+	InFlight    int              `json:"in_flight"`
+	MaxInFlight int              `json:"max_in_flight"`
+	QueueDepth  map[string]int   `json:"queue_depth"`
+	Shed        map[string]int64 `json:"shed"`
+	Saturated   bool             `json:"backends_saturated"`
+}
+
+// Snapshot returns the current queue depths and shed counters
+func (c *Controller) Snapshot() Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := Snapshot{
+		InFlight:    c.inFlight,
+		MaxInFlight: c.opts.MaxInFlight,
+		QueueDepth:  make(map[string]int, numClasses),
+		Shed:        make(map[string]int64, numClasses),
+		Saturated:   time.Now().Before(c.saturatedUntil),
+	}
+	for class := Class(0); class < numClasses; class++ {
+		s.QueueDepth[class.String()] = len(c.queues[class])
+		s.Shed[class.String()] = c.shed[class]
+	}
+	return s
+}
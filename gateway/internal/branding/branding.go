@@ -0,0 +1,93 @@
+// Package branding keeps each tenant's logo, colors and sender name, used
+// by plan exports and by the notifications and digests sent to learners.
+// Tenants without their own branding, and fields a tenant leaves empty,
+// fall back to the configured defaults.
+package branding
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+)
+
+// Branding is how exports and notifications look for a tenant
+type Branding struct {
+	LogoURL      string `json:"logo_url,omitempty" binding:"omitempty,url,max=2048"`
+	PrimaryColor string `json:"primary_color,omitempty" binding:"omitempty,hexcolor"`
+	AccentColor  string `json:"accent_color,omitempty" binding:"omitempty,hexcolor"`
+	SenderName   string `json:"sender_name,omitempty" binding:"omitempty,max=100"`
+}
+
+// over returns b with the non-empty fields of overrides
+func (b Branding) over(overrides Branding) Branding {
+	if overrides.LogoURL != "" {
+		b.LogoURL = overrides.LogoURL
+	}
+	if overrides.PrimaryColor != "" {
+		b.PrimaryColor = overrides.PrimaryColor
+	}
+	if overrides.AccentColor != "" {
+		b.AccentColor = overrides.AccentColor
+	}
+	if overrides.SenderName != "" {
+		b.SenderName = overrides.SenderName
+	}
+	return b
+}
+
+// Store keeps tenants' branding in the shared store
+type Store struct {
+	defaults Branding
+	store    storage.KeyValue
+}
+
+// New creates a store falling back to cfg
+func New(cfg config.BrandingConfig, store storage.KeyValue) *Store {
+	return &Store{defaults: Branding(cfg), store: store}
+}
+
+// Get returns a tenant's branding, over the defaults. An empty tenant gets
+// the defaults.
+func (s *Store) Get(ctx context.Context, tenantID string) (Branding, error) {
+	overrides, _, err := s.Overrides(ctx, tenantID)
+	if err != nil {
+		return s.defaults, err
+	}
+	return s.defaults.over(overrides), nil
+}
+
+// Overrides returns what a tenant set, and whether it set anything
+func (s *Store) Overrides(ctx context.Context, tenantID string) (Branding, bool, error) {
+	if tenantID == "" {
+		return Branding{}, false, nil
+	}
+	data, ok, err := s.store.Get(ctx, key(tenantID))
+	if err != nil || !ok {
+		return Branding{}, false, err
+	}
+	var b Branding
+	if err := json.Unmarshal(data, &b); err != nil {
+		return Branding{}, false, err
+	}
+	return b, true, nil
+}
+
+// Set replaces a tenant's branding
+func (s *Store) Set(ctx context.Context, tenantID string, b Branding) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return s.store.Set(ctx, key(tenantID), data, 0)
+}
+
+// Delete returns a tenant to the defaults
+func (s *Store) Delete(ctx context.Context, tenantID string) error {
+	return s.store.Delete(ctx, key(tenantID))
+}
+
+func key(tenantID string) string {
+	return "branding:" + tenantID
+}
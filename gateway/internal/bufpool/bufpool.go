@@ -0,0 +1,113 @@
+// Package bufpool reuses the byte buffers request bodies are encoded into
+// and responses are read into, so busy proxy paths don't allocate and grow
+// a fresh buffer per request.
+package bufpool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooled keeps buffers grown by an unusually large body from being held
+// on to by the pool
+const maxPooled = 1 << 20
+
+var pool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// Get returns an empty buffer from the pool
+func Get() *bytes.Buffer {
+	return pool.Get().(*bytes.Buffer)
+}
+
+// Put returns buf to the pool. It must not be used afterwards.
+func Put(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > maxPooled {
+		return
+	}
+	buf.Reset()
+	pool.Put(buf)
+}
+
+// ReadAll reads r into a buffer from the pool, which the caller puts back
+func ReadAll(r io.Reader) (*bytes.Buffer, error) {
+	buf := Get()
+	if _, err := buf.ReadFrom(r); err != nil {
+		Put(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+// Body is a request body held in a pooled buffer. The transport may still
+// be reading a body after the response arrives, so the buffer goes back to
+// the pool only once the owner has released it and every request reading
+// it has closed its body.
+type Body struct {
+	buf  *bytes.Buffer
+	refs atomic.Int32
+}
+
+// JSON encodes v as a request body, as json.Marshal would
+func JSON(v any) (*Body, error) {
+	buf := Get()
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		Put(buf)
+		return nil, err
+	}
+	buf.Truncate(buf.Len() - 1) // Encode's newline
+	b := &Body{buf: buf}
+	b.refs.Store(1)
+	return b, nil
+}
+
+// Bytes returns the encoded body, valid until the body is released
+func (b *Body) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// NewRequest creates a request sending b. Its GetBody lets retries and
+// redirects send b again.
+func (b *Body) NewRequest(ctx context.Context, method, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(b.buf.Len())
+	req.Body = b.reader()
+	req.GetBody = func() (io.ReadCloser, error) {
+		return b.reader(), nil
+	}
+	return req, nil
+}
+
+// Release gives up the owner's hold on b, typically deferred right after
+// JSON
+func (b *Body) Release() {
+	if b.refs.Add(-1) == 0 {
+		Put(b.buf)
+	}
+}
+
+func (b *Body) reader() io.ReadCloser {
+	b.refs.Add(1)
+	return &bodyReader{Reader: bytes.NewReader(b.buf.Bytes()), body: b}
+}
+
+// bodyReader reads a Body, releasing its hold when closed
+type bodyReader struct {
+	*bytes.Reader
+	body   *Body
+	closed atomic.Bool
+}
+
+func (r *bodyReader) Close() error {
+	if r.closed.CompareAndSwap(false, true) {
+		r.body.Release()
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package bufpool_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/amirhf/learnpath-gateway/internal/bufpool"
+)
+
+// searchRequest is shaped like the search requests the gateway forwards
+type searchRequest struct {
+	Query    string   `json:"query"`
+	TopK     int      `json:"top_k"`
+	Levels   []string `json:"levels"`
+	Language string   `json:"language"`
+	TenantID string   `json:"tenant_id"`
+}
+
+var request = searchRequest{
+	Query:    strings.Repeat("distributed systems consensus ", 8),
+	TopK:     10,
+	Levels:   []string{"beginner", "intermediate"},
+	Language: "en",
+	TenantID: "tenant-1",
+}
+
+// response is a search response of ten results, about 4KB
+var response = func() []byte {
+	type result struct {
+		ID      string  `json:"id"`
+		Title   string  `json:"title"`
+		URL     string  `json:"url"`
+		Snippet string  `json:"snippet"`
+		Score   float64 `json:"score"`
+	}
+	results := make([]result, 10)
+	for i := range results {
+		results[i] = result{
+			ID:      "resource-" + strings.Repeat("x", 24),
+			Title:   "Consensus in distributed systems",
+			URL:     "https://example.com/resources/consensus",
+			Snippet: strings.Repeat("Raft and Paxos keep replicas in agreement. ", 6),
+			Score:   0.87,
+		}
+	}
+	data, err := json.Marshal(map[string]any{"results": results, "total": len(results)})
+	if err != nil {
+		panic(err)
+	}
+	return data
+}()
+
+// upstream answers every request with response after draining its body,
+// standing in for the network so only the gateway's side is measured
+type upstream struct{}
+
+func (upstream) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(response)),
+		ContentLength: int64(len(response)),
+		Request:       req,
+	}, nil
+}
+
+var client = &http.Client{Transport: upstream{}}
+
+// BenchmarkProxy measures a proxied call as the search and plan handlers
+// make it: the body is encoded, sent, and the response read in full to be
+// written back to the caller. "unpooled" is the path before pooling.
+func BenchmarkProxy(b *testing.B) {
+	ctx := context.Background()
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			body, err := bufpool.JSON(request)
+			if err != nil {
+				b.Fatal(err)
+			}
+			req, err := body.NewRequest(ctx, http.MethodPost, "http://rag/search")
+			if err != nil {
+				b.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				b.Fatal(err)
+			}
+			buf, err := bufpool.ReadAll(resp.Body)
+			if err != nil {
+				b.Fatal(err)
+			}
+			resp.Body.Close()
+			io.Discard.Write(buf.Bytes())
+			bufpool.Put(buf)
+			body.Release()
+		}
+	})
+
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			data, err := json.Marshal(request)
+			if err != nil {
+				b.Fatal(err)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://rag/search", bytes.NewBuffer(data))
+			if err != nil {
+				b.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				b.Fatal(err)
+			}
+			out, err := io.ReadAll(resp.Body)
+			if err != nil {
+				b.Fatal(err)
+			}
+			resp.Body.Close()
+			io.Discard.Write(out)
+		}
+	})
+}
+
+// BenchmarkClient measures a call as the service clients make it: the body
+// is encoded and sent, and the response decoded straight from the
+// connection. Only the request body is pooled here. "unpooled" is the
+// path before pooling.
+func BenchmarkClient(b *testing.B) {
+	ctx := context.Background()
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			body, err := bufpool.JSON(request)
+			if err != nil {
+				b.Fatal(err)
+			}
+			req, err := body.NewRequest(ctx, http.MethodPost, "http://rag/search")
+			if err != nil {
+				b.Fatal(err)
+			}
+			decode(b, req)
+			body.Release()
+		}
+	})
+
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			data, err := json.Marshal(request)
+			if err != nil {
+				b.Fatal(err)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://rag/search", bytes.NewBuffer(data))
+			if err != nil {
+				b.Fatal(err)
+			}
+			decode(b, req)
+		}
+	})
+}
+
+// decode sends req and decodes its response as the clients do
+func decode(b *testing.B, req *http.Request) {
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Results []map[string]any `json:"results"`
+		Total   int              `json:"total"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		b.Fatal(err)
+	}
+}
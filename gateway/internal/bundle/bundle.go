@@ -0,0 +1,339 @@
+// Package bundle packages a plan for offline study and for moving it
+// between deployments: a zip of the plan, its milestone quizzes, the
+// learner's notes and a manifest checksumming them. Quizzes are stored
+// without their answers; each quiz's answers are sealed separately with
+// AES-256-GCM under a key made for the bundle, which is handed to the
+// learner apart from it, so an offline app can grade answers only once
+// they're given.
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/google/uuid"
+)
+
+// Format and Version identify bundles in their manifest. Bundles of a
+// later version are refused.
+const (
+	Format  = "learnpath-bundle"
+	Version = 1
+)
+
+// AnswerCipher is how answer files are sealed: the file is a 12-byte
+// nonce followed by the ciphertext, with the file's name as additional
+// data
+const AnswerCipher = "AES-256-GCM"
+
+// KeySize is the length of the answer key in bytes
+const KeySize = 32
+
+// maxFileBytes bounds each file read from a bundle once uncompressed
+const maxFileBytes = 16 << 20
+
+// InvalidError is returned for archives that aren't a readable bundle
+type InvalidError struct {
+	Reason string
+}
+
+func (e *InvalidError) Error() string {
+	return "invalid bundle: " + e.Reason
+}
+
+// invalid returns an InvalidError for the formatted reason
+func invalid(format string, args ...any) error {
+	return &InvalidError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Manifest describes a bundle. It lists every other file with its
+// SHA-256, checked when the bundle is imported.
+type Manifest struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	PlanID     uuid.UUID `json:"plan_id"`
+	Goal       string    `json:"goal"`
+	Files      []File    `json:"files"`
+	// Answers tells how answer files are sealed; absent without quizzes
+	Answers *Encryption `json:"answers,omitempty"`
+}
+
+// File is a file of a bundle
+type File struct {
+	Name   string `json:"name"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Encryption describes the sealed answer files
+type Encryption struct {
+	Algorithm string `json:"algorithm"`
+	KeyBits   int    `json:"key_bits"`
+}
+
+// Note is a learner's note on the plan or one of its milestones
+type Note struct {
+	MilestoneID string    `json:"milestone_id,omitempty"`
+	Body        string    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Quiz is a milestone's quiz without its answers
+type Quiz struct {
+	MilestoneID uuid.UUID          `json:"milestone_id"`
+	Quiz        *models.PublicQuiz `json:"quiz"`
+	Answers     string             `json:"answers"` // Name of the sealed answer file
+}
+
+// Answers is the answer key of a quiz, as sealed in its answer file
+type Answers struct {
+	QuizID    string           `json:"quiz_id"`
+	Questions []QuestionAnswer `json:"questions"`
+}
+
+// QuestionAnswer is the answer to a question. Short-answer questions have
+// no correct options; their explanation is the model answer.
+type QuestionAnswer struct {
+	QuestionID       string   `json:"question_id"`
+	CorrectOptionIDs []string `json:"correct_option_ids"`
+	Explanation      string   `json:"explanation,omitempty"`
+}
+
+// Bundle is what goes into a bundle
+type Bundle struct {
+	Plan    *models.LearningPath
+	Quizzes map[uuid.UUID]*models.Quiz // By milestone ID
+	Notes   []Note
+}
+
+// Contents is what Read finds in a bundle. Quizzes aren't read back: their
+// answers are sealed, and an imported plan gets quizzes of its own.
+type Contents struct {
+	Manifest Manifest
+	Plan     models.LearningPath
+	Notes    []Note
+}
+
+// Write writes b as a zip archive to w and returns the key its answers
+// are sealed with, nil when it has no quizzes
+func Write(w io.Writer, b Bundle, now time.Time) ([]byte, error) {
+	m := Manifest{
+		Format:     Format,
+		Version:    Version,
+		ExportedAt: now.UTC(),
+		PlanID:     b.Plan.PlanID,
+		Goal:       b.Plan.Goal,
+		Files:      []File{},
+	}
+	var names []string
+	files := map[string][]byte{}
+	add := func(name string, data []byte) {
+		names = append(names, name)
+		files[name] = data
+		sum := sha256.Sum256(data)
+		m.Files = append(m.Files, File{Name: name, Size: len(data), SHA256: hex.EncodeToString(sum[:])})
+	}
+	addJSON := func(name string, v any) error {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("bundle: encoding %s: %w", name, err)
+		}
+		add(name, data)
+		return nil
+	}
+
+	if err := addJSON("plan.json", b.Plan); err != nil {
+		return nil, err
+	}
+	notes := b.Notes
+	if notes == nil {
+		notes = []Note{}
+	}
+	if err := addJSON("notes.json", notes); err != nil {
+		return nil, err
+	}
+
+	var key []byte
+	var aead cipher.AEAD
+	for i, milestone := range b.Plan.Milestones {
+		quiz := b.Quizzes[milestone.MilestoneID]
+		if quiz == nil {
+			continue
+		}
+		if aead == nil {
+			key = make([]byte, KeySize)
+			if _, err := rand.Read(key); err != nil {
+				return nil, fmt.Errorf("bundle: generating answer key: %w", err)
+			}
+			var err error
+			if aead, err = newAEAD(key); err != nil {
+				return nil, err
+			}
+			m.Answers = &Encryption{Algorithm: AnswerCipher, KeyBits: KeySize * 8}
+		}
+
+		answersName := fmt.Sprintf("answers/milestone-%02d.json.enc", i+1)
+		if err := addJSON(fmt.Sprintf("quizzes/milestone-%02d.json", i+1), Quiz{
+			MilestoneID: milestone.MilestoneID,
+			Quiz:        models.NewPublicQuiz(quiz),
+			Answers:     answersName,
+		}); err != nil {
+			return nil, err
+		}
+		plain, err := json.Marshal(answersOf(quiz))
+		if err != nil {
+			return nil, fmt.Errorf("bundle: encoding %s: %w", answersName, err)
+		}
+		sealed, err := seal(aead, plain, answersName)
+		if err != nil {
+			return nil, err
+		}
+		add(answersName, sealed)
+	}
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("bundle: encoding manifest.json: %w", err)
+	}
+	archive := zip.NewWriter(w)
+	write := func(name string, data []byte) error {
+		entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: m.ExportedAt})
+		if err != nil {
+			return err
+		}
+		_, err = entry.Write(data)
+		return err
+	}
+	if err := write("manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if err := write(name, files[name]); err != nil {
+			return nil, fmt.Errorf("bundle: writing %s: %w", name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// answersOf takes the answer key out of a quiz
+func answersOf(quiz *models.Quiz) Answers {
+	answers := Answers{QuizID: quiz.QuizID, Questions: make([]QuestionAnswer, len(quiz.Questions))}
+	for i, question := range quiz.Questions {
+		answer := QuestionAnswer{QuestionID: question.QuestionID, CorrectOptionIDs: []string{}, Explanation: question.Explanation}
+		for _, option := range question.Options {
+			if option.IsCorrect {
+				answer.CorrectOptionIDs = append(answer.CorrectOptionIDs, option.OptionID)
+			}
+		}
+		answers.Questions[i] = answer
+	}
+	return answers
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("bundle: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plain under a fresh nonce, bound to the file's name
+func seal(aead cipher.AEAD, plain []byte, name string) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("bundle: generating nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plain, []byte(name)), nil
+}
+
+// Read reads a bundle's manifest, plan and notes, checking them against
+// the manifest. A malformed bundle returns an *InvalidError.
+func Read(data []byte) (Contents, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return Contents{}, invalid("not a zip archive")
+	}
+	entries := map[string]*zip.File{}
+	for _, f := range archive.File {
+		entries[f.Name] = f
+	}
+	read := func(name string) ([]byte, error) {
+		f, ok := entries[name]
+		if !ok {
+			return nil, invalid("%s is missing", name)
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, invalid("%s: %v", name, err)
+		}
+		defer r.Close()
+		content, err := io.ReadAll(io.LimitReader(r, maxFileBytes+1))
+		if err != nil {
+			return nil, invalid("%s: %v", name, err)
+		}
+		if len(content) > maxFileBytes {
+			return nil, invalid("%s is larger than %d bytes", name, maxFileBytes)
+		}
+		return content, nil
+	}
+
+	var c Contents
+	manifest, err := read("manifest.json")
+	if err != nil {
+		return Contents{}, err
+	}
+	if err := json.Unmarshal(manifest, &c.Manifest); err != nil {
+		return Contents{}, invalid("manifest.json: %v", err)
+	}
+	if c.Manifest.Format != Format {
+		return Contents{}, invalid("not a plan bundle")
+	}
+	if c.Manifest.Version < 1 || c.Manifest.Version > Version {
+		return Contents{}, invalid("unsupported version %d", c.Manifest.Version)
+	}
+
+	sums := map[string]string{}
+	for _, f := range c.Manifest.Files {
+		sums[f.Name] = f.SHA256
+	}
+	readChecked := func(name string, v any) error {
+		want, ok := sums[name]
+		if !ok {
+			return invalid("%s is not in the manifest", name)
+		}
+		content, err := read(name)
+		if err != nil {
+			return err
+		}
+		if sum := sha256.Sum256(content); hex.EncodeToString(sum[:]) != want {
+			return invalid("%s doesn't match its checksum", name)
+		}
+		if err := json.Unmarshal(content, v); err != nil {
+			return invalid("%s: %v", name, err)
+		}
+		return nil
+	}
+	if err := readChecked("plan.json", &c.Plan); err != nil {
+		return Contents{}, err
+	}
+	if err := readChecked("notes.json", &c.Notes); err != nil {
+		return Contents{}, err
+	}
+	return c, nil
+}
@@ -0,0 +1,88 @@
+package calibration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+)
+
+// retention keeps a question's counters for a year after its last answer
+const retention = 365 * 24 * time.Hour
+
+// Question difficulties
+const (
+	Easy   = "easy"
+	Medium = "medium"
+	Hard   = "hard"
+)
+
+// Calibrator counts answers to quiz questions per tenant. Counters are
+// kept in the shared store, so every replica sees the same rates.
+type Calibrator struct {
+	cfg   config.CalibrationConfig
+	store storage.KeyValue
+}
+
+// New creates a calibrator keeping its counters in store
+func New(cfg config.CalibrationConfig, store storage.KeyValue) *Calibrator {
+	return &Calibrator{cfg: cfg, store: store}
+}
+
+// Enabled reports whether answers are counted
+func (c *Calibrator) Enabled() bool {
+	return c != nil && c.cfg.Enabled
+}
+
+// Record counts the graded answers of one quiz submission and returns the
+// updated statistics of its questions
+func (c *Calibrator) Record(ctx context.Context, tenantID, quizID string, results []models.QuestionResult) ([]models.QuestionCalibration, error) {
+	stats := make([]models.QuestionCalibration, 0, len(results))
+	for _, result := range results {
+		key := c.key(tenantID, quizID, result.QuestionID)
+		answered, err := c.store.IncrBy(ctx, key+":answered", 1, retention)
+		if err != nil {
+			return nil, fmt.Errorf("calibrate %s: %w", quizID, err)
+		}
+		var n int64
+		if result.Correct {
+			n = 1
+		}
+		correct, err := c.store.IncrBy(ctx, key+":correct", n, retention)
+		if err != nil {
+			return nil, fmt.Errorf("calibrate %s: %w", quizID, err)
+		}
+
+		stat := models.QuestionCalibration{
+			QuizID:      quizID,
+			QuestionID:  result.QuestionID,
+			ResourceID:  result.SourceResourceID,
+			Answered:    answered,
+			Correct:     correct,
+			CorrectRate: float64(correct) / float64(answered),
+		}
+		stat.Difficulty = c.difficulty(stat)
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// difficulty rates a question once it has enough answers
+func (c *Calibrator) difficulty(stat models.QuestionCalibration) string {
+	switch {
+	case stat.Answered < int64(c.cfg.MinAnswers):
+		return ""
+	case stat.CorrectRate > c.cfg.EasyAbove:
+		return Easy
+	case stat.CorrectRate < c.cfg.HardBelow:
+		return Hard
+	}
+	return Medium
+}
+
+func (c *Calibrator) key(tenantID, quizID, questionID string) string {
+	return "calibration:" + tenantID + ":" + quizID + ":" + questionID
+}
@@ -0,0 +1,278 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxPerRequest caps the exchanges kept for one request ID, e.g. a retried
+// call that keeps failing
+const maxPerRequest = 20
+
+// redacted replaces sensitive header and body values
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are never stored
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Admin-Token":       true,
+	"X-Api-Key":           true,
+	"X-Internal-Token":    true,
+	"X-Guest-Token":       true,
+	"X-Share-Token":       true,
+}
+
+// sensitiveKeys are JSON fields whose values are redacted, matched as
+// substrings of the lower-cased key
+var sensitiveKeys = []string{"password", "token", "secret", "api_key", "apikey", "authorization"}
+
+// Exchange is one captured upstream call
+type Exchange struct {
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	RequestHeaders  http.Header `json:"request_headers"`
+	RequestBody     string      `json:"request_body,omitempty"`
+	Status          int         `json:"status,omitempty"`
+	ResponseHeaders http.Header `json:"response_headers,omitempty"`
+	ResponseBody    string      `json:"response_body,omitempty"`
+	Error           string      `json:"error,omitempty"`
+	StartedAt       time.Time   `json:"started_at"`
+	DurationMs      int64       `json:"duration_ms"`
+}
+
+// Recorder is an http.RoundTripper that records failed upstream calls
+// (transport errors and 4xx/5xx responses) keyed by the X-Request-ID they
+// carry. It keeps the most recent maxRequests request IDs.
+type Recorder struct {
+	next        http.RoundTripper
+	maxBody     int
+	maxRequests int
+
+	mu    sync.Mutex
+	byID  map[string][]Exchange
+	order []string // Oldest first
+}
+
+// NewRecorder wraps next, keeping exchanges for up to maxRequests request
+// IDs with bodies truncated to maxBody bytes
+func NewRecorder(next http.RoundTripper, maxRequests, maxBody int) *Recorder {
+	return &Recorder{
+		next:        next,
+		maxBody:     maxBody,
+		maxRequests: maxRequests,
+		byID:        map[string][]Exchange{},
+	}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestID := req.Header.Get("X-Request-ID")
+	if requestID == "" {
+		return r.next.RoundTrip(req)
+	}
+
+	// Keep a copy of the body for the record; requests built from a byte
+	// buffer can simply be re-read
+	var reqBody []byte
+	if req.Body != nil && req.GetBody == nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = body
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	started := time.Now()
+	resp, err := r.next.RoundTrip(req)
+	if err == nil && resp.StatusCode < http.StatusBadRequest {
+		return resp, nil
+	}
+
+	if reqBody == nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(body, int64(r.maxBody)))
+			body.Close()
+		}
+	}
+	ex := Exchange{
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: sanitizeHeaders(req.Header),
+		RequestBody:    r.sanitizeBody(reqBody),
+		StartedAt:      started.UTC(),
+		DurationMs:     time.Since(started).Milliseconds(),
+	}
+	if err != nil {
+		ex.Error = err.Error()
+	} else {
+		// Read the head of the body for the record and hand the caller an
+		// equivalent body
+		head, readErr := io.ReadAll(io.LimitReader(resp.Body, int64(r.maxBody)))
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+		if readErr != nil {
+			ex.Error = readErr.Error()
+		}
+		ex.Status = resp.StatusCode
+		ex.ResponseHeaders = sanitizeHeaders(resp.Header)
+		ex.ResponseBody = r.sanitizeBody(head)
+	}
+	r.add(requestID, ex)
+	return resp, err
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (r *Recorder) add(requestID string, ex Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	exchanges, ok := r.byID[requestID]
+	if !ok {
+		r.order = append(r.order, requestID)
+		for len(r.order) > r.maxRequests {
+			delete(r.byID, r.order[0])
+			r.order = r.order[1:]
+		}
+	}
+	if len(exchanges) >= maxPerRequest {
+		exchanges = exchanges[1:]
+	}
+	r.byID[requestID] = append(exchanges, ex)
+}
+
+// Get returns the exchanges captured for a request ID, oldest first
+func (r *Recorder) Get(requestID string) ([]Exchange, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	exchanges, ok := r.byID[requestID]
+	return append([]Exchange(nil), exchanges...), ok
+}
+
+// Replay re-sends each captured call of a request to the backends, in
+// order, and returns the new outcomes. Replays are tagged with a derived
+// request ID and are not captured themselves. Redacted values are sent as
+// captured.
+func (r *Recorder) Replay(ctx context.Context, requestID string) ([]Exchange, bool) {
+	captured, ok := r.Get(requestID)
+	if !ok {
+		return nil, false
+	}
+
+	results := make([]Exchange, 0, len(captured))
+	for _, original := range captured {
+		results = append(results, r.replay(ctx, requestID, original))
+	}
+	return results, true
+}
+
+func (r *Recorder) replay(ctx context.Context, requestID string, original Exchange) Exchange {
+	ex := Exchange{
+		Method:         original.Method,
+		URL:            original.URL,
+		RequestHeaders: original.RequestHeaders.Clone(),
+		RequestBody:    original.RequestBody,
+		StartedAt:      time.Now().UTC(),
+	}
+	if ex.RequestHeaders == nil {
+		ex.RequestHeaders = http.Header{}
+	}
+	ex.RequestHeaders.Set("X-Request-ID", requestID+"-replay")
+
+	var body io.Reader
+	if original.RequestBody != "" {
+		body = strings.NewReader(original.RequestBody)
+	}
+	req, err := http.NewRequestWithContext(ctx, original.Method, original.URL, body)
+	if err != nil {
+		ex.Error = err.Error()
+		return ex
+	}
+	for name, values := range ex.RequestHeaders {
+		if values[0] != redacted {
+			req.Header[name] = values
+		}
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	ex.DurationMs = time.Since(ex.StartedAt).Milliseconds()
+	if err != nil {
+		ex.Error = err.Error()
+		return ex
+	}
+	defer resp.Body.Close()
+	head, _ := io.ReadAll(io.LimitReader(resp.Body, int64(r.maxBody)))
+	ex.Status = resp.StatusCode
+	ex.ResponseHeaders = sanitizeHeaders(resp.Header)
+	ex.ResponseBody = r.sanitizeBody(head)
+	return ex
+}
+
+func sanitizeHeaders(h http.Header) http.Header {
+	clean := make(http.Header, len(h))
+	for name, values := range h {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			clean[name] = []string{redacted}
+			continue
+		}
+		clean[name] = append([]string(nil), values...)
+	}
+	return clean
+}
+
+// sanitizeBody truncates body and, when it is JSON, redacts sensitive
+// fields
+func (r *Recorder) sanitizeBody(body []byte) string {
+	if len(body) > r.maxBody {
+		body = body[:r.maxBody]
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	clean, err := json.Marshal(redact(v))
+	if err != nil {
+		return string(body)
+	}
+	return string(clean)
+}
+
+func redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if isSensitive(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redact(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redact(value)
+		}
+	}
+	return v
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
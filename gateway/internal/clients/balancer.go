@@ -0,0 +1,175 @@
+package clients
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Load-balancing strategies
+const (
+	RoundRobin   = "round_robin"
+	LeastPending = "least_pending"
+)
+
+const (
+	// ejectAfterFailures consecutive failures mark an endpoint unhealthy
+	ejectAfterFailures = 3
+	// ejectFor is how long an unhealthy endpoint is skipped
+	ejectFor = 10 * time.Second
+)
+
+// Balancer spreads requests across the replicas of one backend service and
+// passively ejects replicas that keep failing.
+type Balancer struct {
+	mu        sync.RWMutex
+	endpoints []*Endpoint
+	strategy  string
+	next      atomic.Uint64
+}
+
+// Endpoint is a single backend replica.
+type Endpoint struct {
+	URL *url.URL
+
+	pending   atomic.Int64
+	mu        sync.Mutex
+	failures  int
+	ejectedAt time.Time
+}
+
+// NewBalancer creates a balancer over the given base URLs.
+func NewBalancer(urls []string, strategy string) *Balancer {
+	b := &Balancer{strategy: strategy}
+	b.SetEndpoints(urls)
+	return b
+}
+
+// SetEndpoints replaces the replica list, keeping the health state of
+// replicas that remain.
+func (b *Balancer) SetEndpoints(urls []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	existing := make(map[string]*Endpoint, len(b.endpoints))
+	for _, ep := range b.endpoints {
+		existing[ep.URL.String()] = ep
+	}
+
+	endpoints := make([]*Endpoint, 0, len(urls))
+	for _, raw := range urls {
+		u, err := url.Parse(strings.TrimRight(raw, "/"))
+		if err != nil || u.Host == "" {
+			continue
+		}
+		if ep, ok := existing[u.String()]; ok {
+			endpoints = append(endpoints, ep)
+			continue
+		}
+		endpoints = append(endpoints, &Endpoint{URL: u})
+	}
+	b.endpoints = endpoints
+}
+
+// SetStrategy changes the selection strategy.
+func (b *Balancer) SetStrategy(strategy string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.strategy = strategy
+}
+
+// Endpoints returns the current replicas.
+func (b *Balancer) Endpoints() []*Endpoint {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]*Endpoint(nil), b.endpoints...)
+}
+
+// Pick selects the replica for the next attempt. Healthy replicas are
+// preferred; if none are healthy every replica is a candidate.
+func (b *Balancer) Pick() *Endpoint {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if len(b.endpoints) == 0 {
+		return nil
+	}
+
+	candidates := make([]*Endpoint, 0, len(b.endpoints))
+	for _, ep := range b.endpoints {
+		if ep.Healthy() {
+			candidates = append(candidates, ep)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = b.endpoints
+	}
+
+	start := int(b.next.Add(1)-1) % len(candidates)
+	if b.strategy != LeastPending {
+		return candidates[start]
+	}
+
+	// Least pending, starting from the round-robin position to break ties
+	best := candidates[start]
+	for i := 1; i < len(candidates); i++ {
+		ep := candidates[(start+i)%len(candidates)]
+		if ep.pending.Load() < best.pending.Load() {
+			best = ep
+		}
+	}
+	return best
+}
+
+// Healthy reports whether the replica is currently in rotation.
+func (e *Endpoint) Healthy() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.failures < ejectAfterFailures || time.Since(e.ejectedAt) > ejectFor
+}
+
+// begin marks a request as in flight.
+func (e *Endpoint) begin() {
+	e.pending.Add(1)
+}
+
+// end records the outcome of a request started with begin.
+func (e *Endpoint) end(failed bool) {
+	e.pending.Add(-1)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !failed {
+		e.failures = 0
+		return
+	}
+	e.failures++
+	if e.failures >= ejectAfterFailures {
+		e.ejectedAt = time.Now()
+	}
+}
+
+// abandon releases a request started with begin without recording an
+// outcome, e.g. when the caller cancelled it.
+func (e *Endpoint) abandon() {
+	e.pending.Add(-1)
+}
+
+// rewrite points u at this replica, keeping the path below base.
+func (e *Endpoint) rewrite(u *url.URL, base string) *url.URL {
+	return rebase(u, base, e.URL)
+}
+
+// rebase moves u from the base URL it was built on to target, keeping the
+// path below base, query and fragment.
+func rebase(u *url.URL, base string, target *url.URL) *url.URL {
+	rewritten := *u
+	rewritten.Scheme = target.Scheme
+	rewritten.Host = target.Host
+	if baseURL, err := url.Parse(base); err == nil {
+		rewritten.Path = target.Path + strings.TrimPrefix(u.Path, strings.TrimRight(baseURL.Path, "/"))
+	}
+	return &rewritten
+}
@@ -0,0 +1,117 @@
+package clients
+
+import (
+	"context"
+	"hash/fnv"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/common"
+)
+
+// Routing variants
+const (
+	VariantStable = "stable"
+	VariantCanary = "canary"
+)
+
+// Canary routes part of a service's traffic to a second (v2) deployment.
+// Tenants listed explicitly always go to the canary; everyone else is
+// bucketed by user (or tenant/request) so a caller sticks to one variant.
+type Canary struct {
+	URL     string
+	Percent float64 // 0-100
+	Tenants []string
+}
+
+// selects reports whether the request in ctx should use the canary.
+func (c *Canary) selects(ctx context.Context) bool {
+	if c == nil || c.URL == "" {
+		return false
+	}
+
+	tenantID := common.GetTenantID(ctx)
+	for _, t := range c.Tenants {
+		if t == tenantID {
+			return true
+		}
+	}
+	if c.Percent <= 0 {
+		return false
+	}
+
+	key := common.GetUserID(ctx)
+	if key == "" {
+		key = tenantID + common.GetRequestID(ctx)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return float64(h.Sum32()%10000) < c.Percent*100
+}
+
+// target returns the parsed canary base URL.
+func (c *Canary) target() (*url.URL, error) {
+	return url.Parse(strings.TrimRight(c.URL, "/"))
+}
+
+// VariantSnapshot summarises the calls made to one routing variant.
+type VariantSnapshot struct {
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+type variantCounters struct {
+	requests int64
+	errors   int64
+	latency  time.Duration
+}
+
+var variantMetrics = struct {
+	mu       sync.Mutex
+	counters map[string]map[string]*variantCounters
+}{counters: map[string]map[string]*variantCounters{}}
+
+// recordVariant adds the outcome of one upstream call to the variant metrics.
+func recordVariant(service, variant string, latency time.Duration, failed bool) {
+	variantMetrics.mu.Lock()
+	defer variantMetrics.mu.Unlock()
+
+	byVariant, ok := variantMetrics.counters[service]
+	if !ok {
+		byVariant = map[string]*variantCounters{}
+		variantMetrics.counters[service] = byVariant
+	}
+	counters, ok := byVariant[variant]
+	if !ok {
+		counters = &variantCounters{}
+		byVariant[variant] = counters
+	}
+
+	counters.requests++
+	counters.latency += latency
+	if failed {
+		counters.errors++
+	}
+}
+
+// VariantMetrics returns per-service, per-variant call statistics.
+func VariantMetrics() map[string]map[string]VariantSnapshot {
+	variantMetrics.mu.Lock()
+	defer variantMetrics.mu.Unlock()
+
+	snapshot := make(map[string]map[string]VariantSnapshot, len(variantMetrics.counters))
+	for service, byVariant := range variantMetrics.counters {
+		snapshot[service] = make(map[string]VariantSnapshot, len(byVariant))
+		for variant, c := range byVariant {
+			snapshot[service][variant] = VariantSnapshot{
+				Requests:     c.requests,
+				Errors:       c.errors,
+				AvgLatencyMs: float64(c.latency.Milliseconds()) / float64(max(c.requests, 1)),
+			}
+		}
+	}
+	return snapshot
+}
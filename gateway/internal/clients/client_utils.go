@@ -1,44 +1,101 @@
 package clients
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"math"
+	"maps"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/timing"
 )
 
-const (
-	defaultRetryAttempts = 3
-	defaultRetryWait     = 500 * time.Millisecond
-)
+// errRetryBudgetExhausted is returned when a retry is skipped because the
+// shared retry budget is empty.
+var errRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// isIdempotent reports whether repeating a request with this method has the
+// same effect as sending it once (RFC 9110 section 9.2.2).
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// doRequestWithRetries executes an HTTP request with retries and correlation ID
+// injection, routing it to the canary deployment when selected.
+func doRequestWithRetries(client *http.Client, req *http.Request, opts Options) (*http.Response, error) {
+	variant := VariantStable
+	if opts.Canary.selects(req.Context()) {
+		if canaryURL, err := opts.Canary.target(); err == nil {
+			variant = VariantCanary
+			req.URL = rebase(req.URL, opts.BaseURL, canaryURL)
+			req.Host = ""
+			// The canary is a single deployment: no balancing or failover
+			opts.BaseURL, opts.Balancer, opts.FallbackURL = canaryURL.String(), nil, ""
+		}
+	}
 
-// doRequestWithRetries executes an HTTP request with retries and correlation ID injection.
-func doRequestWithRetries(client *http.Client, req *http.Request) (*http.Response, error) {
-	// 1. Inject Correlation ID
-	requestID := common.GetRequestID(req.Context())
-	if requestID != "" {
-		req.Header.Set("X-Request-ID", requestID)
+	start := time.Now()
+	resp, err := doAttempts(client, req, opts)
+	timing.Record(req.Context(), opts.Service, time.Since(start))
+	if !errors.Is(err, context.Canceled) {
+		recordVariant(opts.Service, variant, time.Since(start), err != nil || resp.StatusCode >= 500)
 	}
+	return resp, err
+}
+
+// doAttempts runs the retry loop for a single logical request.
+func doAttempts(client *http.Client, req *http.Request, opts Options) (*http.Response, error) {
+	// Correlation ID, Idempotency-Key, language and the caller's identity;
+	// Propagate sets the same on requests handlers send themselves
+	headers := contextHeaders(req.Context())
+	maps.Copy(headers, opts.Identity.headers(req.Context()))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	idempotencyKey := common.GetIdempotencyKey(req.Context())
+
+	// Unsafe requests (e.g. POST creating a plan) are only retried when the
+	// backend can deduplicate them via an Idempotency-Key
+	policy := opts.Retry
+	attempts := max(policy.MaxAttempts, 1)
+	if !policy.retryableMethod(req.Method) || (!isIdempotent(req.Method) && idempotencyKey == "") {
+		attempts = 1
+	}
+	opts.Budget.deposit()
 
 	var resp *http.Response
 	var err error
+	made := 0
+	originalURL := req.URL
 
 	// 2. Retry Loop
-	for i := 0; i < defaultRetryAttempts; i++ {
+	for i := 0; i < attempts; i++ {
 		if i > 0 {
-			// Exponential backoff
-			backoff := time.Duration(float64(defaultRetryWait) * math.Pow(2, float64(i-1)))
+			if !opts.Budget.withdraw() {
+				err = fmt.Errorf("%w: %v", errRetryBudgetExhausted, err)
+				break
+			}
+
+			// Exponential backoff with jitter
 			select {
 			case <-req.Context().Done():
 				return nil, req.Context().Err()
-			case <-time.After(backoff):
+			case <-time.After(policy.backoff(i)):
 			}
 		}
 
 		// Clone request body if needed (for retries) - usually handled by GetBody,
-		// but standard http.Request.GetBody is set for bytes.Buffer/strings.Reader.
+		// but standard http.Request.GetBody is set for bytes.Buffer/strings.Reader
+		// and bufpool bodies.
 		if i > 0 && req.GetBody != nil {
 			newBody, err := req.GetBody()
 			if err != nil {
@@ -46,27 +103,60 @@ func doRequestWithRetries(client *http.Client, req *http.Request) (*http.Respons
 			}
 			req.Body = newBody
 		}
-		
+
+		// Fail over to the secondary backend while the primary's breaker is open
+		primary := opts.FallbackURL == "" || opts.Breaker == nil || opts.Breaker.Allow()
+
+		// Route each attempt to a replica chosen by the balancer
+		var endpoint *Endpoint
+		if !primary {
+			if fallback, parseErr := url.Parse(strings.TrimRight(opts.FallbackURL, "/")); parseErr == nil {
+				req.URL = rebase(originalURL, opts.BaseURL, fallback)
+				req.Host = ""
+			}
+		} else if opts.Balancer != nil {
+			if endpoint = opts.Balancer.Pick(); endpoint != nil {
+				req.URL = endpoint.rewrite(originalURL, opts.BaseURL)
+				req.Host = ""
+				endpoint.begin()
+			}
+		}
+
 		resp, err = client.Do(req)
-		
-		// Check for network errors or 5xx status codes
+		made++
+
+		// The caller went away; that says nothing about the backend's
+		// health, and there is no one left to retry for
+		if err != nil && errors.Is(req.Context().Err(), context.Canceled) {
+			if endpoint != nil {
+				endpoint.abandon()
+			}
+			return nil, fmt.Errorf("request cancelled after %d attempts: %w", made, err)
+		}
+
+		failed := err != nil || resp.StatusCode >= 500
+		if endpoint != nil {
+			endpoint.end(failed)
+		}
+		if primary && opts.FallbackURL != "" && opts.Breaker != nil {
+			opts.Breaker.Record(!failed)
+		}
+
+		// Check for network errors or retryable status codes
 		if err != nil {
 			continue // Network error, retry
 		}
 
-		if resp.StatusCode >= 500 {
+		if policy.retryableStatus(resp.StatusCode) && i < attempts-1 {
 			resp.Body.Close() // Close body before retrying
 			err = fmt.Errorf("server error: %d", resp.StatusCode)
 			continue
 		}
 
-		// If 4xx or 2xx, return immediately (don't retry client errors)
+		// Anything else (including the final retryable status) is returned as-is
 		return resp, nil
 	}
 
 	// Return last error if all retries failed
-	if err != nil {
-		return nil, fmt.Errorf("request failed after %d attempts: %w", defaultRetryAttempts, err)
-	}
-	return resp, nil
+	return nil, fmt.Errorf("request failed after %d attempts: %w", made, err)
 }
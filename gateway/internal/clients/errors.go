@@ -0,0 +1,185 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxErrorBody caps how much of a failed response is kept on the error
+const maxErrorBody = 4 << 10
+
+// Upstream error codes for failures without a backend status
+const (
+	CodeTimeout         = "timeout"
+	CodeUnavailable     = "unavailable"
+	CodeCanceled        = "canceled"
+	CodeInvalidResponse = "invalid_response"
+)
+
+// UpstreamError is a failed call to a backend service. Clients return it
+// for every failure past building the request, so callers can branch on
+// the status or Retryable instead of parsing messages.
+type UpstreamError struct {
+	Service    string // rag, planner or quiz
+	Op         string // e.g. "create plan"
+	StatusCode int    // 0 when no response was received
+	Code       string // Backend error code, or one of the Code* constants
+	Message    string // Backend error detail, if any
+	Retryable  bool   // Whether the same call may succeed later
+	Body       string // Head of the response body
+	Err        error  // Underlying transport or decode error
+
+	// RetryAfter is how long to wait before retrying, from the backend's
+	// Retry-After header or the breaker's remaining cooldown; 0 if unknown
+	RetryAfter time.Duration
+}
+
+func (e *UpstreamError) Error() string {
+	msg := fmt.Sprintf("%s %s failed", e.Service, e.Op)
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(": status %d", e.StatusCode)
+	}
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// AsUpstreamError returns the UpstreamError in err's chain, if any
+func AsUpstreamError(err error) (*UpstreamError, bool) {
+	var upstream *UpstreamError
+	ok := errors.As(err, &upstream)
+	return upstream, ok
+}
+
+// IsStatus reports whether err is an upstream error with the given status
+func IsStatus(err error, status int) bool {
+	upstream, ok := AsUpstreamError(err)
+	return ok && upstream.StatusCode == status
+}
+
+// transportError wraps a failure to get a response at all
+func transportError(opts Options, op string, err error) *UpstreamError {
+	e := &UpstreamError{Service: opts.Service, Op: op, Err: err, Code: CodeUnavailable, Retryable: true}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		e.Code = CodeTimeout
+	case errors.Is(err, context.Canceled):
+		e.Code, e.Retryable = CodeCanceled, false
+	}
+	if e.Retryable && opts.Breaker != nil {
+		e.RetryAfter = opts.Breaker.RetryAfter()
+	}
+	return e
+}
+
+// statusError reads a non-OK response into an UpstreamError
+func statusError(opts Options, op string, resp *http.Response) *UpstreamError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	e := &UpstreamError{
+		Service:    opts.Service,
+		Op:         op,
+		StatusCode: resp.StatusCode,
+		Code:       statusCode(resp.StatusCode),
+		Retryable:  transientStatus(resp.StatusCode),
+		Body:       string(body),
+	}
+	if e.Retryable {
+		e.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		if e.RetryAfter == 0 && opts.Breaker != nil {
+			e.RetryAfter = opts.Breaker.RetryAfter()
+		}
+	}
+
+	// FastAPI errors carry {"detail": "..."} (or a list for validation
+	// errors); the gateway's own style is {"error": code, "message": text}
+	var parsed struct {
+		Detail  json.RawMessage `json:"detail"`
+		Error   string          `json:"error"`
+		Message string          `json:"message"`
+	}
+	if json.Unmarshal(body, &parsed) == nil {
+		if parsed.Error != "" {
+			e.Code, e.Message = parsed.Error, parsed.Message
+		} else if len(parsed.Detail) > 0 {
+			var detail string
+			if json.Unmarshal(parsed.Detail, &detail) == nil {
+				e.Message = detail
+			} else {
+				e.Message = string(parsed.Detail)
+			}
+		}
+	}
+	return e
+}
+
+// decodeError reports a response that could not be decoded, usually a
+// contract mismatch with the backend
+func decodeError(service, op string, err error) *UpstreamError {
+	return &UpstreamError{Service: service, Op: op, StatusCode: http.StatusOK, Code: CodeInvalidResponse, Err: err}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an
+// HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+func statusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusUnprocessableEntity:
+		return "validation_error"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return CodeTimeout
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 500 {
+		return "server_error"
+	}
+	return "client_error"
+}
+
+func transientStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
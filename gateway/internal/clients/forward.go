@@ -0,0 +1,85 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/bufpool"
+)
+
+// Forward sends a request a handler built itself, such as a proxied search,
+// with the same balancing, failover, canary routing and retries as the
+// client's own calls, and returns the response whatever its status. path is
+// relative to the service's base URL and body may be nil. The call is
+// bounded by the client's timeout until the caller closes the body.
+func (c *ragClient) Forward(ctx context.Context, method, path string, body *bufpool.Body) (*http.Response, error) {
+	opts := c.get()
+	if opts.HedgeDelay > 0 && path == "/search" {
+		// Hedged like Search; the winner is read in full so the other
+		// attempt can be dropped
+		return hedge(ctx, opts.HedgeDelay, func(ctx context.Context) (*http.Response, error) {
+			return buffered(forward(ctx, c.client, opts, method, path, body))
+		})
+	}
+	return forward(ctx, c.client, opts, method, path, body)
+}
+
+// Forward is RAGClient.Forward for the Planner service
+func (c *plannerClient) Forward(ctx context.Context, method, path string, body *bufpool.Body) (*http.Response, error) {
+	return forward(ctx, c.client, c.get(), method, path, body)
+}
+
+func forward(ctx context.Context, client *http.Client, opts Options, method, path string, body *bufpool.Body) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	var httpReq *http.Request
+	var err error
+	if body != nil {
+		httpReq, err = body.NewRequest(ctx, method, opts.BaseURL+path)
+	} else {
+		httpReq, err = http.NewRequestWithContext(ctx, method, opts.BaseURL+path, nil)
+	}
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create %s request: %w", opts.Service, err)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := doRequestWithRetries(client, httpReq, opts)
+	if err != nil {
+		cancel()
+		return nil, transportError(opts, method+" "+path, err)
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// buffered reads a response's body into memory and closes it
+func buffered(resp *http.Response, err error) (*http.Response, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return resp, nil
+}
+
+// cancelOnClose releases a forwarded call's context with its body
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,42 @@
+package clients
+
+import (
+	"context"
+	"time"
+)
+
+// hedge runs fn and, if it hasn't returned within delay, starts a second copy.
+// The first successful result wins and the other attempt is cancelled. An
+// error is only returned once every started attempt has failed.
+func hedge[T any](ctx context.Context, delay time.Duration, fn func(context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		value T
+		err   error
+	}
+	results := make(chan result, 2)
+	run := func() {
+		value, err := fn(ctx)
+		results <- result{value, err}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	go run()
+	started, finished := 1, 0
+	for {
+		select {
+		case <-timer.C:
+			started++
+			go run()
+		case r := <-results:
+			finished++
+			if r.err == nil || finished == started {
+				return r.value, r.err
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package clients
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MTLSOptions configures mutual TLS with the backends. Certificates, keys
+// and CAs are PEM files, or the PEM itself for secrets injected inline.
+type MTLSOptions struct {
+	CertFile       string
+	KeyFile        string
+	CAFile         string            // CA for backends without their own; system roots when empty
+	HostCAFiles    map[string]string // Backend host -> CA it is pinned to
+	ReloadInterval time.Duration
+}
+
+// Credentials hold the client certificate presented to the backends and
+// the CAs their certificates are verified against. Reload swaps them in
+// place, so new connections pick up rotated certificates without a restart.
+type Credentials struct {
+	opts MTLSOptions
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	roots     *x509.CertPool // nil: system roots
+	hostRoots map[string]*x509.CertPool
+}
+
+// NewCredentials loads the certificate and CAs, failing if any is invalid
+func NewCredentials(opts MTLSOptions) (*Credentials, error) {
+	c := &Credentials{opts: opts}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload re-reads the certificate and CAs. On failure the ones in use are
+// kept.
+func (c *Credentials) Reload() error {
+	certPEM, err := readPEM(c.opts.CertFile)
+	if err != nil {
+		return fmt.Errorf("read client certificate: %w", err)
+	}
+	keyPEM, err := readPEM(c.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("read client key: %w", err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("load client certificate: %w", err)
+	}
+
+	var roots *x509.CertPool
+	if c.opts.CAFile != "" {
+		if roots, err = loadPool(c.opts.CAFile); err != nil {
+			return err
+		}
+	}
+	hostRoots := make(map[string]*x509.CertPool, len(c.opts.HostCAFiles))
+	for host, caFile := range c.opts.HostCAFiles {
+		pool, err := loadPool(caFile)
+		if err != nil {
+			return fmt.Errorf("%s: %w", host, err)
+		}
+		hostRoots[host] = pool
+	}
+
+	c.mu.Lock()
+	c.cert, c.roots, c.hostRoots = &cert, roots, hostRoots
+	c.mu.Unlock()
+	return nil
+}
+
+// Run reloads the credentials every ReloadInterval until ctx is done
+func (c *Credentials) Run(ctx context.Context) {
+	if c.opts.ReloadInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(c.opts.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Reload(); err != nil {
+				log.Printf("mtls: reload failed, keeping current certificates: %v", err)
+			}
+		}
+	}
+}
+
+// configure makes tlsConfig present the client certificate and verify
+// backends against their CA. Verification is done here rather than by
+// crypto/tls so the CA can depend on the host and change on reload.
+func (c *Credentials) configure(tlsConfig *tls.Config) {
+	tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return c.cert, nil
+	}
+	if tlsConfig.InsecureSkipVerify {
+		return
+	}
+	tlsConfig.InsecureSkipVerify = true
+	tlsConfig.VerifyConnection = c.verify
+}
+
+// verify checks a backend's certificate chain against the CA for its host
+func (c *Credentials) verify(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("mtls: backend presented no certificate")
+	}
+	c.mu.RLock()
+	roots, pinned := c.hostRoots[state.ServerName]
+	if !pinned {
+		roots = c.roots
+	}
+	c.mu.RUnlock()
+
+	opts := x509.VerifyOptions{
+		DNSName:       state.ServerName,
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range state.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if _, err := state.PeerCertificates[0].Verify(opts); err != nil {
+		return fmt.Errorf("mtls: verify %s: %w", state.ServerName, err)
+	}
+	return nil
+}
+
+// readPEM returns value itself when it is PEM, otherwise the file it names
+func readPEM(value string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return os.ReadFile(value)
+}
+
+func loadPool(caFile string) (*x509.CertPool, error) {
+	data, err := readPEM(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("read CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("CA contains no certificates")
+	}
+	return pool, nil
+}
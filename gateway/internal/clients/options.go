@@ -0,0 +1,50 @@
+package clients
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Options tunes how a client talks to its upstream service.
+type Options struct {
+	Service string // Name used in metrics, e.g. "planner"
+	BaseURL string
+	Timeout time.Duration
+	Retry   RetryPolicy
+	Budget  *RetryBudget // Shared across clients; nil disables the budget
+	// Balancer spreads requests over the service's replicas. Request URLs are
+	// built from BaseURL and rewritten to the chosen replica; nil disables it.
+	Balancer *Balancer
+	// FallbackURL is a secondary backend used while Breaker is open. Both
+	// must be set for failover; otherwise requests always go to the primary.
+	FallbackURL string
+	Breaker     *CircuitBreaker
+	// Canary sends a share of traffic to a v2 deployment; nil disables it.
+	Canary *Canary
+	// HedgeDelay starts a second, parallel attempt when the first hasn't
+	// answered in time. Zero disables hedging.
+	HedgeDelay time.Duration
+	// Identity signs the caller's user and tenant into an internal token;
+	// nil sends them as plain headers only.
+	Identity *Identity
+}
+
+// Configurable is implemented by clients whose Options can be swapped at
+// runtime, e.g. when the config file is reloaded.
+type Configurable interface {
+	Configure(opts Options)
+}
+
+// liveOptions holds a client's current Options.
+type liveOptions struct {
+	v atomic.Pointer[Options]
+}
+
+// Configure replaces the client's Options.
+func (o *liveOptions) Configure(opts Options) {
+	o.v.Store(&opts)
+}
+
+func (o *liveOptions) get() Options {
+	return *o.v.Load()
+}
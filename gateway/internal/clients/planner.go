@@ -1,14 +1,14 @@
 package clients
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+	"net/url"
 
+	"github.com/amirhf/learnpath-gateway/internal/bufpool"
 	"github.com/amirhf/learnpath-gateway/internal/models"
 	"github.com/google/uuid"
 )
@@ -18,70 +18,72 @@ type PlannerClient interface {
 	CreatePlan(ctx context.Context, req models.PlanLearningPathRequest) (*models.LearningPath, error)
 	GetPlan(ctx context.Context, planID uuid.UUID) (*models.LearningPath, error)
 	GetUserPlans(ctx context.Context, userID string) ([]models.LearningPath, error)
-	Replan(ctx context.Context, planID uuid.UUID, req ReplanRequest) (*models.LearningPath, error)
+	Replan(ctx context.Context, planID uuid.UUID, req models.ReplanRequest) (*models.LearningPath, error)
+	// SetPlanStatus marks a plan models.PlanActive or models.PlanDraft
+	SetPlanStatus(ctx context.Context, planID uuid.UUID, status string) error
+	DeletePlan(ctx context.Context, planID uuid.UUID) error
+	// TransferPlans reassigns a user's plans to another user, returning
+	// how many moved
+	TransferPlans(ctx context.Context, fromUserID, toUserID string) (int, error)
+	// DecomposeGoal breaks a broad goal into suggested sub-goals
+	DecomposeGoal(ctx context.Context, req models.DecomposeGoalRequest) (*models.GoalDecomposition, error)
+	// Forward sends a request built by a proxy handler; see forward.go
+	Forward(ctx context.Context, method, path string, body *bufpool.Body) (*http.Response, error)
 }
 
 type plannerClient struct {
-	client  *http.Client
-	baseURL string
+	client *http.Client
+	liveOptions
 }
 
 // NewPlannerClient creates a new Planner client.
-func NewPlannerClient(baseURL string) PlannerClient {
-	return &plannerClient{
-		client: &http.Client{
-			Timeout: 2 * time.Minute, // Planner operations can be long-running
-		},
-		baseURL: baseURL,
-	}
-}
-
-// ReplanRequest mirrors the Python Planner service's ReplanRequest.
-type ReplanRequest struct {
-	CompletedResources []uuid.UUID `json:"completed_resources"`
-	TimeSpentHours     float64     `json:"time_spent_hours"`
-	RemainingTimeHours *float64    `json:"remaining_time_hours,omitempty"`
-	Feedback           *string     `json:"feedback,omitempty"`
+func NewPlannerClient(transport http.RoundTripper, opts Options) PlannerClient {
+	c := &plannerClient{client: &http.Client{Transport: transport}}
+	c.Configure(opts)
+	return c
 }
 
-
 // CreatePlan sends a request to the Planner service to create a new learning plan.
 func (c *plannerClient) CreatePlan(ctx context.Context, req models.PlanLearningPathRequest) (*models.LearningPath, error) {
-	jsonReq, err := json.Marshal(req)
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	body, err := bufpool.JSON(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal Planner create plan request: %w", err)
 	}
+	defer body.Release()
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/plan", c.baseURL), bytes.NewBuffer(jsonReq))
+	httpReq, err := body.NewRequest(ctx, "POST", fmt.Sprintf("%s/plan", opts.BaseURL))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Planner create plan request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := doRequestWithRetries(c.client, httpReq)
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send Planner create plan request: %w", err)
+		return nil, transportError(opts, "create plan", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var errRes map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errRes)
-		return nil, fmt.Errorf("Planner create plan service returned non-OK status: %d, error: %v", resp.StatusCode, errRes)
+		return nil, statusError(opts, "create plan", resp)
 	}
 
 	// DEBUG: Read response body to debug invalid UUID length error
-	bodyBytes, err := io.ReadAll(resp.Body)
+	respBody, err := bufpool.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, transportError(opts, "create plan", err)
 	}
-	fmt.Printf("DEBUG: Planner Response Body: %s\n", string(bodyBytes))
+	defer bufpool.Put(respBody)
+	fmt.Printf("DEBUG: Planner Response Body: %s\n", respBody.String())
 	// Restore body for decoder
-	resp.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+	resp.Body = io.NopCloser(respBody)
 
 	var planResp models.LearningPath
 	if err := json.NewDecoder(resp.Body).Decode(&planResp); err != nil {
-		return nil, fmt.Errorf("failed to decode Planner create plan response: %w", err)
+		return nil, decodeError(opts.Service, "create plan", err)
 	}
 
 	return &planResp, nil
@@ -89,26 +91,28 @@ func (c *plannerClient) CreatePlan(ctx context.Context, req models.PlanLearningP
 
 // GetPlan sends a request to the Planner service to retrieve a learning plan by ID.
 func (c *plannerClient) GetPlan(ctx context.Context, planID uuid.UUID) (*models.LearningPath, error) {
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/plan/%s", c.baseURL, planID.String()), nil)
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/plan/%s", opts.BaseURL, planID.String()), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Planner get plan request: %w", err)
 	}
 
-	resp, err := doRequestWithRetries(c.client, httpReq)
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send Planner get plan request: %w", err)
+		return nil, transportError(opts, "get plan", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var errRes map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errRes)
-		return nil, fmt.Errorf("Planner get plan service returned non-OK status: %d, error: %v", resp.StatusCode, errRes)
+		return nil, statusError(opts, "get plan", resp)
 	}
 
 	var planResp models.LearningPath
 	if err := json.NewDecoder(resp.Body).Decode(&planResp); err != nil {
-		return nil, fmt.Errorf("failed to decode Planner get plan response: %w", err)
+		return nil, decodeError(opts.Service, "get plan", err)
 	}
 
 	return &planResp, nil
@@ -116,62 +120,193 @@ func (c *plannerClient) GetPlan(ctx context.Context, planID uuid.UUID) (*models.
 
 // GetUserPlans sends a request to the Planner service to retrieve all learning plans for a user.
 func (c *plannerClient) GetUserPlans(ctx context.Context, userID string) ([]models.LearningPath, error) {
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/plan/user/%s/plans", c.baseURL, userID), nil)
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/user/%s/plans", opts.BaseURL, url.PathEscape(userID)), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Planner get user plans request: %w", err)
 	}
 
-	resp, err := doRequestWithRetries(c.client, httpReq)
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send Planner get user plans request: %w", err)
+		return nil, transportError(opts, "get user plans", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var errRes map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errRes)
-		return nil, fmt.Errorf("Planner get user plans service returned non-OK status: %d, error: %v", resp.StatusCode, errRes)
+		return nil, statusError(opts, "get user plans", resp)
 	}
 
 	var wrapper struct {
 		Plans []models.LearningPath `json:"plans"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&wrapper); err != nil {
-		return nil, fmt.Errorf("failed to decode Planner get user plans response: %w", err)
+		return nil, decodeError(opts.Service, "get user plans", err)
 	}
 
 	return wrapper.Plans, nil
 }
 
 // Replan sends a request to the Planner service to replan an existing learning plan.
-func (c *plannerClient) Replan(ctx context.Context, planID uuid.UUID, req ReplanRequest) (*models.LearningPath, error) {
-	jsonReq, err := json.Marshal(req)
+func (c *plannerClient) Replan(ctx context.Context, planID uuid.UUID, req models.ReplanRequest) (*models.LearningPath, error) {
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	body, err := bufpool.JSON(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal Planner replan request: %w", err)
 	}
+	defer body.Release()
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/plan/%s/replan", c.baseURL, planID.String()), bytes.NewBuffer(jsonReq))
+	httpReq, err := body.NewRequest(ctx, "POST", fmt.Sprintf("%s/plan/%s/replan", opts.BaseURL, planID.String()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Planner replan request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := doRequestWithRetries(c.client, httpReq)
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send Planner replan request: %w", err)
+		return nil, transportError(opts, "replan", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var errRes map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errRes)
-		return nil, fmt.Errorf("Planner replan service returned non-OK status: %d, error: %v", resp.StatusCode, errRes)
+		return nil, statusError(opts, "replan", resp)
 	}
 
 	var replanResp models.LearningPath
 	if err := json.NewDecoder(resp.Body).Decode(&replanResp); err != nil {
-		return nil, fmt.Errorf("failed to decode Planner replan response: %w", err)
+		return nil, decodeError(opts.Service, "replan", err)
 	}
 
 	return &replanResp, nil
-}
\ No newline at end of file
+}
+
+// SetPlanStatus sends a request to the Planner service to change a plan's status.
+func (c *plannerClient) SetPlanStatus(ctx context.Context, planID uuid.UUID, status string) error {
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	body, err := bufpool.JSON(map[string]string{"status": status})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Planner plan status request: %w", err)
+	}
+	defer body.Release()
+
+	httpReq, err := body.NewRequest(ctx, "PATCH", fmt.Sprintf("%s/plan/%s", opts.BaseURL, planID.String()))
+	if err != nil {
+		return fmt.Errorf("failed to create Planner plan status request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
+	if err != nil {
+		return transportError(opts, "set plan status", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(opts, "set plan status", resp)
+	}
+	return nil
+}
+
+// DeletePlan sends a request to the Planner service to delete a learning plan.
+func (c *plannerClient) DeletePlan(ctx context.Context, planID uuid.UUID) error {
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, "DELETE", fmt.Sprintf("%s/plan/%s", opts.BaseURL, planID.String()), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Planner delete plan request: %w", err)
+	}
+
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
+	if err != nil {
+		return transportError(opts, "delete plan", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return statusError(opts, "delete plan", resp)
+	}
+	return nil
+}
+
+// TransferPlans sends a request to the Planner service to move a user's plans to another user.
+func (c *plannerClient) TransferPlans(ctx context.Context, fromUserID, toUserID string) (int, error) {
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	body, err := bufpool.JSON(map[string]string{"to_user_id": toUserID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal Planner transfer plans request: %w", err)
+	}
+	defer body.Release()
+
+	httpReq, err := body.NewRequest(ctx, "POST", fmt.Sprintf("%s/user/%s/plans/transfer", opts.BaseURL, url.PathEscape(fromUserID)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create Planner transfer plans request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
+	if err != nil {
+		return 0, transportError(opts, "transfer plans", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, statusError(opts, "transfer plans", resp)
+	}
+
+	var transfer struct {
+		Transferred int `json:"transferred"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&transfer); err != nil {
+		return 0, decodeError(opts.Service, "transfer plans", err)
+	}
+	return transfer.Transferred, nil
+}
+
+// DecomposeGoal sends a request to the Planner service to break a goal into sub-goals.
+func (c *plannerClient) DecomposeGoal(ctx context.Context, req models.DecomposeGoalRequest) (*models.GoalDecomposition, error) {
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	body, err := bufpool.JSON(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Planner decompose request: %w", err)
+	}
+	defer body.Release()
+
+	httpReq, err := body.NewRequest(ctx, "POST", opts.BaseURL+"/decompose")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Planner decompose request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
+	if err != nil {
+		return nil, transportError(opts, "decompose goal", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(opts, "decompose goal", resp)
+	}
+
+	var decomposition models.GoalDecomposition
+	if err := json.NewDecoder(resp.Body).Decode(&decomposition); err != nil {
+		return nil, decodeError(opts.Service, "decompose goal", err)
+	}
+
+	return &decomposition, nil
+}
@@ -0,0 +1,67 @@
+package clients
+
+import (
+	"context"
+	"maps"
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/common"
+)
+
+// Propagate wraps next so every upstream call carries the request context
+// of the client request it was made for: its X-Request-ID, Idempotency-Key
+// and language, and the caller's identity signed by identity (which may be
+// nil). Handlers proxying to a backend themselves get the same headers as
+// the typed clients without setting them.
+func Propagate(next http.RoundTripper, identity *Identity) http.RoundTripper {
+	return &propagatingTransport{next: next, identity: identity}
+}
+
+type propagatingTransport struct {
+	next     http.RoundTripper
+	identity *Identity
+}
+
+func (t *propagatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	headers := contextHeaders(req.Context())
+	// Calls made by the clients already carry the identity
+	if req.Header.Get(HeaderUserID) == "" && req.Header.Get(HeaderTenantID) == "" {
+		maps.Copy(headers, t.identity.headers(req.Context()))
+	}
+	missing := false
+	for name := range headers {
+		if req.Header.Get(name) == "" {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return t.next.RoundTrip(req)
+	}
+
+	// A RoundTripper mustn't modify the caller's request
+	req = req.Clone(req.Context())
+	for name, value := range headers {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// contextHeaders are the headers propagated from ctx to the backends
+func contextHeaders(ctx context.Context) map[string]string {
+	headers := map[string]string{}
+	if requestID := common.GetRequestID(ctx); requestID != "" {
+		headers["X-Request-ID"] = requestID
+	}
+	// Propagate the client's Idempotency-Key so backends can deduplicate
+	if key := common.GetIdempotencyKey(ctx); key != "" {
+		headers["Idempotency-Key"] = key
+	}
+	// Backends generate plans and quizzes in the learner's language
+	if language := common.GetLanguage(ctx); language != "" {
+		headers["Accept-Language"] = language
+	}
+	return headers
+}
@@ -1,35 +1,46 @@
 package clients
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"time"
+	"net/url"
+	"strconv"
 
+	"github.com/amirhf/learnpath-gateway/internal/bufpool"
+	"github.com/amirhf/learnpath-gateway/internal/common"
 	"github.com/amirhf/learnpath-gateway/internal/models"
 )
 
 // QuizClient defines the interface for interacting with the Quiz service.
 type QuizClient interface {
 	GenerateQuiz(ctx context.Context, req models.GenerateQuizRequest) (*models.Quiz, error)
+	// RetakeQuiz regenerates a quiz as a variant with paraphrased
+	// questions and shuffled options.
+	RetakeQuiz(ctx context.Context, req models.RetakeQuizRequest) (*models.Quiz, error)
+	// ListQuestions browses the bank of previously generated questions.
+	ListQuestions(ctx context.Context, filter models.QuestionBankFilter) ([]models.BankQuestion, error)
+	// ComposeQuiz assembles a quiz from bank questions without generating any.
+	ComposeQuiz(ctx context.Context, req models.ComposeQuizRequest) (*models.Quiz, error)
 	SubmitQuiz(ctx context.Context, req QuizSubmitRequest) (*QuizSubmitResponse, error)
+	// Calibrate reports how often questions are answered correctly, so
+	// the quiz service can adjust the difficulty of new quizzes.
+	Calibrate(ctx context.Context, req models.CalibrationRequest) error
+	// GradeAnswer grades a short answer; submissions leave them pending.
+	GradeAnswer(ctx context.Context, req models.GradeAnswerRequest) (*models.GradeAnswerResponse, error)
 }
 
 type quizClient struct {
-	client  *http.Client
-	baseURL string
+	client *http.Client
+	liveOptions
 }
 
 // NewQuizClient creates a new Quiz client.
-func NewQuizClient(baseURL string) QuizClient {
-	return &quizClient{
-		client: &http.Client{
-			Timeout: 1 * time.Minute, // Default timeout for quiz operations
-		},
-		baseURL: baseURL,
-	}
+func NewQuizClient(transport http.RoundTripper, opts Options) QuizClient {
+	c := &quizClient{client: &http.Client{Transport: transport}}
+	c.Configure(opts)
+	return c
 }
 
 // QuizSubmitRequest mirrors the Python Quiz service's QuizSubmitRequest.
@@ -40,47 +51,55 @@ type QuizSubmitRequest struct {
 
 // QuizAnswer mirrors the Python Quiz service's QuizAnswer.
 type QuizAnswer struct {
-	QuestionID      string `json:"question_id"`
+	QuestionID       string `json:"question_id"`
 	SelectedOptionID string `json:"selected_option_id"`
+	AnswerText       string `json:"answer_text,omitempty"` // Short-answer questions
 }
 
 // QuizSubmitResponse mirrors the Python Quiz service's QuizSubmitResponse.
 type QuizSubmitResponse struct {
-	QuizID         string                      `json:"quiz_id"`
-	Score          float64                     `json:"score"`
-	TotalQuestions int                         `json:"total_questions"`
-	CorrectAnswers int                         `json:"correct_answers"`
-	Results        []models.QuestionResult     `json:"results"`
+	QuizID           string                  `json:"quiz_id"`
+	Score            float64                 `json:"score"`
+	TotalQuestions   int                     `json:"total_questions"`
+	CorrectAnswers   int                     `json:"correct_answers"`
+	Results          []models.QuestionResult `json:"results"`
+	PendingQuestions int                     `json:"pending_questions"` // Short answers awaiting grading
 }
 
 // GenerateQuiz sends a request to the Quiz service to generate a new quiz.
 func (c *quizClient) GenerateQuiz(ctx context.Context, req models.GenerateQuizRequest) (*models.Quiz, error) {
-	jsonReq, err := json.Marshal(req)
+	if req.TenantID == "" {
+		req.TenantID = common.GetTenantID(ctx)
+	}
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	body, err := bufpool.JSON(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal Quiz generate request: %w", err)
 	}
+	defer body.Release()
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/generate", c.baseURL), bytes.NewBuffer(jsonReq))
+	httpReq, err := body.NewRequest(ctx, "POST", fmt.Sprintf("%s/generate", opts.BaseURL))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Quiz generate request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := doRequestWithRetries(c.client, httpReq)
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send Quiz generate request: %w", err)
+		return nil, transportError(opts, "generate quiz", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var errRes map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errRes)
-		return nil, fmt.Errorf("Quiz generate service returned non-OK status: %d, error: %v", resp.StatusCode, errRes)
+		return nil, statusError(opts, "generate quiz", resp)
 	}
 
 	var quizResp models.Quiz
 	if err := json.NewDecoder(resp.Body).Decode(&quizResp); err != nil {
-		return nil, fmt.Errorf("failed to decode Quiz generate response: %w", err)
+		return nil, decodeError(opts.Service, "generate quiz", err)
 	}
 
 	return &quizResp, nil
@@ -88,33 +107,217 @@ func (c *quizClient) GenerateQuiz(ctx context.Context, req models.GenerateQuizRe
 
 // SubmitQuiz sends a request to the Quiz service to submit answers and get results.
 func (c *quizClient) SubmitQuiz(ctx context.Context, req QuizSubmitRequest) (*QuizSubmitResponse, error) {
-	jsonReq, err := json.Marshal(req)
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	body, err := bufpool.JSON(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal Quiz submit request: %w", err)
 	}
+	defer body.Release()
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/submit", c.baseURL), bytes.NewBuffer(jsonReq))
+	httpReq, err := body.NewRequest(ctx, "POST", fmt.Sprintf("%s/submit", opts.BaseURL))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Quiz submit request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := doRequestWithRetries(c.client, httpReq)
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send Quiz submit request: %w", err)
+		return nil, transportError(opts, "submit quiz", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var errRes map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errRes)
-		return nil, fmt.Errorf("Quiz submit service returned non-OK status: %d, error: %v", resp.StatusCode, errRes)
+		return nil, statusError(opts, "submit quiz", resp)
 	}
 
 	var submitResp QuizSubmitResponse
 	if err := json.NewDecoder(resp.Body).Decode(&submitResp); err != nil {
-		return nil, fmt.Errorf("failed to decode Quiz submit response: %w", err)
+		return nil, decodeError(opts.Service, "submit quiz", err)
 	}
 
 	return &submitResp, nil
-}
\ No newline at end of file
+}
+
+// RetakeQuiz asks the Quiz service for a variant of a quiz.
+func (c *quizClient) RetakeQuiz(ctx context.Context, req models.RetakeQuizRequest) (*models.Quiz, error) {
+	if req.TenantID == "" {
+		req.TenantID = common.GetTenantID(ctx)
+	}
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	body, err := bufpool.JSON(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Quiz retake request: %w", err)
+	}
+	defer body.Release()
+
+	httpReq, err := body.NewRequest(ctx, "POST", fmt.Sprintf("%s/retake", opts.BaseURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Quiz retake request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
+	if err != nil {
+		return nil, transportError(opts, "retake quiz", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(opts, "retake quiz", resp)
+	}
+
+	var quizResp models.Quiz
+	if err := json.NewDecoder(resp.Body).Decode(&quizResp); err != nil {
+		return nil, decodeError(opts.Service, "retake quiz", err)
+	}
+	return &quizResp, nil
+}
+
+// ListQuestions lists bank questions from the Quiz service, newest first.
+func (c *quizClient) ListQuestions(ctx context.Context, filter models.QuestionBankFilter) ([]models.BankQuestion, error) {
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	query := url.Values{}
+	if filter.ResourceID != "" {
+		query.Set("resource_id", filter.ResourceID)
+	}
+	if filter.Skill != "" {
+		query.Set("skill", filter.Skill)
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/questions?%s", opts.BaseURL, query.Encode()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Quiz list questions request: %w", err)
+	}
+
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
+	if err != nil {
+		return nil, transportError(opts, "list questions", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(opts, "list questions", resp)
+	}
+
+	var wrapper struct {
+		Questions []models.BankQuestion `json:"questions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&wrapper); err != nil {
+		return nil, decodeError(opts.Service, "list questions", err)
+	}
+	return wrapper.Questions, nil
+}
+
+// ComposeQuiz asks the Quiz service to assemble a quiz from bank questions.
+func (c *quizClient) ComposeQuiz(ctx context.Context, req models.ComposeQuizRequest) (*models.Quiz, error) {
+	if req.TenantID == "" {
+		req.TenantID = common.GetTenantID(ctx)
+	}
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	body, err := bufpool.JSON(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Quiz compose request: %w", err)
+	}
+	defer body.Release()
+
+	httpReq, err := body.NewRequest(ctx, "POST", fmt.Sprintf("%s/compose", opts.BaseURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Quiz compose request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
+	if err != nil {
+		return nil, transportError(opts, "compose quiz", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(opts, "compose quiz", resp)
+	}
+
+	var quizResp models.Quiz
+	if err := json.NewDecoder(resp.Body).Decode(&quizResp); err != nil {
+		return nil, decodeError(opts.Service, "compose quiz", err)
+	}
+	return &quizResp, nil
+}
+
+// Calibrate sends question statistics to the Quiz service.
+func (c *quizClient) Calibrate(ctx context.Context, req models.CalibrationRequest) error {
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	body, err := bufpool.JSON(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Quiz calibrate request: %w", err)
+	}
+	defer body.Release()
+
+	httpReq, err := body.NewRequest(ctx, "POST", fmt.Sprintf("%s/calibrate", opts.BaseURL))
+	if err != nil {
+		return fmt.Errorf("failed to create Quiz calibrate request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
+	if err != nil {
+		return transportError(opts, "calibrate quiz", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(opts, "calibrate quiz", resp)
+	}
+	return nil
+}
+
+// GradeAnswer asks the Quiz service to grade a short answer.
+func (c *quizClient) GradeAnswer(ctx context.Context, req models.GradeAnswerRequest) (*models.GradeAnswerResponse, error) {
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	body, err := bufpool.JSON(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Quiz grade request: %w", err)
+	}
+	defer body.Release()
+
+	httpReq, err := body.NewRequest(ctx, "POST", fmt.Sprintf("%s/grade", opts.BaseURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Quiz grade request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
+	if err != nil {
+		return nil, transportError(opts, "grade answer", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(opts, "grade answer", resp)
+	}
+
+	var gradeResp models.GradeAnswerResponse
+	if err := json.NewDecoder(resp.Body).Decode(&gradeResp); err != nil {
+		return nil, decodeError(opts.Service, "grade answer", err)
+	}
+	return &gradeResp, nil
+}
@@ -1,37 +1,44 @@
 package clients
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/amirhf/learnpath-gateway/internal/bufpool"
 	"github.com/amirhf/learnpath-gateway/internal/common"
 	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/timing"
 )
 
 // RAGClient defines the interface for interacting with the RAG service.
 type RAGClient interface {
 	Search(ctx context.Context, req SearchRequest) (*models.SearchResponse, error)
-	IngestResources(ctx context.Context, urls []string) error
+	// IngestResources indexes resources and returns the IDs of those
+	// indexed, in request order
+	IngestResources(ctx context.Context, resources []IngestResource) ([]string, error)
+	// IngestDocument indexes a document whose text the gateway extracted
+	// and returns its resource ID
+	IngestDocument(ctx context.Context, doc IngestResource) (string, error)
+	// Forward sends a request built by a proxy handler; see forward.go
+	Forward(ctx context.Context, method, path string, body *bufpool.Body) (*http.Response, error)
 	// TODO: Add other RAG service methods if needed, like Embed, Rerank
 }
 
+const ingestTimeout = 60 * time.Second
+
 type ragClient struct {
-	client  *http.Client
-	baseURL string
+	client *http.Client
+	liveOptions
 }
 
 // NewRAGClient creates a new RAG client.
-func NewRAGClient(baseURL string) RAGClient {
-	return &ragClient{
-		client: &http.Client{
-			Timeout: 10 * time.Second, // Default timeout for RAG operations
-		},
-		baseURL: baseURL,
-	}
+func NewRAGClient(transport http.RoundTripper, opts Options) RAGClient {
+	c := &ragClient{client: &http.Client{Transport: transport}}
+	c.Configure(opts)
+	return c
 }
 
 // SearchRequest mirrors the Python RAG service's SearchRequest.
@@ -45,22 +52,36 @@ type SearchRequest struct {
 }
 
 // SearchFilters mirrors the Python RAG service's SearchFilters.
+
 type SearchFilters struct {
-	Skills       []string `json:"skills,omitempty"`
-	MediaTypes   []string `json:"media_types,omitempty"`
-	Levels       []int    `json:"levels,omitempty"`
-	Providers    []string `json:"providers,omitempty"`
-	MinDuration  *int     `json:"min_duration,omitempty"`
-	MaxDuration  *int     `json:"max_duration,omitempty"`
-	ExcludeURLs  []string `json:"exclude_urls,omitempty"`
+	Skills          []string `json:"skills,omitempty"`
+	MediaTypes      []string `json:"media_types,omitempty"`
+	Levels          []int    `json:"levels,omitempty"`
+	Providers       []string `json:"providers,omitempty"`
+	MinDuration     *int     `json:"min_duration,omitempty"`
+	MaxDuration     *int     `json:"max_duration,omitempty"`
+	ExcludeURLs     []string `json:"exclude_urls,omitempty"`
+	Licenses        []string `json:"licenses,omitempty"`         // Only these licenses
+	ExcludeLicenses []string `json:"exclude_licenses,omitempty"` // None of these licenses
+	ResourceIDs     []string `json:"resource_ids,omitempty"`     // Only these resources
 }
 
 // IngestResource mirrors the Python RAG service's Resource model for ingestion.
 type IngestResource struct {
+	ID          string   `json:"id,omitempty"` // Assigned by the RAG service when empty
 	Title       string   `json:"title"`
 	URL         string   `json:"url"`
 	Provider    string   `json:"provider,omitempty"`
 	TenantID    string   `json:"tenant_id,omitempty"`
+	MediaType   string   `json:"media_type,omitempty"`
+	Description string   `json:"description,omitempty"`
+	DurationMin int      `json:"duration_min,omitempty"`
+	// Content is the full text of an uploaded document or a video's
+	// transcript, indexed instead of fetching URL
+	Content string `json:"content,omitempty"`
+	// RespectRobotsTxt asks the crawler to skip the page if robots.txt
+	// disallows it
+	RespectRobotsTxt bool `json:"respect_robots_txt"`
 	// Other fields optional/default
 }
 
@@ -71,92 +92,153 @@ type IngestRequestPayload struct {
 	ExtractContent     bool             `json:"extract_content"`
 }
 
+// IngestResponse mirrors the Python RAG service's IngestResponse.
+type IngestResponse struct {
+	Success     int      `json:"success"`
+	Failed      int      `json:"failed"`
+	Total       int      `json:"total"`
+	Errors      []string `json:"errors"`
+	ResourceIDs []string `json:"resource_ids"` // Of the resources indexed, in request order
+}
+
 
 // Search sends a search request to the RAG service.
 func (c *ragClient) Search(ctx context.Context, req SearchRequest) (*models.SearchResponse, error) {
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
 	// Inject Tenant ID from context if not set
 	if req.TenantID == "" {
 		req.TenantID = common.GetTenantID(ctx)
 	}
 
-	jsonReq, err := json.Marshal(req)
+	body, err := bufpool.JSON(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal RAG search request: %w", err)
 	}
+	defer body.Release()
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/search", c.baseURL), bytes.NewBuffer(jsonReq))
+	search := func(ctx context.Context) (*models.SearchResponse, error) {
+		return c.search(ctx, opts, body)
+	}
+	if opts.HedgeDelay > 0 {
+		// Tame tail latency (e.g. model cold starts) with a second attempt
+		return hedge(ctx, opts.HedgeDelay, search)
+	}
+	return search(ctx)
+}
+
+// search performs a single (possibly retried) search call.
+func (c *ragClient) search(ctx context.Context, opts Options, body *bufpool.Body) (*models.SearchResponse, error) {
+	httpReq, err := body.NewRequest(ctx, "POST", fmt.Sprintf("%s/search", opts.BaseURL))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create RAG search request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := doRequestWithRetries(c.client, httpReq)
+	resp, err := doRequestWithRetries(c.client, httpReq, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send RAG search request: %w", err)
+		return nil, transportError(opts, "search", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var errRes map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errRes)
-		return nil, fmt.Errorf("RAG search service returned non-OK status: %d, error: %v", resp.StatusCode, errRes)
+		return nil, statusError(opts, "search", resp)
 	}
 
 	var searchResp models.SearchResponse
 	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
-		return nil, fmt.Errorf("failed to decode RAG search response: %w", err)
+		return nil, decodeError(opts.Service, "search", err)
 	}
 
 	return &searchResp, nil
 }
 
-// IngestResources sends resources to be ingested.
-func (c *ragClient) IngestResources(ctx context.Context, urls []string) error {
+// IngestResources sends resources to be ingested; the RAG service fetches
+// each URL for its content.
+func (c *ragClient) IngestResources(ctx context.Context, resources []IngestResource) ([]string, error) {
+	opts := c.get()
+	// Ingestion involves scraping/embedding so it gets a longer timeout
+	ctx, cancel := context.WithTimeout(ctx, ingestTimeout)
+	defer cancel()
+
 	tenantID := common.GetTenantID(ctx)
 	if tenantID == "" {
 		tenantID = "global" // Fallback if not set (though handler should ensure it)
 	}
-
-	resources := make([]IngestResource, len(urls))
-	for i, url := range urls {
-		resources[i] = IngestResource{
-			Title:    url, // Temporary title, assume backend extracts or user updates later
-			URL:      url,
-			TenantID: tenantID,
+	for i := range resources {
+		if resources[i].TenantID == "" {
+			resources[i].TenantID = tenantID
+		}
+		if resources[i].Title == "" {
+			resources[i].Title = resources[i].URL // Temporary title, assume backend extracts or user updates later
 		}
 	}
 
-	payload := IngestRequestPayload{
+	return c.ingest(ctx, opts, IngestRequestPayload{
 		Resources:          resources,
 		GenerateEmbeddings: true,
 		ExtractContent:     true,
+	})
+}
+
+// IngestDocument sends an uploaded document's text to be indexed.
+func (c *ragClient) IngestDocument(ctx context.Context, doc IngestResource) (string, error) {
+	opts := c.get()
+	ctx, cancel := context.WithTimeout(ctx, ingestTimeout)
+	defer cancel()
+
+	if doc.TenantID == "" {
+		doc.TenantID = common.GetTenantID(ctx)
+	}
+	if doc.TenantID == "" {
+		doc.TenantID = "global"
+	}
+
+	// There is nothing to fetch; the text travels with the resource
+	ids, err := c.ingest(ctx, opts, IngestRequestPayload{
+		Resources:          []IngestResource{doc},
+		GenerateEmbeddings: true,
+		ExtractContent:     false,
+	})
+	if err != nil {
+		return "", err
 	}
+	if len(ids) == 0 {
+		return doc.ID, nil // Services predating resource_ids don't report it
+	}
+	return ids[0], nil
+}
 
-	jsonReq, err := json.Marshal(payload)
+func (c *ragClient) ingest(ctx context.Context, opts Options, payload IngestRequestPayload) ([]string, error) {
+	body, err := bufpool.JSON(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal ingest request: %w", err)
+		return nil, fmt.Errorf("failed to marshal ingest request: %w", err)
 	}
+	defer body.Release()
 
-	// Increase timeout for ingestion as it involves scraping/embedding
-	client := &http.Client{Timeout: 60 * time.Second} 
-	
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/ingest/resources", c.baseURL), bytes.NewBuffer(jsonReq))
+	httpReq, err := body.NewRequest(ctx, "POST", fmt.Sprintf("%s/ingest/resources", opts.BaseURL))
 	if err != nil {
-		return fmt.Errorf("failed to create ingest request: %w", err)
+		return nil, fmt.Errorf("failed to create ingest request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(httpReq)
+	start := time.Now()
+	resp, err := c.client.Do(httpReq)
+	timing.Record(ctx, opts.Service, time.Since(start))
 	if err != nil {
-		return fmt.Errorf("failed to send ingest request: %w", err)
+		return nil, transportError(opts, "ingest", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		var errRes map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errRes)
-		return fmt.Errorf("ingest service returned non-OK status: %d, error: %v", resp.StatusCode, errRes)
+		return nil, statusError(opts, "ingest", resp)
 	}
 
-	return nil
+	var ingestResp IngestResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ingestResp); err != nil {
+		return nil, decodeError(opts.Service, "ingest", err)
+	}
+	return ingestResp.ResourceIDs, nil
 }
\ No newline at end of file
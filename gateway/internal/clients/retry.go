@@ -0,0 +1,102 @@
+package clients
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how failed upstream calls are retried.
+type RetryPolicy struct {
+	MaxAttempts          int
+	BaseWait             time.Duration
+	MaxWait              time.Duration
+	Jitter               float64 // Fraction of each backoff randomised, 0..1
+	RetryableMethods     []string
+	RetryableStatusCodes []int
+}
+
+// backoff returns the wait before the given retry (1-based), with exponential
+// growth capped at MaxWait and jitter applied.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	wait := float64(p.BaseWait) * math.Pow(2, float64(retry-1))
+	if p.MaxWait > 0 && wait > float64(p.MaxWait) {
+		wait = float64(p.MaxWait)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		wait = wait * (1 - jitter + jitter*rand.Float64())
+	}
+	return time.Duration(wait)
+}
+
+func (p RetryPolicy) retryableMethod(method string) bool {
+	for _, m := range p.RetryableMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (p RetryPolicy) retryableStatus(code int) bool {
+	for _, c := range p.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryBudget caps retries across all clients to a fraction of overall
+// traffic so retries can't amplify an outage. Every request deposits Ratio
+// tokens (up to Burst) and every retry withdraws one.
+type RetryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	burst  float64
+	tokens float64
+}
+
+// NewRetryBudget creates a budget allowing retries for ratio of requests, with
+// up to burst retries banked.
+func NewRetryBudget(ratio float64, burst int) *RetryBudget {
+	b := &RetryBudget{}
+	b.Configure(ratio, burst)
+	b.tokens = b.burst
+	return b
+}
+
+// Configure updates the budget parameters.
+func (b *RetryBudget) Configure(ratio float64, burst int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ratio = ratio
+	b.burst = float64(burst)
+	b.tokens = math.Min(b.tokens, b.burst)
+}
+
+// deposit records a first attempt.
+func (b *RetryBudget) deposit() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.tokens+b.ratio, b.burst)
+}
+
+// withdraw reports whether a retry is allowed, consuming a token if so.
+func (b *RetryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,88 @@
+package clients
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// TransportOptions tunes the connection pool shared by all upstream calls.
+type TransportOptions struct {
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	DisableKeepAlives     bool
+	TLSHandshakeTimeout   time.Duration
+	TLSInsecureSkipVerify bool
+	TLSMinVersion         uint16
+	HTTP2                 bool         // Negotiate HTTP/2 with TLS backends via ALPN
+	H2C                   bool         // Use cleartext HTTP/2 for http:// backends
+	Credentials           *Credentials // Mutual TLS with https:// backends; nil disables it
+}
+
+// NewTransport creates the transport shared by every client and proxy handler
+// so connections to the backends are pooled and reused. With HTTP/2 the many
+// small calls the orchestrator makes are multiplexed over one connection.
+func NewTransport(opts TransportOptions) http.RoundTripper {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     opts.HTTP2,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		DisableKeepAlives:     opts.DisableKeepAlives,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig: &tls.Config{
+			MinVersion:         opts.TLSMinVersion,
+			InsecureSkipVerify: opts.TLSInsecureSkipVerify,
+		},
+	}
+	if opts.Credentials != nil {
+		opts.Credentials.configure(transport.TLSClientConfig)
+	}
+	if !opts.HTTP2 {
+		// A non-nil empty map disables the bundled HTTP/2 support
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	if !opts.H2C {
+		return transport
+	}
+
+	return &h2cTransport{
+		tls: transport,
+		h2c: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+			ReadIdleTimeout: opts.IdleConnTimeout,
+		},
+	}
+}
+
+// h2cTransport sends http:// requests as cleartext HTTP/2 and everything
+// else through the regular transport.
+type h2cTransport struct {
+	tls *http.Transport
+	h2c *http2.Transport
+}
+
+func (t *h2cTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		return t.h2c.RoundTrip(req)
+	}
+	return t.tls.RoundTrip(req)
+}
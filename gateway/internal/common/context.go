@@ -8,6 +8,11 @@ const (
 	RequestIDKey contextKey = "request_id"
 	UserIDKey    contextKey = "user_id"
 	TenantIDKey  contextKey = "tenant_id"
+	LanguageKey  contextKey = "language"
+
+	VerifiedUserIDKey contextKey = "verified_user_id"
+
+	IdempotencyKeyKey contextKey = "idempotency_key"
 )
 
 // WithRequestID returns a new context with the given RequestID.
@@ -36,6 +41,23 @@ func GetUserID(ctx context.Context) string {
 	return ""
 }
 
+// WithVerifiedUserID returns a new context with the given UserID, marked
+// as proven by a verified token or guest session.
+func WithVerifiedUserID(ctx context.Context, userID string) context.Context {
+	ctx = WithUserID(ctx, userID)
+	return context.WithValue(ctx, VerifiedUserIDKey, userID)
+}
+
+// GetVerifiedUserID retrieves the UserID from the context if it was
+// verified, and "" otherwise.
+func GetVerifiedUserID(ctx context.Context) string {
+	userID := GetUserID(ctx)
+	if val, ok := ctx.Value(VerifiedUserIDKey).(string); ok && val == userID {
+		return userID
+	}
+	return ""
+}
+
 // WithTenantID returns a new context with the given TenantID.
 func WithTenantID(ctx context.Context, tenantID string) context.Context {
 	return context.WithValue(ctx, TenantIDKey, tenantID)
@@ -48,3 +70,30 @@ func GetTenantID(ctx context.Context) string {
 	}
 	return ""
 }
+
+// WithIdempotencyKey returns a new context with the given Idempotency-Key.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, IdempotencyKeyKey, key)
+}
+
+// GetIdempotencyKey retrieves the Idempotency-Key from the context.
+func GetIdempotencyKey(ctx context.Context) string {
+	if val, ok := ctx.Value(IdempotencyKeyKey).(string); ok {
+		return val
+	}
+	return ""
+}
+
+// WithLanguage returns a new context with the language content should be
+// generated in.
+func WithLanguage(ctx context.Context, language string) context.Context {
+	return context.WithValue(ctx, LanguageKey, language)
+}
+
+// GetLanguage retrieves the generation language from the context.
+func GetLanguage(ctx context.Context) string {
+	if val, ok := ctx.Value(LanguageKey).(string); ok {
+		return val
+	}
+	return ""
+}
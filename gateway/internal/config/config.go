@@ -1,30 +1,1581 @@
 package config
 
 import (
+	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds application configuration
 type Config struct {
 	Environment       string
+	ConfigFile        string
 	RAGServiceURL     string
 	PlannerServiceURL string
 	QuizServiceURL    string
-	SupabaseURL       string
-	SupabaseAnonKey   string
-	SupabaseJWTSecret string
+	// The *_SERVICE_URL settings accept comma-separated replica lists; the
+	// single-URL fields above hold the first replica.
+	RAGServiceURLs     []string
+	PlannerServiceURLs []string
+	QuizServiceURLs    []string
+	LoadBalancing      string // round_robin or least_pending
+	// Optional secondary backends used while a primary's circuit is open
+	RAGFallbackURL     string
+	PlannerFallbackURL string
+	QuizFallbackURL    string
+	CircuitBreaker     CircuitBreakerConfig
+	SupabaseURL        string
+	SupabaseAnonKey    string
+	SupabaseJWTSecret  string
+	AdminToken         string
+	CORS               CORSConfig
+	Timeouts           TimeoutConfig
+	Deadlines          DeadlineConfig
+	BodyLimits         BodyLimitConfig
+	Auth               AuthConfig
+	Ownership          OwnershipConfig
+	Guests             GuestConfig
+	Moderation         ModerationConfig
+	Ingestion          IngestionConfig
+	Uploads            UploadConfig
+	Transcripts        TranscriptConfig
+	Previews           PreviewConfig
+	Durations          DurationConfig
+	Costs              CostConfig
+	Languages          LanguageConfig
+	Licenses           LicenseConfig
+	Diversity          DiversityConfig
+	Calibration        CalibrationConfig
+	Grading            GradingConfig
+	QuizIntegrity      QuizIntegrityConfig
+	Retakes            RetakeConfig
+	Remediation        RemediationConfig
+	Compensation       CompensationConfig
+	Drafts             DraftConfig
+	Retry              RetryConfig
+	RetryOverrides     map[string]RetryOverride // Keyed by service: rag, planner, quiz
+	RateLimit          RateLimitConfig
+	IPRateLimit        IPRateLimitConfig
+	Abuse              AbuseConfig
+	Deprecation        DeprecationConfig
+	JSONAPI            JSONAPIConfig
+	Transport          TransportConfig
+	Hedging            HedgingConfig
+	Concurrency        ConcurrencyConfig
+	Admission          AdmissionConfig
+	Storage            StorageConfig
+	Database           DatabaseConfig
+	Events             EventsConfig
+	Notifications      NotificationsConfig
+	Hooks              HooksConfig
+	Branding           BrandingConfig
+	Focus              FocusConfig
+	Scheduler          SchedulerConfig
+	Seed               SeedConfig
+	Discovery          DiscoveryConfig
+	Mirror             MirrorConfig
+	Capture            CaptureConfig
+	Canary             CanaryConfig
+	MaintenanceMessage string
+	Server             ServerConfig
+	Health             HealthConfig
+	AccessLog          AccessLogConfig
+	ErrorReporting     ErrorReportingConfig
+	SlowRequests       SlowRequestConfig
+	Timing             TimingConfig
+	Batch              BatchConfig
+	Caching            CachingConfig
+	TLS                TLSConfig
+	UpstreamTLS        UpstreamTLSConfig
+	InternalToken      InternalTokenConfig
+	Secrets            SecretsConfig
+	Features           map[string]bool
 }
 
-// Load loads configuration from environment variables
+// CORSConfig holds the cross-origin policy applied by the gateway
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// TimeoutConfig holds per-upstream request timeouts
+type TimeoutConfig struct {
+	RAG     time.Duration
+	Planner time.Duration
+	Quiz    time.Duration
+}
+
+// DeadlineConfig holds end-to-end budgets for API requests, covering
+// queueing and every upstream call the request makes
+type DeadlineConfig struct {
+	Default time.Duration            // Applies to routes without their own; 0 is unbounded
+	Routes  map[string]time.Duration // Keyed by the Route* names
+}
+
+// API routes with their own deadline budget
+const (
+	RouteSearch          = "search"
+	RoutePlan            = "plan"
+	RouteGetPlan         = "get_plan"
+	RouteUserPlans       = "user_plans"
+	RouteReplan          = "replan"
+	RouteQuizGenerate    = "quiz_generate"
+	RouteQuizSubmit      = "quiz_submit"
+	RouteContentIngest   = "content_ingest"
+	RouteContentUpload   = "content_upload"
+	RoutePlanFromContent = "plan_from_content"
+	RouteGoalDecompose   = "goal_decompose"
+	RoutePlanImport      = "plan_import"
+)
+
+// routes lists the Route* names, for per-route environment overrides
+var routes = []string{RouteSearch, RoutePlan, RouteGetPlan, RouteUserPlans, RouteReplan, RouteQuizGenerate, RouteQuizSubmit, RouteContentIngest, RouteContentUpload, RoutePlanFromContent, RouteGoalDecompose, RoutePlanImport}
+
+// For returns the deadline budget for a route
+func (d DeadlineConfig) For(route string) time.Duration {
+	if budget, ok := d.Routes[route]; ok {
+		return budget
+	}
+	return d.Default
+}
+
+// BodyLimitConfig bounds request bodies before they are parsed or proxied
+type BodyLimitConfig struct {
+	MaxBytes     int            // Applies to routes without their own limit
+	Routes       map[string]int // Keyed by the Route* names
+	MaxJSONDepth int            // Deepest allowed nesting of JSON objects and arrays
+}
+
+// For returns the body size limit for a route
+func (b BodyLimitConfig) For(route string) int {
+	if limit, ok := b.Routes[route]; ok {
+		return limit
+	}
+	return b.MaxBytes
+}
+
+// Largest returns the highest body size limit of any route
+func (b BodyLimitConfig) Largest() int {
+	largest := b.MaxBytes
+	for _, limit := range b.Routes {
+		largest = max(largest, limit)
+	}
+	return largest
+}
+
+// AuthConfig decides which API routes need a signed-in user. Tokens are
+// checked on every route; a route that doesn't require one also serves
+// anonymous callers, under the global tenant.
+type AuthConfig struct {
+	Required bool            // Applies to routes without their own setting
+	Routes   map[string]bool // Keyed by the Route* names
+	// AnonymousMode attributes anonymous plans to the user_id in their
+	// body, for deployments without sign-in. Signed-in users are always
+	// taken from their token.
+	AnonymousMode bool
+}
+
+// For reports whether a route requires a signed-in user
+func (a AuthConfig) For(route string) bool {
+	if required, ok := a.Routes[route]; ok {
+		return required
+	}
+	return a.Required
+}
+
+// OwnershipConfig controls who may access plans and quizzes. Their owners
+// are recorded when they are created; other users are turned away.
+type OwnershipConfig struct {
+	Enabled   bool
+	Retention time.Duration // How long owners are kept, after which resources aren't found; 0 keeps them
+}
+
+// GuestConfig controls guest sessions: signed tokens letting visitors try
+// the product before signing up, with what they create moved to their
+// account afterwards
+type GuestConfig struct {
+	Enabled    bool
+	Secret     string        // Signs guest tokens; guests are off without one
+	TTL        time.Duration // How long a guest token is valid
+	MaxPlans   int           // Plans a guest may create
+	MaxQuizzes int           // Quizzes a guest may generate besides their plans'
+}
+
+// ModerationConfig screens plan goals and ingestion URLs before they reach
+// the backends
+type ModerationConfig struct {
+	Enabled        bool
+	BlockedTerms   []string // Matched as whole words, case-insensitively
+	BlockedDomains []string // Also block their subdomains
+	// Optional moderation API taking {"input"} and answering {"flagged", "categories"}
+	APIURL     string
+	APIKey     string
+	Timeout    time.Duration
+	FailClosed bool                          // Reject input when the API is unreachable
+	Tenants    map[string]ModerationOverride // Keyed by tenant ID
+}
+
+// ModerationOverride adjusts moderation for one tenant
+type ModerationOverride struct {
+	Enabled        *bool    // Overrides ModerationConfig.Enabled when set
+	BlockedTerms   []string // Added to the global list
+	BlockedDomains []string // Added to the global list
+	AllowedDomains []string // Exempt from the domain blocklist
+	SkipAPI        bool     // Use the blocklists only
+}
+
+// IngestionConfig controls how submitted URLs are checked and canonicalized
+// before ingestion
+type IngestionConfig struct {
+	AllowedSchemes []string
+	StripParams    []string // Query parameters dropped as tracking noise; a trailing * matches by prefix
+	// RespectRobotsTxt asks the RAG service to skip pages robots.txt disallows
+	RespectRobotsTxt bool
+}
+
+// TranscriptConfig controls fetching transcripts of video resources at
+// ingestion
+type TranscriptConfig struct {
+	Enabled    bool
+	Languages  []string // Caption languages to try, in order
+	WhisperURL string   // Transcription service for videos without captions
+	Timeout    time.Duration
+}
+
+// PreviewConfig controls link previews
+type PreviewConfig struct {
+	Timeout  time.Duration
+	CacheTTL time.Duration
+	MaxBytes int // Of a fetched page
+	// AllowPrivate lets previews reach loopback and private addresses, for
+	// local development only
+	AllowPrivate bool
+}
+
+// DurationConfig controls estimating resource durations at ingestion, from
+// video metadata or the page's word count
+type DurationConfig struct {
+	Enabled        bool
+	WordsPerMinute int // Reading speed for articles
+}
+
+// CostConfig controls accounting of LLM and embedding spend per tenant.
+// Backends report a call's cost in X-LLM-Cost-USD or its tokens in
+// X-LLM-Tokens; calls reporting neither are charged an estimate.
+type CostConfig struct {
+	Enabled          bool
+	MonthlyBudgetUSD float64            // Per tenant and calendar month (UTC); 0 is unlimited
+	Budgets          map[string]float64 // Keyed by tenant ID, overriding MonthlyBudgetUSD
+	TokenPriceUSD    float64            // Per 1,000 tokens
+	// Estimates is the USD charged for a call reporting no usage, keyed by
+	// backend path (e.g. /generate). Reads are never charged.
+	Estimates map[string]float64
+}
+
+// LanguageConfig lists the languages plans and quizzes can be generated in
+type LanguageConfig struct {
+	Default   string   // When the request names no supported language
+	Supported []string // BCP 47 tags, e.g. en, pt-BR
+}
+
+// LicenseConfig classifies resource licenses for free-only planning.
+// Resources without a license are assumed free.
+type LicenseConfig struct {
+	Paid []string // Licenses of resources that cost money, compared case-insensitively
+}
+
+// DiversityConfig caps how many search results, and resources offered to
+// the planner, may share a provider or media type. 0 is unlimited.
+type DiversityConfig struct {
+	MaxPerProvider  int
+	MaxPerMediaType int
+	Tenants         map[string]DiversityOverride // Keyed by tenant ID
+}
+
+// DiversityOverride replaces the caps for one tenant where set
+type DiversityOverride struct {
+	MaxPerProvider  *int
+	MaxPerMediaType *int
+}
+
+// CalibrationConfig controls tracking how often each quiz question is
+// answered correctly. Rates are kept per tenant and sent back to the quiz
+// service, which adjusts the difficulty of new quizzes.
+type CalibrationConfig struct {
+	Enabled    bool
+	MinAnswers int     // Answers needed before a question is rated
+	EasyAbove  float64 // Correct rate above which a question is easy
+	HardBelow  float64 // Correct rate below which a question is hard
+}
+
+// GradingConfig controls the worker that has the quiz service grade the
+// short answers of pending quiz attempts
+type GradingConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int // Grading runs before unanswerable short answers are marked incorrect
+}
+
+// QuizIntegrityConfig controls server-side checks on quiz submissions.
+// Quizzes are recorded as issued to each learner, and submissions are
+// checked against them.
+type QuizIntegrityConfig struct {
+	Enabled            bool
+	AllowRetakes       bool          // Otherwise one submission per issued quiz
+	MinTimePerQuestion time.Duration // Faster submissions are recorded as anomalies
+	SessionTTL         time.Duration // How long an issued quiz can be submitted
+}
+
+// RetakeConfig limits how often a learner may retake a quiz as a
+// regenerated variant. MaxRetakes 0 is unlimited.
+type RetakeConfig struct {
+	MaxRetakes int                       // Retakes per quiz, counting variants of variants
+	Cooldown   time.Duration             // Since the last submission of the quiz or a variant
+	Tenants    map[string]RetakeOverride // Keyed by tenant ID
+}
+
+// RetakeOverride replaces the retake limits for one tenant where set
+type RetakeOverride struct {
+	MaxRetakes *int
+	Cooldown   *time.Duration
+}
+
+// RemediationConfig controls when a learner is offered review resources
+// after a quiz
+type RemediationConfig struct {
+	ScoreThreshold     float64 // Quiz percentage below which remediation is offered
+	MaxReviewResources int     // Resources added to the review milestone
+}
+
+// CompensationConfig decides what becomes of a plan the planner created
+// when the rest of its orchestration (quiz generation) then fails
+type CompensationConfig struct {
+	Policy string // none keeps the plan, delete removes it, draft marks it a draft
+}
+
+// DraftConfig controls plan drafts built up step by step before they are
+// committed
+type DraftConfig struct {
+	TTL time.Duration // Since the draft was last changed
+}
+
+// UploadConfig selects where uploaded documents are kept. Their text is
+// indexed by the RAG service; the originals stay with the gateway.
+type UploadConfig struct {
+	Backend     string // local or s3
+	Dir         string // For local
+	S3Bucket    string
+	S3Region    string
+	S3Endpoint  string // S3-compatible service, e.g. MinIO; empty for AWS
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// RetryConfig holds the retry settings for upstream calls
+type RetryConfig struct {
+	MaxAttempts          int
+	BaseWait             time.Duration
+	MaxWait              time.Duration
+	Jitter               float64
+	RetryableMethods     []string
+	RetryableStatusCodes []int
+	// BudgetRatio and BudgetBurst bound retries across all upstreams to a
+	// fraction of total requests
+	BudgetRatio float64
+	BudgetBurst int
+}
+
+// RetryOverride replaces selected retry settings for a single service
+type RetryOverride struct {
+	MaxAttempts          *int
+	BaseWait             *time.Duration
+	MaxWait              *time.Duration
+	Jitter               *float64
+	RetryableMethods     []string
+	RetryableStatusCodes []int
+}
+
+// RateLimitConfig throttles signed-in users and guests by user
+type RateLimitConfig struct {
+	RequestsPerMinute int // 0 disables the limit
+	Burst             int // Requests a second at most; 0 for no cap
+}
+
+// IPRateLimitConfig throttles anonymous callers by client IP
+type IPRateLimitConfig struct {
+	RequestsPerMinute int      // 0 disables the limit
+	Allowlist         []string // IPs and CIDRs never limited, e.g. monitoring
+}
+
+// AbuseConfig bans callers who send too many failed (4xx) requests from
+// the plan and quiz generation endpoints, for twice as long on each repeat
+// offense
+type AbuseConfig struct {
+	Threshold  int // Failed requests per window; 0 disables bans
+	Window     time.Duration
+	Ban        time.Duration // First ban
+	MaxBan     time.Duration
+	OffenseTTL time.Duration // How long a ban counts towards longer ones
+}
+
+// DeprecationConfig marks API routes and request fields as deprecated.
+// Responses using them carry Deprecation, Sunset and Link headers, and
+// each client's use is logged once per LogTTL.
+type DeprecationConfig struct {
+	Rules  []DeprecationRule // The first matching rule applies
+	LogTTL time.Duration
+}
+
+// DeprecationRule deprecates a route, or one field of its requests
+type DeprecationRule struct {
+	Path    string    // Unversioned route, e.g. /api/plan/:id; a trailing * matches a prefix
+	Method  string    // Empty for every method
+	Version string    // legacy (the unversioned /api alias), v1 or v2; empty for all
+	Field   string    // Top-level JSON body field or query parameter
+	Since   time.Time // When it was deprecated; zero if unannounced
+	Sunset  time.Time // When it stops working; zero if unplanned
+	Link    string    // Migration guide
+}
+
+// DeprecatedLegacyAPI is the Version of the unversioned /api alias
+const DeprecatedLegacyAPI = "legacy"
+
+// JSONAPIConfig controls the JSON:API response mode, which callers opt into
+// with ?format=jsonapi or Accept: application/vnd.api+json
+type JSONAPIConfig struct {
+	Tenants []string // Tenants answered in JSON:API unless they ask for ?format=json
+}
+
+// TransportConfig tunes the HTTP connection pool used for upstream calls
+type TransportConfig struct {
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	DisableKeepAlives     bool
+	TLSHandshakeTimeout   time.Duration
+	TLSInsecureSkipVerify bool
+	TLSMinVersion         string // "1.2" or "1.3"
+	HTTP2                 bool   // Negotiate HTTP/2 with TLS backends
+	H2C                   bool   // Speak cleartext HTTP/2 (prior knowledge) to http:// backends
+}
+
+// HedgingConfig holds the delays after which a parallel attempt is started.
+// Hedging is enabled with the "hedged_search" feature flag.
+type HedgingConfig struct {
+	RAGSearchDelay time.Duration // Roughly the p95 latency of RAG search
+}
+
+// ConcurrencyConfig bounds the fan-out of orchestrator operations
+type ConcurrencyConfig struct {
+	MilestoneParallelism int // Concurrent per-milestone calls within one request
+	PlansPerUser         int // In-flight plan generations per user; 0 = unlimited
+}
+
+// AdmissionConfig bounds concurrent API requests. Requests beyond the limit
+// queue by priority (search, then planning, then ingestion) and are shed
+// with 503 when the queue is full or the backends are saturated.
+type AdmissionConfig struct {
+	MaxInFlight       int // 0 disables admission control
+	MaxQueue          int
+	QueueTimeout      time.Duration
+	SaturationCooloff time.Duration // How long to shed low-priority work after a backend pushes back
+	RetryAfter        time.Duration
+}
+
+// StorageConfig selects the store for gateway-side state (rate limits,
+// idempotency records, caches, sessions). Use Redis when running more than
+// one replica.
+type StorageConfig struct {
+	Backend        string // memory or redis
+	RedisURL       string
+	KeyPrefix      string
+	IdempotencyTTL time.Duration // How long responses are kept for Idempotency-Key replay
+}
+
+// DatabaseConfig points at the gateway's own Postgres database for learner
+// data (notes, bookmarks, progress, share tokens). Without a URL that data
+// is kept in memory.
+type DatabaseConfig struct {
+	URL          string
+	Driver       string // database/sql driver name
+	MaxOpenConns int
+}
+
+// EventsConfig selects the message bus domain events are published to.
+// Events are written to an outbox first and relayed in the background, so
+// a bus outage delays delivery instead of losing events.
+type EventsConfig struct {
+	Backend       string // empty (disabled), nats or kafka
+	URL           string // nats:// server, or Kafka REST proxy base URL
+	SubjectPrefix string // Prepended to the event type to form the subject/topic
+	PollInterval  time.Duration
+	BatchSize     int
+}
+
+// NotificationsConfig posts quiz results and milestone reminders to chat
+// channels through their incoming webhooks
+type NotificationsConfig struct {
+	Channels []NotificationChannel
+	AppURL   string // Learner app base URL, for links to plans; empty for none
+	Timeout  time.Duration
+	// TenantTTL is how long a learner's tenant is remembered for routing
+	// reminders, from their last recorded progress
+	TenantTTL time.Duration
+}
+
+// NotificationChannel is one incoming webhook
+type NotificationChannel struct {
+	Type    string   // teams or slack
+	URL     string   // Incoming webhook (Slack) or Workflows/incoming webhook (Teams) URL
+	Tenants []string // Tenants whose learners are reported here; empty for all
+	Events  []string // quiz_result, milestone_reminder; empty for both
+}
+
+// Notification channel types
+const (
+	NotifyTeams = "teams"
+	NotifySlack = "slack"
+)
+
+// HooksConfig controls REST hook subscriptions to learning events, as
+// Zapier and Make create them
+type HooksConfig struct {
+	MaxPerUser int // Subscriptions a user can hold
+	Timeout    time.Duration
+	// AllowPrivate lets hooks target loopback and private addresses, for
+	// local development only
+	AllowPrivate bool
+}
+
+// BrandingConfig is the branding of exports and notifications for tenants
+// without their own, set with PUT /admin/tenants/:id/branding
+type BrandingConfig struct {
+	LogoURL      string
+	PrimaryColor string // Hex, e.g. #2680c2
+	AccentColor  string
+	SenderName   string // Who notifications and digest emails are from
+}
+
+// FocusConfig controls focus (Pomodoro) sessions. Clients send heartbeats
+// with how long the learner has been idle; a session pauses once that
+// reaches IdleThreshold, and time without heartbeats for longer than
+// HeartbeatTimeout isn't counted.
+type FocusConfig struct {
+	DefaultMinutes   int // Session length when the client names none
+	IdleThreshold    time.Duration
+	HeartbeatTimeout time.Duration
+	MaxMinutes       int // Focused time counted per session at most
+}
+
+// SchedulerConfig controls the gateway's recurring background jobs
+type SchedulerConfig struct {
+	Enabled       bool
+	MaxJitter     time.Duration        // Random delay added to each scheduled run
+	Jobs          map[string]JobConfig // Keyed by job name
+	Feeds         []string             // Content URLs re-ingested by feed_reingest
+	WarmupQueries []string             // Popular searches for cache_warmup and dead_link_check
+}
+
+// JobConfig enables and schedules one job. Schedule is "@every <duration>",
+// @hourly, @daily, @weekly or a five-field cron expression in UTC.
+type JobConfig struct {
+	Enabled  bool
+	Schedule string
+}
+
+// SeedConfig describes the demo data loaded into a fresh deployment: the
+// resources ingested and the example plan and quiz created for a demo user
+type SeedConfig struct {
+	OnStartup bool // Seed once when the gateway starts; otherwise only via POST /admin/seed
+	UserID    string
+	Goal      string
+	URLs      []string
+}
+
+// Scheduled jobs
+const (
+	JobHealthPoll         = "health_poll"
+	JobFeedReingest       = "feed_reingest"
+	JobDeadLinkCheck      = "dead_link_check"
+	JobCacheWarmup        = "cache_warmup"
+	JobWeeklyDigest       = "weekly_digest"
+	JobMilestoneReminders = "milestone_reminders"
+)
+
+// DiscoveryConfig controls dynamic resolution of backend addresses. In the
+// default "static" mode the *_SERVICE_URL settings are used as-is.
+type DiscoveryConfig struct {
+	Mode            string // static, dns, kubernetes or consul
+	Scheme          string
+	RefreshInterval time.Duration
+	ConsulAddr      string
+	KubeNamespace   string
+	// Service names as known to the discovery backend (SRV name, Kubernetes
+	// Service or Consul service)
+	RAGService     string
+	PlannerService string
+	QuizService    string
+}
+
+// Dynamic reports whether backend addresses come from service discovery
+func (d DiscoveryConfig) Dynamic() bool {
+	return d.Mode != "" && d.Mode != "static"
+}
+
+// CircuitBreakerConfig controls when traffic fails over to a fallback backend
+type CircuitBreakerConfig struct {
+	FailureThreshold int           // Consecutive failures that open the circuit
+	OpenTimeout      time.Duration // How long to wait before probing the primary
+}
+
+// MirrorConfig controls shadow traffic sent to a staging environment
+type MirrorConfig struct {
+	TargetURL string   // Base URL of the staging gateway; empty disables mirroring
+	Percent   float64  // Share of matching requests to mirror, 0-100
+	Paths     []string // Route paths to mirror
+	Timeout   time.Duration
+}
+
+// CaptureConfig controls recording of failed upstream calls for debugging
+type CaptureConfig struct {
+	Enabled      bool
+	MaxRequests  int // Request IDs kept; the oldest are evicted first
+	MaxBodyBytes int // Captured bodies are truncated to this size
+}
+
+// CanaryConfig routes part of the Planner/Quiz traffic to v2 deployments
+type CanaryConfig struct {
+	PlannerURL string   // v2 Planner base URL; empty disables the Planner canary
+	QuizURL    string   // v2 Quiz base URL; empty disables the Quiz canary
+	Percent    float64  // Share of users routed to v2, 0-100
+	Tenants    []string // Tenants always routed to v2
+}
+
+// HealthConfig controls the background checks of the backends that
+// readiness probes are answered from
+type HealthConfig struct {
+	PollInterval time.Duration
+	ProbeTimeout time.Duration
+	StaleAfter   time.Duration // Older checks are reported as unknown, failing readiness
+	VersionsTTL  time.Duration // How long backend versions and schemas are cached
+}
+
+// AccessLogConfig controls where the per-request log goes. Sinks are
+// stdout, file, loki and http; entries are batched for all of them.
+type AccessLogConfig struct {
+	Sinks         []string
+	Format        string // text or json, for stdout and file
+	File          string
+	LokiURL       string // Loki base URL; entries are pushed to /loki/api/v1/push
+	HTTPURL       string // Receives each batch as a JSON array
+	Timeout       time.Duration
+	BatchSize     int
+	FlushInterval time.Duration
+	BufferSize    int // Entries queued before new ones are dropped
+	// Sampling is the share of requests logged, keyed by path prefix, for
+	// high-volume routes; server errors are always logged
+	Sampling map[string]float64
+}
+
+// ErrorReportingConfig sends panics and server errors to Sentry, or any
+// tracker accepting Sentry events. An empty DSN disables it.
+type ErrorReportingConfig struct {
+	DSN         string
+	Environment string // Defaults to ENVIRONMENT
+	Release     string
+	SampleRate  float64 // Share of 5xx responses reported, 0..1; panics always are
+	Timeout     time.Duration
+}
+
+// SlowRequestConfig sets when a request is logged as slow or large, with
+// the time spent in each backend. Zero disables a threshold.
+type SlowRequestConfig struct {
+	Threshold          time.Duration
+	LargeResponseBytes int
+}
+
+// TimingConfig controls the per-backend latency breakdown shown to
+// clients. Admins can always ask for it with ?debug=1.
+type TimingConfig struct {
+	Header bool // X-Timing on every response
+}
+
+// BatchConfig limits POST /batch, which runs several API requests in one
+type BatchConfig struct {
+	MaxRequests int // Sub-requests accepted per batch
+	Concurrency int // Sub-requests run at once
+}
+
+// CachingConfig sets how long browsers and proxies may reuse responses.
+// Plans are always revalidated with their ETag; zero disables caching.
+type CachingConfig struct {
+	SearchTTL time.Duration // Search results, per caller
+	InfoTTL   time.Duration // API info and languages, which change on deploy
+}
+
+// ServerConfig controls the protocols the gateway accepts from clients and
+// the limits of its HTTP server
+type ServerConfig struct {
+	HTTP2             bool // Offer HTTP/2 over TLS
+	H2C               bool // Accept cleartext HTTP/2 when TLS is off
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration // Headers and body
+	WriteTimeout      time.Duration // From the end of the headers to the end of the response; 0 for none
+	IdleTimeout       time.Duration // Between requests on a kept-alive connection
+	MaxHeaderBytes    int
+	KeepAlives        bool
+	// TrustedProxies are the addresses and CIDRs of load balancers whose
+	// RemoteIPHeaders are believed for the client IP. With none, the
+	// client IP is the connection's peer.
+	TrustedProxies  []string
+	RemoteIPHeaders []string
+}
+
+// TLSConfig controls HTTPS termination by the gateway itself. Either a
+// certificate/key pair or autocert domains enable it.
+type TLSConfig struct {
+	CertFile        string
+	KeyFile         string
+	AutocertDomains []string // Domains to obtain Let's Encrypt certificates for
+	AutocertEmail   string
+	AutocertCache   string // Directory where issued certificates are stored
+	RedirectHTTP    bool   // Redirect plain HTTP to HTTPS
+	HTTPAddr        string // Listener for redirects and ACME challenges
+}
+
+// Enabled reports whether the gateway serves HTTPS
+func (t TLSConfig) Enabled() bool {
+	return t.Autocert() || (t.CertFile != "" && t.KeyFile != "")
+}
+
+// Autocert reports whether certificates are obtained automatically
+func (t TLSConfig) Autocert() bool {
+	return len(t.AutocertDomains) > 0
+}
+
+// UpstreamTLSConfig controls mutual TLS with the backends: the client
+// certificate the gateway presents and the CAs backend certificates must
+// chain to. Certificates and CAs are files, re-read every ReloadInterval so
+// they can be rotated without a restart, or PEM given inline.
+type UpstreamTLSConfig struct {
+	CertFile       string
+	KeyFile        string
+	CAFile         string            // CA for every backend; the system roots when empty
+	BackendCAFiles map[string]string // Pins a backend (rag, planner, quiz) to its own CA
+	ReloadInterval time.Duration
+}
+
+// Enabled reports whether the gateway presents a client certificate
+func (t UpstreamTLSConfig) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// InternalTokenConfig signs the identity the gateway sends the backends.
+// Every upstream call carries X-User-ID and X-Tenant-ID; with a Secret it
+// also carries X-Internal-Token, an HS256 JWT with the same claims, so
+// backends can trust them without trusting the network.
+type InternalTokenConfig struct {
+	Secret string
+	TTL    time.Duration // How long a token is valid
+}
+
+// SecretsConfig selects where credentials come from: the environment
+// (default), a directory of files, HashiCorp Vault or AWS Secrets Manager.
+// Secrets are named like their environment variables and refreshed every
+// RefreshInterval. The credentials to reach the store itself are only read
+// from the environment.
+type SecretsConfig struct {
+	Backend         string // env, file, vault or aws
+	Dir             string // file: one file per secret, e.g. /run/secrets
+	VaultAddr       string
+	VaultToken      string
+	VaultPath       string // KV path holding the secrets, e.g. secret/data/learnpath
+	AWSRegion       string
+	AWSAccessKey    string
+	AWSSecretKey    string
+	AWSSessionToken string
+	AWSSecretID     string // Secret whose JSON holds the secrets
+	Timeout         time.Duration
+	RefreshInterval time.Duration // 0 loads secrets once
+}
+
+// AllowAllOrigins reports whether the policy accepts any origin
+func (c CORSConfig) AllowAllOrigins() bool {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryFor returns the retry settings for a service, applying its overrides
+func (c *Config) RetryFor(service string) RetryConfig {
+	if override, ok := c.RetryOverrides[service]; ok {
+		return override.apply(c.Retry)
+	}
+	return c.Retry
+}
+
+func (o RetryOverride) apply(retry RetryConfig) RetryConfig {
+	if o.MaxAttempts != nil {
+		retry.MaxAttempts = *o.MaxAttempts
+	}
+	if o.BaseWait != nil {
+		retry.BaseWait = *o.BaseWait
+	}
+	if o.MaxWait != nil {
+		retry.MaxWait = *o.MaxWait
+	}
+	if o.Jitter != nil {
+		retry.Jitter = *o.Jitter
+	}
+	if o.RetryableMethods != nil {
+		retry.RetryableMethods = o.RetryableMethods
+	}
+	if o.RetryableStatusCodes != nil {
+		retry.RetryableStatusCodes = o.RetryableStatusCodes
+	}
+	return retry
+}
+
+// FeatureEnabled reports whether the named feature flag is switched on
+func (c *Config) FeatureEnabled(name string) bool {
+	return c.Features[name]
+}
+
+// Load loads configuration from the optional config file and environment
+// variables. Environment variables take precedence over the file.
 func Load() *Config {
+	cfg, err := load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Printf("Failed to load config file, using environment variables: %v", err)
+	}
+	return cfg
+}
+
+// load builds a Config from defaults, the config file at path (if any) and the
+// environment. On a file error the returned Config is still usable.
+func load(path string) (*Config, error) {
+	cfg := defaults()
+	cfg.ConfigFile = path
+
+	var fileErr error
+	if path != "" {
+		fileErr = applyFile(cfg, path)
+	}
+
+	applyEnv(cfg)
+
+	if cfg.CORS.AllowedOrigins == nil {
+		cfg.CORS.AllowedOrigins = defaultCORSOrigins(cfg.Environment)
+	}
+
+	cfg.RAGServiceURLs = splitReplicas(&cfg.RAGServiceURL)
+	cfg.PlannerServiceURLs = splitReplicas(&cfg.PlannerServiceURL)
+	cfg.QuizServiceURLs = splitReplicas(&cfg.QuizServiceURL)
+
+	return cfg, fileErr
+}
+
+func defaults() *Config {
 	return &Config{
-		Environment:       getEnv("ENVIRONMENT", "development"),
-		RAGServiceURL:     getEnv("RAG_SERVICE_URL", "http://localhost:8001"),
-		PlannerServiceURL: getEnv("PLANNER_SERVICE_URL", "http://localhost:8002"),
-		QuizServiceURL:    getEnv("QUIZ_SERVICE_URL", "http://localhost:8003"),
-		SupabaseURL:       getEnv("SUPABASE_URL", ""),
-		SupabaseAnonKey:   getEnv("SUPABASE_ANON_KEY", ""),
-		SupabaseJWTSecret: getEnv("SUPABASE_JWT_SECRET", ""),
+		Environment:       "development",
+		RAGServiceURL:     "http://localhost:8001",
+		PlannerServiceURL: "http://localhost:8002",
+		QuizServiceURL:    "http://localhost:8003",
+		LoadBalancing:     "round_robin",
+		CORS: CORSConfig{
+			MaxAge: 12 * time.Hour,
+		},
+		Timeouts: TimeoutConfig{
+			RAG:     10 * time.Second,
+			Planner: 2 * time.Minute, // Planner operations can be long-running
+			Quiz:    1 * time.Minute,
+		},
+		Deadlines: DeadlineConfig{
+			Routes: map[string]time.Duration{
+				RouteSearch: 15 * time.Second,
+				RoutePlan:   3 * time.Minute,
+				// Ingestion first, then the plan
+				RoutePlanFromContent: 4 * time.Minute,
+				RouteGoalDecompose:   1 * time.Minute,
+				RoutePlanImport:      3 * time.Minute,
+			},
+		},
+		Auth: AuthConfig{
+			Routes: map[string]bool{},
+		},
+		Ownership: OwnershipConfig{
+			Enabled: true,
+		},
+		Guests: GuestConfig{
+			TTL:        7 * 24 * time.Hour,
+			MaxPlans:   1,
+			MaxQuizzes: 1,
+		},
+		BodyLimits: BodyLimitConfig{
+			MaxBytes: 256 << 10,
+			Routes: map[string]int{
+				RouteSearch:        16 << 10,
+				RouteContentIngest: 2 << 20,
+				RouteContentUpload: 20 << 20,
+				RoutePlanImport:    5 << 20,
+			},
+			MaxJSONDepth: 16,
+		},
+		Moderation: ModerationConfig{
+			Timeout: 3 * time.Second,
+			Tenants: map[string]ModerationOverride{},
+		},
+		Ingestion: IngestionConfig{
+			AllowedSchemes:   []string{"http", "https"},
+			StripParams:      []string{"utm_*", "fbclid", "gclid", "dclid", "msclkid", "mc_cid", "mc_eid", "igshid", "ref", "ref_src"},
+			RespectRobotsTxt: true,
+		},
+		Retry: RetryConfig{
+			MaxAttempts:          3,
+			BaseWait:             500 * time.Millisecond,
+			MaxWait:              5 * time.Second,
+			Jitter:               0.5,
+			RetryableMethods:     []string{"GET", "HEAD", "OPTIONS", "PUT", "DELETE", "POST"},
+			RetryableStatusCodes: []int{500, 502, 503, 504},
+			BudgetRatio:          0.2,
+			BudgetBurst:          10,
+		},
+		RetryOverrides: map[string]RetryOverride{},
+		RateLimit: RateLimitConfig{
+			RequestsPerMinute: 60,
+			Burst:             10,
+		},
+		IPRateLimit: IPRateLimitConfig{
+			RequestsPerMinute: 120,
+			Allowlist:         []string{"127.0.0.0/8", "::1"},
+		},
+		Abuse: AbuseConfig{
+			Threshold:  50,
+			Window:     time.Minute,
+			Ban:        5 * time.Minute,
+			MaxBan:     24 * time.Hour,
+			OffenseTTL: 7 * 24 * time.Hour,
+		},
+		Deprecation: DeprecationConfig{
+			LogTTL: 24 * time.Hour,
+		},
+		Transport: TransportConfig{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 32,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+			TLSMinVersion:       "1.2",
+			HTTP2:               true,
+		},
+		Server: ServerConfig{
+			HTTP2:             true,
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       time.Minute,
+			WriteTimeout:      5 * time.Minute,
+			IdleTimeout:       2 * time.Minute,
+			MaxHeaderBytes:    1 << 20,
+			KeepAlives:        true,
+			RemoteIPHeaders:   []string{"X-Forwarded-For", "X-Real-IP"},
+		},
+		Health: HealthConfig{
+			PollInterval: 10 * time.Second,
+			ProbeTimeout: 2 * time.Second,
+			StaleAfter:   time.Minute,
+			VersionsTTL:  5 * time.Minute,
+		},
+		AccessLog: AccessLogConfig{
+			Sinks:         []string{"stdout"},
+			Format:        "text",
+			Timeout:       5 * time.Second,
+			BatchSize:     100,
+			FlushInterval: 2 * time.Second,
+			BufferSize:    10000,
+			Sampling:      map[string]float64{"/health": 0.01},
+		},
+		ErrorReporting: ErrorReportingConfig{
+			SampleRate: 1,
+			Timeout:    5 * time.Second,
+		},
+		Caching: CachingConfig{
+			SearchTTL: time.Minute,
+			InfoTTL:   5 * time.Minute,
+		},
+		Batch: BatchConfig{
+			MaxRequests: 20,
+			Concurrency: 4,
+		},
+		SlowRequests: SlowRequestConfig{
+			Threshold:          10 * time.Second,
+			LargeResponseBytes: 1 << 20,
+		},
+		Hedging: HedgingConfig{
+			RAGSearchDelay: 2 * time.Second,
+		},
+		Concurrency: ConcurrencyConfig{
+			MilestoneParallelism: 4,
+			PlansPerUser:         2,
+		},
+		Storage: StorageConfig{
+			Backend:        "memory",
+			RedisURL:       "redis://localhost:6379/0",
+			KeyPrefix:      "lpd:",
+			IdempotencyTTL: 24 * time.Hour,
+		},
+		Transcripts: TranscriptConfig{
+			Enabled:   true,
+			Languages: []string{"en"},
+			Timeout:   2 * time.Minute, // Whisper runs at about real time on CPU
+		},
+		Previews: PreviewConfig{
+			Timeout:  5 * time.Second,
+			CacheTTL: 24 * time.Hour,
+			MaxBytes: 1 << 20,
+		},
+		Durations: DurationConfig{
+			Enabled:        true,
+			WordsPerMinute: 230,
+		},
+		Costs: CostConfig{
+			Enabled:       true,
+			Budgets:       map[string]float64{},
+			TokenPriceUSD: 0.002,
+			Estimates: map[string]float64{
+				"/search":    0.0001, // Query embedding
+				"/ingest":    0.001,  // Chunk embeddings
+				"/plan":      0.01,
+				"/replan":    0.01,
+				"/decompose": 0.005, // One short LLM call
+				"/generate":  0.02,  // Quiz generation
+			},
+		},
+		Languages: LanguageConfig{
+			Default:   "en",
+			Supported: []string{"en", "es", "fr", "de", "it", "pt"},
+		},
+		Licenses: LicenseConfig{
+			Paid: []string{"proprietary", "paid", "subscription", "commercial"},
+		},
+		Diversity: DiversityConfig{
+			Tenants: map[string]DiversityOverride{},
+		},
+		Calibration: CalibrationConfig{
+			Enabled:    true,
+			MinAnswers: 10,
+			EasyAbove:  0.8,
+			HardBelow:  0.4,
+		},
+		Grading: GradingConfig{
+			PollInterval: 5 * time.Second,
+			BatchSize:    10,
+			MaxAttempts:  5,
+		},
+		QuizIntegrity: QuizIntegrityConfig{
+			Enabled:            true,
+			MinTimePerQuestion: 5 * time.Second,
+			SessionTTL:         7 * 24 * time.Hour,
+		},
+		Retakes: RetakeConfig{
+			MaxRetakes: 3,
+			Cooldown:   time.Hour,
+			Tenants:    map[string]RetakeOverride{},
+		},
+		Remediation: RemediationConfig{
+			ScoreThreshold:     70,
+			MaxReviewResources: 5,
+		},
+		Compensation: CompensationConfig{
+			Policy: "none",
+		},
+		Drafts: DraftConfig{
+			TTL: 24 * time.Hour,
+		},
+		Uploads: UploadConfig{
+			Backend:  "local",
+			Dir:      "uploads",
+			S3Region: "us-east-1",
+		},
+		Database: DatabaseConfig{
+			Driver:       "pgx",
+			MaxOpenConns: 10,
+		},
+		Notifications: NotificationsConfig{
+			Timeout:   10 * time.Second,
+			TenantTTL: 30 * 24 * time.Hour,
+		},
+		Hooks: HooksConfig{
+			MaxPerUser: 20,
+			Timeout:    10 * time.Second,
+		},
+		Branding: BrandingConfig{
+			PrimaryColor: "#2680c2",
+			AccentColor:  "#3ebd93",
+			SenderName:   "Learning Path Designer",
+		},
+		Focus: FocusConfig{
+			DefaultMinutes:   25,
+			IdleThreshold:    2 * time.Minute,
+			HeartbeatTimeout: 5 * time.Minute,
+			MaxMinutes:       240,
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:   true,
+			MaxJitter: 30 * time.Second,
+			Jobs: map[string]JobConfig{
+				JobHealthPoll:         {Enabled: true, Schedule: "@every 1m"},
+				JobFeedReingest:       {Schedule: "0 3 * * *"},
+				JobDeadLinkCheck:      {Schedule: "0 4 * * 0"},
+				JobCacheWarmup:        {Schedule: "@every 30m"},
+				JobWeeklyDigest:       {Enabled: true, Schedule: "0 8 * * 1"},
+				JobMilestoneReminders: {Enabled: true, Schedule: "0 9 * * 1-5"},
+			},
+		},
+		Seed: SeedConfig{
+			UserID: "demo-user",
+			Goal:   "Learn Go for backend development",
+			URLs: []string{
+				"https://go.dev/tour/welcome/1",
+				"https://go.dev/doc/effective_go",
+				"https://gobyexample.com/",
+				"https://go.dev/blog/pipelines",
+				"https://developer.mozilla.org/en-US/docs/Web/HTTP/Overview",
+				"https://www.postgresql.org/docs/current/tutorial.html",
+			},
+		},
+		Events: EventsConfig{
+			SubjectPrefix: "learnpath.",
+			PollInterval:  time.Second,
+			BatchSize:     100,
+		},
+		Admission: AdmissionConfig{
+			MaxInFlight:       256,
+			MaxQueue:          512,
+			QueueTimeout:      5 * time.Second,
+			SaturationCooloff: 10 * time.Second,
+			RetryAfter:        5 * time.Second,
+		},
+		CircuitBreaker: CircuitBreakerConfig{
+			FailureThreshold: 5,
+			OpenTimeout:      30 * time.Second,
+		},
+		Discovery: DiscoveryConfig{
+			Mode:            "static",
+			Scheme:          "http",
+			RefreshInterval: 30 * time.Second,
+			ConsulAddr:      "http://localhost:8500",
+			RAGService:      "rag-service",
+			PlannerService:  "planner-service",
+			QuizService:     "quiz-service",
+		},
+		Capture: CaptureConfig{
+			MaxRequests:  500,
+			MaxBodyBytes: 16 << 10,
+		},
+		Mirror: MirrorConfig{
+			Paths:   []string{"/api/search", "/api/plan"},
+			Timeout: 2 * time.Minute,
+		},
+		TLS: TLSConfig{
+			AutocertCache: "certs",
+			RedirectHTTP:  true,
+			HTTPAddr:      ":80",
+		},
+		Secrets: SecretsConfig{
+			Backend:         "env",
+			Dir:             "/run/secrets",
+			Timeout:         10 * time.Second,
+			RefreshInterval: 5 * time.Minute,
+		},
+		UpstreamTLS: UpstreamTLSConfig{
+			BackendCAFiles: map[string]string{},
+			ReloadInterval: time.Minute,
+		},
+		InternalToken: InternalTokenConfig{
+			TTL: time.Minute,
+		},
+		Features: map[string]bool{},
+	}
+}
+
+func applyEnv(cfg *Config) {
+	cfg.Environment = getEnv("ENVIRONMENT", cfg.Environment)
+	cfg.RAGServiceURL = getEnv("RAG_SERVICE_URL", cfg.RAGServiceURL)
+	cfg.PlannerServiceURL = getEnv("PLANNER_SERVICE_URL", cfg.PlannerServiceURL)
+	cfg.QuizServiceURL = getEnv("QUIZ_SERVICE_URL", cfg.QuizServiceURL)
+	cfg.LoadBalancing = getEnv("LB_STRATEGY", cfg.LoadBalancing)
+	cfg.RAGFallbackURL = getEnv("RAG_FALLBACK_URL", cfg.RAGFallbackURL)
+	cfg.PlannerFallbackURL = getEnv("PLANNER_FALLBACK_URL", cfg.PlannerFallbackURL)
+	cfg.QuizFallbackURL = getEnv("QUIZ_FALLBACK_URL", cfg.QuizFallbackURL)
+	cfg.CircuitBreaker.FailureThreshold = getEnvInt("CIRCUIT_BREAKER_THRESHOLD", cfg.CircuitBreaker.FailureThreshold)
+	cfg.CircuitBreaker.OpenTimeout = getEnvDuration("CIRCUIT_BREAKER_OPEN_TIMEOUT", cfg.CircuitBreaker.OpenTimeout)
+	cfg.SupabaseURL = getEnv("SUPABASE_URL", cfg.SupabaseURL)
+	cfg.SupabaseAnonKey = getEnv("SUPABASE_ANON_KEY", cfg.SupabaseAnonKey)
+	cfg.SupabaseJWTSecret = getEnv("SUPABASE_JWT_SECRET", cfg.SupabaseJWTSecret)
+	cfg.AdminToken = getEnv("ADMIN_TOKEN", cfg.AdminToken)
+	cfg.MaintenanceMessage = getEnv("MAINTENANCE_MESSAGE", cfg.MaintenanceMessage)
+
+	cfg.CORS.AllowedOrigins = getEnvList("CORS_ALLOWED_ORIGINS", cfg.CORS.AllowedOrigins)
+	cfg.CORS.AllowCredentials = getEnvBool("CORS_ALLOW_CREDENTIALS", cfg.CORS.AllowCredentials)
+	cfg.CORS.MaxAge = getEnvDuration("CORS_MAX_AGE", cfg.CORS.MaxAge)
+
+	cfg.Timeouts.RAG = getEnvDuration("RAG_TIMEOUT", cfg.Timeouts.RAG)
+	cfg.Timeouts.Planner = getEnvDuration("PLANNER_TIMEOUT", cfg.Timeouts.Planner)
+	cfg.Timeouts.Quiz = getEnvDuration("QUIZ_TIMEOUT", cfg.Timeouts.Quiz)
+
+	cfg.Deadlines.Default = getEnvDuration("REQUEST_DEADLINE", cfg.Deadlines.Default)
+	// REQUEST_DEADLINE_<ROUTE>, e.g. REQUEST_DEADLINE_PLAN
+	for _, route := range routes {
+		if budget := getEnvDuration("REQUEST_DEADLINE_"+strings.ToUpper(route), -1); budget >= 0 {
+			cfg.Deadlines.Routes[route] = budget
+		}
+	}
+
+	cfg.BodyLimits.MaxBytes = getEnvInt("BODY_MAX_BYTES", cfg.BodyLimits.MaxBytes)
+	// BODY_MAX_BYTES_<ROUTE>, e.g. BODY_MAX_BYTES_CONTENT_INGEST
+	for _, route := range routes {
+		if limit := getEnvInt("BODY_MAX_BYTES_"+strings.ToUpper(route), 0); limit > 0 {
+			cfg.BodyLimits.Routes[route] = limit
+		}
+	}
+	cfg.BodyLimits.MaxJSONDepth = getEnvInt("JSON_MAX_DEPTH", cfg.BodyLimits.MaxJSONDepth)
+
+	cfg.Auth.Required = getEnvBool("AUTH_REQUIRED", cfg.Auth.Required)
+	cfg.Auth.AnonymousMode = getEnvBool("AUTH_ANONYMOUS_MODE", cfg.Auth.AnonymousMode)
+	// AUTH_REQUIRED_<ROUTE>, e.g. AUTH_REQUIRED_PLAN
+	for _, route := range routes {
+		key := "AUTH_REQUIRED_" + strings.ToUpper(route)
+		if _, ok := os.LookupEnv(key); ok {
+			cfg.Auth.Routes[route] = getEnvBool(key, cfg.Auth.For(route))
+		}
+	}
+
+	cfg.Ownership.Enabled = getEnvBool("OWNERSHIP_ENFORCED", cfg.Ownership.Enabled)
+	cfg.Ownership.Retention = getEnvDuration("OWNERSHIP_RETENTION", cfg.Ownership.Retention)
+	cfg.Guests.Enabled = getEnvBool("GUEST_SESSIONS_ENABLED", cfg.Guests.Enabled)
+	cfg.Guests.Secret = getEnv("GUEST_SESSION_SECRET", cfg.Guests.Secret)
+	cfg.Guests.TTL = getEnvDuration("GUEST_SESSION_TTL", cfg.Guests.TTL)
+	cfg.Guests.MaxPlans = getEnvInt("GUEST_MAX_PLANS", cfg.Guests.MaxPlans)
+	cfg.Guests.MaxQuizzes = getEnvInt("GUEST_MAX_QUIZZES", cfg.Guests.MaxQuizzes)
+
+	cfg.Moderation.Enabled = getEnvBool("MODERATION_ENABLED", cfg.Moderation.Enabled)
+	cfg.Moderation.BlockedTerms = getEnvList("MODERATION_BLOCKED_TERMS", cfg.Moderation.BlockedTerms)
+	cfg.Moderation.BlockedDomains = getEnvList("MODERATION_BLOCKED_DOMAINS", cfg.Moderation.BlockedDomains)
+	cfg.Moderation.APIURL = getEnv("MODERATION_API_URL", cfg.Moderation.APIURL)
+	cfg.Moderation.APIKey = getEnv("MODERATION_API_KEY", cfg.Moderation.APIKey)
+	cfg.Moderation.Timeout = getEnvDuration("MODERATION_TIMEOUT", cfg.Moderation.Timeout)
+	cfg.Moderation.FailClosed = getEnvBool("MODERATION_FAIL_CLOSED", cfg.Moderation.FailClosed)
+
+	cfg.Ingestion.AllowedSchemes = getEnvList("INGEST_ALLOWED_SCHEMES", cfg.Ingestion.AllowedSchemes)
+	cfg.Ingestion.StripParams = getEnvList("INGEST_STRIP_PARAMS", cfg.Ingestion.StripParams)
+	cfg.Ingestion.RespectRobotsTxt = getEnvBool("INGEST_RESPECT_ROBOTS_TXT", cfg.Ingestion.RespectRobotsTxt)
+
+	cfg.Retry.MaxAttempts = getEnvInt("RETRY_MAX_ATTEMPTS", cfg.Retry.MaxAttempts)
+	cfg.Retry.BaseWait = getEnvDuration("RETRY_BASE_WAIT", cfg.Retry.BaseWait)
+	cfg.Retry.MaxWait = getEnvDuration("RETRY_MAX_WAIT", cfg.Retry.MaxWait)
+	cfg.Retry.Jitter = getEnvFloat("RETRY_JITTER", cfg.Retry.Jitter)
+	cfg.Retry.RetryableMethods = getEnvList("RETRY_METHODS", cfg.Retry.RetryableMethods)
+	cfg.Retry.RetryableStatusCodes = getEnvIntList("RETRY_STATUS_CODES", cfg.Retry.RetryableStatusCodes)
+	cfg.Retry.BudgetRatio = getEnvFloat("RETRY_BUDGET_RATIO", cfg.Retry.BudgetRatio)
+	cfg.Retry.BudgetBurst = getEnvInt("RETRY_BUDGET_BURST", cfg.Retry.BudgetBurst)
+
+	cfg.RateLimit.RequestsPerMinute = getEnvInt("RATE_LIMIT_RPM", cfg.RateLimit.RequestsPerMinute)
+	cfg.RateLimit.Burst = getEnvInt("RATE_LIMIT_BURST", cfg.RateLimit.Burst)
+	cfg.IPRateLimit.RequestsPerMinute = getEnvInt("IP_RATE_LIMIT_RPM", cfg.IPRateLimit.RequestsPerMinute)
+	cfg.IPRateLimit.Allowlist = getEnvList("IP_RATE_LIMIT_ALLOWLIST", cfg.IPRateLimit.Allowlist)
+	cfg.Abuse.Threshold = getEnvInt("ABUSE_THRESHOLD", cfg.Abuse.Threshold)
+	cfg.Abuse.Window = getEnvDuration("ABUSE_WINDOW", cfg.Abuse.Window)
+	cfg.Abuse.Ban = getEnvDuration("ABUSE_BAN", cfg.Abuse.Ban)
+	cfg.Abuse.MaxBan = getEnvDuration("ABUSE_MAX_BAN", cfg.Abuse.MaxBan)
+	cfg.Abuse.OffenseTTL = getEnvDuration("ABUSE_OFFENSE_TTL", cfg.Abuse.OffenseTTL)
+
+	cfg.Deprecation.LogTTL = getEnvDuration("DEPRECATION_LOG_TTL", cfg.Deprecation.LogTTL)
+	// Shorthand for deprecating the whole /api alias in favour of /api/v1
+	since, sunset := getEnvDate("LEGACY_API_DEPRECATED_AT"), getEnvDate("LEGACY_API_SUNSET")
+	if !since.IsZero() || !sunset.IsZero() {
+		cfg.Deprecation.Rules = append(cfg.Deprecation.Rules, DeprecationRule{
+			Path:    "*",
+			Version: DeprecatedLegacyAPI,
+			Since:   since,
+			Sunset:  sunset,
+			Link:    getEnv("LEGACY_API_DEPRECATION_LINK", ""),
+		})
+	}
+
+	cfg.JSONAPI.Tenants = getEnvList("JSONAPI_TENANTS", cfg.JSONAPI.Tenants)
+
+	cfg.Transport.MaxIdleConns = getEnvInt("HTTP_MAX_IDLE_CONNS", cfg.Transport.MaxIdleConns)
+	cfg.Transport.MaxIdleConnsPerHost = getEnvInt("HTTP_MAX_IDLE_CONNS_PER_HOST", cfg.Transport.MaxIdleConnsPerHost)
+	cfg.Transport.MaxConnsPerHost = getEnvInt("HTTP_MAX_CONNS_PER_HOST", cfg.Transport.MaxConnsPerHost)
+	cfg.Transport.IdleConnTimeout = getEnvDuration("HTTP_IDLE_CONN_TIMEOUT", cfg.Transport.IdleConnTimeout)
+	cfg.Transport.DisableKeepAlives = getEnvBool("HTTP_DISABLE_KEEPALIVES", cfg.Transport.DisableKeepAlives)
+	cfg.Transport.TLSHandshakeTimeout = getEnvDuration("HTTP_TLS_HANDSHAKE_TIMEOUT", cfg.Transport.TLSHandshakeTimeout)
+	cfg.Transport.TLSInsecureSkipVerify = getEnvBool("HTTP_TLS_INSECURE_SKIP_VERIFY", cfg.Transport.TLSInsecureSkipVerify)
+	cfg.Transport.TLSMinVersion = getEnv("HTTP_TLS_MIN_VERSION", cfg.Transport.TLSMinVersion)
+	cfg.Transport.HTTP2 = getEnvBool("HTTP_UPSTREAM_HTTP2", cfg.Transport.HTTP2)
+	cfg.Transport.H2C = getEnvBool("HTTP_UPSTREAM_H2C", cfg.Transport.H2C)
+
+	cfg.Server.HTTP2 = getEnvBool("SERVER_HTTP2", cfg.Server.HTTP2)
+	cfg.Server.H2C = getEnvBool("SERVER_H2C", cfg.Server.H2C)
+	cfg.Server.ReadHeaderTimeout = getEnvDuration("SERVER_READ_HEADER_TIMEOUT", cfg.Server.ReadHeaderTimeout)
+	cfg.Server.ReadTimeout = getEnvDuration("SERVER_READ_TIMEOUT", cfg.Server.ReadTimeout)
+	cfg.Server.WriteTimeout = getEnvDuration("SERVER_WRITE_TIMEOUT", cfg.Server.WriteTimeout)
+	cfg.Server.IdleTimeout = getEnvDuration("SERVER_IDLE_TIMEOUT", cfg.Server.IdleTimeout)
+	cfg.Server.MaxHeaderBytes = getEnvInt("SERVER_MAX_HEADER_BYTES", cfg.Server.MaxHeaderBytes)
+	cfg.Server.KeepAlives = getEnvBool("SERVER_KEEPALIVES", cfg.Server.KeepAlives)
+	cfg.Server.TrustedProxies = getEnvList("TRUSTED_PROXIES", cfg.Server.TrustedProxies)
+	cfg.Server.RemoteIPHeaders = getEnvList("REMOTE_IP_HEADERS", cfg.Server.RemoteIPHeaders)
+
+	cfg.Health.PollInterval = getEnvDuration("HEALTH_POLL_INTERVAL", cfg.Health.PollInterval)
+	cfg.Health.ProbeTimeout = getEnvDuration("HEALTH_PROBE_TIMEOUT", cfg.Health.ProbeTimeout)
+	cfg.Health.StaleAfter = getEnvDuration("HEALTH_STALE_AFTER", cfg.Health.StaleAfter)
+	cfg.Health.VersionsTTL = getEnvDuration("BACKEND_VERSIONS_TTL", cfg.Health.VersionsTTL)
+
+	cfg.AccessLog.Sinks = getEnvList("ACCESS_LOG_SINKS", cfg.AccessLog.Sinks)
+	cfg.AccessLog.Format = getEnv("ACCESS_LOG_FORMAT", cfg.AccessLog.Format)
+	cfg.AccessLog.File = getEnv("ACCESS_LOG_FILE", cfg.AccessLog.File)
+	cfg.AccessLog.LokiURL = getEnv("ACCESS_LOG_LOKI_URL", cfg.AccessLog.LokiURL)
+	cfg.AccessLog.HTTPURL = getEnv("ACCESS_LOG_HTTP_URL", cfg.AccessLog.HTTPURL)
+	cfg.AccessLog.Timeout = getEnvDuration("ACCESS_LOG_TIMEOUT", cfg.AccessLog.Timeout)
+	cfg.AccessLog.BatchSize = getEnvInt("ACCESS_LOG_BATCH_SIZE", cfg.AccessLog.BatchSize)
+	cfg.AccessLog.FlushInterval = getEnvDuration("ACCESS_LOG_FLUSH_INTERVAL", cfg.AccessLog.FlushInterval)
+	cfg.AccessLog.BufferSize = getEnvInt("ACCESS_LOG_BUFFER_SIZE", cfg.AccessLog.BufferSize)
+
+	cfg.ErrorReporting.DSN = getEnv("SENTRY_DSN", cfg.ErrorReporting.DSN)
+	cfg.ErrorReporting.Environment = getEnv("SENTRY_ENVIRONMENT", cfg.ErrorReporting.Environment)
+	cfg.ErrorReporting.Release = getEnv("SENTRY_RELEASE", cfg.ErrorReporting.Release)
+	cfg.ErrorReporting.SampleRate = getEnvFloat("SENTRY_SAMPLE_RATE", cfg.ErrorReporting.SampleRate)
+	cfg.ErrorReporting.Timeout = getEnvDuration("SENTRY_TIMEOUT", cfg.ErrorReporting.Timeout)
+
+	cfg.SlowRequests.Threshold = getEnvDuration("SLOW_REQUEST_THRESHOLD", cfg.SlowRequests.Threshold)
+	cfg.SlowRequests.LargeResponseBytes = getEnvInt("LARGE_RESPONSE_BYTES", cfg.SlowRequests.LargeResponseBytes)
+	cfg.Timing.Header = getEnvBool("TIMING_HEADER", cfg.Timing.Header)
+
+	cfg.Batch.MaxRequests = getEnvInt("BATCH_MAX_REQUESTS", cfg.Batch.MaxRequests)
+	cfg.Batch.Concurrency = getEnvInt("BATCH_CONCURRENCY", cfg.Batch.Concurrency)
+
+	cfg.Caching.SearchTTL = getEnvDuration("CACHE_SEARCH_TTL", cfg.Caching.SearchTTL)
+	cfg.Caching.InfoTTL = getEnvDuration("CACHE_INFO_TTL", cfg.Caching.InfoTTL)
+
+	cfg.Hedging.RAGSearchDelay = getEnvDuration("RAG_SEARCH_HEDGE_DELAY", cfg.Hedging.RAGSearchDelay)
+	cfg.Concurrency.MilestoneParallelism = getEnvInt("MILESTONE_PARALLELISM", cfg.Concurrency.MilestoneParallelism)
+	cfg.Concurrency.PlansPerUser = getEnvInt("MAX_CONCURRENT_PLANS_PER_USER", cfg.Concurrency.PlansPerUser)
+
+	cfg.Storage.Backend = getEnv("STORAGE_BACKEND", cfg.Storage.Backend)
+	cfg.Storage.RedisURL = getEnv("REDIS_URL", cfg.Storage.RedisURL)
+	cfg.Storage.KeyPrefix = getEnv("STORAGE_KEY_PREFIX", cfg.Storage.KeyPrefix)
+	cfg.Storage.IdempotencyTTL = getEnvDuration("IDEMPOTENCY_TTL", cfg.Storage.IdempotencyTTL)
+
+	cfg.Transcripts.Enabled = getEnvBool("TRANSCRIPTS_ENABLED", cfg.Transcripts.Enabled)
+	cfg.Transcripts.Languages = getEnvList("TRANSCRIPT_LANGUAGES", cfg.Transcripts.Languages)
+	cfg.Transcripts.WhisperURL = getEnv("WHISPER_URL", cfg.Transcripts.WhisperURL)
+	cfg.Transcripts.Timeout = getEnvDuration("TRANSCRIPT_TIMEOUT", cfg.Transcripts.Timeout)
+
+	cfg.Previews.Timeout = getEnvDuration("PREVIEW_TIMEOUT", cfg.Previews.Timeout)
+	cfg.Previews.CacheTTL = getEnvDuration("PREVIEW_CACHE_TTL", cfg.Previews.CacheTTL)
+	cfg.Previews.MaxBytes = getEnvInt("PREVIEW_MAX_BYTES", cfg.Previews.MaxBytes)
+	cfg.Previews.AllowPrivate = getEnvBool("PREVIEW_ALLOW_PRIVATE", cfg.Previews.AllowPrivate)
+
+	cfg.Durations.Enabled = getEnvBool("DURATION_ESTIMATION_ENABLED", cfg.Durations.Enabled)
+	cfg.Durations.WordsPerMinute = getEnvInt("READING_WORDS_PER_MINUTE", cfg.Durations.WordsPerMinute)
+
+	cfg.Costs.Enabled = getEnvBool("COST_TRACKING_ENABLED", cfg.Costs.Enabled)
+	cfg.Costs.MonthlyBudgetUSD = getEnvFloat("COST_MONTHLY_BUDGET_USD", cfg.Costs.MonthlyBudgetUSD)
+	cfg.Costs.TokenPriceUSD = getEnvFloat("COST_TOKEN_PRICE_USD", cfg.Costs.TokenPriceUSD)
+
+	cfg.Languages.Default = getEnv("DEFAULT_LANGUAGE", cfg.Languages.Default)
+	cfg.Languages.Supported = getEnvList("SUPPORTED_LANGUAGES", cfg.Languages.Supported)
+
+	cfg.Licenses.Paid = getEnvList("PAID_LICENSES", cfg.Licenses.Paid)
+
+	cfg.Diversity.MaxPerProvider = getEnvInt("DIVERSITY_MAX_PER_PROVIDER", cfg.Diversity.MaxPerProvider)
+	cfg.Diversity.MaxPerMediaType = getEnvInt("DIVERSITY_MAX_PER_MEDIA_TYPE", cfg.Diversity.MaxPerMediaType)
+
+	cfg.Calibration.Enabled = getEnvBool("CALIBRATION_ENABLED", cfg.Calibration.Enabled)
+	cfg.Calibration.MinAnswers = getEnvInt("CALIBRATION_MIN_ANSWERS", cfg.Calibration.MinAnswers)
+	cfg.Calibration.EasyAbove = getEnvFloat("CALIBRATION_EASY_ABOVE", cfg.Calibration.EasyAbove)
+	cfg.Calibration.HardBelow = getEnvFloat("CALIBRATION_HARD_BELOW", cfg.Calibration.HardBelow)
+	cfg.Grading.PollInterval = getEnvDuration("GRADING_POLL_INTERVAL", cfg.Grading.PollInterval)
+	cfg.Grading.BatchSize = getEnvInt("GRADING_BATCH_SIZE", cfg.Grading.BatchSize)
+	cfg.Grading.MaxAttempts = getEnvInt("GRADING_MAX_ATTEMPTS", cfg.Grading.MaxAttempts)
+	cfg.QuizIntegrity.Enabled = getEnvBool("QUIZ_INTEGRITY_ENABLED", cfg.QuizIntegrity.Enabled)
+	cfg.QuizIntegrity.AllowRetakes = getEnvBool("QUIZ_ALLOW_RETAKES", cfg.QuizIntegrity.AllowRetakes)
+	cfg.QuizIntegrity.MinTimePerQuestion = getEnvDuration("QUIZ_MIN_TIME_PER_QUESTION", cfg.QuizIntegrity.MinTimePerQuestion)
+	cfg.QuizIntegrity.SessionTTL = getEnvDuration("QUIZ_SESSION_TTL", cfg.QuizIntegrity.SessionTTL)
+	cfg.Retakes.MaxRetakes = getEnvInt("QUIZ_MAX_RETAKES", cfg.Retakes.MaxRetakes)
+	cfg.Retakes.Cooldown = getEnvDuration("QUIZ_RETAKE_COOLDOWN", cfg.Retakes.Cooldown)
+	cfg.Remediation.ScoreThreshold = getEnvFloat("REMEDIATION_SCORE_THRESHOLD", cfg.Remediation.ScoreThreshold)
+	cfg.Remediation.MaxReviewResources = getEnvInt("REMEDIATION_MAX_REVIEW_RESOURCES", cfg.Remediation.MaxReviewResources)
+	cfg.Compensation.Policy = getEnv("PLAN_COMPENSATION", cfg.Compensation.Policy)
+	cfg.Drafts.TTL = getEnvDuration("PLAN_DRAFT_TTL", cfg.Drafts.TTL)
+
+	cfg.Uploads.Backend = getEnv("UPLOAD_STORAGE", cfg.Uploads.Backend)
+	cfg.Uploads.Dir = getEnv("UPLOAD_DIR", cfg.Uploads.Dir)
+	cfg.Uploads.S3Bucket = getEnv("UPLOAD_S3_BUCKET", cfg.Uploads.S3Bucket)
+	cfg.Uploads.S3Region = getEnv("AWS_REGION", cfg.Uploads.S3Region)
+	cfg.Uploads.S3Endpoint = getEnv("UPLOAD_S3_ENDPOINT", cfg.Uploads.S3Endpoint)
+	cfg.Uploads.S3AccessKey = getEnv("AWS_ACCESS_KEY_ID", cfg.Uploads.S3AccessKey)
+	cfg.Uploads.S3SecretKey = getEnv("AWS_SECRET_ACCESS_KEY", cfg.Uploads.S3SecretKey)
+
+	cfg.Database.URL = getEnv("GATEWAY_DATABASE_URL", cfg.Database.URL)
+	cfg.Database.Driver = getEnv("GATEWAY_DATABASE_DRIVER", cfg.Database.Driver)
+	cfg.Database.MaxOpenConns = getEnvInt("GATEWAY_DATABASE_MAX_CONNS", cfg.Database.MaxOpenConns)
+
+	cfg.Events.Backend = getEnv("EVENTS_BACKEND", cfg.Events.Backend)
+	cfg.Events.URL = getEnv("EVENTS_URL", cfg.Events.URL)
+	cfg.Events.SubjectPrefix = getEnv("EVENTS_SUBJECT_PREFIX", cfg.Events.SubjectPrefix)
+	cfg.Events.PollInterval = getEnvDuration("EVENTS_POLL_INTERVAL", cfg.Events.PollInterval)
+	cfg.Events.BatchSize = getEnvInt("EVENTS_BATCH_SIZE", cfg.Events.BatchSize)
+
+	cfg.Notifications.AppURL = getEnv("NOTIFY_APP_URL", cfg.Notifications.AppURL)
+	cfg.Notifications.Timeout = getEnvDuration("NOTIFY_TIMEOUT", cfg.Notifications.Timeout)
+	cfg.Notifications.TenantTTL = getEnvDuration("NOTIFY_TENANT_TTL", cfg.Notifications.TenantTTL)
+	// Shorthands for a channel receiving everything
+	for _, channel := range []struct{ kind, env string }{{NotifyTeams, "TEAMS_WEBHOOK_URL"}, {NotifySlack, "SLACK_WEBHOOK_URL"}} {
+		if url := getEnv(channel.env, ""); url != "" {
+			cfg.Notifications.Channels = append(cfg.Notifications.Channels, NotificationChannel{Type: channel.kind, URL: url})
+		}
+	}
+
+	cfg.Hooks.MaxPerUser = getEnvInt("HOOKS_MAX_PER_USER", cfg.Hooks.MaxPerUser)
+	cfg.Hooks.Timeout = getEnvDuration("HOOKS_TIMEOUT", cfg.Hooks.Timeout)
+	cfg.Hooks.AllowPrivate = getEnvBool("HOOKS_ALLOW_PRIVATE", cfg.Hooks.AllowPrivate)
+
+	cfg.Branding.LogoURL = getEnv("BRAND_LOGO_URL", cfg.Branding.LogoURL)
+	cfg.Branding.PrimaryColor = getEnv("BRAND_PRIMARY_COLOR", cfg.Branding.PrimaryColor)
+	cfg.Branding.AccentColor = getEnv("BRAND_ACCENT_COLOR", cfg.Branding.AccentColor)
+	cfg.Branding.SenderName = getEnv("BRAND_SENDER_NAME", cfg.Branding.SenderName)
+
+	cfg.Focus.DefaultMinutes = getEnvInt("FOCUS_DEFAULT_MINUTES", cfg.Focus.DefaultMinutes)
+	cfg.Focus.IdleThreshold = getEnvDuration("FOCUS_IDLE_THRESHOLD", cfg.Focus.IdleThreshold)
+	cfg.Focus.HeartbeatTimeout = getEnvDuration("FOCUS_HEARTBEAT_TIMEOUT", cfg.Focus.HeartbeatTimeout)
+	cfg.Focus.MaxMinutes = getEnvInt("FOCUS_MAX_MINUTES", cfg.Focus.MaxMinutes)
+
+	cfg.Scheduler.Enabled = getEnvBool("SCHEDULER_ENABLED", cfg.Scheduler.Enabled)
+	cfg.Scheduler.MaxJitter = getEnvDuration("SCHEDULER_MAX_JITTER", cfg.Scheduler.MaxJitter)
+	cfg.Scheduler.Feeds = getEnvList("SCHEDULER_FEEDS", cfg.Scheduler.Feeds)
+	cfg.Scheduler.WarmupQueries = getEnvList("SCHEDULER_WARMUP_QUERIES", cfg.Scheduler.WarmupQueries)
+	// JOB_<NAME>_ENABLED and JOB_<NAME>_SCHEDULE, e.g. JOB_CACHE_WARMUP_ENABLED
+	for name, job := range cfg.Scheduler.Jobs {
+		prefix := "JOB_" + strings.ToUpper(name) + "_"
+		job.Enabled = getEnvBool(prefix+"ENABLED", job.Enabled)
+		job.Schedule = getEnv(prefix+"SCHEDULE", job.Schedule)
+		cfg.Scheduler.Jobs[name] = job
+	}
+
+	cfg.Seed.OnStartup = getEnvBool("SEED_ON_STARTUP", cfg.Seed.OnStartup)
+	cfg.Seed.UserID = getEnv("SEED_USER_ID", cfg.Seed.UserID)
+	cfg.Seed.Goal = getEnv("SEED_GOAL", cfg.Seed.Goal)
+	cfg.Seed.URLs = getEnvList("SEED_URLS", cfg.Seed.URLs)
+
+	cfg.Admission.MaxInFlight = getEnvInt("ADMISSION_MAX_IN_FLIGHT", cfg.Admission.MaxInFlight)
+	cfg.Admission.MaxQueue = getEnvInt("ADMISSION_MAX_QUEUE", cfg.Admission.MaxQueue)
+	cfg.Admission.QueueTimeout = getEnvDuration("ADMISSION_QUEUE_TIMEOUT", cfg.Admission.QueueTimeout)
+	cfg.Admission.SaturationCooloff = getEnvDuration("ADMISSION_SATURATION_COOLOFF", cfg.Admission.SaturationCooloff)
+	cfg.Admission.RetryAfter = getEnvDuration("ADMISSION_RETRY_AFTER", cfg.Admission.RetryAfter)
+
+	cfg.Discovery.Mode = getEnv("DISCOVERY_MODE", cfg.Discovery.Mode)
+	cfg.Discovery.Scheme = getEnv("DISCOVERY_SCHEME", cfg.Discovery.Scheme)
+	cfg.Discovery.RefreshInterval = getEnvDuration("DISCOVERY_REFRESH_INTERVAL", cfg.Discovery.RefreshInterval)
+	cfg.Discovery.ConsulAddr = getEnv("CONSUL_HTTP_ADDR", cfg.Discovery.ConsulAddr)
+	cfg.Discovery.KubeNamespace = getEnv("KUBERNETES_NAMESPACE", cfg.Discovery.KubeNamespace)
+	cfg.Discovery.RAGService = getEnv("DISCOVERY_RAG_SERVICE", cfg.Discovery.RAGService)
+	cfg.Discovery.PlannerService = getEnv("DISCOVERY_PLANNER_SERVICE", cfg.Discovery.PlannerService)
+	cfg.Discovery.QuizService = getEnv("DISCOVERY_QUIZ_SERVICE", cfg.Discovery.QuizService)
+
+	cfg.Mirror.TargetURL = getEnv("MIRROR_TARGET_URL", cfg.Mirror.TargetURL)
+	cfg.Mirror.Percent = getEnvFloat("MIRROR_PERCENT", cfg.Mirror.Percent)
+	cfg.Mirror.Paths = getEnvList("MIRROR_PATHS", cfg.Mirror.Paths)
+	cfg.Mirror.Timeout = getEnvDuration("MIRROR_TIMEOUT", cfg.Mirror.Timeout)
+
+	cfg.Capture.Enabled = getEnvBool("CAPTURE_ENABLED", cfg.Capture.Enabled)
+	cfg.Capture.MaxRequests = getEnvInt("CAPTURE_MAX_REQUESTS", cfg.Capture.MaxRequests)
+	cfg.Capture.MaxBodyBytes = getEnvInt("CAPTURE_MAX_BODY_BYTES", cfg.Capture.MaxBodyBytes)
+
+	cfg.Canary.PlannerURL = getEnv("CANARY_PLANNER_URL", cfg.Canary.PlannerURL)
+	cfg.Canary.QuizURL = getEnv("CANARY_QUIZ_URL", cfg.Canary.QuizURL)
+	cfg.Canary.Percent = getEnvFloat("CANARY_PERCENT", cfg.Canary.Percent)
+	cfg.Canary.Tenants = getEnvList("CANARY_TENANTS", cfg.Canary.Tenants)
+
+	cfg.TLS.CertFile = getEnv("TLS_CERT_FILE", cfg.TLS.CertFile)
+	cfg.TLS.KeyFile = getEnv("TLS_KEY_FILE", cfg.TLS.KeyFile)
+	cfg.TLS.AutocertDomains = getEnvList("TLS_AUTOCERT_DOMAINS", cfg.TLS.AutocertDomains)
+	cfg.TLS.AutocertEmail = getEnv("TLS_AUTOCERT_EMAIL", cfg.TLS.AutocertEmail)
+	cfg.TLS.AutocertCache = getEnv("TLS_AUTOCERT_CACHE", cfg.TLS.AutocertCache)
+	cfg.TLS.RedirectHTTP = getEnvBool("TLS_REDIRECT_HTTP", cfg.TLS.RedirectHTTP)
+	cfg.TLS.HTTPAddr = getEnv("TLS_HTTP_ADDR", cfg.TLS.HTTPAddr)
+
+	cfg.UpstreamTLS.CertFile = getEnv("UPSTREAM_TLS_CERT", cfg.UpstreamTLS.CertFile)
+	cfg.UpstreamTLS.KeyFile = getEnv("UPSTREAM_TLS_KEY", cfg.UpstreamTLS.KeyFile)
+	cfg.UpstreamTLS.CAFile = getEnv("UPSTREAM_TLS_CA", cfg.UpstreamTLS.CAFile)
+	// UPSTREAM_TLS_CA_PLANNER=/etc/certs/planner-ca.pem pins one backend
+	for _, service := range []string{"rag", "planner", "quiz"} {
+		if caFile := os.Getenv("UPSTREAM_TLS_CA_" + strings.ToUpper(service)); caFile != "" {
+			cfg.UpstreamTLS.BackendCAFiles[service] = caFile
+		}
+	}
+	cfg.UpstreamTLS.ReloadInterval = getEnvDuration("UPSTREAM_TLS_RELOAD_INTERVAL", cfg.UpstreamTLS.ReloadInterval)
+
+	cfg.InternalToken.Secret = getEnv("INTERNAL_TOKEN_SECRET", cfg.InternalToken.Secret)
+	cfg.InternalToken.TTL = getEnvDuration("INTERNAL_TOKEN_TTL", cfg.InternalToken.TTL)
+
+	cfg.Secrets.Backend = getEnv("SECRETS_BACKEND", cfg.Secrets.Backend)
+	cfg.Secrets.Dir = getEnv("SECRETS_DIR", cfg.Secrets.Dir)
+	cfg.Secrets.VaultAddr = getEnv("VAULT_ADDR", cfg.Secrets.VaultAddr)
+	cfg.Secrets.VaultToken = getEnv("VAULT_TOKEN", cfg.Secrets.VaultToken)
+	cfg.Secrets.VaultPath = getEnv("VAULT_SECRET_PATH", cfg.Secrets.VaultPath)
+	cfg.Secrets.AWSRegion = getEnv("AWS_REGION", cfg.Secrets.AWSRegion)
+	cfg.Secrets.AWSAccessKey = getEnv("AWS_ACCESS_KEY_ID", cfg.Secrets.AWSAccessKey)
+	cfg.Secrets.AWSSecretKey = getEnv("AWS_SECRET_ACCESS_KEY", cfg.Secrets.AWSSecretKey)
+	cfg.Secrets.AWSSessionToken = getEnv("AWS_SESSION_TOKEN", cfg.Secrets.AWSSessionToken)
+	cfg.Secrets.AWSSecretID = getEnv("AWS_SECRET_ID", cfg.Secrets.AWSSecretID)
+	cfg.Secrets.Timeout = getEnvDuration("SECRETS_TIMEOUT", cfg.Secrets.Timeout)
+	cfg.Secrets.RefreshInterval = getEnvDuration("SECRETS_REFRESH_INTERVAL", cfg.Secrets.RefreshInterval)
+
+	// FEATURE_FLAGS=hedged_search,-mock_backends enables/disables named flags
+	for _, flag := range getEnvList("FEATURE_FLAGS", nil) {
+		if name := strings.TrimPrefix(flag, "-"); name != flag {
+			cfg.Features[name] = false
+		} else {
+			cfg.Features[flag] = true
+		}
+	}
+	// MOCK_BACKENDS=true serves fake RAG/Planner/Quiz responses in-process
+	if getEnvBool("MOCK_BACKENDS", false) {
+		cfg.Features["mock_backends"] = true
+	}
+}
+
+// splitReplicas splits a comma-separated URL list, leaving the first entry
+// in *serviceURL.
+func splitReplicas(serviceURL *string) []string {
+	var urls []string
+	for _, u := range strings.Split(*serviceURL, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	if len(urls) > 0 {
+		*serviceURL = urls[0]
+	}
+	return urls
+}
+
+// defaultCORSOrigins returns the allowed origins used when none are configured.
+// Production starts locked down so origins must be listed explicitly.
+func defaultCORSOrigins(environment string) []string {
+	switch environment {
+	case "production":
+		return []string{}
+	case "staging":
+		return []string{"http://localhost:3000"}
+	default:
+		return []string{"*"}
 	}
 }
 
@@ -34,3 +1585,81 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvList reads a comma-separated list, trimming blanks
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvIntList reads a comma-separated list of integers, ignoring bad entries
+func getEnvIntList(key string, defaultValue []int) []int {
+	items := getEnvList(key, nil)
+	if items == nil {
+		return defaultValue
+	}
+
+	values := []int{}
+	for _, item := range items {
+		if value, err := strconv.Atoi(item); err == nil {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvDate reads a date (2006-01-02) or RFC 3339 time, zero when unset
+// or invalid
+func getEnvDate(key string) time.Time {
+	value, err := parseDate(os.Getenv(key))
+	if err != nil {
+		return time.Time{}
+	}
+	return value
+}
+
+// parseDate reads a date (2006-01-02, midnight UTC) or RFC 3339 time
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,945 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration that decodes from strings such as "500ms" or "2m"
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// fileConfig is the on-disk layout of the config file. Every field is
+// optional; unset fields keep their defaults.
+type fileConfig struct {
+	Environment string `yaml:"environment" toml:"environment"`
+
+	Services struct {
+		RAGURL     string `yaml:"rag_url" toml:"rag_url"`
+		PlannerURL string `yaml:"planner_url" toml:"planner_url"`
+		QuizURL    string `yaml:"quiz_url" toml:"quiz_url"`
+		Balancing  string `yaml:"load_balancing" toml:"load_balancing"`
+
+		RAGFallbackURL     string `yaml:"rag_fallback_url" toml:"rag_fallback_url"`
+		PlannerFallbackURL string `yaml:"planner_fallback_url" toml:"planner_fallback_url"`
+		QuizFallbackURL    string `yaml:"quiz_fallback_url" toml:"quiz_fallback_url"`
+	} `yaml:"services" toml:"services"`
+
+	CircuitBreaker struct {
+		FailureThreshold *int      `yaml:"failure_threshold" toml:"failure_threshold"`
+		OpenTimeout      *Duration `yaml:"open_timeout" toml:"open_timeout"`
+	} `yaml:"circuit_breaker" toml:"circuit_breaker"`
+
+	CORS struct {
+		AllowedOrigins   []string  `yaml:"allowed_origins" toml:"allowed_origins"`
+		AllowCredentials *bool     `yaml:"allow_credentials" toml:"allow_credentials"`
+		MaxAge           *Duration `yaml:"max_age" toml:"max_age"`
+	} `yaml:"cors" toml:"cors"`
+
+	Timeouts struct {
+		RAG     *Duration `yaml:"rag" toml:"rag"`
+		Planner *Duration `yaml:"planner" toml:"planner"`
+		Quiz    *Duration `yaml:"quiz" toml:"quiz"`
+	} `yaml:"timeouts" toml:"timeouts"`
+
+	Deadlines struct {
+		Default *Duration           `yaml:"default" toml:"default"`
+		Routes  map[string]Duration `yaml:"routes" toml:"routes"`
+	} `yaml:"deadlines" toml:"deadlines"`
+
+	BodyLimits struct {
+		MaxBytes     *int           `yaml:"max_bytes" toml:"max_bytes"`
+		Routes       map[string]int `yaml:"routes" toml:"routes"`
+		MaxJSONDepth *int           `yaml:"max_json_depth" toml:"max_json_depth"`
+	} `yaml:"body_limits" toml:"body_limits"`
+
+	Auth struct {
+		Required      *bool           `yaml:"required" toml:"required"`
+		Routes        map[string]bool `yaml:"routes" toml:"routes"`
+		AnonymousMode *bool           `yaml:"anonymous_mode" toml:"anonymous_mode"`
+	} `yaml:"auth" toml:"auth"`
+
+	Ownership struct {
+		Enabled   *bool     `yaml:"enabled" toml:"enabled"`
+		Retention *Duration `yaml:"retention" toml:"retention"`
+	} `yaml:"ownership" toml:"ownership"`
+
+	Guests struct {
+		Enabled    *bool     `yaml:"enabled" toml:"enabled"`
+		TTL        *Duration `yaml:"ttl" toml:"ttl"`
+		MaxPlans   *int      `yaml:"max_plans" toml:"max_plans"`
+		MaxQuizzes *int      `yaml:"max_quizzes" toml:"max_quizzes"`
+	} `yaml:"guests" toml:"guests"`
+
+	Moderation struct {
+		Enabled        *bool     `yaml:"enabled" toml:"enabled"`
+		BlockedTerms   []string  `yaml:"blocked_terms" toml:"blocked_terms"`
+		BlockedDomains []string  `yaml:"blocked_domains" toml:"blocked_domains"`
+		APIURL         string    `yaml:"api_url" toml:"api_url"`
+		APIKey         string    `yaml:"api_key" toml:"api_key"`
+		Timeout        *Duration `yaml:"timeout" toml:"timeout"`
+		FailClosed     *bool     `yaml:"fail_closed" toml:"fail_closed"`
+		Tenants        map[string]struct {
+			Enabled        *bool    `yaml:"enabled" toml:"enabled"`
+			BlockedTerms   []string `yaml:"blocked_terms" toml:"blocked_terms"`
+			BlockedDomains []string `yaml:"blocked_domains" toml:"blocked_domains"`
+			AllowedDomains []string `yaml:"allowed_domains" toml:"allowed_domains"`
+			SkipAPI        *bool    `yaml:"skip_api" toml:"skip_api"`
+		} `yaml:"tenants" toml:"tenants"`
+	} `yaml:"moderation" toml:"moderation"`
+
+	Ingestion struct {
+		AllowedSchemes   []string `yaml:"allowed_schemes" toml:"allowed_schemes"`
+		StripParams      []string `yaml:"strip_params" toml:"strip_params"`
+		RespectRobotsTxt *bool    `yaml:"respect_robots_txt" toml:"respect_robots_txt"`
+	} `yaml:"ingestion" toml:"ingestion"`
+
+	Retry struct {
+		fileRetry   `yaml:",inline"`
+		BudgetRatio *float64             `yaml:"budget_ratio" toml:"budget_ratio"`
+		BudgetBurst *int                 `yaml:"budget_burst" toml:"budget_burst"`
+		Services    map[string]fileRetry `yaml:"services" toml:"services"`
+	} `yaml:"retry" toml:"retry"`
+
+	RateLimit struct {
+		RequestsPerMinute *int `yaml:"requests_per_minute" toml:"requests_per_minute"`
+		Burst             *int `yaml:"burst" toml:"burst"`
+	} `yaml:"rate_limit" toml:"rate_limit"`
+
+	IPRateLimit struct {
+		RequestsPerMinute *int     `yaml:"requests_per_minute" toml:"requests_per_minute"`
+		Allowlist         []string `yaml:"allowlist" toml:"allowlist"`
+	} `yaml:"ip_rate_limit" toml:"ip_rate_limit"`
+
+	Abuse struct {
+		Threshold  *int      `yaml:"threshold" toml:"threshold"`
+		Window     *Duration `yaml:"window" toml:"window"`
+		Ban        *Duration `yaml:"ban" toml:"ban"`
+		MaxBan     *Duration `yaml:"max_ban" toml:"max_ban"`
+		OffenseTTL *Duration `yaml:"offense_ttl" toml:"offense_ttl"`
+	} `yaml:"abuse" toml:"abuse"`
+
+	Deprecation struct {
+		Rules []struct {
+			Path    string `yaml:"path" toml:"path"`
+			Method  string `yaml:"method" toml:"method"`
+			Version string `yaml:"version" toml:"version"`
+			Field   string `yaml:"field" toml:"field"`
+			Since   string `yaml:"since" toml:"since"`
+			Sunset  string `yaml:"sunset" toml:"sunset"`
+			Link    string `yaml:"link" toml:"link"`
+		} `yaml:"rules" toml:"rules"`
+		LogTTL *Duration `yaml:"log_ttl" toml:"log_ttl"`
+	} `yaml:"deprecation" toml:"deprecation"`
+
+	JSONAPI struct {
+		Tenants []string `yaml:"tenants" toml:"tenants"`
+	} `yaml:"jsonapi" toml:"jsonapi"`
+
+	Transport struct {
+		MaxIdleConns          *int      `yaml:"max_idle_conns" toml:"max_idle_conns"`
+		MaxIdleConnsPerHost   *int      `yaml:"max_idle_conns_per_host" toml:"max_idle_conns_per_host"`
+		MaxConnsPerHost       *int      `yaml:"max_conns_per_host" toml:"max_conns_per_host"`
+		IdleConnTimeout       *Duration `yaml:"idle_conn_timeout" toml:"idle_conn_timeout"`
+		DisableKeepAlives     *bool     `yaml:"disable_keepalives" toml:"disable_keepalives"`
+		TLSHandshakeTimeout   *Duration `yaml:"tls_handshake_timeout" toml:"tls_handshake_timeout"`
+		TLSInsecureSkipVerify *bool     `yaml:"tls_insecure_skip_verify" toml:"tls_insecure_skip_verify"`
+		TLSMinVersion         string    `yaml:"tls_min_version" toml:"tls_min_version"`
+		HTTP2                 *bool     `yaml:"http2" toml:"http2"`
+		H2C                   *bool     `yaml:"h2c" toml:"h2c"`
+	} `yaml:"transport" toml:"transport"`
+
+	Server struct {
+		HTTP2             *bool     `yaml:"http2" toml:"http2"`
+		H2C               *bool     `yaml:"h2c" toml:"h2c"`
+		ReadHeaderTimeout *Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+		ReadTimeout       *Duration `yaml:"read_timeout" toml:"read_timeout"`
+		WriteTimeout      *Duration `yaml:"write_timeout" toml:"write_timeout"`
+		IdleTimeout       *Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+		MaxHeaderBytes    *int      `yaml:"max_header_bytes" toml:"max_header_bytes"`
+		KeepAlives        *bool     `yaml:"keepalives" toml:"keepalives"`
+		TrustedProxies    []string  `yaml:"trusted_proxies" toml:"trusted_proxies"`
+		RemoteIPHeaders   []string  `yaml:"remote_ip_headers" toml:"remote_ip_headers"`
+	} `yaml:"server" toml:"server"`
+
+	Health struct {
+		PollInterval *Duration `yaml:"poll_interval" toml:"poll_interval"`
+		ProbeTimeout *Duration `yaml:"probe_timeout" toml:"probe_timeout"`
+		StaleAfter   *Duration `yaml:"stale_after" toml:"stale_after"`
+		VersionsTTL  *Duration `yaml:"versions_ttl" toml:"versions_ttl"`
+	} `yaml:"health" toml:"health"`
+
+	AccessLog struct {
+		Sinks         []string           `yaml:"sinks" toml:"sinks"`
+		Format        string             `yaml:"format" toml:"format"`
+		File          string             `yaml:"file" toml:"file"`
+		LokiURL       string             `yaml:"loki_url" toml:"loki_url"`
+		HTTPURL       string             `yaml:"http_url" toml:"http_url"`
+		Timeout       *Duration          `yaml:"timeout" toml:"timeout"`
+		BatchSize     *int               `yaml:"batch_size" toml:"batch_size"`
+		FlushInterval *Duration          `yaml:"flush_interval" toml:"flush_interval"`
+		BufferSize    *int               `yaml:"buffer_size" toml:"buffer_size"`
+		Sampling      map[string]float64 `yaml:"sampling" toml:"sampling"`
+	} `yaml:"access_log" toml:"access_log"`
+
+	// The DSN is a credential and only read from the environment
+	ErrorReporting struct {
+		Environment string    `yaml:"environment" toml:"environment"`
+		Release     string    `yaml:"release" toml:"release"`
+		SampleRate  *float64  `yaml:"sample_rate" toml:"sample_rate"`
+		Timeout     *Duration `yaml:"timeout" toml:"timeout"`
+	} `yaml:"error_reporting" toml:"error_reporting"`
+
+	SlowRequests struct {
+		Threshold          *Duration `yaml:"threshold" toml:"threshold"`
+		LargeResponseBytes *int      `yaml:"large_response_bytes" toml:"large_response_bytes"`
+	} `yaml:"slow_requests" toml:"slow_requests"`
+
+	Timing struct {
+		Header *bool `yaml:"header" toml:"header"`
+	} `yaml:"timing" toml:"timing"`
+
+	Batch struct {
+		MaxRequests *int `yaml:"max_requests" toml:"max_requests"`
+		Concurrency *int `yaml:"concurrency" toml:"concurrency"`
+	} `yaml:"batch" toml:"batch"`
+
+	Caching struct {
+		SearchTTL *Duration `yaml:"search_ttl" toml:"search_ttl"`
+		InfoTTL   *Duration `yaml:"info_ttl" toml:"info_ttl"`
+	} `yaml:"caching" toml:"caching"`
+
+	Hedging struct {
+		RAGSearchDelay *Duration `yaml:"rag_search_delay" toml:"rag_search_delay"`
+	} `yaml:"hedging" toml:"hedging"`
+
+	Concurrency struct {
+		MilestoneParallelism *int `yaml:"milestone_parallelism" toml:"milestone_parallelism"`
+		PlansPerUser         *int `yaml:"plans_per_user" toml:"plans_per_user"`
+	} `yaml:"concurrency" toml:"concurrency"`
+
+	Storage struct {
+		Backend        string    `yaml:"backend" toml:"backend"`
+		RedisURL       string    `yaml:"redis_url" toml:"redis_url"`
+		KeyPrefix      string    `yaml:"key_prefix" toml:"key_prefix"`
+		IdempotencyTTL *Duration `yaml:"idempotency_ttl" toml:"idempotency_ttl"`
+	} `yaml:"storage" toml:"storage"`
+
+	Transcripts struct {
+		Enabled    *bool     `yaml:"enabled" toml:"enabled"`
+		Languages  []string  `yaml:"languages" toml:"languages"`
+		WhisperURL string    `yaml:"whisper_url" toml:"whisper_url"`
+		Timeout    *Duration `yaml:"timeout" toml:"timeout"`
+	} `yaml:"transcripts" toml:"transcripts"`
+
+	Previews struct {
+		Timeout      *Duration `yaml:"timeout" toml:"timeout"`
+		CacheTTL     *Duration `yaml:"cache_ttl" toml:"cache_ttl"`
+		MaxBytes     *int      `yaml:"max_bytes" toml:"max_bytes"`
+		AllowPrivate *bool     `yaml:"allow_private" toml:"allow_private"`
+	} `yaml:"previews" toml:"previews"`
+
+	Durations struct {
+		Enabled        *bool `yaml:"enabled" toml:"enabled"`
+		WordsPerMinute *int  `yaml:"words_per_minute" toml:"words_per_minute"`
+	} `yaml:"durations" toml:"durations"`
+
+	Costs struct {
+		Enabled          *bool              `yaml:"enabled" toml:"enabled"`
+		MonthlyBudgetUSD *float64           `yaml:"monthly_budget_usd" toml:"monthly_budget_usd"`
+		Budgets          map[string]float64 `yaml:"budgets" toml:"budgets"`
+		TokenPriceUSD    *float64           `yaml:"token_price_usd" toml:"token_price_usd"`
+		Estimates        map[string]float64 `yaml:"estimates" toml:"estimates"`
+	} `yaml:"costs" toml:"costs"`
+
+	Languages struct {
+		Default   string   `yaml:"default" toml:"default"`
+		Supported []string `yaml:"supported" toml:"supported"`
+	} `yaml:"languages" toml:"languages"`
+
+	Licenses struct {
+		Paid []string `yaml:"paid" toml:"paid"`
+	} `yaml:"licenses" toml:"licenses"`
+
+	Diversity struct {
+		MaxPerProvider  *int `yaml:"max_per_provider" toml:"max_per_provider"`
+		MaxPerMediaType *int `yaml:"max_per_media_type" toml:"max_per_media_type"`
+		Tenants         map[string]struct {
+			MaxPerProvider  *int `yaml:"max_per_provider" toml:"max_per_provider"`
+			MaxPerMediaType *int `yaml:"max_per_media_type" toml:"max_per_media_type"`
+		} `yaml:"tenants" toml:"tenants"`
+	} `yaml:"diversity" toml:"diversity"`
+
+	Calibration struct {
+		Enabled    *bool    `yaml:"enabled" toml:"enabled"`
+		MinAnswers *int     `yaml:"min_answers" toml:"min_answers"`
+		EasyAbove  *float64 `yaml:"easy_above" toml:"easy_above"`
+		HardBelow  *float64 `yaml:"hard_below" toml:"hard_below"`
+	} `yaml:"calibration" toml:"calibration"`
+
+	Grading struct {
+		PollInterval *Duration `yaml:"poll_interval" toml:"poll_interval"`
+		BatchSize    *int      `yaml:"batch_size" toml:"batch_size"`
+		MaxAttempts  *int      `yaml:"max_attempts" toml:"max_attempts"`
+	} `yaml:"grading" toml:"grading"`
+
+	QuizIntegrity struct {
+		Enabled            *bool     `yaml:"enabled" toml:"enabled"`
+		AllowRetakes       *bool     `yaml:"allow_retakes" toml:"allow_retakes"`
+		MinTimePerQuestion *Duration `yaml:"min_time_per_question" toml:"min_time_per_question"`
+		SessionTTL         *Duration `yaml:"session_ttl" toml:"session_ttl"`
+	} `yaml:"quiz_integrity" toml:"quiz_integrity"`
+
+	Retakes struct {
+		MaxRetakes *int      `yaml:"max_retakes" toml:"max_retakes"`
+		Cooldown   *Duration `yaml:"cooldown" toml:"cooldown"`
+		Tenants    map[string]struct {
+			MaxRetakes *int      `yaml:"max_retakes" toml:"max_retakes"`
+			Cooldown   *Duration `yaml:"cooldown" toml:"cooldown"`
+		} `yaml:"tenants" toml:"tenants"`
+	} `yaml:"retakes" toml:"retakes"`
+
+	Remediation struct {
+		ScoreThreshold     *float64 `yaml:"score_threshold" toml:"score_threshold"`
+		MaxReviewResources *int     `yaml:"max_review_resources" toml:"max_review_resources"`
+	} `yaml:"remediation" toml:"remediation"`
+
+	Compensation struct {
+		Policy string `yaml:"policy" toml:"policy"`
+	} `yaml:"compensation" toml:"compensation"`
+
+	Drafts struct {
+		TTL *Duration `yaml:"ttl" toml:"ttl"`
+	} `yaml:"drafts" toml:"drafts"`
+
+	Uploads struct {
+		Backend    string `yaml:"backend" toml:"backend"`
+		Dir        string `yaml:"dir" toml:"dir"`
+		S3Bucket   string `yaml:"s3_bucket" toml:"s3_bucket"`
+		S3Region   string `yaml:"s3_region" toml:"s3_region"`
+		S3Endpoint string `yaml:"s3_endpoint" toml:"s3_endpoint"`
+	} `yaml:"uploads" toml:"uploads"`
+
+	Database struct {
+		URL          string `yaml:"url" toml:"url"`
+		Driver       string `yaml:"driver" toml:"driver"`
+		MaxOpenConns *int   `yaml:"max_open_conns" toml:"max_open_conns"`
+	} `yaml:"database" toml:"database"`
+
+	Events struct {
+		Backend       string    `yaml:"backend" toml:"backend"`
+		URL           string    `yaml:"url" toml:"url"`
+		SubjectPrefix string    `yaml:"subject_prefix" toml:"subject_prefix"`
+		PollInterval  *Duration `yaml:"poll_interval" toml:"poll_interval"`
+		BatchSize     *int      `yaml:"batch_size" toml:"batch_size"`
+	} `yaml:"events" toml:"events"`
+
+	Notifications struct {
+		Channels []struct {
+			Type    string   `yaml:"type" toml:"type"`
+			URL     string   `yaml:"url" toml:"url"`
+			Tenants []string `yaml:"tenants" toml:"tenants"`
+			Events  []string `yaml:"events" toml:"events"`
+		} `yaml:"channels" toml:"channels"`
+		AppURL    string    `yaml:"app_url" toml:"app_url"`
+		Timeout   *Duration `yaml:"timeout" toml:"timeout"`
+		TenantTTL *Duration `yaml:"tenant_ttl" toml:"tenant_ttl"`
+	} `yaml:"notifications" toml:"notifications"`
+
+	Hooks struct {
+		MaxPerUser   *int      `yaml:"max_per_user" toml:"max_per_user"`
+		Timeout      *Duration `yaml:"timeout" toml:"timeout"`
+		AllowPrivate *bool     `yaml:"allow_private" toml:"allow_private"`
+	} `yaml:"hooks" toml:"hooks"`
+
+	Branding struct {
+		LogoURL      string `yaml:"logo_url" toml:"logo_url"`
+		PrimaryColor string `yaml:"primary_color" toml:"primary_color"`
+		AccentColor  string `yaml:"accent_color" toml:"accent_color"`
+		SenderName   string `yaml:"sender_name" toml:"sender_name"`
+	} `yaml:"branding" toml:"branding"`
+
+	Focus struct {
+		DefaultMinutes   *int      `yaml:"default_minutes" toml:"default_minutes"`
+		IdleThreshold    *Duration `yaml:"idle_threshold" toml:"idle_threshold"`
+		HeartbeatTimeout *Duration `yaml:"heartbeat_timeout" toml:"heartbeat_timeout"`
+		MaxMinutes       *int      `yaml:"max_minutes" toml:"max_minutes"`
+	} `yaml:"focus" toml:"focus"`
+
+	Scheduler struct {
+		Enabled       *bool     `yaml:"enabled" toml:"enabled"`
+		MaxJitter     *Duration `yaml:"max_jitter" toml:"max_jitter"`
+		Feeds         []string  `yaml:"feeds" toml:"feeds"`
+		WarmupQueries []string  `yaml:"warmup_queries" toml:"warmup_queries"`
+		Jobs          map[string]struct {
+			Enabled  *bool  `yaml:"enabled" toml:"enabled"`
+			Schedule string `yaml:"schedule" toml:"schedule"`
+		} `yaml:"jobs" toml:"jobs"`
+	} `yaml:"scheduler" toml:"scheduler"`
+
+	Seed struct {
+		OnStartup *bool    `yaml:"on_startup" toml:"on_startup"`
+		UserID    string   `yaml:"user_id" toml:"user_id"`
+		Goal      string   `yaml:"goal" toml:"goal"`
+		URLs      []string `yaml:"urls" toml:"urls"`
+	} `yaml:"seed" toml:"seed"`
+
+	Admission struct {
+		MaxInFlight       *int      `yaml:"max_in_flight" toml:"max_in_flight"`
+		MaxQueue          *int      `yaml:"max_queue" toml:"max_queue"`
+		QueueTimeout      *Duration `yaml:"queue_timeout" toml:"queue_timeout"`
+		SaturationCooloff *Duration `yaml:"saturation_cooloff" toml:"saturation_cooloff"`
+		RetryAfter        *Duration `yaml:"retry_after" toml:"retry_after"`
+	} `yaml:"admission" toml:"admission"`
+
+	Discovery struct {
+		Mode            string    `yaml:"mode" toml:"mode"`
+		Scheme          string    `yaml:"scheme" toml:"scheme"`
+		RefreshInterval *Duration `yaml:"refresh_interval" toml:"refresh_interval"`
+		ConsulAddr      string    `yaml:"consul_addr" toml:"consul_addr"`
+		KubeNamespace   string    `yaml:"kubernetes_namespace" toml:"kubernetes_namespace"`
+		RAGService      string    `yaml:"rag_service" toml:"rag_service"`
+		PlannerService  string    `yaml:"planner_service" toml:"planner_service"`
+		QuizService     string    `yaml:"quiz_service" toml:"quiz_service"`
+	} `yaml:"discovery" toml:"discovery"`
+
+	Mirror struct {
+		TargetURL string    `yaml:"target_url" toml:"target_url"`
+		Percent   *float64  `yaml:"percent" toml:"percent"`
+		Paths     []string  `yaml:"paths" toml:"paths"`
+		Timeout   *Duration `yaml:"timeout" toml:"timeout"`
+	} `yaml:"mirror" toml:"mirror"`
+
+	Capture struct {
+		Enabled      *bool `yaml:"enabled" toml:"enabled"`
+		MaxRequests  *int  `yaml:"max_requests" toml:"max_requests"`
+		MaxBodyBytes *int  `yaml:"max_body_bytes" toml:"max_body_bytes"`
+	} `yaml:"capture" toml:"capture"`
+
+	Canary struct {
+		PlannerURL string   `yaml:"planner_url" toml:"planner_url"`
+		QuizURL    string   `yaml:"quiz_url" toml:"quiz_url"`
+		Percent    *float64 `yaml:"percent" toml:"percent"`
+		Tenants    []string `yaml:"tenants" toml:"tenants"`
+	} `yaml:"canary" toml:"canary"`
+
+	MaintenanceMessage string `yaml:"maintenance_message" toml:"maintenance_message"`
+
+	TLS struct {
+		CertFile        string   `yaml:"cert_file" toml:"cert_file"`
+		KeyFile         string   `yaml:"key_file" toml:"key_file"`
+		AutocertDomains []string `yaml:"autocert_domains" toml:"autocert_domains"`
+		AutocertEmail   string   `yaml:"autocert_email" toml:"autocert_email"`
+		AutocertCache   string   `yaml:"autocert_cache" toml:"autocert_cache"`
+		RedirectHTTP    *bool    `yaml:"redirect_http" toml:"redirect_http"`
+		HTTPAddr        string   `yaml:"http_addr" toml:"http_addr"`
+	} `yaml:"tls" toml:"tls"`
+
+	UpstreamTLS struct {
+		CertFile       string            `yaml:"cert_file" toml:"cert_file"`
+		KeyFile        string            `yaml:"key_file" toml:"key_file"`
+		CAFile         string            `yaml:"ca_file" toml:"ca_file"`
+		BackendCAFiles map[string]string `yaml:"backend_ca_files" toml:"backend_ca_files"`
+		ReloadInterval *Duration         `yaml:"reload_interval" toml:"reload_interval"`
+	} `yaml:"upstream_tls" toml:"upstream_tls"`
+
+	// The secret is a credential and only read from the environment
+	InternalToken struct {
+		TTL *Duration `yaml:"ttl" toml:"ttl"`
+	} `yaml:"internal_token" toml:"internal_token"`
+
+	Secrets struct {
+		Backend         string    `yaml:"backend" toml:"backend"`
+		Dir             string    `yaml:"dir" toml:"dir"`
+		VaultAddr       string    `yaml:"vault_addr" toml:"vault_addr"`
+		VaultPath       string    `yaml:"vault_path" toml:"vault_path"`
+		AWSRegion       string    `yaml:"aws_region" toml:"aws_region"`
+		AWSSecretID     string    `yaml:"aws_secret_id" toml:"aws_secret_id"`
+		Timeout         *Duration `yaml:"timeout" toml:"timeout"`
+		RefreshInterval *Duration `yaml:"refresh_interval" toml:"refresh_interval"`
+	} `yaml:"secrets" toml:"secrets"`
+
+	Features map[string]bool `yaml:"features" toml:"features"`
+}
+
+// fileRetry is the retry section, usable globally or per service
+type fileRetry struct {
+	MaxAttempts          *int      `yaml:"max_attempts" toml:"max_attempts"`
+	BaseWait             *Duration `yaml:"base_wait" toml:"base_wait"`
+	MaxWait              *Duration `yaml:"max_wait" toml:"max_wait"`
+	Jitter               *float64  `yaml:"jitter" toml:"jitter"`
+	RetryableMethods     []string  `yaml:"methods" toml:"methods"`
+	RetryableStatusCodes []int     `yaml:"status_codes" toml:"status_codes"`
+}
+
+func (r fileRetry) override() RetryOverride {
+	return RetryOverride{
+		MaxAttempts:          r.MaxAttempts,
+		BaseWait:             (*time.Duration)(r.BaseWait),
+		MaxWait:              (*time.Duration)(r.MaxWait),
+		Jitter:               r.Jitter,
+		RetryableMethods:     r.RetryableMethods,
+		RetryableStatusCodes: r.RetryableStatusCodes,
+	}
+}
+
+// applyFile overlays the YAML or TOML file at path onto cfg
+func applyFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var fc fileConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &fc)
+	case ".toml":
+		err = toml.Unmarshal(data, &fc)
+	default:
+		return fmt.Errorf("unsupported config file format: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	setString(&cfg.Environment, fc.Environment)
+	setString(&cfg.RAGServiceURL, fc.Services.RAGURL)
+	setString(&cfg.PlannerServiceURL, fc.Services.PlannerURL)
+	setString(&cfg.QuizServiceURL, fc.Services.QuizURL)
+	setString(&cfg.LoadBalancing, fc.Services.Balancing)
+	setString(&cfg.RAGFallbackURL, fc.Services.RAGFallbackURL)
+	setString(&cfg.PlannerFallbackURL, fc.Services.PlannerFallbackURL)
+	setString(&cfg.QuizFallbackURL, fc.Services.QuizFallbackURL)
+	setInt(&cfg.CircuitBreaker.FailureThreshold, fc.CircuitBreaker.FailureThreshold)
+	setDuration(&cfg.CircuitBreaker.OpenTimeout, fc.CircuitBreaker.OpenTimeout)
+
+	if fc.CORS.AllowedOrigins != nil {
+		cfg.CORS.AllowedOrigins = fc.CORS.AllowedOrigins
+	}
+	setBool(&cfg.CORS.AllowCredentials, fc.CORS.AllowCredentials)
+	setDuration(&cfg.CORS.MaxAge, fc.CORS.MaxAge)
+
+	setDuration(&cfg.Timeouts.RAG, fc.Timeouts.RAG)
+	setDuration(&cfg.Timeouts.Planner, fc.Timeouts.Planner)
+	setDuration(&cfg.Timeouts.Quiz, fc.Timeouts.Quiz)
+
+	setDuration(&cfg.Deadlines.Default, fc.Deadlines.Default)
+	for route, budget := range fc.Deadlines.Routes {
+		cfg.Deadlines.Routes[route] = time.Duration(budget)
+	}
+
+	setInt(&cfg.BodyLimits.MaxBytes, fc.BodyLimits.MaxBytes)
+	for route, limit := range fc.BodyLimits.Routes {
+		cfg.BodyLimits.Routes[route] = limit
+	}
+	setInt(&cfg.BodyLimits.MaxJSONDepth, fc.BodyLimits.MaxJSONDepth)
+
+	setBool(&cfg.Auth.Required, fc.Auth.Required)
+	setBool(&cfg.Auth.AnonymousMode, fc.Auth.AnonymousMode)
+	for route, required := range fc.Auth.Routes {
+		cfg.Auth.Routes[route] = required
+	}
+	setBool(&cfg.Ownership.Enabled, fc.Ownership.Enabled)
+	setDuration(&cfg.Ownership.Retention, fc.Ownership.Retention)
+	setBool(&cfg.Guests.Enabled, fc.Guests.Enabled)
+	setDuration(&cfg.Guests.TTL, fc.Guests.TTL)
+	setInt(&cfg.Guests.MaxPlans, fc.Guests.MaxPlans)
+	setInt(&cfg.Guests.MaxQuizzes, fc.Guests.MaxQuizzes)
+
+	setBool(&cfg.Moderation.Enabled, fc.Moderation.Enabled)
+	if fc.Moderation.BlockedTerms != nil {
+		cfg.Moderation.BlockedTerms = fc.Moderation.BlockedTerms
+	}
+	if fc.Moderation.BlockedDomains != nil {
+		cfg.Moderation.BlockedDomains = fc.Moderation.BlockedDomains
+	}
+	setString(&cfg.Moderation.APIURL, fc.Moderation.APIURL)
+	setString(&cfg.Moderation.APIKey, fc.Moderation.APIKey)
+	setDuration(&cfg.Moderation.Timeout, fc.Moderation.Timeout)
+	setBool(&cfg.Moderation.FailClosed, fc.Moderation.FailClosed)
+	for tenantID, t := range fc.Moderation.Tenants {
+		override := ModerationOverride{
+			Enabled:        t.Enabled,
+			BlockedTerms:   t.BlockedTerms,
+			BlockedDomains: t.BlockedDomains,
+			AllowedDomains: t.AllowedDomains,
+		}
+		setBool(&override.SkipAPI, t.SkipAPI)
+		cfg.Moderation.Tenants[tenantID] = override
+	}
+
+	if fc.Ingestion.AllowedSchemes != nil {
+		cfg.Ingestion.AllowedSchemes = fc.Ingestion.AllowedSchemes
+	}
+	if fc.Ingestion.StripParams != nil {
+		cfg.Ingestion.StripParams = fc.Ingestion.StripParams
+	}
+	setBool(&cfg.Ingestion.RespectRobotsTxt, fc.Ingestion.RespectRobotsTxt)
+
+	cfg.Retry = fc.Retry.fileRetry.override().apply(cfg.Retry)
+	setFloat(&cfg.Retry.BudgetRatio, fc.Retry.BudgetRatio)
+	setInt(&cfg.Retry.BudgetBurst, fc.Retry.BudgetBurst)
+	for service, retry := range fc.Retry.Services {
+		cfg.RetryOverrides[service] = retry.override()
+	}
+
+	setInt(&cfg.RateLimit.RequestsPerMinute, fc.RateLimit.RequestsPerMinute)
+	setInt(&cfg.RateLimit.Burst, fc.RateLimit.Burst)
+	setInt(&cfg.IPRateLimit.RequestsPerMinute, fc.IPRateLimit.RequestsPerMinute)
+	if fc.IPRateLimit.Allowlist != nil {
+		cfg.IPRateLimit.Allowlist = fc.IPRateLimit.Allowlist
+	}
+	setInt(&cfg.Abuse.Threshold, fc.Abuse.Threshold)
+	setDuration(&cfg.Abuse.Window, fc.Abuse.Window)
+	setDuration(&cfg.Abuse.Ban, fc.Abuse.Ban)
+	setDuration(&cfg.Abuse.MaxBan, fc.Abuse.MaxBan)
+	setDuration(&cfg.Abuse.OffenseTTL, fc.Abuse.OffenseTTL)
+	setDuration(&cfg.Deprecation.LogTTL, fc.Deprecation.LogTTL)
+	if fc.Deprecation.Rules != nil {
+		cfg.Deprecation.Rules = nil
+		for _, fileRule := range fc.Deprecation.Rules {
+			rule := DeprecationRule{
+				Path:    fileRule.Path,
+				Method:  fileRule.Method,
+				Version: fileRule.Version,
+				Field:   fileRule.Field,
+				Link:    fileRule.Link,
+			}
+			for _, date := range []struct {
+				name  string
+				value string
+				dst   *time.Time
+			}{{"since", fileRule.Since, &rule.Since}, {"sunset", fileRule.Sunset, &rule.Sunset}} {
+				if date.value == "" {
+					continue
+				}
+				parsed, err := parseDate(date.value)
+				if err != nil {
+					return fmt.Errorf("deprecation rule for %s: invalid %s %q", fileRule.Path, date.name, date.value)
+				}
+				*date.dst = parsed
+			}
+			cfg.Deprecation.Rules = append(cfg.Deprecation.Rules, rule)
+		}
+	}
+	if fc.JSONAPI.Tenants != nil {
+		cfg.JSONAPI.Tenants = fc.JSONAPI.Tenants
+	}
+
+	setInt(&cfg.Transport.MaxIdleConns, fc.Transport.MaxIdleConns)
+	setInt(&cfg.Transport.MaxIdleConnsPerHost, fc.Transport.MaxIdleConnsPerHost)
+	setInt(&cfg.Transport.MaxConnsPerHost, fc.Transport.MaxConnsPerHost)
+	setDuration(&cfg.Transport.IdleConnTimeout, fc.Transport.IdleConnTimeout)
+	setBool(&cfg.Transport.DisableKeepAlives, fc.Transport.DisableKeepAlives)
+	setDuration(&cfg.Transport.TLSHandshakeTimeout, fc.Transport.TLSHandshakeTimeout)
+	setBool(&cfg.Transport.TLSInsecureSkipVerify, fc.Transport.TLSInsecureSkipVerify)
+	setString(&cfg.Transport.TLSMinVersion, fc.Transport.TLSMinVersion)
+	setBool(&cfg.Transport.HTTP2, fc.Transport.HTTP2)
+	setBool(&cfg.Transport.H2C, fc.Transport.H2C)
+
+	setBool(&cfg.Server.HTTP2, fc.Server.HTTP2)
+	setBool(&cfg.Server.H2C, fc.Server.H2C)
+	setDuration(&cfg.Server.ReadHeaderTimeout, fc.Server.ReadHeaderTimeout)
+	setDuration(&cfg.Server.ReadTimeout, fc.Server.ReadTimeout)
+	setDuration(&cfg.Server.WriteTimeout, fc.Server.WriteTimeout)
+	setDuration(&cfg.Server.IdleTimeout, fc.Server.IdleTimeout)
+	setInt(&cfg.Server.MaxHeaderBytes, fc.Server.MaxHeaderBytes)
+	setBool(&cfg.Server.KeepAlives, fc.Server.KeepAlives)
+	if fc.Server.TrustedProxies != nil {
+		cfg.Server.TrustedProxies = fc.Server.TrustedProxies
+	}
+	if fc.Server.RemoteIPHeaders != nil {
+		cfg.Server.RemoteIPHeaders = fc.Server.RemoteIPHeaders
+	}
+
+	setDuration(&cfg.Health.PollInterval, fc.Health.PollInterval)
+	setDuration(&cfg.Health.ProbeTimeout, fc.Health.ProbeTimeout)
+	setDuration(&cfg.Health.StaleAfter, fc.Health.StaleAfter)
+	setDuration(&cfg.Health.VersionsTTL, fc.Health.VersionsTTL)
+
+	if fc.AccessLog.Sinks != nil {
+		cfg.AccessLog.Sinks = fc.AccessLog.Sinks
+	}
+	setString(&cfg.AccessLog.Format, fc.AccessLog.Format)
+	setString(&cfg.AccessLog.File, fc.AccessLog.File)
+	setString(&cfg.AccessLog.LokiURL, fc.AccessLog.LokiURL)
+	setString(&cfg.AccessLog.HTTPURL, fc.AccessLog.HTTPURL)
+	setDuration(&cfg.AccessLog.Timeout, fc.AccessLog.Timeout)
+	setInt(&cfg.AccessLog.BatchSize, fc.AccessLog.BatchSize)
+	setDuration(&cfg.AccessLog.FlushInterval, fc.AccessLog.FlushInterval)
+	setInt(&cfg.AccessLog.BufferSize, fc.AccessLog.BufferSize)
+	if fc.AccessLog.Sampling != nil {
+		cfg.AccessLog.Sampling = fc.AccessLog.Sampling
+	}
+
+	setString(&cfg.ErrorReporting.Environment, fc.ErrorReporting.Environment)
+	setString(&cfg.ErrorReporting.Release, fc.ErrorReporting.Release)
+	setFloat(&cfg.ErrorReporting.SampleRate, fc.ErrorReporting.SampleRate)
+	setDuration(&cfg.ErrorReporting.Timeout, fc.ErrorReporting.Timeout)
+
+	setDuration(&cfg.SlowRequests.Threshold, fc.SlowRequests.Threshold)
+	setInt(&cfg.SlowRequests.LargeResponseBytes, fc.SlowRequests.LargeResponseBytes)
+	setBool(&cfg.Timing.Header, fc.Timing.Header)
+
+	setInt(&cfg.Batch.MaxRequests, fc.Batch.MaxRequests)
+	setInt(&cfg.Batch.Concurrency, fc.Batch.Concurrency)
+
+	setDuration(&cfg.Caching.SearchTTL, fc.Caching.SearchTTL)
+	setDuration(&cfg.Caching.InfoTTL, fc.Caching.InfoTTL)
+
+	setDuration(&cfg.Hedging.RAGSearchDelay, fc.Hedging.RAGSearchDelay)
+	setInt(&cfg.Concurrency.MilestoneParallelism, fc.Concurrency.MilestoneParallelism)
+	setInt(&cfg.Concurrency.PlansPerUser, fc.Concurrency.PlansPerUser)
+
+	setString(&cfg.Storage.Backend, fc.Storage.Backend)
+	setString(&cfg.Storage.RedisURL, fc.Storage.RedisURL)
+	setString(&cfg.Storage.KeyPrefix, fc.Storage.KeyPrefix)
+	setDuration(&cfg.Storage.IdempotencyTTL, fc.Storage.IdempotencyTTL)
+
+	setBool(&cfg.Transcripts.Enabled, fc.Transcripts.Enabled)
+	if fc.Transcripts.Languages != nil {
+		cfg.Transcripts.Languages = fc.Transcripts.Languages
+	}
+	setString(&cfg.Transcripts.WhisperURL, fc.Transcripts.WhisperURL)
+	setDuration(&cfg.Transcripts.Timeout, fc.Transcripts.Timeout)
+
+	setDuration(&cfg.Previews.Timeout, fc.Previews.Timeout)
+	setDuration(&cfg.Previews.CacheTTL, fc.Previews.CacheTTL)
+	setInt(&cfg.Previews.MaxBytes, fc.Previews.MaxBytes)
+	setBool(&cfg.Previews.AllowPrivate, fc.Previews.AllowPrivate)
+
+	setBool(&cfg.Durations.Enabled, fc.Durations.Enabled)
+	setInt(&cfg.Durations.WordsPerMinute, fc.Durations.WordsPerMinute)
+
+	setBool(&cfg.Costs.Enabled, fc.Costs.Enabled)
+	setFloat(&cfg.Costs.MonthlyBudgetUSD, fc.Costs.MonthlyBudgetUSD)
+	setFloat(&cfg.Costs.TokenPriceUSD, fc.Costs.TokenPriceUSD)
+	for tenantID, budget := range fc.Costs.Budgets {
+		cfg.Costs.Budgets[tenantID] = budget
+	}
+	for path, cost := range fc.Costs.Estimates {
+		cfg.Costs.Estimates[path] = cost
+	}
+
+	setString(&cfg.Languages.Default, fc.Languages.Default)
+	if fc.Languages.Supported != nil {
+		cfg.Languages.Supported = fc.Languages.Supported
+	}
+
+	if fc.Licenses.Paid != nil {
+		cfg.Licenses.Paid = fc.Licenses.Paid
+	}
+
+	setInt(&cfg.Diversity.MaxPerProvider, fc.Diversity.MaxPerProvider)
+	setInt(&cfg.Diversity.MaxPerMediaType, fc.Diversity.MaxPerMediaType)
+	for tenantID, t := range fc.Diversity.Tenants {
+		cfg.Diversity.Tenants[tenantID] = DiversityOverride{
+			MaxPerProvider:  t.MaxPerProvider,
+			MaxPerMediaType: t.MaxPerMediaType,
+		}
+	}
+
+	setBool(&cfg.Calibration.Enabled, fc.Calibration.Enabled)
+	setInt(&cfg.Calibration.MinAnswers, fc.Calibration.MinAnswers)
+	setFloat(&cfg.Calibration.EasyAbove, fc.Calibration.EasyAbove)
+	setFloat(&cfg.Calibration.HardBelow, fc.Calibration.HardBelow)
+	setDuration(&cfg.Grading.PollInterval, fc.Grading.PollInterval)
+	setInt(&cfg.Grading.BatchSize, fc.Grading.BatchSize)
+	setInt(&cfg.Grading.MaxAttempts, fc.Grading.MaxAttempts)
+	setBool(&cfg.QuizIntegrity.Enabled, fc.QuizIntegrity.Enabled)
+	setBool(&cfg.QuizIntegrity.AllowRetakes, fc.QuizIntegrity.AllowRetakes)
+	setDuration(&cfg.QuizIntegrity.MinTimePerQuestion, fc.QuizIntegrity.MinTimePerQuestion)
+	setDuration(&cfg.QuizIntegrity.SessionTTL, fc.QuizIntegrity.SessionTTL)
+	setInt(&cfg.Retakes.MaxRetakes, fc.Retakes.MaxRetakes)
+	setDuration(&cfg.Retakes.Cooldown, fc.Retakes.Cooldown)
+	for tenantID, t := range fc.Retakes.Tenants {
+		override := RetakeOverride{MaxRetakes: t.MaxRetakes}
+		if t.Cooldown != nil {
+			cooldown := time.Duration(*t.Cooldown)
+			override.Cooldown = &cooldown
+		}
+		cfg.Retakes.Tenants[tenantID] = override
+	}
+	setFloat(&cfg.Remediation.ScoreThreshold, fc.Remediation.ScoreThreshold)
+	setInt(&cfg.Remediation.MaxReviewResources, fc.Remediation.MaxReviewResources)
+	setString(&cfg.Compensation.Policy, fc.Compensation.Policy)
+	setDuration(&cfg.Drafts.TTL, fc.Drafts.TTL)
+
+	setString(&cfg.Uploads.Backend, fc.Uploads.Backend)
+	setString(&cfg.Uploads.Dir, fc.Uploads.Dir)
+	setString(&cfg.Uploads.S3Bucket, fc.Uploads.S3Bucket)
+	setString(&cfg.Uploads.S3Region, fc.Uploads.S3Region)
+	setString(&cfg.Uploads.S3Endpoint, fc.Uploads.S3Endpoint)
+
+	setString(&cfg.Database.URL, fc.Database.URL)
+	setString(&cfg.Database.Driver, fc.Database.Driver)
+	setInt(&cfg.Database.MaxOpenConns, fc.Database.MaxOpenConns)
+
+	setString(&cfg.Events.Backend, fc.Events.Backend)
+	setString(&cfg.Events.URL, fc.Events.URL)
+	setString(&cfg.Events.SubjectPrefix, fc.Events.SubjectPrefix)
+	setDuration(&cfg.Events.PollInterval, fc.Events.PollInterval)
+	setInt(&cfg.Events.BatchSize, fc.Events.BatchSize)
+
+	if fc.Notifications.Channels != nil {
+		cfg.Notifications.Channels = nil
+		for _, channel := range fc.Notifications.Channels {
+			cfg.Notifications.Channels = append(cfg.Notifications.Channels, NotificationChannel(channel))
+		}
+	}
+	setString(&cfg.Notifications.AppURL, fc.Notifications.AppURL)
+	setDuration(&cfg.Notifications.Timeout, fc.Notifications.Timeout)
+	setDuration(&cfg.Notifications.TenantTTL, fc.Notifications.TenantTTL)
+
+	setInt(&cfg.Hooks.MaxPerUser, fc.Hooks.MaxPerUser)
+	setDuration(&cfg.Hooks.Timeout, fc.Hooks.Timeout)
+	setBool(&cfg.Hooks.AllowPrivate, fc.Hooks.AllowPrivate)
+
+	setString(&cfg.Branding.LogoURL, fc.Branding.LogoURL)
+	setString(&cfg.Branding.PrimaryColor, fc.Branding.PrimaryColor)
+	setString(&cfg.Branding.AccentColor, fc.Branding.AccentColor)
+	setString(&cfg.Branding.SenderName, fc.Branding.SenderName)
+
+	setInt(&cfg.Focus.DefaultMinutes, fc.Focus.DefaultMinutes)
+	setDuration(&cfg.Focus.IdleThreshold, fc.Focus.IdleThreshold)
+	setDuration(&cfg.Focus.HeartbeatTimeout, fc.Focus.HeartbeatTimeout)
+	setInt(&cfg.Focus.MaxMinutes, fc.Focus.MaxMinutes)
+
+	setBool(&cfg.Scheduler.Enabled, fc.Scheduler.Enabled)
+	setDuration(&cfg.Scheduler.MaxJitter, fc.Scheduler.MaxJitter)
+	if fc.Scheduler.Feeds != nil {
+		cfg.Scheduler.Feeds = fc.Scheduler.Feeds
+	}
+	if fc.Scheduler.WarmupQueries != nil {
+		cfg.Scheduler.WarmupQueries = fc.Scheduler.WarmupQueries
+	}
+	for name, fileJob := range fc.Scheduler.Jobs {
+		job := cfg.Scheduler.Jobs[name]
+		setBool(&job.Enabled, fileJob.Enabled)
+		setString(&job.Schedule, fileJob.Schedule)
+		cfg.Scheduler.Jobs[name] = job
+	}
+
+	setBool(&cfg.Seed.OnStartup, fc.Seed.OnStartup)
+	setString(&cfg.Seed.UserID, fc.Seed.UserID)
+	setString(&cfg.Seed.Goal, fc.Seed.Goal)
+	if fc.Seed.URLs != nil {
+		cfg.Seed.URLs = fc.Seed.URLs
+	}
+
+	setInt(&cfg.Admission.MaxInFlight, fc.Admission.MaxInFlight)
+	setInt(&cfg.Admission.MaxQueue, fc.Admission.MaxQueue)
+	setDuration(&cfg.Admission.QueueTimeout, fc.Admission.QueueTimeout)
+	setDuration(&cfg.Admission.SaturationCooloff, fc.Admission.SaturationCooloff)
+	setDuration(&cfg.Admission.RetryAfter, fc.Admission.RetryAfter)
+
+	setString(&cfg.Discovery.Mode, fc.Discovery.Mode)
+	setString(&cfg.Discovery.Scheme, fc.Discovery.Scheme)
+	setDuration(&cfg.Discovery.RefreshInterval, fc.Discovery.RefreshInterval)
+	setString(&cfg.Discovery.ConsulAddr, fc.Discovery.ConsulAddr)
+	setString(&cfg.Discovery.KubeNamespace, fc.Discovery.KubeNamespace)
+	setString(&cfg.Discovery.RAGService, fc.Discovery.RAGService)
+	setString(&cfg.Discovery.PlannerService, fc.Discovery.PlannerService)
+	setString(&cfg.Discovery.QuizService, fc.Discovery.QuizService)
+
+	setString(&cfg.Mirror.TargetURL, fc.Mirror.TargetURL)
+	setFloat(&cfg.Mirror.Percent, fc.Mirror.Percent)
+	if fc.Mirror.Paths != nil {
+		cfg.Mirror.Paths = fc.Mirror.Paths
+	}
+	setDuration(&cfg.Mirror.Timeout, fc.Mirror.Timeout)
+
+	setBool(&cfg.Capture.Enabled, fc.Capture.Enabled)
+	setInt(&cfg.Capture.MaxRequests, fc.Capture.MaxRequests)
+	setInt(&cfg.Capture.MaxBodyBytes, fc.Capture.MaxBodyBytes)
+
+	setString(&cfg.Canary.PlannerURL, fc.Canary.PlannerURL)
+	setString(&cfg.Canary.QuizURL, fc.Canary.QuizURL)
+	setFloat(&cfg.Canary.Percent, fc.Canary.Percent)
+	if fc.Canary.Tenants != nil {
+		cfg.Canary.Tenants = fc.Canary.Tenants
+	}
+
+	setString(&cfg.MaintenanceMessage, fc.MaintenanceMessage)
+
+	setString(&cfg.TLS.CertFile, fc.TLS.CertFile)
+	setString(&cfg.TLS.KeyFile, fc.TLS.KeyFile)
+	if fc.TLS.AutocertDomains != nil {
+		cfg.TLS.AutocertDomains = fc.TLS.AutocertDomains
+	}
+	setString(&cfg.TLS.AutocertEmail, fc.TLS.AutocertEmail)
+	setString(&cfg.TLS.AutocertCache, fc.TLS.AutocertCache)
+	setBool(&cfg.TLS.RedirectHTTP, fc.TLS.RedirectHTTP)
+	setString(&cfg.TLS.HTTPAddr, fc.TLS.HTTPAddr)
+
+	setString(&cfg.UpstreamTLS.CertFile, fc.UpstreamTLS.CertFile)
+	setString(&cfg.UpstreamTLS.KeyFile, fc.UpstreamTLS.KeyFile)
+	setString(&cfg.UpstreamTLS.CAFile, fc.UpstreamTLS.CAFile)
+	for service, caFile := range fc.UpstreamTLS.BackendCAFiles {
+		cfg.UpstreamTLS.BackendCAFiles[service] = caFile
+	}
+	setDuration(&cfg.UpstreamTLS.ReloadInterval, fc.UpstreamTLS.ReloadInterval)
+
+	setDuration(&cfg.InternalToken.TTL, fc.InternalToken.TTL)
+
+	setString(&cfg.Secrets.Backend, fc.Secrets.Backend)
+	setString(&cfg.Secrets.Dir, fc.Secrets.Dir)
+	setString(&cfg.Secrets.VaultAddr, fc.Secrets.VaultAddr)
+	setString(&cfg.Secrets.VaultPath, fc.Secrets.VaultPath)
+	setString(&cfg.Secrets.AWSRegion, fc.Secrets.AWSRegion)
+	setString(&cfg.Secrets.AWSSecretID, fc.Secrets.AWSSecretID)
+	setDuration(&cfg.Secrets.Timeout, fc.Secrets.Timeout)
+	setDuration(&cfg.Secrets.RefreshInterval, fc.Secrets.RefreshInterval)
+
+	for name, enabled := range fc.Features {
+		cfg.Features[name] = enabled
+	}
+
+	return nil
+}
+
+func setString(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
+
+func setBool(dst *bool, value *bool) {
+	if value != nil {
+		*dst = *value
+	}
+}
+
+func setInt(dst *int, value *int) {
+	if value != nil {
+		*dst = *value
+	}
+}
+
+func setFloat(dst *float64, value *float64) {
+	if value != nil {
+		*dst = *value
+	}
+}
+
+func setDuration(dst *time.Duration, value *Duration) {
+	if value != nil {
+		*dst = time.Duration(*value)
+	}
+}
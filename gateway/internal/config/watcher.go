@@ -0,0 +1,90 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Watcher polls the config file and publishes reloaded configuration.
+// Only settings read per request (timeouts, retries, rate limits, feature
+// flags) take effect without a restart.
+type Watcher struct {
+	interval time.Duration
+	current  atomic.Pointer[Config]
+	modTime  time.Time
+
+	mu       sync.Mutex
+	handlers []func(*Config)
+}
+
+// NewWatcher creates a Watcher seeded with the initial configuration
+func NewWatcher(cfg *Config, interval time.Duration) *Watcher {
+	w := &Watcher{interval: interval}
+	w.current.Store(cfg)
+	if info, err := os.Stat(cfg.ConfigFile); err == nil {
+		w.modTime = info.ModTime()
+	}
+	return w
+}
+
+// Current returns the latest configuration
+func (w *Watcher) Current() *Config {
+	return w.current.Load()
+}
+
+// OnReload registers fn to be called with each reloaded configuration
+func (w *Watcher) OnReload(fn func(*Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers = append(w.handlers, fn)
+}
+
+// Run polls the config file until ctx is cancelled. It is a no-op when no
+// config file is in use.
+func (w *Watcher) Run(ctx context.Context) {
+	path := w.Current().ConfigFile
+	if path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll(path)
+		}
+	}
+}
+
+func (w *Watcher) poll(path string) {
+	info, err := os.Stat(path)
+	if err != nil || !info.ModTime().After(w.modTime) {
+		return
+	}
+	w.modTime = info.ModTime()
+
+	cfg, err := load(path)
+	if err != nil {
+		// Keep serving with the previous configuration
+		log.Printf("Ignoring invalid config file update: %v", err)
+		return
+	}
+	w.current.Store(cfg)
+	log.Printf("Reloaded configuration from %s", path)
+
+	w.mu.Lock()
+	handlers := append([]func(*Config){}, w.handlers...)
+	w.mu.Unlock()
+
+	for _, fn := range handlers {
+		fn(cfg)
+	}
+}
@@ -0,0 +1,302 @@
+package costs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+)
+
+// Headers in which backends report the usage of a call
+const (
+	HeaderCost   = "X-LLM-Cost-USD"
+	HeaderTokens = "X-LLM-Tokens"
+)
+
+// ErrBudgetExceeded is returned by Check once a tenant has spent its budget
+var ErrBudgetExceeded = errors.New("monthly budget exceeded")
+
+// micro converts dollars to the integer counters kept in the store
+const micro = 1e6
+
+// retention keeps a month's counters long enough to report on the previous
+// month
+const retention = 93 * 24 * time.Hour
+
+// monthLayout formats the month a counter belongs to
+const monthLayout = "2006-01"
+
+// operationOther groups calls matching no configured estimate
+const operationOther = "other"
+
+// Call is one backend call made while serving a request
+type Call struct {
+	Method  string
+	Path    string
+	Status  int
+	CostUSD float64 // Reported by the backend; -1 when not
+	Tokens  int64   // Reported by the backend; -1 when not
+}
+
+// Meter collects the backend calls made for one request
+type Meter struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (m *Meter) add(call Call) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, call)
+}
+
+// Calls returns the calls recorded so far
+func (m *Meter) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+type meterKey struct{}
+
+// WithMeter returns a context whose backend calls are recorded on the
+// returned meter
+func WithMeter(ctx context.Context) (context.Context, *Meter) {
+	meter := &Meter{}
+	return context.WithValue(ctx, meterKey{}, meter), meter
+}
+
+// Transport wraps next, recording every response to a request whose context
+// carries a meter, along with the usage the backend reported
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return &transport{next: next}
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	meter, _ := req.Context().Value(meterKey{}).(*Meter)
+	if meter == nil || err != nil {
+		return resp, err
+	}
+
+	call := Call{Method: req.Method, Path: req.URL.Path, Status: resp.StatusCode, CostUSD: -1, Tokens: -1}
+	if cost, err := strconv.ParseFloat(resp.Header.Get(HeaderCost), 64); err == nil && cost >= 0 {
+		call.CostUSD = cost
+	}
+	if tokens, err := strconv.ParseInt(resp.Header.Get(HeaderTokens), 10, 64); err == nil && tokens >= 0 {
+		call.Tokens = tokens
+	}
+	meter.add(call)
+	return resp, nil
+}
+
+// Report is a tenant's spend for one month
+type Report struct {
+	TenantID     string             `json:"tenant_id"`
+	Month        string             `json:"month"`
+	CostUSD      float64            `json:"cost_usd"`
+	EstimatedUSD float64            `json:"estimated_usd"` // Part of CostUSD not reported by the backends
+	BudgetUSD    float64            `json:"budget_usd"`    // 0 is unlimited
+	RemainingUSD *float64           `json:"remaining_usd,omitempty"`
+	Tokens       int64              `json:"tokens"`
+	Requests     int64              `json:"requests"`
+	Operations   map[string]float64 `json:"operations"` // USD by backend path
+}
+
+// Tracker charges backend usage to tenants and enforces their monthly
+// budgets. Totals are kept in the shared store, so budgets hold across
+// replicas.
+type Tracker struct {
+	cfg   config.CostConfig
+	store storage.KeyValue
+}
+
+// New creates a tracker keeping its counters in store
+func New(cfg config.CostConfig, store storage.KeyValue) *Tracker {
+	return &Tracker{cfg: cfg, store: store}
+}
+
+// Enabled reports whether usage is tracked
+func (t *Tracker) Enabled() bool {
+	return t != nil && t.cfg.Enabled
+}
+
+// Budget returns a tenant's monthly budget in USD, 0 when unlimited
+func (t *Tracker) Budget(tenantID string) float64 {
+	if budget, ok := t.cfg.Budgets[tenantID]; ok {
+		return budget
+	}
+	return t.cfg.MonthlyBudgetUSD
+}
+
+// Check returns what the tenant has spent this month and its budget,
+// failing with ErrBudgetExceeded once the budget is used up
+func (t *Tracker) Check(ctx context.Context, tenantID string) (spent, budget float64, err error) {
+	budget = t.Budget(tenantID)
+	if budget <= 0 {
+		return 0, 0, nil
+	}
+	total, err := t.counter(ctx, t.key(tenantID, time.Now(), "total"))
+	if err != nil {
+		return 0, budget, err
+	}
+	spent = float64(total) / micro
+	if spent >= budget {
+		return spent, budget, ErrBudgetExceeded
+	}
+	return spent, budget, nil
+}
+
+// Charge adds the cost of calls to the tenant's monthly totals and returns
+// it in USD
+func (t *Tracker) Charge(ctx context.Context, tenantID string, calls []Call) (float64, error) {
+	now := time.Now()
+	byOperation := map[string]int64{}
+	var total, estimated, tokens int64
+	for _, call := range calls {
+		usd, isEstimate := t.price(call)
+		cost := int64(math.Round(usd * micro))
+		if cost == 0 && call.Tokens <= 0 {
+			continue
+		}
+		byOperation[t.operation(call.Path)] += cost
+		total += cost
+		if isEstimate {
+			estimated += cost
+		}
+		tokens += max(call.Tokens, 0)
+	}
+	if len(byOperation) == 0 {
+		return 0, nil
+	}
+
+	counters := map[string]int64{"total": total, "estimated": estimated, "tokens": tokens, "requests": 1}
+	for op, cost := range byOperation {
+		counters["op:"+op] = cost
+	}
+	for field, n := range counters {
+		if n == 0 {
+			continue
+		}
+		if _, err := t.store.IncrBy(ctx, t.key(tenantID, now, field), n, retention); err != nil {
+			return 0, fmt.Errorf("charge %s: %w", tenantID, err)
+		}
+	}
+	return float64(total) / micro, nil
+}
+
+// Report returns a tenant's spend for the month containing month
+func (t *Tracker) Report(ctx context.Context, tenantID string, month time.Time) (*Report, error) {
+	report := &Report{
+		TenantID:   tenantID,
+		Month:      month.UTC().Format(monthLayout),
+		BudgetUSD:  t.Budget(tenantID),
+		Operations: map[string]float64{},
+	}
+
+	fields := map[string]int64{"total": 0, "estimated": 0, "tokens": 0, "requests": 0}
+	for field := range fields {
+		n, err := t.counter(ctx, t.key(tenantID, month, field))
+		if err != nil {
+			return nil, err
+		}
+		fields[field] = n
+	}
+	report.CostUSD = float64(fields["total"]) / micro
+	report.EstimatedUSD = float64(fields["estimated"]) / micro
+	report.Tokens = fields["tokens"]
+	report.Requests = fields["requests"]
+
+	for _, op := range t.operations() {
+		n, err := t.counter(ctx, t.key(tenantID, month, "op:"+op))
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			report.Operations[op] = float64(n) / micro
+		}
+	}
+
+	if report.BudgetUSD > 0 {
+		remaining := math.Max(report.BudgetUSD-report.CostUSD, 0)
+		report.RemainingUSD = &remaining
+	}
+	return report, nil
+}
+
+// Quote returns the estimated cost in USD of calling each of the given
+// backend paths once
+func (t *Tracker) Quote(paths ...string) float64 {
+	var usd float64
+	for _, path := range paths {
+		usd += t.cfg.Estimates[t.operation(path)]
+	}
+	return math.Round(usd*micro) / micro
+}
+
+// price returns the cost of a call and whether it is an estimate. Reported
+// costs win over reported tokens; failed calls and reads reporting nothing
+// are free.
+func (t *Tracker) price(call Call) (float64, bool) {
+	switch {
+	case call.CostUSD >= 0:
+		return call.CostUSD, false
+	case call.Tokens >= 0:
+		return float64(call.Tokens) / 1000 * t.cfg.TokenPriceUSD, false
+	case call.Status >= http.StatusBadRequest, call.Method == http.MethodGet, call.Method == http.MethodHead:
+		return 0, false
+	}
+	return t.cfg.Estimates[t.operation(call.Path)], true
+}
+
+// operation maps a backend path to the longest configured estimate path it
+// ends with or contains as a segment prefix, so base paths and IDs don't
+// matter: /plan/<id>/replan is /replan
+func (t *Tracker) operation(path string) string {
+	best := ""
+	for op := range t.cfg.Estimates {
+		if len(op) > len(best) && (strings.HasSuffix(path, op) || strings.Contains(path, op+"/")) {
+			best = op
+		}
+	}
+	if best == "" {
+		return operationOther
+	}
+	return best
+}
+
+// operations lists every operation a cost can be charged to
+func (t *Tracker) operations() []string {
+	ops := []string{operationOther}
+	for op := range t.cfg.Estimates {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+func (t *Tracker) key(tenantID string, month time.Time, field string) string {
+	return "cost:" + tenantID + ":" + month.UTC().Format(monthLayout) + ":" + field
+}
+
+// counter reads a counter, 0 when it doesn't exist
+func (t *Tracker) counter(ctx context.Context, key string) (int64, error) {
+	value, ok, err := t.store.Get(ctx, key)
+	if err != nil || !ok {
+		return 0, err
+	}
+	return strconv.ParseInt(string(value), 10, 64)
+}
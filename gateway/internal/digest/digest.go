@@ -0,0 +1,210 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/branding"
+	"github.com/amirhf/learnpath-gateway/internal/events"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/scheduler"
+)
+
+const (
+	// maxPlans is the number of most recent plans a digest covers
+	maxPlans = 3
+	// maxUpcoming is the number of upcoming milestones listed
+	maxUpcoming = 5
+)
+
+// Weekly is the event type digests are dispatched as; the notification
+// worker renders and emails them
+const Weekly = "digest.weekly"
+
+// Digest summarizes a learner's past week
+type Digest struct {
+	UserID              string              `json:"user_id"`
+	WeekStart           time.Time           `json:"week_start"`
+	WeekEnd             time.Time           `json:"week_end"`
+	HoursCompleted      float64             `json:"hours_completed"`
+	HoursPlanned        float64             `json:"hours_planned"`
+	MilestonesCompleted int                 `json:"milestones_completed"`
+	QuizzesTaken        int                 `json:"quizzes_taken"`
+	FocusSessions       int                 `json:"focus_sessions"`
+	FocusMinutes        float64             `json:"focus_minutes"`
+	Streak              repository.Streak   `json:"streak"`
+	UpcomingMilestones  []UpcomingMilestone `json:"upcoming_milestones"`
+	// Branding is the learner's tenant's, for the email sent
+	Branding *branding.Branding `json:"branding,omitempty"`
+}
+
+// Tenants finds the tenant a learner last acted in, "" if unknown
+type Tenants interface {
+	Tenant(ctx context.Context, userID string) (string, error)
+}
+
+// UpcomingMilestone is the next unfinished milestone of a plan
+type UpcomingMilestone struct {
+	PlanID         string  `json:"plan_id"`
+	Goal           string  `json:"goal"`
+	MilestoneID    string  `json:"milestone_id"`
+	Title          string  `json:"title"`
+	EstimatedHours float64 `json:"estimated_hours"`
+}
+
+// Builder compiles digests from gateway-owned progress and the planner's
+// plans
+type Builder struct {
+	repos *repository.Repositories
+	orch  orchestrator.Orchestrator
+}
+
+// NewBuilder creates a digest builder
+func NewBuilder(repos *repository.Repositories, orch orchestrator.Orchestrator) *Builder {
+	return &Builder{repos: repos, orch: orch}
+}
+
+// Build compiles the digest for the seven days up to now. Hours completed
+// are the hours logged on milestones updated during the week; hours
+// planned are the weekly pace of the user's recent plans. Focus is the
+// focus sessions started during the week.
+func (b *Builder) Build(ctx context.Context, userID string, now time.Time) (*Digest, error) {
+	now = now.UTC()
+	d := &Digest{
+		UserID:             userID,
+		WeekStart:          now.AddDate(0, 0, -7),
+		WeekEnd:            now,
+		UpcomingMilestones: []UpcomingMilestone{},
+	}
+
+	progress, err := b.repos.Progress.ListProgress(ctx, userID, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load progress: %w", err)
+	}
+	completed := map[string]bool{}
+	for _, p := range progress {
+		if p.Completed {
+			completed[p.PlanID+"/"+p.MilestoneID] = true
+		}
+		if p.UpdatedAt.Before(d.WeekStart) {
+			continue
+		}
+		d.HoursCompleted += p.HoursSpent
+		if p.Completed {
+			d.MilestonesCompleted++
+		}
+	}
+
+	if d.QuizzesTaken, err = b.repos.Quizzes.CountQuizAttempts(ctx, userID, d.WeekStart); err != nil {
+		return nil, fmt.Errorf("failed to count quizzes: %w", err)
+	}
+
+	sessions, err := b.repos.Focus.ListFocusSessions(ctx, userID, d.WeekStart, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load focus sessions: %w", err)
+	}
+	for _, s := range sessions {
+		d.FocusSessions++
+		d.FocusMinutes += s.FocusedMinutes
+	}
+	d.FocusMinutes = math.Round(d.FocusMinutes*100) / 100
+
+	days, err := b.repos.Progress.ActiveDays(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load activity: %w", err)
+	}
+	d.Streak = repository.ComputeStreak(days, now)
+
+	plans, err := b.orch.GetUserPlans(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].CreatedAt.After(plans[j].CreatedAt) })
+	if len(plans) > maxPlans {
+		plans = plans[:maxPlans]
+	}
+	for _, summary := range plans {
+		if summary.EstimatedWeeks > 0 {
+			d.HoursPlanned += summary.TotalHours / float64(summary.EstimatedWeeks)
+		}
+
+		plan, err := b.orch.GetPlan(ctx, summary.PlanID)
+		if err != nil {
+			return nil, err
+		}
+		if next := nextMilestone(plan, completed); next != nil {
+			d.UpcomingMilestones = append(d.UpcomingMilestones, *next)
+		}
+	}
+	if len(d.UpcomingMilestones) > maxUpcoming {
+		d.UpcomingMilestones = d.UpcomingMilestones[:maxUpcoming]
+	}
+	return d, nil
+}
+
+// nextMilestone returns the first milestone of plan not yet completed
+func nextMilestone(plan *models.LearningPath, completed map[string]bool) *UpcomingMilestone {
+	milestones := append([]models.Milestone(nil), plan.Milestones...)
+	sort.Slice(milestones, func(i, j int) bool { return milestones[i].Order < milestones[j].Order })
+
+	planID := plan.PlanID.String()
+	for _, m := range milestones {
+		if completed[planID+"/"+m.MilestoneID.String()] {
+			continue
+		}
+		return &UpcomingMilestone{
+			PlanID:         planID,
+			Goal:           plan.Goal,
+			MilestoneID:    m.MilestoneID.String(),
+			Title:          m.Title,
+			EstimatedHours: m.EstimatedHours,
+		}
+	}
+	return nil
+}
+
+// Job compiles a digest for every user active in the past week and
+// dispatches it through the event bus, branded for their tenant
+func Job(b *Builder, bus *events.Bus, tenants Tenants, brands *branding.Store) scheduler.Func {
+	return func(ctx context.Context) error {
+		if !bus.Enabled() {
+			return nil
+		}
+
+		now := time.Now()
+		users, err := b.repos.Progress.ActiveUsers(ctx, now.AddDate(0, 0, -7))
+		if err != nil {
+			return fmt.Errorf("failed to list active users: %w", err)
+		}
+
+		var failed int
+		for _, userID := range users {
+			d, err := b.Build(ctx, userID, now)
+			if err != nil {
+				log.Printf("digest: failed to build digest for %s: %v", userID, err)
+				failed++
+				continue
+			}
+			tenantID, err := tenants.Tenant(ctx, userID)
+			if err != nil {
+				log.Printf("digest: failed to look up tenant of %s: %v", userID, err)
+			}
+			brand, err := brands.Get(ctx, tenantID)
+			if err != nil {
+				log.Printf("digest: failed to load branding of tenant %s: %v", tenantID, err)
+			}
+			d.Branding = &brand
+			bus.Emit(ctx, Weekly, userID, d)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d digests failed", failed, len(users))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// consulResolver resolves services through Consul's health API. Only
+// instances passing their health checks are returned.
+type consulResolver struct {
+	addr   string
+	scheme string
+}
+
+type consulServiceEntry struct {
+	Node struct {
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service struct {
+		Address string `json:"Address"`
+		Port    int    `json:"Port"`
+	} `json:"Service"`
+}
+
+// Resolve implements Resolver.
+func (r *consulResolver) Resolve(ctx context.Context, name string) ([]string, error) {
+	endpoint := fmt.Sprintf("%s/v1/health/service/%s?passing=true", r.addr, url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Consul request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Consul: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Consul returned non-OK status: %d", resp.StatusCode)
+	}
+
+	var entries []consulServiceEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to decode Consul response: %w", err)
+	}
+
+	urls := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+		urls = append(urls, fmt.Sprintf("%s://%s", r.scheme, net.JoinHostPort(host, fmt.Sprint(entry.Service.Port))))
+	}
+	return urls, nil
+}
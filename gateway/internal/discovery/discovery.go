@@ -0,0 +1,89 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Discovery modes
+const (
+	ModeStatic     = "static"
+	ModeDNS        = "dns"
+	ModeKubernetes = "kubernetes"
+	ModeConsul     = "consul"
+)
+
+// Resolver looks up the current base URLs of a backend service.
+type Resolver interface {
+	Resolve(ctx context.Context, name string) ([]string, error)
+}
+
+// Options configures how backend addresses are discovered.
+type Options struct {
+	Mode            string
+	Scheme          string // Scheme used to build URLs, e.g. "http"
+	ConsulAddr      string
+	KubeNamespace   string
+	RefreshInterval time.Duration
+}
+
+// NewResolver returns the resolver for the configured mode, or nil for static
+// configuration.
+func NewResolver(opts Options) (Resolver, error) {
+	switch opts.Mode {
+	case "", ModeStatic:
+		return nil, nil
+	case ModeDNS:
+		return &dnsResolver{scheme: opts.Scheme}, nil
+	case ModeKubernetes:
+		return newKubernetesResolver(opts.KubeNamespace, opts.Scheme)
+	case ModeConsul:
+		return &consulResolver{addr: opts.ConsulAddr, scheme: opts.Scheme}, nil
+	default:
+		return nil, fmt.Errorf("unknown discovery mode: %s", opts.Mode)
+	}
+}
+
+// Target pairs a discoverable service name with the callback that receives
+// its resolved URLs.
+type Target struct {
+	Name   string
+	Update func(urls []string)
+}
+
+// Run resolves every target immediately and then on each interval until ctx
+// is cancelled. Failed or empty lookups keep the previous endpoints.
+func Run(ctx context.Context, resolver Resolver, interval time.Duration, targets []Target) {
+	refresh := func() {
+		for _, target := range targets {
+			lookupCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			urls, err := resolver.Resolve(lookupCtx, target.Name)
+			cancel()
+
+			if err != nil {
+				log.Printf("Service discovery for %s failed: %v", target.Name, err)
+				continue
+			}
+			if len(urls) == 0 {
+				log.Printf("Service discovery for %s returned no healthy endpoints", target.Name)
+				continue
+			}
+			target.Update(urls)
+		}
+	}
+
+	refresh()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refresh()
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// dnsResolver resolves services via DNS SRV records, e.g.
+// _http._tcp.rag.default.svc.cluster.local.
+type dnsResolver struct {
+	scheme string
+}
+
+// Resolve implements Resolver.
+func (r *dnsResolver) Resolve(ctx context.Context, name string) ([]string, error) {
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, fmt.Errorf("SRV lookup failed: %w", err)
+	}
+
+	urls := make([]string, 0, len(records))
+	for _, srv := range records {
+		host := strings.TrimSuffix(srv.Target, ".")
+		urls = append(urls, fmt.Sprintf("%s://%s", r.scheme, net.JoinHostPort(host, fmt.Sprint(srv.Port))))
+	}
+	return urls, nil
+}
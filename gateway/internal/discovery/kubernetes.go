@@ -0,0 +1,114 @@
+package discovery
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// kubernetesResolver resolves services from the Kubernetes Endpoints API
+// using the pod's service account. Only ready addresses are returned.
+type kubernetesResolver struct {
+	client    *http.Client
+	apiServer string
+	namespace string
+	scheme    string
+	token     string
+}
+
+type kubeEndpoints struct {
+	Subsets []struct {
+		Addresses []struct {
+			IP string `json:"ip"`
+		} `json:"addresses"`
+		Ports []struct {
+			Name string `json:"name"`
+			Port int    `json:"port"`
+		} `json:"ports"`
+	} `json:"subsets"`
+}
+
+func newKubernetesResolver(namespace, scheme string) (*kubernetesResolver, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("kubernetes discovery requires running in a cluster")
+	}
+
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+	caCert, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caCert)
+
+	if namespace == "" {
+		if ns, err := os.ReadFile(serviceAccountDir + "/namespace"); err == nil {
+			namespace = strings.TrimSpace(string(ns))
+		}
+	}
+
+	return &kubernetesResolver{
+		client: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+		},
+		apiServer: "https://" + net.JoinHostPort(host, port),
+		namespace: namespace,
+		scheme:    scheme,
+		token:     strings.TrimSpace(string(token)),
+	}, nil
+}
+
+// Resolve implements Resolver. The name is the Kubernetes Service name.
+func (r *kubernetesResolver) Resolve(ctx context.Context, name string) ([]string, error) {
+	endpoint := fmt.Sprintf("%s/api/v1/namespaces/%s/endpoints/%s", r.apiServer, r.namespace, name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+r.token)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Kubernetes API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Kubernetes API returned non-OK status: %d", resp.StatusCode)
+	}
+
+	var endpoints kubeEndpoints
+	if err := json.NewDecoder(resp.Body).Decode(&endpoints); err != nil {
+		return nil, fmt.Errorf("failed to decode Kubernetes endpoints: %w", err)
+	}
+
+	var urls []string
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Ports) == 0 {
+			continue
+		}
+		// Prefer a port named "http", otherwise the first one
+		port := subset.Ports[0].Port
+		for _, p := range subset.Ports {
+			if p.Name == "http" {
+				port = p.Port
+			}
+		}
+		for _, addr := range subset.Addresses {
+			urls = append(urls, fmt.Sprintf("%s://%s", r.scheme, net.JoinHostPort(addr.IP, fmt.Sprint(port))))
+		}
+	}
+	return urls, nil
+}
@@ -0,0 +1,77 @@
+package diversity
+
+import (
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+)
+
+// Oversample is how many times more results to fetch from the RAG service
+// when limits apply, so the kept results still fill the requested count
+const Oversample = 3
+
+// maxTopK is the most results the RAG service returns for one search
+const maxTopK = 50
+
+// Resolve returns the limits for a request: the global caps, replaced by
+// the tenant's where it sets them, then by the request's
+func Resolve(cfg config.DiversityConfig, tenantID string, req *models.Diversity) models.Diversity {
+	limits := models.Diversity{MaxPerProvider: cfg.MaxPerProvider, MaxPerMediaType: cfg.MaxPerMediaType}
+	if override, ok := cfg.Tenants[tenantID]; ok {
+		if override.MaxPerProvider != nil {
+			limits.MaxPerProvider = *override.MaxPerProvider
+		}
+		if override.MaxPerMediaType != nil {
+			limits.MaxPerMediaType = *override.MaxPerMediaType
+		}
+	}
+	if req != nil {
+		if req.MaxPerProvider > 0 {
+			limits.MaxPerProvider = req.MaxPerProvider
+		}
+		if req.MaxPerMediaType > 0 {
+			limits.MaxPerMediaType = req.MaxPerMediaType
+		}
+	}
+	return limits
+}
+
+// TopK returns how many results to request so that topK remain after
+// applying limits
+func TopK(topK int, limits models.Diversity) int {
+	if !limits.Active() {
+		return topK
+	}
+	return min(topK*Oversample, maxTopK)
+}
+
+// Apply keeps items in rank order, skipping any whose provider or media
+// type has already reached its cap, and returns at most topK of them.
+// Items without a provider or media type are not counted against it.
+func Apply[T any](items []T, limits models.Diversity, topK int, key func(T) (provider, mediaType *string)) []T {
+	if !limits.Active() {
+		return items
+	}
+	providers := map[string]int{}
+	mediaTypes := map[string]int{}
+	kept := items[:0:0]
+	for _, item := range items {
+		if topK > 0 && len(kept) == topK {
+			break
+		}
+		provider, mediaType := key(item)
+		if provider != nil && limits.MaxPerProvider > 0 && providers[*provider] >= limits.MaxPerProvider {
+			continue
+		}
+		if mediaType != nil && limits.MaxPerMediaType > 0 && mediaTypes[*mediaType] >= limits.MaxPerMediaType {
+			continue
+		}
+		if provider != nil {
+			providers[*provider]++
+		}
+		if mediaType != nil {
+			mediaTypes[*mediaType]++
+		}
+		kept = append(kept, item)
+	}
+	return kept
+}
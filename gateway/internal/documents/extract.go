@@ -0,0 +1,185 @@
+// Package documents turns uploaded files into text for ingestion and keeps
+// the originals in blob storage
+package documents
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Document formats accepted for upload
+const (
+	FormatPDF      = "pdf"
+	FormatDOCX     = "docx"
+	FormatMarkdown = "markdown"
+)
+
+// mediaTypes are stored with each original
+var mediaTypes = map[string]string{
+	FormatPDF:      "application/pdf",
+	FormatDOCX:     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	FormatMarkdown: "text/markdown",
+}
+
+// ErrUnsupported is returned for files that aren't PDF, DOCX or Markdown
+var ErrUnsupported = errors.New("unsupported document type; upload a PDF, DOCX or Markdown file")
+
+// ErrNoText is returned when a document has no extractable text, e.g. a
+// scanned PDF
+var ErrNoText = errors.New("document contains no extractable text")
+
+// Format picks a document's format from its name, checked against its
+// leading bytes
+func Format(filename string, data []byte) (string, error) {
+	var format string
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".pdf":
+		format = FormatPDF
+	case ".docx":
+		format = FormatDOCX
+	case ".md", ".markdown", ".txt":
+		format = FormatMarkdown
+	default:
+		return "", ErrUnsupported
+	}
+
+	ok := true
+	switch format {
+	case FormatPDF:
+		ok = bytes.HasPrefix(data, []byte("%PDF-"))
+	case FormatDOCX:
+		ok = bytes.HasPrefix(data, []byte("PK\x03\x04"))
+	case FormatMarkdown:
+		ok = utf8.Valid(data)
+	}
+	if !ok {
+		return "", fmt.Errorf("file content does not match its %s extension", path.Ext(filename))
+	}
+	return format, nil
+}
+
+// MediaType returns the MIME type of a format
+func MediaType(format string) string {
+	return mediaTypes[format]
+}
+
+// Extract returns the plain text of a document
+func Extract(format string, data []byte) (string, error) {
+	var text string
+	var err error
+	switch format {
+	case FormatPDF:
+		text = extractPDF(data)
+	case FormatDOCX:
+		text, err = extractDOCX(data)
+	case FormatMarkdown:
+		text = string(data)
+	default:
+		return "", ErrUnsupported
+	}
+	if err != nil {
+		return "", err
+	}
+	text = strings.TrimSpace(text)
+	if !readable(text) {
+		return "", ErrNoText
+	}
+	return text, nil
+}
+
+// Title returns the first Markdown heading or line of text, for documents
+// uploaded without a title
+func Title(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > 120 {
+			line = string(runes[:120])
+		}
+		return line
+	}
+	return ""
+}
+
+// readable reports whether text is mostly letters, digits and spaces;
+// PDFs with embedded font encodings extract as noise
+func readable(text string) bool {
+	if text == "" {
+		return false
+	}
+	good, total := 0, 0
+	for _, r := range text {
+		total++
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) || unicode.IsPunct(r) {
+			good++
+		}
+	}
+	return good*10 >= total*8
+}
+
+// extractDOCX reads the paragraphs of word/document.xml
+func extractDOCX(data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("invalid DOCX file: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", fmt.Errorf("invalid DOCX file: %w", err)
+		}
+		defer rc.Close()
+		return docxText(rc)
+	}
+	return "", errors.New("invalid DOCX file: missing word/document.xml")
+}
+
+func docxText(r io.Reader) (string, error) {
+	var text strings.Builder
+	inText := false
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return text.String(), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid DOCX file: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				text.WriteByte('\t')
+			case "br", "cr":
+				text.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		}
+	}
+}
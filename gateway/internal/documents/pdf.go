@@ -0,0 +1,171 @@
+package documents
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// maxStreamSize caps a decompressed PDF stream, against zip bombs
+const maxStreamSize = 16 << 20
+
+// streamPattern finds each stream with the dictionary in front of it
+var streamPattern = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n`)
+
+// extractPDF pulls the text drawn by the Tj, TJ, ' and " operators out of
+// the content streams. It handles uncompressed and Flate streams with
+// single-byte font encodings, which covers most PDFs exported from office
+// suites; anything else extracts as noise and is caught by readable.
+func extractPDF(data []byte) string {
+	var text strings.Builder
+	for _, loc := range streamPattern.FindAllSubmatchIndex(data, -1) {
+		dict := data[loc[2]:loc[3]]
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		raw := data[start : start+end]
+		if bytes.Contains(dict, []byte("/Subtype")) {
+			continue // Images, fonts and other embedded files
+		}
+
+		var content []byte
+		switch {
+		case bytes.Contains(dict, []byte("/FlateDecode")):
+			zr, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				continue
+			}
+			// Truncated streams still yield what was inflated
+			content, _ = io.ReadAll(io.LimitReader(zr, maxStreamSize))
+		case bytes.Contains(dict, []byte("/Filter")):
+			continue // Image or other encoded data
+		default:
+			content = raw
+		}
+		contentText(content, &text)
+	}
+	return text.String()
+}
+
+// contentText appends the text shown by a content stream
+func contentText(content []byte, out *strings.Builder) {
+	var pending []string // Strings since the last operator
+	inText := false
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '(':
+			s, n := literalString(content[i:])
+			pending = append(pending, s)
+			i += n
+			continue
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+			continue
+		case isRegular(c):
+			j := i
+			for j < len(content) && isRegular(content[j]) {
+				j++
+			}
+			op := string(content[i:j])
+			i = j
+			switch op {
+			case "BT":
+				inText, pending = true, nil
+			case "ET":
+				inText = false
+				out.WriteByte('\n')
+			case "Tj", "TJ":
+				if inText {
+					out.WriteString(strings.Join(pending, ""))
+				}
+			case "'", "\"", "T*":
+				if inText {
+					out.WriteByte('\n')
+					out.WriteString(strings.Join(pending, ""))
+				}
+			case "Td", "TD", "Tm":
+				// Usually a new line; extra breaks don't hurt indexing
+				if inText {
+					out.WriteByte('\n')
+				}
+			}
+			if !isNumber(op) {
+				pending = nil
+			}
+			continue
+		}
+		i++
+	}
+}
+
+// literalString decodes a (...) string at the start of data and returns it
+// with the number of bytes consumed
+func literalString(data []byte) (string, int) {
+	var s strings.Builder
+	depth := 0
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '(':
+			if depth > 0 {
+				s.WriteByte(c)
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s.String(), i + 1
+			}
+			s.WriteRune(rune(c))
+		case '\\':
+			i++
+			if i >= len(data) {
+				break
+			}
+			switch e := data[i]; e {
+			case 'n':
+				s.WriteByte('\n')
+			case 'r', 'b', 'f':
+			case 't':
+				s.WriteByte('\t')
+			case '\r', '\n':
+				// Line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					v, n := 0, 0
+					for n < 3 && i+n < len(data) && data[i+n] >= '0' && data[i+n] <= '7' {
+						v = v*8 + int(data[i+n]-'0')
+						n++
+					}
+					i += n - 1
+					s.WriteRune(rune(v & 0xff))
+				} else {
+					s.WriteRune(rune(e))
+				}
+			}
+		default:
+			s.WriteRune(rune(c)) // Read as Latin-1
+		}
+	}
+	return s.String(), len(data)
+}
+
+// isRegular reports whether c can be part of a PDF operator or number
+func isRegular(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0, '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return false
+	}
+	return true
+}
+
+func isNumber(token string) bool {
+	return strings.Trim(token, "0123456789.+-") == ""
+}
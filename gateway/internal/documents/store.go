@@ -0,0 +1,149 @@
+package documents
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+)
+
+// Store keeps uploaded originals
+type Store interface {
+	// Put saves data under key and returns where it was stored, e.g.
+	// s3://bucket/key
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+}
+
+// NewStore creates the store selected by cfg.Backend
+func NewStore(cfg config.UploadConfig) (Store, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return &LocalStore{Dir: cfg.Dir}, nil
+	case "s3":
+		if cfg.S3Bucket == "" {
+			return nil, fmt.Errorf("upload storage s3 requires a bucket")
+		}
+		return &S3Store{cfg: cfg, client: &http.Client{Timeout: time.Minute}}, nil
+	}
+	return nil, fmt.Errorf("unknown upload storage %q", cfg.Backend)
+}
+
+// LocalStore writes files under a directory
+type LocalStore struct {
+	Dir string
+}
+
+func (s *LocalStore) Put(_ context.Context, key string, data []byte, _ string) (string, error) {
+	path := filepath.Join(s.Dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		return "", fmt.Errorf("failed to write upload: %w", err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(), nil
+}
+
+// S3Store uploads to an S3 bucket, or any S3-compatible service when an
+// endpoint is set, signing requests with AWS Signature Version 4
+type S3Store struct {
+	cfg    config.UploadConfig
+	client *http.Client
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	// Virtual-hosted style on AWS, path style on custom endpoints
+	// (MinIO, R2, ...)
+	var target string
+	if s.cfg.S3Endpoint != "" {
+		target = strings.TrimSuffix(s.cfg.S3Endpoint, "/") + "/" + s.cfg.S3Bucket + "/" + escapeKey(key)
+	} else {
+		target = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.cfg.S3Bucket, s.cfg.S3Region, escapeKey(key))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, data, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("upload to s3 failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("upload to s3 failed: status %d: %s", resp.StatusCode, body)
+	}
+	return "s3://" + s.cfg.S3Bucket + "/" + key, nil
+}
+
+// sign adds a SigV4 Authorization header covering the host, content hash
+// and date
+func (s *S3Store) sign(req *http.Request, payload []byte, now time.Time) {
+	sum := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(sum[:])
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonical))
+
+	scope := day + "/" + s.cfg.S3Region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.S3SecretKey), day)
+	key = hmacSHA256(key, s.cfg.S3Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.S3AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escapeKey URI-encodes each segment of an object key as SigV4 expects
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "+", "%2B")
+	}
+	return strings.Join(segments, "/")
+}
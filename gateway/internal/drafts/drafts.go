@@ -0,0 +1,95 @@
+package drafts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Draft is a plan request built up step by step: created with a goal,
+// refined with skills and preferences, then committed to generate the plan
+type Draft struct {
+	ID               string               `json:"draft_id"`
+	UserID           string               `json:"user_id"`
+	Goal             string               `json:"goal"`
+	CurrentSkills    []string             `json:"current_skills,omitempty"`
+	TimeBudgetHours  int                  `json:"time_budget_hours,omitempty"`
+	HoursPerWeek     int                  `json:"hours_per_week,omitempty"`
+	Preferences      models.Preferences   `json:"preferences"`
+	Language         string               `json:"language,omitempty"`
+	GenerateQuiz     bool                 `json:"generate_quiz"`
+	QuizPerMilestone bool                 `json:"quiz_per_milestone"`
+	NumQuestions     int                  `json:"num_questions,omitempty"`
+	QuizDifficulty   string               `json:"quiz_difficulty,omitempty"`
+	Step             int                  `json:"step"`              // Refinements applied so far
+	Preview          *models.PlanEstimate `json:"preview,omitempty"` // From the last refinement that asked for one
+	CreatedAt        time.Time            `json:"created_at"`
+	UpdatedAt        time.Time            `json:"updated_at"`
+	ExpiresAt        time.Time            `json:"expires_at"`
+}
+
+// Store keeps drafts in the shared store so any replica can continue a
+// draft. Drafts expire once left untouched for the configured TTL.
+type Store struct {
+	cfg   config.DraftConfig
+	store storage.KeyValue
+}
+
+// New creates a draft store
+func New(cfg config.DraftConfig, store storage.KeyValue) *Store {
+	return &Store{cfg: cfg, store: store}
+}
+
+// Create assigns a new draft its ID and saves it
+func (s *Store) Create(ctx context.Context, draft *Draft) error {
+	draft.ID = uuid.NewString()
+	draft.CreatedAt = time.Now().UTC()
+	return s.Save(ctx, draft)
+}
+
+// Get returns one of a user's drafts. Drafts of other users are reported as
+// not found.
+func (s *Store) Get(ctx context.Context, userID, draftID string) (*Draft, error) {
+	data, ok, err := s.store.Get(ctx, draftKey(draftID))
+	if err != nil {
+		return nil, fmt.Errorf("load draft: %w", err)
+	}
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	var draft Draft
+	if err := json.Unmarshal(data, &draft); err != nil {
+		return nil, fmt.Errorf("decode draft: %w", err)
+	}
+	if draft.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	return &draft, nil
+}
+
+// Save stores a draft, restarting its TTL
+func (s *Store) Save(ctx context.Context, draft *Draft) error {
+	draft.UpdatedAt = time.Now().UTC()
+	draft.ExpiresAt = draft.UpdatedAt.Add(s.cfg.TTL)
+	data, err := json.Marshal(draft)
+	if err != nil {
+		return err
+	}
+	return s.store.Set(ctx, draftKey(draft.ID), data, s.cfg.TTL)
+}
+
+// Delete removes a draft
+func (s *Store) Delete(ctx context.Context, draftID string) error {
+	return s.store.Delete(ctx, draftKey(draftID))
+}
+
+func draftKey(draftID string) string {
+	return "drafts:" + draftID
+}
@@ -0,0 +1,324 @@
+// Package errorreport sends panics and server errors to Sentry, or any
+// tracker accepting Sentry envelopes, without the Sentry SDK.
+package errorreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	mrand "math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/clients"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+)
+
+// maxInFlight caps reports being sent at once; more are dropped so a burst
+// of errors can't pile up goroutines
+const maxInFlight = 16
+
+// Request describes the request an error happened in
+type Request struct {
+	Method    string
+	URL       string
+	Route     string
+	RequestID string
+	UserID    string
+	TenantID  string
+	Status    int
+}
+
+// Reporter sends error events to the tracker named by the DSN
+type Reporter struct {
+	cfg      config.ErrorReportingConfig
+	endpoint string // Envelope endpoint
+	auth     string // X-Sentry-Auth header
+	client   *http.Client
+	server   string
+	inFlight chan struct{}
+}
+
+// New creates a reporter, or returns nil when no DSN is configured.
+// environment is used when the config names none.
+func New(cfg config.ErrorReportingConfig, environment string) (*Reporter, error) {
+	if cfg.DSN == "" {
+		return nil, nil
+	}
+	dsn, err := url.Parse(cfg.DSN)
+	if err != nil || dsn.User == nil || dsn.Host == "" {
+		return nil, fmt.Errorf("errorreport: invalid DSN")
+	}
+	path := strings.Trim(dsn.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	project := path[slash+1:]
+	if project == "" {
+		return nil, fmt.Errorf("errorreport: DSN has no project ID")
+	}
+	prefix := ""
+	if slash >= 0 {
+		prefix = "/" + path[:slash]
+	}
+	if cfg.Environment == "" {
+		cfg.Environment = environment
+	}
+	server, _ := os.Hostname()
+
+	return &Reporter{
+		cfg:      cfg,
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/envelope/", dsn.Scheme, dsn.Host, prefix, project),
+		auth:     fmt.Sprintf("Sentry sentry_version=7, sentry_client=learnpath-gateway/1.0, sentry_key=%s", dsn.User.Username()),
+		client:   &http.Client{Timeout: cfg.Timeout},
+		server:   server,
+		inFlight: make(chan struct{}, maxInFlight),
+	}, nil
+}
+
+// Enabled reports whether errors are sent anywhere
+func (r *Reporter) Enabled() bool {
+	return r != nil
+}
+
+// CapturePanic reports a recovered panic with the stack it unwound from.
+// Call it from the deferred function that recovered.
+func (r *Reporter) CapturePanic(req Request, recovered any) {
+	if !r.Enabled() {
+		return
+	}
+	value := exception{
+		Type:       fmt.Sprintf("%T", recovered),
+		Value:      fmt.Sprint(recovered),
+		Mechanism:  &mechanism{Type: "recovery", Handled: false},
+		Stacktrace: &stacktrace{Frames: panicFrames()},
+	}
+	if err, ok := recovered.(error); ok {
+		value.Value = err.Error()
+	}
+	r.send(r.event(req, "fatal", "panic: "+value.Value, []exception{value}, nil))
+}
+
+// CaptureErrors reports a 5xx response and the errors handlers attached to
+// it, at SampleRate. Upstream errors carry the failed backend call.
+func (r *Reporter) CaptureErrors(req Request, errs []error) {
+	if !r.Enabled() || mrand.Float64() >= r.cfg.SampleRate {
+		return
+	}
+	message := fmt.Sprintf("%s %s returned %d", req.Method, req.Route, req.Status)
+	var exceptions []exception
+	var upstream map[string]any
+	for _, err := range errs {
+		exceptions = append(exceptions, exception{Type: fmt.Sprintf("%T", err), Value: err.Error()})
+		if u, ok := clients.AsUpstreamError(err); ok && upstream == nil {
+			upstream = map[string]any{
+				"service":     u.Service,
+				"op":          u.Op,
+				"status_code": u.StatusCode,
+				"code":        u.Code,
+				"message":     u.Message,
+				"retryable":   u.Retryable,
+				"body":        u.Body,
+			}
+		}
+	}
+	if len(exceptions) > 0 {
+		message = exceptions[len(exceptions)-1].Value
+	}
+	contexts := map[string]any{}
+	if upstream != nil {
+		contexts["upstream"] = upstream
+	}
+	r.send(r.event(req, "error", message, exceptions, contexts))
+}
+
+type event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Logger      string            `json:"logger"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Transaction string            `json:"transaction,omitempty"`
+	Exception   *exceptions       `json:"exception,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	User        map[string]string `json:"user,omitempty"`
+	Request     map[string]string `json:"request,omitempty"`
+	Contexts    map[string]any    `json:"contexts,omitempty"`
+}
+
+type exceptions struct {
+	Values []exception `json:"values"`
+}
+
+type exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Mechanism  *mechanism  `json:"mechanism,omitempty"`
+	Stacktrace *stacktrace `json:"stacktrace,omitempty"`
+}
+
+type mechanism struct {
+	Type    string `json:"type"`
+	Handled bool   `json:"handled"`
+}
+
+type stacktrace struct {
+	Frames []frame `json:"frames"`
+}
+
+type frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+func (r *Reporter) event(req Request, level, message string, values []exception, contexts map[string]any) event {
+	e := event{
+		EventID:     eventID(),
+		Timestamp:   time.Now().UTC().Format(time.RFC3339Nano),
+		Level:       level,
+		Platform:    "go",
+		Logger:      "gateway",
+		ServerName:  r.server,
+		Environment: r.cfg.Environment,
+		Release:     r.cfg.Release,
+		Message:     message,
+		Transaction: req.Method + " " + req.Route,
+		Tags: map[string]string{
+			"request_id": req.RequestID,
+			"status":     fmt.Sprint(req.Status),
+		},
+		Request:  map[string]string{"method": req.Method, "url": req.URL},
+		Contexts: contexts,
+	}
+	if req.TenantID != "" {
+		e.Tags["tenant_id"] = req.TenantID
+	}
+	if req.UserID != "" {
+		e.User = map[string]string{"id": req.UserID}
+	}
+	if len(values) > 0 {
+		e.Exception = &exceptions{Values: values}
+	}
+	return e
+}
+
+// send posts the event in the background; reports are best effort
+func (r *Reporter) send(e event) {
+	select {
+	case r.inFlight <- struct{}{}:
+	default:
+		log.Printf("errorreport: too many reports in flight, dropped %s", e.EventID)
+		return
+	}
+	go func() {
+		defer func() { <-r.inFlight }()
+		if err := r.post(e); err != nil {
+			log.Printf("errorreport: failed to send %s: %v", e.EventID, err)
+		}
+	}()
+}
+
+func (r *Reporter) post(e event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	header, _ := json.Marshal(map[string]string{"event_id": e.EventID, "sent_at": time.Now().UTC().Format(time.RFC3339)})
+	item, _ := json.Marshal(map[string]any{"type": "event", "length": len(payload)})
+	var body bytes.Buffer
+	for _, line := range [][]byte{header, item, payload} {
+		body.Write(line)
+		body.WriteByte('\n')
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// panicFrames returns the stack of the panicking goroutine, oldest call
+// first as Sentry expects, without the runtime's panic machinery and the
+// reporter itself
+func panicFrames() []frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(4, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []frame
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "runtime.") {
+			module, function := splitFunction(f.Function)
+			stack = append(stack, frame{
+				Function: function,
+				Module:   module,
+				Filename: shortPath(f.File),
+				AbsPath:  f.File,
+				Lineno:   f.Line,
+				InApp:    strings.HasPrefix(module, "github.com/amirhf/learnpath-gateway") || module == "main",
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(stack)-1; i < j; i, j = i+1, j-1 {
+		stack[i], stack[j] = stack[j], stack[i]
+	}
+	return stack
+}
+
+// splitFunction splits "github.com/x/y/pkg.(*T).Method" into its package
+// path and function name
+func splitFunction(name string) (string, string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	return name[:slash+1+dot], name[slash+1+dot+1:]
+}
+
+func shortPath(file string) string {
+	parts := strings.Split(file, "/")
+	if len(parts) > 2 {
+		return strings.Join(parts[len(parts)-2:], "/")
+	}
+	return file
+}
+
+func eventID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
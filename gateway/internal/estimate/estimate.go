@@ -0,0 +1,106 @@
+// Package estimate works out how long a resource takes to get through,
+// for resources ingested without a duration
+package estimate
+
+import (
+	"context"
+	"math"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/preview"
+	"github.com/amirhf/learnpath-gateway/internal/workerpool"
+)
+
+// parallelism bounds the pages fetched at once for a batch
+const parallelism = 4
+
+// Estimation methods
+const (
+	MethodVideo     = "video_metadata" // Duration from OpenGraph or oEmbed
+	MethodWordCount = "word_count"     // Reading time of the page text
+)
+
+// Estimate is the duration worked out for one URL
+type Estimate struct {
+	URL         string `json:"url"`
+	DurationMin int    `json:"duration_min,omitempty"`
+	Method      string `json:"method,omitempty"`
+	Words       int    `json:"words,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Estimator derives durations from link previews
+type Estimator struct {
+	cfg      config.DurationConfig
+	previews *preview.Fetcher
+}
+
+// New creates an estimator
+func New(cfg config.DurationConfig, previews *preview.Fetcher) *Estimator {
+	return &Estimator{cfg: cfg, previews: previews}
+}
+
+// Enabled reports whether durations are estimated at ingestion
+func (e *Estimator) Enabled() bool {
+	return e != nil && e.cfg.Enabled
+}
+
+// Durations estimates each URL, using cached previews where it can, and
+// returns the minutes for those it could estimate
+func (e *Estimator) Durations(ctx context.Context, urls []string) map[string]int {
+	durations := make(map[string]int, len(urls))
+	for _, est := range e.run(ctx, urls, false) {
+		if est.DurationMin > 0 {
+			durations[est.URL] = est.DurationMin
+		}
+	}
+	return durations
+}
+
+// Recalculate estimates each URL from a fresh fetch, replacing cached
+// previews
+func (e *Estimator) Recalculate(ctx context.Context, urls []string) []Estimate {
+	return e.run(ctx, urls, true)
+}
+
+func (e *Estimator) run(ctx context.Context, urls []string, fresh bool) []Estimate {
+	estimates := make([]Estimate, len(urls))
+	group, ctx := workerpool.New(ctx, parallelism)
+	for i, url := range urls {
+		i, url := i, url
+		group.Go(func(ctx context.Context) error {
+			estimates[i] = e.estimate(ctx, url, fresh)
+			return nil // One page failing doesn't stop the others
+		})
+	}
+	group.Wait()
+	return estimates
+}
+
+func (e *Estimator) estimate(ctx context.Context, url string, fresh bool) Estimate {
+	est := Estimate{URL: url}
+	var p *preview.Preview
+	var err error
+	if fresh {
+		p, err = e.previews.Refresh(ctx, url)
+	} else {
+		p, _, err = e.previews.Get(ctx, url)
+	}
+	if err != nil {
+		est.Error = err.Error()
+		return est
+	}
+
+	switch {
+	case p.DurationSeconds > 0:
+		est.Method = MethodVideo
+		est.DurationMin = int(math.Ceil(float64(p.DurationSeconds) / 60))
+	case p.WordCount > 0:
+		est.Method = MethodWordCount
+		est.Words = p.WordCount
+		est.DurationMin = int(math.Ceil(float64(p.WordCount) / float64(e.cfg.WordsPerMinute)))
+	default:
+		est.Error = "page has no duration metadata or readable text"
+	}
+	return est
+}
@@ -0,0 +1,181 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Event types
+const (
+	PlanCreated      = "plan.created"
+	QuizGenerated    = "quiz.generated"
+	QuizSubmitted    = "quiz.submitted"
+	QuizGraded       = "quiz.graded"
+	ProgressRecorded = "progress.recorded"
+	PlanRemediated   = "plan.remediated"
+)
+
+// Event is the envelope published for every domain event. Delivery is
+// at-least-once; consumers should deduplicate on ID.
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	UserID     string    `json:"user_id,omitempty"`
+	Data       any       `json:"data"`
+}
+
+// Publisher delivers a serialized event to a message bus
+type Publisher interface {
+	Publish(ctx context.Context, subject string, payload []byte) error
+	Close() error
+}
+
+// Backends
+const (
+	NATS  = "nats"
+	Kafka = "kafka"
+)
+
+// Options selects and configures a publisher
+type Options struct {
+	Backend string // empty (disabled), nats or kafka
+	URL     string
+}
+
+// NewPublisher creates the configured publisher, or nil when events are
+// disabled
+func NewPublisher(opts Options) (Publisher, error) {
+	switch opts.Backend {
+	case "":
+		return nil, nil
+	case NATS:
+		return NewNATS(opts.URL)
+	case Kafka:
+		return NewKafkaREST(opts.URL)
+	default:
+		return nil, fmt.Errorf("unknown events backend %q", opts.Backend)
+	}
+}
+
+const (
+	// claimLease is how long a relay owns a claimed batch before another
+	// relay may retry it
+	claimLease = 30 * time.Second
+	// publishTimeout bounds a single publish
+	publishTimeout = 5 * time.Second
+)
+
+// Bus records domain events in the outbox and relays them to the publisher.
+// A nil Bus, or one without a publisher, drops events.
+type Bus struct {
+	outbox    repository.OutboxRepository
+	publisher Publisher
+	prefix    string
+}
+
+// NewBus creates a bus relaying outbox events to publisher, prefixing each
+// event type to form the subject
+func NewBus(outbox repository.OutboxRepository, publisher Publisher, prefix string) *Bus {
+	return &Bus{outbox: outbox, publisher: publisher, prefix: prefix}
+}
+
+// Emit records an event for publishing. Failures are logged rather than
+// returned so a bus problem never fails the request that caused the event.
+func (b *Bus) Emit(ctx context.Context, eventType, userID string, data any) {
+	if !b.Enabled() {
+		return
+	}
+
+	event := Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		UserID:     userID,
+		Data:       data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("events: failed to encode %s: %v", eventType, err)
+		return
+	}
+
+	// Keep the write even if the request is cancelled right after
+	if err := b.outbox.Enqueue(context.WithoutCancel(ctx), repository.OutboxEvent{
+		ID:        event.ID,
+		Type:      eventType,
+		Payload:   payload,
+		CreatedAt: event.OccurredAt,
+	}); err != nil {
+		log.Printf("events: failed to enqueue %s: %v", eventType, err)
+	}
+}
+
+// Run relays outbox events every interval until ctx is done
+func (b *Bus) Run(ctx context.Context, interval time.Duration, batchSize int) {
+	if !b.Enabled() {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// Drain full batches before waiting for the next tick
+		for b.relay(ctx, batchSize) == batchSize {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relay publishes one batch, returning how many events were published
+func (b *Bus) relay(ctx context.Context, batchSize int) int {
+	claimed, err := b.outbox.Claim(ctx, batchSize, claimLease)
+	if err != nil {
+		log.Printf("events: failed to claim outbox events: %v", err)
+		return 0
+	}
+
+	published := make([]string, 0, len(claimed))
+	for _, event := range claimed {
+		pubCtx, cancel := context.WithTimeout(ctx, publishTimeout)
+		err := b.publisher.Publish(pubCtx, b.prefix+event.Type, event.Payload)
+		cancel()
+		if err != nil {
+			// Stop here to keep events in order; the lease expires and the
+			// rest of the batch is retried
+			log.Printf("events: failed to publish %s (attempt %d): %v", event.ID, event.Attempts, err)
+			break
+		}
+		published = append(published, event.ID)
+	}
+
+	if len(published) > 0 {
+		if err := b.outbox.Ack(ctx, published); err != nil {
+			log.Printf("events: failed to ack published events: %v", err)
+		}
+	}
+	return len(published)
+}
+
+// Enabled reports whether emitted events are published anywhere
+func (b *Bus) Enabled() bool {
+	return b != nil && b.publisher != nil
+}
+
+// Close closes the publisher
+func (b *Bus) Close() error {
+	if !b.Enabled() {
+		return nil
+	}
+	return b.publisher.Close()
+}
@@ -0,0 +1,196 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsTimeout bounds connecting and publishing when the context has no
+// deadline
+const natsTimeout = 5 * time.Second
+
+// NATSPublisher publishes events to a NATS server over its text protocol.
+// Each publish is followed by a PING so it only returns once the server has
+// accepted the message.
+type NATSPublisher struct {
+	addr      string
+	tls       bool
+	serverTLS string
+	user      string
+	pass      string
+	token     string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// natsInfo is the subset of the server's INFO we act on
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+// NewNATS creates a publisher for a nats:// or tls:// URL. Credentials may
+// be given as user:pass@ or as a token in the user part. The connection is
+// opened lazily.
+func NewNATS(rawURL string) (*NATSPublisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS URL: %w", err)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return nil, fmt.Errorf("invalid NATS URL scheme %q", u.Scheme)
+	}
+
+	p := &NATSPublisher{
+		addr:      u.Host,
+		tls:       u.Scheme == "tls",
+		serverTLS: u.Hostname(),
+	}
+	if u.Port() == "" {
+		p.addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			p.user, p.pass = u.User.Username(), pass
+		} else {
+			p.token = u.User.Username()
+		}
+	}
+	return p, nil
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, subject string, payload []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		if err := p.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(natsTimeout)
+	}
+	p.conn.SetDeadline(deadline)
+
+	msg := fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", subject, len(payload), payload)
+	if _, err := p.conn.Write([]byte(msg)); err != nil {
+		p.reset()
+		return fmt.Errorf("nats: %w", err)
+	}
+	if err := p.awaitPong(); err != nil {
+		p.reset()
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reset()
+	return nil
+}
+
+func (p *NATSPublisher) connect(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: natsTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return fmt.Errorf("nats: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(natsTimeout))
+	r := bufio.NewReader(conn)
+
+	// The server greets with INFO before any TLS upgrade
+	line, err := r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("nats: %w", err)
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("nats: unexpected greeting %q", strings.TrimSpace(line))
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(strings.TrimSpace(line[5:])), &info); err != nil {
+		conn.Close()
+		return fmt.Errorf("nats: invalid INFO: %w", err)
+	}
+
+	if p.tls || info.TLSRequired {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: p.serverTLS, MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return fmt.Errorf("nats: %w", err)
+		}
+		conn = tlsConn
+		r = bufio.NewReader(conn)
+	}
+
+	connect, _ := json.Marshal(map[string]any{
+		"verbose":      false,
+		"pedantic":     false,
+		"tls_required": p.tls || info.TLSRequired,
+		"name":         "learnpath-gateway",
+		"lang":         "go",
+		"version":      "1.0.0",
+		"protocol":     1,
+		"user":         p.user,
+		"pass":         p.pass,
+		"auth_token":   p.token,
+	})
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		conn.Close()
+		return fmt.Errorf("nats: %w", err)
+	}
+
+	p.conn, p.r = conn, r
+	if err := p.awaitPong(); err != nil {
+		p.reset()
+		return err
+	}
+	return nil
+}
+
+// awaitPong reads until the PONG answering our PING, replying to server
+// PINGs and surfacing -ERR
+func (p *NATSPublisher) awaitPong() error {
+	for {
+		line, err := p.r.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("nats: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return fmt.Errorf("nats: %w", err)
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New("nats: " + strings.Trim(strings.TrimSpace(line[4:]), "'"))
+		}
+		// +OK and INFO updates need no action
+	}
+}
+
+func (p *NATSPublisher) reset() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn, p.r = nil, nil
+	}
+}
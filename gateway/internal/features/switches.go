@@ -0,0 +1,112 @@
+package features
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+)
+
+// Operational switches. Kill switches disable an expensive feature while the
+// rest of the API keeps serving; maintenance mode disables the whole API.
+const (
+	MaintenanceMode    = "maintenance_mode"
+	KillQuizGeneration = "kill_quiz_generation"
+	KillRerank         = "kill_rerank"
+	KillIngestion      = "kill_ingestion"
+)
+
+// Switches holds feature flags from configuration plus runtime overrides set
+// through the admin API. Overrides win until cleared and survive config
+// reloads.
+type Switches struct {
+	mu                 sync.RWMutex
+	fromConfig         map[string]bool
+	overrides          map[string]bool
+	maintenanceMessage string
+}
+
+// NewSwitches creates switches seeded from configuration
+func NewSwitches(cfg *config.Config) *Switches {
+	s := &Switches{overrides: map[string]bool{}}
+	s.ApplyConfig(cfg)
+	return s
+}
+
+// ApplyConfig replaces the configured flags, keeping runtime overrides
+func (s *Switches) ApplyConfig(cfg *config.Config) {
+	flags := make(map[string]bool, len(cfg.Features))
+	for name, enabled := range cfg.Features {
+		flags[name] = enabled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fromConfig = flags
+	s.maintenanceMessage = cfg.MaintenanceMessage
+}
+
+// Enabled reports whether the named switch is on
+func (s *Switches) Enabled(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if enabled, ok := s.overrides[name]; ok {
+		return enabled
+	}
+	return s.fromConfig[name]
+}
+
+// Set overrides a switch at runtime
+func (s *Switches) Set(name string, enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[name] = enabled
+}
+
+// Clear removes a runtime override so the configured value applies again
+func (s *Switches) Clear(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.overrides, name)
+}
+
+// MaintenanceMessage returns the message shown while in maintenance mode
+func (s *Switches) MaintenanceMessage() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.maintenanceMessage
+}
+
+// State describes a switch for the admin API
+type State struct {
+	Name       string `json:"name"`
+	Enabled    bool   `json:"enabled"`
+	Overridden bool   `json:"overridden"`
+}
+
+// Snapshot lists every known switch, sorted by name
+func (s *Switches) Snapshot() []State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := map[string]bool{
+		MaintenanceMode: true, KillQuizGeneration: true, KillRerank: true, KillIngestion: true,
+	}
+	for name := range s.fromConfig {
+		names[name] = true
+	}
+	for name := range s.overrides {
+		names[name] = true
+	}
+
+	states := make([]State, 0, len(names))
+	for name := range names {
+		enabled, overridden := s.overrides[name]
+		if !overridden {
+			enabled = s.fromConfig[name]
+		}
+		states = append(states, State{Name: name, Enabled: enabled, Overridden: overridden})
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
@@ -0,0 +1,76 @@
+package fields
+
+import (
+	"strings"
+)
+
+// Selection is a parsed sparse fieldset such as "plan_id,goal,milestones.title".
+// Each key maps to the selection for its children; a nil child keeps the
+// whole value.
+type Selection map[string]Selection
+
+// Parse parses a comma-separated list of dotted field paths. It returns nil
+// when raw selects nothing.
+func Parse(raw string) Selection {
+	var sel Selection
+	for _, path := range strings.Split(raw, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if sel == nil {
+			sel = Selection{}
+		}
+		sel.add(strings.Split(path, "."))
+	}
+	return sel
+}
+
+func (s Selection) add(path []string) {
+	name := path[0]
+	if name == "" {
+		return
+	}
+	child, seen := s[name]
+	if len(path) == 1 {
+		// Selecting a field whole wins over selecting some of its children
+		s[name] = nil
+		return
+	}
+	if seen && child == nil {
+		return
+	}
+	if child == nil {
+		child = Selection{}
+		s[name] = child
+	}
+	child.add(path[1:])
+}
+
+// Apply projects a decoded JSON value onto the selection. Objects keep only
+// the selected keys, arrays are projected element by element and scalars are
+// returned unchanged.
+func (s Selection) Apply(value interface{}) interface{} {
+	if s == nil {
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		projected := make(map[string]interface{}, len(s))
+		for name, child := range s {
+			if field, ok := v[name]; ok {
+				projected[name] = child.Apply(field)
+			}
+		}
+		return projected
+	case []interface{}:
+		projected := make([]interface{}, len(v))
+		for i, item := range v {
+			projected[i] = s.Apply(item)
+		}
+		return projected
+	default:
+		return value
+	}
+}
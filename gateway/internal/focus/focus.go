@@ -0,0 +1,225 @@
+// Package focus runs focus (Pomodoro) sessions on plan resources. Clients
+// send heartbeats with how long the learner has been idle: a session
+// counts focused time up to the last moment the learner was active,
+// pauses once they have been idle for the configured threshold and
+// resumes when they are back. Ended sessions count toward the learner's
+// streak and are logged as time spent on the resource.
+package focus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+)
+
+// minCreditMinutes is the focused time a session needs to count toward
+// the streak and time spent
+const minCreditMinutes = 1
+
+// ErrEnded is returned for heartbeats to, or ending, an ended session
+var ErrEnded = errors.New("focus session has ended")
+
+// Tracker starts, advances and ends focus sessions
+type Tracker struct {
+	cfg   config.FocusConfig
+	repos *repository.Repositories
+}
+
+// New creates a tracker storing sessions in repos
+func New(cfg config.FocusConfig, repos *repository.Repositories) *Tracker {
+	return &Tracker{cfg: cfg, repos: repos}
+}
+
+// Start opens a session of minutes (0 for the default) on a resource. A
+// session the learner left open without heartbeats is ended first; one
+// still in use makes Start return repository.ErrFocusSessionOpen.
+func (t *Tracker) Start(ctx context.Context, userID, planID, resourceID string, minutes int, now time.Time) (repository.FocusSession, error) {
+	if minutes <= 0 {
+		minutes = t.cfg.DefaultMinutes
+	}
+	session := repository.FocusSession{
+		UserID:         userID,
+		PlanID:         planID,
+		ResourceID:     resourceID,
+		Status:         repository.FocusActive,
+		PlannedMinutes: minutes,
+		StartedAt:      now,
+		LastActiveAt:   now,
+	}
+	started, err := t.repos.Focus.StartFocusSession(ctx, session)
+	if !errors.Is(err, repository.ErrFocusSessionOpen) {
+		return started, err
+	}
+
+	open, err := t.repos.Focus.OpenFocusSession(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		// Ended in the meantime
+		return t.repos.Focus.StartFocusSession(ctx, session)
+	}
+	if err != nil {
+		return repository.FocusSession{}, err
+	}
+	if now.Sub(open.LastActiveAt) <= t.cfg.HeartbeatTimeout {
+		return repository.FocusSession{}, repository.ErrFocusSessionOpen
+	}
+	// Abandoned: it ends when last heard from, without the silence
+	ended := open.LastActiveAt
+	open.Status, open.EndedAt = repository.FocusEnded, &ended
+	if err := t.finish(ctx, open); err != nil {
+		return repository.FocusSession{}, err
+	}
+	return t.repos.Focus.StartFocusSession(ctx, session)
+}
+
+// Heartbeat counts the focus since the last heartbeat, as of the learner
+// having been idle for idle at now
+func (t *Tracker) Heartbeat(ctx context.Context, userID, sessionID string, idle time.Duration, now time.Time) (repository.FocusSession, error) {
+	session, err := t.repos.Focus.GetFocusSession(ctx, userID, sessionID)
+	if err != nil {
+		return repository.FocusSession{}, err
+	}
+	if session.EndedAt != nil {
+		return session, ErrEnded
+	}
+	t.advance(&session, idle, now)
+	if err := t.repos.Focus.UpdateFocusSession(ctx, session); err != nil {
+		return repository.FocusSession{}, err
+	}
+	return session, nil
+}
+
+// End counts a session's last focus, as Heartbeat does, and ends it
+func (t *Tracker) End(ctx context.Context, userID, sessionID string, idle time.Duration, now time.Time) (repository.FocusSession, error) {
+	session, err := t.repos.Focus.GetFocusSession(ctx, userID, sessionID)
+	if err != nil {
+		return repository.FocusSession{}, err
+	}
+	if session.EndedAt != nil {
+		return session, ErrEnded
+	}
+	t.advance(&session, idle, now)
+	session.Status, session.EndedAt = repository.FocusEnded, &now
+	if err := t.finish(ctx, session); err != nil {
+		return repository.FocusSession{}, err
+	}
+	return session, nil
+}
+
+// advance counts focus up to now less idle. Time beyond the heartbeat
+// timeout since the last heartbeat isn't counted, and counts as a pause.
+func (t *Tracker) advance(s *repository.FocusSession, idle time.Duration, now time.Time) {
+	activeUntil := now.Add(-max(idle, 0))
+	if activeUntil.Before(s.LastActiveAt) {
+		activeUntil = s.LastActiveAt
+	}
+	idling := idle >= t.cfg.IdleThreshold
+
+	switch s.Status {
+	case repository.FocusActive:
+		counted := activeUntil
+		if cutoff := s.LastActiveAt.Add(t.cfg.HeartbeatTimeout); counted.After(cutoff) {
+			counted = cutoff
+			if !idling {
+				s.Pauses++ // Silent since, but back now
+			}
+		}
+		s.FocusedMinutes += counted.Sub(s.LastActiveAt).Minutes()
+		s.FocusedMinutes = math.Min(math.Round(s.FocusedMinutes*100)/100, float64(t.cfg.MaxMinutes))
+		s.LastActiveAt = activeUntil
+		if idling {
+			s.Status = repository.FocusPaused
+			s.Pauses++
+		}
+	case repository.FocusPaused:
+		if !idling {
+			s.Status = repository.FocusActive
+			s.LastActiveAt = activeUntil
+		}
+	}
+}
+
+// finish stores an ended session and, when it was long enough, credits
+// the learner's streak and logs the focused time on the resource
+func (t *Tracker) finish(ctx context.Context, s repository.FocusSession) error {
+	if err := t.repos.Focus.UpdateFocusSession(ctx, s); err != nil {
+		return err
+	}
+	if s.FocusedMinutes < minCreditMinutes {
+		return nil
+	}
+	if err := t.repos.Progress.RecordActivity(ctx, s.UserID, *s.EndedAt); err != nil {
+		return fmt.Errorf("focus: failed to record activity: %w", err)
+	}
+	ended := *s.EndedAt
+	_, err := t.repos.TimeLogs.AddTimeLog(ctx, repository.TimeLog{
+		UserID:     s.UserID,
+		PlanID:     s.PlanID,
+		ResourceID: s.ResourceID,
+		StartedAt:  ended.Add(-time.Duration(s.FocusedMinutes * float64(time.Minute))),
+		EndedAt:    &ended,
+		Minutes:    s.FocusedMinutes,
+	})
+	if err != nil {
+		return fmt.Errorf("focus: failed to log time: %w", err)
+	}
+	return nil
+}
+
+// Summary is a learner's focus over one UTC day
+type Summary struct {
+	Date           string                    `json:"date"`
+	Sessions       int                       `json:"sessions"`
+	Completed      int                       `json:"completed"` // Focused for at least their planned length
+	FocusedMinutes float64                   `json:"focused_minutes"`
+	Pauses         int                       `json:"pauses"`
+	Resources      []ResourceFocus           `json:"resources"` // Most focused first
+	FocusSessions  []repository.FocusSession `json:"focus_sessions"`
+}
+
+// ResourceFocus is the focus on one resource over a day
+type ResourceFocus struct {
+	PlanID         string  `json:"plan_id"`
+	ResourceID     string  `json:"resource_id"`
+	Sessions       int     `json:"sessions"`
+	FocusedMinutes float64 `json:"focused_minutes"`
+}
+
+// Summarize rolls up the sessions a learner started on day's UTC date
+func (t *Tracker) Summarize(ctx context.Context, userID string, day time.Time) (Summary, error) {
+	from := day.UTC().Truncate(24 * time.Hour)
+	sessions, err := t.repos.Focus.ListFocusSessions(ctx, userID, from, from.Add(24*time.Hour))
+	if err != nil {
+		return Summary{}, err
+	}
+
+	summary := Summary{Date: from.Format(time.DateOnly), Resources: []ResourceFocus{}, FocusSessions: sessions}
+	index := map[string]int{}
+	for _, s := range sessions {
+		summary.Sessions++
+		summary.FocusedMinutes += s.FocusedMinutes
+		summary.Pauses += s.Pauses
+		if s.FocusedMinutes >= float64(s.PlannedMinutes) {
+			summary.Completed++
+		}
+		key := s.PlanID + "/" + s.ResourceID
+		i, ok := index[key]
+		if !ok {
+			i = len(summary.Resources)
+			index[key] = i
+			summary.Resources = append(summary.Resources, ResourceFocus{PlanID: s.PlanID, ResourceID: s.ResourceID})
+		}
+		summary.Resources[i].Sessions++
+		summary.Resources[i].FocusedMinutes = math.Round((summary.Resources[i].FocusedMinutes+s.FocusedMinutes)*100) / 100
+	}
+	summary.FocusedMinutes = math.Round(summary.FocusedMinutes*100) / 100
+	sort.SliceStable(summary.Resources, func(i, j int) bool {
+		return summary.Resources[i].FocusedMinutes > summary.Resources[j].FocusedMinutes
+	})
+	return summary, nil
+}
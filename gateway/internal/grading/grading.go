@@ -0,0 +1,130 @@
+package grading
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/events"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+)
+
+// claimLease is how long a claimed attempt is held before another worker
+// may retry it; it covers grading every short answer of one attempt
+const claimLease = 2 * time.Minute
+
+// ungradable is the feedback on a short answer the quiz service could not
+// grade within the configured attempts
+const ungradable = "This answer could not be graded."
+
+// Worker has the quiz service grade the short answers of pending quiz
+// attempts. Pending attempts are stored, so grading survives restarts and
+// several replicas can share the work.
+type Worker struct {
+	cfg      config.GradingConfig
+	attempts repository.QuizAttemptRepository
+	orch     orchestrator.Orchestrator
+	bus      *events.Bus
+	wake     chan struct{}
+}
+
+// New creates a grading worker
+func New(cfg config.GradingConfig, attempts repository.QuizAttemptRepository, orch orchestrator.Orchestrator, bus *events.Bus) *Worker {
+	return &Worker{cfg: cfg, attempts: attempts, orch: orch, bus: bus, wake: make(chan struct{}, 1)}
+}
+
+// Notify wakes the worker so a new submission is graded without waiting
+// for the next poll
+func (w *Worker) Notify() {
+	if w == nil {
+		return
+	}
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run grades pending attempts every poll interval, or when notified, until
+// ctx is done
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		// Drain full batches before waiting
+		for w.gradeBatch(ctx) == w.cfg.BatchSize {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+	}
+}
+
+// gradeBatch grades one batch, returning how many attempts were claimed
+func (w *Worker) gradeBatch(ctx context.Context) int {
+	claimed, err := w.attempts.ClaimPendingQuizAttempts(ctx, w.cfg.BatchSize, claimLease)
+	if err != nil {
+		log.Printf("grading: failed to claim quiz attempts: %v", err)
+		return 0
+	}
+	for _, attempt := range claimed {
+		if err := w.grade(ctx, attempt); err != nil {
+			// The lease expires and the attempt is retried
+			log.Printf("grading: failed to grade attempt %s (run %d): %v", attempt.ID, attempt.GradingAttempts, err)
+		}
+	}
+	return len(claimed)
+}
+
+// grade grades an attempt's pending short answers, then recomputes its
+// score and marks it graded. Once the attempt has used up its runs,
+// answers that still fail to grade count as incorrect.
+func (w *Worker) grade(ctx context.Context, attempt repository.QuizAttempt) error {
+	lastRun := attempt.GradingAttempts >= w.cfg.MaxAttempts
+	var correct int
+	for i := range attempt.Results {
+		outcome := &attempt.Results[i]
+		if outcome.Pending {
+			graded, err := w.orch.GradeAnswer(ctx, models.GradeAnswerRequest{
+				QuizID:     attempt.QuizID,
+				QuestionID: outcome.QuestionID,
+				AnswerText: outcome.AnswerText,
+			})
+			switch {
+			case err == nil:
+				outcome.Correct, outcome.Feedback = graded.Correct, graded.Feedback
+			case lastRun:
+				outcome.Correct, outcome.Feedback = false, ungradable
+			default:
+				return fmt.Errorf("question %s: %w", outcome.QuestionID, err)
+			}
+			outcome.Pending = false
+		}
+		if outcome.Correct {
+			correct++
+		}
+	}
+
+	score := 0.0
+	if len(attempt.Results) > 0 {
+		score = float64(correct) * 100 / float64(len(attempt.Results))
+	}
+	attempt.Score = &score
+	if err := w.attempts.CompleteQuizAttempt(ctx, attempt); err != nil {
+		return err
+	}
+
+	w.bus.Emit(ctx, events.QuizGraded, attempt.UserID, map[string]any{
+		"attempt_id": attempt.ID,
+		"quiz_id":    attempt.QuizID,
+		"score":      score,
+	})
+	return nil
+}
@@ -0,0 +1,179 @@
+package guests
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Quota kinds: what a guest may create a limited number of
+const (
+	Plans   = "plans"
+	Quizzes = "quizzes"
+)
+
+// idPrefix marks the user IDs of guests
+const idPrefix = "guest-"
+
+// ErrInvalidToken is returned for guest tokens that are malformed, forged,
+// expired or already merged into an account
+var ErrInvalidToken = errors.New("invalid guest token")
+
+// Session is a guest session as issued
+type Session struct {
+	GuestID   string    `json:"guest_id"`
+	Token     string    `json:"guest_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Denial is a guest request over its quota
+type Denial struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (d *Denial) Error() string {
+	return d.Message
+}
+
+// claims is the signed part of a guest token
+type claims struct {
+	GuestID   string `json:"gid"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Sessions issues and verifies signed guest tokens, which let visitors who
+// haven't signed up try the product under a temporary user ID. Quotas and
+// merged guests are kept in the shared store so every replica enforces
+// them.
+type Sessions struct {
+	cfg   config.GuestConfig
+	store storage.KeyValue
+}
+
+// New creates a guest session issuer
+func New(cfg config.GuestConfig, store storage.KeyValue) *Sessions {
+	return &Sessions{cfg: cfg, store: store}
+}
+
+// Enabled reports whether guest sessions are issued and accepted
+func (s *Sessions) Enabled() bool {
+	return s != nil && s.cfg.Enabled && s.cfg.Secret != ""
+}
+
+// IsGuest reports whether userID is a guest's
+func IsGuest(userID string) bool {
+	return strings.HasPrefix(userID, idPrefix)
+}
+
+// Issue starts a new guest session
+func (s *Sessions) Issue() (*Session, error) {
+	c := claims{
+		GuestID:   idPrefix + uuid.NewString(),
+		ExpiresAt: time.Now().Add(s.cfg.TTL).Unix(),
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return &Session{
+		GuestID:   c.GuestID,
+		Token:     encoded + "." + s.sign(encoded),
+		ExpiresAt: time.Unix(c.ExpiresAt, 0).UTC(),
+	}, nil
+}
+
+// Verify returns the guest a token was issued to. Tokens of guests already
+// merged into an account are refused.
+func (s *Sessions) Verify(ctx context.Context, token string) (string, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(encoded))) {
+		return "", ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil || !IsGuest(c.GuestID) {
+		return "", ErrInvalidToken
+	}
+	if time.Now().Unix() >= c.ExpiresAt {
+		return "", ErrInvalidToken
+	}
+
+	_, merged, err := s.store.Get(ctx, mergedKey(c.GuestID))
+	if err != nil {
+		return "", fmt.Errorf("check guest: %w", err)
+	}
+	if merged {
+		return "", ErrInvalidToken
+	}
+	return c.GuestID, nil
+}
+
+// MarkMerged retires a guest whose data moved to an account, so its token
+// stops working
+func (s *Sessions) MarkMerged(ctx context.Context, guestID string) error {
+	return s.store.Set(ctx, mergedKey(guestID), []byte("1"), s.cfg.TTL)
+}
+
+// Reserve counts one more plan or quiz toward a guest's quota, refusing it
+// once the quota is used up. Release the reservation if nothing was
+// created.
+func (s *Sessions) Reserve(ctx context.Context, guestID, kind string) error {
+	limit := s.limit(kind)
+	count, err := s.store.Incr(ctx, quotaKey(guestID, kind), s.cfg.TTL)
+	if err != nil {
+		return fmt.Errorf("count guest %s: %w", kind, err)
+	}
+	if int(count) <= limit {
+		return nil
+	}
+	s.Release(ctx, guestID, kind)
+	return &Denial{
+		Status:  http.StatusForbidden,
+		Code:    "guest_limit_reached",
+		Message: fmt.Sprintf("Guests can create %d %s; sign up to create more", limit, kind),
+	}
+}
+
+// Release gives back a reservation
+func (s *Sessions) Release(ctx context.Context, guestID, kind string) error {
+	_, err := s.store.IncrBy(ctx, quotaKey(guestID, kind), -1, s.cfg.TTL)
+	return err
+}
+
+func (s *Sessions) limit(kind string) int {
+	if kind == Plans {
+		return s.cfg.MaxPlans
+	}
+	return s.cfg.MaxQuizzes
+}
+
+func (s *Sessions) sign(encoded string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.Secret))
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func quotaKey(guestID, kind string) string {
+	return "guests:" + guestID + ":" + kind
+}
+
+func mergedKey(guestID string) string {
+	return "guests:" + guestID + ":merged"
+}
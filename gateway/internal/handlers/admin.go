@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/abuse"
+	"github.com/amirhf/learnpath-gateway/internal/branding"
+	"github.com/amirhf/learnpath-gateway/internal/capture"
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/costs"
+	"github.com/amirhf/learnpath-gateway/internal/estimate"
+	"github.com/amirhf/learnpath-gateway/internal/features"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/scheduler"
+	"github.com/amirhf/learnpath-gateway/internal/seed"
+	"github.com/amirhf/learnpath-gateway/internal/urlnorm"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// SetSwitchRequest represents a runtime switch override. A null Enabled
+// clears the override so the configured value applies again.
+type SetSwitchRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// ListSwitches returns the current state of every feature switch
+func ListSwitches(switches *features.Switches) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"switches": switches.Snapshot(),
+		})
+	}
+}
+
+// SetSwitch overrides or clears a feature switch at runtime
+func SetSwitch(switches *features.Switches) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req SetSwitchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+
+		name := c.Param("name")
+		if req.Enabled == nil {
+			switches.Clear(name)
+		} else {
+			switches.Set(name, *req.Enabled)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"switches": switches.Snapshot(),
+		})
+	}
+}
+
+// ListJobs returns every scheduled job with its next run and recent history
+func ListJobs(sched *scheduler.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"jobs": sched.Snapshot(),
+		})
+	}
+}
+
+// RunJob triggers a job immediately
+func RunJob(sched *scheduler.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// The run outlives this request
+		err := sched.Trigger(context.WithoutCancel(c.Request.Context()), c.Param("name"))
+		switch {
+		case errors.Is(err, scheduler.ErrUnknownJob):
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "Unknown job",
+			})
+		case errors.Is(err, scheduler.ErrJobRunning):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "job_running",
+				Message: "Job is already running",
+			})
+		default:
+			c.Status(http.StatusAccepted)
+		}
+	}
+}
+
+// SeedDemo loads the demo resources, plan and quiz. A deployment that was
+// already seeded returns the earlier result unless ?force=true.
+func SeedDemo(seeder *seed.Seeder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Finish seeding even if the operator disconnects
+		result, err := seeder.Run(context.WithoutCancel(c.Request.Context()), c.Query("force") == "true")
+		if errors.Is(err, seed.ErrInProgress) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "seed_in_progress",
+				Message: "Demo data is already being seeded",
+			})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadGateway, ErrorResponse{
+				Error:   "seed_error",
+				Message: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// GetCapturedRequest returns the failed upstream calls captured for a
+// request ID
+func GetCapturedRequest(recorder *capture.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if recorder == nil {
+			captureDisabled(c)
+			return
+		}
+		requestID := c.Param("request_id")
+		exchanges, ok := recorder.Get(requestID)
+		if !ok {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "No failed upstream calls captured for this request",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"request_id": requestID,
+			"exchanges":  exchanges,
+		})
+	}
+}
+
+// ReplayRequest re-sends a request's captured upstream calls and returns
+// the new outcomes
+func ReplayRequest(recorder *capture.Recorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if recorder == nil {
+			captureDisabled(c)
+			return
+		}
+		requestID := c.Param("request_id")
+		exchanges, ok := recorder.Replay(c.Request.Context(), requestID)
+		if !ok {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "No failed upstream calls captured for this request",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"request_id": requestID + "-replay",
+			"exchanges":  exchanges,
+		})
+	}
+}
+
+func captureDisabled(c *gin.Context) {
+	c.JSON(http.StatusNotFound, ErrorResponse{
+		Error:   "capture_disabled",
+		Message: "Request capture is not enabled (CAPTURE_ENABLED)",
+	})
+}
+
+// RecalculateDurationsRequest lists resources whose durations should be
+// estimated again. TenantID is the tenant they were ingested for, since
+// re-ingesting updates the resource in place.
+type RecalculateDurationsRequest struct {
+	URLs     []string `json:"urls" binding:"required,min=1"`
+	TenantID string   `json:"tenant_id"`
+}
+
+// RecalculateDurations re-estimates resource durations from fresh page
+// fetches and re-ingests the resources that got one
+func RecalculateDurations(cfg *config.Config, orch orchestrator.Orchestrator, durations *estimate.Estimator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RecalculateDurationsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+		tenantID := req.TenantID
+		if tenantID == "" {
+			tenantID = "global"
+		}
+
+		normalized := urlnorm.NormalizeAll(req.URLs, cfg.Ingestion)
+		estimates := durations.Recalculate(c.Request.Context(), normalized.URLs)
+		var updated []string
+		for _, est := range estimates {
+			if est.DurationMin > 0 {
+				updated = append(updated, est.URL)
+			}
+		}
+
+		if len(updated) > 0 {
+			ctx := common.WithTenantID(c.Request.Context(), tenantID)
+			if _, err := orch.IngestContent(ctx, models.IngestRequest{URLs: updated}); err != nil {
+				upstreamError(c, err, "ingestion_failed")
+				return
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"estimates": estimates,
+			"updated":   len(updated),
+			"invalid":   len(normalized.Problems),
+		})
+	}
+}
+
+// GetTenantCosts reports a tenant's LLM and embedding spend for the current
+// month, or the one given as ?month=2006-01
+func GetTenantCosts(tracker *costs.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !tracker.Enabled() {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "cost_tracking_disabled",
+				Message: "Cost tracking is not enabled (COST_TRACKING_ENABLED)",
+			})
+			return
+		}
+
+		month := time.Now()
+		if value := c.Query("month"); value != "" {
+			parsed, err := time.Parse("2006-01", value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Error:   "invalid_request",
+					Message: "Invalid month",
+					Errors: []validation.FieldError{{
+						Field:   "month",
+						Rule:    "datetime",
+						Message: "must be a month formatted as YYYY-MM",
+					}},
+				})
+				return
+			}
+			month = parsed
+		}
+
+		report, err := tracker.Report(c.Request.Context(), c.Param("id"), month)
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// GetTenantBranding returns a tenant's branding as exports and
+// notifications use it, and what the tenant overrides of the defaults
+func GetTenantBranding(brands *branding.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		overrides, _, err := brands.Overrides(ctx, c.Param("id"))
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		effective, err := brands.Get(ctx, c.Param("id"))
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"tenant_id": c.Param("id"), "branding": effective, "overrides": overrides})
+	}
+}
+
+// SetTenantBranding replaces a tenant's branding; fields left out fall
+// back to the defaults
+func SetTenantBranding(brands *branding.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req branding.Branding
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+		ctx := c.Request.Context()
+		if err := brands.Set(ctx, c.Param("id"), req); err != nil {
+			storageError(c, err)
+			return
+		}
+		effective, err := brands.Get(ctx, c.Param("id"))
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"tenant_id": c.Param("id"), "branding": effective, "overrides": req})
+	}
+}
+
+// ResetTenantBranding returns a tenant to the default branding
+func ResetTenantBranding(brands *branding.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := brands.Delete(c.Request.Context(), c.Param("id")); err != nil {
+			storageError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// maxAnomalies caps one listing of quiz anomalies
+const maxAnomalies = 500
+
+// ListQuizAnomalies returns suspicious quiz submissions, newest first,
+// optionally for one user or quiz
+func ListQuizAnomalies(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := repository.QuizAnomalyFilter{
+			UserID: c.Query("user_id"),
+			QuizID: c.Query("quiz_id"),
+			Limit:  100,
+		}
+		if value := c.Query("limit"); value != "" {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxAnomalies {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Error:   "invalid_request",
+					Message: "Invalid limit",
+					Errors: []validation.FieldError{{
+						Field:   "limit",
+						Rule:    "max",
+						Message: fmt.Sprintf("must be a number from 1 to %d", maxAnomalies),
+					}},
+				})
+				return
+			}
+			filter.Limit = limit
+		}
+
+		anomalies, err := repos.Anomalies.ListQuizAnomalies(c.Request.Context(), filter)
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"anomalies": anomalies})
+	}
+}
+
+// ListBans returns the callers banned for repeated failed requests
+func ListBans(detector *abuse.Detector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bans, err := detector.List(c.Request.Context())
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"bans": bans})
+	}
+}
+
+// LiftBan ends a caller's ban, named like "user:<id>" or "ip:<address>",
+// and forgets their past offenses
+func LiftBan(detector *abuse.Detector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.Param("key")
+		lifted, err := detector.Lift(c.Request.Context(), key)
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		if !lifted {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: fmt.Sprintf("%s is not banned", key),
+			})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
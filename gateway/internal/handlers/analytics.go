@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// QuizAnalytics is how well a learner answers the questions drawn from a
+// plan's resources, by milestone and by skill
+type QuizAnalytics struct {
+	PlanID            string           `json:"plan_id"`
+	Attempts          int              `json:"attempts"` // Attempts with at least one question from the plan
+	QuestionsAnswered int              `json:"questions_answered"`
+	CorrectRate       *float64         `json:"correct_rate"` // 0-1; null until a question is answered
+	Milestones        []MilestoneScore `json:"milestones"`   // In plan order
+	Skills            []SkillScore     `json:"skills"`       // Weakest first
+}
+
+// MilestoneScore aggregates the answers to one milestone's questions
+type MilestoneScore struct {
+	MilestoneID string `json:"milestone_id"`
+	Title       string `json:"title"`
+	Order       int    `json:"order"`
+	Score
+}
+
+// SkillScore aggregates the answers to questions on resources tagged with a
+// skill
+type SkillScore struct {
+	Skill string `json:"skill"`
+	Score
+}
+
+// Score counts answers to a group of questions
+type Score struct {
+	Answered    int      `json:"answered"`
+	Correct     int      `json:"correct"`
+	CorrectRate *float64 `json:"correct_rate"` // 0-1; null when none were answered
+}
+
+func (s *Score) add(correct bool) {
+	s.Answered++
+	if correct {
+		s.Correct++
+	}
+	rate := float64(s.Correct) / float64(s.Answered)
+	s.CorrectRate = &rate
+}
+
+// GetQuizAnalytics aggregates the caller's quiz attempts on a plan. Each
+// answer counts toward the milestone holding the question's resource and
+// toward every skill that resource is tagged with. Short answers still
+// awaiting grading are left out.
+func GetQuizAnalytics(orch orchestrator.Orchestrator, repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+
+		planID, ok := planIDParam(c)
+		if !ok {
+			return
+		}
+		plan, err := orch.GetPlan(c.Request.Context(), planID)
+		if err != nil {
+			upstreamError(c, err, "plan_error")
+			return
+		}
+		attempts, err := repos.Quizzes.ListQuizAttempts(c.Request.Context(), userID)
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, quizAnalytics(plan, attempts))
+	}
+}
+
+func quizAnalytics(plan *models.LearningPath, attempts []repository.QuizAttempt) QuizAnalytics {
+	analytics := QuizAnalytics{PlanID: plan.PlanID.String(), Milestones: []MilestoneScore{}, Skills: []SkillScore{}}
+
+	milestoneOf := map[string]int{}
+	skillsOf := map[string][]string{}
+	for i, milestone := range plan.Milestones {
+		analytics.Milestones = append(analytics.Milestones, MilestoneScore{
+			MilestoneID: milestone.MilestoneID.String(),
+			Title:       milestone.Title,
+			Order:       milestone.Order,
+		})
+		for _, resource := range milestone.Resources {
+			milestoneOf[resource.ResourceID.String()] = i
+			skillsOf[resource.ResourceID.String()] = resource.Skills
+		}
+	}
+
+	var total Score
+	skills := map[string]*Score{}
+	for _, attempt := range attempts {
+		counted := false
+		for _, outcome := range attempt.Results {
+			i, ok := milestoneOf[outcome.ResourceID]
+			if !ok || outcome.Pending {
+				continue
+			}
+			counted = true
+			total.add(outcome.Correct)
+			analytics.Milestones[i].add(outcome.Correct)
+			for _, skill := range skillsOf[outcome.ResourceID] {
+				if skills[skill] == nil {
+					skills[skill] = &Score{}
+				}
+				skills[skill].add(outcome.Correct)
+			}
+		}
+		if counted {
+			analytics.Attempts++
+		}
+	}
+	analytics.QuestionsAnswered = total.Answered
+	analytics.CorrectRate = total.CorrectRate
+
+	for skill, score := range skills {
+		analytics.Skills = append(analytics.Skills, SkillScore{Skill: skill, Score: *score})
+	}
+	sort.Slice(analytics.Skills, func(i, j int) bool {
+		a, b := analytics.Skills[i], analytics.Skills[j]
+		if *a.CorrectRate != *b.CorrectRate {
+			return *a.CorrectRate < *b.CorrectRate
+		}
+		return a.Skill < b.Skill
+	})
+	return analytics
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/amirhf/learnpath-gateway/internal/bufpool"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/workerpool"
+	"github.com/gin-gonic/gin"
+)
+
+// BatchRequest is the body of POST /batch: API requests to run at once
+type BatchRequest struct {
+	Requests []BatchItem `json:"requests" binding:"required,min=1,dive"`
+}
+
+// BatchItem is one API request of a batch. Path is relative to the batch's
+// API version, e.g. "/plan/<id>" or "/quiz/attempts?plan_id=<id>".
+type BatchItem struct {
+	ID     string          `json:"id" binding:"required,max=100"`
+	Method string          `json:"method" binding:"required,oneof=GET POST PUT PATCH DELETE"`
+	Path   string          `json:"path" binding:"required,startswith=/,max=2048"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// BatchResponse holds each sub-request's outcome, in request order
+type BatchResponse struct {
+	Responses []BatchResult `json:"responses"`
+}
+
+// BatchResult is a sub-request's status and body. Bodies that aren't JSON
+// are returned as a string.
+type BatchResult struct {
+	ID     string          `json:"id"`
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// batchHeaders are not passed on from the batch to its sub-requests
+var batchHeaders = []string{"Accept", "Content-Length", "Content-Type", "Idempotency-Key", "X-Request-ID"}
+
+// Batch runs several API requests concurrently, saving round trips on slow
+// networks. Each sub-request is served by router like a request of its
+// own, with the batch's credentials, so it goes through the same auth,
+// limits and ownership checks. Sub-requests fail independently: the batch
+// answers 200 with each one's status and body.
+func Batch(cfg *config.Config, router http.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req BatchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+		if len(req.Requests) > cfg.Batch.MaxRequests {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Message: fmt.Sprintf("A batch may have at most %d requests", cfg.Batch.MaxRequests),
+			})
+			return
+		}
+
+		// Sub-request paths are under the batch's API version
+		prefix := strings.TrimSuffix(c.FullPath(), "/batch")
+		ids := make(map[string]bool, len(req.Requests))
+		for _, item := range req.Requests {
+			if ids[item.ID] {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Error:   "invalid_request",
+					Message: fmt.Sprintf("ID %q is used by more than one request", item.ID),
+				})
+				return
+			}
+			ids[item.ID] = true
+			if target, err := url.Parse(item.Path); err != nil || target.Path == "/batch" || strings.Contains(target.Path, "..") {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Error:   "invalid_request",
+					Message: fmt.Sprintf("Request %q: invalid path", item.ID),
+				})
+				return
+			}
+		}
+
+		results := make([]BatchResult, len(req.Requests))
+		bodies := make([]*bytes.Buffer, len(req.Requests))
+		defer func() {
+			for _, buf := range bodies {
+				bufpool.Put(buf)
+			}
+		}()
+		workerpool.ForEach(c.Request.Context(), cfg.Batch.Concurrency, len(req.Requests), func(ctx context.Context, i int) error {
+			results[i], bodies[i] = serveBatchItem(ctx, c, router, prefix, i, req.Requests[i])
+			return nil
+		})
+		if clientGone(c) {
+			return
+		}
+		writeBatchResponse(c, results)
+	}
+}
+
+// writeBatchResponse encodes a BatchResponse a result at a time, so large
+// sub-responses aren't copied into one more buffer of their combined size
+func writeBatchResponse(c *gin.Context, results []BatchResult) {
+	out := bufpool.Get()
+	defer bufpool.Put(out)
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	out.WriteString(`{"responses":[`)
+	enc := json.NewEncoder(out)
+	for i, result := range results {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		if err := enc.Encode(result); err != nil {
+			c.Error(err)
+			panic(http.ErrAbortHandler)
+		}
+		out.Truncate(out.Len() - 1) // Encode's newline
+		if out.Len() >= streamFlushBytes {
+			if !flushBuffer(c, out) {
+				return
+			}
+		}
+	}
+	out.WriteString("]}")
+	flushBuffer(c, out)
+}
+
+// serveBatchItem runs one sub-request through router. The result's body is
+// held by the returned pooled buffer, if any.
+func serveBatchItem(ctx context.Context, c *gin.Context, router http.Handler, prefix string, i int, item BatchItem) (BatchResult, *bytes.Buffer) {
+	sub, err := http.NewRequestWithContext(ctx, item.Method, prefix+item.Path, bytes.NewReader(item.Body))
+	if err != nil {
+		body, _ := json.Marshal(ErrorResponse{Error: "invalid_request", Message: "Invalid request"})
+		return BatchResult{ID: item.ID, Status: http.StatusBadRequest, Body: body}, nil
+	}
+	sub.Header = c.Request.Header.Clone()
+	for _, name := range batchHeaders {
+		sub.Header.Del(name)
+	}
+	if len(item.Body) > 0 {
+		sub.Header.Set("Content-Type", "application/json")
+	}
+	// Sub-requests are logged and traced under the batch's request ID
+	sub.Header.Set("X-Request-ID", fmt.Sprintf("%s-%d", c.GetString("request_id"), i+1))
+	sub.RemoteAddr = c.Request.RemoteAddr
+
+	recorder := newBatchRecorder()
+	router.ServeHTTP(recorder, sub)
+
+	result := BatchResult{ID: item.ID, Status: recorder.status, Body: recorder.body.Bytes()}
+	if len(result.Body) > 0 && !json.Valid(result.Body) {
+		result.Body, _ = json.Marshal(recorder.body.String())
+	}
+	return result, recorder.body
+}
+
+// batchRecorder holds a sub-request's response
+type batchRecorder struct {
+	header http.Header
+	body   *bytes.Buffer
+	status int
+}
+
+func newBatchRecorder() *batchRecorder {
+	return &batchRecorder{header: http.Header{}, body: bufpool.Get(), status: http.StatusOK}
+}
+
+func (r *batchRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *batchRecorder) Write(data []byte) (int, error) {
+	return r.body.Write(data)
+}
+
+func (r *batchRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+// Flush lets streaming handlers run; their output is returned at once
+func (r *batchRecorder) Flush() {}
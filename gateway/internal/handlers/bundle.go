@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/bufpool"
+	"github.com/amirhf/learnpath-gateway/internal/bundle"
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/features"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// AnswerKeyHeader carries the key a bundle's answers are sealed with,
+// base64-encoded, so it is kept apart from the bundle
+const AnswerKeyHeader = "X-Answer-Key"
+
+// ExportBundle packages a plan for offline study: the plan, a quiz for
+// each milestone as ExportSCORM generates them, and the caller's notes.
+// The quizzes' answers are sealed under a key sent in AnswerKeyHeader.
+func ExportBundle(cfg *config.Config, orch orchestrator.Orchestrator, switches *features.Switches, repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		language, ok := requestLanguage(c, cfg, "")
+		if !ok {
+			return
+		}
+		planID, ok := planIDParam(c)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		var userID *string
+		if uid := c.GetString("user_id"); uid != "" {
+			userID = &uid
+			ctx = common.WithUserID(ctx, uid)
+		}
+		if tenantID := c.GetString("tenant_id"); tenantID != "" {
+			ctx = common.WithTenantID(ctx, tenantID)
+		}
+
+		plan, err := orch.GetPlan(ctx, planID)
+		if err != nil {
+			upstreamError(c, err, "plan_error")
+			return
+		}
+
+		notes := []bundle.Note{}
+		if userID != nil {
+			stored, err := repos.Notes.ListNotes(ctx, *userID, plan.PlanID.String())
+			if err != nil {
+				storageError(c, err)
+				return
+			}
+			for _, note := range stored {
+				notes = append(notes, bundle.Note{MilestoneID: note.MilestoneID, Body: note.Body, CreatedAt: note.CreatedAt, UpdatedAt: note.UpdatedAt})
+			}
+		}
+
+		quizzes, ok := exportQuizzes(ctx, c, orch, switches, plan, userID, language)
+		if !ok {
+			return
+		}
+
+		buf := bufpool.Get()
+		defer bufpool.Put(buf)
+		key, err := bundle.Write(buf, bundle.Bundle{Plan: plan, Quizzes: quizzes, Notes: notes}, time.Now())
+		if err != nil {
+			log.Printf("Failed to bundle plan %s: %v", plan.PlanID, err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to package the plan",
+			})
+			return
+		}
+
+		if key != nil {
+			c.Header(AnswerKeyHeader, base64.StdEncoding.EncodeToString(key))
+		}
+		c.Header("Cache-Control", "no-store")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="plan-%s-bundle.zip"`, plan.PlanID))
+		c.Data(http.StatusOK, "application/zip", buf.Bytes())
+	}
+}
@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/amirhf/learnpath-gateway/internal/common"
 	"github.com/amirhf/learnpath-gateway/internal/config"
 	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/moderation"
 	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/urlnorm"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
 	"github.com/gin-gonic/gin"
 )
 
@@ -16,47 +21,68 @@ type IngestContentRequest struct {
 }
 
 // IngestContent handler
-func IngestContent(cfg *config.Config, orch orchestrator.Orchestrator) gin.HandlerFunc {
+func IngestContent(cfg *config.Config, orch orchestrator.Orchestrator, moderator *moderation.Moderator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req IngestContentRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Error:   "invalid_request",
-				Message: err.Error(),
-			})
+			bindError(c, err)
 			return
 		}
 
-		// Propagate context
-		ctx := c.Request.Context()
-		if requestID := c.GetString("request_id"); requestID != "" {
-			ctx = common.WithRequestID(ctx, requestID)
+		normalized, ok := normalizeURLs(c, cfg, req.URLs)
+		if !ok {
+			return
 		}
-		if tenantID := c.GetString("tenant_id"); tenantID != "" {
-			ctx = common.WithTenantID(ctx, tenantID)
-		} else {
-			// Should default to global if not set, but for BYO Content,
-			// if no tenant is set (anonymous), maybe we should forbid it?
-			// Or allow it and it goes to "global"?
-			// Let's allow it for now, but typically BYO is for a specific tenant.
-			ctx = common.WithTenantID(ctx, "global")
+
+		violations, err := moderator.CheckURLs(c.Request.Context(), c.GetString("tenant_id"), req.URLs)
+		if !screened(c, violations, err) {
+			return
 		}
 
+		ctx := ingestContext(c)
 		orchReq := models.IngestRequest{
-			URLs: req.URLs,
+			URLs: normalized.URLs,
 		}
 
-		if err := orch.IngestContent(ctx, orchReq); err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "ingestion_failed",
-				Message: err.Error(),
-			})
+		if _, err := orch.IngestContent(ctx, orchReq); err != nil {
+			upstreamError(c, err, "ingestion_failed")
 			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"message": "Content ingestion started successfully",
-			"count":   len(req.URLs),
+			"message":    "Content ingestion started successfully",
+			"count":      len(normalized.URLs),
+			"duplicates": normalized.Duplicates,
 		})
 	}
 }
+
+// normalizeURLs canonicalizes the urls of an ingestion request, answering
+// 400 when any of them can't be ingested
+func normalizeURLs(c *gin.Context, cfg *config.Config, urls []string) (urlnorm.Result, bool) {
+	normalized := urlnorm.NormalizeAll(urls, cfg.Ingestion)
+	if len(normalized.Problems) > 0 {
+		fields := make([]validation.FieldError, len(normalized.Problems))
+		for i, p := range normalized.Problems {
+			fields[i] = validation.FieldError{Field: fmt.Sprintf("urls[%d]", p.Index), Rule: p.Rule, Message: p.Message}
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Some URLs cannot be ingested",
+			Errors:  fields,
+		})
+		return urlnorm.Result{}, false
+	}
+	return normalized, true
+}
+
+// ingestContext carries the request and tenant IDs to ingestion. Callers
+// without a tenant ingest into the global corpus.
+func ingestContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	tenantID := c.GetString("tenant_id")
+	if tenantID == "" {
+		tenantID = "global"
+	}
+	return common.WithTenantID(ctx, tenantID)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/branding"
+	"github.com/amirhf/learnpath-gateway/internal/digest"
+	"github.com/gin-gonic/gin"
+)
+
+// GetDigest previews the caller's weekly progress digest, branded for the
+// caller's tenant
+func GetDigest(builder *digest.Builder, brands *branding.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		if c.Param("id") != userID {
+			c.JSON(http.StatusForbidden, ErrorResponse{
+				Error:   "forbidden",
+				Message: "You can only view your own digest",
+			})
+			return
+		}
+
+		d, err := builder.Build(c.Request.Context(), userID, time.Now())
+		if err != nil {
+			c.JSON(http.StatusBadGateway, ErrorResponse{
+				Error:   "digest_error",
+				Message: err.Error(),
+			})
+			return
+		}
+		brand, err := brands.Get(c.Request.Context(), c.GetString("tenant_id"))
+		if err != nil {
+			log.Printf("Failed to load branding of tenant %s: %v", c.GetString("tenant_id"), err)
+		}
+		d.Branding = &brand
+		c.JSON(http.StatusOK, d)
+	}
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/costs"
+	"github.com/amirhf/learnpath-gateway/internal/drafts"
+	"github.com/amirhf/learnpath-gateway/internal/events"
+	"github.com/amirhf/learnpath-gateway/internal/hooks"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/moderation"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/ownership"
+	"github.com/amirhf/learnpath-gateway/internal/quizsession"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateDraftRequest starts a plan draft
+type CreateDraftRequest struct {
+	Goal string `json:"goal" binding:"required,min=1"`
+}
+
+// UpdateDraftRequest refines a draft; fields left out are unchanged
+type UpdateDraftRequest struct {
+	Goal             *string             `json:"goal,omitempty" binding:"omitempty,min=1"`
+	CurrentSkills    *[]string           `json:"current_skills,omitempty"`
+	TimeBudgetHours  *int                `json:"time_budget_hours,omitempty" binding:"omitempty,gt=0"`
+	HoursPerWeek     *int                `json:"hours_per_week,omitempty" binding:"omitempty,gt=0"`
+	Preferences      *models.Preferences `json:"preferences,omitempty"`
+	Language         *string             `json:"language,omitempty"`
+	GenerateQuiz     *bool               `json:"generate_quiz,omitempty"`
+	QuizPerMilestone *bool               `json:"quiz_per_milestone,omitempty"`
+	NumQuestions     *int                `json:"num_questions,omitempty" binding:"omitempty,gt=0"`
+	QuizDifficulty   *string             `json:"quiz_difficulty,omitempty"`
+	Preview          bool                `json:"preview,omitempty"` // Run a RAG preview of the refined draft
+}
+
+// CreateDraft starts a plan draft from a goal
+func CreateDraft(store *drafts.Store, moderator *moderation.Moderator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		var req CreateDraftRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+		violations, err := moderator.CheckGoal(c.Request.Context(), c.GetString("tenant_id"), req.Goal)
+		if !screened(c, violations, err) {
+			return
+		}
+
+		draft := &drafts.Draft{UserID: userID, Goal: req.Goal}
+		if err := store.Create(c.Request.Context(), draft); err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, draft)
+	}
+}
+
+// GetDraft returns one of the caller's drafts
+func GetDraft(store *drafts.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		draft, err := store.Get(c.Request.Context(), userID, c.Param("draft_id"))
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, draft)
+	}
+}
+
+// UpdateDraft applies one wizard step to a draft. With preview set, the
+// refined draft is estimated against the RAG index, which is cheap next to
+// generating the plan.
+func UpdateDraft(store *drafts.Store, orch orchestrator.Orchestrator, tracker *costs.Tracker, moderator *moderation.Moderator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		var req UpdateDraftRequest
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			bindError(c, err)
+			return
+		}
+		draft, err := store.Get(c.Request.Context(), userID, c.Param("draft_id"))
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+
+		if req.Goal != nil && *req.Goal != draft.Goal {
+			violations, err := moderator.CheckGoal(c.Request.Context(), c.GetString("tenant_id"), *req.Goal)
+			if !screened(c, violations, err) {
+				return
+			}
+			draft.Goal = *req.Goal
+		}
+		if req.CurrentSkills != nil {
+			draft.CurrentSkills = *req.CurrentSkills
+		}
+		if req.TimeBudgetHours != nil {
+			draft.TimeBudgetHours = *req.TimeBudgetHours
+		}
+		if req.HoursPerWeek != nil {
+			draft.HoursPerWeek = *req.HoursPerWeek
+		}
+		if req.Preferences != nil {
+			draft.Preferences = *req.Preferences
+		}
+		if req.Language != nil {
+			draft.Language = *req.Language
+		}
+		if req.GenerateQuiz != nil {
+			draft.GenerateQuiz = *req.GenerateQuiz
+		}
+		if req.QuizPerMilestone != nil {
+			draft.QuizPerMilestone = *req.QuizPerMilestone
+		}
+		if req.NumQuestions != nil {
+			draft.NumQuestions = *req.NumQuestions
+		}
+		if req.QuizDifficulty != nil {
+			draft.QuizDifficulty = *req.QuizDifficulty
+		}
+		draft.Step++
+
+		if req.Preview {
+			estimate, err := estimatePlan(c, orch, tracker, draftPlanRequest(draft))
+			if err != nil {
+				upstreamError(c, err, "estimation_error")
+				return
+			}
+			draft.Preview = estimate
+		}
+
+		if err := store.Save(c.Request.Context(), draft); err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, draft)
+	}
+}
+
+// DeleteDraft discards one of the caller's drafts
+func DeleteDraft(store *drafts.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		draft, err := store.Get(c.Request.Context(), userID, c.Param("draft_id"))
+		if err == nil {
+			err = store.Delete(c.Request.Context(), draft.ID)
+		}
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// CommitDraft generates the plan a draft describes, as POST /plan would,
+// and discards the draft once the plan is created
+func CommitDraft(cfg *config.Config, store *drafts.Store, orch orchestrator.Orchestrator, bus *events.Bus, subscribers *hooks.Registry, guard *quizsession.Guard, owners *ownership.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		draft, err := store.Get(c.Request.Context(), userID, c.Param("draft_id"))
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+
+		var missing []validation.FieldError
+		if draft.TimeBudgetHours <= 0 {
+			missing = append(missing, validation.FieldError{Field: "time_budget_hours", Rule: "required", Message: "is required"})
+		}
+		if draft.HoursPerWeek <= 0 {
+			missing = append(missing, validation.FieldError{Field: "hours_per_week", Rule: "required", Message: "is required"})
+		}
+		if len(missing) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+				Error:   "draft_incomplete",
+				Message: "The draft is missing fields a plan needs",
+				Errors:  missing,
+			})
+			return
+		}
+
+		req := draftPlanRequest(draft)
+		language, ok := planLanguage(c, cfg, req)
+		if !ok {
+			return
+		}
+		createPlan(c, cfg, orch, bus, subscribers, guard, owners, req, language, nil)
+		if c.Writer.Status() < http.StatusMultipleChoices {
+			if err := store.Delete(c.Request.Context(), draft.ID); err != nil {
+				log.Printf("Failed to delete committed draft %s: %v", draft.ID, err)
+			}
+		}
+	}
+}
+
+// draftPlanRequest is the plan request a draft describes
+func draftPlanRequest(draft *drafts.Draft) PlanRequest {
+	return PlanRequest{
+		Goal:             draft.Goal,
+		CurrentSkills:    draft.CurrentSkills,
+		TimeBudgetHours:  draft.TimeBudgetHours,
+		HoursPerWeek:     draft.HoursPerWeek,
+		Preferences:      draft.Preferences,
+		UserID:           draft.UserID,
+		Language:         draft.Language,
+		GenerateQuiz:     draft.GenerateQuiz,
+		QuizPerMilestone: draft.QuizPerMilestone,
+		NumQuestions:     draft.NumQuestions,
+		QuizDifficulty:   draft.QuizDifficulty,
+	}
+}
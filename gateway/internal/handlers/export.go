@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/amirhf/learnpath-gateway/internal/branding"
+	"github.com/amirhf/learnpath-gateway/internal/bufpool"
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/features"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/scorm"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// exportQuizQuestions and exportQuizDifficulty shape the quizzes generated
+// for an export, as plans default to
+const (
+	exportQuizQuestions  = 3
+	exportQuizDifficulty = "medium"
+)
+
+// ExportSCORM packages a plan as a SCORM zip for importing into an LMS,
+// ?version=1.2 (the default) or 2004. Unless ?quizzes=false, a quiz is
+// generated for each milestone with resources and packaged as a scored
+// SCO; milestones whose quiz fails, or all of them while quiz generation
+// is switched off, are exported without one. Pages are styled in the
+// caller's tenant's branding.
+func ExportSCORM(cfg *config.Config, orch orchestrator.Orchestrator, switches *features.Switches, brands *branding.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version := c.DefaultQuery("version", scorm.Version12)
+		if version != scorm.Version12 && version != scorm.Version2004 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Message: "version must be 1.2 or 2004",
+			})
+			return
+		}
+		language, ok := requestLanguage(c, cfg, "")
+		if !ok {
+			return
+		}
+		planID, ok := planIDParam(c)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		var userID *string
+		if uid := c.GetString("user_id"); uid != "" {
+			userID = &uid
+			ctx = common.WithUserID(ctx, uid)
+		}
+		tenantID := c.GetString("tenant_id")
+		if tenantID != "" {
+			ctx = common.WithTenantID(ctx, tenantID)
+		}
+
+		plan, err := orch.GetPlan(ctx, planID)
+		if err != nil {
+			upstreamError(c, err, "plan_error")
+			return
+		}
+
+		quizzes, ok := exportQuizzes(ctx, c, orch, switches, plan, userID, language)
+		if !ok {
+			return
+		}
+
+		brand, err := brands.Get(ctx, tenantID)
+		if err != nil {
+			log.Printf("Failed to load branding of tenant %s, exporting plan %s with the defaults: %v", tenantID, plan.PlanID, err)
+		}
+
+		buf := bufpool.Get()
+		defer bufpool.Put(buf)
+		if err := scorm.Build(buf, scorm.Package{Plan: plan, Quizzes: quizzes, Version: version, Branding: brand}); err != nil {
+			log.Printf("Failed to package plan %s for SCORM: %v", plan.PlanID, err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to package the plan",
+			})
+			return
+		}
+
+		filename := fmt.Sprintf("plan-%s-scorm%s.zip", plan.PlanID, strings.ReplaceAll(version, ".", ""))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		c.Data(http.StatusOK, "application/zip", buf.Bytes())
+	}
+}
+
+// exportQuizzes generates a quiz for each milestone of plan with resources,
+// unless ?quizzes=false or quiz generation is switched off. Milestones
+// whose quiz fails are left without one. It reports false once the
+// client has gone.
+func exportQuizzes(ctx context.Context, c *gin.Context, orch orchestrator.Orchestrator, switches *features.Switches, plan *models.LearningPath, userID *string, language string) (map[uuid.UUID]*models.Quiz, bool) {
+	quizzes := map[uuid.UUID]*models.Quiz{}
+	if c.Query("quizzes") != "false" && !switches.Enabled(features.KillQuizGeneration) {
+		var milestones []models.Milestone
+		var reqs []models.GenerateQuizRequest
+		for _, milestone := range plan.Milestones {
+			if len(milestone.Resources) == 0 {
+				continue
+			}
+			resourceIDs := make([]string, 0, len(milestone.Resources))
+			for _, resource := range milestone.Resources {
+				resourceIDs = append(resourceIDs, resource.ResourceID.String())
+			}
+			milestones = append(milestones, milestone)
+			reqs = append(reqs, models.GenerateQuizRequest{
+				ResourceIDs:  resourceIDs,
+				NumQuestions: exportQuizQuestions,
+				Difficulty:   exportQuizDifficulty,
+				UserID:       userID,
+				Language:     language,
+			})
+		}
+		generated, errs := orch.GenerateQuizzes(ctx, reqs)
+		for i, quiz := range generated {
+			if errs[i] != nil {
+				log.Printf("Exporting milestone %q of plan %s without a quiz: %v", milestones[i].Title, plan.PlanID, errs[i])
+				continue
+			}
+			quizzes[milestones[i].MilestoneID] = quiz
+		}
+		if clientGone(c) {
+			return nil, false
+		}
+	}
+	return quizzes, true
+}
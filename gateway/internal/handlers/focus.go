@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/focus"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// StartFocusRequest starts a focus session on a resource
+type StartFocusRequest struct {
+	Minutes int `json:"minutes,omitempty" binding:"omitempty,min=1,max=240"` // Planned length; the configured default if absent
+}
+
+// FocusHeartbeatRequest reports how long the learner has been idle
+type FocusHeartbeatRequest struct {
+	IdleSeconds int `json:"idle_seconds" binding:"gte=0"`
+}
+
+// StartFocus starts the caller's focus session on a resource of a plan,
+// answering 409 while another of their sessions is in use
+func StartFocus(tracker *focus.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		var req StartFocusRequest
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			bindError(c, err)
+			return
+		}
+
+		session, err := tracker.Start(c.Request.Context(), userID, c.Param("id"), c.Param("rid"), req.Minutes, time.Now().UTC())
+		if errors.Is(err, repository.ErrFocusSessionOpen) {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "focus_session_open",
+				Message: "End your current focus session before starting another",
+			})
+			return
+		}
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, session)
+	}
+}
+
+// FocusHeartbeat counts the caller's focus, pausing the session while they
+// are idle and resuming it once they are back
+func FocusHeartbeat(tracker *focus.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		var req FocusHeartbeatRequest
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			bindError(c, err)
+			return
+		}
+		session, err := tracker.Heartbeat(c.Request.Context(), userID, c.Param("session_id"), time.Duration(req.IdleSeconds)*time.Second, time.Now().UTC())
+		focusResponse(c, session, err)
+	}
+}
+
+// EndFocus ends the caller's focus session, crediting their streak and
+// logging the focused time on the resource
+func EndFocus(tracker *focus.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		var req FocusHeartbeatRequest
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			bindError(c, err)
+			return
+		}
+		session, err := tracker.End(c.Request.Context(), userID, c.Param("session_id"), time.Duration(req.IdleSeconds)*time.Second, time.Now().UTC())
+		focusResponse(c, session, err)
+	}
+}
+
+// focusResponse answers with a session, or 409 if it had already ended
+func focusResponse(c *gin.Context, session repository.FocusSession, err error) {
+	switch {
+	case errors.Is(err, focus.ErrEnded):
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:   "focus_session_ended",
+			Message: "This focus session has ended",
+		})
+	case err != nil:
+		storageError(c, err)
+	default:
+		c.JSON(http.StatusOK, session)
+	}
+}
+
+// GetFocusSummary rolls up the caller's focus sessions of a UTC day,
+// ?date=YYYY-MM-DD or today
+func GetFocusSummary(tracker *focus.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		day := time.Now().UTC()
+		if value := c.Query("date"); value != "" {
+			parsed, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Error:   "invalid_request",
+					Message: "Invalid date",
+					Errors: []validation.FieldError{{
+						Field:   "date",
+						Rule:    "datetime",
+						Message: "must be a date formatted as YYYY-MM-DD",
+					}},
+				})
+				return
+			}
+			day = parsed
+		}
+
+		summary, err := tracker.Summarize(c.Request.Context(), userID, day)
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, summary)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/moderation"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/gin-gonic/gin"
+)
+
+// DecomposeGoalRequest asks for the sub-goals of a broad goal
+type DecomposeGoalRequest struct {
+	Goal          string   `json:"goal" binding:"required,min=1"`
+	CurrentSkills []string `json:"current_skills,omitempty"`
+	MaxSubGoals   int      `json:"max_sub_goals,omitempty" binding:"omitempty,min=2,max=10"`
+	Language      string   `json:"language,omitempty"` // Overrides Accept-Language
+}
+
+// DecomposeGoal breaks a broad goal, such as "become a backend engineer",
+// into sub-goals with estimated hours. The learner picks the ones to keep
+// and sends them as sub_goals when creating the plan.
+func DecomposeGoal(cfg *config.Config, orch orchestrator.Orchestrator, moderator *moderation.Moderator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req DecomposeGoalRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+
+		violations, err := moderator.CheckGoal(c.Request.Context(), c.GetString("tenant_id"), req.Goal)
+		if !screened(c, violations, err) {
+			return
+		}
+		language, ok := requestLanguage(c, cfg, req.Language)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		decomposition, err := orch.DecomposeGoal(ctx, models.DecomposeGoalRequest{
+			Goal:          req.Goal,
+			CurrentSkills: req.CurrentSkills,
+			MaxSubGoals:   req.MaxSubGoals,
+			Language:      language,
+		})
+		if err != nil {
+			upstreamError(c, err, "decomposition_error")
+			return
+		}
+		c.JSON(http.StatusOK, decomposition)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/guests"
+	"github.com/amirhf/learnpath-gateway/internal/middleware"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/ownership"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// MergeGuestRequest represents a request to adopt a guest's data
+type MergeGuestRequest struct {
+	GuestToken string `json:"guest_token" binding:"required"`
+}
+
+// CreateGuestSession issues a guest token that lets a visitor create a
+// plan and take a quiz before signing up
+func CreateGuestSession(cfg *config.Config, sessions *guests.Sessions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !sessions.Enabled() {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "Guest sessions are not enabled",
+			})
+			return
+		}
+
+		session, err := sessions.Issue()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to issue guest session",
+			})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{
+			"guest_id":    session.GuestID,
+			"guest_token": session.Token,
+			"expires_at":  session.ExpiresAt,
+			"max_plans":   cfg.Guests.MaxPlans,
+			"max_quizzes": cfg.Guests.MaxQuizzes,
+		})
+	}
+}
+
+// MergeGuest hands a guest's plans, quizzes and learner data to the
+// signed-in caller, then retires the guest token
+func MergeGuest(sessions *guests.Sessions, orch orchestrator.Orchestrator, owners *ownership.Registry, repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		if !sessions.Enabled() {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "Guest sessions are not enabled",
+			})
+			return
+		}
+
+		var req MergeGuestRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+
+		ctx := c.Request.Context()
+		guestID, err := sessions.Verify(ctx, req.GuestToken)
+		if errors.Is(err, guests.ErrInvalidToken) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_guest_token",
+				Message: "The guest session is invalid, expired or already merged",
+			})
+			return
+		}
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+
+		plans, err := orch.TransferPlans(ctx, guestID, userID)
+		if err != nil {
+			upstreamError(c, err, "merge_failed")
+			return
+		}
+		resources, err := owners.Transfer(ctx, guestID, middleware.Caller(c))
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		if err := repos.Users.MergeUser(ctx, guestID, userID); err != nil {
+			storageError(c, err)
+			return
+		}
+		if err := sessions.MarkMerged(ctx, guestID); err != nil {
+			storageError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"guest_id":  guestID,
+			"user_id":   userID,
+			"plans":     plans,
+			"resources": resources,
+		})
+	}
+}
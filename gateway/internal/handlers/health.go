@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/health"
 	"github.com/gin-gonic/gin"
 )
 
@@ -30,3 +31,48 @@ func HealthCheck(cfg *config.Config) gin.HandlerFunc {
 		})
 	}
 }
+
+// ReadinessCheck reports whether every backend was up when last polled.
+// It answers from the poller's cache, so probes never reach the backends.
+func ReadinessCheck(poller *health.Poller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status, code := "ready", http.StatusOK
+		if !poller.Ready() {
+			status, code = "not_ready", http.StatusServiceUnavailable
+		}
+		c.JSON(code, gin.H{
+			"status":       status,
+			"dependencies": poller.Dependencies(),
+		})
+	}
+}
+
+// DependencyHealth returns the last check of each backend, with its latency
+// and error
+func DependencyHealth(poller *health.Poller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"ready":        poller.Ready(),
+			"dependencies": poller.Dependencies(),
+		})
+	}
+}
+
+// SystemVersions reports each backend's version and whether the routes and
+// request bodies the gateway relies on match what it serves
+func SystemVersions(versions *health.Versions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		backends := versions.Backends(c.Request.Context())
+		compatible := true
+		for _, backend := range backends {
+			if backend.Status != health.Compatible {
+				compatible = false
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"gateway":    gin.H{"version": "1.0.0"},
+			"compatible": compatible,
+			"backends":   backends,
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/locale"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// ListLanguages returns the languages plans and quizzes can be generated in
+func ListLanguages(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		languages := make([]locale.Language, len(cfg.Languages.Supported))
+		for i, code := range cfg.Languages.Supported {
+			languages[i] = locale.Lookup(code)
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"default":   cfg.Languages.Default,
+			"languages": languages,
+		})
+	}
+}
+
+// requestLanguage resolves the language to generate in: the body's language
+// field when set, otherwise the one negotiated from Accept-Language. It is
+// set as the response's Content-Language. An unsupported field is answered
+// with 400 and reports false.
+func requestLanguage(c *gin.Context, cfg *config.Config, field string) (string, bool) {
+	language := c.GetString("language")
+	if field != "" {
+		match, ok := locale.Match(field, cfg.Languages.Supported)
+		if !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Message: "Unsupported language",
+				Errors: []validation.FieldError{{
+					Field:   "language",
+					Rule:    "oneof",
+					Message: "must be one of the languages listed at /languages",
+				}},
+			})
+			return "", false
+		}
+		language = match
+	}
+	if language == "" {
+		language = cfg.Languages.Default
+	}
+	c.Header("Content-Language", language)
+	c.Request = c.Request.WithContext(common.WithLanguage(c.Request.Context(), language))
+	return language, true
+}
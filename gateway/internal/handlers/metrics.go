@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/amirhf/learnpath-gateway/internal/admission"
+	"github.com/amirhf/learnpath-gateway/internal/clients"
+	"github.com/gin-gonic/gin"
+)
+
+// VariantMetrics returns per-service stable/canary call statistics
+func VariantMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"variants": clients.VariantMetrics(),
+		})
+	}
+}
+
+// AdmissionMetrics returns in-flight requests, queue depth per priority class
+// and shed counts
+func AdmissionMetrics(ctrl *admission.Controller) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, ctrl.Snapshot())
+	}
+}
+
+var clientCancelled = struct {
+	mu      sync.Mutex
+	byRoute map[string]int64
+}{byRoute: map[string]int64{}}
+
+func recordClientCancelled(route string) {
+	clientCancelled.mu.Lock()
+	defer clientCancelled.mu.Unlock()
+	clientCancelled.byRoute[route]++
+}
+
+// CancellationMetrics returns the number of requests abandoned by the
+// client mid-flight, per route
+func CancellationMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientCancelled.mu.Lock()
+		defer clientCancelled.mu.Unlock()
+
+		byRoute := make(map[string]int64, len(clientCancelled.byRoute))
+		var total int64
+		for route, n := range clientCancelled.byRoute {
+			byRoute[route] = n
+			total += n
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"client_cancelled": total,
+			"by_route":         byRoute,
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/moderation"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// screened writes the response for a moderation check and reports whether
+// the request may go on
+func screened(c *gin.Context, violations []moderation.Violation, err error) bool {
+	if err != nil {
+		log.Printf("Moderation check failed: %v", err)
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Error:   "moderation_unavailable",
+			Message: "Content moderation is unavailable; try again later",
+		})
+		return false
+	}
+	if len(violations) == 0 {
+		return true
+	}
+	fields := make([]validation.FieldError, len(violations))
+	for i, v := range violations {
+		fields[i] = validation.FieldError{Field: v.Field, Rule: v.Category, Message: v.Message}
+	}
+	c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+		Error:   "policy_violation",
+		Message: "The request violates the content policy",
+		Errors:  fields,
+	})
+	return false
+}
+
+// checkPlan moderates a plan request's goal and the sub-goals picked for
+// it, prefixing violation fields with prefix
+func checkPlan(c *gin.Context, moderator *moderation.Moderator, req PlanRequest, prefix string) ([]moderation.Violation, error) {
+	tenantID := c.GetString("tenant_id")
+	violations, err := moderator.CheckGoal(c.Request.Context(), tenantID, req.Goal)
+	for i, subGoal := range req.SubGoals {
+		if err != nil {
+			break
+		}
+		var found []moderation.Violation
+		found, err = moderator.CheckGoal(c.Request.Context(), tenantID, subGoal)
+		for _, v := range found {
+			v.Field = fmt.Sprintf("sub_goals[%d]", i)
+			violations = append(violations, v)
+		}
+	}
+	for i := range violations {
+		violations[i].Field = prefix + violations[i].Field
+	}
+	return violations, err
+}
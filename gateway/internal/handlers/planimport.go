@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/amirhf/learnpath-gateway/internal/bundle"
+	"github.com/amirhf/learnpath-gateway/internal/clients"
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/events"
+	"github.com/amirhf/learnpath-gateway/internal/hooks"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/moderation"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/ownership"
+	"github.com/amirhf/learnpath-gateway/internal/planschema"
+	"github.com/amirhf/learnpath-gateway/internal/quizsession"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/urlnorm"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/amirhf/learnpath-gateway/internal/workerpool"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Searches an import runs to find its resources: one for the plan's goal,
+// then one per resource still missing, importLookups at a time
+const (
+	importGoalTopK     = 20
+	importResourceTopK = 5
+	importLookups      = 4
+)
+
+// ImportedResource is a resource of an imported plan
+type ImportedResource struct {
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url"`
+}
+
+// ImportPlanResponse is the plan created from an import
+type ImportPlanResponse struct {
+	LearningPath models.LearningPath `json:"learning_path"`
+	SourcePlanID *uuid.UUID          `json:"source_plan_id,omitempty"`
+	// UnmatchedResources weren't found on this deployment and were left
+	// out of the plan
+	UnmatchedResources []ImportedResource `json:"unmatched_resources"`
+	NotesRestored      int                `json:"notes_restored"`
+}
+
+// GetImportSchema serves a version of the JSON Schema plans imported as
+// JSON are checked against, /plan/import/schema/v1
+func GetImportSchema() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version, err := strconv.Atoi(strings.TrimPrefix(c.Param("version"), "v"))
+		schema, ok := planschema.Schema(version)
+		if err != nil || !ok {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: fmt.Sprintf("No such schema version; the latest is v%d", planschema.Latest),
+			})
+			return
+		}
+		c.Header("Cache-Control", "public, max-age=86400")
+		c.Data(http.StatusOK, "application/schema+json", schema)
+	}
+}
+
+// ImportPlan creates a plan from one exported elsewhere, for moving plans
+// between deployments or tenants: an offline bundle (application/zip)
+// made by ExportBundle, or the plan's JSON checked against the import
+// schema its schema_version names. The plan's resources are found on this
+// deployment by URL, and the plan is created afresh through the Planner
+// over them, with its goal and time budget. A bundle's notes are restored
+// onto it for a signed-in caller. Quizzes aren't imported; new ones are
+// generated for the plan as usual.
+func ImportPlan(cfg *config.Config, orch orchestrator.Orchestrator, repos *repository.Repositories, bus *events.Bus, subscribers *hooks.Registry, moderator *moderation.Moderator, guard *quizsession.Guard, owners *ownership.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			bindError(c, err)
+			return
+		}
+		var source models.LearningPath
+		var notes []bundle.Note
+		if c.ContentType() == "application/zip" {
+			contents, ok := readBundle(c, data)
+			if !ok {
+				return
+			}
+			source, notes = contents.Plan, contents.Notes
+		} else {
+			plan, ok := readPlanJSON(c, data)
+			if !ok {
+				return
+			}
+			source = plan
+		}
+		if strings.TrimSpace(source.Goal) == "" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Message: "The imported plan has no goal",
+			})
+			return
+		}
+
+		req := importedPlanRequest(source)
+		violations, err := checkPlan(c, moderator, req, "")
+		if !screened(c, violations, err) {
+			return
+		}
+		language, ok := requestLanguage(c, cfg, "")
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		if userID := c.GetString("user_id"); userID != "" {
+			ctx = common.WithUserID(ctx, userID)
+		}
+		if tenantID := c.GetString("tenant_id"); tenantID != "" {
+			ctx = common.WithTenantID(ctx, tenantID)
+		}
+		resourceIDs, unmatched, err := matchResources(ctx, cfg, orch, source)
+		if err != nil {
+			upstreamError(c, err, "resource_lookup_error")
+			return
+		}
+		result, ok := generatePlan(c, cfg, orch, bus, subscribers, guard, owners, req, language, resourceIDs)
+		if !ok {
+			return
+		}
+
+		resp := ImportPlanResponse{LearningPath: result.LearningPath, UnmatchedResources: unmatched}
+		if source.PlanID != uuid.Nil {
+			resp.SourcePlanID = &source.PlanID
+		}
+		if userID := c.GetString("user_id"); userID != "" && len(notes) > 0 {
+			milestones := remapMilestones(cfg, source.Milestones, result.LearningPath.Milestones)
+			for _, note := range notes {
+				if strings.TrimSpace(note.Body) == "" {
+					continue
+				}
+				_, err := repos.Notes.CreateNote(ctx, repository.Note{
+					UserID:      userID,
+					PlanID:      result.LearningPath.PlanID.String(),
+					MilestoneID: milestones[note.MilestoneID],
+					Body:        note.Body,
+				})
+				if err != nil {
+					log.Printf("Failed to restore a note onto imported plan %s: %v", result.LearningPath.PlanID, err)
+					continue
+				}
+				resp.NotesRestored++
+			}
+		}
+		c.JSON(http.StatusCreated, resp)
+	}
+}
+
+// readBundle reads an imported bundle, or responds with why it can't
+func readBundle(c *gin.Context, data []byte) (bundle.Contents, bool) {
+	contents, err := bundle.Read(data)
+	var invalid *bundle.InvalidError
+	if errors.As(err, &invalid) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_bundle",
+			Message: "Invalid bundle: " + invalid.Reason,
+		})
+		return bundle.Contents{}, false
+	}
+	if err != nil {
+		log.Printf("Failed to read an imported bundle: %v", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to read the bundle",
+		})
+		return bundle.Contents{}, false
+	}
+	return contents, true
+}
+
+// readPlanJSON checks an imported plan against the schema version it
+// names and decodes it, or responds with what's wrong
+func readPlanJSON(c *gin.Context, data []byte) (models.LearningPath, bool) {
+	version, ok := planschema.Version(data)
+	if !ok {
+		version = planschema.Latest // Validation says what's wrong
+	} else if _, ok := planschema.Schema(version); !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "unsupported_schema_version",
+			Message: fmt.Sprintf("schema_version %d isn't supported; the latest is %d", version, planschema.Latest),
+		})
+		return models.LearningPath{}, false
+	}
+	problems, err := planschema.Validate(version, data)
+	if err != nil {
+		log.Printf("Failed to validate an imported plan: %v", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to read the plan",
+		})
+		return models.LearningPath{}, false
+	}
+	var plan models.LearningPath
+	if len(problems) == 0 {
+		if err := json.Unmarshal(data, &plan); err != nil {
+			problems = validation.Translate(err)
+		}
+	}
+	if len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: fmt.Sprintf("The plan doesn't match import schema v%d", version),
+			Errors:  problems,
+		})
+		return models.LearningPath{}, false
+	}
+	return plan, true
+}
+
+// importedPlanRequest asks for a plan with an imported plan's goal, total
+// hours and pace
+func importedPlanRequest(source models.LearningPath) PlanRequest {
+	budget := max(int(math.Ceil(source.TotalHours)), 1)
+	perWeek := budget
+	if source.EstimatedWeeks > 0 {
+		perWeek = max(int(math.Ceil(source.TotalHours/float64(source.EstimatedWeeks))), 1)
+	}
+	return PlanRequest{Goal: source.Goal, TimeBudgetHours: budget, HoursPerWeek: perWeek}
+}
+
+// matchResources finds an imported plan's resources on this deployment,
+// by canonical URL among RAG search results: first those found searching
+// for the plan's goal, then searching for each missing resource's title.
+// It returns the IDs found, in plan order, and the resources that weren't.
+func matchResources(ctx context.Context, cfg *config.Config, orch orchestrator.Orchestrator, source models.LearningPath) ([]string, []ImportedResource, error) {
+	type wanted struct {
+		resource  ImportedResource
+		canonical string
+	}
+	var wants []wanted
+	unmatched := []ImportedResource{}
+	seen := map[string]bool{}
+	for _, milestone := range source.Milestones {
+		for _, r := range milestone.Resources {
+			resource := ImportedResource{Title: r.Title, URL: r.URL}
+			canonical := canonicalURL(cfg, r.URL)
+			switch {
+			case canonical == "":
+				unmatched = append(unmatched, resource)
+			case !seen[canonical]:
+				seen[canonical] = true
+				wants = append(wants, wanted{resource: resource, canonical: canonical})
+			}
+		}
+	}
+	if len(wants) == 0 {
+		return nil, unmatched, nil
+	}
+
+	var mu sync.Mutex
+	found := map[string]string{} // Canonical URL to resource ID
+	record := func(results []models.ResourceResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, result := range results {
+			if canonical := canonicalURL(cfg, result.URL); canonical != "" && found[canonical] == "" {
+				found[canonical] = result.ID.String()
+			}
+		}
+	}
+
+	resp, err := orch.Search(ctx, clients.SearchRequest{Query: source.Goal, TopK: importGoalTopK})
+	if err != nil {
+		return nil, nil, err
+	}
+	record(resp.Results)
+	var missing []wanted
+	for _, want := range wants {
+		if found[want.canonical] == "" {
+			missing = append(missing, want)
+		}
+	}
+	err = workerpool.ForEach(ctx, importLookups, len(missing), func(ctx context.Context, i int) error {
+		query := missing[i].resource.Title
+		if strings.TrimSpace(query) == "" {
+			query = missing[i].resource.URL
+		}
+		resp, err := orch.Search(ctx, clients.SearchRequest{Query: query, TopK: importResourceTopK})
+		if err != nil {
+			return err
+		}
+		record(resp.Results)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ids []string
+	taken := map[string]bool{}
+	for _, want := range wants {
+		id := found[want.canonical]
+		if id == "" {
+			unmatched = append(unmatched, want.resource)
+			continue
+		}
+		if !taken[id] {
+			taken[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, unmatched, nil
+}
+
+// canonicalURL is raw as ingestion would store it, or "" if it isn't a
+// URL that could have been ingested
+func canonicalURL(cfg *config.Config, raw string) string {
+	canonical, _, err := urlnorm.Normalize(raw, cfg.Ingestion)
+	if err != nil {
+		return ""
+	}
+	return canonical
+}
+
+// remapMilestones maps each old milestone's ID to the new milestone
+// sharing most of its resources by URL, or the one in its position when
+// none do. Old milestones past the end of the new plan map to "", the
+// plan.
+func remapMilestones(cfg *config.Config, old, updated []models.Milestone) map[string]string {
+	urls := make([]map[string]bool, len(updated))
+	for i, candidate := range updated {
+		urls[i] = map[string]bool{}
+		for _, resource := range candidate.Resources {
+			urls[i][canonicalURL(cfg, resource.URL)] = true
+		}
+	}
+	mapped := map[string]string{}
+	for i, milestone := range old {
+		best, shared := "", 0
+		for j, candidate := range updated {
+			n := 0
+			for _, resource := range milestone.Resources {
+				if canonical := canonicalURL(cfg, resource.URL); canonical != "" && urls[j][canonical] {
+					n++
+				}
+			}
+			if n > shared {
+				best, shared = candidate.MilestoneID.String(), n
+			}
+		}
+		if best == "" && i < len(updated) {
+			best = updated[i].MilestoneID.String()
+		}
+		mapped[milestone.MilestoneID.String()] = best
+	}
+	return mapped
+}
@@ -1,310 +1,427 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
+	"net/url"
 	"io"
-	"bytes"
 	"encoding/json"
 	"time"
 
 	"github.com/amirhf/learnpath-gateway/internal/common"
 	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/costs"
+	"github.com/amirhf/learnpath-gateway/internal/etag"
+	"github.com/amirhf/learnpath-gateway/internal/events"
+	"github.com/amirhf/learnpath-gateway/internal/hooks"
+	"github.com/amirhf/learnpath-gateway/internal/middleware"
 	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/moderation"
 	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/ownership"
+	"github.com/amirhf/learnpath-gateway/internal/quizsession"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// concurrentPlanRetryAfter is the back-off suggested to a user who already
+// has the maximum number of plans generating
+const concurrentPlanRetryAfter = 10 * time.Second
+
 // PlanRequest represents the plan generation request
 type PlanRequest struct {
-	Goal            string   `json:"goal" binding:"required,min=1"`
-	CurrentSkills   []string `json:"current_skills,omitempty"`
-	TimeBudgetHours int      `json:"time_budget_hours" binding:"required,gt=0"`
-	HoursPerWeek    int      `json:"hours_per_week" binding:"required,gt=0"`
-	Preferences     map[string]interface{} `json:"preferences,omitempty"`
-	UserID          string   `json:"user_id,omitempty"`
+	Goal            string             `json:"goal" binding:"required,min=1"`
+	CurrentSkills   []string           `json:"current_skills,omitempty"`
+	TimeBudgetHours int                `json:"time_budget_hours" binding:"required,gt=0"`
+	HoursPerWeek    int                `json:"hours_per_week" binding:"required,gt=0"`
+	Preferences     models.Preferences `json:"preferences,omitempty"`
+	UserID          string             `json:"user_id,omitempty"`  // Ignored unless AUTH_ANONYMOUS_MODE is on and the request is anonymous
+	Language        string             `json:"language,omitempty"` // Overrides Accept-Language
 	// Optional fields for quiz generation
-	GenerateQuiz   bool   `json:"generate_quiz,omitempty"`
-	NumQuestions   int    `json:"num_questions,omitempty"`
-	QuizDifficulty string `json:"quiz_difficulty,omitempty"`
+	GenerateQuiz     bool   `json:"generate_quiz,omitempty"`
+	QuizPerMilestone bool   `json:"quiz_per_milestone,omitempty"`
+	NumQuestions     int    `json:"num_questions,omitempty"`
+	QuizDifficulty   string `json:"quiz_difficulty,omitempty"`
+	// Sub-goals picked from POST /goal/decompose
+	SubGoals []string `json:"sub_goals,omitempty" binding:"omitempty,max=20,dive,min=1"`
+}
+
+// PlanFromContentRequest is a plan request over content to ingest first
+type PlanFromContentRequest struct {
+	URLs []string    `json:"urls" binding:"required,min=1,max=50"`
+	Plan PlanRequest `json:"plan" binding:"required"`
 }
 
-// ReplanRequest represents the replan request
+// ReplanRequest is the body of POST /plan/:id/replan. PlanID and
+// CompletedLessons are accepted from clients of the old proxy.
 type ReplanRequest struct {
-	PlanID           string   `json:"plan_id" binding:"required"`
-	CompletedLessons []string `json:"completed_lessons"`
-	Feedback         string   `json:"feedback,omitempty"`
+	CompletedResources []string `json:"completed_resources,omitempty" binding:"omitempty,dive,uuid"`
+	TimeSpentHours     float64  `json:"time_spent_hours,omitempty" binding:"gte=0"`
+	RemainingTimeHours *float64 `json:"remaining_time_hours,omitempty" binding:"omitempty,gte=0"`
+	Feedback           string   `json:"feedback,omitempty"`
+	// Legacy
+	PlanID           string   `json:"plan_id,omitempty"`
+	CompletedLessons []string `json:"completed_lessons,omitempty" binding:"omitempty,dive,uuid"` // Same as CompletedResources
 }
 
-// CreatePlan returns a handler for creating learning plans
-func CreatePlan(cfg *config.Config, orch orchestrator.Orchestrator) gin.HandlerFunc {
+// CreatePlan returns a handler for creating learning plans. The plan is
+// owned by the caller, and generated quizzes are recorded as issued to them.
+func CreatePlan(cfg *config.Config, orch orchestrator.Orchestrator, bus *events.Bus, subscribers *hooks.Registry, moderator *moderation.Moderator, guard *quizsession.Guard, owners *ownership.Registry) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req PlanRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Error:   "invalid_request",
-				Message: err.Error(),
-			})
+			bindError(c, err)
 			return
 		}
 
-		// Convert preferences map[string]interface{} to map[string]string
-		prefs := make(map[string]string)
-		for k, v := range req.Preferences {
-			prefs[k] = fmt.Sprintf("%v", v)
+		violations, err := checkPlan(c, moderator, req, "")
+		if !screened(c, violations, err) {
+			return
 		}
 
-		// Prepare orchestrator request
-		// Default to generating quiz if not specified, or allow frontend to control
-		generateQuiz := req.GenerateQuiz
-		
-		numQuestions := req.NumQuestions
-		if numQuestions == 0 {
-			numQuestions = 3 // Default
-		}
-		
-		difficulty := req.QuizDifficulty
-		if difficulty == "" {
-			difficulty = "medium"
+		language, ok := planLanguage(c, cfg, req)
+		if !ok {
+			return
 		}
+		createPlan(c, cfg, orch, bus, subscribers, guard, owners, req, language, nil)
+	}
+}
+
+// requestUser is the user a request acts for: the signed-in user, or in
+// anonymous mode the user_id the body names for anonymous requests
+func requestUser(c *gin.Context, cfg *config.Config, bodyUserID string) string {
+	if userID := c.GetString("user_id"); userID != "" || !cfg.Auth.AnonymousMode {
+		return userID
+	}
+	return bodyUserID
+}
+
+// planLanguage resolves the language to write a plan in: the request's,
+// its preferences' or Accept-Language
+func planLanguage(c *gin.Context, cfg *config.Config, req PlanRequest) (string, bool) {
+	language := req.Language
+	if language == "" {
+		language = req.Preferences.Language
+	}
+	return requestLanguage(c, cfg, language)
+}
+
+// createPlan generates a plan and its quizzes and responds with them. A
+// non-empty resourceIDs restricts the plan to those resources.
+func createPlan(c *gin.Context, cfg *config.Config, orch orchestrator.Orchestrator, bus *events.Bus, subscribers *hooks.Registry, guard *quizsession.Guard, owners *ownership.Registry, req PlanRequest, language string, resourceIDs []string) {
+	result, ok := generatePlan(c, cfg, orch, bus, subscribers, guard, owners, req, language, resourceIDs)
+	if !ok {
+		return
+	}
+
+	// Return response
+	if apiVersion(c) == "v2" {
+		c.JSON(http.StatusOK, models.NewPublicLearningPathWithQuiz(result))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// generatePlan generates a plan and its quizzes for createPlan, claiming
+// them for the caller and publishing plan.created. It responds with the
+// error when generation fails.
+func generatePlan(c *gin.Context, cfg *config.Config, orch orchestrator.Orchestrator, bus *events.Bus, subscribers *hooks.Registry, guard *quizsession.Guard, owners *ownership.Registry, req PlanRequest, language string, resourceIDs []string) (*models.LearningPathWithQuiz, bool) {
+	// Prepare orchestrator request
+	// Default to generating quiz if not specified, or allow frontend to control
+	generateQuiz := req.GenerateQuiz
+	
+	numQuestions := req.NumQuestions
+	if numQuestions == 0 {
+		numQuestions = 3 // Default
+	}
+	
+	difficulty := req.QuizDifficulty
+	if difficulty == "" {
+		difficulty = "medium"
+	}
+
+	// The plan belongs to the signed-in user, never to one named in the body
+	req.UserID = requestUser(c, cfg, req.UserID)
+	var userID *string
+	if req.UserID != "" {
+		userID = &req.UserID
+	}
+
+	orchReq := models.OrchestrateFullFlowRequest{
+		PlanLearningPathRequest: models.PlanLearningPathRequest{
+			Goal:            req.Goal,
+			CurrentSkills:   req.CurrentSkills,
+			TimeBudgetHours: req.TimeBudgetHours,
+			HoursPerWeek:    req.HoursPerWeek,
+			Preferences:     req.Preferences,
+			UserID:          userID,
+			Language:        language,
+			ResourceIDs:     resourceIDs,
+			SubGoals:        req.SubGoals,
+		},
+		GenerateQuiz:     generateQuiz,
+		QuizPerMilestone: req.QuizPerMilestone,
+		NumQuestions:   numQuestions,
+		QuizDifficulty: difficulty,
+	}
+
+	ctx := c.Request.Context()
+
+	// Propagate User ID from Auth middleware
+	if userID := c.GetString("user_id"); userID != "" {
+		ctx = common.WithUserID(ctx, userID)
+	}
+	
+	// Propagate Tenant ID
+	if tenantID := c.GetString("tenant_id"); tenantID != "" {
+		ctx = common.WithTenantID(ctx, tenantID)
+	}
 
-		orchReq := models.OrchestrateFullFlowRequest{
-			PlanLearningPathRequest: models.PlanLearningPathRequest{
-				Goal:            req.Goal,
-				CurrentSkills:   req.CurrentSkills,
-				TimeBudgetHours: req.TimeBudgetHours,
-				HoursPerWeek:    req.HoursPerWeek,
-				Preferences:     prefs,
-				UserID:          &req.UserID,
-			},
-			GenerateQuiz:   generateQuiz,
-			NumQuestions:   numQuestions,
-			QuizDifficulty: difficulty,
+	// Call Orchestrator
+	result, err := orch.OrchestrateFullFlow(ctx, orchReq)
+	if errors.Is(err, orchestrator.ErrTooManyConcurrentPlans) {
+		middleware.SetRetryAfter(c, concurrentPlanRetryAfter)
+		middleware.SetRateLimit(c, cfg.Concurrency.PlansPerUser, 0, concurrentPlanRetryAfter)
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{
+			Error:   "too_many_concurrent_plans",
+			Message: "A plan is already being generated for this user; wait for it to finish and try again",
+		})
+		return nil, false
+	}
+	if err != nil {
+		upstreamError(c, err, "orchestration_error")
+		return nil, false
+	}
+	claimOwnership(c, owners, ownership.Plan, result.LearningPath.PlanID.String())
+	issueQuizzes(c, guard, owners, result.Quiz)
+	for _, mq := range result.MilestoneQuizzes {
+		issueQuizzes(c, guard, owners, mq.Quiz)
+	}
+
+	bus.Emit(ctx, events.PlanCreated, req.UserID, gin.H{
+		"plan_id":         result.LearningPath.PlanID,
+		"goal":            result.LearningPath.Goal,
+		"total_hours":     result.LearningPath.TotalHours,
+		"milestone_count": len(result.LearningPath.Milestones),
+		"with_quiz":       result.Quiz != nil || len(result.MilestoneQuizzes) > 0,
+	})
+	subscribers.Fire(ctx, req.UserID, hooks.NewPlan, hooks.NewPlanItem(result.LearningPath))
+	return result, true
+}
+
+// PlanFromContent ingests a list of URLs, such as a course's reading list,
+// and generates a plan from only those resources. Ingestion completes
+// before planning starts, so the plan sees every resource indexed.
+func PlanFromContent(cfg *config.Config, orch orchestrator.Orchestrator, bus *events.Bus, subscribers *hooks.Registry, moderator *moderation.Moderator, guard *quizsession.Guard, owners *ownership.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req PlanFromContentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
 		}
 
-		// Propagate Request ID to context
-		ctx := c.Request.Context()
-		if requestID := c.GetString("request_id"); requestID != "" {
-			ctx = common.WithRequestID(ctx, requestID)
+		normalized, ok := normalizeURLs(c, cfg, req.URLs)
+		if !ok {
+			return
 		}
-		
-		// Propagate User ID from Auth middleware
-		if userID := c.GetString("user_id"); userID != "" {
-			req.UserID = userID
-			orchReq.PlanLearningPathRequest.UserID = &userID
-			ctx = common.WithUserID(ctx, userID)
+		tenantID := c.GetString("tenant_id")
+		violations, err := moderator.CheckURLs(c.Request.Context(), tenantID, req.URLs)
+		if err == nil {
+			var planViolations []moderation.Violation
+			planViolations, err = checkPlan(c, moderator, req.Plan, "plan.")
+			violations = append(violations, planViolations...)
 		}
-		
-		// Propagate Tenant ID
-		if tenantID := c.GetString("tenant_id"); tenantID != "" {
-			ctx = common.WithTenantID(ctx, tenantID)
+		if !screened(c, violations, err) {
+			return
+		}
+		language, ok := planLanguage(c, cfg, req.Plan)
+		if !ok {
+			return
 		}
 
-		// Call Orchestrator
-		result, err := orch.OrchestrateFullFlow(ctx, orchReq)
+		resourceIDs, err := orch.IngestContent(ingestContext(c), models.IngestRequest{URLs: normalized.URLs})
 		if err != nil {
-			// TODO: Differentiate between 400 (validation) and 500 (service) errors
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "orchestration_error",
-				Message: err.Error(),
+			upstreamError(c, err, "ingestion_failed")
+			return
+		}
+		if len(resourceIDs) == 0 {
+			c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+				Error:   "nothing_ingested",
+				Message: "None of the URLs could be ingested",
 			})
 			return
 		}
-
-		// Return response
-		c.JSON(http.StatusOK, result)
+		createPlan(c, cfg, orch, bus, subscribers, guard, owners, req.Plan, language, resourceIDs)
 	}
 }
 
-// GetPlan returns a handler for retrieving a plan
-func GetPlan(cfg *config.Config) gin.HandlerFunc {
+// EstimatePlan previews a plan request: how many resources the planner
+// would consider, how long generation is expected to take and what it
+// would cost. Only the RAG search runs.
+func EstimatePlan(orch orchestrator.Orchestrator, tracker *costs.Tracker, moderator *moderation.Moderator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		planID := c.Param("id")
-		if planID == "" {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Error:   "invalid_request",
-				Message: "Plan ID is required",
-			})
+		var req PlanRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
 			return
 		}
 
-		// Forward request to Planner service
-		plannerURL := fmt.Sprintf("%s/plan/%s", cfg.PlannerServiceURL, planID)
-		
-		// Create HTTP request
-		httpReq, err := http.NewRequestWithContext(
-			c.Request.Context(),
-			"GET",
-			plannerURL,
-			nil,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "internal_error",
-				Message: "Failed to create request",
-			})
+		violations, err := moderator.CheckGoal(c.Request.Context(), c.GetString("tenant_id"), req.Goal)
+		if !screened(c, violations, err) {
 			return
 		}
 
-		// Set headers
-		if requestID := c.GetString("request_id"); requestID != "" {
-			httpReq.Header.Set("X-Request-ID", requestID)
+		estimate, err := estimatePlan(c, orch, tracker, req)
+		if err != nil {
+			upstreamError(c, err, "estimation_error")
+			return
 		}
+		c.JSON(http.StatusOK, estimate)
+	}
+}
+
+// estimatePlan runs the RAG search behind a plan request and prices the
+// full flow
+func estimatePlan(c *gin.Context, orch orchestrator.Orchestrator, tracker *costs.Tracker, req PlanRequest) (*models.PlanEstimate, error) {
+	ctx := c.Request.Context()
+	if tenantID := c.GetString("tenant_id"); tenantID != "" {
+		ctx = common.WithTenantID(ctx, tenantID)
+	}
+	estimate, err := orch.EstimatePlan(ctx, models.OrchestrateFullFlowRequest{
+		PlanLearningPathRequest: models.PlanLearningPathRequest{
+			Goal:            req.Goal,
+			CurrentSkills:   req.CurrentSkills,
+			TimeBudgetHours: req.TimeBudgetHours,
+			HoursPerWeek:    req.HoursPerWeek,
+			Preferences:     req.Preferences,
+			SubGoals:        req.SubGoals,
+		},
+		GenerateQuiz:     req.GenerateQuiz,
+		QuizPerMilestone: req.QuizPerMilestone,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// Send request
-		client := &http.Client{
-			Timeout: 10 * time.Second,
+	// What the full flow is charged: its search, the plan and the quizzes
+	paths := []string{"/search", "/plan"}
+	for i := 0; i < estimate.QuizCalls; i++ {
+		paths = append(paths, "/generate")
+	}
+	estimate.EstimatedCostUSD = tracker.Quote(paths...)
+	return estimate, nil
+}
+
+// GetPlan returns a handler for retrieving a plan. Like the other plan
+// endpoints it goes through the orchestrator, so resources carry their cost.
+func GetPlan(orch orchestrator.Orchestrator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		planID, ok := planIDParam(c)
+		if !ok {
+			return
 		}
-		resp, err := client.Do(httpReq)
+
+		plan, err := orch.GetPlan(c.Request.Context(), planID)
 		if err != nil {
-			c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-				Error:   "service_unavailable",
-				Message: "Planner service is unavailable",
-			})
+			upstreamError(c, err, "plan_error")
 			return
 		}
-		defer resp.Body.Close()
 
-		// Read response
-		body, err := io.ReadAll(resp.Body)
+		// Tag the exact bytes we send so the SPA's polling can revalidate
+		// cheaply; equal plans always encode the same
+		encoded, err := json.Marshal(plan)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, ErrorResponse{
 				Error:   "internal_error",
-				Message: "Failed to read response",
+				Message: "Failed to encode response",
 			})
 			return
 		}
-
-		// Check status code
-		if resp.StatusCode != http.StatusOK {
-			var errResp ErrorResponse
-			if err := json.Unmarshal(body, &errResp); err == nil {
-				c.JSON(resp.StatusCode, errResp)
-			} else {
-				c.JSON(resp.StatusCode, ErrorResponse{
-					Error:   "planner_service_error",
-					Message: string(body),
-				})
-			}
+		tag := etag.Strong(encoded)
+		c.Header("ETag", tag)
+		if etag.Matches(c.GetHeader("If-None-Match"), tag) {
+			c.Status(http.StatusNotModified)
 			return
 		}
 
-		// Parse and return response
-		var planResp map[string]interface{}
-		if err := json.Unmarshal(body, &planResp); err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "internal_error",
-				Message: "Failed to parse response",
-			})
-			return
+		// Caches validating by date; If-None-Match takes precedence
+		if !plan.UpdatedAt.IsZero() {
+			modified := plan.UpdatedAt.UTC().Truncate(time.Second)
+			c.Header("Last-Modified", modified.Format(http.TimeFormat))
+			since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+			if c.GetHeader("If-None-Match") == "" && err == nil && !modified.After(since) {
+				c.Status(http.StatusNotModified)
+				return
+			}
 		}
 
-		// Return response
-		c.JSON(http.StatusOK, planResp)
+		c.Data(http.StatusOK, "application/json; charset=utf-8", encoded)
 	}
 }
 
-// Replan returns a handler for replanning
-func Replan(cfg *config.Config) gin.HandlerFunc {
+// Replan adjusts a plan to the learner's progress through the planner
+// client, returning the saved plan. Legacy bodies are translated: their
+// completed_lessons are completed resources, and their plan_id must name
+// the plan in the path. Without time_spent_hours, a signed-in learner's
+// time logged on the plan is sent.
+func Replan(orch orchestrator.Orchestrator, repos *repository.Repositories) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req ReplanRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Error:   "invalid_request",
-				Message: err.Error(),
-			})
+			bindError(c, err)
 			return
 		}
 
-		// Forward request to Planner service
-		plannerURL := fmt.Sprintf("%s/replan", cfg.PlannerServiceURL)
-		
-		// Marshal request
-		reqBody, err := json.Marshal(req)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "internal_error",
-				Message: "Failed to marshal request",
-			})
+		planID, ok := planIDParam(c)
+		if !ok {
 			return
 		}
-
-		// Create HTTP request
-		httpReq, err := http.NewRequestWithContext(
-			c.Request.Context(),
-			"POST",
-			plannerURL,
-			bytes.NewBuffer(reqBody),
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "internal_error",
-				Message: "Failed to create request",
+		if req.PlanID != "" && req.PlanID != planID.String() {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Message: "Invalid request body",
+				Errors:  []validation.FieldError{{Field: "plan_id", Rule: "eqfield", Message: "must match the plan in the path"}},
 			})
 			return
 		}
 
-		// Set headers
-		httpReq.Header.Set("Content-Type", "application/json")
-		if requestID := c.GetString("request_id"); requestID != "" {
-			httpReq.Header.Set("X-Request-ID", requestID)
+		replan := models.ReplanRequest{
+			CompletedResources: []uuid.UUID{},
+			TimeSpentHours:     req.TimeSpentHours,
+			RemainingTimeHours: req.RemainingTimeHours,
 		}
-
-		// Send request
-		client := &http.Client{
-			Timeout: 60 * time.Second,
+		for _, id := range append(req.CompletedResources, req.CompletedLessons...) {
+			replan.CompletedResources = append(replan.CompletedResources, uuid.MustParse(id))
 		}
-		resp, err := client.Do(httpReq)
-		if err != nil {
-			c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-				Error:   "service_unavailable",
-				Message: "Planner service is unavailable",
-			})
-			return
+		if req.Feedback != "" {
+			replan.Feedback = &req.Feedback
 		}
-		defer resp.Body.Close()
 
-		// Read response
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "internal_error",
-				Message: "Failed to read response",
-			})
-			return
-		}
-
-		// Check status code
-		if resp.StatusCode != http.StatusOK {
-			var errResp ErrorResponse
-			if err := json.Unmarshal(body, &errResp); err == nil {
-				c.JSON(resp.StatusCode, errResp)
-			} else {
-				c.JSON(resp.StatusCode, ErrorResponse{
-					Error:   "planner_service_error",
-					Message: string(body),
-				})
+		ctx := c.Request.Context()
+		if userID := c.GetString("user_id"); userID != "" {
+			ctx = common.WithUserID(ctx, userID)
+			if replan.TimeSpentHours == 0 {
+				entries, err := repos.TimeLogs.ListTimeLogs(ctx, userID, planID.String())
+				if err != nil {
+					storageError(c, err)
+					return
+				}
+				replan.TimeSpentHours = loggedHours(entries)
 			}
-			return
 		}
-
-		// Parse and return response
-		var replanResp map[string]interface{}
-		if err := json.Unmarshal(body, &replanResp); err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "internal_error",
-				Message: "Failed to parse response",
-			})
+		plan, err := orch.Replan(ctx, planID, replan)
+		if err != nil {
+			upstreamError(c, err, "replan_error")
 			return
 		}
-
-		// Return response
-		c.JSON(http.StatusOK, replanResp)
+		c.JSON(http.StatusOK, plan)
 	}
 }
 
 // GetUserPlans handles GET /api/plan/user/:user_id/plans
-func GetUserPlans(cfg *config.Config) gin.HandlerFunc {
+func GetUserPlans(orch orchestrator.Orchestrator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID := c.Param("user_id")
 		
@@ -317,27 +434,13 @@ func GetUserPlans(cfg *config.Config) gin.HandlerFunc {
 		}
 
 		// Forward request to Planner service
-		plannerURL := fmt.Sprintf("%s/user/%s/plans", cfg.PlannerServiceURL, userID)
-		
-		// Create HTTP request
-		httpReq, err := http.NewRequestWithContext(
-			c.Request.Context(),
-			http.MethodGet,
-			plannerURL,
-			nil,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "internal_error",
-				Message: "Failed to create request",
-			})
-			return
-		}
-
-		// Forward request
-		client := &http.Client{Timeout: 30 * time.Second}
-		resp, err := client.Do(httpReq)
+		resp, err := orch.Forward(c.Request.Context(), "planner", http.MethodGet, "/user/"+url.PathEscape(userID)+"/plans", nil)
 		if err != nil {
+			if clientGone(c) {
+				return
+			}
+			reportSaturation(c, err)
+			middleware.SetRetryAfter(c, upstreamRetryAfter)
 			c.JSON(http.StatusServiceUnavailable, ErrorResponse{
 				Error:   "service_unavailable",
 				Message: "Planner service is unavailable",
@@ -346,40 +449,20 @@ func GetUserPlans(cfg *config.Config) gin.HandlerFunc {
 		}
 		defer resp.Body.Close()
 
-		// Read response
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "internal_error",
-				Message: "Failed to read response",
-			})
-			return
-		}
-
 		// Check status code
 		if resp.StatusCode != http.StatusOK {
-			var errResp ErrorResponse
-			if err := json.Unmarshal(body, &errResp); err == nil {
-				c.JSON(resp.StatusCode, errResp)
-			} else {
-				c.JSON(resp.StatusCode, ErrorResponse{
-					Error:   "planner_service_error",
-					Message: string(body),
-				})
-			}
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+			backendError(c, resp.StatusCode, body, "planner_service_error")
 			return
 		}
 
-		// Parse and return response
-		var plansResp map[string]interface{}
-		if err := json.Unmarshal(body, &plansResp); err != nil {
+		// A user can have hundreds of plans: pass them on as they arrive
+		// rather than holding the whole list
+		if err := streamJSON(c, http.StatusOK, resp.Body); err != nil {
 			c.JSON(http.StatusInternalServerError, ErrorResponse{
 				Error:   "internal_error",
 				Message: "Failed to parse response",
 			})
-			return
 		}
-
-		c.JSON(http.StatusOK, plansResp)
 	}
 }
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/preview"
+	"github.com/amirhf/learnpath-gateway/internal/urlnorm"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// GetPreview returns the title, thumbnail and duration of the link in the
+// url query parameter, from OpenGraph tags and oEmbed
+func GetPreview(cfg *config.Config, previews *preview.Fetcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := c.Query("url")
+		if raw == "" {
+			invalidPreviewURL(c, "required", "is required")
+			return
+		}
+		target, rule, err := urlnorm.Normalize(raw, cfg.Ingestion)
+		if err != nil {
+			invalidPreviewURL(c, rule, err.Error())
+			return
+		}
+
+		p, cached, err := previews.Get(c.Request.Context(), target)
+		if errors.Is(err, preview.ErrPrivateAddress) {
+			invalidPreviewURL(c, "public", "must point to a public address")
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadGateway, ErrorResponse{
+				Error:   "preview_unavailable",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		if cached {
+			c.Header("X-Cache", "HIT")
+		} else {
+			c.Header("X-Cache", "MISS")
+		}
+		c.Header("Cache-Control", "public, max-age=3600")
+		c.JSON(http.StatusOK, p)
+	}
+}
+
+func invalidPreviewURL(c *gin.Context, rule, message string) {
+	c.JSON(http.StatusBadRequest, ErrorResponse{
+		Error:   "invalid_request",
+		Message: "Invalid preview URL",
+		Errors:  []validation.FieldError{{Field: "url", Rule: rule, Message: message}},
+	})
+}
@@ -1,18 +1,34 @@
 package handlers
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/amirhf/learnpath-gateway/internal/calibration"
+	"github.com/amirhf/learnpath-gateway/internal/clients"
 	"github.com/amirhf/learnpath-gateway/internal/common"
 	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/events"
+	"github.com/amirhf/learnpath-gateway/internal/grading"
+	"github.com/amirhf/learnpath-gateway/internal/hooks"
+	"github.com/amirhf/learnpath-gateway/internal/middleware"
 	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/notify"
 	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/ownership"
+	"github.com/amirhf/learnpath-gateway/internal/quizsession"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/retakes"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // QuizGenerateRequest represents quiz generation request
@@ -20,25 +36,87 @@ type QuizGenerateRequest struct {
 	ResourceIDs  []string `json:"resource_ids" binding:"required,min=1"`
 	NumQuestions int      `json:"num_questions,omitempty"`
 	Difficulty   string   `json:"difficulty,omitempty"`
+	Language     string   `json:"language,omitempty"`                                                // Overrides Accept-Language
+	ShortAnswers int      `json:"short_answer_questions,omitempty" binding:"omitempty,gte=0,lte=20"` // How many of the questions are short-answer
 }
 
+// setDefaults fills in the question count and difficulty
+func (r *QuizGenerateRequest) setDefaults() error {
+	if r.NumQuestions == 0 {
+		r.NumQuestions = 5
+	}
+	if r.Difficulty == "" {
+		r.Difficulty = "medium"
+	}
+	if r.ShortAnswers > r.NumQuestions {
+		return errors.New("short_answer_questions cannot exceed num_questions")
+	}
+	return nil
+}
+
+// QuizBatchRequest represents a batch of quizzes to generate, e.g. one per
+// milestone
+type QuizBatchRequest struct {
+	Items []QuizBatchItem `json:"items" binding:"required,min=1,max=20,dive"`
+}
+
+// QuizBatchItem is one quiz of a batch, named by a key unique within it
+type QuizBatchItem struct {
+	Key          string   `json:"key" binding:"required,max=100"`
+	ResourceIDs  []string `json:"resource_ids" binding:"required,min=1"`
+	NumQuestions int      `json:"num_questions,omitempty"`
+	Difficulty   string   `json:"difficulty,omitempty"`
+	Language     string   `json:"language,omitempty"`
+	ShortAnswers int      `json:"short_answer_questions,omitempty" binding:"omitempty,gte=0,lte=20"`
+}
+
+// QuizBatchResponse holds the quizzes generated and the errors of those that
+// failed, by item key
+type QuizBatchResponse struct {
+	Quizzes map[string]any           `json:"quizzes"`
+	Errors  map[string]ErrorResponse `json:"errors"`
+}
+
+// QuizComposeRequest represents a quiz assembled from bank questions
+type QuizComposeRequest struct {
+	Title     string               `json:"title,omitempty" binding:"max=200"`
+	Questions []models.QuestionRef `json:"questions" binding:"required,min=1,max=50,dive"`
+}
+
+// maxBankQuestions caps a question bank listing
+const maxBankQuestions = 200
+
 // QuizSubmitRequest represents quiz submission
 type QuizSubmitRequest struct {
 	QuizID  string       `json:"quiz_id" binding:"required"`
 	Answers []QuizAnswer `json:"answers" binding:"required"`
 }
 
+// QuizSubmitResponse is a graded submission. AttemptID names the attempt
+// recorded for a signed-in learner.
+type QuizSubmitResponse struct {
+	clients.QuizSubmitResponse
+	AttemptID string `json:"attempt_id,omitempty"`
+}
+
 // QuizAnswer represents a single answer
 type QuizAnswer struct {
 	QuestionID       string `json:"question_id"`
 	SelectedOptionID string `json:"selected_option_id"`
+	AnswerText       string `json:"answer_text,omitempty" binding:"max=4000"` // Short-answer questions
 }
 
-// GenerateQuiz uses the orchestrator to generate a quiz
-func GenerateQuiz(cfg *config.Config, orch orchestrator.Orchestrator) gin.HandlerFunc {
+// GenerateQuiz uses the orchestrator to generate a quiz, recording it as
+// issued to the caller so the submission can be checked
+func GenerateQuiz(cfg *config.Config, orch orchestrator.Orchestrator, bus *events.Bus, guard *quizsession.Guard, owners *ownership.Registry) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req QuizGenerateRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+
+		if err := req.setDefaults(); err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResponse{
 				Error:   "invalid_request",
 				Message: err.Error(),
@@ -46,20 +124,13 @@ func GenerateQuiz(cfg *config.Config, orch orchestrator.Orchestrator) gin.Handle
 			return
 		}
 
-		// Set defaults
-		if req.NumQuestions == 0 {
-			req.NumQuestions = 5
-		}
-		if req.Difficulty == "" {
-			req.Difficulty = "medium"
+		language, ok := requestLanguage(c, cfg, req.Language)
+		if !ok {
+			return
 		}
 
-		// Propagate Request ID to context
 		ctx := c.Request.Context()
-		if requestID := c.GetString("request_id"); requestID != "" {
-			ctx = common.WithRequestID(ctx, requestID)
-		}
-		
+
 		// Get User ID from context
 		var userID *string
 		if uid := c.GetString("user_id"); uid != "" {
@@ -78,110 +149,511 @@ func GenerateQuiz(cfg *config.Config, orch orchestrator.Orchestrator) gin.Handle
 			NumQuestions: req.NumQuestions,
 			Difficulty:   req.Difficulty,
 			UserID:       userID,
+			Language:     language,
+			ShortAnswers: req.ShortAnswers,
 		}
 
 		quiz, err := orch.GenerateQuiz(ctx, orchReq)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "quiz_generation_error",
-				Message: err.Error(),
-			})
+			upstreamError(c, err, "quiz_generation_error")
 			return
 		}
+		issueQuizzes(c, guard, owners, quiz)
+
+		bus.Emit(ctx, events.QuizGenerated, c.GetString("user_id"), gin.H{
+			"quiz_id":         quiz.QuizID,
+			"resource_ids":    req.ResourceIDs,
+			"difficulty":      req.Difficulty,
+			"total_questions": quiz.TotalQuestions,
+		})
 
+		if apiVersion(c) == "v2" {
+			c.JSON(http.StatusOK, models.NewPublicQuiz(quiz))
+			return
+		}
 		c.JSON(http.StatusOK, quiz)
 	}
 }
 
-// SubmitQuiz proxies quiz submission to quiz service
-func SubmitQuiz(cfg *config.Config) gin.HandlerFunc {
+// GenerateQuizBatch generates the quizzes of a batch concurrently. Items
+// fail independently: the response is 200 with each quiz, or its error,
+// under the item's key.
+func GenerateQuizBatch(cfg *config.Config, orch orchestrator.Orchestrator, bus *events.Bus, guard *quizsession.Guard, owners *ownership.Registry) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var req QuizSubmitRequest
+		var req QuizBatchRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Error:   "invalid_request",
-				Message: err.Error(),
+			bindError(c, err)
+			return
+		}
+
+		defaultLanguage, ok := requestLanguage(c, cfg, "")
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		var userID *string
+		if uid := c.GetString("user_id"); uid != "" {
+			userID = &uid
+			ctx = common.WithUserID(ctx, uid)
+		}
+		if tenantID := c.GetString("tenant_id"); tenantID != "" {
+			ctx = common.WithTenantID(ctx, tenantID)
+		}
+
+		orchReqs := make([]models.GenerateQuizRequest, len(req.Items))
+		keys := make(map[string]bool, len(req.Items))
+		for i, batchItem := range req.Items {
+			item := QuizGenerateRequest{
+				ResourceIDs:  batchItem.ResourceIDs,
+				NumQuestions: batchItem.NumQuestions,
+				Difficulty:   batchItem.Difficulty,
+				Language:     batchItem.Language,
+				ShortAnswers: batchItem.ShortAnswers,
+			}
+			if keys[batchItem.Key] {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Error:   "invalid_request",
+					Message: fmt.Sprintf("Key %q is used by more than one item", batchItem.Key),
+				})
+				return
+			}
+			keys[batchItem.Key] = true
+			if err := item.setDefaults(); err != nil {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Error:   "invalid_request",
+					Message: fmt.Sprintf("Item %q: %v", batchItem.Key, err),
+				})
+				return
+			}
+			language := defaultLanguage
+			if item.Language != "" {
+				if language, ok = requestLanguage(c, cfg, item.Language); !ok {
+					return
+				}
+			}
+			orchReqs[i] = models.GenerateQuizRequest{
+				ResourceIDs:  item.ResourceIDs,
+				NumQuestions: item.NumQuestions,
+				Difficulty:   item.Difficulty,
+				UserID:       userID,
+				Language:     language,
+				ShortAnswers: item.ShortAnswers,
+			}
+		}
+		// Items naming their own language don't change the batch's
+		c.Header("Content-Language", defaultLanguage)
+
+		quizzes, errs := orch.GenerateQuizzes(ctx, orchReqs)
+		if clientGone(c) {
+			return
+		}
+
+		resp := QuizBatchResponse{Quizzes: map[string]any{}, Errors: map[string]ErrorResponse{}}
+		for i, item := range req.Items {
+			if errs[i] != nil {
+				_, resp.Errors[item.Key], _ = upstreamFailure(errs[i], "quiz_generation_error")
+				continue
+			}
+			quiz := quizzes[i]
+			issueQuizzes(c, guard, owners, quiz)
+			bus.Emit(ctx, events.QuizGenerated, c.GetString("user_id"), gin.H{
+				"quiz_id":         quiz.QuizID,
+				"resource_ids":    item.ResourceIDs,
+				"difficulty":      orchReqs[i].Difficulty,
+				"total_questions": quiz.TotalQuestions,
 			})
+			if apiVersion(c) == "v2" {
+				resp.Quizzes[item.Key] = models.NewPublicQuiz(quiz)
+			} else {
+				resp.Quizzes[item.Key] = quiz
+			}
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// ListBankQuestions browses previously generated questions, newest first,
+// by resource_id and skill (slug or ID). Answers are never included.
+func ListBankQuestions(orch orchestrator.Orchestrator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := models.QuestionBankFilter{
+			ResourceID: c.Query("resource_id"),
+			Skill:      c.Query("skill"),
+			Limit:      50,
+		}
+		if value := c.Query("limit"); value != "" {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxBankQuestions {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Error:   "invalid_request",
+					Message: "Invalid limit",
+					Errors: []validation.FieldError{{
+						Field:   "limit",
+						Rule:    "max",
+						Message: fmt.Sprintf("must be a number from 1 to %d", maxBankQuestions),
+					}},
+				})
+				return
+			}
+			filter.Limit = limit
+		}
+
+		questions, err := orch.ListQuestions(c.Request.Context(), filter)
+		if err != nil {
+			upstreamError(c, err, "question_bank_error")
+			return
+		}
+		if questions == nil {
+			questions = []models.BankQuestion{}
+		}
+		c.JSON(http.StatusOK, gin.H{"questions": questions})
+	}
+}
+
+// ComposeQuiz assembles a quiz from bank questions, in the order given,
+// without generating any. It is served and submitted like a generated quiz.
+func ComposeQuiz(orch orchestrator.Orchestrator, bus *events.Bus, guard *quizsession.Guard, owners *ownership.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req QuizComposeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+		seen := make(map[models.QuestionRef]bool, len(req.Questions))
+		for _, ref := range req.Questions {
+			if seen[ref] {
+				c.JSON(http.StatusBadRequest, ErrorResponse{
+					Error:   "invalid_request",
+					Message: fmt.Sprintf("Question %q of quiz %q is included more than once", ref.QuestionID, ref.QuizID),
+				})
+				return
+			}
+			seen[ref] = true
+		}
+
+		ctx := c.Request.Context()
+		if tenantID := c.GetString("tenant_id"); tenantID != "" {
+			ctx = common.WithTenantID(ctx, tenantID)
+		}
+
+		quiz, err := orch.ComposeQuiz(ctx, models.ComposeQuizRequest{Title: req.Title, Questions: req.Questions})
+		if err != nil {
+			upstreamError(c, err, "quiz_compose_error")
 			return
 		}
+		issueQuizzes(c, guard, owners, quiz)
 
-		// Forward to quiz service
-		quizURL := fmt.Sprintf("%s/submit", cfg.QuizServiceURL)
-		proxyRequest(c, quizURL, req, 30*time.Second)
+		bus.Emit(ctx, events.QuizGenerated, c.GetString("user_id"), gin.H{
+			"quiz_id":         quiz.QuizID,
+			"composed":        true,
+			"total_questions": quiz.TotalQuestions,
+		})
+
+		if apiVersion(c) == "v2" {
+			c.JSON(http.StatusOK, models.NewPublicQuiz(quiz))
+			return
+		}
+		c.JSON(http.StatusOK, quiz)
 	}
 }
 
-// proxyRequest is a helper to forward requests to backend services
-func proxyRequest(c *gin.Context, serviceURL string, payload interface{}, timeout time.Duration) {
-	// Marshal request
-	reqBody, err := json.Marshal(payload)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "internal_error",
-			Message: "Failed to marshal request",
+// QuizRetakeRequest represents a quiz retake request; the body is optional
+type QuizRetakeRequest struct {
+	Language string `json:"language,omitempty"` // Overrides Accept-Language
+}
+
+// RetakeQuiz serves the caller a regenerated variant of a quiz, with
+// paraphrased questions and shuffled options, within the tenant's retake
+// limit and cooldown. Retaking a variant counts toward its original quiz.
+func RetakeQuiz(cfg *config.Config, orch orchestrator.Orchestrator, bus *events.Bus, policy *retakes.Policy, guard *quizsession.Guard, owners *ownership.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+
+		var req QuizRetakeRequest
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			bindError(c, err)
+			return
+		}
+		language, ok := requestLanguage(c, cfg, req.Language)
+		if !ok {
+			return
+		}
+
+		ctx := c.Request.Context()
+		ctx = common.WithUserID(ctx, userID)
+		tenantID := c.GetString("tenant_id")
+		if tenantID != "" {
+			ctx = common.WithTenantID(ctx, tenantID)
+		}
+
+		reservation, err := policy.Reserve(ctx, tenantID, userID, c.Param("id"))
+		var denial *retakes.Denial
+		if errors.As(err, &denial) {
+			if denial.RetryAfter > 0 {
+				middleware.SetRetryAfter(c, denial.RetryAfter)
+			}
+			c.JSON(denial.Status, ErrorResponse{Error: denial.Code, Message: denial.Message})
+			return
+		}
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+
+		quiz, err := orch.RetakeQuiz(ctx, models.RetakeQuizRequest{
+			QuizID:   reservation.QuizID,
+			Language: language,
+			TenantID: tenantID,
 		})
-		return
+		if err != nil {
+			policy.Release(ctx, reservation)
+			upstreamError(c, err, "quiz_retake_error")
+			return
+		}
+		if err := policy.Record(ctx, reservation, quiz.QuizID); err != nil {
+			log.Printf("Failed to record quiz variant %s: %v", quiz.QuizID, err)
+		}
+		issueQuizzes(c, guard, owners, quiz)
+
+		bus.Emit(ctx, events.QuizGenerated, userID, gin.H{
+			"quiz_id":         quiz.QuizID,
+			"variant_of":      reservation.QuizID,
+			"retake":          reservation.Count,
+			"total_questions": quiz.TotalQuestions,
+		})
+
+		if reservation.Remaining >= 0 {
+			c.Header("X-Retakes-Remaining", strconv.Itoa(reservation.Remaining))
+		}
+		if apiVersion(c) == "v2" {
+			c.JSON(http.StatusOK, models.NewPublicQuiz(quiz))
+			return
+		}
+		c.JSON(http.StatusOK, quiz)
 	}
+}
 
-	// Create HTTP request
-	httpReq, err := http.NewRequestWithContext(
-		c.Request.Context(),
-		"POST",
-		serviceURL,
-		bytes.NewBuffer(reqBody),
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "internal_error",
-			Message: "Failed to create request",
+// SubmitQuiz has the quiz service grade a submission through the
+// orchestrator, counting the graded answers toward each question's
+// calibration. Answers are first checked against the quiz as issued to the
+// caller, who must own it. A signed-in learner's attempt is recorded, and
+// its ID and Location returned; short answers are left pending for the
+// grading worker. The result is posted to the tenant's chat channels.
+func SubmitQuiz(orch orchestrator.Orchestrator, repos *repository.Repositories, bus *events.Bus, subscribers *hooks.Registry, calibrator *calibration.Calibrator, grader *grading.Worker, guard *quizsession.Guard, owners *ownership.Registry, notifier *notify.Dispatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req QuizSubmitRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+
+		err := owners.Check(c.Request.Context(), ownership.Quiz, req.QuizID, middleware.Caller(c), ownership.Write, "")
+		var denial *ownership.Denial
+		if errors.As(err, &denial) {
+			c.JSON(denial.Status, ErrorResponse{Error: denial.Code, Message: denial.Message})
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to check owner of quiz %s: %v", req.QuizID, err)
+			middleware.OwnershipUnavailable(c)
+			return
+		}
+
+		answers := make([]quizsession.Answer, len(req.Answers))
+		for i, answer := range req.Answers {
+			answers[i] = quizsession.Answer{QuestionID: answer.QuestionID, OptionID: answer.SelectedOptionID, Text: answer.AnswerText}
+		}
+		session, err := guard.Check(c.Request.Context(), c.GetString("user_id"), req.QuizID, answers)
+		var violation *quizsession.Violation
+		if errors.As(err, &violation) {
+			c.JSON(violation.Status, ErrorResponse{Error: violation.Code, Message: violation.Message})
+			return
+		}
+		if err != nil {
+			// Checks fail open so a store outage doesn't block submissions
+			log.Printf("Failed to check quiz submission %s: %v", req.QuizID, err)
+		}
+
+		ctx := c.Request.Context()
+		submission := clients.QuizSubmitRequest{QuizID: req.QuizID, Answers: make([]clients.QuizAnswer, len(req.Answers))}
+		for i, answer := range req.Answers {
+			submission.Answers[i] = clients.QuizAnswer(answer)
+		}
+		graded, err := orch.SubmitQuiz(ctx, submission)
+		if err != nil {
+			// Ungraded submissions don't use up the attempt
+			guard.Release(context.WithoutCancel(c.Request.Context()), session)
+			upstreamError(c, err, "quiz_service_error")
+			return
+		}
+
+		if calibrator.Enabled() {
+			calibrate(c, orch, calibrator, *graded)
+		}
+		resp := QuizSubmitResponse{QuizSubmitResponse: *graded}
+		if userID := c.GetString("user_id"); userID != "" {
+			attempt := repository.QuizAttempt{
+				ID:          uuid.NewString(),
+				UserID:      userID,
+				QuizID:      req.QuizID,
+				Status:      repository.QuizAttemptGraded,
+				Score:       &graded.Score,
+				SubmittedAt: time.Now().UTC(),
+			}
+			// Outcomes per question feed the plan's quiz analytics
+			for _, result := range graded.Results {
+				outcome := repository.QuestionOutcome{
+					QuestionID: result.QuestionID,
+					ResourceID: result.SourceResourceID,
+					Correct:    result.Correct,
+					AnswerText: result.AnswerText,
+					Feedback:   result.Feedback,
+				}
+				if result.Status == models.ResultPending {
+					outcome.Pending = true
+					attempt.Status = repository.QuizAttemptPending
+				}
+				attempt.Results = append(attempt.Results, outcome)
+			}
+			if err := repos.Quizzes.RecordQuizAttempt(c.Request.Context(), attempt); err != nil {
+				log.Printf("Failed to record quiz attempt: %v", err)
+			} else {
+				resp.AttemptID = attempt.ID
+				subscribers.Fire(c.Request.Context(), userID, hooks.QuizSubmitted, hooks.QuizSubmissionItem(attempt))
+				c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/submit")+"/attempts/"+attempt.ID)
+				if attempt.Status == repository.QuizAttemptPending {
+					grader.Notify()
+				}
+			}
+		}
+		bus.Emit(c.Request.Context(), events.QuizSubmitted, c.GetString("user_id"), gin.H{
+			"quiz_id":     req.QuizID,
+			"num_answers": len(req.Answers),
+			"score":       graded.Score,
 		})
-		return
+		if notifier.Wants(c.GetString("tenant_id"), notify.QuizResult) {
+			notifyQuizResult(c, notifier, *graded)
+		}
+		c.JSON(http.StatusOK, resp)
 	}
+}
 
-	// Set headers
-	httpReq.Header.Set("Content-Type", "application/json")
-	if requestID := c.GetString("request_id"); requestID != "" {
-		httpReq.Header.Set("X-Request-ID", requestID)
+// notifyQuizResult posts a graded submission to the tenant's chat channels
+// without holding up the response
+func notifyQuizResult(c *gin.Context, notifier *notify.Dispatcher, graded clients.QuizSubmitResponse) {
+	tenantID := c.GetString("tenant_id")
+	learner := c.GetString("user_id")
+	if learner == "" {
+		learner = "A guest"
+	}
+	msg := notify.Message{
+		Title: "Quiz result",
+		Text:  fmt.Sprintf("%s scored %.0f%% on quiz %s", learner, graded.Score, graded.QuizID),
+		Facts: []notify.Fact{
+			{Name: "Score", Value: fmt.Sprintf("%.0f%%", graded.Score)},
+			{Name: "Correct answers", Value: fmt.Sprintf("%d of %d", graded.CorrectAnswers, graded.TotalQuestions)},
+		},
+	}
+	if graded.PendingQuestions > 0 {
+		msg.Facts = append(msg.Facts, notify.Fact{Name: "Awaiting grading", Value: fmt.Sprint(graded.PendingQuestions)})
 	}
+	ctx := context.WithoutCancel(c.Request.Context())
+	go func() {
+		if err := notifier.Send(ctx, tenantID, notify.QuizResult, msg); err != nil {
+			log.Printf("Failed to post quiz result of %s: %v", graded.QuizID, err)
+		}
+	}()
+}
 
-	// Send request
-	client := &http.Client{
-		Timeout: timeout,
+// calibrate counts a submission's graded answers and sends the updated
+// question statistics to the quiz service without holding up the response
+func calibrate(c *gin.Context, orch orchestrator.Orchestrator, calibrator *calibration.Calibrator, graded clients.QuizSubmitResponse) {
+	tenantID := c.GetString("tenant_id")
+	if tenantID == "" {
+		tenantID = "global"
+	}
+	results := make([]models.QuestionResult, 0, len(graded.Results))
+	for _, result := range graded.Results {
+		if result.Status != models.ResultPending {
+			results = append(results, result)
+		}
 	}
-	resp, err := client.Do(httpReq)
+	ctx := context.WithoutCancel(c.Request.Context())
+	stats, err := calibrator.Record(ctx, tenantID, graded.QuizID, results)
 	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error:   "service_unavailable",
-			Message: "Quiz service is unavailable",
-		})
+		log.Printf("Failed to record quiz calibration: %v", err)
 		return
 	}
-	defer resp.Body.Close()
-
-	// Read response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "internal_error",
-			Message: "Failed to read response",
-		})
+	if len(stats) == 0 {
 		return
 	}
+	go func() {
+		if err := orch.CalibrateQuiz(ctx, models.CalibrationRequest{TenantID: tenantID, Questions: stats}); err != nil {
+			log.Printf("Failed to send quiz calibration: %v", err)
+		}
+	}()
+}
 
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		var errResp ErrorResponse
-		if err := json.Unmarshal(body, &errResp); err == nil {
-			c.JSON(resp.StatusCode, errResp)
-		} else {
-			c.JSON(resp.StatusCode, ErrorResponse{
-				Error:   "quiz_service_error",
-				Message: string(body),
-			})
+// issueQuizzes records quizzes as issued to, and owned by, the caller.
+// Failures are logged; the quizzes' submissions then go unchecked.
+func issueQuizzes(c *gin.Context, guard *quizsession.Guard, owners *ownership.Registry, quizzes ...*models.Quiz) {
+	for _, quiz := range quizzes {
+		if quiz == nil {
+			continue
 		}
-		return
+		if err := guard.Issue(c.Request.Context(), c.GetString("user_id"), quiz); err != nil {
+			log.Printf("Failed to record quiz session %s: %v", quiz.QuizID, err)
+		}
+		claimOwnership(c, owners, ownership.Quiz, quiz.QuizID)
+	}
+}
+
+// claimOwnership records the caller as the owner of a plan or quiz it
+// created. Failures are logged; the resource is then not found by anyone.
+func claimOwnership(c *gin.Context, owners *ownership.Registry, kind, id string) {
+	if err := owners.Claim(c.Request.Context(), kind, id, middleware.Caller(c)); err != nil {
+		log.Printf("Failed to record owner of %s %s: %v", kind, id, err)
+	}
+}
+
+// ListQuizAttempts lists the caller's quiz attempts, oldest first
+func ListQuizAttempts(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+
+		attempts, err := repos.Quizzes.ListQuizAttempts(c.Request.Context(), userID)
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		if attempts == nil {
+			attempts = []repository.QuizAttempt{}
+		}
+		c.JSON(http.StatusOK, gin.H{"attempts": attempts})
 	}
+}
+
+// GetQuizAttempt returns one of the caller's quiz attempts. Poll it until
+// its status moves from pending to graded.
+func GetQuizAttempt(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
 
-	// Forward response with correct content type
-	c.Data(resp.StatusCode, "application/json", body)
+		attempt, err := repos.Quizzes.GetQuizAttempt(c.Request.Context(), userID, c.Param("attempt_id"))
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, attempt)
+	}
 }
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/events"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// RemediationRequest picks the quiz attempt to remediate; the body is
+// optional
+type RemediationRequest struct {
+	AttemptID string `json:"attempt_id,omitempty"` // Defaults to the latest graded attempt
+}
+
+// RemediationResponse is a plan replanned with review of the resources
+// behind a failed quiz attempt
+type RemediationResponse struct {
+	AttemptID string  `json:"attempt_id"`
+	Score     float64 `json:"score"`
+	Threshold float64 `json:"threshold"`
+	*models.Remediation
+}
+
+// RemediatePlan replans a plan after a quiz attempt scoring below the
+// remediation threshold, adding a review milestone of the resources behind
+// the missed questions before the learner advances.
+func RemediatePlan(cfg *config.Config, orch orchestrator.Orchestrator, repos *repository.Repositories, bus *events.Bus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		var req RemediationRequest
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			bindError(c, err)
+			return
+		}
+
+		attempt, err := remediationAttempt(c, repos, userID, req.AttemptID)
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		if attempt == nil {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "not_found", Message: "Quiz attempt not found"})
+			return
+		}
+		if attempt.Status == repository.QuizAttemptPending {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "attempt_pending",
+				Message: "The attempt is still being graded",
+			})
+			return
+		}
+
+		threshold := cfg.Remediation.ScoreThreshold
+		score := attemptScore(attempt)
+		if score >= threshold {
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "remediation_not_needed",
+				Message: fmt.Sprintf("A score of %.0f%% meets the %.0f%% threshold", score, threshold),
+			})
+			return
+		}
+
+		planID, ok := planIDParam(c)
+		if !ok {
+			return
+		}
+		remediation, err := orch.RemediatePlan(c.Request.Context(), models.RemediationRequest{
+			PlanID:          planID,
+			MissedResources: missedResources(attempt),
+			MaxResources:    cfg.Remediation.MaxReviewResources,
+		})
+		if errors.Is(err, orchestrator.ErrNothingToReview) {
+			c.JSON(http.StatusUnprocessableEntity, ErrorResponse{
+				Error:   "nothing_to_review",
+				Message: "None of the attempt's missed questions are on this plan's resources",
+			})
+			return
+		}
+		if err != nil {
+			upstreamError(c, err, "remediation_error")
+			return
+		}
+
+		bus.Emit(c.Request.Context(), events.PlanRemediated, userID, gin.H{
+			"plan_id":          planID,
+			"attempt_id":       attempt.ID,
+			"score":            score,
+			"review_resources": remediation.ReviewResources,
+			"weak_skills":      remediation.WeakSkills,
+		})
+		c.JSON(http.StatusOK, RemediationResponse{AttemptID: attempt.ID, Score: score, Threshold: threshold, Remediation: remediation})
+	}
+}
+
+// remediationAttempt returns the learner's attempt with the given ID, or
+// their latest graded attempt; nil if there is none
+func remediationAttempt(c *gin.Context, repos *repository.Repositories, userID, attemptID string) (*repository.QuizAttempt, error) {
+	if attemptID != "" {
+		attempt, err := repos.Quizzes.GetQuizAttempt(c.Request.Context(), userID, attemptID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &attempt, nil
+	}
+	attempts, err := repos.Quizzes.ListQuizAttempts(c.Request.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+	var latest *repository.QuizAttempt
+	for i := range attempts {
+		if attempts[i].Status != repository.QuizAttemptPending && (latest == nil || attempts[i].SubmittedAt.After(latest.SubmittedAt)) {
+			latest = &attempts[i]
+		}
+	}
+	return latest, nil
+}
+
+// attemptScore is an attempt's percentage, counted from its answers for
+// attempts recorded before scores were
+func attemptScore(attempt *repository.QuizAttempt) float64 {
+	if attempt.Score != nil {
+		return *attempt.Score
+	}
+	var score Score
+	for _, outcome := range attempt.Results {
+		score.add(outcome.Correct)
+	}
+	if score.CorrectRate == nil {
+		return 0
+	}
+	return *score.CorrectRate * 100
+}
+
+// missedResources lists the resources of an attempt's incorrect answers,
+// most missed first
+func missedResources(attempt *repository.QuizAttempt) []string {
+	var resources []string
+	misses := map[string]int{}
+	for _, outcome := range attempt.Results {
+		if outcome.Correct || outcome.Pending || outcome.ResourceID == "" {
+			continue
+		}
+		if misses[outcome.ResourceID] == 0 {
+			resources = append(resources, outcome.ResourceID)
+		}
+		misses[outcome.ResourceID]++
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return misses[resources[i]] > misses[resources[j]]
+	})
+	return resources
+}
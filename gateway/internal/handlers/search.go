@@ -1,14 +1,17 @@
 package handlers
 
 import (
-	"bytes"
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
-	"time"
 
+	"github.com/amirhf/learnpath-gateway/internal/bufpool"
 	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/diversity"
+	"github.com/amirhf/learnpath-gateway/internal/features"
+	"github.com/amirhf/learnpath-gateway/internal/middleware"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,7 +22,9 @@ type SearchRequest struct {
 	Rerank      bool          `json:"rerank,omitempty"`
 	RerankTopN  int           `json:"rerank_top_n,omitempty"`
 	Filters     *SearchFilter `json:"filters,omitempty"`
-	TenantID    string        `json:"tenant_id,omitempty"`
+	TenantID    string        `json:"tenant_id,omitempty"` // Set from the caller's tenant
+	// Applied by the gateway, not sent to the RAG service
+	Diversity *models.Diversity `json:"diversity,omitempty"`
 }
 
 // SearchFilter represents search filters
@@ -56,19 +61,27 @@ type SearchResponse struct {
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
+	Error   string                  `json:"error"`
+	Message string                  `json:"message,omitempty"`
+	Errors  []validation.FieldError `json:"errors,omitempty"` // Per-field problems with the request
+}
+
+// bindError responds to a request body that failed to bind or validate,
+// listing the problem with each field
+func bindError(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, ErrorResponse{
+		Error:   "invalid_request",
+		Message: "Invalid request body",
+		Errors:  validation.Translate(err),
+	})
 }
 
 // Search returns a search handler
-func Search(cfg *config.Config) gin.HandlerFunc {
+func Search(cfg *config.Config, orch orchestrator.Orchestrator, switches *features.Switches) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req SearchRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Error:   "invalid_request",
-				Message: err.Error(),
-			})
+			bindError(c, err)
 			return
 		}
 
@@ -82,17 +95,26 @@ func Search(cfg *config.Config) gin.HandlerFunc {
 		// Default rerank to false to avoid timeout issues with model loading
 		// Frontend can explicitly set to true if needed
 		// Note: Rerank is currently disabled due to model loading time
+		if switches.Enabled(features.KillRerank) {
+			req.Rerank = false
+		}
+
+		// Search the caller's tenant, whatever the body names; admins pick
+		// another through TenantOverride
+		req.TenantID = c.GetString("tenant_id")
 
-		// Inject Tenant ID from context
-		if tenantID := c.GetString("tenant_id"); tenantID != "" {
-			req.TenantID = tenantID
+		// Fetch extra results when some will be dropped for diversity
+		limits := diversity.Resolve(cfg.Diversity, c.GetString("tenant_id"), req.Diversity)
+		keep := req.TopK
+		if req.Rerank {
+			keep = req.RerankTopN
 		}
+		forward := req
+		forward.Diversity = nil
+		forward.TopK = diversity.TopK(req.TopK, limits)
 
-		// Forward request to RAG service
-		ragURL := fmt.Sprintf("%s/search", cfg.RAGServiceURL)
-		
 		// Marshal request
-		reqBody, err := json.Marshal(req)
+		reqBody, err := bufpool.JSON(forward)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, ErrorResponse{
 				Error:   "internal_error",
@@ -100,35 +122,17 @@ func Search(cfg *config.Config) gin.HandlerFunc {
 			})
 			return
 		}
+		defer reqBody.Release()
 
-		// Create HTTP request
-		httpReq, err := http.NewRequestWithContext(
-			c.Request.Context(),
-			"POST",
-			ragURL,
-			bytes.NewBuffer(reqBody),
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "internal_error",
-				Message: "Failed to create request",
-			})
-			return
-		}
-
-		// Set headers
-		httpReq.Header.Set("Content-Type", "application/json")
-		if requestID := c.GetString("request_id"); requestID != "" {
-			httpReq.Header.Set("X-Request-ID", requestID)
-		}
-
-		// Send request
-		// Increased timeout to 60s to allow for model loading on cold start
-		client := &http.Client{
-			Timeout: 60 * time.Second,
-		}
-		resp, err := client.Do(httpReq)
+		// Forward request to RAG service, through the client's balancing,
+		// failover and hedging
+		resp, err := orch.Forward(c.Request.Context(), "rag", http.MethodPost, "/search", reqBody)
 		if err != nil {
+			if clientGone(c) {
+				return
+			}
+			reportSaturation(c, err)
+			middleware.SetRetryAfter(c, upstreamRetryAfter)
 			c.JSON(http.StatusServiceUnavailable, ErrorResponse{
 				Error:   "service_unavailable",
 				Message: "RAG service is unavailable",
@@ -138,7 +142,7 @@ func Search(cfg *config.Config) gin.HandlerFunc {
 		defer resp.Body.Close()
 
 		// Read response
-		body, err := io.ReadAll(resp.Body)
+		respBody, err := bufpool.ReadAll(resp.Body)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, ErrorResponse{
 				Error:   "internal_error",
@@ -146,18 +150,12 @@ func Search(cfg *config.Config) gin.HandlerFunc {
 			})
 			return
 		}
+		defer bufpool.Put(respBody)
+		body := respBody.Bytes()
 
 		// Check status code
 		if resp.StatusCode != http.StatusOK {
-			var errResp ErrorResponse
-			if err := json.Unmarshal(body, &errResp); err == nil {
-				c.JSON(resp.StatusCode, errResp)
-			} else {
-				c.JSON(resp.StatusCode, ErrorResponse{
-					Error:   "rag_service_error",
-					Message: string(body),
-				})
-			}
+			backendError(c, resp.StatusCode, body, "rag_service_error")
 			return
 		}
 
@@ -171,6 +169,10 @@ func Search(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		searchResp.Results = diversity.Apply(searchResp.Results, limits, keep, func(r ResourceResult) (*string, *string) {
+			return r.Provider, r.MediaType
+		})
+
 		// Return response
 		c.JSON(http.StatusOK, searchResp)
 	}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateShareTokenRequest represents a request to share a plan
+type CreateShareTokenRequest struct {
+	ExpiresInHours int `json:"expires_in_hours,omitempty" binding:"gte=0"`
+}
+
+// CreateShareToken issues a token granting read access to a plan
+func CreateShareToken(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+
+		var req CreateShareTokenRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				bindError(c, err)
+				return
+			}
+		}
+
+		secret := make([]byte, 24)
+		if _, err := rand.Read(secret); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to generate token",
+			})
+			return
+		}
+
+		token := repository.ShareToken{
+			Token:     base64.RawURLEncoding.EncodeToString(secret),
+			UserID:    userID,
+			PlanID:    c.Param("id"),
+			CreatedAt: time.Now().UTC(),
+		}
+		if req.ExpiresInHours > 0 {
+			expiresAt := token.CreatedAt.Add(time.Duration(req.ExpiresInHours) * time.Hour)
+			token.ExpiresAt = &expiresAt
+		}
+
+		if err := repos.ShareTokens.CreateShareToken(c.Request.Context(), token); err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, token)
+	}
+}
+
+// ResolveShareToken returns the plan a share token points to
+func ResolveShareToken(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := repos.ShareTokens.GetShareToken(c.Request.Context(), c.Param("token"))
+		if err == nil && token.Expired(time.Now()) {
+			err = repository.ErrNotFound
+		}
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"plan_id":    token.PlanID,
+			"expires_at": token.ExpiresAt,
+		})
+	}
+}
+
+// RevokeShareToken deletes one of the caller's share tokens
+func RevokeShareToken(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+
+		if err := repos.ShareTokens.RevokeShareToken(c.Request.Context(), userID, c.Param("token")); err != nil {
+			storageError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/health"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/gin-gonic/gin"
+)
+
+// statusMaxAge is how long status page clients and CDNs may cache /status
+const statusMaxAge = "public, max-age=30"
+
+// Status serves the public status page: the current status of the gateway
+// and each backend, their uptime over the last 24 hours and 7 days, and
+// ongoing incidents
+func Status(poller *health.Poller, orch orchestrator.Orchestrator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", statusMaxAge)
+		c.JSON(http.StatusOK, poller.Status(c.Request.Context(), orch.BreakerStates()))
+	}
+}
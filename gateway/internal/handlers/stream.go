@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/bufpool"
+	"github.com/gin-gonic/gin"
+)
+
+// streamFlushBytes is how much streamed output is sent to the client at a
+// time
+const streamFlushBytes = 32 << 10
+
+// errClientGone stops a stream once writes to the client fail
+var errClientGone = errors.New("client went away")
+
+// streamJSON copies the JSON value in body to the response, decoding and
+// writing arrays an element at a time so a long list is never held in
+// memory whole. Output is compacted and flushed every streamFlushBytes.
+// A body that turns out to be malformed is reported as an error while
+// nothing has been sent; after that the status can't change, so the
+// response is aborted instead.
+func streamJSON(c *gin.Context, status int, body io.Reader) error {
+	s := &jsonStream{c: c, dec: json.NewDecoder(body), out: bufpool.Get()}
+	defer bufpool.Put(s.out)
+	s.dec.UseNumber()
+
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(status)
+
+	err = s.value(tok)
+	switch {
+	case errors.Is(err, errClientGone):
+	case err != nil && !s.sent:
+		return err
+	case err != nil:
+		c.Error(err)
+		panic(http.ErrAbortHandler)
+	default:
+		s.flush()
+	}
+	return nil
+}
+
+// jsonStream re-encodes decoder tokens to the response through a buffer
+type jsonStream struct {
+	c    *gin.Context
+	dec  *json.Decoder
+	out  *bytes.Buffer
+	sent bool
+}
+
+// value writes the value starting with tok
+func (s *jsonStream) value(tok json.Token) error {
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return s.scalar(tok)
+	}
+	switch delim {
+	case '{':
+		s.out.WriteByte('{')
+		for i := 0; s.dec.More(); i++ {
+			key, err := s.dec.Token()
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				s.out.WriteByte(',')
+			}
+			if err := s.scalar(key); err != nil {
+				return err
+			}
+			s.out.WriteByte(':')
+			if err := s.next(); err != nil {
+				return err
+			}
+		}
+		s.out.WriteByte('}')
+	case '[':
+		s.out.WriteByte('[')
+		for i := 0; s.dec.More(); i++ {
+			if i > 0 {
+				s.out.WriteByte(',')
+			}
+			// Elements are decoded whole; only the list is streamed
+			var elem json.RawMessage
+			if err := s.dec.Decode(&elem); err != nil {
+				return err
+			}
+			if err := json.Compact(s.out, elem); err != nil {
+				return err
+			}
+			if s.out.Len() >= streamFlushBytes && !s.flush() {
+				return errClientGone
+			}
+		}
+		s.out.WriteByte(']')
+	default:
+		return errors.New("unexpected " + delim.String())
+	}
+	// Consume the closing delimiter
+	_, err := s.dec.Token()
+	return err
+}
+
+// next writes the value at the decoder's position
+func (s *jsonStream) next() error {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	return s.value(tok)
+}
+
+// scalar writes a string, number, boolean or null
+func (s *jsonStream) scalar(tok json.Token) error {
+	data, err := json.Marshal(tok)
+	if err != nil {
+		return err
+	}
+	s.out.Write(data)
+	return nil
+}
+
+func (s *jsonStream) flush() bool {
+	s.sent = true
+	return flushBuffer(s.c, s.out)
+}
+
+// flushBuffer sends buf to the client and empties it. It reports false if
+// the client has gone away, as there is no point in writing more.
+func flushBuffer(c *gin.Context, buf *bytes.Buffer) bool {
+	if _, err := c.Writer.Write(buf.Bytes()); err != nil {
+		c.Error(err)
+		return false
+	}
+	buf.Reset()
+	c.Writer.Flush()
+	return true
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// maxTimerDuration caps a timed session, so a timer left running counts
+// as a long study session rather than days
+const maxTimerDuration = 8 * time.Hour
+
+// Time log actions
+const (
+	TimerStart = "start"
+	TimerStop  = "stop"
+)
+
+// LogTimeRequest starts or stops a timer on a resource, or logs minutes
+// spent on it
+type LogTimeRequest struct {
+	Action  string  `json:"action,omitempty" binding:"omitempty,oneof=start stop"`
+	Minutes float64 `json:"minutes,omitempty" binding:"omitempty,gt=0,max=1440"`
+	// EndedAt is when logged minutes ended; now if absent
+	EndedAt *time.Time `json:"ended_at,omitempty"`
+}
+
+// ResourceTime is the time logged on one resource of a plan
+type ResourceTime struct {
+	ResourceID string  `json:"resource_id"`
+	Minutes    float64 `json:"minutes"`
+	Running    bool    `json:"running"` // A timer is running, not yet counted
+}
+
+// TimeSpentResponse is the time the caller logged on a plan
+type TimeSpentResponse struct {
+	PlanID     string         `json:"plan_id"`
+	TotalHours float64        `json:"total_hours"`
+	Resources  []ResourceTime `json:"resources"` // By when first studied
+}
+
+// LogTime starts or stops the caller's timer on a resource of a plan, or
+// records minutes spent on it. Starting answers 409 while a timer is
+// already running on the resource and stopping 404 when none is; a
+// timer stops counting after maxTimerDuration.
+func LogTime(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		var req LogTimeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+		if (req.Action == "") == (req.Minutes == 0) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Message: "Invalid request body",
+				Errors: []validation.FieldError{{
+					Field:   "action",
+					Rule:    "required_without",
+					Message: "set either action (start or stop) or minutes",
+				}},
+			})
+			return
+		}
+
+		ctx := c.Request.Context()
+		now := time.Now().UTC()
+		planID, resourceID := c.Param("id"), c.Param("rid")
+		switch req.Action {
+		case TimerStart:
+			entry, err := repos.TimeLogs.StartTimer(ctx, repository.TimeLog{UserID: userID, PlanID: planID, ResourceID: resourceID, StartedAt: now})
+			if errors.Is(err, repository.ErrTimerRunning) {
+				c.JSON(http.StatusConflict, ErrorResponse{
+					Error:   "timer_running",
+					Message: "A timer is already running on this resource",
+				})
+				return
+			}
+			if err != nil {
+				storageError(c, err)
+				return
+			}
+			c.JSON(http.StatusCreated, entry)
+		case TimerStop:
+			entry, err := repos.TimeLogs.StopTimer(ctx, userID, planID, resourceID, now, maxTimerDuration)
+			if err != nil {
+				storageError(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, entry)
+		default:
+			ended := now
+			if req.EndedAt != nil {
+				if req.EndedAt.After(now) {
+					c.JSON(http.StatusBadRequest, ErrorResponse{
+						Error:   "invalid_request",
+						Message: "Invalid request body",
+						Errors:  []validation.FieldError{{Field: "ended_at", Rule: "lte", Message: "must not be in the future"}},
+					})
+					return
+				}
+				ended = req.EndedAt.UTC()
+			}
+			entry, err := repos.TimeLogs.AddTimeLog(ctx, repository.TimeLog{
+				UserID:     userID,
+				PlanID:     planID,
+				ResourceID: resourceID,
+				StartedAt:  ended.Add(-time.Duration(req.Minutes * float64(time.Minute))),
+				EndedAt:    &ended,
+				Minutes:    req.Minutes,
+			})
+			if err != nil {
+				storageError(c, err)
+				return
+			}
+			c.JSON(http.StatusCreated, entry)
+		}
+	}
+}
+
+// GetTimeSpent returns the time the caller logged on a plan, per resource
+func GetTimeSpent(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		entries, err := repos.TimeLogs.ListTimeLogs(c.Request.Context(), userID, c.Param("id"))
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+
+		resp := TimeSpentResponse{PlanID: c.Param("id"), TotalHours: loggedHours(entries), Resources: []ResourceTime{}}
+		index := map[string]int{}
+		for _, entry := range entries {
+			i, ok := index[entry.ResourceID]
+			if !ok {
+				i = len(resp.Resources)
+				index[entry.ResourceID] = i
+				resp.Resources = append(resp.Resources, ResourceTime{ResourceID: entry.ResourceID})
+			}
+			resp.Resources[i].Minutes = math.Round((resp.Resources[i].Minutes+entry.Minutes)*100) / 100
+			if entry.EndedAt == nil {
+				resp.Resources[i].Running = true
+			}
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// loggedHours totals finished time logs, in hours to two decimals
+func loggedHours(entries []repository.TimeLog) float64 {
+	var minutes float64
+	for _, entry := range entries {
+		minutes += entry.Minutes
+	}
+	return math.Round(minutes/60*100) / 100
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/transcripts"
+	"github.com/gin-gonic/gin"
+)
+
+// GetTranscript returns the time-coded transcript of a video resource
+func GetTranscript(videos *transcripts.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t, ok, err := videos.Get(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "not_found",
+				Message: "No transcript for this resource",
+			})
+			return
+		}
+		c.JSON(http.StatusOK, t)
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/hooks"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// maxTriggerItems caps one page of a trigger listing
+const maxTriggerItems = 100
+
+// SubscribeHookRequest registers a REST hook
+type SubscribeHookRequest struct {
+	Event     string `json:"event" binding:"required"`
+	TargetURL string `json:"target_url" binding:"required"`
+}
+
+// ListNewPlans lists the caller's plans created after ?since, newest first
+func ListNewPlans(orch orchestrator.Orchestrator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		since, limit, ok := triggerWindow(c)
+		if !ok {
+			return
+		}
+
+		plans, err := orch.GetUserPlans(common.WithUserID(c.Request.Context(), userID), userID)
+		if err != nil {
+			upstreamError(c, err, "plan_error")
+			return
+		}
+		items := []hooks.Plan{}
+		for _, plan := range plans {
+			if plan.CreatedAt.After(since) {
+				items = append(items, hooks.NewPlanItem(plan))
+			}
+		}
+		sort.Slice(items, func(i, j int) bool { return items[i].CreatedAt.After(items[j].CreatedAt) })
+		c.JSON(http.StatusOK, truncate(items, limit))
+	}
+}
+
+// ListCompletedMilestones lists the milestones the caller completed after
+// ?since, newest first
+func ListCompletedMilestones(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		since, limit, ok := triggerWindow(c)
+		if !ok {
+			return
+		}
+
+		progress, err := repos.Progress.ListProgress(c.Request.Context(), userID, "")
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		items := []hooks.Milestone{}
+		for _, p := range progress {
+			if p.Completed && p.UpdatedAt.After(since) {
+				items = append(items, hooks.MilestoneItem(p))
+			}
+		}
+		sort.Slice(items, func(i, j int) bool { return items[i].CompletedAt.After(items[j].CompletedAt) })
+		c.JSON(http.StatusOK, truncate(items, limit))
+	}
+}
+
+// ListQuizSubmissions lists the caller's quiz attempts submitted after
+// ?since, newest first
+func ListQuizSubmissions(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		since, limit, ok := triggerWindow(c)
+		if !ok {
+			return
+		}
+
+		attempts, err := repos.Quizzes.ListQuizAttempts(c.Request.Context(), userID)
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		items := []hooks.QuizSubmission{}
+		for i := len(attempts) - 1; i >= 0; i-- {
+			if attempts[i].SubmittedAt.After(since) {
+				items = append(items, hooks.QuizSubmissionItem(attempts[i]))
+			}
+		}
+		c.JSON(http.StatusOK, truncate(items, limit))
+	}
+}
+
+// triggerWindow reads a trigger listing's ?since cursor, an RFC 3339 time
+// (zero when absent), and ?limit, answering 400 when either is invalid
+func triggerWindow(c *gin.Context) (time.Time, int, bool) {
+	var since time.Time
+	if value := c.Query("since"); value != "" {
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Message: "Invalid since",
+				Errors: []validation.FieldError{{
+					Field:   "since",
+					Rule:    "datetime",
+					Message: "must be an RFC 3339 time, such as 2026-01-02T15:04:05Z",
+				}},
+			})
+			return time.Time{}, 0, false
+		}
+		since = t
+	}
+	limit := 50
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxTriggerItems {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Message: "Invalid limit",
+				Errors: []validation.FieldError{{
+					Field:   "limit",
+					Rule:    "max",
+					Message: fmt.Sprintf("must be a number from 1 to %d", maxTriggerItems),
+				}},
+			})
+			return time.Time{}, 0, false
+		}
+		limit = n
+	}
+	return since, limit, true
+}
+
+// truncate keeps the first limit items
+func truncate[T any](items []T, limit int) []T {
+	if len(items) > limit {
+		return items[:limit]
+	}
+	return items
+}
+
+// ListHooks returns the caller's REST hook subscriptions
+func ListHooks(registry *hooks.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		subs, err := registry.List(c.Request.Context(), userID)
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"hooks": subs})
+	}
+}
+
+// SubscribeHook registers a target URL to be posted the caller's
+// occurrences of an event, answering 201 with the subscription's ID
+func SubscribeHook(registry *hooks.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		var req SubscribeHookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+
+		sub, err := registry.Subscribe(c.Request.Context(), userID, req.Event, req.TargetURL)
+		switch {
+		case errors.Is(err, hooks.ErrUnknownEvent), errors.Is(err, hooks.ErrInvalidTarget):
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Message: err.Error(),
+			})
+			return
+		case errors.Is(err, hooks.ErrTooMany):
+			c.JSON(http.StatusConflict, ErrorResponse{
+				Error:   "too_many_hooks",
+				Message: "Unsubscribe a hook before adding another",
+			})
+			return
+		case err != nil:
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, sub)
+	}
+}
+
+// UnsubscribeHook removes one of the caller's REST hook subscriptions
+func UnsubscribeHook(registry *hooks.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+		if err := registry.Unsubscribe(c.Request.Context(), userID, c.Param("hook_id")); err != nil {
+			storageError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
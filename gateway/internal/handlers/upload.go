@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/documents"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// unsafeFilename matches characters not kept in stored file names
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// UploadDocument ingests a PDF, DOCX or Markdown file sent as multipart
+// form field "file", with an optional "title". The original is kept in
+// store and its text is indexed by the RAG service.
+func UploadDocument(orch orchestrator.Orchestrator, store documents.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		file, header, err := c.Request.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_request",
+				Message: "Invalid request body",
+				Errors:  []validation.FieldError{{Field: "file", Rule: "required", Message: "is required as a multipart file"}},
+			})
+			return
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "Failed to read uploaded file"})
+			return
+		}
+
+		format, err := documents.Format(header.Filename, data)
+		if err != nil {
+			c.JSON(http.StatusUnsupportedMediaType, ErrorResponse{Error: "unsupported_document", Message: err.Error()})
+			return
+		}
+		text, err := documents.Extract(format, data)
+		if err != nil {
+			status := http.StatusUnprocessableEntity
+			if errors.Is(err, documents.ErrUnsupported) {
+				status = http.StatusUnsupportedMediaType
+			}
+			c.JSON(status, ErrorResponse{Error: "unreadable_document", Message: err.Error()})
+			return
+		}
+
+		title := strings.TrimSpace(c.PostForm("title"))
+		if title == "" {
+			title = documents.Title(text)
+		}
+		if title == "" {
+			title = strings.TrimSuffix(header.Filename, path.Ext(header.Filename))
+		}
+
+		tenantID := c.GetString("tenant_id")
+		if tenantID == "" {
+			tenantID = "global"
+		}
+		ctx := common.WithTenantID(c.Request.Context(), tenantID)
+
+		documentID := uuid.NewString()
+		name := unsafeFilename.ReplaceAllString(path.Base(header.Filename), "_")
+		mediaType := documents.MediaType(format)
+		location, err := store.Put(ctx, tenantID+"/"+documentID+"/"+name, data, mediaType)
+		if err != nil {
+			log.Printf("Failed to store upload %s: %v", documentID, err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "storage_error", Message: "Failed to store the document"})
+			return
+		}
+
+		err = orch.IngestDocument(ctx, models.IngestDocumentRequest{
+			Title:     title,
+			URL:       location,
+			MediaType: mediaType,
+			Content:   text,
+		})
+		if err != nil {
+			upstreamError(c, err, "ingestion_failed")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":     "Document ingested successfully",
+			"document_id": documentID,
+			"title":       title,
+			"media_type":  mediaType,
+			"characters":  len([]rune(text)),
+		})
+	}
+}
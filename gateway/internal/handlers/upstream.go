@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/clients"
+	"github.com/amirhf/learnpath-gateway/internal/middleware"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// upstreamRetryAfter is the back-off suggested when a backend is unavailable
+// and gave no hint of its own
+const upstreamRetryAfter = 5 * time.Second
+
+// upstreamError responds to a failed orchestrator call, deriving the status
+// from the backend failure when there is one; other errors are a 500 with
+// the given code
+func upstreamError(c *gin.Context, err error, code string) {
+	if clientGone(c) {
+		return
+	}
+	c.Error(err)
+	reportSaturation(c, err)
+	status, resp, retryAfter := upstreamFailure(err, code)
+	if retryAfter > 0 {
+		middleware.SetRetryAfter(c, retryAfter)
+	}
+	c.JSON(status, resp)
+}
+
+// upstreamFailure is the status and body upstreamError responds with, and
+// the back-off to suggest for unavailable backends
+func upstreamFailure(err error, code string) (int, ErrorResponse, time.Duration) {
+	upstream, ok := clients.AsUpstreamError(err)
+	if !ok {
+		return http.StatusInternalServerError, ErrorResponse{Error: code, Message: err.Error()}, 0
+	}
+
+	status, resp := http.StatusBadGateway, ErrorResponse{Error: "upstream_error", Message: err.Error()}
+	var retryAfter time.Duration
+	switch {
+	case upstream.StatusCode == http.StatusNotFound:
+		status, resp.Error = http.StatusNotFound, "not_found"
+	case upstream.StatusCode == http.StatusBadRequest || upstream.StatusCode == http.StatusUnprocessableEntity:
+		// The backend rejected what the caller sent
+		status, resp.Error = http.StatusUnprocessableEntity, "invalid_request"
+		if upstream.Message != "" {
+			resp.Message = upstream.Message
+		}
+	case upstream.Code == clients.CodeTimeout:
+		status, resp.Error = http.StatusGatewayTimeout, "upstream_timeout"
+	case upstream.Retryable:
+		status, resp.Error = http.StatusServiceUnavailable, "service_unavailable"
+		retryAfter = upstream.RetryAfter
+		if retryAfter <= 0 {
+			retryAfter = upstreamRetryAfter
+		}
+	}
+	return status, resp, retryAfter
+}
+
+// reportSaturation tells admission control about a failed backend call
+// that signals saturation: a 503 or 504 from the backend, or a call that
+// timed out before the request's own deadline
+func reportSaturation(c *gin.Context, err error) {
+	upstream, ok := clients.AsUpstreamError(err)
+	if !ok {
+		return
+	}
+	switch {
+	case upstream.StatusCode == http.StatusServiceUnavailable, upstream.StatusCode == http.StatusGatewayTimeout:
+		middleware.UpstreamSaturated(c)
+	case upstream.Code == clients.CodeTimeout && c.Request.Context().Err() == nil:
+		middleware.UpstreamSaturated(c)
+	}
+}
+
+// statusClientClosedRequest is the de facto status (from nginx) for a
+// request the client abandoned; it only reaches logs and metrics
+const statusClientClosedRequest = 499
+
+// clientGone reports whether the client disconnected, which cancels the
+// request context and with it every backend call. Such requests are counted
+// as client_cancelled and end without a response instead of as failures.
+func clientGone(c *gin.Context) bool {
+	if !errors.Is(c.Request.Context().Err(), context.Canceled) {
+		return false
+	}
+	recordClientCancelled(c.Request.Method + " " + c.FullPath())
+	c.AbortWithStatus(statusClientClosedRequest)
+	return true
+}
+
+// maxErrorBody caps how much of a proxied backend's error response is read
+const maxErrorBody = 64 << 10
+
+// backendError relays a proxied backend's error response. Gateway-style
+// bodies pass through; FastAPI {"detail": ...} bodies are converted, with
+// validation failures listed per field; anything else is wrapped under code.
+func backendError(c *gin.Context, status int, body []byte, code string) {
+	if status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout {
+		middleware.UpstreamSaturated(c)
+	}
+	var resp ErrorResponse
+	var fastAPI struct {
+		Detail json.RawMessage `json:"detail"`
+	}
+	switch {
+	case json.Unmarshal(body, &resp) == nil && resp.Error != "":
+	case json.Unmarshal(body, &fastAPI) == nil && len(fastAPI.Detail) > 0:
+		resp = ErrorResponse{Error: code}
+		if json.Unmarshal(fastAPI.Detail, &resp.Message) != nil {
+			resp.Error, resp.Message = "invalid_request", "Invalid request"
+			resp.Errors = validation.FromFastAPI(fastAPI.Detail)
+		}
+	default:
+		resp = ErrorResponse{Error: code, Message: string(body)}
+	}
+	c.JSON(status, resp)
+}
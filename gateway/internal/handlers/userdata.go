@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/events"
+	"github.com/amirhf/learnpath-gateway/internal/hooks"
+	"github.com/amirhf/learnpath-gateway/internal/notify"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateNoteRequest represents a new note on a plan
+type CreateNoteRequest struct {
+	MilestoneID string `json:"milestone_id,omitempty"`
+	Body        string `json:"body" binding:"required,min=1,max=10000"`
+}
+
+// AddBookmarkRequest represents a resource to bookmark
+type AddBookmarkRequest struct {
+	ResourceID string `json:"resource_id" binding:"required"`
+	PlanID     string `json:"plan_id,omitempty"`
+}
+
+// RecordProgressRequest represents progress on a milestone
+type RecordProgressRequest struct {
+	Completed  bool    `json:"completed"`
+	HoursSpent float64 `json:"hours_spent" binding:"gte=0"`
+}
+
+// ListNotes returns the caller's notes on a plan
+func ListNotes(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+
+		notes, err := repos.Notes.ListNotes(c.Request.Context(), userID, c.Param("id"))
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"notes": notes})
+	}
+}
+
+// CreateNote adds a note to a plan
+func CreateNote(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+
+		var req CreateNoteRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+
+		note, err := repos.Notes.CreateNote(c.Request.Context(), repository.Note{
+			UserID:      userID,
+			PlanID:      c.Param("id"),
+			MilestoneID: req.MilestoneID,
+			Body:        req.Body,
+		})
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, note)
+	}
+}
+
+// DeleteNote removes one of the caller's notes
+func DeleteNote(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+
+		if err := repos.Notes.DeleteNote(c.Request.Context(), userID, c.Param("note_id")); err != nil {
+			storageError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ListBookmarks returns the caller's bookmarks, newest first
+func ListBookmarks(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+
+		bookmarks, err := repos.Bookmarks.ListBookmarks(c.Request.Context(), userID)
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"bookmarks": bookmarks})
+	}
+}
+
+// AddBookmark bookmarks a resource
+func AddBookmark(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+
+		var req AddBookmarkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+
+		bookmark, err := repos.Bookmarks.AddBookmark(c.Request.Context(), repository.Bookmark{
+			UserID:     userID,
+			ResourceID: req.ResourceID,
+			PlanID:     req.PlanID,
+		})
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, bookmark)
+	}
+}
+
+// RemoveBookmark deletes one of the caller's bookmarks
+func RemoveBookmark(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+
+		if err := repos.Bookmarks.RemoveBookmark(c.Request.Context(), userID, c.Param("bookmark_id")); err != nil {
+			storageError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// GetProgress returns the caller's milestone progress on a plan
+func GetProgress(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+
+		progress, err := repos.Progress.ListProgress(c.Request.Context(), userID, c.Param("id"))
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"progress": progress})
+	}
+}
+
+// RecordProgress stores the caller's progress on a milestone. The tenant it
+// is made in is remembered for milestone reminders.
+func RecordProgress(repos *repository.Repositories, bus *events.Bus, subscribers *hooks.Registry, notifier *notify.Dispatcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+
+		var req RecordProgressRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			bindError(c, err)
+			return
+		}
+
+		progress := repository.Progress{
+			UserID:      userID,
+			PlanID:      c.Param("id"),
+			MilestoneID: c.Param("milestone_id"),
+			Completed:   req.Completed,
+			HoursSpent:  req.HoursSpent,
+			UpdatedAt:   time.Now().UTC(),
+		}
+		if err := repos.Progress.RecordProgress(c.Request.Context(), progress); err != nil {
+			storageError(c, err)
+			return
+		}
+		bus.Emit(c.Request.Context(), events.ProgressRecorded, userID, progress)
+		if progress.Completed {
+			subscribers.Fire(c.Request.Context(), userID, hooks.MilestoneCompleted, hooks.MilestoneItem(progress))
+		}
+		if err := notifier.RememberTenant(c.Request.Context(), userID, c.GetString("tenant_id")); err != nil {
+			log.Printf("Failed to remember tenant of %s: %v", userID, err)
+		}
+		c.JSON(http.StatusOK, progress)
+	}
+}
+
+// GetStreak returns the caller's current and longest daily streak
+func GetStreak(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := requireUser(c)
+		if !ok {
+			return
+		}
+
+		days, err := repos.Progress.ActiveDays(c.Request.Context(), userID)
+		if err != nil {
+			storageError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, repository.ComputeStreak(days, time.Now()))
+	}
+}
+
+// requireUser returns the authenticated user, answering 401 when there is none
+func requireUser(c *gin.Context) (string, bool) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Sign in to use this feature",
+		})
+		return "", false
+	}
+	return userID, true
+}
+
+// planIDParam returns the plan named by the id path parameter, answering
+// 400 when it isn't a UUID. Routes check it with UUIDParams first, but a
+// handler mounted without it mustn't panic.
+func planIDParam(c *gin.Context) (uuid.UUID, bool) {
+	planID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Invalid path parameters",
+			Errors:  []validation.FieldError{{Field: "id", Rule: "uuid", Message: "must be a UUID"}},
+		})
+		return uuid.UUID{}, false
+	}
+	return planID, true
+}
+
+// storageError maps repository errors onto responses
+func storageError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: "Not found",
+		})
+		return
+	}
+	c.Error(err)
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error:   "storage_error",
+		Message: "Failed to access storage",
+	})
+}
@@ -0,0 +1,11 @@
+package handlers
+
+import "github.com/gin-gonic/gin"
+
+// apiVersion returns the API version of the matched route group, "v1" by default
+func apiVersion(c *gin.Context) string {
+	if version := c.GetString("api_version"); version != "" {
+		return version
+	}
+	return "v1"
+}
@@ -0,0 +1,161 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+)
+
+// Statuses of a dependency
+const (
+	Up      = "up"
+	Down    = "down"
+	Unknown = "unknown" // Not checked yet, or not recently
+)
+
+// Dependency is the last check of one backend
+type Dependency struct {
+	Name      string     `json:"name"`
+	URL       string     `json:"url"`
+	Status    string     `json:"status"`
+	LatencyMS int64      `json:"latency_ms"`
+	Error     string     `json:"error,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
+
+// Poller checks the backends in the background so readiness probes are
+// answered from the last results instead of fanning out on every probe.
+// Each replica polls for itself: readiness is about its own view of the
+// backends.
+type Poller struct {
+	cfg     config.HealthConfig
+	client  *http.Client
+	targets []Dependency
+	history *History // Availability for the status page; nil keeps none
+
+	mu      sync.RWMutex
+	results map[string]Dependency
+}
+
+// New creates a poller for the RAG, Planner and Quiz services, recording
+// each check and the gateway's own uptime in history
+func New(cfg config.HealthConfig, appCfg *config.Config, transport http.RoundTripper, history *History) *Poller {
+	return &Poller{
+		cfg:     cfg,
+		client:  &http.Client{Transport: transport, Timeout: cfg.ProbeTimeout},
+		history: history,
+		targets: []Dependency{
+			{Name: "rag", URL: appCfg.RAGServiceURL},
+			{Name: "planner", URL: appCfg.PlannerServiceURL},
+			{Name: "quiz", URL: appCfg.QuizServiceURL},
+		},
+		results: map[string]Dependency{},
+	}
+}
+
+// Run checks the backends right away and then every PollInterval until ctx
+// is done
+func (p *Poller) Run(ctx context.Context) {
+	p.Poll(ctx)
+	ticker := time.NewTicker(p.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.Poll(ctx)
+		}
+	}
+}
+
+// Poll checks every backend concurrently and records the results
+func (p *Poller) Poll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, target := range p.targets {
+		wg.Add(1)
+		go func(dep Dependency) {
+			defer wg.Done()
+			result := p.check(ctx, dep)
+			p.mu.Lock()
+			p.results[dep.Name] = result
+			p.mu.Unlock()
+			p.record(ctx, dep.Name, result.Status == Up, *result.CheckedAt)
+		}(target)
+	}
+	p.record(ctx, Gateway, true, time.Now())
+	wg.Wait()
+}
+
+func (p *Poller) record(ctx context.Context, name string, up bool, at time.Time) {
+	if err := p.history.Record(ctx, name, up, at); err != nil {
+		log.Printf("health: failed to record availability of %s: %v", name, err)
+	}
+}
+
+// Dependencies returns the last check of each backend. Checks older than
+// StaleAfter are reported as unknown.
+func (p *Poller) Dependencies() []Dependency {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	deps := make([]Dependency, 0, len(p.targets))
+	for _, target := range p.targets {
+		dep, ok := p.results[target.Name]
+		if !ok {
+			dep = target
+			dep.Status = Unknown
+		} else if time.Since(*dep.CheckedAt) > p.cfg.StaleAfter {
+			dep.Status = Unknown
+		}
+		deps = append(deps, dep)
+	}
+	return deps
+}
+
+// Ready reports whether every backend was up when last checked
+func (p *Poller) Ready() bool {
+	for _, dep := range p.Dependencies() {
+		if dep.Status != Up {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Poller) check(ctx context.Context, dep Dependency) Dependency {
+	start := time.Now()
+	err := p.probe(ctx, dep.URL+"/health")
+	checkedAt := time.Now().UTC()
+
+	dep.Status = Up
+	dep.LatencyMS = time.Since(start).Milliseconds()
+	dep.CheckedAt = &checkedAt
+	if err != nil {
+		dep.Status = Down
+		dep.Error = err.Error()
+	}
+	return dep
+}
+
+func (p *Poller) probe(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
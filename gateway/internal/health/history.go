@@ -0,0 +1,188 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+)
+
+// Gateway is the name the gateway's own availability is recorded under
+const Gateway = "gateway"
+
+// historyRetention is how far back availability is kept, the longest
+// window reported
+const historyRetention = 7 * 24 * time.Hour
+
+// History records, per minute, whether each backend was up when polled and
+// whether the gateway was running, in the shared store so availability
+// survives restarts and covers every replica. A minute with a failed check
+// counts as down.
+type History struct {
+	store storage.KeyValue
+
+	mu   sync.Mutex
+	last map[string]mark // What was last written, to skip redundant writes
+}
+
+// mark is the minute and outcome last written for a component
+type mark struct {
+	minute int64
+	down   bool
+}
+
+// record is a component's stored history
+type record struct {
+	// Hours are keyed by Unix hour; each bit of a bitmap is a minute
+	Hours     map[int64]*hour `json:"hours"`
+	First     time.Time       `json:"first"` // First minute recorded
+	DownSince *time.Time      `json:"down_since,omitempty"`
+}
+
+type hour struct {
+	Checked uint64 `json:"checked"`
+	Down    uint64 `json:"down"`
+}
+
+// Availability is a component's rolled-up history
+type Availability struct {
+	Uptime24h *float64   `json:"uptime_24h"` // Percent; nil without checks in the window
+	Uptime7d  *float64   `json:"uptime_7d"`
+	DownSince *time.Time `json:"down_since,omitempty"`
+}
+
+// NewHistory creates a history kept in store
+func NewHistory(store storage.KeyValue) *History {
+	return &History{store: store, last: map[string]mark{}}
+}
+
+// Record notes a check of a component at a time. Only the first check of a
+// minute, and changes of outcome, are written.
+func (h *History) Record(ctx context.Context, name string, up bool, at time.Time) error {
+	if h == nil {
+		return nil
+	}
+	minute := at.Unix() / 60
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if last, ok := h.last[name]; ok && last.minute == minute && last.down == !up {
+		return nil
+	}
+
+	rec, err := h.load(ctx, name)
+	if err != nil {
+		return err
+	}
+	if rec.First.IsZero() {
+		rec.First = time.Unix(minute*60, 0).UTC()
+	}
+	b := rec.Hours[minute/60]
+	if b == nil {
+		b = &hour{}
+		rec.Hours[minute/60] = b
+	}
+	bit := uint64(1) << (minute % 60)
+	b.Checked |= bit
+	if up {
+		rec.DownSince = nil
+	} else {
+		b.Down |= bit
+		if rec.DownSince == nil {
+			since := at.UTC()
+			rec.DownSince = &since
+		}
+	}
+	oldest := (at.Add(-historyRetention).Unix() / 3600) - 1
+	for key := range rec.Hours {
+		if key < oldest {
+			delete(rec.Hours, key)
+		}
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if err := h.store.Set(ctx, historyKey(name), data, historyRetention+time.Hour); err != nil {
+		return err
+	}
+	h.last[name] = mark{minute: minute, down: !up}
+	return nil
+}
+
+// Availability rolls up a component's history as of now. Backends are up
+// for the checked minutes without a failure; the gateway, for the minutes
+// it was running since it was first recorded.
+func (h *History) Availability(ctx context.Context, name string, now time.Time) (Availability, error) {
+	rec, err := h.load(ctx, name)
+	if err != nil {
+		return Availability{}, err
+	}
+	return Availability{
+		Uptime24h: rec.uptime(name, now, 24*time.Hour),
+		Uptime7d:  rec.uptime(name, now, historyRetention),
+		DownSince: rec.DownSince,
+	}, nil
+}
+
+func (r *record) uptime(name string, now time.Time, window time.Duration) *float64 {
+	if r.First.IsZero() {
+		return nil
+	}
+	start := now.Add(-window)
+	if start.Before(r.First) {
+		start = r.First
+	}
+	var checked, down, elapsed int
+	current := now.Unix() / 60
+	for minute := start.Unix() / 60; minute <= current; minute++ {
+		var b hour
+		if stored := r.Hours[minute/60]; stored != nil {
+			b = *stored
+		}
+		bit := uint64(1) << (minute % 60)
+		if b.Checked&bit == 0 {
+			// The current minute may not have been checked yet
+			if minute < current {
+				elapsed++
+			}
+			continue
+		}
+		elapsed++
+		checked++
+		if b.Down&bit != 0 {
+			down++
+		}
+	}
+	if checked == 0 {
+		return nil
+	}
+	pct := 100 * float64(checked-down) / float64(checked)
+	if name == Gateway {
+		pct = 100 * float64(checked) / float64(elapsed)
+	}
+	pct = math.Round(pct*1000) / 1000
+	return &pct
+}
+
+func (h *History) load(ctx context.Context, name string) (*record, error) {
+	rec := &record{Hours: map[int64]*hour{}}
+	data, ok, err := h.store.Get(ctx, historyKey(name))
+	if err != nil || !ok {
+		return rec, err
+	}
+	if err := json.Unmarshal(data, rec); err != nil {
+		return nil, err
+	}
+	if rec.Hours == nil {
+		rec.Hours = map[int64]*hour{}
+	}
+	return rec, nil
+}
+
+func historyKey(name string) string {
+	return "status:history:" + name
+}
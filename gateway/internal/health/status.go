@@ -0,0 +1,125 @@
+package health
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/clients"
+)
+
+// Component statuses on the status page, from best to worst. Unknown is
+// for backends not checked recently.
+const (
+	Operational = "operational"
+	Degraded    = "degraded"
+	Outage      = "major_outage"
+)
+
+// StatusPage is the public, rolled-up status of the gateway and its
+// backends. It names no URLs or internal errors.
+type StatusPage struct {
+	Status     string            `json:"status"`
+	Components []ComponentStatus `json:"components"`
+	Incidents  []Incident        `json:"incidents"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// ComponentStatus is the current status and availability of one component
+type ComponentStatus struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Breaker string `json:"circuit_breaker,omitempty"` // Backends only
+	Availability
+}
+
+// Incident is an ongoing problem with a component
+type Incident struct {
+	Component string     `json:"component"`
+	Status    string     `json:"status"`
+	Message   string     `json:"message"`
+	StartedAt *time.Time `json:"started_at,omitempty"` // When the backend was first seen down
+}
+
+// Status rolls up the last checks, the backends' circuit breakers and the
+// recorded history. Incidents are derived from backends currently down and
+// from open or recovering breakers.
+func (p *Poller) Status(ctx context.Context, breakers map[string]string) StatusPage {
+	now := time.Now().UTC()
+	page := StatusPage{Status: Operational, Incidents: []Incident{}, UpdatedAt: now}
+
+	gateway := ComponentStatus{Name: Gateway, Status: Operational}
+	gateway.Availability = p.availability(ctx, Gateway, now)
+	page.Components = append(page.Components, gateway)
+
+	for _, dep := range p.Dependencies() {
+		component := ComponentStatus{Name: dep.Name, Status: Operational, Breaker: breakers[dep.Name]}
+		component.Availability = p.availability(ctx, dep.Name, now)
+
+		switch dep.Status {
+		case Down:
+			component.Status = Outage
+			page.Incidents = append(page.Incidents, Incident{
+				Component: dep.Name,
+				Status:    Outage,
+				Message:   "The " + dep.Name + " service is not responding to health checks",
+				StartedAt: component.DownSince,
+			})
+		case Unknown:
+			component.Status = Unknown
+		}
+		if component.Status != Outage {
+			switch component.Breaker {
+			case clients.BreakerOpen:
+				component.Status = Degraded
+				page.Incidents = append(page.Incidents, Incident{
+					Component: dep.Name,
+					Status:    Degraded,
+					Message:   "Requests to the " + dep.Name + " service are failing and are paused or served by its fallback",
+				})
+			case clients.BreakerHalfOpen:
+				component.Status = Degraded
+				page.Incidents = append(page.Incidents, Incident{
+					Component: dep.Name,
+					Status:    Degraded,
+					Message:   "The " + dep.Name + " service is recovering from failed requests",
+				})
+			}
+		}
+
+		// A backend not checked lately degrades the overall status
+		overall := component.Status
+		if overall == Unknown {
+			overall = Degraded
+		}
+		if rank(overall) > rank(page.Status) {
+			page.Status = overall
+		}
+		page.Components = append(page.Components, component)
+	}
+	return page
+}
+
+// availability reports a component's history, or none if it can't be read
+func (p *Poller) availability(ctx context.Context, name string, now time.Time) Availability {
+	if p.history == nil {
+		return Availability{}
+	}
+	a, err := p.history.Availability(ctx, name, now)
+	if err != nil {
+		log.Printf("health: failed to load availability of %s: %v", name, err)
+	}
+	return a
+}
+
+// rank orders statuses from best to worst
+func rank(status string) int {
+	switch status {
+	case Outage:
+		return 2
+	case Degraded:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,241 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/clients"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+)
+
+// Compatibility of a backend with the gateway; Unknown when the backend
+// couldn't be reached or has no /version endpoint
+const (
+	Compatible   = "compatible"
+	Incompatible = "incompatible"
+)
+
+// expectation is what the gateway relies on a backend for: the routes it
+// calls and, by the backend's model name, the request bodies it sends
+type expectation struct {
+	routes  []string
+	schemas map[string]any
+}
+
+var expectations = map[string]expectation{
+	"rag": {
+		routes: []string{"/search", "/ingest/resources"},
+		schemas: map[string]any{
+			"SearchRequest":          clients.SearchRequest{},
+			"IngestResourcesRequest": clients.IngestRequestPayload{},
+			"Resource":               clients.IngestResource{},
+		},
+	},
+	"planner": {
+		routes: []string{"/plan", "/plan/{plan_id}", "/plan/{plan_id}/replan", "/decompose", "/user/{user_id}/plans", "/user/{user_id}/plans/transfer"},
+		schemas: map[string]any{
+			"PlanRequest":      models.PlanLearningPathRequest{},
+			"ReplanRequest":    models.ReplanRequest{},
+			"DecomposeRequest": models.DecomposeGoalRequest{},
+		},
+	},
+	"quiz": {
+		routes: []string{"/generate", "/retake", "/compose", "/questions", "/submit", "/grade", "/calibrate"},
+		schemas: map[string]any{
+			"QuizGenerateRequest": models.GenerateQuizRequest{},
+			"RetakeRequest":       models.RetakeQuizRequest{},
+			"ComposeRequest":      models.ComposeQuizRequest{},
+			"QuizSubmitRequest":   clients.QuizSubmitRequest{},
+			"GradeRequest":        models.GradeAnswerRequest{},
+			"CalibrationRequest":  models.CalibrationRequest{},
+		},
+	},
+}
+
+// SchemaDrift is how a request body the gateway sends differs from the
+// backend's model
+type SchemaDrift struct {
+	Unsupported     []string `json:"unsupported,omitempty"`      // Sent by the gateway, unknown to the backend
+	MissingRequired []string `json:"missing_required,omitempty"` // Required by the backend, never sent
+	Unused          []string `json:"unused,omitempty"`           // Accepted by the backend, never sent
+}
+
+// BackendVersion is a backend's version and its compatibility with the
+// gateway
+type BackendVersion struct {
+	Name          string                 `json:"name"`
+	URL           string                 `json:"url"`
+	Status        string                 `json:"status"`
+	Version       string                 `json:"version,omitempty"`
+	MissingRoutes []string               `json:"missing_routes,omitempty"`
+	Schemas       map[string]SchemaDrift `json:"schemas,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+	CheckedAt     time.Time              `json:"checked_at"`
+}
+
+// versionInfo is a backend's GET /version response
+type versionInfo struct {
+	Version string   `json:"version"`
+	Routes  []string `json:"routes"`
+	Schemas map[string]struct {
+		Fields   []string `json:"fields"`
+		Required []string `json:"required"`
+	} `json:"schemas"`
+}
+
+// Versions asks each backend for its version, routes and request schemas
+// and compares them with what the gateway sends, so schema drift shows up
+// before requests fail to decode. Results are cached for VersionsTTL.
+type Versions struct {
+	cfg     config.HealthConfig
+	client  *http.Client
+	targets []Dependency
+
+	mu        sync.Mutex
+	cached    []BackendVersion
+	fetchedAt time.Time
+}
+
+// NewVersions creates a version checker for the RAG, Planner and Quiz
+// services
+func NewVersions(cfg config.HealthConfig, appCfg *config.Config, transport http.RoundTripper) *Versions {
+	return &Versions{
+		cfg:    cfg,
+		client: &http.Client{Transport: transport, Timeout: cfg.ProbeTimeout},
+		targets: []Dependency{
+			{Name: "rag", URL: appCfg.RAGServiceURL},
+			{Name: "planner", URL: appCfg.PlannerServiceURL},
+			{Name: "quiz", URL: appCfg.QuizServiceURL},
+		},
+	}
+}
+
+// Backends returns each backend's version and compatibility, from the cache
+// when it is fresh
+func (v *Versions) Backends(ctx context.Context) []BackendVersion {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.cached != nil && time.Since(v.fetchedAt) < v.cfg.VersionsTTL {
+		return v.cached
+	}
+
+	// The results are shared, so a caller going away mustn't cut them short
+	ctx = context.WithoutCancel(ctx)
+	results := make([]BackendVersion, len(v.targets))
+	var wg sync.WaitGroup
+	for i, target := range v.targets {
+		wg.Add(1)
+		go func(i int, dep Dependency) {
+			defer wg.Done()
+			results[i] = v.check(ctx, dep)
+		}(i, target)
+	}
+	wg.Wait()
+
+	v.cached, v.fetchedAt = results, time.Now()
+	return results
+}
+
+func (v *Versions) check(ctx context.Context, dep Dependency) BackendVersion {
+	result := BackendVersion{Name: dep.Name, URL: dep.URL, Status: Unknown, CheckedAt: time.Now().UTC()}
+	info, err := v.fetch(ctx, dep.URL+"/version")
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Version = info.Version
+	result.Status = Compatible
+
+	expected := expectations[dep.Name]
+	for _, route := range expected.routes {
+		if !slices.Contains(info.Routes, route) {
+			result.MissingRoutes = append(result.MissingRoutes, route)
+			result.Status = Incompatible
+		}
+	}
+
+	result.Schemas = map[string]SchemaDrift{}
+	for name, body := range expected.schemas {
+		schema, ok := info.Schemas[name]
+		if !ok {
+			continue
+		}
+		sent := jsonFields(reflect.TypeOf(body))
+		var drift SchemaDrift
+		for _, field := range sent {
+			if !slices.Contains(schema.Fields, field) {
+				drift.Unsupported = append(drift.Unsupported, field)
+			}
+		}
+		for _, field := range schema.Required {
+			if !slices.Contains(sent, field) {
+				drift.MissingRequired = append(drift.MissingRequired, field)
+			}
+		}
+		for _, field := range schema.Fields {
+			if !slices.Contains(sent, field) && !slices.Contains(schema.Required, field) {
+				drift.Unused = append(drift.Unused, field)
+			}
+		}
+		if len(drift.Unsupported) > 0 || len(drift.MissingRequired) > 0 {
+			result.Status = Incompatible
+		}
+		if len(drift.Unsupported) > 0 || len(drift.MissingRequired) > 0 || len(drift.Unused) > 0 {
+			result.Schemas[name] = drift
+		}
+	}
+	return result
+}
+
+func (v *Versions) fetch(ctx context.Context, url string) (*versionInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var info versionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("decode version: %w", err)
+	}
+	return &info, nil
+}
+
+// jsonFields lists the JSON names of a struct's fields, including those of
+// embedded structs
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,302 @@
+// Package hooks lets automation platforms such as Zapier and Make react to
+// learning events. Each event is a trigger with a polling listing (served
+// by the API handlers) and REST hook subscriptions: a subscriber registers
+// a target URL and is posted each occurrence, until it unsubscribes or the
+// target answers 410 Gone.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Triggers
+const (
+	NewPlan            = "new_plan"
+	MilestoneCompleted = "milestone_completed"
+	QuizSubmitted      = "quiz_submitted"
+)
+
+// Events lists the triggers subscriptions can be made to
+var Events = []string{NewPlan, MilestoneCompleted, QuizSubmitted}
+
+var (
+	// ErrUnknownEvent is returned for subscriptions to an unknown trigger
+	ErrUnknownEvent = errors.New("unknown event")
+	// ErrInvalidTarget is returned for target URLs that aren't absolute
+	// http or https URLs
+	ErrInvalidTarget = errors.New("target_url must be an absolute http or https URL")
+	// ErrTooMany is returned once a user holds the maximum subscriptions
+	ErrTooMany = errors.New("too many subscriptions")
+	// ErrPrivateAddress is returned for targets that resolve to loopback,
+	// private or link-local addresses
+	ErrPrivateAddress = errors.New("address is not publicly routable")
+)
+
+// Subscription is a target URL posted each occurrence of an event
+type Subscription struct {
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	TargetURL string    `json:"target_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Plan is a new_plan item
+type Plan struct {
+	ID             string    `json:"id"`
+	Goal           string    `json:"goal"`
+	TotalHours     float64   `json:"total_hours"`
+	EstimatedWeeks int       `json:"estimated_weeks"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Milestone is a milestone_completed item. Its ID combines the plan and
+// milestone, so a milestone completed again isn't a new item.
+type Milestone struct {
+	ID          string    `json:"id"`
+	PlanID      string    `json:"plan_id"`
+	MilestoneID string    `json:"milestone_id"`
+	HoursSpent  float64   `json:"hours_spent"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// QuizSubmission is a quiz_submitted item
+type QuizSubmission struct {
+	ID          string    `json:"id"` // The attempt ID
+	QuizID      string    `json:"quiz_id"`
+	Status      string    `json:"status"`
+	Score       *float64  `json:"score,omitempty"` // Percentage
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+// NewPlanItem describes a plan as a new_plan item
+func NewPlanItem(plan models.LearningPath) Plan {
+	return Plan{
+		ID:             plan.PlanID.String(),
+		Goal:           plan.Goal,
+		TotalHours:     plan.TotalHours,
+		EstimatedWeeks: plan.EstimatedWeeks,
+		CreatedAt:      plan.CreatedAt,
+	}
+}
+
+// MilestoneItem describes completed progress as a milestone_completed item
+func MilestoneItem(progress repository.Progress) Milestone {
+	return Milestone{
+		ID:          progress.PlanID + ":" + progress.MilestoneID,
+		PlanID:      progress.PlanID,
+		MilestoneID: progress.MilestoneID,
+		HoursSpent:  progress.HoursSpent,
+		CompletedAt: progress.UpdatedAt,
+	}
+}
+
+// QuizSubmissionItem describes an attempt as a quiz_submitted item
+func QuizSubmissionItem(attempt repository.QuizAttempt) QuizSubmission {
+	return QuizSubmission{
+		ID:          attempt.ID,
+		QuizID:      attempt.QuizID,
+		Status:      attempt.Status,
+		Score:       attempt.Score,
+		SubmittedAt: attempt.SubmittedAt,
+	}
+}
+
+// Registry keeps each user's subscriptions in the shared store and posts
+// them their events
+type Registry struct {
+	cfg    config.HooksConfig
+	store  storage.KeyValue
+	client *http.Client
+	mu     sync.Mutex // Serializes changes to a user's subscriptions on this replica
+}
+
+// New creates a registry whose deliveries can only reach public addresses,
+// unless cfg.AllowPrivate is set
+func New(cfg config.HooksConfig, store storage.KeyValue) *Registry {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivate {
+		dialer.Control = publicOnly
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   cfg.Timeout,
+		ResponseHeaderTimeout: cfg.Timeout,
+		MaxIdleConnsPerHost:   2,
+	}
+	return &Registry{
+		cfg:   cfg,
+		store: store,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+			// A redirect could lead a delivery somewhere the subscriber
+			// didn't register
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+// List returns a user's subscriptions, oldest first
+func (r *Registry) List(ctx context.Context, userID string) ([]Subscription, error) {
+	data, ok, err := r.store.Get(ctx, key(userID))
+	if err != nil || !ok {
+		return []Subscription{}, err
+	}
+	var subs []Subscription
+	if err := json.Unmarshal(data, &subs); err != nil {
+		return nil, fmt.Errorf("hooks: corrupt subscriptions of %s: %w", userID, err)
+	}
+	return subs, nil
+}
+
+// Subscribe registers targetURL for a user's occurrences of event
+func (r *Registry) Subscribe(ctx context.Context, userID, event, targetURL string) (Subscription, error) {
+	if !slices.Contains(Events, event) {
+		return Subscription{}, fmt.Errorf("%w %q", ErrUnknownEvent, event)
+	}
+	u, err := url.Parse(targetURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Subscription{}, ErrInvalidTarget
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subs, err := r.List(ctx, userID)
+	if err != nil {
+		return Subscription{}, err
+	}
+	if len(subs) >= r.cfg.MaxPerUser {
+		return Subscription{}, ErrTooMany
+	}
+	sub := Subscription{ID: uuid.NewString(), Event: event, TargetURL: targetURL, CreatedAt: time.Now().UTC()}
+	if err := r.save(ctx, userID, append(subs, sub)); err != nil {
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+// Unsubscribe removes a user's subscription, returning
+// repository.ErrNotFound if there is none with that ID
+func (r *Registry) Unsubscribe(ctx context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subs, err := r.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(subs, func(sub Subscription) bool { return sub.ID == id })
+	if i < 0 {
+		return repository.ErrNotFound
+	}
+	return r.save(ctx, userID, slices.Delete(subs, i, i+1))
+}
+
+func (r *Registry) save(ctx context.Context, userID string, subs []Subscription) error {
+	if len(subs) == 0 {
+		return r.store.Delete(ctx, key(userID))
+	}
+	data, err := json.Marshal(subs)
+	if err != nil {
+		return err
+	}
+	return r.store.Set(ctx, key(userID), data, 0)
+}
+
+// Fire posts item to the user's subscriptions to event, in the background.
+// Failed deliveries are logged and not retried; a target answering 410
+// Gone is unsubscribed.
+func (r *Registry) Fire(ctx context.Context, userID, event string, item interface{}) {
+	if userID == "" {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	subs, err := r.List(ctx, userID)
+	if err != nil {
+		log.Printf("hooks: failed to load subscriptions of %s: %v", userID, err)
+		return
+	}
+	var targets []Subscription
+	for _, sub := range subs {
+		if sub.Event == event {
+			targets = append(targets, sub)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(item)
+	if err != nil {
+		log.Printf("hooks: failed to encode %s: %v", event, err)
+		return
+	}
+	go func() {
+		for _, sub := range targets {
+			status, err := r.post(ctx, sub.TargetURL, body)
+			switch {
+			case err != nil:
+				log.Printf("hooks: failed to deliver %s to subscription %s: %v", event, sub.ID, err)
+			case status == http.StatusGone:
+				if err := r.Unsubscribe(ctx, userID, sub.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+					log.Printf("hooks: failed to remove gone subscription %s: %v", sub.ID, err)
+				}
+			case status >= 300:
+				log.Printf("hooks: subscription %s answered %d to %s", sub.ID, status, event)
+			}
+		}
+	}()
+}
+
+func (r *Registry) post(ctx context.Context, target string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+func key(userID string) string {
+	return "hooks:" + userID
+}
+
+// publicOnly refuses connections to non-public addresses, after DNS
+// resolution so a public name can't point inside the network
+func publicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%s: %w", host, ErrPrivateAddress)
+	}
+	return nil
+}
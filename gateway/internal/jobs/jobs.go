@@ -0,0 +1,141 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/clients"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/scheduler"
+)
+
+const (
+	// probeTimeout bounds a single health or link probe
+	probeTimeout = 10 * time.Second
+	// maxReportedLinks caps the dead links listed in a run's error
+	maxReportedLinks = 10
+)
+
+// HealthPoll checks each backend's /health endpoint, failing the run if
+// any backend is unhealthy
+func HealthPoll(cfg *config.Config, transport http.RoundTripper) scheduler.Func {
+	client := &http.Client{Transport: transport, Timeout: probeTimeout}
+	return func(ctx context.Context) error {
+		var unhealthy []string
+		for _, service := range []struct{ name, url string }{
+			{"rag", cfg.RAGServiceURL},
+			{"planner", cfg.PlannerServiceURL},
+			{"quiz", cfg.QuizServiceURL},
+		} {
+			if err := probe(ctx, client, http.MethodGet, service.url+"/health"); err != nil {
+				unhealthy = append(unhealthy, fmt.Sprintf("%s: %v", service.name, err))
+			}
+		}
+		if len(unhealthy) > 0 {
+			return errors.New("unhealthy backends: " + strings.Join(unhealthy, "; "))
+		}
+		return nil
+	}
+}
+
+// FeedReingest re-ingests the configured content feeds so new items are
+// picked up by search
+func FeedReingest(orch orchestrator.Orchestrator, feeds []string) scheduler.Func {
+	return func(ctx context.Context) error {
+		if len(feeds) == 0 {
+			return nil
+		}
+		_, err := orch.IngestContent(ctx, models.IngestRequest{URLs: feeds})
+		return err
+	}
+}
+
+// CacheWarmup runs popular searches so the RAG service's embedding and
+// result caches are warm before traffic arrives
+func CacheWarmup(orch orchestrator.Orchestrator, queries []string) scheduler.Func {
+	return func(ctx context.Context) error {
+		var failed int
+		for _, query := range queries {
+			if _, err := orch.Search(ctx, clients.SearchRequest{Query: query, TopK: 20}); err != nil {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d warmup searches failed", failed, len(queries))
+		}
+		return nil
+	}
+}
+
+// DeadLinkCheck probes the resources returned for popular searches, the
+// links learners are most likely to be sent to, failing the run with the
+// dead ones
+func DeadLinkCheck(orch orchestrator.Orchestrator, transport http.RoundTripper, queries []string) scheduler.Func {
+	client := &http.Client{Transport: transport, Timeout: probeTimeout}
+	return func(ctx context.Context) error {
+		checked := map[string]bool{}
+		var dead []string
+		for _, query := range queries {
+			resp, err := orch.Search(ctx, clients.SearchRequest{Query: query, TopK: 20})
+			if err != nil {
+				return err
+			}
+			for _, result := range resp.Results {
+				if result.URL == "" || checked[result.URL] {
+					continue
+				}
+				checked[result.URL] = true
+				if err := probeLink(ctx, client, result.URL); err != nil {
+					dead = append(dead, fmt.Sprintf("%s (%v)", result.URL, err))
+				}
+			}
+		}
+
+		if len(dead) == 0 {
+			return nil
+		}
+		report := dead
+		if len(report) > maxReportedLinks {
+			report = report[:maxReportedLinks]
+		}
+		return fmt.Errorf("%d dead links: %s", len(dead), strings.Join(report, ", "))
+	}
+}
+
+// probeLink checks a link with HEAD, falling back to GET for servers that
+// don't support it
+func probeLink(ctx context.Context, client *http.Client, url string) error {
+	err := probe(ctx, client, http.MethodHead, url)
+	var status statusError
+	if errors.As(err, &status) && (status == http.StatusMethodNotAllowed || status == http.StatusForbidden) {
+		err = probe(ctx, client, http.MethodGet, url)
+	}
+	return err
+}
+
+// statusError is an unsuccessful response status
+type statusError int
+
+func (e statusError) Error() string { return fmt.Sprintf("status %d", int(e)) }
+
+func probe(ctx context.Context, client *http.Client, method, url string) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return statusError(resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+// Package jsonapi reshapes the gateway's learning path, quiz and resource
+// documents into JSON:API (https://jsonapi.org) documents, for frontends
+// built on JSON:API client libraries.
+package jsonapi
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// MediaType is the JSON:API media type
+const MediaType = "application/vnd.api+json"
+
+// Resource types
+const (
+	TypeLearningPath = "learning-paths"
+	TypeMilestone    = "milestones"
+	TypeResource     = "resources"
+	TypeQuiz         = "quizzes"
+)
+
+// Document is a top-level JSON:API document
+type Document struct {
+	Data     interface{}            `json:"data,omitempty"` // *Resource or []*Resource
+	Errors   []Error                `json:"errors,omitempty"`
+	Included []*Resource            `json:"included,omitempty"`
+	Meta     map[string]interface{} `json:"meta,omitempty"`
+}
+
+// Resource is a resource object
+type Resource struct {
+	Type          string                   `json:"type"`
+	ID            string                   `json:"id"`
+	Attributes    map[string]interface{}   `json:"attributes,omitempty"`
+	Relationships map[string]*Relationship `json:"relationships,omitempty"`
+}
+
+// Relationship links a resource to others
+type Relationship struct {
+	Data interface{} `json:"data"` // *Identifier, []Identifier or nil
+}
+
+// Identifier identifies a related resource. Meta holds what is particular
+// to the relationship, such as a resource's order within a milestone.
+type Identifier struct {
+	Type string                 `json:"type"`
+	ID   string                 `json:"id"`
+	Meta map[string]interface{} `json:"meta,omitempty"`
+}
+
+// Error is an error object
+type Error struct {
+	Status string       `json:"status"`
+	Code   string       `json:"code,omitempty"`
+	Detail string       `json:"detail,omitempty"`
+	Source *ErrorSource `json:"source,omitempty"`
+}
+
+// ErrorSource points at the request member an error is about
+type ErrorSource struct {
+	Pointer string `json:"pointer"`
+}
+
+// collectionKeys are the members list responses keep their items in
+var collectionKeys = map[string]bool{"results": true, "plans": true, "quizzes": true, "items": true}
+
+// Transform reshapes a decoded JSON document: a learning path (with or
+// without its quizzes), a quiz, a search result, or a list of them. Other
+// documents aren't JSON:API resources and are reported as not transformed.
+func Transform(doc interface{}) (*Document, bool) {
+	b := &builder{seen: map[string]*Resource{}}
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		if res, ok := b.resource(v); ok {
+			return &Document{Data: res, Included: b.included}, true
+		}
+		if key, items, ok := collection(v); ok {
+			data, ok := b.resources(items)
+			if !ok {
+				return nil, false
+			}
+			delete(v, key)
+			document := &Document{Data: data, Included: b.included}
+			if len(v) > 0 {
+				document.Meta = v
+			}
+			return document, true
+		}
+	case []interface{}:
+		if data, ok := b.resources(v); ok && len(v) > 0 {
+			return &Document{Data: data, Included: b.included}, true
+		}
+	}
+	return nil, false
+}
+
+// Errors converts a gateway error body, {"error": code, "message": text,
+// "errors": [{"field", "rule", "message"}]}, to JSON:API error objects
+func Errors(status int, body map[string]interface{}) ([]Error, bool) {
+	code, ok := body["error"].(string)
+	if !ok {
+		return nil, false
+	}
+	message, _ := body["message"].(string)
+	fields, _ := body["errors"].([]interface{})
+	if len(fields) == 0 {
+		return []Error{{Status: strconv.Itoa(status), Code: code, Detail: message}}, true
+	}
+	errs := make([]Error, 0, len(fields))
+	for _, item := range fields {
+		field, _ := item.(map[string]interface{})
+		name, _ := field["field"].(string)
+		detail, _ := field["message"].(string)
+		e := Error{Status: strconv.Itoa(status), Code: code, Detail: name + " " + detail}
+		if name != "" {
+			e.Source = &ErrorSource{Pointer: "/data/attributes/" + name}
+		}
+		errs = append(errs, e)
+	}
+	return errs, true
+}
+
+// collection finds the one list member of a list response
+func collection(doc map[string]interface{}) (string, []interface{}, bool) {
+	var key string
+	var items []interface{}
+	for k, v := range doc {
+		list, ok := v.([]interface{})
+		if !ok {
+			continue
+		}
+		if items != nil {
+			return "", nil, false
+		}
+		key, items = k, list
+	}
+	if items == nil || (len(items) == 0 && !collectionKeys[key]) {
+		return "", nil, false
+	}
+	return key, items, true
+}
+
+// builder collects the included resources of a document, once each
+type builder struct {
+	included []*Resource
+	seen     map[string]*Resource
+}
+
+func (b *builder) include(res *Resource) *Identifier {
+	key := res.Type + "/" + res.ID
+	if _, ok := b.seen[key]; !ok {
+		b.seen[key] = res
+		b.included = append(b.included, res)
+	}
+	return &Identifier{Type: res.Type, ID: res.ID}
+}
+
+func (b *builder) resources(items []interface{}) ([]*Resource, bool) {
+	data := make([]*Resource, 0, len(items))
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		res, ok := b.resource(obj)
+		if !ok {
+			return nil, false
+		}
+		data = append(data, res)
+	}
+	return data, true
+}
+
+// resource recognizes a document by its identifying members
+func (b *builder) resource(obj map[string]interface{}) (*Resource, bool) {
+	switch {
+	case isObject(obj["learning_path"]):
+		return b.pathWithQuizzes(obj), true
+	case has(obj, "plan_id") && has(obj, "milestones"):
+		return b.learningPath(obj), true
+	case has(obj, "quiz_id") && has(obj, "questions"):
+		return quiz(obj), true
+	case has(obj, "id") && has(obj, "url") && has(obj, "title"):
+		return newResource(TypeResource, obj, "id"), true
+	}
+	return nil, false
+}
+
+// pathWithQuizzes relates a learning path to its final quiz, and each of
+// its milestones to the milestone's quiz
+func (b *builder) pathWithQuizzes(obj map[string]interface{}) *Resource {
+	path := b.learningPath(obj["learning_path"].(map[string]interface{}))
+	if q, ok := obj["quiz"].(map[string]interface{}); ok {
+		path.relate("quiz", b.include(quiz(q)))
+	}
+	quizzes, _ := obj["milestone_quizzes"].([]interface{})
+	for _, item := range quizzes {
+		mq, _ := item.(map[string]interface{})
+		q, ok := mq["quiz"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if milestone, ok := b.seen[TypeMilestone+"/"+id(mq["milestone_id"])]; ok {
+			milestone.relate("quiz", b.include(quiz(q)))
+		}
+	}
+	return path
+}
+
+func (b *builder) learningPath(obj map[string]interface{}) *Resource {
+	milestones, _ := obj["milestones"].([]interface{})
+	path := newResource(TypeLearningPath, obj, "plan_id", "milestones")
+	linkage := []Identifier{}
+	for _, item := range milestones {
+		if m, ok := item.(map[string]interface{}); ok && has(m, "milestone_id") {
+			linkage = append(linkage, *b.include(b.milestone(m)))
+		}
+	}
+	path.relate("milestones", linkage)
+	return path
+}
+
+// milestone relates a milestone to its resources. Why and where a resource
+// is in the milestone is kept in the linkage, as the resource may be in
+// several.
+func (b *builder) milestone(obj map[string]interface{}) *Resource {
+	items, _ := obj["resources"].([]interface{})
+	milestone := newResource(TypeMilestone, obj, "milestone_id", "resources")
+	linkage := []Identifier{}
+	for _, item := range items {
+		r, ok := item.(map[string]interface{})
+		if !ok || !has(r, "resource_id") {
+			continue
+		}
+		meta := map[string]interface{}{}
+		for _, key := range []string{"why_included", "order"} {
+			if value, ok := r[key]; ok {
+				meta[key] = value
+			}
+		}
+		ref := b.include(newResource(TypeResource, r, "resource_id", "why_included", "order"))
+		ref.Meta = meta
+		linkage = append(linkage, *ref)
+	}
+	milestone.relate("resources", linkage)
+	return milestone
+}
+
+// quiz keeps questions as attributes; they only exist within their quiz
+func quiz(obj map[string]interface{}) *Resource {
+	variantOf := id(obj["variant_of"])
+	q := newResource(TypeQuiz, obj, "quiz_id", "variant_of")
+	if variantOf != "" {
+		q.relate("variant_of", &Identifier{Type: TypeQuiz, ID: variantOf})
+	}
+	return q
+}
+
+// newResource makes obj a resource identified by idKey, with the other
+// members but the omitted ones as attributes
+func newResource(typ string, obj map[string]interface{}, idKey string, omit ...string) *Resource {
+	res := &Resource{Type: typ, ID: id(obj[idKey])}
+	delete(obj, idKey)
+	for _, key := range omit {
+		delete(obj, key)
+	}
+	if len(obj) > 0 {
+		res.Attributes = obj
+	}
+	return res
+}
+
+func (r *Resource) relate(name string, data interface{}) {
+	if r.Relationships == nil {
+		r.Relationships = map[string]*Relationship{}
+	}
+	r.Relationships[name] = &Relationship{Data: data}
+}
+
+func has(obj map[string]interface{}, key string) bool {
+	_, ok := obj[key]
+	return ok
+}
+
+func isObject(value interface{}) bool {
+	_, ok := value.(map[string]interface{})
+	return ok
+}
+
+// id renders an identifying member as JSON:API's string ID
+func id(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,99 @@
+package locale
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Language is a language plans and quizzes can be generated in
+type Language struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	NativeName string `json:"native_name"`
+}
+
+// known names the languages the generation models handle well, keyed by
+// lower-cased BCP 47 tag
+var known = map[string]Language{
+	"ar":    {"ar", "Arabic", "العربية"},
+	"de":    {"de", "German", "Deutsch"},
+	"en":    {"en", "English", "English"},
+	"es":    {"es", "Spanish", "Español"},
+	"fr":    {"fr", "French", "Français"},
+	"hi":    {"hi", "Hindi", "हिन्दी"},
+	"it":    {"it", "Italian", "Italiano"},
+	"ja":    {"ja", "Japanese", "日本語"},
+	"ko":    {"ko", "Korean", "한국어"},
+	"nl":    {"nl", "Dutch", "Nederlands"},
+	"pl":    {"pl", "Polish", "Polski"},
+	"pt":    {"pt", "Portuguese", "Português"},
+	"pt-br": {"pt-BR", "Brazilian Portuguese", "Português do Brasil"},
+	"ru":    {"ru", "Russian", "Русский"},
+	"tr":    {"tr", "Turkish", "Türkçe"},
+	"uk":    {"uk", "Ukrainian", "Українська"},
+	"zh":    {"zh", "Chinese", "中文"},
+}
+
+// Lookup describes a language tag, falling back to the tag itself for
+// languages without a known name
+func Lookup(code string) Language {
+	if lang, ok := known[strings.ToLower(code)]; ok {
+		return lang
+	}
+	return Language{Code: code, Name: code, NativeName: code}
+}
+
+// Match returns the supported tag a requested one selects: an exact match,
+// otherwise the supported base language ("pt-PT" selects "pt"). Tags are
+// compared case-insensitively.
+func Match(tag string, supported []string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", false
+	}
+	base, _, _ := strings.Cut(tag, "-")
+	match := ""
+	for _, s := range supported {
+		switch strings.ToLower(s) {
+		case tag:
+			return s, true
+		case base:
+			match = s
+		}
+	}
+	return match, match != ""
+}
+
+// Negotiate picks the supported language an Accept-Language header prefers
+// (RFC 9110 section 12.5.4), or fallback when it names none of them
+func Negotiate(header string, supported []string, fallback string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if tag = strings.TrimSpace(tag); tag != "" && tag != "*" && q > 0 {
+			candidates = append(candidates, candidate{tag, q})
+		}
+	}
+	// Stable, so equally weighted tags keep the client's order
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if match, ok := Match(c.tag, supported); ok {
+			return match
+		}
+	}
+	return fallback
+}
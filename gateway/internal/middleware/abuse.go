@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/abuse"
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/gin-gonic/gin"
+)
+
+// AbuseWatch counts each caller's failed (4xx) requests, including those
+// turned away by auth and rate limits, so the detector can ban callers
+// who fail too often. It must run before Auth. Failures always count
+// against the client IP, and against the user too when Auth verified one,
+// so a forged or rejected identity can't dodge an IP ban. Admin requests
+// aren't counted.
+func AbuseWatch(detector *abuse.Detector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		status := c.Writer.Status()
+		if status < 400 || status >= 500 || !detector.Enabled() || c.GetBool("admin") || c.GetBool("banned") {
+			return
+		}
+		for _, key := range abuseKeys(c) {
+			ban, err := detector.Record(context.WithoutCancel(c.Request.Context()), key)
+			if err != nil {
+				log.Printf("abuse: failed to record %d from %s: %v", status, key, err)
+				continue
+			}
+			if ban != nil {
+				log.Printf("abuse: banned %s until %s after %d failed requests (offense %d)",
+					key, ban.Until.Format(time.RFC3339), ban.Failures, ban.Offenses)
+			}
+		}
+	}
+}
+
+// NotBanned turns away banned callers, whether banned by user or by the
+// IP they call from, with 429 until the ban ends. Put it on the expensive
+// endpoints. The store being unavailable lets requests through.
+func NotBanned(detector *abuse.Detector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, key := range abuseKeys(c) {
+			ban, err := detector.Banned(c.Request.Context(), key)
+			if err != nil {
+				log.Printf("abuse: ban check for %s failed, processing request: %v", key, err)
+				continue
+			}
+			if ban == nil {
+				continue
+			}
+			c.Set("banned", true)
+			SetRetryAfter(c, time.Until(ban.Until))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":   "temporarily_banned",
+				"message": "Too many failed requests, please retry later",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// abuseKeys names the caller: by verified user, if any, then by client IP
+func abuseKeys(c *gin.Context) []string {
+	ip := "ip:" + c.ClientIP()
+	if userID := common.GetVerifiedUserID(c.Request.Context()); userID != "" {
+		return []string{"user:" + userID, ip}
+	}
+	return []string{ip}
+}
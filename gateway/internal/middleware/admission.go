@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/admission"
+	"github.com/gin-gonic/gin"
+)
+
+// upstreamSaturatedKey marks requests whose backend pushed back
+const upstreamSaturatedKey = "upstream_saturated"
+
+// UpstreamSaturated records that a backend pushed back on the request: it
+// answered 503 or 504, or a call timed out while the request still had
+// time. Admission reports only these as saturation; a request running out
+// its own deadline, or the gateway's own 503s, say nothing of the backends.
+func UpstreamSaturated(c *gin.Context) {
+	c.Set(upstreamSaturatedKey, true)
+}
+
+// Admission queues requests of the given priority class behind the
+// controller's concurrency limit and sheds them with 503 when the gateway or
+// the backends are saturated
+func Admission(ctrl *admission.Controller, class admission.Class) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		release, err := ctrl.Acquire(c.Request.Context(), class)
+		if err != nil {
+			if errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+				// The route's deadline ran out while queued
+				c.JSON(http.StatusGatewayTimeout, gin.H{
+					"error":   "deadline_exceeded",
+					"message": "The request could not be served in time, please retry shortly",
+				})
+				c.Abort()
+				return
+			}
+			if c.Request.Context().Err() != nil {
+				// The client went away while queued
+				c.Abort()
+				return
+			}
+			retryAfter := ctrl.RetryAfter()
+			SetRetryAfter(c, retryAfter)
+			SetRateLimit(c, ctrl.Limit(), 0, retryAfter)
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "overloaded",
+				"message": "The service is busy, please retry shortly",
+			})
+			c.Abort()
+			return
+		}
+		defer release()
+
+		c.Next()
+
+		// Backends pushing back mean we should shed lower-priority work
+		if c.GetBool(upstreamSaturatedKey) {
+			ctrl.ReportSaturation()
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// MaxBody caps every request body at maxBytes while it is read, so
+// middleware that buffers bodies ahead of routing stays bounded. Routes
+// apply their own, tighter limits with BodyLimit.
+func MaxBody(maxBytes int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body != nil && maxBytes > 0 {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(maxBytes))
+		}
+		c.Next()
+	}
+}
+
+// BodyLimit rejects bodies larger than maxBytes with 413 and JSON nested
+// deeper than maxDepth with 400, before the handler parses or proxies them
+func BodyLimit(maxBytes, maxDepth int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > int64(maxBytes) {
+			bodyTooLarge(c, maxBytes)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, int64(maxBytes)))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				bodyTooLarge(c, int(tooLarge.Limit))
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": "Failed to read request body",
+			})
+			return
+		}
+
+		// Uploaded files and bundles are binary; only JSON bodies are
+		// checked for depth
+		if maxDepth > 0 && !strings.HasPrefix(c.ContentType(), "multipart/") && c.ContentType() != "application/zip" && jsonDepth(body) > maxDepth {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": "Invalid request body",
+				"errors": []validation.FieldError{{
+					Rule:    "max_depth",
+					Message: fmt.Sprintf("must not nest objects and arrays more than %d levels deep", maxDepth),
+				}},
+			})
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+func bodyTooLarge(c *gin.Context, maxBytes int) {
+	c.Header("Connection", "close")
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":   "payload_too_large",
+		"message": "Request body must not exceed " + strconv.Itoa(maxBytes) + " bytes",
+	})
+}
+
+// jsonDepth returns the deepest nesting of objects and arrays in data,
+// without decoding it. Malformed JSON is left for the handler to reject.
+func jsonDepth(data []byte) int {
+	depth, deepest := 0, 0
+	inString, escaped := false, false
+	for _, b := range data {
+		switch {
+		case inString:
+			if escaped {
+				escaped = false
+			} else if b == '\\' {
+				escaped = true
+			} else if b == '"' {
+				inString = false
+			}
+		case b == '"':
+			inString = true
+		case b == '{' || b == '[':
+			depth++
+			deepest = max(deepest, depth)
+		case b == '}' || b == ']':
+			depth--
+		}
+	}
+	return deepest
+}
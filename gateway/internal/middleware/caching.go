@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cachePolicy is how long browsers and proxies may reuse a response
+type cachePolicy struct {
+	maxAge       time.Duration
+	shared       bool // Proxies may keep responses to anonymous callers
+	revalidate   bool // Reuse only after checking the validator with the gateway
+	noStore      bool
+	vary         []string
+	lastModified time.Time
+}
+
+// privateNoCache lets browsers keep a response but not reuse it unchecked,
+// for GET endpoints without a policy of their own
+var privateNoCache = cachePolicy{revalidate: true}
+
+// credentialHeaders are the request headers a response can depend on
+// through the caller's user and tenant
+var credentialHeaders = []string{"Authorization", "X-Guest-Token"}
+
+// Caching sets Cache-Control and Vary on successful GET responses: private
+// and revalidated unless the route sets a policy with CacheFor, CacheStatic
+// or Revalidate. Error responses are never stored. Headers a handler sets
+// itself are kept.
+func Caching() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		applyCachePolicy(c, privateNoCache)
+	}
+}
+
+// CacheFor lets responses be reused for ttl, by proxies too for anonymous
+// callers. They vary by the caller's credentials, as results are the
+// tenant's, and by language. A ttl of 0 turns caching off.
+func CacheFor(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy := cachePolicy{maxAge: ttl, shared: true, vary: append([]string{"Accept-Language"}, credentialHeaders...)}
+		if ttl <= 0 {
+			policy = cachePolicy{noStore: true}
+		}
+		applyCachePolicy(c, policy)
+	}
+}
+
+// CacheStatic lets anyone reuse responses that only change with the
+// deployment for ttl, and answers If-Modified-Since against modified
+func CacheStatic(ttl time.Duration, modified time.Time) gin.HandlerFunc {
+	modified = modified.UTC().Truncate(time.Second)
+	return func(c *gin.Context) {
+		if ttl <= 0 {
+			applyCachePolicy(c, cachePolicy{noStore: true})
+			return
+		}
+		if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !modified.After(since) {
+			c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(ttl.Seconds())))
+			c.Header("Last-Modified", modified.Format(http.TimeFormat))
+			c.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+		applyCachePolicy(c, cachePolicy{maxAge: ttl, shared: true, lastModified: modified})
+	}
+}
+
+// Revalidate lets browsers keep a response for the caller only, checking
+// its ETag or Last-Modified with the gateway before each reuse
+func Revalidate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		applyCachePolicy(c, cachePolicy{revalidate: true, vary: credentialHeaders})
+	}
+}
+
+// applyCachePolicy sets the request's policy, replacing that of an earlier
+// caching middleware, and runs the rest of the chain
+func applyCachePolicy(c *gin.Context, policy cachePolicy) {
+	if w, ok := c.Writer.(*cacheWriter); ok {
+		w.policy = policy
+		c.Next()
+		return
+	}
+	w := &cacheWriter{ResponseWriter: c.Writer, c: c, policy: policy}
+	c.Writer = w
+	c.Next()
+	// Responses without a body are written after the handlers return
+	w.setHeaders()
+}
+
+// cacheControl renders the policy for c's caller
+func (p cachePolicy) cacheControl(c *gin.Context) string {
+	if p.noStore {
+		return "no-store"
+	}
+	scope := "public"
+	for _, name := range credentialHeaders {
+		if !p.shared || c.GetHeader(name) != "" {
+			scope = "private"
+		}
+	}
+	if p.revalidate {
+		return scope + ", max-age=0, must-revalidate"
+	}
+	return fmt.Sprintf("%s, max-age=%d", scope, int(p.maxAge.Seconds()))
+}
+
+// cacheWriter sets the caching headers just before the response headers go
+// out, once the status is known
+type cacheWriter struct {
+	gin.ResponseWriter
+	c      *gin.Context
+	policy cachePolicy
+	done   bool
+}
+
+func (w *cacheWriter) setHeaders() {
+	// Buffering writers below report being written once the status is set,
+	// so only our own writes tell whether the headers went out
+	if w.done {
+		return
+	}
+	w.done = true
+	header := w.Header()
+	if header.Get("Cache-Control") != "" {
+		return
+	}
+	status := w.Status()
+	if status != http.StatusNotModified && (status < 200 || status >= 300) {
+		header.Set("Cache-Control", "no-store")
+		return
+	}
+	header.Set("Cache-Control", w.policy.cacheControl(w.c))
+	if len(w.policy.vary) > 0 {
+		header.Add("Vary", strings.Join(w.policy.vary, ", "))
+	}
+	if !w.policy.lastModified.IsZero() && header.Get("Last-Modified") == "" {
+		header.Set("Last-Modified", w.policy.lastModified.Format(http.TimeFormat))
+	}
+}
+
+func (w *cacheWriter) WriteHeaderNow() {
+	w.setHeaders()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *cacheWriter) Write(data []byte) (int, error) {
+	w.setHeaders()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *cacheWriter) WriteString(s string) (int, error) {
+	w.setHeaders()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *cacheWriter) Flush() {
+	w.setHeaders()
+	w.ResponseWriter.Flush()
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"log"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORS applies the cross-origin policy from configuration
+func CORS(cfg *config.Config) gin.HandlerFunc {
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Accept", "X-Request-ID", "Idempotency-Key", "If-None-Match", "If-Modified-Since", "X-Guest-Token", "X-Share-Token"}
+	// Headers the SPA reads: caching, replays, back-off, timings and API
+	// deprecation
+	corsConfig.ExposeHeaders = []string{
+		"Content-Length", "ETag", "Idempotent-Replayed", "X-Answer-Key",
+		"Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+		"X-Timing", "Deprecation", "Sunset", "Link", "X-API-Version",
+	}
+	corsConfig.AllowCredentials = cfg.CORS.AllowCredentials
+	corsConfig.MaxAge = cfg.CORS.MaxAge
+
+	switch {
+	case cfg.CORS.AllowAllOrigins():
+		corsConfig.AllowAllOrigins = true
+		if corsConfig.AllowCredentials {
+			// Browsers reject credentialed responses with a wildcard origin
+			log.Println("CORS: credentials disabled because all origins are allowed")
+			corsConfig.AllowCredentials = false
+		}
+	case len(cfg.CORS.AllowedOrigins) == 0:
+		// No origins configured: reject all cross-origin requests
+		corsConfig.AllowOriginFunc = func(origin string) bool { return false }
+	default:
+		corsConfig.AllowOrigins = cfg.CORS.AllowedOrigins
+	}
+
+	return cors.New(corsConfig)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/costs"
+	"github.com/gin-gonic/gin"
+)
+
+// CostBudget rejects requests with 402 once their tenant has spent its
+// monthly budget, and charges the backend calls a request makes to the
+// tenant. The store being unavailable lets requests through.
+func CostBudget(tracker *costs.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !tracker.Enabled() {
+			c.Next()
+			return
+		}
+		tenantID := c.GetString("tenant_id")
+		if tenantID == "" {
+			tenantID = "global"
+		}
+
+		_, budget, err := tracker.Check(c.Request.Context(), tenantID)
+		if errors.Is(err, costs.ErrBudgetExceeded) {
+			c.JSON(http.StatusPaymentRequired, gin.H{
+				"error":   "budget_exceeded",
+				"message": fmt.Sprintf("The monthly budget of $%.2f has been spent", budget),
+			})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			log.Printf("costs: budget check for tenant %s failed, processing request: %v", tenantID, err)
+		}
+
+		ctx, meter := costs.WithMeter(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		// The backends did the work even if the client went away
+		if _, err := tracker.Charge(context.WithoutCancel(ctx), tenantID, meter.Calls()); err != nil {
+			log.Printf("costs: failed to charge tenant %s: %v", tenantID, err)
+		}
+	}
+}
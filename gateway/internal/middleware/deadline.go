@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deadline bounds the rest of the request, including admission queueing and
+// upstream calls, to budget through the request context. A zero budget
+// leaves the request unbounded.
+func Deadline(budget time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if budget <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), budget)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecations marks responses to deprecated routes, and to requests using
+// deprecated fields, with Deprecation (RFC 9745), Sunset (RFC 8594) and
+// Link headers. The first matching rule applies. Each client's use of a
+// rule is logged once per cfg.LogTTL, so owners of old integrations can be
+// found before the sunset.
+func Deprecations(cfg config.DeprecationConfig, store storage.KeyValue) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(cfg.Rules) == 0 {
+			c.Next()
+			return
+		}
+		route := c.FullPath()
+		path := UnversionedPath(route)
+		version := c.GetString("api_version")
+		if path == route {
+			version = config.DeprecatedLegacyAPI
+		}
+
+		var body map[string]json.RawMessage
+		bodyRead := false
+		for _, rule := range cfg.Rules {
+			if !deprecationMatches(rule, c.Request.Method, path, version) {
+				continue
+			}
+			if rule.Field != "" {
+				if !bodyRead {
+					bodyRead = true
+					if data := peekJSONBody(c); data != nil {
+						json.Unmarshal(data, &body)
+					}
+				}
+				if _, ok := body[rule.Field]; !ok && !c.Request.URL.Query().Has(rule.Field) {
+					continue
+				}
+			}
+			setDeprecationHeaders(c, rule, version)
+			logDeprecatedUse(c, store, cfg.LogTTL, rule)
+			break
+		}
+		c.Next()
+	}
+}
+
+// deprecationMatches reports whether rule covers a request to the
+// unversioned route path
+func deprecationMatches(rule config.DeprecationRule, method, path, version string) bool {
+	if rule.Method != "" && !strings.EqualFold(rule.Method, method) {
+		return false
+	}
+	if rule.Version != "" && rule.Version != version {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(rule.Path, "*"); ok {
+		return strings.HasPrefix(path, prefix)
+	}
+	return rule.Path == path
+}
+
+func setDeprecationHeaders(c *gin.Context, rule config.DeprecationRule, version string) {
+	if rule.Since.IsZero() {
+		c.Header("Deprecation", "true")
+	} else {
+		c.Header("Deprecation", fmt.Sprintf("@%d", rule.Since.Unix()))
+	}
+	if !rule.Sunset.IsZero() {
+		c.Header("Sunset", rule.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if rule.Link != "" {
+		c.Writer.Header().Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"; type="text/html"`, rule.Link))
+	}
+	// The legacy alias has the same route under /api/v1
+	if version == config.DeprecatedLegacyAPI && rule.Field == "" {
+		successor := "/api/" + APIv1 + strings.TrimPrefix(c.Request.URL.Path, "/api")
+		c.Writer.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+	}
+}
+
+// logDeprecatedUse logs a client's use of rule unless it was logged within
+// ttl. Clients are told apart by user, or by IP when anonymous.
+func logDeprecatedUse(c *gin.Context, store storage.KeyValue, ttl time.Duration, rule config.DeprecationRule) {
+	client := "ip:" + c.ClientIP()
+	if userID := c.GetString("user_id"); userID != "" {
+		client = "user:" + userID
+	}
+	key := fmt.Sprintf("deprecation:%s|%s|%s|%s:%s", rule.Version, rule.Method, rule.Path, rule.Field, client)
+	first, err := store.SetNX(context.WithoutCancel(c.Request.Context()), key, []byte("1"), ttl)
+	if err != nil || !first {
+		return
+	}
+
+	sunset := "none"
+	if !rule.Sunset.IsZero() {
+		sunset = rule.Sunset.UTC().Format(time.RFC3339)
+	}
+	log.Printf("msg=deprecated_use client=%s tenant_id=%s request_id=%s method=%s path=%q route=%q field=%q sunset=%s user_agent=%q",
+		client, c.GetString("tenant_id"), c.GetString("request_id"), c.Request.Method, c.Request.URL.Path, c.FullPath(),
+		rule.Field, sunset, c.Request.UserAgent())
+}
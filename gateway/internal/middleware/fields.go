@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/amirhf/learnpath-gateway/internal/etag"
+	"github.com/amirhf/learnpath-gateway/internal/fields"
+	"github.com/gin-gonic/gin"
+)
+
+// SparseFieldsets trims successful JSON responses to the fields listed in the
+// ?fields= query parameter, e.g. ?fields=plan_id,goal,milestones.title
+func SparseFieldsets() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		selection := fields.Parse(c.Query("fields"))
+		if selection == nil {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		c.Writer = buffered
+
+		c.Next()
+
+		c.Writer = original
+		status := buffered.Status()
+		body := buffered.body.Bytes()
+
+		if status >= 200 && status < 300 && strings.HasPrefix(original.Header().Get("Content-Type"), "application/json") {
+			if projected, ok := project(body, selection); ok {
+				body = projected
+
+				// The projection is a different representation of the resource
+				if original.Header().Get("ETag") != "" {
+					tag := etag.Strong(body)
+					original.Header().Set("ETag", tag)
+					if etag.Matches(c.GetHeader("If-None-Match"), tag) {
+						original.Header().Del("Content-Length")
+						original.WriteHeader(http.StatusNotModified)
+						return
+					}
+				}
+			}
+		}
+
+		original.Header().Del("Content-Length")
+		original.WriteHeader(status)
+		original.Write(body)
+	}
+}
+
+// project applies the selection to a JSON document, preserving numbers as
+// written by the backend
+func project(body []byte, selection fields.Selection) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+
+	projected, err := json.Marshal(selection.Apply(value))
+	if err != nil {
+		return nil, false
+	}
+	return projected, true
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/guests"
+	"github.com/gin-gonic/gin"
+)
+
+// GuestQuota counts what a guest creates against their quota of plans or
+// quizzes, turning them away with 403 once it is used up. Requests that
+// fail don't count. The store being unavailable lets requests through.
+func GuestQuota(sessions *guests.Sessions, kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("guest") {
+			c.Next()
+			return
+		}
+		guestID := c.GetString("user_id")
+
+		err := sessions.Reserve(c.Request.Context(), guestID, kind)
+		var denial *guests.Denial
+		if errors.As(err, &denial) {
+			c.AbortWithStatusJSON(denial.Status, gin.H{"error": denial.Code, "message": denial.Message})
+			return
+		}
+		if err != nil {
+			log.Printf("guests: quota check for %s failed, processing request: %v", guestID, err)
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusMultipleChoices {
+			if err := sessions.Release(context.WithoutCancel(c.Request.Context()), guestID, kind); err != nil {
+				log.Printf("guests: failed to release %s of %s: %v", kind, guestID, err)
+			}
+		}
+	}
+}
+
+// NoGuests turns away guests from routes for signed-in users only
+func NoGuests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("guest") {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":   "account_required",
+			"message": "Sign up to use this feature",
+		})
+	}
+}
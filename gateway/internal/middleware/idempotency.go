@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyLockTTL bounds how long an in-progress request holds its key,
+// in case the replica handling it dies
+const idempotencyLockTTL = 5 * time.Minute
+
+// idempotencyRecord is the stored outcome of a request. A zero Status marks
+// a request that is still in progress.
+type idempotencyRecord struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// IdempotencyKey propagates a client-supplied Idempotency-Key to upstream
+// calls. For unsafe methods the response is stored for ttl, and a repeat of
+// the key by the same caller replays it instead of running the request
+// again. It runs after Auth, which identifies the caller.
+func IdempotencyKey(store storage.KeyValue, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		c.Set("idempotency_key", key)
+		ctx := common.WithIdempotencyKey(c.Request.Context(), key)
+		c.Request = c.Request.WithContext(ctx)
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		recordKey := idempotencyRecordKey(c, key)
+		// Store writes must outlive a client that disconnects mid-request
+		storeCtx := context.WithoutCancel(ctx)
+
+		if stored, ok, err := store.Get(storeCtx, recordKey); err != nil {
+			log.Printf("idempotency: lookup failed, processing request: %v", err)
+			c.Next()
+			return
+		} else if ok {
+			replayIdempotent(c, stored)
+			return
+		}
+
+		marker, _ := json.Marshal(idempotencyRecord{})
+		claimed, err := store.SetNX(storeCtx, recordKey, marker, idempotencyLockTTL)
+		if err != nil {
+			log.Printf("idempotency: claim failed, processing request: %v", err)
+			c.Next()
+			return
+		}
+		if !claimed {
+			idempotencyInProgress(c)
+			return
+		}
+
+		recorder := &teeWriter{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors are not final: let the client retry with the same key
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Delete(storeCtx, recordKey); err != nil {
+				log.Printf("idempotency: failed to release key: %v", err)
+			}
+			return
+		}
+
+		record, err := json.Marshal(idempotencyRecord{
+			Status:      status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		})
+		if err == nil {
+			err = store.Set(storeCtx, recordKey, record, ttl)
+		}
+		if err != nil {
+			log.Printf("idempotency: failed to store response: %v", err)
+		}
+	}
+}
+
+// idempotencyRecordKey scopes a key to the caller and the route, so keys
+// from different callers or endpoints never collide
+func idempotencyRecordKey(c *gin.Context, key string) string {
+	caller := sha256.Sum256([]byte(idempotencyScope(c)))
+	return "idempotency:" + hex.EncodeToString(caller[:16]) + ":" + c.Request.Method + ":" + c.Request.URL.Path + ":" + key
+}
+
+// idempotencyScope identifies the caller: its verified user or guest,
+// else its credentials, else its tenant and address. Guests and anonymous
+// callers send no Authorization, so that alone would let them replay each
+// other's responses.
+func idempotencyScope(c *gin.Context) string {
+	if userID := common.GetVerifiedUserID(c.Request.Context()); userID != "" {
+		if c.GetBool("guest") {
+			return "guest:" + userID
+		}
+		return "user:" + userID
+	}
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		return "auth:" + auth
+	}
+	return "anonymous:" + c.GetString("tenant_id") + ":" + c.ClientIP()
+}
+
+// replayIdempotent answers with a stored response
+func replayIdempotent(c *gin.Context, stored []byte) {
+	var record idempotencyRecord
+	if err := json.Unmarshal(stored, &record); err != nil {
+		log.Printf("idempotency: discarding unreadable record: %v", err)
+		c.Next()
+		return
+	}
+	if record.Status == 0 {
+		idempotencyInProgress(c)
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(record.Status, record.ContentType, record.Body)
+	c.Abort()
+}
+
+func idempotencyInProgress(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error":   "idempotency_in_progress",
+		"message": "A request with this Idempotency-Key is still being processed",
+	})
+	c.Abort()
+}
+
+// teeWriter passes the response through while keeping a copy of the body
+type teeWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *teeWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *teeWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,89 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/guests"
+	"github.com/amirhf/learnpath-gateway/internal/middleware"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// idempotentRouter serves POST /plan, answering with the caller's user ID,
+// behind Auth and IdempotencyKey as main.go mounts them
+func idempotentRouter(sessions *guests.Sessions) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	store := storage.NewMemory()
+	r := gin.New()
+	r.Use(middleware.Auth(&config.Config{SupabaseJWTSecret: "s3cret"}, sessions))
+	r.Use(middleware.IdempotencyKey(store, time.Hour))
+	r.POST("/plan", func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"user_id": common.GetVerifiedUserID(c.Request.Context())})
+	})
+	return r
+}
+
+func post(r *gin.Engine, header, value, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/plan", nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyKeyScopedToGuest(t *testing.T) {
+	sessions := guests.New(config.GuestConfig{Enabled: true, Secret: "guest-secret", TTL: time.Hour}, storage.NewMemory())
+	r := idempotentRouter(sessions)
+
+	first, err := sessions.Issue()
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	second, err := sessions.Issue()
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	w := post(r, "X-Guest-Token", first.Token, "key-1")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("first guest: status %d, want %d", w.Code, http.StatusCreated)
+	}
+
+	w = post(r, "X-Guest-Token", second.Token, "key-1")
+	if w.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("second guest replayed the first guest's response: %s", w.Body)
+	}
+	if want := `{"user_id":"` + second.GuestID + `"}`; w.Body.String() != want {
+		t.Errorf("second guest got %s, want %s", w.Body, want)
+	}
+
+	w = post(r, "X-Guest-Token", first.Token, "key-1")
+	if w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("first guest's repeat was not replayed")
+	}
+	if want := `{"user_id":"` + first.GuestID + `"}`; w.Body.String() != want {
+		t.Errorf("first guest's replay = %s, want %s", w.Body, want)
+	}
+}
+
+func TestIdempotencyKeyScopedToAnonymousAddress(t *testing.T) {
+	r := idempotentRouter(nil)
+
+	post(r, "", "", "key-1") // From httptest's default address
+	req := httptest.NewRequest(http.MethodPost, "/plan", nil)
+	req.RemoteAddr = "203.0.113.7:4000"
+	req.Header.Set("Idempotency-Key", "key-1")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("an anonymous caller at another address replayed a stored response")
+	}
+}
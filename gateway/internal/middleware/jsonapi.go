@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/amirhf/learnpath-gateway/internal/etag"
+	"github.com/amirhf/learnpath-gateway/internal/jsonapi"
+	"github.com/gin-gonic/gin"
+)
+
+// JSONAPI reshapes learning path, quiz and resource responses, and errors,
+// into JSON:API documents for callers asking with ?format=jsonapi or
+// Accept: application/vnd.api+json. It is the default for the listed
+// tenants, who can opt out with ?format=json. Other responses are left as
+// they are.
+func JSONAPI(tenants []string) gin.HandlerFunc {
+	defaultTenants := make(map[string]bool, len(tenants))
+	for _, tenant := range tenants {
+		defaultTenants[tenant] = true
+	}
+	return func(c *gin.Context) {
+		addVary(c.Writer.Header(), "Accept")
+		if !wantsJSONAPI(c, defaultTenants) {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		c.Writer = buffered
+
+		c.Next()
+
+		c.Writer = original
+		status := buffered.Status()
+		body := buffered.body.Bytes()
+
+		if strings.HasPrefix(original.Header().Get("Content-Type"), "application/json") {
+			if reshaped, ok := toJSONAPI(status, body); ok {
+				body = reshaped
+				original.Header().Set("Content-Type", jsonapi.MediaType)
+
+				if original.Header().Get("ETag") != "" {
+					tag := etag.Strong(body)
+					original.Header().Set("ETag", tag)
+					if status == http.StatusOK && etag.Matches(c.GetHeader("If-None-Match"), tag) {
+						original.Header().Del("Content-Length")
+						original.WriteHeader(http.StatusNotModified)
+						return
+					}
+				}
+			}
+		}
+
+		original.Header().Del("Content-Length")
+		original.WriteHeader(status)
+		original.Write(body)
+	}
+}
+
+func wantsJSONAPI(c *gin.Context, defaultTenants map[string]bool) bool {
+	switch c.Query("format") {
+	case "jsonapi":
+		return true
+	case "json":
+		return false
+	}
+	return strings.Contains(c.GetHeader("Accept"), jsonapi.MediaType) || defaultTenants[c.GetString("tenant_id")]
+}
+
+// toJSONAPI reshapes a successful response body, or converts an error body
+func toJSONAPI(status int, body []byte) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+
+	var document *jsonapi.Document
+	if status >= http.StatusBadRequest {
+		obj, _ := value.(map[string]interface{})
+		errs, ok := jsonapi.Errors(status, obj)
+		if !ok {
+			return nil, false
+		}
+		document = &jsonapi.Document{Errors: errs}
+	} else {
+		var ok bool
+		if document, ok = jsonapi.Transform(value); !ok {
+			return nil, false
+		}
+	}
+
+	reshaped, err := json.Marshal(document)
+	if err != nil {
+		return nil, false
+	}
+	return reshaped, true
+}
+
+// addVary adds a header to Vary unless it is listed already
+func addVary(header http.Header, name string) {
+	for _, value := range header.Values("Vary") {
+		for _, listed := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(listed), name) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", name)
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/locale"
+	"github.com/gin-gonic/gin"
+)
+
+// Language negotiates the language to generate content in from the
+// Accept-Language header, falling back to the configured default
+func Language(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		language := locale.Negotiate(c.GetHeader("Accept-Language"), cfg.Languages.Supported, cfg.Languages.Default)
+		c.Set("language", language)
+		c.Request = c.Request.WithContext(common.WithLanguage(c.Request.Context(), language))
+		c.Next()
+	}
+}
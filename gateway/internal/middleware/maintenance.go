@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/amirhf/learnpath-gateway/internal/features"
+	"github.com/gin-gonic/gin"
+)
+
+// maintenanceRetryAfter is the Retry-After hint (seconds) sent while the API
+// or a feature is switched off
+const maintenanceRetryAfter = "300"
+
+// Maintenance rejects API requests with 503 while maintenance mode is on.
+// Health checks and admin routes stay available so the switch can be undone.
+func Maintenance(switches *features.Switches) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if !switches.Enabled(features.MaintenanceMode) || path == "/health" || strings.HasPrefix(path, "/health/") || strings.HasPrefix(path, "/admin") {
+			c.Next()
+			return
+		}
+
+		message := switches.MaintenanceMessage()
+		if message == "" {
+			message = "The service is undergoing maintenance. Please try again shortly."
+		}
+
+		c.Header("Retry-After", maintenanceRetryAfter)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "maintenance",
+			"message": message,
+		})
+		c.Abort()
+	}
+}
+
+// KillSwitch rejects requests with 503 while the named kill switch is on
+func KillSwitch(switches *features.Switches, name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !switches.Enabled(name) {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", maintenanceRetryAfter)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "feature_disabled",
+			"message": "This feature is temporarily disabled",
+		})
+		c.Abort()
+	}
+}
+
+// isAdmin reports whether a request carries the admin token in
+// X-Admin-Token, compared in constant time. Nothing matches when no token
+// is configured.
+func isAdmin(c *gin.Context, token func() string) bool {
+	expected := token()
+	return expected != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("X-Admin-Token")), []byte(expected)) == 1
+}
+
+// AdminAuth guards admin routes with the shared admin token, looked up per
+// request so it can be rotated. Admin routes are disabled entirely when no
+// token is configured.
+func AdminAuth(token func() string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(c, token) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -2,8 +2,12 @@ package middleware
 
 import (
 	"log"
+	"net/http"
 	"time"
 
+	"github.com/amirhf/learnpath-gateway/internal/accesslog"
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/errorreport"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -17,12 +21,14 @@ func RequestID() gin.HandlerFunc {
 		}
 		c.Set("request_id", requestID)
 		c.Header("X-Request-ID", requestID)
+		// Upstream calls made with the request context carry it too
+		c.Request = c.Request.WithContext(common.WithRequestID(c.Request.Context(), requestID))
 		c.Next()
 	}
 }
 
-// Logger logs request details
-func Logger() gin.HandlerFunc {
+// Logger sends an access log entry for each request to logger's sinks
+func Logger(logger *accesslog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
@@ -30,21 +36,83 @@ func Logger() gin.HandlerFunc {
 
 		c.Next()
 
-		latency := time.Since(start)
-		statusCode := c.Writer.Status()
-		requestID := c.GetString("request_id")
-
-		log.Printf("[%s] %s %s %d %v",
-			requestID,
-			method,
-			path,
-			statusCode,
-			latency,
-		)
+		logger.Log(accesslog.Entry{
+			Time:      start,
+			RequestID: c.GetString("request_id"),
+			Method:    method,
+			Path:      path,
+			Route:     c.FullPath(),
+			Status:    c.Writer.Status(),
+			LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			Bytes:     max(c.Writer.Size(), 0),
+			ClientIP:  c.ClientIP(),
+			UserID:    c.GetString("user_id"),
+			TenantID:  c.GetString("tenant_id"),
+		})
 	}
 }
 
-// Recovery recovers from panics
-func Recovery() gin.HandlerFunc {
-	return gin.Recovery()
+// Recovery recovers from panics with a 500 in the standard error envelope
+// and reports them, with their stack, to reporter. Responses that end in a
+// 5xx are reported too, with the errors handlers attached to the context.
+// reporter may be nil.
+func Recovery(reporter *errorreport.Reporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Buffering middleware further down swaps the writer and won't get
+		// to restore it after a panic
+		writer := c.Writer
+
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			log.Printf("panic: %v [request_id=%s]", recovered, c.GetString("request_id"))
+			c.Writer = writer
+			reporter.CapturePanic(reportedRequest(c, http.StatusInternalServerError), recovered)
+
+			if c.GetString("api_version") == APIv2 {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, v2Error{Error: v2ErrorBody{
+					Code:      "internal_error",
+					Message:   "An unexpected error occurred",
+					Status:    http.StatusInternalServerError,
+					RequestID: c.GetString("request_id"),
+				}})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error":   "internal_error",
+				"message": "An unexpected error occurred",
+			})
+		}()
+
+		c.Next()
+
+		if status := c.Writer.Status(); status >= http.StatusInternalServerError {
+			errs := make([]error, 0, len(c.Errors))
+			for _, err := range c.Errors {
+				errs = append(errs, err.Err)
+			}
+			reporter.CaptureErrors(reportedRequest(c, status), errs)
+		}
+	}
+}
+
+func reportedRequest(c *gin.Context, status int) errorreport.Request {
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	return errorreport.Request{
+		Method:    c.Request.Method,
+		URL:       c.Request.URL.String(),
+		Route:     route,
+		RequestID: c.GetString("request_id"),
+		UserID:    c.GetString("user_id"),
+		TenantID:  c.GetString("tenant_id"),
+		Status:    status,
+	}
 }
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// maxInflightMirrors bounds concurrent shadow requests; extra ones are dropped
+const maxInflightMirrors = 32
+
+// mirroredHeaders are copied onto shadow requests
+var mirroredHeaders = []string{"Content-Type", "Accept", "Authorization", "X-Request-ID", "Accept-Language"}
+
+// Mirror asynchronously copies a percentage of requests on the configured
+// paths to a staging gateway. Mirrored responses are discarded and never
+// affect the live request.
+func Mirror(cfg *config.Config, transport http.RoundTripper) gin.HandlerFunc {
+	mirror := cfg.Mirror
+	target := strings.TrimRight(mirror.TargetURL, "/")
+	client := &http.Client{Transport: transport, Timeout: mirror.Timeout}
+	inflight := make(chan struct{}, maxInflightMirrors)
+
+	return func(c *gin.Context) {
+		if target == "" || mirror.Percent <= 0 || !mirrorsPath(mirror.Paths, UnversionedPath(c.FullPath())) ||
+			rand.Float64()*100 >= mirror.Percent {
+			c.Next()
+			return
+		}
+
+		// Buffer the body so both the live and shadow request can read it
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				c.Next()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		select {
+		case inflight <- struct{}{}:
+		default:
+			c.Next()
+			return
+		}
+
+		header := make(http.Header)
+		for _, name := range mirroredHeaders {
+			if value := c.GetHeader(name); value != "" {
+				header.Set(name, value)
+			}
+		}
+		header.Set("X-Mirrored-From", "gateway")
+		method, url := c.Request.Method, target+c.Request.URL.RequestURI()
+
+		go func() {
+			defer func() { <-inflight }()
+
+			req, err := http.NewRequestWithContext(context.Background(), method, url, bytes.NewReader(body))
+			if err != nil {
+				return
+			}
+			req.Header = header
+
+			start := time.Now()
+			resp, err := client.Do(req)
+			if err != nil {
+				log.Printf("[mirror] %s %s failed: %v", method, url, err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			log.Printf("[mirror] %s %s %d %v", method, url, resp.StatusCode, time.Since(start))
+		}()
+
+		c.Next()
+	}
+}
+
+func mirrorsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
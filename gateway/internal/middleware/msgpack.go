@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/amirhf/learnpath-gateway/internal/etag"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/ugorji/go/codec"
+)
+
+// msgpackHandle writes the current MessagePack spec (str8 and bin types),
+// with map keys sorted so equal documents give equal ETags
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{WriteExt: true}
+	h.Canonical = true
+	return h
+}()
+
+// MessagePack re-encodes JSON responses as MessagePack for callers that
+// prefer it in Accept (application/msgpack or application/x-msgpack), as the
+// mobile app does to save bandwidth. It must wrap every middleware that
+// rewrites JSON bodies, such as ErrorEnvelope and SparseFieldsets.
+func MessagePack() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		addVary(c.Writer.Header(), "Accept")
+		format := c.NegotiateFormat(binding.MIMEJSON, binding.MIMEMSGPACK2, binding.MIMEMSGPACK)
+		if format != binding.MIMEMSGPACK2 && format != binding.MIMEMSGPACK {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		c.Writer = buffered
+
+		c.Next()
+
+		c.Writer = original
+		status := buffered.Status()
+		body := buffered.body.Bytes()
+
+		if len(body) > 0 && strings.HasPrefix(original.Header().Get("Content-Type"), "application/json") {
+			if packed, ok := toMessagePack(body); ok {
+				body = packed
+				original.Header().Set("Content-Type", format)
+
+				// A different representation of the resource
+				if original.Header().Get("ETag") != "" {
+					tag := etag.Strong(body)
+					original.Header().Set("ETag", tag)
+					if status == http.StatusOK && etag.Matches(c.GetHeader("If-None-Match"), tag) {
+						original.Header().Del("Content-Length")
+						original.WriteHeader(http.StatusNotModified)
+						return
+					}
+				}
+			}
+		}
+
+		original.Header().Del("Content-Length")
+		original.WriteHeader(status)
+		original.Write(body)
+	}
+}
+
+// toMessagePack converts a JSON document, keeping integers as integers
+func toMessagePack(body []byte) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+
+	var packed []byte
+	if err := codec.NewEncoderBytes(&packed, msgpackHandle).Encode(numbers(value)); err != nil {
+		return nil, false
+	}
+	return packed, true
+}
+
+// numbers replaces json.Numbers, which would be packed as strings, with
+// int64s or float64s
+func numbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = numbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = numbers(item)
+		}
+	}
+	return value
+}
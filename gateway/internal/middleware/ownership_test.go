@@ -0,0 +1,77 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/middleware"
+	"github.com/amirhf/learnpath-gateway/internal/ownership"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const planID = "6f1c2a9e-3b4d-4e5f-8a7b-9c0d1e2f3a4b"
+
+// ownedRouter serves GET /plan/:id as routes.go does, to the user named
+// in X-Test-User
+func ownedRouter(owners *ownership.Registry) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Request = c.Request.WithContext(common.WithVerifiedUserID(c.Request.Context(), user))
+		}
+		c.Next()
+	})
+	r.GET("/plan/:id", middleware.UUIDParams("id"), middleware.Owned(owners, ownership.Plan, "id", ownership.Read), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func get(r *gin.Engine, path, user string) int {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if user != "" {
+		req.Header.Set("X-Test-User", user)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestOwned(t *testing.T) {
+	owners := ownership.New(config.OwnershipConfig{Enabled: true}, storage.NewMemory(), repository.NewMemory().ShareTokens)
+	if err := owners.Claim(context.Background(), ownership.Plan, planID, ownership.Owner{UserID: "alice"}); err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	r := ownedRouter(owners)
+
+	tests := []struct {
+		name string
+		id   string
+		user string
+		want int
+	}{
+		{"owner", planID, "alice", http.StatusOK},
+		{"other user", planID, "mallory", http.StatusForbidden},
+		{"anonymous", planID, "", http.StatusNotFound},
+		{"unrecorded plan", "0b7d4c1e-2f3a-4b5c-9d6e-7f8a9b0c1d2e", "alice", http.StatusNotFound},
+		{"uppercase ID", strings.ToUpper(planID), "mallory", http.StatusBadRequest},
+		{"braced ID", "{" + planID + "}", "mallory", http.StatusBadRequest},
+		{"URN ID", "urn:uuid:" + planID, "mallory", http.StatusBadRequest},
+		{"unhyphenated ID", strings.ReplaceAll(planID, "-", ""), "mallory", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := get(r, "/plan/"+tt.id, tt.user); got != tt.want {
+				t.Errorf("GET /plan/%s as %q = %d, want %d", tt.id, tt.user, got, tt.want)
+			}
+		})
+	}
+}
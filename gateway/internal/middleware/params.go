@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UUIDParams rejects requests whose named path parameters are not UUIDs
+// in canonical form (lowercase and hyphenated, as uuid.UUID.String writes
+// them) with a 400 naming each bad field, before anything is sent
+// upstream. Other spellings of the same ID would otherwise key the
+// gateway's own records, such as owners, differently from the backends.
+func UUIDParams(names ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var fields []validation.FieldError
+		for _, name := range names {
+			if id, err := uuid.Parse(c.Param(name)); err != nil || id.String() != c.Param(name) {
+				fields = append(fields, validation.FieldError{Field: name, Rule: "uuid", Message: "must be a lowercase, hyphenated UUID"})
+			}
+		}
+		if len(fields) > 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": "Invalid path parameters",
+				"errors":  fields,
+			})
+			return
+		}
+		c.Next()
+	}
+}
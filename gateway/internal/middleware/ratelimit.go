@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// SetRetryAfter tells the client how long to back off, in whole seconds
+// (at least one)
+func SetRetryAfter(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(ceilSeconds(wait)))
+}
+
+// SetRateLimit describes the limiter that rejected a request: its limit,
+// what is left of it and the seconds until it frees up
+func SetRateLimit(c *gin.Context, limit, remaining int, reset time.Duration) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(max(remaining, 0)))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return max(int(math.Ceil(d.Seconds())), 1)
+}
+
+// UserRateLimit throttles verified users, signed in or guests, by user ID
+// with each of limiters in turn, e.g. a per-minute limit and a per-second
+// burst cap. Anonymous callers and admins aren't counted. The store being
+// unavailable lets requests through.
+func UserRateLimit(limiters ...*ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := common.GetVerifiedUserID(c.Request.Context())
+		if userID == "" || c.GetBool("admin") {
+			c.Next()
+			return
+		}
+		for _, limiter := range limiters {
+			if !limiter.Enabled() {
+				continue
+			}
+			decision, err := limiter.Allow(c.Request.Context(), "user:"+userID)
+			if err != nil {
+				log.Printf("ratelimit: count for user %s failed, processing request: %v", userID, err)
+			}
+			if !decision.Allowed {
+				SetRetryAfter(c, decision.Reset)
+				SetRateLimit(c, decision.Limit, decision.Remaining, decision.Reset)
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+					"error":   "rate_limited",
+					"message": "Too many requests, please retry shortly",
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// IPRateLimit throttles anonymous callers by client IP, as resolved through
+// the trusted proxies. Verified users, signed in or guests, are left to
+// UserRateLimit and admins to their own limits; any other request is
+// counted by IP. Allowlisted IPs aren't counted. The store being
+// unavailable lets requests through.
+func IPRateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limiter.Enabled() || common.GetVerifiedUserID(c.Request.Context()) != "" || c.GetBool("admin") {
+			c.Next()
+			return
+		}
+		ip := c.ClientIP()
+		if limiter.Exempt(ip) {
+			c.Next()
+			return
+		}
+
+		decision, err := limiter.Allow(c.Request.Context(), "ip:"+ip)
+		if err != nil {
+			log.Printf("ratelimit: count for %s failed, processing request: %v", ip, err)
+		}
+		if !decision.Allowed {
+			SetRetryAfter(c, decision.Reset)
+			SetRateLimit(c, decision.Limit, decision.Remaining, decision.Reset)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":   "rate_limited",
+				"message": "Too many requests, please retry shortly",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// SlowRequests logs requests slower than cfg.Threshold or with responses
+// larger than cfg.LargeResponseBytes as one logfmt line, with the time
+// spent in each backend and the remainder spent in the gateway
+func SlowRequests(cfg config.SlowRequestConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.Threshold <= 0 && cfg.LargeResponseBytes <= 0 {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		breakdown := requestBreakdown(c)
+
+		c.Next()
+
+		latency := time.Since(start)
+		size := max(c.Writer.Size(), 0)
+		var reasons []string
+		if cfg.Threshold > 0 && latency >= cfg.Threshold {
+			reasons = append(reasons, "slow")
+		}
+		if cfg.LargeResponseBytes > 0 && size >= cfg.LargeResponseBytes {
+			reasons = append(reasons, "large")
+		}
+		if len(reasons) == 0 {
+			return
+		}
+
+		var line strings.Builder
+		fmt.Fprintf(&line, "msg=slow_request reason=%s request_id=%s method=%s path=%q route=%q status=%d latency_ms=%d response_bytes=%d",
+			strings.Join(reasons, ","), c.GetString("request_id"), c.Request.Method, c.Request.URL.Path, c.FullPath(),
+			c.Writer.Status(), latency.Milliseconds(), size)
+		spent := breakdown.Spent()
+		for _, service := range breakdown.Services() {
+			fmt.Fprintf(&line, " %s_ms=%d %s_calls=%d", service, spent[service].Milliseconds(), service, breakdown.Calls(service))
+		}
+		// Concurrent backend calls can add up to more than the latency
+		fmt.Fprintf(&line, " gateway_ms=%d", max(latency-breakdown.Total(), 0).Milliseconds())
+		log.Print(line.String())
+	}
+}
@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/timing"
+	"github.com/gin-gonic/gin"
+)
+
+// timingSteps names the orchestration step each backend serves
+var timingSteps = map[string]string{
+	"rag":     "search",
+	"planner": "plan",
+	"quiz":    "quiz",
+}
+
+// requestBreakdown returns the request's timing breakdown, attaching one if no
+// earlier middleware did
+func requestBreakdown(c *gin.Context) *timing.Breakdown {
+	if b := timing.FromContext(c.Request.Context()); b != nil {
+		return b
+	}
+	ctx, b := timing.WithBreakdown(c.Request.Context())
+	c.Request = c.Request.WithContext(ctx)
+	return b
+}
+
+// Timings reports how long a request spent in search, plan creation and
+// quiz generation, and in the gateway itself, in milliseconds. With header
+// set every response carries X-Timing; an admin (X-Admin-Token) asking with
+// ?debug=1 gets it too, plus a _timings field in JSON object responses.
+func Timings(header bool, adminToken func() string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		debug := c.Query("debug") == "1" && isAdmin(c, adminToken)
+		if !header && !debug {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		b := requestBreakdown(c)
+
+		if !debug {
+			writer := &timingWriter{ResponseWriter: c.Writer, start: start, breakdown: b}
+			c.Writer = writer
+			c.Next()
+			// Responses without a body are written after the handlers return
+			writer.setHeader()
+			return
+		}
+
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		c.Writer = buffered
+
+		c.Next()
+
+		c.Writer = original
+		timings := stepTimings(start, b)
+		body := buffered.body.Bytes()
+		if strings.HasPrefix(original.Header().Get("Content-Type"), "application/json") {
+			if annotated, ok := withTimings(body, timings); ok {
+				body = annotated
+			}
+		}
+		original.Header().Set("X-Timing", formatTimings(timings))
+		original.Header().Del("Content-Length")
+		original.WriteHeader(buffered.Status())
+		original.Write(body)
+	}
+}
+
+// stepTimings breaks the time since start down by step, in milliseconds
+func stepTimings(start time.Time, b *timing.Breakdown) map[string]int64 {
+	total := time.Since(start)
+	timings := map[string]int64{"total": total.Milliseconds()}
+	spent := b.Spent()
+	for _, service := range b.Services() {
+		step := timingSteps[service]
+		if step == "" {
+			step = service
+		}
+		timings[step] += spent[service].Milliseconds()
+	}
+	// Concurrent backend calls can add up to more than the total
+	timings["gateway"] = max(total-b.Total(), 0).Milliseconds()
+	return timings
+}
+
+// formatTimings renders timings as X-Timing, e.g.
+// "search=1850, plan=61020, quiz=28140, gateway=224, total=91234". Steps
+// of backends without a name come after quiz.
+func formatTimings(timings map[string]int64) string {
+	rank := func(step string) int {
+		switch step {
+		case "search":
+			return 0
+		case "plan":
+			return 1
+		case "quiz":
+			return 2
+		case "gateway":
+			return 4
+		case "total":
+			return 5
+		}
+		return 3
+	}
+	steps := make([]string, 0, len(timings))
+	for step := range timings {
+		steps = append(steps, step)
+	}
+	sort.Slice(steps, func(i, j int) bool {
+		if rank(steps[i]) != rank(steps[j]) {
+			return rank(steps[i]) < rank(steps[j])
+		}
+		return steps[i] < steps[j]
+	})
+	parts := make([]string, len(steps))
+	for i, step := range steps {
+		parts[i] = fmt.Sprintf("%s=%d", step, timings[step])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// withTimings adds a _timings field to a JSON object
+func withTimings(body []byte, timings map[string]int64) ([]byte, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil || object == nil {
+		return nil, false
+	}
+	object["_timings"] = timings
+	annotated, err := json.Marshal(object)
+	if err != nil {
+		return nil, false
+	}
+	return annotated, true
+}
+
+// timingWriter sets X-Timing just before the response headers go out
+type timingWriter struct {
+	gin.ResponseWriter
+	start     time.Time
+	breakdown *timing.Breakdown
+	done      bool
+}
+
+func (w *timingWriter) setHeader() {
+	if w.done || w.ResponseWriter.Written() {
+		return
+	}
+	w.done = true
+	w.Header().Set("X-Timing", formatTimings(stepTimings(w.start, w.breakdown)))
+}
+
+func (w *timingWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *timingWriter) Write(data []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *timingWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *timingWriter) Flush() {
+	w.setHeader()
+	w.ResponseWriter.Flush()
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// API versions
+const (
+	APIv1 = "v1"
+	APIv2 = "v2"
+)
+
+// APIVersion tags requests with the API version of their route group
+func APIVersion(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("api_version", version)
+		c.Header("X-API-Version", version)
+		c.Next()
+	}
+}
+
+// UnversionedPath maps /api/v1/... and /api/v2/... route paths onto their
+// legacy /api/... form so path-based settings apply to every version
+func UnversionedPath(path string) string {
+	for _, prefix := range []string{"/api/" + APIv1, "/api/" + APIv2} {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return "/api" + strings.TrimPrefix(path, prefix)
+		}
+	}
+	return path
+}
+
+// v2Error is the v2 error envelope
+type v2Error struct {
+	Error v2ErrorBody `json:"error"`
+}
+
+type v2ErrorBody struct {
+	Code      string                  `json:"code"`
+	Message   string                  `json:"message,omitempty"`
+	Errors    []validation.FieldError `json:"errors,omitempty"` // Per-field problems with the request
+	Status    int                     `json:"status"`
+	RequestID string                  `json:"request_id,omitempty"`
+}
+
+// ErrorEnvelope rewrites the v1 {"error": code, "message": text} error body
+// into the v2 envelope, so handlers can be shared between versions
+func ErrorEnvelope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		c.Writer = buffered
+
+		c.Next()
+
+		c.Writer = original
+		status := buffered.Status()
+		body := buffered.body.Bytes()
+
+		if status >= http.StatusBadRequest {
+			var v1 struct {
+				Error   string                  `json:"error"`
+				Message string                  `json:"message"`
+				Errors  []validation.FieldError `json:"errors"`
+			}
+			if err := json.Unmarshal(body, &v1); err == nil && v1.Error != "" {
+				if rewritten, err := json.Marshal(v2Error{Error: v2ErrorBody{
+					Code:      v1.Error,
+					Message:   v1.Message,
+					Errors:    v1.Errors,
+					Status:    status,
+					RequestID: c.GetString("request_id"),
+				}}); err == nil {
+					body = rewritten
+				}
+			}
+		}
+
+		original.Header().Del("Content-Length")
+		original.WriteHeader(status)
+		original.Write(body)
+	}
+}
+
+// bufferedWriter holds the response in memory until the handler chain ends
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+// Flush is a no-op; flushing would send the headers before the response
+// is rewritten
+func (w *bufferedWriter) Flush() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.status != 0 || w.body.Len() > 0
+}
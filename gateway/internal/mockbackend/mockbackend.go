@@ -0,0 +1,652 @@
+package mockbackend
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/clients"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/google/uuid"
+)
+
+// namespace derives stable IDs, so the same input always yields the same
+// resources, plans and quizzes
+var namespace = uuid.MustParse("6f1c2a0e-4b7d-4c55-9a1e-3d2b8f7e6a10")
+
+// epoch is the fixed timestamp on generated records
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	providers  = []string{"MDN", "freeCodeCamp", "YouTube", "Coursera", "Official Docs"}
+	licenses   = []string{"CC-BY-SA-2.5", "BSD-3-Clause", "youtube-standard", "proprietary", "CC-BY-4.0"} // By provider
+	mediaTypes = []string{"article", "video", "course", "tutorial"}
+	angles     = []string{"Introduction to", "Hands-on", "Deep Dive into", "Practical", "Patterns in", "Testing", "Debugging", "Building with"}
+)
+
+func stableID(parts ...string) uuid.UUID {
+	return uuid.NewSHA1(namespace, []byte(strings.Join(parts, "\x00")))
+}
+
+func hash(s string) int {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return int(h.Sum32() & 0x7fffffff)
+}
+
+// resources returns n deterministic resources about topic
+func resources(topic string, n int) []models.ResourceResult {
+	topic = strings.TrimSpace(topic)
+	if topic == "" {
+		topic = "programming"
+	}
+	results := make([]models.ResourceResult, 0, n)
+	for i := 0; i < n; i++ {
+		seed := hash(fmt.Sprintf("%s/%d", topic, i))
+		provider := providers[seed%len(providers)]
+		license := licenses[seed%len(licenses)]
+		mediaType := mediaTypes[seed%len(mediaTypes)]
+		level := 1 + i*3/n
+		duration := 15 + seed%6*15
+		score := 0.95 - float64(i)*0.03
+		id := stableID("resource", topic, fmt.Sprint(i))
+		title := fmt.Sprintf("%s %s", angles[(seed+i)%len(angles)], topic)
+		description := fmt.Sprintf("A %s %s covering %s.", provider, mediaType, topic)
+
+		results = append(results, models.ResourceResult{
+			ID:          id,
+			Title:       title,
+			URL:         fmt.Sprintf("https://example.com/learn/%s/%s", slug(topic), id.String()[:8]),
+			Provider:    &provider,
+			License:     &license,
+			DurationMin: &duration,
+			Level:       &level,
+			Skills:      []string{topic},
+			MediaType:   &mediaType,
+			Description: &description,
+			Score:       &score,
+		})
+	}
+	return results
+}
+
+// restrict gives generated resources the IDs of a plan or search limited to
+// those resources, dropping the rest; ids that aren't UUIDs are skipped
+func restrict(results []models.ResourceResult, ids []string) []models.ResourceResult {
+	if len(ids) == 0 {
+		return results
+	}
+	kept := results[:0]
+	for _, id := range ids {
+		parsed, err := uuid.Parse(id)
+		if err != nil || len(kept) == len(results) {
+			continue
+		}
+		r := results[len(kept)]
+		r.ID = parsed
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+func slug(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), "-")
+}
+
+// RAG is an in-process clients.RAGClient returning generated resources
+type RAG struct{}
+
+func (RAG) Search(_ context.Context, req clients.SearchRequest) (*models.SearchResponse, error) {
+	topK := req.TopK
+	if topK <= 0 || topK > 20 {
+		topK = 10
+	}
+	results := resources(req.Query, topK)
+	if req.Filters != nil {
+		results = restrict(results, req.Filters.ResourceIDs)
+	}
+	return &models.SearchResponse{
+		Results:    results,
+		Query:      req.Query,
+		TotalFound: len(results),
+		Reranked:   req.Rerank,
+	}, nil
+}
+
+func (RAG) IngestResources(_ context.Context, resources []clients.IngestResource) ([]string, error) {
+	ids := make([]string, len(resources))
+	for i, r := range resources {
+		ids[i] = ingestedID(r)
+	}
+	return ids, nil
+}
+
+func (RAG) IngestDocument(_ context.Context, doc clients.IngestResource) (string, error) {
+	return ingestedID(doc), nil
+}
+
+// ingestedID is the caller's ID for a resource, or one derived from its
+// URL like the RAG service's upsert by URL
+func ingestedID(r clients.IngestResource) string {
+	if r.ID != "" {
+		return r.ID
+	}
+	return stableID("ingested", r.URL).String()
+}
+
+// Planner is an in-process clients.PlannerClient that builds plans from
+// generated resources and remembers them for later reads
+type Planner struct {
+	mu    sync.RWMutex
+	plans map[uuid.UUID]*models.LearningPath
+	users map[string][]uuid.UUID
+}
+
+// NewPlanner creates an empty planner
+func NewPlanner() *Planner {
+	return &Planner{plans: map[uuid.UUID]*models.LearningPath{}, users: map[string][]uuid.UUID{}}
+}
+
+func (p *Planner) CreatePlan(_ context.Context, req models.PlanLearningPathRequest) (*models.LearningPath, error) {
+	userID := "anonymous"
+	if req.UserID != nil && *req.UserID != "" {
+		userID = *req.UserID
+	}
+
+	plan := buildPlan(req.Goal, userID, float64(req.TimeBudgetHours), req.HoursPerWeek, req.ResourceIDs)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, exists := p.plans[plan.PlanID]; !exists {
+		p.users[userID] = append(p.users[userID], plan.PlanID)
+	}
+	p.plans[plan.PlanID] = plan
+	return plan, nil
+}
+
+func (p *Planner) GetPlan(_ context.Context, planID uuid.UUID) (*models.LearningPath, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	plan, ok := p.plans[planID]
+	if !ok {
+		return nil, errNotFound
+	}
+	return plan, nil
+}
+
+func (p *Planner) GetUserPlans(_ context.Context, userID string) ([]models.LearningPath, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	plans := []models.LearningPath{}
+	for _, id := range p.users[userID] {
+		plans = append(plans, *p.plans[id])
+	}
+	return plans, nil
+}
+
+func (p *Planner) Replan(ctx context.Context, planID uuid.UUID, req models.ReplanRequest) (*models.LearningPath, error) {
+	plan, err := p.GetPlan(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[uuid.UUID]bool, len(req.CompletedResources))
+	for _, id := range req.CompletedResources {
+		done[id] = true
+	}
+	updated := *plan
+	updated.Milestones = nil
+	updated.TotalHours = 0
+	for _, m := range plan.Milestones {
+		var remaining []models.ResourceItem
+		for _, r := range m.Resources {
+			if !done[r.ResourceID] {
+				remaining = append(remaining, r)
+			}
+		}
+		if len(remaining) == 0 {
+			continue
+		}
+		m.Resources = remaining
+		m.EstimatedHours = resourceHours(remaining)
+		updated.TotalHours += m.EstimatedHours
+		updated.Milestones = append(updated.Milestones, m)
+	}
+	if review := reviewMilestone(plan, req); review != nil {
+		at := 0
+		if req.ReviewAfter != nil {
+			at = slices.IndexFunc(updated.Milestones, func(m models.Milestone) bool { return m.MilestoneID == *req.ReviewAfter }) + 1
+		}
+		updated.Milestones = slices.Insert(updated.Milestones, at, *review)
+		updated.TotalHours += review.EstimatedHours
+		for i := range updated.Milestones {
+			updated.Milestones[i].Order = i + 1
+		}
+	}
+	updated.UpdatedAt = time.Now().UTC()
+
+	p.mu.Lock()
+	p.plans[planID] = &updated
+	p.mu.Unlock()
+	return &updated, nil
+}
+
+func (p *Planner) SetPlanStatus(_ context.Context, planID uuid.UUID, status string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	plan, ok := p.plans[planID]
+	if !ok {
+		return errNotFound
+	}
+	updated := *plan
+	updated.Status = status
+	updated.UpdatedAt = time.Now().UTC()
+	p.plans[planID] = &updated
+	return nil
+}
+
+func (p *Planner) DeletePlan(_ context.Context, planID uuid.UUID) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.plans[planID]; !ok {
+		return errNotFound
+	}
+	delete(p.plans, planID)
+	for userID, ids := range p.users {
+		p.users[userID] = slices.DeleteFunc(ids, func(id uuid.UUID) bool { return id == planID })
+	}
+	return nil
+}
+
+func (p *Planner) TransferPlans(_ context.Context, fromUserID, toUserID string) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	moved := p.users[fromUserID]
+	p.users[toUserID] = append(p.users[toUserID], moved...)
+	delete(p.users, fromUserID)
+	return len(moved), nil
+}
+
+// DecomposeGoal suggests the same stages buildPlan uses, plus a capstone
+func (p *Planner) DecomposeGoal(_ context.Context, req models.DecomposeGoalRequest) (*models.GoalDecomposition, error) {
+	stages := []struct {
+		title string
+		hours float64
+	}{{"Foundations", 10}, {"Core Skills", 20}, {"Applied Projects", 15}, {"Capstone", 10}}
+	if req.MaxSubGoals > 0 && req.MaxSubGoals < len(stages) {
+		stages = stages[:req.MaxSubGoals]
+	}
+	decomposition := &models.GoalDecomposition{Goal: req.Goal, SubGoals: []models.SubGoal{}}
+	for i, stage := range stages {
+		decomposition.SubGoals = append(decomposition.SubGoals, models.SubGoal{
+			Title:          stage.title + ": " + req.Goal,
+			Description:    stage.title + " for " + req.Goal,
+			EstimatedHours: stage.hours,
+			Order:          i + 1,
+		})
+		decomposition.TotalEstimatedHours += stage.hours
+	}
+	return decomposition, nil
+}
+
+// reviewMilestone collects a plan's review resources into a milestone, or
+// returns nil when there are none
+func reviewMilestone(plan *models.LearningPath, req models.ReplanRequest) *models.Milestone {
+	var items []models.ResourceItem
+	for _, m := range plan.Milestones {
+		for _, r := range m.Resources {
+			if slices.Contains(req.ReviewResources, r.ResourceID) {
+				r.WhyIncluded = "Review: a quiz showed this material needs another look"
+				r.Order = len(items) + 1
+				items = append(items, r)
+			}
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	topics := "this material"
+	if len(req.WeakSkills) > 0 {
+		topics = strings.Join(req.WeakSkills, ", ")
+	}
+	after := ""
+	if req.ReviewAfter != nil {
+		after = req.ReviewAfter.String()
+	}
+	return &models.Milestone{
+		MilestoneID:    stableID("review", plan.PlanID.String(), after, fmt.Sprint(len(plan.Milestones))),
+		Title:          "Review",
+		Description:    "Revisit " + topics + " before moving on.",
+		Resources:      items,
+		EstimatedHours: resourceHours(items),
+		SkillsGained:   req.WeakSkills,
+	}
+}
+
+// buildPlan splits generated resources for goal into milestones. A
+// non-empty only restricts the plan to those resource IDs.
+func buildPlan(goal, userID string, budgetHours float64, hoursPerWeek int, only []string) *models.LearningPath {
+	found := restrict(resources(goal, 9), only)
+	titles := []string{"Foundations", "Core Skills", "Applied Projects"}
+
+	plan := &models.LearningPath{
+		PlanID:           stableID(append([]string{"plan", userID, goal, fmt.Sprint(budgetHours)}, only...)...),
+		Goal:             goal,
+		PrerequisitesMet: true,
+		Reasoning:        "Mock plan: resources are grouped from introductory to advanced in three milestones.",
+		Status:           models.PlanActive,
+		CreatedAt:        epoch,
+		UpdatedAt:        epoch,
+	}
+	for i, title := range titles {
+		if i*3 >= len(found) {
+			break
+		}
+		var items []models.ResourceItem
+		for j, r := range found[i*3 : min(i*3+3, len(found))] {
+			items = append(items, models.ResourceItem{
+				ResourceID:  r.ID,
+				Title:       r.Title,
+				URL:         r.URL,
+				DurationMin: *r.DurationMin,
+				Level:       r.Level,
+				Skills:      r.Skills,
+				WhyIncluded: fmt.Sprintf("Covers %s at level %d", goal, *r.Level),
+				Order:       j + 1,
+				License:     r.License,
+			})
+		}
+		milestone := models.Milestone{
+			MilestoneID:    stableID("milestone", plan.PlanID.String(), title),
+			Title:          fmt.Sprintf("%s: %s", title, goal),
+			Description:    fmt.Sprintf("%s for %s", title, goal),
+			Resources:      items,
+			EstimatedHours: resourceHours(items),
+			SkillsGained:   []string{goal},
+			Order:          i + 1,
+		}
+		plan.TotalHours += milestone.EstimatedHours
+		plan.Milestones = append(plan.Milestones, milestone)
+	}
+	if budgetHours > 0 && plan.TotalHours > budgetHours {
+		plan.PrerequisitesMet = false
+	}
+	if hoursPerWeek > 0 {
+		plan.EstimatedWeeks = int(plan.TotalHours)/hoursPerWeek + 1
+	}
+	return plan
+}
+
+func resourceHours(items []models.ResourceItem) float64 {
+	var minutes int
+	for _, item := range items {
+		minutes += item.DurationMin
+	}
+	return float64(minutes) / 60
+}
+
+// Quiz is an in-process clients.QuizClient generating multiple-choice
+// questions per resource and grading submissions against them. Requested
+// short-answer questions come last and are graded correct when the answer
+// is at least minShortAnswer characters long.
+type Quiz struct {
+	mu       sync.RWMutex
+	quizzes  map[string]*models.Quiz
+	composed map[string]bool // Quizzes assembled from bank questions
+}
+
+// NewQuiz creates an empty quiz service
+func NewQuiz() *Quiz {
+	return &Quiz{quizzes: map[string]*models.Quiz{}, composed: map[string]bool{}}
+}
+
+func (q *Quiz) GenerateQuiz(_ context.Context, req models.GenerateQuizRequest) (*models.Quiz, error) {
+	n := req.NumQuestions
+	if n <= 0 {
+		n = 5
+	}
+	if len(req.ResourceIDs) == 0 {
+		return nil, fmt.Errorf("at least one resource is required")
+	}
+
+	quizID := stableID("quiz", strings.Join(req.ResourceIDs, ","), req.Difficulty, fmt.Sprint(n), fmt.Sprint(req.ShortAnswers)).String()
+	title := fmt.Sprintf("Mock %s quiz", req.Difficulty)
+	quiz := &models.Quiz{QuizID: quizID, Title: &title, TotalQuestions: n, CreatedAt: epoch}
+	for i := 0; i < n; i++ {
+		resourceID := req.ResourceIDs[i%len(req.ResourceIDs)]
+		questionID := fmt.Sprintf("q%d", i+1)
+		correct := hash(quizID+questionID) % 4
+		question := models.QuizQuestion{
+			QuestionID:       questionID,
+			QuestionText:     fmt.Sprintf("Question %d about resource %s?", i+1, resourceID),
+			Explanation:      fmt.Sprintf("Option %c is covered in the resource.", 'A'+correct),
+			SourceResourceID: resourceID,
+			Citation:         "Mock citation",
+		}
+		if i >= n-req.ShortAnswers {
+			question.QuestionType = models.QuestionShortAnswer
+			question.QuestionText = fmt.Sprintf("In your own words, what does resource %s cover?", resourceID)
+			question.Explanation = "A good answer summarizes the resource."
+			quiz.Questions = append(quiz.Questions, question)
+			continue
+		}
+		question.QuestionType = models.QuestionMultipleChoice
+		for o := 0; o < 4; o++ {
+			question.Options = append(question.Options, models.QuizOption{
+				OptionID:  string(rune('a' + o)),
+				Text:      fmt.Sprintf("Option %c", 'A'+o),
+				IsCorrect: o == correct,
+			})
+		}
+		quiz.Questions = append(quiz.Questions, question)
+	}
+
+	q.mu.Lock()
+	q.quizzes[quizID] = quiz
+	q.mu.Unlock()
+	return quiz, nil
+}
+
+func (q *Quiz) SubmitQuiz(_ context.Context, req clients.QuizSubmitRequest) (*clients.QuizSubmitResponse, error) {
+	q.mu.RLock()
+	quiz, ok := q.quizzes[req.QuizID]
+	q.mu.RUnlock()
+	if !ok {
+		return nil, errNotFound
+	}
+
+	selected := make(map[string]string, len(req.Answers))
+	written := make(map[string]string, len(req.Answers))
+	for _, answer := range req.Answers {
+		selected[answer.QuestionID] = answer.SelectedOptionID
+		written[answer.QuestionID] = answer.AnswerText
+	}
+	resp := &clients.QuizSubmitResponse{QuizID: quiz.QuizID, TotalQuestions: len(quiz.Questions)}
+	for _, question := range quiz.Questions {
+		if question.QuestionType == models.QuestionShortAnswer {
+			resp.PendingQuestions++
+			resp.Results = append(resp.Results, models.QuestionResult{
+				QuestionID:       question.QuestionID,
+				QuestionType:     question.QuestionType,
+				AnswerText:       written[question.QuestionID],
+				Status:           models.ResultPending,
+				Citation:         question.Citation,
+				SourceResourceID: question.SourceResourceID,
+			})
+			continue
+		}
+		var correctID string
+		for _, option := range question.Options {
+			if option.IsCorrect {
+				correctID = option.OptionID
+			}
+		}
+		result := models.QuestionResult{
+			QuestionID:       question.QuestionID,
+			SelectedOptionID: selected[question.QuestionID],
+			CorrectOptionID:  correctID,
+			Correct:          selected[question.QuestionID] == correctID,
+			Explanation:      question.Explanation,
+			Citation:         question.Citation,
+			SourceResourceID: question.SourceResourceID,
+			QuestionType:     question.QuestionType,
+			Status:           models.ResultGraded,
+		}
+		if result.Correct {
+			resp.CorrectAnswers++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	if resp.TotalQuestions > 0 {
+		resp.Score = float64(resp.CorrectAnswers) * 100 / float64(resp.TotalQuestions)
+	}
+	return resp, nil
+}
+
+// RetakeQuiz returns a variant of a quiz with its questions reworded and
+// options rotated, so the correct option moves
+func (q *Quiz) RetakeQuiz(_ context.Context, req models.RetakeQuizRequest) (*models.Quiz, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	original, ok := q.quizzes[req.QuizID]
+	if !ok {
+		return nil, errNotFound
+	}
+
+	variantID := stableID("variant", original.QuizID, fmt.Sprint(len(q.quizzes))).String()
+	title := "Retake"
+	if original.Title != nil {
+		title += ": " + *original.Title
+	}
+	variant := &models.Quiz{QuizID: variantID, Title: &title, TotalQuestions: original.TotalQuestions, CreatedAt: epoch, VariantOf: original.QuizID}
+	for i, question := range original.Questions {
+		question.QuestionText = "In other words: " + question.QuestionText
+		if n := len(question.Options); n > 0 {
+			rotated := make([]models.QuizOption, n)
+			for o, option := range question.Options {
+				rotated[(o+i+1)%n] = option
+			}
+			question.Options = rotated
+		}
+		variant.Questions = append(variant.Questions, question)
+	}
+	q.quizzes[variantID] = variant
+	return variant, nil
+}
+
+// bankSkillResources is how many of the resources RAG returns for a skill
+// a question bank listing filtered by that skill covers
+const bankSkillResources = 20
+
+// ListQuestions lists the questions of generated quizzes, by quiz ID and
+// then in quiz order. Variants and composed quizzes only repeat them. A
+// skill matches the resources RAG returns when searching for it.
+func (q *Quiz) ListQuestions(_ context.Context, filter models.QuestionBankFilter) ([]models.BankQuestion, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	var skillResources map[string]bool
+	if filter.Skill != "" {
+		skillResources = make(map[string]bool, bankSkillResources)
+		for _, resource := range resources(filter.Skill, bankSkillResources) {
+			skillResources[resource.ID.String()] = true
+		}
+	}
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	quizIDs := make([]string, 0, len(q.quizzes))
+	for id, quiz := range q.quizzes {
+		if quiz.VariantOf == "" && !q.composed[id] {
+			quizIDs = append(quizIDs, id)
+		}
+	}
+	slices.Sort(quizIDs)
+
+	questions := []models.BankQuestion{}
+	for _, id := range quizIDs {
+		quiz := q.quizzes[id]
+		for _, question := range quiz.Questions {
+			if filter.ResourceID != "" && question.SourceResourceID != filter.ResourceID {
+				continue
+			}
+			if skillResources != nil && !skillResources[question.SourceResourceID] {
+				continue
+			}
+			questions = append(questions, models.BankQuestion{QuizID: id, PublicQuizQuestion: models.NewPublicQuestion(question), CreatedAt: quiz.CreatedAt})
+			if len(questions) == limit {
+				return questions, nil
+			}
+		}
+	}
+	return questions, nil
+}
+
+// ComposeQuiz assembles a quiz from questions of earlier quizzes,
+// numbering them afresh
+func (q *Quiz) ComposeQuiz(_ context.Context, req models.ComposeQuizRequest) (*models.Quiz, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	parts := []string{"composed", fmt.Sprint(len(q.quizzes))}
+	var questions []models.QuizQuestion
+	for i, ref := range req.Questions {
+		quiz, ok := q.quizzes[ref.QuizID]
+		if !ok {
+			return nil, errNotFound
+		}
+		index := slices.IndexFunc(quiz.Questions, func(question models.QuizQuestion) bool {
+			return question.QuestionID == ref.QuestionID
+		})
+		if index < 0 {
+			return nil, errNotFound
+		}
+		question := quiz.Questions[index]
+		question.QuestionID = fmt.Sprintf("q%d", i+1)
+		questions = append(questions, question)
+		parts = append(parts, ref.QuizID, ref.QuestionID)
+	}
+
+	title := req.Title
+	if title == "" {
+		title = "Composed quiz"
+	}
+	quizID := stableID(parts...).String()
+	quiz := &models.Quiz{QuizID: quizID, Title: &title, Questions: questions, TotalQuestions: len(questions), CreatedAt: epoch}
+	q.quizzes[quizID] = quiz
+	q.composed[quizID] = true
+	return quiz, nil
+}
+
+// minShortAnswer is the length of a short answer graded correct
+const minShortAnswer = 20
+
+// GradeAnswer grades a short answer by its length
+func (q *Quiz) GradeAnswer(_ context.Context, req models.GradeAnswerRequest) (*models.GradeAnswerResponse, error) {
+	q.mu.RLock()
+	quiz, ok := q.quizzes[req.QuizID]
+	q.mu.RUnlock()
+	if !ok {
+		return nil, errNotFound
+	}
+	for _, question := range quiz.Questions {
+		if question.QuestionID != req.QuestionID || question.QuestionType != models.QuestionShortAnswer {
+			continue
+		}
+		resp := &models.GradeAnswerResponse{QuizID: req.QuizID, QuestionID: req.QuestionID, Feedback: "Too short to show understanding."}
+		if len(strings.TrimSpace(req.AnswerText)) >= minShortAnswer {
+			resp.Correct, resp.Score, resp.Feedback = true, 1, "Covers the resource."
+		}
+		return resp, nil
+	}
+	return nil, errNotFound
+}
+
+// Calibrate accepts question statistics; generated quizzes ignore them
+func (q *Quiz) Calibrate(_ context.Context, _ models.CalibrationRequest) error {
+	return nil
+}
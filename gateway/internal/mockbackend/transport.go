@@ -0,0 +1,295 @@
+package mockbackend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/amirhf/learnpath-gateway/internal/clients"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/google/uuid"
+)
+
+var errNotFound = errors.New("not found")
+
+// Transport answers backend requests in-process, routing by path to the
+// mock RAG, Planner and Quiz services. Responses are encoded the way the
+// Python services encode them, so both the typed clients and the handlers
+// that proxy raw requests work unchanged. Requests for any other path get
+// a 404 without touching the network.
+type Transport struct {
+	RAG     RAG
+	Planner *Planner
+	Quiz    *Quiz
+}
+
+// NewTransport creates a transport backed by fresh mock services
+func NewTransport() *Transport {
+	return &Transport{Planner: NewPlanner(), Quiz: NewQuiz()}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	// Honour deadlines like a real backend call would
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	status, body := t.route(req)
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(payload)),
+		ContentLength: int64(len(payload)),
+		Request:       req,
+	}, nil
+}
+
+// detail is the FastAPI error body
+type detail struct {
+	Detail string `json:"detail"`
+}
+
+func (t *Transport) route(req *http.Request) (int, any) {
+	ctx := req.Context()
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	post := req.Method == http.MethodPost
+
+	switch {
+	case path == "/health":
+		return http.StatusOK, map[string]string{"status": "healthy", "mode": "mock"}
+
+	case path == "/search" && post:
+		var in clients.SearchRequest
+		if err := decode(req, &in); err != nil {
+			return invalid(err)
+		}
+		resp, _ := t.RAG.Search(ctx, in)
+		results := make([]searchResult, len(resp.Results))
+		for i, r := range resp.Results {
+			results[i] = searchResult{ResourceResult: r, ResourceID: r.ID}
+		}
+		return http.StatusOK, map[string]any{
+			"results":     results,
+			"query":       resp.Query,
+			"total_found": resp.TotalFound,
+			"reranked":    resp.Reranked,
+		}
+
+	case path == "/ingest/resources" && post:
+		var in clients.IngestRequestPayload
+		if err := decode(req, &in); err != nil {
+			return invalid(err)
+		}
+		ids, _ := t.RAG.IngestResources(ctx, in.Resources)
+		return http.StatusOK, clients.IngestResponse{
+			Success:     len(ids),
+			Total:       len(in.Resources),
+			Errors:      []string{},
+			ResourceIDs: ids,
+		}
+
+	case path == "/plan" && post:
+		var in models.PlanLearningPathRequest
+		if err := decode(req, &in); err != nil {
+			return invalid(err)
+		}
+		return reply(t.Planner.CreatePlan(ctx, in))
+
+	case path == "/decompose" && post:
+		var in models.DecomposeGoalRequest
+		if err := decode(req, &in); err != nil {
+			return invalid(err)
+		}
+		return reply(t.Planner.DecomposeGoal(ctx, in))
+
+	case strings.HasPrefix(path, "/plan/") && strings.HasSuffix(path, "/replan") && post:
+		planID, err := uuid.Parse(strings.TrimSuffix(strings.TrimPrefix(path, "/plan/"), "/replan"))
+		if err != nil {
+			return invalid(err)
+		}
+		var in models.ReplanRequest
+		if err := decode(req, &in); err != nil {
+			return invalid(err)
+		}
+		return reply(t.Planner.Replan(ctx, planID, in))
+
+	case strings.HasPrefix(path, "/plan/") && req.Method == http.MethodGet:
+		planID, err := uuid.Parse(strings.TrimPrefix(path, "/plan/"))
+		if err != nil {
+			return invalid(err)
+		}
+		return reply(t.Planner.GetPlan(ctx, planID))
+
+	case strings.HasPrefix(path, "/plan/") && req.Method == http.MethodPatch:
+		planID, err := uuid.Parse(strings.TrimPrefix(path, "/plan/"))
+		if err != nil {
+			return invalid(err)
+		}
+		var in struct {
+			Status string `json:"status"`
+		}
+		if err := decode(req, &in); err != nil {
+			return invalid(err)
+		}
+		if err := t.Planner.SetPlanStatus(ctx, planID, in.Status); err != nil {
+			return failed(err)
+		}
+		return http.StatusOK, map[string]any{"plan_id": planID, "status": in.Status}
+
+	case strings.HasPrefix(path, "/plan/") && req.Method == http.MethodDelete:
+		planID, err := uuid.Parse(strings.TrimPrefix(path, "/plan/"))
+		if err != nil {
+			return invalid(err)
+		}
+		if err := t.Planner.DeletePlan(ctx, planID); err != nil {
+			return failed(err)
+		}
+		return http.StatusNoContent, nil
+
+	case strings.HasPrefix(path, "/user/") && strings.HasSuffix(path, "/plans/transfer") && post:
+		userID := strings.TrimSuffix(strings.TrimPrefix(path, "/user/"), "/plans/transfer")
+		var in struct {
+			ToUserID string `json:"to_user_id"`
+		}
+		if err := decode(req, &in); err != nil {
+			return invalid(err)
+		}
+		transferred, _ := t.Planner.TransferPlans(ctx, userID, in.ToUserID)
+		return http.StatusOK, map[string]any{"user_id": in.ToUserID, "transferred": transferred}
+
+	case strings.HasPrefix(path, "/user/") && strings.HasSuffix(path, "/plans"):
+		userID := strings.TrimSuffix(strings.TrimPrefix(path, "/user/"), "/plans")
+		plans, _ := t.Planner.GetUserPlans(ctx, userID)
+		return http.StatusOK, map[string]any{"user_id": userID, "plans": plans, "total": len(plans)}
+
+	case path == "/replan" && post:
+		var in struct {
+			PlanID string `json:"plan_id"`
+			models.ReplanRequest
+		}
+		if err := decode(req, &in); err != nil {
+			return invalid(err)
+		}
+		planID, err := uuid.Parse(in.PlanID)
+		if err != nil {
+			return invalid(err)
+		}
+		plan, err := t.Planner.Replan(ctx, planID, in.ReplanRequest)
+		if err != nil {
+			return failed(err)
+		}
+		return http.StatusOK, map[string]any{
+			"plan_id":            plan.PlanID,
+			"updated_milestones": plan.Milestones,
+			"total_hours":        plan.TotalHours,
+			"estimated_weeks":    plan.EstimatedWeeks,
+			"changes_made":       []string{fmt.Sprintf("Removed %d completed resources", len(in.CompletedResources))},
+		}
+
+	case path == "/generate" && post:
+		var in models.GenerateQuizRequest
+		if err := decode(req, &in); err != nil {
+			return invalid(err)
+		}
+		return reply(t.Quiz.GenerateQuiz(ctx, in))
+
+	case path == "/submit" && post:
+		var in clients.QuizSubmitRequest
+		if err := decode(req, &in); err != nil {
+			return invalid(err)
+		}
+		return reply(t.Quiz.SubmitQuiz(ctx, in))
+
+	case path == "/retake" && post:
+		var in models.RetakeQuizRequest
+		if err := decode(req, &in); err != nil {
+			return invalid(err)
+		}
+		return reply(t.Quiz.RetakeQuiz(ctx, in))
+
+	case path == "/questions" && req.Method == http.MethodGet:
+		query := req.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		questions, err := t.Quiz.ListQuestions(ctx, models.QuestionBankFilter{
+			ResourceID: query.Get("resource_id"),
+			Skill:      query.Get("skill"),
+			Limit:      limit,
+		})
+		if err != nil {
+			return failed(err)
+		}
+		return http.StatusOK, map[string]any{"questions": questions}
+
+	case path == "/compose" && post:
+		var in models.ComposeQuizRequest
+		if err := decode(req, &in); err != nil {
+			return invalid(err)
+		}
+		return reply(t.Quiz.ComposeQuiz(ctx, in))
+
+	case path == "/grade" && post:
+		var in models.GradeAnswerRequest
+		if err := decode(req, &in); err != nil {
+			return invalid(err)
+		}
+		return reply(t.Quiz.GradeAnswer(ctx, in))
+
+	case path == "/calibrate" && post:
+		var in models.CalibrationRequest
+		if err := decode(req, &in); err != nil {
+			return invalid(err)
+		}
+		if err := t.Quiz.Calibrate(ctx, in); err != nil {
+			return failed(err)
+		}
+		return http.StatusOK, map[string]string{"status": "ok"}
+	}
+	return http.StatusNotFound, detail{Detail: "Not Found"}
+}
+
+// searchResult carries the ID under both the Python service's resource_id
+// and the typed client's id
+type searchResult struct {
+	models.ResourceResult
+	ResourceID uuid.UUID `json:"resource_id"`
+}
+
+func decode(req *http.Request, v any) error {
+	if req.Body == nil {
+		return errors.New("missing request body")
+	}
+	return json.NewDecoder(req.Body).Decode(v)
+}
+
+func reply[T any](v T, err error) (int, any) {
+	if err != nil {
+		return failed(err)
+	}
+	return http.StatusOK, v
+}
+
+func invalid(err error) (int, any) {
+	return http.StatusUnprocessableEntity, detail{Detail: err.Error()}
+}
+
+func failed(err error) (int, any) {
+	if errors.Is(err, errNotFound) {
+		return http.StatusNotFound, detail{Detail: err.Error()}
+	}
+	return http.StatusBadRequest, detail{Detail: err.Error()}
+}
@@ -0,0 +1,10 @@
+// Package mocks holds moq mocks of the orchestrator and backend clients,
+// for tests that program individual calls and inspect their arguments.
+// Regenerate them with `go generate ./internal/mocks` after changing an
+// interface.
+package mocks
+
+//go:generate go run github.com/matryer/moq@v0.3.4 -out orchestrator.go -pkg mocks ../orchestrator Orchestrator
+//go:generate go run github.com/matryer/moq@v0.3.4 -out rag_client.go -pkg mocks ../clients RAGClient
+//go:generate go run github.com/matryer/moq@v0.3.4 -out planner_client.go -pkg mocks ../clients PlannerClient
+//go:generate go run github.com/matryer/moq@v0.3.4 -out quiz_client.go -pkg mocks ../clients QuizClient
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+	// Preferences.Timezone must resolve even where the host has no zoneinfo
+	_ "time/tzdata"
 
 	"github.com/google/uuid"
 )
@@ -42,8 +44,16 @@ type ResourceItem struct {
 	Skills       []string  `json:"skills"`
 	WhyIncluded  string    `json:"why_included"`
 	Order        int       `json:"order"`
+	License      *string   `json:"license,omitempty"`
+	Cost         string    `json:"cost,omitempty"` // CostFree or CostPaid, from the license
 }
 
+// Whether a resource costs money to access
+const (
+	CostFree = "free"
+	CostPaid = "paid"
+)
+
 type Milestone struct {
 	MilestoneID    uuid.UUID      `json:"milestone_id"`
 	Title          string         `json:"title"`
@@ -62,18 +72,33 @@ type LearningPath struct {
 	Milestones      []Milestone `json:"milestones"`
 	PrerequisitesMet bool        `json:"prerequisites_met"`
 	Reasoning       string      `json:"reasoning"`
+	Status          string      `json:"status,omitempty"` // PlanActive or PlanDraft; empty from planners predating it
 	CreatedAt       time.Time   `json:"created_at"`
 	UpdatedAt       time.Time   `json:"updated_at"`
 }
 
+// Plan statuses. A draft is kept after the quizzes generated with it failed.
+const (
+	PlanActive = "active"
+	PlanDraft  = "draft"
+)
+
 type QuizOption struct {
 	OptionID  string `json:"option_id"`
 	Text      string `json:"text"`
 	IsCorrect bool   `json:"is_correct"` // Hidden from external responses, used internally for grading
 }
 
+// Question types. Short-answer questions have no options; their answers
+// are graded asynchronously by the quiz service.
+const (
+	QuestionMultipleChoice = "multiple_choice"
+	QuestionShortAnswer    = "short_answer"
+)
+
 type QuizQuestion struct {
 	QuestionID       string       `json:"question_id"`
+	QuestionType     string       `json:"question_type,omitempty"` // Empty means multiple_choice
 	QuestionText     string       `json:"question_text"`
 	Options          []QuizOption `json:"options"`
 	Explanation      string       `json:"explanation"`
@@ -82,26 +107,154 @@ type QuizQuestion struct {
 }
 
 type Quiz struct {
-	QuizID        string         `json:"quiz_id"`
-	Title         *string        `json:"title,omitempty"`
-	Questions     []QuizQuestion `json:"questions"`
+	QuizID         string         `json:"quiz_id"`
+	Title          *string        `json:"title,omitempty"`
+	Questions      []QuizQuestion `json:"questions"`
 	TotalQuestions int            `json:"total_questions"`
-	CreatedAt     time.Time      `json:"created_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	VariantOf      string         `json:"variant_of,omitempty"` // Quiz this one was regenerated from for a retake
 }
 
 type LearningPathWithQuiz struct {
-	LearningPath LearningPath `json:"learning_path"`
-	Quiz         *Quiz        `json:"quiz,omitempty"`
+	LearningPath     LearningPath    `json:"learning_path"`
+	Quiz             *Quiz           `json:"quiz,omitempty"`
+	MilestoneQuizzes []MilestoneQuiz `json:"milestone_quizzes,omitempty"`
+}
+
+// MilestoneQuiz is a quiz covering the resources of a single milestone
+type MilestoneQuiz struct {
+	MilestoneID uuid.UUID `json:"milestone_id"`
+	Quiz        *Quiz     `json:"quiz"`
+}
+
+// ============================================================================
+// Public (API v2) Models
+// Quizzes served to learners omit the answer key; correctness and
+// explanations are only revealed on submission.
+// ============================================================================
+
+type PublicQuizOption struct {
+	OptionID string `json:"option_id"`
+	Text     string `json:"text"`
+}
+
+type PublicQuizQuestion struct {
+	QuestionID       string             `json:"question_id"`
+	QuestionType     string             `json:"question_type,omitempty"`
+	QuestionText     string             `json:"question_text"`
+	Options          []PublicQuizOption `json:"options"`
+	SourceResourceID string             `json:"source_resource_id"`
+	Citation         string             `json:"citation"`
+}
+
+type PublicQuiz struct {
+	QuizID         string               `json:"quiz_id"`
+	Title          *string              `json:"title,omitempty"`
+	Questions      []PublicQuizQuestion `json:"questions"`
+	TotalQuestions int                  `json:"total_questions"`
+	CreatedAt      time.Time            `json:"created_at"`
+	VariantOf      string               `json:"variant_of,omitempty"`
+}
+
+type PublicLearningPathWithQuiz struct {
+	LearningPath     LearningPath          `json:"learning_path"`
+	Quiz             *PublicQuiz           `json:"quiz,omitempty"`
+	MilestoneQuizzes []PublicMilestoneQuiz `json:"milestone_quizzes,omitempty"`
+}
+
+type PublicMilestoneQuiz struct {
+	MilestoneID uuid.UUID   `json:"milestone_id"`
+	Quiz        *PublicQuiz `json:"quiz"`
+}
+
+// NewPublicLearningPathWithQuiz strips the answer keys from every quiz.
+func NewPublicLearningPathWithQuiz(r *LearningPathWithQuiz) PublicLearningPathWithQuiz {
+	public := PublicLearningPathWithQuiz{
+		LearningPath: r.LearningPath,
+		Quiz:         NewPublicQuiz(r.Quiz),
+	}
+	for _, mq := range r.MilestoneQuizzes {
+		public.MilestoneQuizzes = append(public.MilestoneQuizzes, PublicMilestoneQuiz{
+			MilestoneID: mq.MilestoneID,
+			Quiz:        NewPublicQuiz(mq.Quiz),
+		})
+	}
+	return public
+}
+
+// NewPublicQuiz strips the answer key from a quiz. It returns nil for nil.
+func NewPublicQuiz(q *Quiz) *PublicQuiz {
+	if q == nil {
+		return nil
+	}
+
+	questions := make([]PublicQuizQuestion, len(q.Questions))
+	for i, question := range q.Questions {
+		questions[i] = NewPublicQuestion(question)
+	}
+
+	return &PublicQuiz{
+		QuizID:         q.QuizID,
+		Title:          q.Title,
+		Questions:      questions,
+		TotalQuestions: q.TotalQuestions,
+		CreatedAt:      q.CreatedAt,
+		VariantOf:      q.VariantOf,
+	}
+}
+
+// NewPublicQuestion strips the answer from a question
+func NewPublicQuestion(question QuizQuestion) PublicQuizQuestion {
+	options := make([]PublicQuizOption, len(question.Options))
+	for i, option := range question.Options {
+		options[i] = PublicQuizOption{OptionID: option.OptionID, Text: option.Text}
+	}
+	return PublicQuizQuestion{
+		QuestionID:       question.QuestionID,
+		QuestionType:     question.QuestionType,
+		QuestionText:     question.QuestionText,
+		Options:          options,
+		SourceResourceID: question.SourceResourceID,
+		Citation:         question.Citation,
+	}
 }
 
 // QuestionResult used in QuizSubmitResponse
 type QuestionResult struct {
-	QuestionID      string `json:"question_id"`
-	Correct         bool   `json:"correct"`
+	QuestionID       string `json:"question_id"`
+	Correct          bool   `json:"correct"`
 	SelectedOptionID string `json:"selected_option_id"`
-	CorrectOptionID string `json:"correct_option_id"`
-	Explanation     string `json:"explanation"`
-	Citation        string `json:"citation"`
+	CorrectOptionID  string `json:"correct_option_id"`
+	Explanation      string `json:"explanation"`
+	Citation         string `json:"citation"`
+	SourceResourceID string `json:"source_resource_id,omitempty"`
+	QuestionType     string `json:"question_type,omitempty"`
+	AnswerText       string `json:"answer_text,omitempty"` // Short answers only
+	Status           string `json:"status,omitempty"`      // pending until a short answer is graded
+	Feedback         string `json:"feedback,omitempty"`    // Grader's comments on a short answer
+}
+
+// Result statuses
+const (
+	ResultPending = "pending"
+	ResultGraded  = "graded"
+)
+
+// GradeAnswerRequest asks the quiz service to grade one short answer
+// against the question's reference answer
+type GradeAnswerRequest struct {
+	QuizID     string `json:"quiz_id"`
+	QuestionID string `json:"question_id"`
+	AnswerText string `json:"answer_text"`
+}
+
+// GradeAnswerResponse is the quiz service's verdict on a short answer
+type GradeAnswerResponse struct {
+	QuizID     string  `json:"quiz_id"`
+	QuestionID string  `json:"question_id"`
+	Correct    bool    `json:"correct"`
+	Score      float64 `json:"score"` // 0-1 credit for partially correct answers
+	Feedback   string  `json:"feedback"`
 }
 
 // ============================================================================
@@ -109,12 +262,37 @@ type QuestionResult struct {
 // ============================================================================
 
 type PlanLearningPathRequest struct {
-	Goal            string            `json:"goal"`
-	CurrentSkills   []string          `json:"current_skills"`
-	TimeBudgetHours int               `json:"time_budget_hours"`
-	HoursPerWeek    int               `json:"hours_per_week"`
-	Preferences     map[string]string `json:"preferences"` // e.g., media types, providers
-	UserID          *string           `json:"user_id,omitempty"`
+	Goal            string      `json:"goal"`
+	CurrentSkills   []string    `json:"current_skills"`
+	TimeBudgetHours int         `json:"time_budget_hours"`
+	HoursPerWeek    int         `json:"hours_per_week"`
+	Preferences     Preferences `json:"preferences"`
+	UserID          *string     `json:"user_id,omitempty"`
+	Language        string      `json:"language,omitempty"` // BCP 47 tag to write the plan in
+	// ResourceIDs restricts the plan to these resources, e.g. ones just
+	// ingested; empty searches the whole corpus
+	ResourceIDs []string `json:"resource_ids,omitempty"`
+	TenantID    string   `json:"tenant_id,omitempty"` // Whose ingested content the planner may search besides the global corpus
+	// SubGoals narrows a broad goal to the parts the learner picked from
+	// its decomposition
+	SubGoals []string `json:"sub_goals,omitempty"`
+}
+
+// Preferences shape a learning plan. They are validated when a request
+// arrives and passed to the planner as they are.
+type Preferences struct {
+	MediaTypes          []string   `json:"media_types,omitempty" binding:"omitempty,max=10,dive,min=1,max=32"` // e.g. video, article
+	Providers           []string   `json:"providers,omitempty" binding:"omitempty,max=20,dive,min=1,max=64"`
+	MaxResourceDuration int        `json:"max_resource_duration,omitempty" binding:"omitempty,gt=0"`        // Minutes per resource
+	Language            string     `json:"language,omitempty"`                                              // BCP 47 tag; see PlanLearningPathRequest.Language
+	Budget              *float64   `json:"budget,omitempty" binding:"omitempty,gte=0"`                      // USD to spend on paid resources
+	Licenses            []string   `json:"licenses,omitempty" binding:"omitempty,max=20,dive,min=1,max=64"` // Only resources under these, e.g. CC-BY
+	FreeOnly            bool       `json:"free_only,omitempty"`                                             // Exclude resources under paid licenses
+	Diversity           *Diversity `json:"diversity,omitempty"`
+	// When the learner studies
+	Timezone   string   `json:"timezone,omitempty" binding:"omitempty,timezone"`                                 // IANA name, e.g. Europe/Berlin
+	StudyDays  []string `json:"study_days,omitempty" binding:"omitempty,dive,oneof=mon tue wed thu fri sat sun"` // Preferred days
+	StudyTimes []string `json:"study_times,omitempty" binding:"omitempty,dive,datetime=15:04"`                   // Session start times, local
 }
 
 // GenerateQuizRequest represents the request to generate a quiz.
@@ -123,6 +301,80 @@ type GenerateQuizRequest struct {
 	NumQuestions int      `json:"num_questions"`
 	Difficulty   string   `json:"difficulty"`
 	UserID       *string  `json:"user_id,omitempty"`
+	Language     string   `json:"language,omitempty"`               // BCP 47 tag to write the questions in
+	TenantID     string   `json:"tenant_id,omitempty"`              // Selects the calibration the quiz service applies
+	ShortAnswers int      `json:"short_answer_questions,omitempty"` // How many of NumQuestions are short-answer
+}
+
+// RetakeQuizRequest asks the quiz service for a variant of a quiz covering
+// the same material: questions paraphrased and options shuffled
+type RetakeQuizRequest struct {
+	QuizID   string `json:"quiz_id"`
+	Language string `json:"language,omitempty"` // BCP 47 tag to write the questions in
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+// RemediationRequest asks for a plan to be replanned with review of the
+// resources behind a learner's missed quiz questions
+type RemediationRequest struct {
+	PlanID          uuid.UUID
+	MissedResources []string // Most missed first; resources outside the plan are ignored
+	MaxResources    int      // Review resources at most; 0 for all
+}
+
+// Remediation is a plan replanned with a review milestone
+type Remediation struct {
+	Plan            *LearningPath `json:"plan"`
+	ReviewResources []uuid.UUID   `json:"review_resources"`
+	WeakSkills      []string      `json:"weak_skills"`
+}
+
+// BankQuestion is a previously generated question, without its answer,
+// as found in the question bank
+type BankQuestion struct {
+	QuizID string `json:"quiz_id"` // Quiz the question was generated for
+	PublicQuizQuestion
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// QuestionBankFilter narrows a question bank listing
+type QuestionBankFilter struct {
+	ResourceID string
+	Skill      string // Skill slug or ID of the question's resource
+	Limit      int
+}
+
+// QuestionRef names a question of the bank
+type QuestionRef struct {
+	QuizID     string `json:"quiz_id" binding:"required"`
+	QuestionID string `json:"question_id" binding:"required"`
+}
+
+// ComposeQuizRequest asks the quiz service to assemble a quiz from bank
+// questions, in the given order, without generating any
+type ComposeQuizRequest struct {
+	Title     string        `json:"title,omitempty"`
+	Questions []QuestionRef `json:"questions"`
+	TenantID  string        `json:"tenant_id,omitempty"`
+}
+
+// QuestionCalibration is how often a quiz question has been answered
+// correctly within a tenant
+type QuestionCalibration struct {
+	QuizID      string  `json:"quiz_id"`
+	QuestionID  string  `json:"question_id"`
+	ResourceID  string  `json:"resource_id,omitempty"`
+	Answered    int64   `json:"answered"`
+	Correct     int64   `json:"correct"`
+	CorrectRate float64 `json:"correct_rate"`
+	Difficulty  string  `json:"difficulty,omitempty"` // easy, medium or hard once enough answers are in
+}
+
+// CalibrationRequest reports a tenant's question statistics to the quiz
+// service. Counts are totals, not increments.
+type CalibrationRequest struct {
+	TenantID  string                `json:"tenant_id"`
+	Questions []QuestionCalibration `json:"questions"`
 }
 
 // IngestRequest represents the request to ingest content URLs.
@@ -130,13 +382,88 @@ type IngestRequest struct {
 	URLs []string `json:"urls" binding:"required,min=1"`
 }
 
+// IngestDocumentRequest is an uploaded document to index. URL is where the
+// original is stored; Content is its extracted text.
+type IngestDocumentRequest struct {
+	Title     string
+	URL       string
+	MediaType string
+	Content   string
+}
+
 type OrchestrateFullFlowRequest struct {
 	PlanLearningPathRequest
 	GenerateQuiz  bool `json:"generate_quiz"`
+	// QuizPerMilestone generates one quiz per milestone instead of a single
+	// quiz over the whole path
+	QuizPerMilestone bool `json:"quiz_per_milestone"`
 	NumQuestions  int  `json:"num_questions"`
 	QuizDifficulty string `json:"quiz_difficulty"`
 }
 
+// Diversity caps how many resources may share a provider or media type;
+// 0 leaves a cap to the tenant or gateway default
+type Diversity struct {
+	MaxPerProvider  int `json:"max_per_provider,omitempty" binding:"omitempty,gt=0"`
+	MaxPerMediaType int `json:"max_per_media_type,omitempty" binding:"omitempty,gt=0"`
+}
+
+// Active reports whether any cap applies
+func (d Diversity) Active() bool {
+	return d.MaxPerProvider > 0 || d.MaxPerMediaType > 0
+}
+
+// PlanEstimate previews generating a plan before the user commits to it
+type PlanEstimate struct {
+	ResourcesConsidered int     `json:"resources_considered"`
+	ResourcesSelected   int     `json:"resources_selected"` // Fitting the time budget
+	ContentHours        float64 `json:"content_hours"`      // Of the selected resources
+	Milestones          int     `json:"milestones"`
+	EstimatedWeeks      int     `json:"estimated_weeks"`
+	QuizCalls           int     `json:"quiz_calls"`
+	ExpectedSeconds     float64 `json:"expected_generation_seconds"`
+	EstimatedCostUSD    float64 `json:"estimated_cost_usd"`
+}
+
+// ReplanRequest adjusts a plan to the learner's progress. It mirrors the
+// Python Planner service's ReplanRequest.
+type ReplanRequest struct {
+	CompletedResources []uuid.UUID `json:"completed_resources"`
+	TimeSpentHours     float64     `json:"time_spent_hours"`
+	RemainingTimeHours *float64    `json:"remaining_time_hours,omitempty"`
+	Feedback           *string     `json:"feedback,omitempty"`
+
+	// Remediation: review ReviewResources in a milestone inserted after
+	// ReviewAfter, before the learner advances
+	ReviewResources []uuid.UUID `json:"review_resources,omitempty"`
+	WeakSkills      []string    `json:"weak_skills,omitempty"`
+	ReviewAfter     *uuid.UUID  `json:"review_after_milestone_id,omitempty"`
+}
+
+// DecomposeGoalRequest asks the planner to break a broad goal into sub-goals
+type DecomposeGoalRequest struct {
+	Goal          string   `json:"goal"`
+	CurrentSkills []string `json:"current_skills,omitempty"`
+	MaxSubGoals   int      `json:"max_sub_goals,omitempty"`
+	Language      string   `json:"language,omitempty"` // BCP 47 tag to write the sub-goals in
+}
+
+// SubGoal is one suggested part of a broad goal
+type SubGoal struct {
+	Title          string   `json:"title"`
+	Description    string   `json:"description"`
+	EstimatedHours float64  `json:"estimated_hours"`
+	Skills         []string `json:"skills,omitempty"`
+	Order          int      `json:"order"`
+}
+
+// GoalDecomposition is a goal broken into sub-goals, in learning order
+type GoalDecomposition struct {
+	Goal                string    `json:"goal"`
+	SubGoals            []SubGoal `json:"sub_goals"`
+	TotalEstimatedHours float64   `json:"total_estimated_hours"`
+}
+
 type OrchestrateFullFlowResponse struct {
 	LearningPath *LearningPath `json:"learning_path"`
 	Quiz         *Quiz         `json:"quiz,omitempty"`
@@ -0,0 +1,201 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+)
+
+// Violation is a screened input that breaks content policy
+type Violation struct {
+	Field    string `json:"field"`    // e.g. goal, urls[2]
+	Category string `json:"category"` // blocked_term, blocked_domain or a moderation API category
+	Message  string `json:"message"`
+}
+
+// Moderator screens plan goals and ingestion URLs against the configured
+// blocklists and, optionally, an external moderation API
+type Moderator struct {
+	cfg      config.ModerationConfig
+	client   *http.Client
+	defaults policy
+	tenants  map[string]policy
+}
+
+// New creates a moderator; a disabled config lets everything through
+func New(cfg config.ModerationConfig) *Moderator {
+	m := &Moderator{
+		cfg:      cfg,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		defaults: newPolicy(cfg, config.ModerationOverride{}),
+		tenants:  make(map[string]policy, len(cfg.Tenants)),
+	}
+	for tenantID, override := range cfg.Tenants {
+		m.tenants[tenantID] = newPolicy(cfg, override)
+	}
+	return m
+}
+
+// policy is the effective configuration for one tenant
+type policy struct {
+	enabled        bool
+	terms          []*regexp.Regexp
+	blockedDomains []string
+	allowedDomains []string
+	useAPI         bool
+}
+
+// newPolicy applies a tenant's override to the global settings
+func newPolicy(cfg config.ModerationConfig, override config.ModerationOverride) policy {
+	p := policy{
+		enabled:        cfg.Enabled,
+		blockedDomains: append(append([]string(nil), cfg.BlockedDomains...), override.BlockedDomains...),
+		allowedDomains: override.AllowedDomains,
+		useAPI:         cfg.APIURL != "" && !override.SkipAPI,
+	}
+	if override.Enabled != nil {
+		p.enabled = *override.Enabled
+	}
+	for _, term := range append(append([]string(nil), cfg.BlockedTerms...), override.BlockedTerms...) {
+		if term = strings.TrimSpace(term); term != "" {
+			// Whole words only, so "class" doesn't block "classic"
+			p.terms = append(p.terms, regexp.MustCompile(`(?i)\b`+regexp.QuoteMeta(term)+`\b`))
+		}
+	}
+	return p
+}
+
+func (m *Moderator) policyFor(tenantID string) policy {
+	if p, ok := m.tenants[tenantID]; ok {
+		return p
+	}
+	return m.defaults
+}
+
+// CheckGoal screens a learning goal
+func (m *Moderator) CheckGoal(ctx context.Context, tenantID, goal string) ([]Violation, error) {
+	p := m.policyFor(tenantID)
+	if !p.enabled {
+		return nil, nil
+	}
+	if violation, ok := p.checkText("goal", goal); ok {
+		return []Violation{violation}, nil
+	}
+	return m.checkAPI(ctx, p, "goal", goal)
+}
+
+// CheckURLs screens URLs submitted for ingestion
+func (m *Moderator) CheckURLs(ctx context.Context, tenantID string, urls []string) ([]Violation, error) {
+	p := m.policyFor(tenantID)
+	if !p.enabled {
+		return nil, nil
+	}
+	var violations []Violation
+	for i, raw := range urls {
+		field := fmt.Sprintf("urls[%d]", i)
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			continue // Left for ingestion to reject
+		}
+		host := strings.ToLower(u.Hostname())
+		if matchDomain(host, p.allowedDomains) {
+			continue
+		}
+		if matchDomain(host, p.blockedDomains) {
+			violations = append(violations, Violation{Field: field, Category: "blocked_domain", Message: "links to a blocked site"})
+			continue
+		}
+		if violation, ok := p.checkText(field, raw); ok {
+			violations = append(violations, violation)
+		}
+	}
+	return violations, nil
+}
+
+func (p policy) checkText(field, text string) (Violation, bool) {
+	for _, term := range p.terms {
+		if term.MatchString(text) {
+			return Violation{Field: field, Category: "blocked_term", Message: "contains a blocked term"}, true
+		}
+	}
+	return Violation{}, false
+}
+
+// matchDomain reports whether host is one of domains or a subdomain of one
+func matchDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "."))
+		if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAPI asks the moderation API about text. The API takes
+// {"input": text} and answers {"flagged": bool, "categories": [...]}.
+// When it cannot be reached the text passes unless FailClosed is set.
+func (m *Moderator) checkAPI(ctx context.Context, p policy, field, text string) ([]Violation, error) {
+	if !p.useAPI {
+		return nil, nil
+	}
+	result, err := m.callAPI(ctx, text)
+	if err != nil {
+		if m.cfg.FailClosed {
+			return nil, err
+		}
+		log.Printf("moderation: API unavailable, allowing input: %v", err)
+		return nil, nil
+	}
+	if !result.Flagged {
+		return nil, nil
+	}
+	category := "flagged"
+	if len(result.Categories) > 0 {
+		category = result.Categories[0]
+	}
+	return []Violation{{Field: field, Category: category, Message: "was flagged by content moderation"}}, nil
+}
+
+type apiResult struct {
+	Flagged    bool     `json:"flagged"`
+	Categories []string `json:"categories"`
+}
+
+func (m *Moderator) callAPI(ctx context.Context, text string) (*apiResult, error) {
+	payload, err := json.Marshal(map[string]string{"input": text})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.cfg.APIURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create moderation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.cfg.APIKey)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moderation API returned %d", resp.StatusCode)
+	}
+
+	var result apiResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode moderation response: %w", err)
+	}
+	return &result, nil
+}
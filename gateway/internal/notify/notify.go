@@ -0,0 +1,216 @@
+// Package notify posts learner notifications, quiz results and milestone
+// reminders, to team chat channels. Each channel type implements Notifier;
+// new ones (Discord, Mattermost, ...) plug in with Register.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/branding"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+)
+
+// Events channels can subscribe to
+const (
+	QuizResult        = "quiz_result"
+	MilestoneReminder = "milestone_reminder"
+)
+
+// Message is a notification, rendered by each channel in its own format
+type Message struct {
+	Title string
+	Text  string
+	Facts []Fact
+	// Link opens the plan or quiz concerned; empty for none
+	Link      string
+	LinkTitle string
+	// Brand is the tenant's branding, filled in by the dispatcher
+	Brand branding.Branding
+}
+
+// Fact is a labelled value, such as a score
+type Fact struct {
+	Name  string
+	Value string
+}
+
+// Notifier posts messages to one channel
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Factory creates the notifier of a channel posting to url
+type Factory func(url string, client *http.Client) Notifier
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{
+		config.NotifyTeams: NewTeams,
+		config.NotifySlack: NewSlack,
+	}
+)
+
+// Register adds a channel type, making it configurable as a channel's type
+func Register(kind string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[kind] = factory
+}
+
+// channel is a configured notifier and what it is sent
+type channel struct {
+	kind     string
+	notifier Notifier
+	tenants  []string
+	events   []string
+}
+
+func (ch channel) wants(tenantID, event string) bool {
+	return (len(ch.tenants) == 0 || slices.Contains(ch.tenants, tenantID)) &&
+		(len(ch.events) == 0 || slices.Contains(ch.events, event))
+}
+
+// Dispatcher sends each notification to the channels that want it
+type Dispatcher struct {
+	channels  []channel
+	appURL    string
+	store     storage.KeyValue
+	tenantTTL time.Duration
+	brands    *branding.Store
+}
+
+// New creates a dispatcher for the configured channels, branding messages
+// for each tenant
+func New(cfg config.NotificationsConfig, transport http.RoundTripper, store storage.KeyValue, brands *branding.Store) (*Dispatcher, error) {
+	client := &http.Client{Transport: transport, Timeout: cfg.Timeout}
+	d := &Dispatcher{appURL: strings.TrimSuffix(cfg.AppURL, "/"), store: store, tenantTTL: cfg.TenantTTL, brands: brands}
+
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	for _, ch := range cfg.Channels {
+		factory, ok := factories[ch.Type]
+		if !ok {
+			return nil, fmt.Errorf("notify: unknown channel type %q", ch.Type)
+		}
+		if ch.URL == "" {
+			return nil, fmt.Errorf("notify: %s channel has no url", ch.Type)
+		}
+		for _, event := range ch.Events {
+			if event != QuizResult && event != MilestoneReminder {
+				return nil, fmt.Errorf("notify: unknown event %q", event)
+			}
+		}
+		d.channels = append(d.channels, channel{kind: ch.Type, notifier: factory(ch.URL, client), tenants: ch.Tenants, events: ch.Events})
+	}
+	return d, nil
+}
+
+// Enabled reports whether any channel is configured
+func (d *Dispatcher) Enabled() bool {
+	return d != nil && len(d.channels) > 0
+}
+
+// Wants reports whether any channel takes event for the tenant's learners
+func (d *Dispatcher) Wants(tenantID, event string) bool {
+	if !d.Enabled() {
+		return false
+	}
+	for _, ch := range d.channels {
+		if ch.wants(tenantID, event) {
+			return true
+		}
+	}
+	return false
+}
+
+// Send posts msg, in the tenant's branding, to every channel that takes
+// event for the tenant's learners. All channels are tried; their failures
+// are returned together.
+func (d *Dispatcher) Send(ctx context.Context, tenantID, event string, msg Message) error {
+	if !d.Wants(tenantID, event) {
+		return nil
+	}
+	brand, err := d.brands.Get(ctx, tenantID)
+	if err != nil {
+		log.Printf("notify: failed to load branding of tenant %s: %v", tenantID, err)
+	}
+	msg.Brand = brand
+
+	var errs []error
+	for _, ch := range d.channels {
+		if !ch.wants(tenantID, event) {
+			continue
+		}
+		if err := ch.notifier.Notify(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch.kind, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PlanLink is the learner app's page for a plan, or empty without an app
+// URL
+func (d *Dispatcher) PlanLink(planID string) string {
+	if d.appURL == "" || planID == "" {
+		return ""
+	}
+	return d.appURL + "/plans/" + planID
+}
+
+// RememberTenant records the tenant a learner last acted in, so scheduled
+// reminders reach that tenant's channels and digests carry its branding
+func (d *Dispatcher) RememberTenant(ctx context.Context, userID, tenantID string) error {
+	if d == nil || userID == "" || tenantID == "" {
+		return nil
+	}
+	return d.store.Set(ctx, tenantKey(userID), []byte(tenantID), d.tenantTTL)
+}
+
+// Tenant returns the tenant a learner last acted in, or "" if unknown
+func (d *Dispatcher) Tenant(ctx context.Context, userID string) (string, error) {
+	data, ok, err := d.store.Get(ctx, tenantKey(userID))
+	if err != nil || !ok {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func tenantKey(userID string) string {
+	return "notify:tenant:" + userID
+}
+
+// postJSON posts a webhook payload, failing on a non-2xx answer
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook answered %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/digest"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/scheduler"
+)
+
+// reminderWindow is how recently a learner must have made progress to be
+// reminded; the rest have stepped away and aren't chased
+const reminderWindow = 14 * 24 * time.Hour
+
+// ReminderJob reminds learners active in the past two weeks of the next
+// milestone of each of their recent plans, on the channels of the tenant
+// they last made progress in
+func ReminderJob(d *Dispatcher, repos *repository.Repositories, digests *digest.Builder) scheduler.Func {
+	return func(ctx context.Context) error {
+		if !d.Enabled() {
+			return nil
+		}
+
+		now := time.Now()
+		users, err := repos.Progress.ActiveUsers(ctx, now.Add(-reminderWindow))
+		if err != nil {
+			return fmt.Errorf("failed to list active users: %w", err)
+		}
+
+		var failed int
+		for _, userID := range users {
+			tenantID, err := d.Tenant(ctx, userID)
+			if err != nil {
+				log.Printf("notify: failed to look up the tenant of %s: %v", userID, err)
+				failed++
+				continue
+			}
+			if !d.Wants(tenantID, MilestoneReminder) {
+				continue
+			}
+			summary, err := digests.Build(ctx, userID, now)
+			if err != nil {
+				log.Printf("notify: failed to build reminders for %s: %v", userID, err)
+				failed++
+				continue
+			}
+			for _, next := range summary.UpcomingMilestones {
+				if err := d.Send(ctx, tenantID, MilestoneReminder, reminder(d, userID, next)); err != nil {
+					log.Printf("notify: failed to remind %s of milestone %s: %v", userID, next.MilestoneID, err)
+					failed++
+				}
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d reminders failed", failed)
+		}
+		return nil
+	}
+}
+
+func reminder(d *Dispatcher, userID string, next digest.UpcomingMilestone) Message {
+	return Message{
+		Title: "Milestone reminder",
+		Text:  fmt.Sprintf("Next up for %s: %s", userID, next.Title),
+		Facts: []Fact{
+			{Name: "Plan", Value: next.Goal},
+			{Name: "Estimated hours", Value: fmt.Sprintf("%.1f", next.EstimatedHours)},
+		},
+		Link:      d.PlanLink(next.PlanID),
+		LinkTitle: "Open plan",
+	}
+}
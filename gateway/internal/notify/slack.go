@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/amirhf/learnpath-gateway/internal/branding"
+)
+
+// Slack posts Block Kit messages to a Slack incoming webhook
+type Slack struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlack creates a Slack notifier posting to url
+func NewSlack(url string, client *http.Client) Notifier {
+	return &Slack{url: url, client: client}
+}
+
+// slackEscaper escapes the characters Slack's mrkdwn reserves
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Notify posts msg as a header, text and fields, signed with the sender's
+// logo and name, with the title as the notification's fallback text
+func (s *Slack) Notify(ctx context.Context, msg Message) error {
+	blocks := []map[string]interface{}{
+		{"type": "header", "text": map[string]string{"type": "plain_text", "text": msg.Title}},
+	}
+	if msg.Text != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": slackEscaper.Replace(msg.Text)},
+		})
+	}
+	if len(msg.Facts) > 0 {
+		// Slack takes at most ten fields per section
+		fields := make([]map[string]string, 0, len(msg.Facts))
+		for _, fact := range msg.Facts {
+			if len(fields) == 10 {
+				break
+			}
+			fields = append(fields, map[string]string{
+				"type": "mrkdwn",
+				"text": "*" + slackEscaper.Replace(fact.Name) + "*\n" + slackEscaper.Replace(fact.Value),
+			})
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "fields": fields})
+	}
+	if msg.Link != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "actions",
+			"elements": []map[string]interface{}{{
+				"type": "button",
+				"text": map[string]string{"type": "plain_text", "text": linkTitle(msg)},
+				"url":  msg.Link,
+			}},
+		})
+	}
+
+	if brand := slackBrand(msg.Brand); brand != nil {
+		blocks = append(blocks, brand)
+	}
+
+	return postJSON(ctx, s.client, s.url, map[string]interface{}{"text": slackEscaper.Replace(msg.Title), "blocks": blocks})
+}
+
+// slackBrand is a context block with the sender's logo and name, or nil
+// without either
+func slackBrand(b branding.Branding) map[string]interface{} {
+	var elements []map[string]string
+	if b.LogoURL != "" {
+		alt := b.SenderName
+		if alt == "" {
+			alt = "Logo" // Slack requires alt text
+		}
+		elements = append(elements, map[string]string{"type": "image", "image_url": b.LogoURL, "alt_text": alt})
+	}
+	if b.SenderName != "" {
+		elements = append(elements, map[string]string{"type": "mrkdwn", "text": slackEscaper.Replace(b.SenderName)})
+	}
+	if len(elements) == 0 {
+		return nil
+	}
+	return map[string]interface{}{"type": "context", "elements": elements}
+}
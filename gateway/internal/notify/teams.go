@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+)
+
+// Teams posts Adaptive Cards to a Microsoft Teams incoming webhook, or to a
+// Power Automate workflow or bot endpoint accepting the same payload
+type Teams struct {
+	url    string
+	client *http.Client
+}
+
+// NewTeams creates a Teams notifier posting to url
+func NewTeams(url string, client *http.Client) Notifier {
+	return &Teams{url: url, client: client}
+}
+
+// Notify posts msg as an Adaptive Card
+func (t *Teams) Notify(ctx context.Context, msg Message) error {
+	var body []map[string]interface{}
+	if msg.Brand.LogoURL != "" {
+		body = append(body, map[string]interface{}{"type": "Image", "url": msg.Brand.LogoURL, "size": "Small", "altText": msg.Brand.SenderName})
+	}
+	if msg.Brand.SenderName != "" {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": msg.Brand.SenderName, "isSubtle": true, "size": "Small", "wrap": true})
+	}
+	body = append(body, map[string]interface{}{"type": "TextBlock", "text": msg.Title, "weight": "Bolder", "size": "Medium", "wrap": true})
+	if msg.Text != "" {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": msg.Text, "wrap": true})
+	}
+	if len(msg.Facts) > 0 {
+		facts := make([]map[string]string, 0, len(msg.Facts))
+		for _, fact := range msg.Facts {
+			facts = append(facts, map[string]string{"title": fact.Name, "value": fact.Value})
+		}
+		body = append(body, map[string]interface{}{"type": "FactSet", "facts": facts})
+	}
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if msg.Link != "" {
+		card["actions"] = []map[string]string{{"type": "Action.OpenUrl", "title": linkTitle(msg), "url": msg.Link}}
+	}
+
+	return postJSON(ctx, t.client, t.url, map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{
+			{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	})
+}
+
+func linkTitle(msg Message) string {
+	if msg.LinkTitle != "" {
+		return msg.LinkTitle
+	}
+	return "Open"
+}
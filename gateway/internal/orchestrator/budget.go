@@ -0,0 +1,43 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+)
+
+// stepBudget splits what is left of a request's deadline across the
+// remaining orchestration steps, in proportion to their weights (the
+// steps' client timeouts), so a slow early step cannot starve the later
+// ones. Time a step leaves unused carries over to the steps after it.
+type stepBudget struct {
+	weights []time.Duration // Weights of the steps not yet started
+}
+
+func newStepBudget(weights ...time.Duration) *stepBudget {
+	return &stepBudget{weights: weights}
+}
+
+// next returns the context for the next step. Without a deadline on ctx the
+// step is bounded only by its client's own timeout.
+func (b *stepBudget) next(ctx context.Context) (context.Context, context.CancelFunc) {
+	if len(b.weights) == 0 {
+		return context.WithCancel(ctx)
+	}
+	weight := b.weights[0]
+	b.weights = b.weights[1:]
+
+	deadline, ok := ctx.Deadline()
+	if !ok || len(b.weights) == 0 {
+		// The last step gets everything that is left
+		return context.WithCancel(ctx)
+	}
+	total := weight
+	for _, w := range b.weights {
+		total += w
+	}
+	if total <= 0 {
+		return context.WithCancel(ctx)
+	}
+	share := time.Duration(float64(time.Until(deadline)) * float64(weight) / float64(total))
+	return context.WithTimeout(ctx, share)
+}
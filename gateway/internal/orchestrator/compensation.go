@@ -0,0 +1,65 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/google/uuid"
+)
+
+// Compensation policies for a plan whose orchestration failed after the
+// planner created it
+const (
+	CompensateNone   = "none"   // Keep the plan as it is
+	CompensateDelete = "delete" // Delete the plan
+	CompensateDraft  = "draft"  // Keep the plan, marked models.PlanDraft
+)
+
+// CompensatedError is a failed orchestration whose plan was deleted or
+// marked a draft by the compensation policy.
+type CompensatedError struct {
+	PlanID uuid.UUID
+	Policy string // CompensateDelete or CompensateDraft
+	Err    error
+}
+
+func (e *CompensatedError) Error() string {
+	if e.Policy == CompensateDelete {
+		return fmt.Sprintf("%v; plan %s was deleted", e.Err, e.PlanID)
+	}
+	return fmt.Sprintf("%v; plan %s was kept as a draft", e.Err, e.PlanID)
+}
+
+func (e *CompensatedError) Unwrap() error {
+	return e.Err
+}
+
+// compensate applies the compensation policy to a plan created before err
+// ended its orchestration, so the planner isn't left with a plan nobody was
+// given. It returns err, wrapped in a CompensatedError when the plan was
+// deleted or marked a draft. If compensation itself fails the plan stays
+// as it is.
+func (s *orchestratorService) compensate(ctx context.Context, plan *models.LearningPath, err error) error {
+	policy := s.compensation.Load().Policy
+	if policy != CompensateDelete && policy != CompensateDraft {
+		return err
+	}
+
+	// The request may have timed out or been abandoned; the planner call
+	// still gets its own timeout
+	ctx = context.WithoutCancel(ctx)
+	var undoErr error
+	if policy == CompensateDelete {
+		undoErr = s.plannerClient.DeletePlan(ctx, plan.PlanID)
+	} else {
+		undoErr = s.plannerClient.SetPlanStatus(ctx, plan.PlanID, models.PlanDraft)
+	}
+	if undoErr != nil {
+		log.Printf("Failed to compensate plan %s (%s): %v", plan.PlanID, policy, undoErr)
+		return err
+	}
+	log.Printf("Compensated plan %s (%s) after: %v", plan.PlanID, policy, err)
+	return &CompensatedError{PlanID: plan.PlanID, Policy: policy, Err: err}
+}
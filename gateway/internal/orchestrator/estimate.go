@@ -0,0 +1,117 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/clients"
+	"github.com/amirhf/learnpath-gateway/internal/diversity"
+	"github.com/amirhf/learnpath-gateway/internal/features"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+)
+
+// plannerTopK is how many resources the planner retrieves for a goal
+const plannerTopK = 30
+
+// The planner breaks a goal into 3-5 milestones
+const (
+	minMilestones         = 3
+	maxMilestones         = 5
+	resourcesPerMilestone = 3
+)
+
+// Step latencies assumed until a plan has been generated
+var latencySeeds = map[string]time.Duration{
+	"search":  2 * time.Second,
+	"planner": 30 * time.Second,
+	"quiz":    20 * time.Second,
+}
+
+// stepLatency is a moving average of how long an orchestration step takes
+type stepLatency struct {
+	mu  sync.Mutex
+	avg time.Duration
+}
+
+// observe folds in one run, weighting it a fifth
+func (l *stepLatency) observe(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.avg += (d - l.avg) / 5
+}
+
+func (l *stepLatency) get() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.avg
+}
+
+func newLatencies() map[string]*stepLatency {
+	latencies := make(map[string]*stepLatency, len(latencySeeds))
+	for step, seed := range latencySeeds {
+		latencies[step] = &stepLatency{avg: seed}
+	}
+	return latencies
+}
+
+// EstimatePlan previews generating a plan for req: it runs the planner's
+// resource search and fits the results into the time budget the way the
+// planner would, without calling the planner or quiz services.
+func (s *orchestratorService) EstimatePlan(ctx context.Context, req models.OrchestrateFullFlowRequest) (*models.PlanEstimate, error) {
+	limits := models.Diversity{}
+	if d := s.diversity(ctx, req.Preferences.Diversity); d != nil {
+		limits = *d
+	}
+	resp, err := s.ragClient.Search(ctx, clients.SearchRequest{
+		Query:   planQuery(req.PlanLearningPathRequest),
+		TopK:    diversity.TopK(plannerTopK, limits),
+		Filters: s.searchFilters(req.PlanLearningPathRequest),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search RAG resources: %w", err)
+	}
+	resp.Results = diversity.Apply(resp.Results, limits, plannerTopK, func(r models.ResourceResult) (*string, *string) {
+		return r.Provider, r.MediaType
+	})
+
+	// Take resources in rank order while they fit the budget; ones without
+	// a duration count as free, as in the planner
+	budgetMin := req.TimeBudgetHours * 60
+	estimate := &models.PlanEstimate{ResourcesConsidered: len(resp.Results)}
+	contentMin := 0
+	for _, resource := range resp.Results {
+		duration := 0
+		if resource.DurationMin != nil {
+			duration = *resource.DurationMin
+		}
+		if contentMin+duration > budgetMin {
+			continue
+		}
+		contentMin += duration
+		estimate.ResourcesSelected++
+	}
+	estimate.ContentHours = math.Round(float64(contentMin)/60*10) / 10
+	if req.HoursPerWeek > 0 {
+		estimate.EstimatedWeeks = int(math.Ceil(float64(req.TimeBudgetHours) / float64(req.HoursPerWeek)))
+	}
+	if estimate.ResourcesSelected > 0 {
+		estimate.Milestones = min(max((estimate.ResourcesSelected+resourcesPerMilestone-1)/resourcesPerMilestone, minMilestones), maxMilestones, estimate.ResourcesSelected)
+	}
+
+	expected := s.latencies["search"].get() + s.latencies["planner"].get()
+	if req.GenerateQuiz && estimate.Milestones > 0 && !s.switches.Enabled(features.KillQuizGeneration) {
+		estimate.QuizCalls = 1
+		rounds := 1
+		if req.QuizPerMilestone {
+			estimate.QuizCalls = estimate.Milestones
+			parallelism := max(int(s.milestoneParallelism.Load()), 1)
+			rounds = (estimate.QuizCalls + parallelism - 1) / parallelism
+		}
+		expected += time.Duration(rounds) * s.latencies["quiz"].get()
+	}
+	estimate.ExpectedSeconds = math.Round(expected.Seconds())
+	return estimate, nil
+}
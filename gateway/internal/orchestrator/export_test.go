@@ -0,0 +1,11 @@
+package orchestrator
+
+import "github.com/amirhf/learnpath-gateway/internal/clients"
+
+// SetClients replaces an orchestrator's RAG and planner clients, e.g. with
+// mocks
+func SetClients(o Orchestrator, rag clients.RAGClient, planner clients.PlannerClient) {
+	s := o.(*orchestratorService)
+	s.ragClient = rag
+	s.plannerClient = planner
+}
@@ -0,0 +1,48 @@
+package orchestrator
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrTooManyConcurrentPlans is returned when a user already has the maximum
+// number of plan generations in flight.
+var ErrTooManyConcurrentPlans = errors.New("too many concurrent plan generations for this user")
+
+// userLimiter counts in-flight operations per user.
+type userLimiter struct {
+	limit    atomic.Int64 // 0 = unlimited
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+func newUserLimiter(limit int) *userLimiter {
+	l := &userLimiter{inFlight: map[string]int{}}
+	l.limit.Store(int64(limit))
+	return l
+}
+
+// acquire reserves a slot for userID, returning the function that frees it.
+// Anonymous requests are not limited.
+func (l *userLimiter) acquire(userID string) (func(), error) {
+	limit := int(l.limit.Load())
+	if userID == "" || limit <= 0 {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inFlight[userID] >= limit {
+		return nil, ErrTooManyConcurrentPlans
+	}
+	l.inFlight[userID]++
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.inFlight[userID]--; l.inFlight[userID] <= 0 {
+			delete(l.inFlight, userID)
+		}
+	}, nil
+}
@@ -2,10 +2,27 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/amirhf/learnpath-gateway/internal/bufpool"
 	"github.com/amirhf/learnpath-gateway/internal/clients"
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/diversity"
+	"github.com/amirhf/learnpath-gateway/internal/estimate"
+	"github.com/amirhf/learnpath-gateway/internal/features"
 	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/transcripts"
+	"github.com/amirhf/learnpath-gateway/internal/workerpool"
+	"github.com/google/uuid"
 )
 
 // ============================================================================
@@ -13,39 +30,255 @@ import (
 // ============================================================================
 
 type Orchestrator interface {
+	Search(ctx context.Context, req clients.SearchRequest) (*models.SearchResponse, error)
 	PlanLearningPath(ctx context.Context, req models.PlanLearningPathRequest) (*models.LearningPath, error)
+	GetPlan(ctx context.Context, planID uuid.UUID) (*models.LearningPath, error)
+	GetUserPlans(ctx context.Context, userID string) ([]models.LearningPath, error)
+	// TransferPlans reassigns a user's plans to another user.
+	TransferPlans(ctx context.Context, fromUserID, toUserID string) (int, error)
+	// Replan adjusts a plan to the learner's progress and saves it.
+	Replan(ctx context.Context, planID uuid.UUID, req models.ReplanRequest) (*models.LearningPath, error)
 	GenerateQuiz(ctx context.Context, req models.GenerateQuizRequest) (*models.Quiz, error)
+	// GenerateQuizzes generates several quizzes concurrently. A failed quiz
+	// has a nil entry in quizzes and its error at the same index in errs.
+	GenerateQuizzes(ctx context.Context, reqs []models.GenerateQuizRequest) (quizzes []*models.Quiz, errs []error)
+	// RetakeQuiz regenerates a quiz as a variant for a retake.
+	RetakeQuiz(ctx context.Context, req models.RetakeQuizRequest) (*models.Quiz, error)
+	// ListQuestions browses the quiz service's question bank.
+	ListQuestions(ctx context.Context, filter models.QuestionBankFilter) ([]models.BankQuestion, error)
+	// ComposeQuiz assembles a quiz from bank questions.
+	ComposeQuiz(ctx context.Context, req models.ComposeQuizRequest) (*models.Quiz, error)
+	// SubmitQuiz has the quiz service grade a submission.
+	SubmitQuiz(ctx context.Context, req clients.QuizSubmitRequest) (*clients.QuizSubmitResponse, error)
+	// CalibrateQuiz sends question statistics to the quiz service.
+	CalibrateQuiz(ctx context.Context, req models.CalibrationRequest) error
+	// GradeAnswer has the quiz service grade a short answer.
+	GradeAnswer(ctx context.Context, req models.GradeAnswerRequest) (*models.GradeAnswerResponse, error)
 	OrchestrateFullFlow(ctx context.Context, req models.OrchestrateFullFlowRequest) (*models.LearningPathWithQuiz, error)
-	IngestContent(ctx context.Context, req models.IngestRequest) error
+	// RemediatePlan replans a plan with a review milestone of the resources
+	// behind missed quiz questions.
+	RemediatePlan(ctx context.Context, req models.RemediationRequest) (*models.Remediation, error)
+	// EstimatePlan previews what OrchestrateFullFlow would do for req,
+	// calling only the RAG service.
+	EstimatePlan(ctx context.Context, req models.OrchestrateFullFlowRequest) (*models.PlanEstimate, error)
+	// DecomposeGoal has the planner suggest sub-goals of a broad goal.
+	DecomposeGoal(ctx context.Context, req models.DecomposeGoalRequest) (*models.GoalDecomposition, error)
+	IngestContent(ctx context.Context, req models.IngestRequest) ([]string, error)
+	IngestDocument(ctx context.Context, req models.IngestDocumentRequest) error
+	// Forward sends a request built by a proxy handler to the "rag" or
+	// "planner" service through its client, so balancing, failover, canary
+	// routing and hedging apply. The caller closes the response body.
+	Forward(ctx context.Context, service, method, path string, body *bufpool.Body) (*http.Response, error)
+	// ApplyConfig updates client timeouts, retries and URLs from a reloaded config.
+	ApplyConfig(cfg *config.Config)
+	// SetEndpoints replaces the replicas of a backend service ("rag",
+	// "planner" or "quiz"), e.g. from service discovery.
+	SetEndpoints(service string, urls []string)
+	// BreakerStates returns the circuit breaker state of each backend
+	// service.
+	BreakerStates() map[string]string
 }
 
-// NewOrchestrator creates a new Orchestrator instance.
-func NewOrchestrator(ragBaseURL, plannerBaseURL, quizBaseURL string) Orchestrator {
-	return &orchestratorService{
-		ragClient:    clients.NewRAGClient(ragBaseURL),
-		plannerClient: clients.NewPlannerClient(plannerBaseURL),
-		quizClient:   clients.NewQuizClient(quizBaseURL),
+// NewOrchestrator creates a new Orchestrator instance. All clients share the
+// given transport; switches turn off expensive steps at runtime.
+func NewOrchestrator(cfg *config.Config, transport http.RoundTripper, switches *features.Switches, videos *transcripts.Service, durations *estimate.Estimator) Orchestrator {
+	s := &orchestratorService{
+		switches:    switches,
+		transcripts: videos,
+		durations:   durations,
+		latencies:   newLatencies(),
+		retryBudget: clients.NewRetryBudget(cfg.Retry.BudgetRatio, cfg.Retry.BudgetBurst),
+		balancers: map[string]*clients.Balancer{
+			"rag":     clients.NewBalancer(cfg.RAGServiceURLs, cfg.LoadBalancing),
+			"planner": clients.NewBalancer(cfg.PlannerServiceURLs, cfg.LoadBalancing),
+			"quiz":    clients.NewBalancer(cfg.QuizServiceURLs, cfg.LoadBalancing),
+		},
+		breakers: map[string]*clients.CircuitBreaker{
+			"rag":     newBreaker(cfg),
+			"planner": newBreaker(cfg),
+			"quiz":    newBreaker(cfg),
+		},
 	}
+	s.milestoneParallelism.Store(int64(cfg.Concurrency.MilestoneParallelism))
+	s.timeouts.Store(&cfg.Timeouts)
+	s.licenses.Store(&cfg.Licenses)
+	s.diversityConfig.Store(&cfg.Diversity)
+	s.compensation.Store(&cfg.Compensation)
+	s.respectRobotsTxt.Store(cfg.Ingestion.RespectRobotsTxt)
+	s.planLimiter = newUserLimiter(cfg.Concurrency.PlansPerUser)
+	s.ragClient = clients.NewRAGClient(transport, s.clientOptions(cfg, "rag"))
+	s.plannerClient = clients.NewPlannerClient(transport, s.clientOptions(cfg, "planner"))
+	s.quizClient = clients.NewQuizClient(transport, s.clientOptions(cfg, "quiz"))
+	return s
+}
+
+// clientOptions derives a service's client options from configuration.
+func (s *orchestratorService) clientOptions(cfg *config.Config, service string) clients.Options {
+	opts := clients.Options{
+		Service:  service,
+		Budget:   s.retryBudget,
+		Balancer: s.balancers[service],
+		Breaker:  s.breakers[service],
+		Identity: clients.NewIdentity(cfg.InternalToken.Secret, cfg.InternalToken.TTL),
+	}
+	switch service {
+	case "rag":
+		opts.BaseURL, opts.Timeout = cfg.RAGServiceURL, cfg.Timeouts.RAG
+		opts.FallbackURL = cfg.RAGFallbackURL
+		if cfg.FeatureEnabled("hedged_search") {
+			opts.HedgeDelay = cfg.Hedging.RAGSearchDelay
+		}
+	case "planner":
+		opts.BaseURL, opts.Timeout = cfg.PlannerServiceURL, cfg.Timeouts.Planner
+		opts.FallbackURL = cfg.PlannerFallbackURL
+		opts.Canary = newCanary(cfg, cfg.Canary.PlannerURL)
+	case "quiz":
+		opts.BaseURL, opts.Timeout = cfg.QuizServiceURL, cfg.Timeouts.Quiz
+		opts.FallbackURL = cfg.QuizFallbackURL
+		opts.Canary = newCanary(cfg, cfg.Canary.QuizURL)
+	}
+
+	retry := cfg.RetryFor(service)
+	opts.Retry = clients.RetryPolicy{
+		MaxAttempts:          retry.MaxAttempts,
+		BaseWait:             retry.BaseWait,
+		MaxWait:              retry.MaxWait,
+		Jitter:               retry.Jitter,
+		RetryableMethods:     retry.RetryableMethods,
+		RetryableStatusCodes: retry.RetryableStatusCodes,
+	}
+	return opts
 }
 
 // orchestratorService implements the Orchestrator interface.
 type orchestratorService struct {
-	ragClient    clients.RAGClient
+	ragClient     clients.RAGClient
 	plannerClient clients.PlannerClient
-	quizClient   clients.QuizClient
+	quizClient    clients.QuizClient
+	retryBudget   *clients.RetryBudget
+	balancers     map[string]*clients.Balancer
+	breakers      map[string]*clients.CircuitBreaker
+	switches      *features.Switches
+	// Bounds per-milestone fan-out within a single request
+	milestoneParallelism atomic.Int64
+	// Caps concurrent full-flow orchestrations per user
+	planLimiter *userLimiter
+	// Client timeouts, used to split a request's deadline across steps
+	timeouts atomic.Pointer[config.TimeoutConfig]
+	// Licenses excluded by free-only plans
+	licenses atomic.Pointer[config.LicenseConfig]
+	// Caps on resources sharing a provider or media type
+	diversityConfig atomic.Pointer[config.DiversityConfig]
+	// What becomes of a plan whose quizzes then fail
+	compensation atomic.Pointer[config.CompensationConfig]
+	// Crawl hint passed along with ingested URLs
+	respectRobotsTxt atomic.Bool
+	// Fetches transcripts of video URLs at ingestion; nil disables it
+	transcripts *transcripts.Service
+	// Fills in durations of ingested URLs; nil disables it
+	durations *estimate.Estimator
+	// Recent durations of the search, planner and quiz steps
+	latencies map[string]*stepLatency
+}
+
+// newCanary returns the canary routing for a v2 URL, or nil when unset.
+func newCanary(cfg *config.Config, url string) *clients.Canary {
+	if url == "" {
+		return nil
+	}
+	return &clients.Canary{
+		URL:     url,
+		Percent: cfg.Canary.Percent,
+		Tenants: cfg.Canary.Tenants,
+	}
+}
+
+// newBreaker creates the circuit breaker guarding a primary backend.
+func newBreaker(cfg *config.Config) *clients.CircuitBreaker {
+	return clients.NewCircuitBreaker(cfg.CircuitBreaker.FailureThreshold, cfg.CircuitBreaker.OpenTimeout)
+}
+
+// Search queries the RAG service.
+func (s *orchestratorService) Search(ctx context.Context, req clients.SearchRequest) (*models.SearchResponse, error) {
+	resp, err := s.ragClient.Search(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	return resp, nil
 }
 
 // PlanLearningPath orchestrates the creation of a learning path.
 func (s *orchestratorService) PlanLearningPath(ctx context.Context, req models.PlanLearningPathRequest) (*models.LearningPath, error) {
+	req.UserID = attributedUser(ctx, req.UserID)
+	req.Preferences.Diversity = s.diversity(ctx, req.Preferences.Diversity)
+	if req.TenantID == "" {
+		req.TenantID = common.GetTenantID(ctx)
+	}
 	learningPath, err := s.plannerClient.CreatePlan(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create learning plan: %w", err)
 	}
+	s.annotateCosts(learningPath)
 	return learningPath, nil
 }
 
+// GetPlan fetches a learning path from the planner.
+func (s *orchestratorService) GetPlan(ctx context.Context, planID uuid.UUID) (*models.LearningPath, error) {
+	learningPath, err := s.plannerClient.GetPlan(ctx, planID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get learning plan: %w", err)
+	}
+	s.annotateCosts(learningPath)
+	return learningPath, nil
+}
+
+// GetUserPlans lists a user's learning paths. The planner returns summaries
+// without milestones.
+func (s *orchestratorService) GetUserPlans(ctx context.Context, userID string) ([]models.LearningPath, error) {
+	plans, err := s.plannerClient.GetUserPlans(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list learning plans: %w", err)
+	}
+	return plans, nil
+}
+
+// TransferPlans moves a user's plans to another user, e.g. a guest's to the
+// account they signed up for.
+func (s *orchestratorService) TransferPlans(ctx context.Context, fromUserID, toUserID string) (int, error) {
+	transferred, err := s.plannerClient.TransferPlans(ctx, fromUserID, toUserID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to transfer learning plans: %w", err)
+	}
+	return transferred, nil
+}
+
+// Replan has the planner drop completed resources from a plan and save the
+// result.
+func (s *orchestratorService) Replan(ctx context.Context, planID uuid.UUID, req models.ReplanRequest) (*models.LearningPath, error) {
+	if req.CompletedResources == nil {
+		req.CompletedResources = []uuid.UUID{} // Required by the planner
+	}
+	learningPath, err := s.plannerClient.Replan(ctx, planID, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replan learning plan: %w", err)
+	}
+	s.annotateCosts(learningPath)
+	return learningPath, nil
+}
+
+// DecomposeGoal breaks a broad goal into sub-goals the learner can pick
+// from before planning.
+func (s *orchestratorService) DecomposeGoal(ctx context.Context, req models.DecomposeGoalRequest) (*models.GoalDecomposition, error) {
+	decomposition, err := s.plannerClient.DecomposeGoal(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompose goal: %w", err)
+	}
+	return decomposition, nil
+}
+
 // GenerateQuiz orchestrates the generation of a quiz for a given learning path.
 func (s *orchestratorService) GenerateQuiz(ctx context.Context, req models.GenerateQuizRequest) (*models.Quiz, error) {
+	req.UserID = attributedUser(ctx, req.UserID)
 	generatedQuiz, err := s.quizClient.GenerateQuiz(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate quiz: %w", err)
@@ -53,24 +286,126 @@ func (s *orchestratorService) GenerateQuiz(ctx context.Context, req models.Gener
 	return generatedQuiz, nil
 }
 
+// SubmitQuiz grades a submission. Short answers come back pending.
+func (s *orchestratorService) SubmitQuiz(ctx context.Context, req clients.QuizSubmitRequest) (*clients.QuizSubmitResponse, error) {
+	graded, err := s.quizClient.SubmitQuiz(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit quiz: %w", err)
+	}
+	return graded, nil
+}
+
+// GenerateQuizzes generates several quizzes with at most
+// milestoneParallelism calls at once. One quiz failing doesn't stop the
+// others.
+func (s *orchestratorService) GenerateQuizzes(ctx context.Context, reqs []models.GenerateQuizRequest) ([]*models.Quiz, []error) {
+	quizzes := make([]*models.Quiz, len(reqs))
+	errs := make([]error, len(reqs))
+	err := workerpool.ForEach(ctx, int(s.milestoneParallelism.Load()), len(reqs), func(ctx context.Context, i int) error {
+		start := time.Now()
+		reqs[i].UserID = attributedUser(ctx, reqs[i].UserID)
+		quizzes[i], errs[i] = s.quizClient.GenerateQuiz(ctx, reqs[i])
+		if errs[i] != nil {
+			errs[i] = fmt.Errorf("failed to generate quiz: %w", errs[i])
+			return nil
+		}
+		s.latencies["quiz"].observe(time.Since(start))
+		return nil
+	})
+	if err != nil {
+		// Quizzes not started before ctx was cancelled
+		for i := range errs {
+			if quizzes[i] == nil && errs[i] == nil {
+				errs[i] = err
+			}
+		}
+	}
+	return quizzes, errs
+}
+
+// RetakeQuiz regenerates a quiz as a variant for a retake.
+func (s *orchestratorService) RetakeQuiz(ctx context.Context, req models.RetakeQuizRequest) (*models.Quiz, error) {
+	variant, err := s.quizClient.RetakeQuiz(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retake quiz: %w", err)
+	}
+	return variant, nil
+}
+
+// ListQuestions browses the quiz service's question bank.
+func (s *orchestratorService) ListQuestions(ctx context.Context, filter models.QuestionBankFilter) ([]models.BankQuestion, error) {
+	questions, err := s.quizClient.ListQuestions(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list questions: %w", err)
+	}
+	return questions, nil
+}
+
+// ComposeQuiz assembles a quiz from bank questions.
+func (s *orchestratorService) ComposeQuiz(ctx context.Context, req models.ComposeQuizRequest) (*models.Quiz, error) {
+	composed, err := s.quizClient.ComposeQuiz(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compose quiz: %w", err)
+	}
+	return composed, nil
+}
+
+// CalibrateQuiz sends question statistics to the quiz service.
+func (s *orchestratorService) CalibrateQuiz(ctx context.Context, req models.CalibrationRequest) error {
+	if err := s.quizClient.Calibrate(ctx, req); err != nil {
+		return fmt.Errorf("failed to calibrate quiz: %w", err)
+	}
+	return nil
+}
+
+// GradeAnswer has the quiz service grade a short answer.
+func (s *orchestratorService) GradeAnswer(ctx context.Context, req models.GradeAnswerRequest) (*models.GradeAnswerResponse, error) {
+	graded, err := s.quizClient.GradeAnswer(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to grade answer: %w", err)
+	}
+	return graded, nil
+}
+
 // OrchestrateFullFlow orchestrates the entire process of generating a learning path and an associated quiz.
 func (s *orchestratorService) OrchestrateFullFlow(ctx context.Context, req models.OrchestrateFullFlowRequest) (*models.LearningPathWithQuiz, error) {
+	req.UserID = attributedUser(ctx, req.UserID)
+	var userID string
+	if req.UserID != nil {
+		userID = *req.UserID
+	}
+	release, err := s.planLimiter.acquire(userID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	req.Preferences.Diversity = s.diversity(ctx, req.Preferences.Diversity)
+
+	// Share the request's deadline between the steps that will run
+	timeouts := s.timeouts.Load()
+	weights := []time.Duration{timeouts.RAG, timeouts.Planner}
+	if req.GenerateQuiz && !s.switches.Enabled(features.KillQuizGeneration) {
+		weights = append(weights, timeouts.Quiz)
+	}
+	budget := newStepBudget(weights...)
+
 	// 1. Call RAG service to get relevant resources
 	ragSearchReq := clients.SearchRequest{
-		Query: req.Goal,
-		TopK:  10, // Default for now, can be made configurable
-		Rerank: true,
+		Query:      planQuery(req.PlanLearningPathRequest),
+		TopK:       10, // Default for now, can be made configurable
+		Rerank:     !s.switches.Enabled(features.KillRerank),
 		RerankTopN: 5, // Default for now
-		Filters: &clients.SearchFilters{
-			Skills: req.CurrentSkills,
-			// TODO: Add other filters from req.Preferences if applicable
-		},
+		Filters:    s.searchFilters(req.PlanLearningPathRequest),
 	}
 
-	_, err := s.ragClient.Search(ctx, ragSearchReq)
+	stepCtx, cancel := budget.next(ctx)
+	start := time.Now()
+	_, err = s.ragClient.Search(stepCtx, ragSearchReq)
+	cancel()
 	if err != nil {
 		return nil, fmt.Errorf("failed to search RAG resources: %w", err)
 	}
+	s.latencies["search"].observe(time.Since(start))
 
 	// 2. Prepare Planner request with RAG results (if any)
 	// Currently, Planner service doesn't take RAG results directly,
@@ -83,17 +418,32 @@ func (s *orchestratorService) OrchestrateFullFlow(ctx context.Context, req model
 		HoursPerWeek:    req.HoursPerWeek,
 		Preferences:     req.Preferences,
 		UserID:          req.UserID,
+		Language:        req.Language,
+		ResourceIDs:     req.ResourceIDs,
+		TenantID:        common.GetTenantID(ctx),
+		SubGoals:        req.SubGoals,
 	}
 
 	// 3. Call Planner service to create the learning path
-	learningPath, err := s.plannerClient.CreatePlan(ctx, plannerReq)
+	stepCtx, cancel = budget.next(ctx)
+	start = time.Now()
+	learningPath, err := s.plannerClient.CreatePlan(stepCtx, plannerReq)
+	cancel()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create learning plan: %w", err)
 	}
+	s.latencies["planner"].observe(time.Since(start))
+	s.annotateCosts(learningPath)
 
 	// 4. Optionally call Quiz service to generate a quiz
 	var quiz *models.Quiz
-	if req.GenerateQuiz {
+	var milestoneQuizzes []models.MilestoneQuiz
+	if req.GenerateQuiz && req.QuizPerMilestone && !s.switches.Enabled(features.KillQuizGeneration) {
+		milestoneQuizzes, err = s.generateMilestoneQuizzes(ctx, learningPath, req)
+		if err != nil {
+			return nil, s.compensate(ctx, learningPath, err)
+		}
+	} else if req.GenerateQuiz && !s.switches.Enabled(features.KillQuizGeneration) {
 		// Extract resource IDs from the generated learning path for quiz generation
 		var resourceIDs []string
 		for _, milestone := range learningPath.Milestones {
@@ -114,27 +464,312 @@ func (s *orchestratorService) OrchestrateFullFlow(ctx context.Context, req model
 				NumQuestions: req.NumQuestions,
 				Difficulty:   req.QuizDifficulty,
 				UserID:       req.UserID,
+				Language:     req.Language,
 			}
 
+			start := time.Now()
 			generatedQuiz, err := s.quizClient.GenerateQuiz(ctx, quizReq)
-			if err != nil {
-				return nil, fmt.Errorf("failed to generate quiz: %w", err)
+			if err == nil {
+				s.latencies["quiz"].observe(time.Since(start))
+			}
+			if quizRejected(err) {
+				// The plan is still useful without its quiz
+				log.Printf("Skipping quiz for plan %s: %v", learningPath.PlanID, err)
+			} else if err != nil {
+				return nil, s.compensate(ctx, learningPath, fmt.Errorf("failed to generate quiz: %w", err))
 			}
 			quiz = generatedQuiz
 		}
 	}
 
 	return &models.LearningPathWithQuiz{
-		LearningPath: *learningPath,
-		Quiz:         quiz,
+		LearningPath:     *learningPath,
+		Quiz:             quiz,
+		MilestoneQuizzes: milestoneQuizzes,
 	}, nil
 }
 
-// IngestContent orchestrates the ingestion of content URLs.
-func (s *orchestratorService) IngestContent(ctx context.Context, req models.IngestRequest) error {
-	// Directly forward to RAG client's ingestion
-	// In future, this could involve validation, quota checking, etc.
-	return s.ragClient.IngestResources(ctx, req.URLs)
+// generateMilestoneQuizzes generates a quiz for every milestone that has
+// resources, running at most milestoneParallelism calls at once.
+func (s *orchestratorService) generateMilestoneQuizzes(ctx context.Context, learningPath *models.LearningPath, req models.OrchestrateFullFlowRequest) ([]models.MilestoneQuiz, error) {
+	var milestones []models.Milestone
+	for _, milestone := range learningPath.Milestones {
+		if len(milestone.Resources) > 0 {
+			milestones = append(milestones, milestone)
+		}
+	}
+
+	quizzes := make([]models.MilestoneQuiz, len(milestones))
+	err := workerpool.ForEach(ctx, int(s.milestoneParallelism.Load()), len(milestones), func(ctx context.Context, i int) error {
+		milestone := milestones[i]
+		resourceIDs := make([]string, 0, len(milestone.Resources))
+		for _, resource := range milestone.Resources {
+			resourceIDs = append(resourceIDs, resource.ResourceID.String())
+		}
+
+		start := time.Now()
+		generatedQuiz, err := s.quizClient.GenerateQuiz(ctx, models.GenerateQuizRequest{
+			ResourceIDs:  resourceIDs,
+			NumQuestions: req.NumQuestions,
+			Difficulty:   req.QuizDifficulty,
+			UserID:       req.UserID,
+			Language:     req.Language,
+		})
+		if err == nil {
+			s.latencies["quiz"].observe(time.Since(start))
+		}
+		if quizRejected(err) {
+			log.Printf("Skipping quiz for milestone %q: %v", milestone.Title, err)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to generate quiz for milestone %q: %w", milestone.Title, err)
+		}
+		quizzes[i] = models.MilestoneQuiz{MilestoneID: milestone.MilestoneID, Quiz: generatedQuiz}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop the milestones whose quiz was skipped
+	generated := quizzes[:0]
+	for _, quiz := range quizzes {
+		if quiz.Quiz != nil {
+			generated = append(generated, quiz)
+		}
+	}
+	return generated, nil
+}
+
+// attributedUser is the user a plan or quiz is generated for: the verified
+// user of ctx, whatever the request says, or userID for anonymous requests
+func attributedUser(ctx context.Context, userID *string) *string {
+	if authenticated := common.GetVerifiedUserID(ctx); authenticated != "" {
+		return &authenticated
+	}
+	return userID
+}
+
+// searchFilters narrows a plan's resource search to the learner's skills
+// and preferences
+func (s *orchestratorService) searchFilters(req models.PlanLearningPathRequest) *clients.SearchFilters {
+	filters := &clients.SearchFilters{
+		Skills:      req.CurrentSkills,
+		MediaTypes:  req.Preferences.MediaTypes,
+		Providers:   req.Preferences.Providers,
+		Licenses:    req.Preferences.Licenses,
+		ResourceIDs: req.ResourceIDs,
+	}
+	if req.Preferences.MaxResourceDuration > 0 {
+		filters.MaxDuration = &req.Preferences.MaxResourceDuration
+	}
+	if req.Preferences.FreeOnly {
+		filters.ExcludeLicenses = s.licenses.Load().Paid
+	}
+	return filters
+}
+
+// diversity resolves the caps for a plan request against the tenant's and
+// gateway's, so the planner enforces them on its own search; nil when none
+// apply
+func (s *orchestratorService) diversity(ctx context.Context, req *models.Diversity) *models.Diversity {
+	limits := diversity.Resolve(*s.diversityConfig.Load(), common.GetTenantID(ctx), req)
+	if !limits.Active() {
+		return nil
+	}
+	return &limits
+}
+
+// planQuery is what resources for a plan are searched by: its goal,
+// narrowed to the sub-goals picked, as the planner searches
+func planQuery(req models.PlanLearningPathRequest) string {
+	if len(req.SubGoals) == 0 {
+		return req.Goal
+	}
+	return req.Goal + ": " + strings.Join(req.SubGoals, "; ")
+}
+
+// annotateCosts marks each resource of a plan free or paid by its license
+func (s *orchestratorService) annotateCosts(learningPath *models.LearningPath) {
+	paid := s.licenses.Load().Paid
+	for i := range learningPath.Milestones {
+		resources := learningPath.Milestones[i].Resources
+		for j := range resources {
+			resources[j].Cost = models.CostFree
+			if resources[j].License != nil && slices.ContainsFunc(paid, func(l string) bool {
+				return strings.EqualFold(l, *resources[j].License)
+			}) {
+				resources[j].Cost = models.CostPaid
+			}
+		}
+	}
+}
+
+// quizRejected reports whether the Quiz service refused a request as
+// invalid (e.g. resources without extractable content); retrying won't help
+// and the plan can be returned without that quiz
+func quizRejected(err error) bool {
+	return clients.IsStatus(err, http.StatusBadRequest) || clients.IsStatus(err, http.StatusUnprocessableEntity)
+}
+
+// IngestContent orchestrates the ingestion of content URLs. Callers pass
+// URLs already canonicalized with urlnorm. Videos with a transcript are
+// indexed by it; everything else is fetched by the RAG service. It returns
+// the IDs of the resources indexed.
+func (s *orchestratorService) IngestContent(ctx context.Context, req models.IngestRequest) ([]string, error) {
+	var ids []string
+	urls := req.URLs
+	if s.transcripts.Enabled() {
+		urls = nil
+		for _, url := range req.URLs {
+			video, ok := s.videoResource(ctx, url)
+			if !ok {
+				urls = append(urls, url)
+				continue
+			}
+			id, err := s.ragClient.IngestDocument(ctx, video)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+	}
+	if len(urls) == 0 {
+		return ids, nil
+	}
+
+	var durations map[string]int
+	if s.durations.Enabled() {
+		durations = s.durations.Durations(ctx, urls)
+	}
+	resources := make([]clients.IngestResource, len(urls))
+	for i, url := range urls {
+		resources[i] = clients.IngestResource{
+			URL:              url,
+			DurationMin:      durations[url], // 0 leaves it to the RAG service
+			RespectRobotsTxt: s.respectRobotsTxt.Load(),
+		}
+	}
+	indexed, err := s.ragClient.IngestResources(ctx, resources)
+	if err != nil {
+		return nil, err
+	}
+	return append(ids, indexed...), nil
+}
+
+// videoResource builds the resource for a video URL from its transcript.
+// It reports false for other URLs and for videos without a transcript.
+func (s *orchestratorService) videoResource(ctx context.Context, url string) (clients.IngestResource, bool) {
+	t, err := s.transcripts.Transcribe(ctx, url)
+	if err != nil {
+		if !errors.Is(err, transcripts.ErrNotVideo) {
+			log.Printf("No transcript for %s, ingesting without one: %v", url, err)
+		}
+		return clients.IngestResource{}, false
+	}
+
+	spoken := make([]string, len(t.Segments))
+	for i, segment := range t.Segments {
+		spoken[i] = segment.Text
+	}
+	title := t.Title
+	if title == "" {
+		title = url
+	}
+	return clients.IngestResource{
+		// The transcript is stored under this ID for the resource viewer
+		ID:          t.ResourceID,
+		Title:       title,
+		URL:         url,
+		MediaType:   "video",
+		Description: summary(strings.Join(spoken, " ")),
+		DurationMin: int(math.Ceil(t.Duration().Minutes())),
+		Content:     t.Text(),
+	}, true
+}
+
+// IngestDocument indexes the text of an uploaded document.
+func (s *orchestratorService) IngestDocument(ctx context.Context, req models.IngestDocumentRequest) error {
+	_, err := s.ragClient.IngestDocument(ctx, clients.IngestResource{
+		Title:       req.Title,
+		URL:         req.URL,
+		MediaType:   req.MediaType,
+		Description: summary(req.Content),
+		Content:     req.Content,
+	})
+	return err
+}
+
+// summary is the start of a document's text, cut at a word boundary
+func summary(text string) string {
+	const maxLen = 300
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= maxLen {
+		return string(runes)
+	}
+	head := string(runes[:maxLen])
+	if cut := strings.LastIndex(head, " "); cut > 0 {
+		head = head[:cut]
+	}
+	return head + "…"
+}
+
+// ApplyConfig pushes reloaded settings into the clients that support it.
+func (s *orchestratorService) ApplyConfig(cfg *config.Config) {
+	s.retryBudget.Configure(cfg.Retry.BudgetRatio, cfg.Retry.BudgetBurst)
+	s.milestoneParallelism.Store(int64(cfg.Concurrency.MilestoneParallelism))
+	s.timeouts.Store(&cfg.Timeouts)
+	s.licenses.Store(&cfg.Licenses)
+	s.diversityConfig.Store(&cfg.Diversity)
+	s.compensation.Store(&cfg.Compensation)
+	s.respectRobotsTxt.Store(cfg.Ingestion.RespectRobotsTxt)
+	s.planLimiter.limit.Store(int64(cfg.Concurrency.PlansPerUser))
+	if !cfg.Discovery.Dynamic() {
+		s.SetEndpoints("rag", cfg.RAGServiceURLs)
+		s.SetEndpoints("planner", cfg.PlannerServiceURLs)
+		s.SetEndpoints("quiz", cfg.QuizServiceURLs)
+	}
+	for _, b := range s.balancers {
+		b.SetStrategy(cfg.LoadBalancing)
+	}
+
+	if c, ok := s.ragClient.(clients.Configurable); ok {
+		c.Configure(s.clientOptions(cfg, "rag"))
+	}
+	if c, ok := s.plannerClient.(clients.Configurable); ok {
+		c.Configure(s.clientOptions(cfg, "planner"))
+	}
+	if c, ok := s.quizClient.(clients.Configurable); ok {
+		c.Configure(s.clientOptions(cfg, "quiz"))
+	}
+}
+
+// SetEndpoints replaces the replicas the named service's client balances over.
+func (s *orchestratorService) SetEndpoints(service string, urls []string) {
+	if b, ok := s.balancers[service]; ok {
+		b.SetEndpoints(urls)
+	}
+}
+
+// Forward sends a proxied request through the service's client.
+func (s *orchestratorService) Forward(ctx context.Context, service, method, path string, body *bufpool.Body) (*http.Response, error) {
+	switch service {
+	case "rag":
+		return s.ragClient.Forward(ctx, method, path, body)
+	case "planner":
+		return s.plannerClient.Forward(ctx, method, path, body)
+	}
+	return nil, fmt.Errorf("requests to %s aren't forwarded", service)
+}
+
+// BreakerStates returns the circuit breaker state of each backend service.
+func (s *orchestratorService) BreakerStates() map[string]string {
+	states := make(map[string]string, len(s.breakers))
+	for service, breaker := range s.breakers {
+		states[service] = breaker.State()
+	}
+	return states
 }
 
 // ============================================================================
@@ -153,4 +788,4 @@ type PlannerExecutorAgent interface {
 type VerifierAgent interface {
 	VerifyLearningPath(ctx context.Context, lp models.LearningPath) (bool, []string, error) // Returns true if valid, list of issues
 	VerifyQuiz(ctx context.Context, quiz models.Quiz) (bool, []string, error)
-}
\ No newline at end of file
+}
@@ -0,0 +1,90 @@
+package orchestrator_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/amirhf/learnpath-gateway/internal/clients"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/features"
+	"github.com/amirhf/learnpath-gateway/internal/mocks"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/google/uuid"
+)
+
+var subGoals = []string{"HTTP servers", "Concurrency"}
+
+const wantQuery = "Learn Go: HTTP servers; Concurrency"
+
+// newOrchestrator returns an orchestrator whose RAG and planner calls go
+// to rag and planner
+func newOrchestrator(rag *mocks.RAGClientMock, planner *mocks.PlannerClientMock) orchestrator.Orchestrator {
+	cfg := config.Load()
+	orch := orchestrator.NewOrchestrator(cfg, http.DefaultTransport, features.NewSwitches(cfg), nil, nil)
+	orchestrator.SetClients(orch, rag, planner)
+	return orch
+}
+
+func searchReturning(queries *[]string) *mocks.RAGClientMock {
+	return &mocks.RAGClientMock{
+		SearchFunc: func(ctx context.Context, req clients.SearchRequest) (*models.SearchResponse, error) {
+			*queries = append(*queries, req.Query)
+			return &models.SearchResponse{}, nil
+		},
+	}
+}
+
+func TestOrchestrateFullFlowSendsSubGoals(t *testing.T) {
+	var queries []string
+	planner := &mocks.PlannerClientMock{
+		CreatePlanFunc: func(ctx context.Context, req models.PlanLearningPathRequest) (*models.LearningPath, error) {
+			return &models.LearningPath{PlanID: uuid.New(), Goal: req.Goal}, nil
+		},
+	}
+	orch := newOrchestrator(searchReturning(&queries), planner)
+
+	_, err := orch.OrchestrateFullFlow(context.Background(), models.OrchestrateFullFlowRequest{
+		PlanLearningPathRequest: models.PlanLearningPathRequest{
+			Goal:            "Learn Go",
+			TimeBudgetHours: 10,
+			HoursPerWeek:    5,
+			SubGoals:        subGoals,
+		},
+	})
+	if err != nil {
+		t.Fatalf("OrchestrateFullFlow: %v", err)
+	}
+
+	calls := planner.CreatePlanCalls()
+	if len(calls) != 1 {
+		t.Fatalf("CreatePlan called %d times, want 1", len(calls))
+	}
+	if got := calls[0].Req.SubGoals; len(got) != len(subGoals) || got[0] != subGoals[0] || got[1] != subGoals[1] {
+		t.Errorf("planner got sub-goals %q, want %q", got, subGoals)
+	}
+	if len(queries) != 1 || queries[0] != wantQuery {
+		t.Errorf("searched %q, want %q", queries, wantQuery)
+	}
+}
+
+func TestEstimatePlanSearchesSubGoals(t *testing.T) {
+	var queries []string
+	orch := newOrchestrator(searchReturning(&queries), &mocks.PlannerClientMock{})
+
+	_, err := orch.EstimatePlan(context.Background(), models.OrchestrateFullFlowRequest{
+		PlanLearningPathRequest: models.PlanLearningPathRequest{
+			Goal:            "Learn Go",
+			TimeBudgetHours: 10,
+			HoursPerWeek:    5,
+			SubGoals:        subGoals,
+		},
+	})
+	if err != nil {
+		t.Fatalf("EstimatePlan: %v", err)
+	}
+	if len(queries) != 1 || queries[0] != wantQuery {
+		t.Errorf("searched %q, want %q", queries, wantQuery)
+	}
+}
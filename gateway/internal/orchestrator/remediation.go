@@ -0,0 +1,71 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrNothingToReview is returned when none of the missed questions were
+// drawn from the plan's resources.
+var ErrNothingToReview = errors.New("no missed questions on this plan's resources")
+
+// RemediatePlan replans a plan with a review milestone of the resources a
+// quiz showed the learner hasn't mastered. The review is placed after the
+// last milestone those resources belong to, so it comes before the learner
+// advances; the skills they cover are passed to the planner as weak skills.
+func (s *orchestratorService) RemediatePlan(ctx context.Context, req models.RemediationRequest) (*models.Remediation, error) {
+	plan, err := s.plannerClient.GetPlan(ctx, req.PlanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get learning plan: %w", err)
+	}
+
+	type placement struct {
+		milestone int
+		resource  models.ResourceItem
+	}
+	inPlan := map[string]placement{}
+	for i, milestone := range plan.Milestones {
+		for _, resource := range milestone.Resources {
+			inPlan[resource.ResourceID.String()] = placement{milestone: i, resource: resource}
+		}
+	}
+
+	remediation := &models.Remediation{ReviewResources: []uuid.UUID{}, WeakSkills: []string{}}
+	last := -1
+	for _, resourceID := range req.MissedResources {
+		found, ok := inPlan[resourceID]
+		if !ok || slices.Contains(remediation.ReviewResources, found.resource.ResourceID) {
+			continue
+		}
+		if req.MaxResources > 0 && len(remediation.ReviewResources) == req.MaxResources {
+			break
+		}
+		remediation.ReviewResources = append(remediation.ReviewResources, found.resource.ResourceID)
+		for _, skill := range found.resource.Skills {
+			if !slices.Contains(remediation.WeakSkills, skill) {
+				remediation.WeakSkills = append(remediation.WeakSkills, skill)
+			}
+		}
+		last = max(last, found.milestone)
+	}
+	if last < 0 {
+		return nil, ErrNothingToReview
+	}
+
+	reviewAfter := plan.Milestones[last].MilestoneID
+	remediation.Plan, err = s.plannerClient.Replan(ctx, req.PlanID, models.ReplanRequest{
+		CompletedResources: []uuid.UUID{},
+		ReviewResources:    remediation.ReviewResources,
+		WeakSkills:         remediation.WeakSkills,
+		ReviewAfter:        &reviewAfter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to replan for remediation: %w", err)
+	}
+	return remediation, nil
+}
@@ -0,0 +1,237 @@
+package ownership
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Kinds of owned resources
+const (
+	Plan = "plan"
+	Quiz = "quiz"
+)
+
+// Access is what a caller wants to do with a resource
+type Access int
+
+const (
+	Read  Access = iota // View it
+	Write               // Change it, or act on it as its learner
+)
+
+// Owner is who a plan or quiz was created for. Resources created by
+// anonymous callers have no user and are open to their tenant.
+type Owner struct {
+	UserID   string `json:"user_id,omitempty"`
+	TenantID string `json:"tenant_id,omitempty"`
+	Guest    bool   `json:"guest,omitempty"` // A guest session, whose resources can be transferred
+}
+
+// Denial is an access the registry refuses
+type Denial struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (d *Denial) Error() string {
+	return d.Message
+}
+
+// Registry records who owns the plans and quizzes served through the
+// gateway and checks callers against it. Owners are kept in the shared
+// store so every replica enforces them. Resources without a record, such
+// as those created before ownership was enforced, claimed on a replica
+// with a process-local store or kept past the retention, are not found.
+type Registry struct {
+	cfg    config.OwnershipConfig
+	store  storage.KeyValue
+	shares repository.ShareTokenRepository
+}
+
+// New creates a registry. Share tokens grant read access to the plan they
+// name.
+func New(cfg config.OwnershipConfig, store storage.KeyValue, shares repository.ShareTokenRepository) *Registry {
+	return &Registry{cfg: cfg, store: store, shares: shares}
+}
+
+// Enabled reports whether ownership is enforced
+func (r *Registry) Enabled() bool {
+	return r != nil && r.cfg.Enabled
+}
+
+// Claim records owner as the owner of a resource, replacing any previous
+// owner
+func (r *Registry) Claim(ctx context.Context, kind, id string, owner Owner) error {
+	if !r.Enabled() || id == "" {
+		return nil
+	}
+	data, err := json.Marshal(owner)
+	if err != nil {
+		return err
+	}
+	if err := r.store.Set(ctx, ownerKey(kind, id), data, r.cfg.Retention); err != nil {
+		return err
+	}
+	if !owner.Guest {
+		return nil
+	}
+
+	// Guests' resources are listed, one kind:id per line, so they can be
+	// transferred on sign-up. Appending keeps concurrent claims from
+	// overwriting each other.
+	return r.store.Append(ctx, guestKey(owner.UserID), []byte(kind+":"+id+"\n"), r.cfg.Retention)
+}
+
+// Transfer hands everything a guest owns to a new owner, returning how
+// many resources moved
+func (r *Registry) Transfer(ctx context.Context, guestID string, to Owner) (int, error) {
+	if !r.Enabled() {
+		return 0, nil
+	}
+	owned, err := r.guestResources(ctx, guestID)
+	if err != nil {
+		return 0, err
+	}
+	data, err := json.Marshal(to)
+	if err != nil {
+		return 0, err
+	}
+	var moved int
+	for _, resource := range owned {
+		kind, id, _ := strings.Cut(resource, ":")
+		owner, err := r.Owner(ctx, kind, id)
+		if err != nil {
+			return moved, err
+		}
+		if owner == nil || owner.UserID != guestID {
+			continue
+		}
+		if err := r.store.Set(ctx, ownerKey(kind, id), data, r.cfg.Retention); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	return moved, r.store.Delete(ctx, guestKey(guestID))
+}
+
+// guestResources lists the resources a guest has claimed, as kind:id
+func (r *Registry) guestResources(ctx context.Context, guestID string) ([]string, error) {
+	data, ok, err := r.store.Get(ctx, guestKey(guestID))
+	if err != nil {
+		return nil, fmt.Errorf("load guest resources: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return strings.Fields(string(data)), nil
+}
+
+// Owner returns the owner of a resource, or nil when none is recorded
+func (r *Registry) Owner(ctx context.Context, kind, id string) (*Owner, error) {
+	data, ok, err := r.store.Get(ctx, ownerKey(kind, id))
+	if err != nil {
+		return nil, fmt.Errorf("load %s owner: %w", kind, err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	var owner Owner
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return nil, fmt.Errorf("decode %s owner: %w", kind, err)
+	}
+	return &owner, nil
+}
+
+// Check decides whether caller may access a resource. caller.UserID must
+// be a verified identity, or empty. Resources without a recorded owner,
+// another tenant's resources, and another user's to anonymous callers are
+// reported as not found; a signed-in caller of the same tenant is refused
+// with 403. A share token for a plan lets anyone read it, but not change
+// it. Other errors mean the owner couldn't be looked up.
+func (r *Registry) Check(ctx context.Context, kind, id string, caller Owner, access Access, shareToken string) error {
+	if !r.Enabled() {
+		return nil
+	}
+	owner, err := r.Owner(ctx, kind, id)
+	if err != nil {
+		return err
+	}
+	if owner == nil {
+		return notFound(kind)
+	}
+	if owner.TenantID != "" && caller.TenantID != "" && owner.TenantID != caller.TenantID {
+		return notFound(kind)
+	}
+	if owner.UserID == "" || owner.UserID == caller.UserID {
+		return nil
+	}
+
+	if kind == Plan && shareToken != "" {
+		shared, err := r.shared(ctx, id, shareToken)
+		if err != nil {
+			return err
+		}
+		if shared && access == Read {
+			return nil
+		}
+		if shared {
+			return &Denial{
+				Status:  http.StatusForbidden,
+				Code:    "forbidden",
+				Message: "A shared plan can only be viewed",
+			}
+		}
+	}
+	if caller.UserID == "" {
+		return notFound(kind)
+	}
+	return &Denial{
+		Status:  http.StatusForbidden,
+		Code:    "forbidden",
+		Message: fmt.Sprintf("This %s belongs to another user", kind),
+	}
+}
+
+// shared reports whether token is a live share token for planID
+func (r *Registry) shared(ctx context.Context, planID, token string) (bool, error) {
+	share, err := r.shares.GetShareToken(ctx, token)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("load share token: %w", err)
+	}
+	return share.PlanID == planID && !share.Expired(time.Now()), nil
+}
+
+func notFound(kind string) *Denial {
+	return &Denial{
+		Status:  http.StatusNotFound,
+		Code:    "not_found",
+		Message: fmt.Sprintf("The %s does not exist", kind),
+	}
+}
+
+// ownerKey is where a resource's owner is kept. UUIDs are keyed in their
+// canonical form, so another spelling of an ID finds the same owner.
+func ownerKey(kind, id string) string {
+	if parsed, err := uuid.Parse(id); err == nil {
+		id = parsed.String()
+	}
+	return "owners:" + kind + ":" + id
+}
+
+func guestKey(guestID string) string {
+	return "owners:guest:" + guestID
+}
@@ -0,0 +1,92 @@
+package ownership_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/ownership"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+)
+
+const planID = "6f1c2a9e-3b4d-4e5f-8a7b-9c0d1e2f3a4b"
+
+func newRegistry() *ownership.Registry {
+	return ownership.New(config.OwnershipConfig{Enabled: true}, storage.NewMemory(), repository.NewMemory().ShareTokens)
+}
+
+// status returns the status a Check error answers with, 0 when allowed
+func status(t *testing.T, err error) int {
+	t.Helper()
+	if err == nil {
+		return 0
+	}
+	var denial *ownership.Denial
+	if !errors.As(err, &denial) {
+		t.Fatalf("Check: unexpected error %v", err)
+	}
+	return denial.Status
+}
+
+func TestCheckUnrecordedOwner(t *testing.T) {
+	owners := newRegistry()
+	ctx := context.Background()
+
+	for _, caller := range []ownership.Owner{
+		{UserID: "alice", TenantID: "t1"},
+		{TenantID: "t1"},
+		{},
+	} {
+		err := owners.Check(ctx, ownership.Plan, planID, caller, ownership.Read, "")
+		if got := status(t, err); got != http.StatusNotFound {
+			t.Errorf("Check(%+v) of an unrecorded plan = %d, want %d", caller, got, http.StatusNotFound)
+		}
+	}
+}
+
+func TestCheckNonCanonicalID(t *testing.T) {
+	owners := newRegistry()
+	ctx := context.Background()
+	alice := ownership.Owner{UserID: "alice", TenantID: "t1"}
+	mallory := ownership.Owner{UserID: "mallory", TenantID: "t1"}
+
+	if err := owners.Claim(ctx, ownership.Plan, planID, alice); err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+
+	for _, id := range []string{
+		planID,
+		strings.ToUpper(planID),
+		"{" + planID + "}",
+		"urn:uuid:" + planID,
+		strings.ReplaceAll(planID, "-", ""),
+	} {
+		if got := status(t, owners.Check(ctx, ownership.Plan, id, mallory, ownership.Read, "")); got != http.StatusForbidden {
+			t.Errorf("Check(mallory, %q) = %d, want %d", id, got, http.StatusForbidden)
+		}
+		if got := status(t, owners.Check(ctx, ownership.Plan, id, alice, ownership.Write, "")); got != 0 {
+			t.Errorf("Check(alice, %q) = %d, want allowed", id, got)
+		}
+	}
+}
+
+func TestClaimNonCanonicalID(t *testing.T) {
+	owners := newRegistry()
+	ctx := context.Background()
+	alice := ownership.Owner{UserID: "alice", TenantID: "t1"}
+
+	if err := owners.Claim(ctx, ownership.Plan, strings.ToUpper(planID), alice); err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	owner, err := owners.Owner(ctx, ownership.Plan, planID)
+	if err != nil {
+		t.Fatalf("Owner: %v", err)
+	}
+	if owner == nil || owner.UserID != "alice" {
+		t.Errorf("Owner = %+v, want alice", owner)
+	}
+}
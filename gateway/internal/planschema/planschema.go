@@ -0,0 +1,228 @@
+// Package planschema publishes the versioned JSON Schemas that plans
+// imported as JSON are checked against, and validates documents with
+// them. Only the keywords the schemas use are understood: type, const,
+// required, properties, items, minLength, maxLength, minItems, maxItems,
+// minimum, maximum and format (uuid and uri).
+package planschema
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/amirhf/learnpath-gateway/internal/validation"
+	"github.com/google/uuid"
+)
+
+// Latest is the schema version documents without schema_version are
+// checked against
+const Latest = 1
+
+//go:embed schemas/*.json
+var files embed.FS
+
+// published is a schema version, as served and decoded
+type published struct {
+	raw    []byte
+	schema map[string]any
+}
+
+var schemas = map[int]published{}
+
+func init() {
+	for version := 1; version <= Latest; version++ {
+		raw, err := files.ReadFile(fmt.Sprintf("schemas/v%d.json", version))
+		if err != nil {
+			panic(err)
+		}
+		var schema map[string]any
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			panic(fmt.Sprintf("planschema: v%d: %v", version, err))
+		}
+		schemas[version] = published{raw: raw, schema: schema}
+	}
+}
+
+// Schema returns a version's schema document, and whether there is one
+func Schema(version int) ([]byte, bool) {
+	s, ok := schemas[version]
+	return s.raw, ok
+}
+
+// Version returns the schema version a document names, Latest if none.
+// It reports false when the document isn't a JSON object or names a
+// version that isn't an integer.
+func Version(doc []byte) (int, bool) {
+	var header struct {
+		SchemaVersion *json.Number `json:"schema_version"`
+	}
+	if err := json.Unmarshal(doc, &header); err != nil {
+		return 0, false
+	}
+	if header.SchemaVersion == nil {
+		return Latest, true
+	}
+	version, err := header.SchemaVersion.Int64()
+	if err != nil {
+		return 0, false
+	}
+	return int(version), true
+}
+
+// Validate checks doc against a version's schema and returns what it
+// breaks, with fields named as in doc (e.g. milestones[0].title)
+func Validate(version int, doc []byte) ([]validation.FieldError, error) {
+	s, ok := schemas[version]
+	if !ok {
+		return nil, fmt.Errorf("planschema: no schema version %d", version)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return validation.Translate(err), nil
+	}
+	var problems []validation.FieldError
+	check(s.schema, value, "", &problems)
+	return problems, nil
+}
+
+// check validates value at path against schema, adding what it breaks
+func check(schema map[string]any, value any, path string, problems *[]validation.FieldError) {
+	add := func(rule, message string) {
+		*problems = append(*problems, validation.FieldError{Field: path, Rule: rule, Message: message})
+	}
+
+	if want, ok := schema["const"]; ok && !equal(value, want) {
+		add("eq", "must be "+fmt.Sprint(want))
+		return
+	}
+	if kind, ok := schema["type"].(string); ok && !hasType(value, kind) {
+		add("type", "must be "+typeName(kind))
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := v[name.(string)]; !ok {
+				*problems = append(*problems, validation.FieldError{Field: join(path, name.(string)), Rule: "required", Message: "is required"})
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if field, ok := v[name]; ok {
+				check(properties[name].(map[string]any), field, join(path, name), problems)
+			}
+		}
+	case []any:
+		if n, ok := bound(schema, "minItems"); ok && float64(len(v)) < n {
+			add("min", fmt.Sprintf("must have at least %g items", n))
+		}
+		if n, ok := bound(schema, "maxItems"); ok && float64(len(v)) > n {
+			add("max", fmt.Sprintf("must have at most %g items", n))
+			return
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				check(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if n, ok := bound(schema, "minLength"); ok && length < n {
+			add("min", fmt.Sprintf("must be at least %g characters", n))
+		}
+		if n, ok := bound(schema, "maxLength"); ok && length > n {
+			add("max", fmt.Sprintf("must be at most %g characters", n))
+		}
+		switch schema["format"] {
+		case "uuid":
+			if _, err := uuid.Parse(v); err != nil {
+				add("uuid", "must be a UUID")
+			}
+		case "uri":
+			if u, err := url.Parse(v); err != nil || u.Scheme == "" || u.Host == "" {
+				add("url", "must be a valid URL")
+			}
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if n, ok := bound(schema, "minimum"); ok && f < n {
+			add("gte", fmt.Sprintf("must be at least %g", n))
+		}
+		if n, ok := bound(schema, "maximum"); ok && f > n {
+			add("lte", fmt.Sprintf("must be at most %g", n))
+		}
+	}
+}
+
+// hasType reports whether a decoded value is of a JSON Schema type
+func hasType(value any, kind string) bool {
+	switch v := value.(type) {
+	case map[string]any:
+		return kind == "object"
+	case []any:
+		return kind == "array"
+	case string:
+		return kind == "string"
+	case bool:
+		return kind == "boolean"
+	case json.Number:
+		if kind == "integer" {
+			f, err := v.Float64()
+			return err == nil && f == math.Trunc(f)
+		}
+		return kind == "number"
+	case nil:
+		return kind == "null"
+	}
+	return false
+}
+
+func typeName(kind string) string {
+	switch kind {
+	case "object", "array", "integer":
+		return "an " + kind
+	case "null":
+		return "null"
+	}
+	return "a " + kind
+}
+
+// equal compares a decoded value with a scalar from a schema
+func equal(value, want any) bool {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		w, ok := want.(float64)
+		return err == nil && ok && f == w
+	case string, bool, nil:
+		return v == want
+	}
+	return false
+}
+
+// bound returns a numeric keyword of schema
+func bound(schema map[string]any, keyword string) (float64, bool) {
+	n, ok := schema[keyword].(float64)
+	return n, ok
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
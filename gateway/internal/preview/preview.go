@@ -0,0 +1,312 @@
+// Package preview fetches OpenGraph and oEmbed metadata for links, so the
+// UI can show rich previews of search results and ingested resources
+package preview
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+	"golang.org/x/net/html"
+)
+
+// keyPrefix namespaces cached previews
+const keyPrefix = "preview:"
+
+// ErrPrivateAddress is returned for links that resolve to loopback,
+// private or link-local addresses, which previews must not reach
+var ErrPrivateAddress = errors.New("address is not publicly routable")
+
+// oEmbedProviders are endpoints for sites that don't advertise one in
+// their pages, keyed by host
+var oEmbedProviders = map[string]string{
+	"youtube.com": "https://www.youtube.com/oembed",
+	"youtu.be":    "https://www.youtube.com/oembed",
+	"vimeo.com":   "https://vimeo.com/api/oembed.json",
+}
+
+// Preview is the display metadata of a link
+type Preview struct {
+	URL             string    `json:"url"`
+	Title           string    `json:"title,omitempty"`
+	Description     string    `json:"description,omitempty"`
+	ThumbnailURL    string    `json:"thumbnail_url,omitempty"`
+	SiteName        string    `json:"site_name,omitempty"`
+	Type            string    `json:"type,omitempty"` // e.g. article, video
+	DurationSeconds int       `json:"duration_seconds,omitempty"`
+	WordCount       int       `json:"word_count,omitempty"` // Of the page's visible text
+	FetchedAt       time.Time `json:"fetched_at"`
+}
+
+// Fetcher loads previews, caching them in the shared store
+type Fetcher struct {
+	cfg    config.PreviewConfig
+	store  storage.KeyValue
+	client *http.Client
+}
+
+// New creates a fetcher whose requests can only reach public addresses,
+// unless cfg.AllowPrivate is set
+func New(cfg config.PreviewConfig, store storage.KeyValue) *Fetcher {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivate {
+		dialer.Control = publicOnly
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   cfg.Timeout,
+		ResponseHeaderTimeout: cfg.Timeout,
+		MaxIdleConnsPerHost:   2,
+	}
+	return &Fetcher{
+		cfg:   cfg,
+		store: store,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return errors.New("too many redirects")
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// Get returns the preview of a canonical URL, from the cache when it was
+// fetched within the cache TTL
+func (f *Fetcher) Get(ctx context.Context, rawURL string) (*Preview, bool, error) {
+	if data, ok, err := f.store.Get(ctx, cacheKey(rawURL)); err == nil && ok {
+		var p Preview
+		if json.Unmarshal(data, &p) == nil {
+			return &p, true, nil
+		}
+	}
+	p, err := f.Refresh(ctx, rawURL)
+	return p, false, err
+}
+
+// Refresh fetches the preview of a canonical URL, replacing any cached one
+func (f *Fetcher) Refresh(ctx context.Context, rawURL string) (*Preview, error) {
+	p, err := f.fetch(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(p); err == nil {
+		if err := f.store.Set(ctx, cacheKey(rawURL), data, f.cfg.CacheTTL); err != nil {
+			log.Printf("preview: failed to cache %s: %v", rawURL, err)
+		}
+	}
+	return p, nil
+}
+
+func cacheKey(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return keyPrefix + hex.EncodeToString(sum[:])
+}
+
+func (f *Fetcher) fetch(ctx context.Context, rawURL string) (*Preview, error) {
+	p := &Preview{URL: rawURL}
+	page, err := f.get(ctx, rawURL, "text/html")
+	if err != nil {
+		return nil, err
+	}
+	endpoint := readPage(page, p)
+
+	// oEmbed fills in what the page's own tags left out
+	if endpoint == "" {
+		if provider, ok := oEmbedProviders[siteHost(rawURL)]; ok {
+			endpoint = provider + "?" + url.Values{"url": {rawURL}, "format": {"json"}}.Encode()
+		}
+	}
+	if endpoint != "" {
+		if err := f.oEmbed(ctx, endpoint, p); err != nil {
+			log.Printf("preview: oEmbed failed for %s: %v", rawURL, err)
+		}
+	}
+
+	if p.Title == "" {
+		p.Title = rawURL
+	}
+	p.FetchedAt = time.Now().UTC()
+	return p, nil
+}
+
+func (f *Fetcher) get(ctx context.Context, target, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", "LearnPathPreview/1.0")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", req.URL.Host, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, int64(f.cfg.MaxBytes)))
+}
+
+// hiddenElements hold text that isn't read, so it doesn't count as words
+var hiddenElements = map[string]bool{"script": true, "style": true, "noscript": true, "template": true, "svg": true}
+
+// readPage fills p from the page's OpenGraph and standard meta tags and
+// body text, and returns its advertised JSON oEmbed endpoint, if any
+func readPage(page []byte, p *Preview) string {
+	var endpoint, titleTag, description string
+	z := html.NewTokenizer(strings.NewReader(string(page)))
+	inTitle, inBody, hidden := false, false, 0
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if p.Title == "" {
+				p.Title = strings.TrimSpace(titleTag)
+			}
+			if p.Description == "" {
+				p.Description = description
+			}
+			return endpoint
+		case html.TextToken:
+			switch {
+			case inTitle:
+				titleTag += string(z.Text())
+			case inBody && hidden == 0:
+				p.WordCount += len(strings.Fields(string(z.Text())))
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "body":
+				inBody = false
+			default:
+				if hiddenElements[string(name)] && hidden > 0 {
+					hidden--
+				}
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if tt == html.StartTagToken && hiddenElements[string(name)] {
+				hidden++
+			}
+			attrs := map[string]string{}
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = z.TagAttr()
+				attrs[string(key)] = string(value)
+			}
+			switch string(name) {
+			case "title":
+				inTitle = true
+			case "body":
+				inBody = true
+			case "meta":
+				property := strings.ToLower(attrs["property"])
+				if property == "" {
+					property = strings.ToLower(attrs["name"])
+				}
+				content := strings.TrimSpace(attrs["content"])
+				switch property {
+				case "og:title":
+					p.Title = content
+				case "og:description":
+					p.Description = content
+				case "description":
+					description = content
+				case "og:image", "og:image:url", "og:image:secure_url":
+					if p.ThumbnailURL == "" {
+						p.ThumbnailURL = content
+					}
+				case "og:site_name":
+					p.SiteName = content
+				case "og:type":
+					p.Type = content
+				case "og:video:duration", "video:duration":
+					p.DurationSeconds, _ = strconv.Atoi(content)
+				}
+			case "link":
+				if strings.EqualFold(attrs["type"], "application/json+oembed") && strings.Contains(strings.ToLower(attrs["rel"]), "alternate") {
+					endpoint = attrs["href"]
+				}
+			}
+		}
+	}
+}
+
+// oEmbed fills the fields of p still empty from an oEmbed endpoint
+func (f *Fetcher) oEmbed(ctx context.Context, endpoint string, p *Preview) error {
+	body, err := f.get(ctx, endpoint, "application/json")
+	if err != nil {
+		return err
+	}
+	var meta struct {
+		Title        string  `json:"title"`
+		ProviderName string  `json:"provider_name"`
+		ThumbnailURL string  `json:"thumbnail_url"`
+		Type         string  `json:"type"`
+		Duration     float64 `json:"duration"`
+		Description  string  `json:"description"`
+	}
+	if err := json.Unmarshal(body, &meta); err != nil {
+		return fmt.Errorf("invalid oEmbed response: %w", err)
+	}
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&p.Title, meta.Title)
+	fill(&p.Description, meta.Description)
+	fill(&p.ThumbnailURL, meta.ThumbnailURL)
+	fill(&p.SiteName, meta.ProviderName)
+	fill(&p.Type, meta.Type)
+	if p.DurationSeconds == 0 {
+		p.DurationSeconds = int(meta.Duration)
+	}
+	return nil
+}
+
+func siteHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	return strings.TrimPrefix(host, "m.")
+}
+
+// publicOnly refuses connections to non-public addresses, after DNS
+// resolution so a public name can't point inside the network
+func publicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%s: %w", host, ErrPrivateAddress)
+	}
+	return nil
+}
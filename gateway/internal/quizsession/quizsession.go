@@ -0,0 +1,214 @@
+package quizsession
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Anomaly kinds
+const (
+	UnknownQuestion = "unknown_question"
+	UnknownOption   = "unknown_option"
+	DuplicateAnswer = "duplicate_answer"
+	RepeatAttempt   = "repeat_attempt"
+	TooFast         = "too_fast"
+)
+
+// anonymous owns the sessions of learners who aren't signed in
+const anonymous = "anonymous"
+
+// Session is a quiz as issued to one learner: the questions and options a
+// submission may name, and when the learner received them
+type Session struct {
+	ID        string              `json:"session_id"`
+	QuizID    string              `json:"quiz_id"`
+	UserID    string              `json:"user_id,omitempty"`
+	IssuedAt  time.Time           `json:"issued_at"`
+	Questions map[string]Question `json:"questions"` // By question ID
+}
+
+// Question is what a submission may answer for one question
+type Question struct {
+	Type    string   `json:"type"`
+	Options []string `json:"options,omitempty"`
+}
+
+// Answer is one submitted answer
+type Answer struct {
+	QuestionID string
+	OptionID   string
+	Text       string
+}
+
+// Violation is a submission the guard rejects
+type Violation struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// Guard checks quiz submissions against the quizzes issued to learners.
+// Sessions are kept in the shared store so every replica can check them;
+// anomalies are stored for admins to review.
+type Guard struct {
+	cfg       config.QuizIntegrityConfig
+	store     storage.KeyValue
+	anomalies repository.QuizAnomalyRepository
+}
+
+// New creates a guard
+func New(cfg config.QuizIntegrityConfig, store storage.KeyValue, anomalies repository.QuizAnomalyRepository) *Guard {
+	return &Guard{cfg: cfg, store: store, anomalies: anomalies}
+}
+
+// Enabled reports whether submissions are checked
+func (g *Guard) Enabled() bool {
+	return g != nil && g.cfg.Enabled
+}
+
+// Issue records that a quiz was served to a learner, starting a new session.
+// Issuing the same quiz again replaces the learner's previous session.
+func (g *Guard) Issue(ctx context.Context, userID string, quiz *models.Quiz) error {
+	if !g.Enabled() || quiz == nil {
+		return nil
+	}
+	session := Session{
+		ID:        uuid.NewString(),
+		QuizID:    quiz.QuizID,
+		UserID:    userID,
+		IssuedAt:  time.Now().UTC(),
+		Questions: make(map[string]Question, len(quiz.Questions)),
+	}
+	for _, question := range quiz.Questions {
+		issued := Question{Type: question.QuestionType}
+		if issued.Type == "" {
+			issued.Type = models.QuestionMultipleChoice
+		}
+		for _, option := range question.Options {
+			issued.Options = append(issued.Options, option.OptionID)
+		}
+		session.Questions[question.QuestionID] = issued
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return g.store.Set(ctx, sessionKey(quiz.QuizID, userID), data, g.cfg.SessionTTL)
+}
+
+// Check validates a submission against the learner's session and claims the
+// session's attempt. Invalid answers and repeat attempts are rejected and
+// recorded as anomalies; a submission arriving faster than the questions
+// can be read is recorded but let through. Quizzes without a session, such
+// as those issued before the guard was enabled, are not checked. On
+// success the session is returned, to be released if the submission fails
+// downstream.
+func (g *Guard) Check(ctx context.Context, userID, quizID string, answers []Answer) (*Session, error) {
+	if !g.Enabled() {
+		return nil, nil
+	}
+	data, ok, err := g.store.Get(ctx, sessionKey(quizID, userID))
+	if err != nil {
+		return nil, fmt.Errorf("load quiz session: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("decode quiz session: %w", err)
+	}
+
+	if kind, detail := session.validate(answers); kind != "" {
+		g.record(ctx, userID, quizID, kind, detail)
+		return nil, &Violation{Status: http.StatusUnprocessableEntity, Code: "invalid_answer", Message: detail}
+	}
+
+	if !g.cfg.AllowRetakes {
+		claimed, err := g.store.SetNX(ctx, attemptKey(session.ID), []byte("1"), g.cfg.SessionTTL)
+		if err != nil {
+			return nil, fmt.Errorf("claim quiz attempt: %w", err)
+		}
+		if !claimed {
+			g.record(ctx, userID, quizID, RepeatAttempt, "Quiz submitted again in the same session")
+			return nil, &Violation{Status: http.StatusConflict, Code: "already_submitted", Message: "This quiz has already been submitted"}
+		}
+	}
+
+	elapsed := time.Since(session.IssuedAt)
+	if minimum := g.cfg.MinTimePerQuestion * time.Duration(len(session.Questions)); elapsed < minimum {
+		g.record(ctx, userID, quizID, TooFast, fmt.Sprintf("%d questions answered in %s, under the %s minimum",
+			len(session.Questions), elapsed.Round(time.Millisecond), minimum))
+	}
+	return &session, nil
+}
+
+// Release gives back a session's attempt, for submissions that could not be
+// graded
+func (g *Guard) Release(ctx context.Context, session *Session) {
+	if session == nil || g.cfg.AllowRetakes {
+		return
+	}
+	if err := g.store.Delete(ctx, attemptKey(session.ID)); err != nil {
+		log.Printf("Failed to release quiz attempt %s: %v", session.ID, err)
+	}
+}
+
+// validate returns the kind and description of the first answer the
+// session doesn't allow
+func (s *Session) validate(answers []Answer) (string, string) {
+	seen := make(map[string]bool, len(answers))
+	for _, answer := range answers {
+		question, ok := s.Questions[answer.QuestionID]
+		if !ok {
+			return UnknownQuestion, fmt.Sprintf("Question %q is not part of this quiz", answer.QuestionID)
+		}
+		if seen[answer.QuestionID] {
+			return DuplicateAnswer, fmt.Sprintf("Question %q is answered more than once", answer.QuestionID)
+		}
+		seen[answer.QuestionID] = true
+		if question.Type == models.QuestionShortAnswer {
+			if answer.OptionID != "" {
+				return UnknownOption, fmt.Sprintf("Question %q takes a written answer, not an option", answer.QuestionID)
+			}
+			continue
+		}
+		if answer.OptionID != "" && !slices.Contains(question.Options, answer.OptionID) {
+			return UnknownOption, fmt.Sprintf("Option %q is not an option of question %q", answer.OptionID, answer.QuestionID)
+		}
+	}
+	return "", ""
+}
+
+func (g *Guard) record(ctx context.Context, userID, quizID, kind, detail string) {
+	err := g.anomalies.RecordQuizAnomaly(ctx, repository.QuizAnomaly{UserID: userID, QuizID: quizID, Kind: kind, Detail: detail})
+	if err != nil {
+		log.Printf("Failed to record quiz anomaly: %v", err)
+	}
+}
+
+func sessionKey(quizID, userID string) string {
+	if userID == "" {
+		userID = anonymous
+	}
+	return "quizsession:" + quizID + ":" + userID
+}
+
+func attemptKey(sessionID string) string {
+	return "quizsession:" + sessionID + ":submitted"
+}
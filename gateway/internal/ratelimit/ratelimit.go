@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+)
+
+// Options configures a Limiter
+type Options struct {
+	Limit  int           // Requests per window and key; 0 disables the limiter
+	Window time.Duration // Length of each counting window
+	// Allowlist holds the IPs and CIDRs that are never limited, e.g.
+	// monitoring systems
+	Allowlist []string
+}
+
+// Decision is the outcome of counting a request
+type Decision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration // Until the window ends and the count starts over
+}
+
+// Limiter counts requests per key in fixed windows held in the shared
+// store, so the limit holds across replicas
+type Limiter struct {
+	store storage.KeyValue
+
+	mu        sync.RWMutex
+	opts      Options
+	allowlist []netip.Prefix
+}
+
+// New creates a limiter
+func New(store storage.KeyValue, opts Options) (*Limiter, error) {
+	l := &Limiter{store: store}
+	if err := l.Configure(opts); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Configure updates the limit and allowlist. Counts in the current window
+// are kept.
+func (l *Limiter) Configure(opts Options) error {
+	allowlist, err := parsePrefixes(opts.Allowlist)
+	if err != nil {
+		return err
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.opts, l.allowlist = opts, allowlist
+	return nil
+}
+
+// Enabled reports whether requests are limited at all
+func (l *Limiter) Enabled() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.opts.Limit > 0
+}
+
+// Exempt reports whether ip is on the allowlist
+func (l *Limiter) Exempt(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, prefix := range l.allowlist {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow counts a request for key in the current window
+func (l *Limiter) Allow(ctx context.Context, key string) (Decision, error) {
+	l.mu.RLock()
+	opts := l.opts
+	l.mu.RUnlock()
+	if opts.Limit <= 0 {
+		return Decision{Allowed: true}, nil
+	}
+
+	now := time.Now()
+	start := now.Truncate(opts.Window)
+	// The window is part of the key so limiters of different windows
+	// counting the same key don't share counters
+	counter := "ratelimit:" + key + ":" + opts.Window.String() + ":" + strconv.FormatInt(start.Unix(), 10)
+	count, err := l.store.Incr(ctx, counter, opts.Window)
+	if err != nil {
+		return Decision{Allowed: true}, err
+	}
+	return Decision{
+		Allowed:   count <= int64(opts.Limit),
+		Limit:     opts.Limit,
+		Remaining: opts.Limit - int(count),
+		Reset:     start.Add(opts.Window).Sub(now),
+	}, nil
+}
+
+// parsePrefixes reads addresses and CIDRs, treating a bare address as a
+// prefix of one
+func parsePrefixes(entries []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("ratelimit: invalid allowlist entry %q", entry)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("ratelimit: invalid allowlist entry %q", entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,616 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NewMemory returns repositories held in process memory. Data is lost on
+// restart; use Postgres in production.
+func NewMemory() *Repositories {
+	m := &memoryStore{
+		notes:       map[string]Note{},
+		bookmarks:   map[string]Bookmark{},
+		progress:    map[string]Progress{},
+		activity:    map[string]map[time.Time]bool{},
+		shareTokens: map[string]ShareToken{},
+		gradeLeases: map[string]time.Time{},
+	}
+	return &Repositories{Notes: m, Bookmarks: m, Progress: m, TimeLogs: m, Focus: m, ShareTokens: m, Quizzes: m, Anomalies: m, Users: m, Outbox: m}
+}
+
+type memoryStore struct {
+	mu          sync.RWMutex
+	notes       map[string]Note
+	bookmarks   map[string]Bookmark
+	progress    map[string]Progress // Keyed by user/plan/milestone
+	activity    map[string]map[time.Time]bool
+	timeLogs    []TimeLog // Oldest first
+	focus       []FocusSession
+	shareTokens map[string]ShareToken
+	attempts    []QuizAttempt
+	gradeLeases map[string]time.Time // Pending attempts being graded, by ID
+	anomalies   []QuizAnomaly        // Oldest first
+	outbox      []outboxEntry        // Oldest first
+}
+
+type outboxEntry struct {
+	event       OutboxEvent
+	leasedUntil time.Time
+}
+
+func (m *memoryStore) ListNotes(_ context.Context, userID, planID string) ([]Note, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	notes := []Note{}
+	for _, note := range m.notes {
+		if note.UserID == userID && (planID == "" || note.PlanID == planID) {
+			notes = append(notes, note)
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].CreatedAt.Before(notes[j].CreatedAt) })
+	return notes, nil
+}
+
+func (m *memoryStore) CreateNote(_ context.Context, note Note) (Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	note.ID = uuid.NewString()
+	note.CreatedAt, note.UpdatedAt = now, now
+	m.notes[note.ID] = note
+	return note, nil
+}
+
+func (m *memoryStore) DeleteNote(_ context.Context, userID, noteID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if note, ok := m.notes[noteID]; !ok || note.UserID != userID {
+		return ErrNotFound
+	}
+	delete(m.notes, noteID)
+	return nil
+}
+
+func (m *memoryStore) ListBookmarks(_ context.Context, userID string) ([]Bookmark, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bookmarks := []Bookmark{}
+	for _, bookmark := range m.bookmarks {
+		if bookmark.UserID == userID {
+			bookmarks = append(bookmarks, bookmark)
+		}
+	}
+	sort.Slice(bookmarks, func(i, j int) bool { return bookmarks[i].CreatedAt.After(bookmarks[j].CreatedAt) })
+	return bookmarks, nil
+}
+
+func (m *memoryStore) AddBookmark(_ context.Context, bookmark Bookmark) (Bookmark, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Bookmarking the same resource twice returns the existing bookmark
+	for _, existing := range m.bookmarks {
+		if existing.UserID == bookmark.UserID && existing.ResourceID == bookmark.ResourceID {
+			return existing, nil
+		}
+	}
+	bookmark.ID = uuid.NewString()
+	bookmark.CreatedAt = time.Now().UTC()
+	m.bookmarks[bookmark.ID] = bookmark
+	return bookmark, nil
+}
+
+func (m *memoryStore) RemoveBookmark(_ context.Context, userID, bookmarkID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if bookmark, ok := m.bookmarks[bookmarkID]; !ok || bookmark.UserID != userID {
+		return ErrNotFound
+	}
+	delete(m.bookmarks, bookmarkID)
+	return nil
+}
+
+func (m *memoryStore) RecordProgress(_ context.Context, progress Progress) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	if progress.UpdatedAt.IsZero() {
+		progress.UpdatedAt = now
+	}
+	m.progress[progress.UserID+"/"+progress.PlanID+"/"+progress.MilestoneID] = progress
+
+	days, ok := m.activity[progress.UserID]
+	if !ok {
+		days = map[time.Time]bool{}
+		m.activity[progress.UserID] = days
+	}
+	days[now.Truncate(24*time.Hour)] = true
+	return nil
+}
+
+func (m *memoryStore) RecordActivity(_ context.Context, userID string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	days, ok := m.activity[userID]
+	if !ok {
+		days = map[time.Time]bool{}
+		m.activity[userID] = days
+	}
+	days[at.UTC().Truncate(24*time.Hour)] = true
+	return nil
+}
+
+func (m *memoryStore) ListProgress(_ context.Context, userID, planID string) ([]Progress, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := []Progress{}
+	for _, progress := range m.progress {
+		if progress.UserID == userID && (planID == "" || progress.PlanID == planID) {
+			entries = append(entries, progress)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].MilestoneID < entries[j].MilestoneID })
+	return entries, nil
+}
+
+func (m *memoryStore) ActiveDays(_ context.Context, userID string) ([]time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	days := make([]time.Time, 0, len(m.activity[userID]))
+	for day := range m.activity[userID] {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].After(days[j]) })
+	return days, nil
+}
+
+func (m *memoryStore) ActiveUsers(_ context.Context, since time.Time) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	since = since.UTC().Truncate(24 * time.Hour)
+	var users []string
+	for userID, days := range m.activity {
+		for day := range days {
+			if !day.Before(since) {
+				users = append(users, userID)
+				break
+			}
+		}
+	}
+	sort.Strings(users)
+	return users, nil
+}
+
+func (m *memoryStore) RecordQuizAttempt(_ context.Context, attempt QuizAttempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if attempt.ID == "" {
+		attempt.ID = uuid.NewString()
+	}
+	if attempt.Status == "" {
+		attempt.Status = QuizAttemptGraded
+	}
+	m.attempts = append(m.attempts, attempt)
+	return nil
+}
+
+func (m *memoryStore) CountQuizAttempts(_ context.Context, userID string, since time.Time) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var n int
+	for _, attempt := range m.attempts {
+		if attempt.UserID == userID && !attempt.SubmittedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memoryStore) ListQuizAttempts(_ context.Context, userID string) ([]QuizAttempt, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var attempts []QuizAttempt
+	for _, attempt := range m.attempts {
+		if attempt.UserID == userID {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts, nil
+}
+
+func (m *memoryStore) GetQuizAttempt(_ context.Context, userID, attemptID string) (QuizAttempt, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, attempt := range m.attempts {
+		if attempt.ID == attemptID && attempt.UserID == userID {
+			return attempt, nil
+		}
+	}
+	return QuizAttempt{}, ErrNotFound
+}
+
+func (m *memoryStore) ClaimPendingQuizAttempts(_ context.Context, limit int, lease time.Duration) ([]QuizAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var claimed []QuizAttempt
+	for i := range m.attempts {
+		if len(claimed) == limit {
+			break
+		}
+		attempt := &m.attempts[i]
+		if attempt.Status != QuizAttemptPending || m.gradeLeases[attempt.ID].After(now) {
+			continue
+		}
+		m.gradeLeases[attempt.ID] = now.Add(lease)
+		attempt.GradingAttempts++
+		claimed = append(claimed, *attempt)
+	}
+	return claimed, nil
+}
+
+func (m *memoryStore) CompleteQuizAttempt(_ context.Context, graded QuizAttempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, attempt := range m.attempts {
+		if attempt.ID != graded.ID {
+			continue
+		}
+		now := time.Now().UTC()
+		attempt.Status, attempt.GradedAt = QuizAttemptGraded, &now
+		attempt.Score, attempt.Results = graded.Score, graded.Results
+		m.attempts[i] = attempt
+		delete(m.gradeLeases, attempt.ID)
+		return nil
+	}
+	return ErrNotFound
+}
+
+func (m *memoryStore) StartTimer(_ context.Context, entry TimeLog) (TimeLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.timeLogs {
+		if existing.EndedAt == nil && existing.UserID == entry.UserID && existing.PlanID == entry.PlanID && existing.ResourceID == entry.ResourceID {
+			return TimeLog{}, ErrTimerRunning
+		}
+	}
+	entry.ID = uuid.NewString()
+	entry.EndedAt, entry.Minutes = nil, 0
+	m.timeLogs = append(m.timeLogs, entry)
+	return entry, nil
+}
+
+func (m *memoryStore) StopTimer(_ context.Context, userID, planID, resourceID string, endedAt time.Time, limit time.Duration) (TimeLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.timeLogs {
+		entry := &m.timeLogs[i]
+		if entry.EndedAt != nil || entry.UserID != userID || entry.PlanID != planID || entry.ResourceID != resourceID {
+			continue
+		}
+		if cutoff := entry.StartedAt.Add(limit); endedAt.After(cutoff) {
+			endedAt = cutoff
+		}
+		if endedAt.Before(entry.StartedAt) {
+			endedAt = entry.StartedAt
+		}
+		entry.EndedAt = &endedAt
+		entry.Minutes = endedAt.Sub(entry.StartedAt).Minutes()
+		return *entry, nil
+	}
+	return TimeLog{}, ErrNotFound
+}
+
+func (m *memoryStore) AddTimeLog(_ context.Context, entry TimeLog) (TimeLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry.ID = uuid.NewString()
+	m.timeLogs = append(m.timeLogs, entry)
+	return entry, nil
+}
+
+func (m *memoryStore) ListTimeLogs(_ context.Context, userID, planID string) ([]TimeLog, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := []TimeLog{}
+	for _, entry := range m.timeLogs {
+		if entry.UserID == userID && (planID == "" || entry.PlanID == planID) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].StartedAt.Before(entries[j].StartedAt) })
+	return entries, nil
+}
+
+func (m *memoryStore) StartFocusSession(_ context.Context, session FocusSession) (FocusSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.focus {
+		if existing.UserID == session.UserID && existing.EndedAt == nil {
+			return FocusSession{}, ErrFocusSessionOpen
+		}
+	}
+	session.ID = uuid.NewString()
+	m.focus = append(m.focus, session)
+	return session, nil
+}
+
+func (m *memoryStore) GetFocusSession(_ context.Context, userID, sessionID string) (FocusSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, session := range m.focus {
+		if session.ID == sessionID && session.UserID == userID {
+			return session, nil
+		}
+	}
+	return FocusSession{}, ErrNotFound
+}
+
+func (m *memoryStore) OpenFocusSession(_ context.Context, userID string) (FocusSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, session := range m.focus {
+		if session.UserID == userID && session.EndedAt == nil {
+			return session, nil
+		}
+	}
+	return FocusSession{}, ErrNotFound
+}
+
+func (m *memoryStore) UpdateFocusSession(_ context.Context, session FocusSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.focus {
+		if m.focus[i].ID == session.ID && m.focus[i].UserID == session.UserID {
+			m.focus[i] = session
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *memoryStore) ListFocusSessions(_ context.Context, userID string, from, to time.Time) ([]FocusSession, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := []FocusSession{}
+	for _, session := range m.focus {
+		if session.UserID == userID && !session.StartedAt.Before(from) && session.StartedAt.Before(to) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].StartedAt.Before(sessions[j].StartedAt) })
+	return sessions, nil
+}
+
+func (m *memoryStore) RecordQuizAnomaly(_ context.Context, anomaly QuizAnomaly) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if anomaly.ID == "" {
+		anomaly.ID = uuid.NewString()
+	}
+	if anomaly.CreatedAt.IsZero() {
+		anomaly.CreatedAt = time.Now().UTC()
+	}
+	m.anomalies = append(m.anomalies, anomaly)
+	return nil
+}
+
+func (m *memoryStore) ListQuizAnomalies(_ context.Context, filter QuizAnomalyFilter) ([]QuizAnomaly, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	anomalies := []QuizAnomaly{}
+	for i := len(m.anomalies) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(anomalies) == filter.Limit {
+			break
+		}
+		anomaly := m.anomalies[i]
+		if (filter.UserID == "" || anomaly.UserID == filter.UserID) && (filter.QuizID == "" || anomaly.QuizID == filter.QuizID) {
+			anomalies = append(anomalies, anomaly)
+		}
+	}
+	return anomalies, nil
+}
+
+func (m *memoryStore) CreateShareToken(_ context.Context, token ShareToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shareTokens[token.Token] = token
+	return nil
+}
+
+func (m *memoryStore) GetShareToken(_ context.Context, token string) (ShareToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	shareToken, ok := m.shareTokens[token]
+	if !ok {
+		return ShareToken{}, ErrNotFound
+	}
+	return shareToken, nil
+}
+
+func (m *memoryStore) RevokeShareToken(_ context.Context, userID, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if shareToken, ok := m.shareTokens[token]; !ok || shareToken.UserID != userID {
+		return ErrNotFound
+	}
+	delete(m.shareTokens, token)
+	return nil
+}
+
+func (m *memoryStore) MergeUser(_ context.Context, fromUserID, toUserID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, note := range m.notes {
+		if note.UserID == fromUserID {
+			note.UserID = toUserID
+			m.notes[id] = note
+		}
+	}
+	saved := map[string]bool{}
+	for _, bookmark := range m.bookmarks {
+		if bookmark.UserID == toUserID {
+			saved[bookmark.ResourceID] = true
+		}
+	}
+	for id, bookmark := range m.bookmarks {
+		if bookmark.UserID != fromUserID {
+			continue
+		}
+		if saved[bookmark.ResourceID] {
+			delete(m.bookmarks, id)
+			continue
+		}
+		bookmark.UserID = toUserID
+		m.bookmarks[id] = bookmark
+	}
+	for key, progress := range m.progress {
+		if progress.UserID != fromUserID {
+			continue
+		}
+		delete(m.progress, key)
+		progress.UserID = toUserID
+		key = toUserID + "/" + progress.PlanID + "/" + progress.MilestoneID
+		if existing, ok := m.progress[key]; !ok || existing.UpdatedAt.Before(progress.UpdatedAt) {
+			m.progress[key] = progress
+		}
+	}
+	if days, ok := m.activity[fromUserID]; ok {
+		if m.activity[toUserID] == nil {
+			m.activity[toUserID] = map[time.Time]bool{}
+		}
+		for day := range days {
+			m.activity[toUserID][day] = true
+		}
+		delete(m.activity, fromUserID)
+	}
+	for i := range m.attempts {
+		if m.attempts[i].UserID == fromUserID {
+			m.attempts[i].UserID = toUserID
+		}
+	}
+	// A running timer the target also has on the resource is dropped
+	running := map[string]bool{}
+	for _, entry := range m.timeLogs {
+		if entry.UserID == toUserID && entry.EndedAt == nil {
+			running[entry.PlanID+"/"+entry.ResourceID] = true
+		}
+	}
+	timeLogs := m.timeLogs[:0]
+	for _, entry := range m.timeLogs {
+		if entry.UserID == fromUserID {
+			if entry.EndedAt == nil && running[entry.PlanID+"/"+entry.ResourceID] {
+				continue
+			}
+			entry.UserID = toUserID
+		}
+		timeLogs = append(timeLogs, entry)
+	}
+	m.timeLogs = timeLogs
+	// So is an open focus session when the target has one
+	open := false
+	for _, session := range m.focus {
+		open = open || (session.UserID == toUserID && session.EndedAt == nil)
+	}
+	sessions := m.focus[:0]
+	for _, session := range m.focus {
+		if session.UserID == fromUserID {
+			if session.EndedAt == nil && open {
+				continue
+			}
+			session.UserID = toUserID
+		}
+		sessions = append(sessions, session)
+	}
+	m.focus = sessions
+	for token, shareToken := range m.shareTokens {
+		if shareToken.UserID == fromUserID {
+			shareToken.UserID = toUserID
+			m.shareTokens[token] = shareToken
+		}
+	}
+	return nil
+}
+
+func (m *memoryStore) Enqueue(_ context.Context, event OutboxEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+	m.outbox = append(m.outbox, outboxEntry{event: event})
+	return nil
+}
+
+func (m *memoryStore) Claim(_ context.Context, limit int, lease time.Duration) ([]OutboxEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var claimed []OutboxEvent
+	for i := range m.outbox {
+		if len(claimed) == limit {
+			break
+		}
+		entry := &m.outbox[i]
+		if entry.leasedUntil.After(now) {
+			continue
+		}
+		entry.leasedUntil = now.Add(lease)
+		entry.event.Attempts++
+		claimed = append(claimed, entry.event)
+	}
+	return claimed, nil
+}
+
+func (m *memoryStore) Ack(_ context.Context, ids []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	acked := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		acked[id] = true
+	}
+	kept := m.outbox[:0]
+	for _, entry := range m.outbox {
+		if !acked[entry.event.ID] {
+			kept = append(kept, entry)
+		}
+	}
+	m.outbox = kept
+	return nil
+}
@@ -0,0 +1,47 @@
+-- Gateway-owned learner data: notes, bookmarks, progress, streaks, share tokens
+
+CREATE TABLE IF NOT EXISTS notes (
+    note_id      UUID PRIMARY KEY,
+    user_id      TEXT NOT NULL,
+    plan_id      TEXT NOT NULL,
+    milestone_id TEXT NOT NULL DEFAULT '',
+    body         TEXT NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS notes_user_plan_idx ON notes (user_id, plan_id);
+
+CREATE TABLE IF NOT EXISTS bookmarks (
+    bookmark_id UUID PRIMARY KEY,
+    user_id     TEXT NOT NULL,
+    resource_id TEXT NOT NULL,
+    plan_id     TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (user_id, resource_id)
+);
+
+CREATE TABLE IF NOT EXISTS milestone_progress (
+    user_id      TEXT NOT NULL,
+    plan_id      TEXT NOT NULL,
+    milestone_id TEXT NOT NULL,
+    completed    BOOLEAN NOT NULL DEFAULT false,
+    hours_spent  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, plan_id, milestone_id)
+);
+
+-- One row per user per day with any progress; streaks are derived from it
+CREATE TABLE IF NOT EXISTS user_activity (
+    user_id TEXT NOT NULL,
+    day     DATE NOT NULL,
+    PRIMARY KEY (user_id, day)
+);
+
+CREATE TABLE IF NOT EXISTS share_tokens (
+    token      TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    plan_id    TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS share_tokens_plan_idx ON share_tokens (plan_id);
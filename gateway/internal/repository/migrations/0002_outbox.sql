@@ -0,0 +1,11 @@
+-- Transactional outbox for domain events relayed to the message bus
+
+CREATE TABLE IF NOT EXISTS outbox (
+    event_id     UUID PRIMARY KEY,
+    event_type   TEXT NOT NULL,
+    payload      BYTEA NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    leased_until TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS outbox_created_idx ON outbox (created_at);
@@ -0,0 +1,9 @@
+-- Quiz submissions, counted in weekly digests
+
+CREATE TABLE IF NOT EXISTS quiz_attempts (
+    attempt_id   BIGSERIAL PRIMARY KEY,
+    user_id      TEXT NOT NULL,
+    quiz_id      TEXT NOT NULL,
+    submitted_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS quiz_attempts_user_idx ON quiz_attempts (user_id, submitted_at);
@@ -0,0 +1,4 @@
+-- Scores and per-question outcomes of quiz submissions, for quiz analytics
+
+ALTER TABLE quiz_attempts ADD COLUMN IF NOT EXISTS score DOUBLE PRECISION;
+ALTER TABLE quiz_attempts ADD COLUMN IF NOT EXISTS results JSONB;
@@ -0,0 +1,10 @@
+-- Attempts with short answers stay pending until the grading worker has
+-- graded them
+
+ALTER TABLE quiz_attempts ADD COLUMN IF NOT EXISTS attempt_uuid UUID NOT NULL DEFAULT gen_random_uuid();
+ALTER TABLE quiz_attempts ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'graded';
+ALTER TABLE quiz_attempts ADD COLUMN IF NOT EXISTS graded_at TIMESTAMPTZ;
+ALTER TABLE quiz_attempts ADD COLUMN IF NOT EXISTS grading_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE quiz_attempts ADD COLUMN IF NOT EXISTS leased_until TIMESTAMPTZ;
+CREATE UNIQUE INDEX IF NOT EXISTS quiz_attempts_uuid_idx ON quiz_attempts (attempt_uuid);
+CREATE INDEX IF NOT EXISTS quiz_attempts_pending_idx ON quiz_attempts (submitted_at) WHERE status = 'pending';
@@ -0,0 +1,12 @@
+-- Suspicious quiz submissions, reviewed by admins
+
+CREATE TABLE IF NOT EXISTS quiz_anomalies (
+    anomaly_id  UUID PRIMARY KEY,
+    user_id     TEXT NOT NULL,
+    quiz_id     TEXT NOT NULL,
+    kind        TEXT NOT NULL,
+    detail      TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS quiz_anomalies_created_idx ON quiz_anomalies (created_at);
+CREATE INDEX IF NOT EXISTS quiz_anomalies_user_idx ON quiz_anomalies (user_id, created_at);
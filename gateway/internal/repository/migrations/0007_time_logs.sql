@@ -0,0 +1,14 @@
+-- Time learners spent on plan resources, timed or logged by hand
+
+CREATE TABLE IF NOT EXISTS time_logs (
+    time_log_id UUID PRIMARY KEY,
+    user_id     TEXT NOT NULL,
+    plan_id     TEXT NOT NULL,
+    resource_id TEXT NOT NULL,
+    started_at  TIMESTAMPTZ NOT NULL,
+    ended_at    TIMESTAMPTZ,
+    minutes     DOUBLE PRECISION NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS time_logs_user_plan_idx ON time_logs (user_id, plan_id, started_at);
+-- At most one running timer per resource
+CREATE UNIQUE INDEX IF NOT EXISTS time_logs_running_idx ON time_logs (user_id, plan_id, resource_id) WHERE ended_at IS NULL;
@@ -0,0 +1,18 @@
+-- Focus (Pomodoro) sessions on plan resources
+
+CREATE TABLE IF NOT EXISTS focus_sessions (
+    session_id      UUID PRIMARY KEY,
+    user_id         TEXT NOT NULL,
+    plan_id         TEXT NOT NULL,
+    resource_id     TEXT NOT NULL,
+    status          TEXT NOT NULL,
+    planned_minutes INTEGER NOT NULL,
+    focused_minutes DOUBLE PRECISION NOT NULL DEFAULT 0,
+    pauses          INTEGER NOT NULL DEFAULT 0,
+    started_at      TIMESTAMPTZ NOT NULL,
+    last_active_at  TIMESTAMPTZ NOT NULL,
+    ended_at        TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS focus_sessions_user_started_idx ON focus_sessions (user_id, started_at);
+-- At most one open session per user
+CREATE UNIQUE INDEX IF NOT EXISTS focus_sessions_open_idx ON focus_sessions (user_id) WHERE ended_at IS NULL;
@@ -0,0 +1,705 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostgresOptions configures the Postgres datastore
+type PostgresOptions struct {
+	Driver       string // database/sql driver name, e.g. pgx
+	URL          string
+	MaxOpenConns int
+}
+
+// NewPostgres connects to Postgres, applies pending migrations and returns
+// repositories backed by it. The driver must be registered with
+// database/sql by the binary.
+func NewPostgres(ctx context.Context, opts PostgresOptions) (*Repositories, error) {
+	db, err := sql.Open(opts.Driver, opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	p := &postgresStore{db: db}
+	return &Repositories{Notes: p, Bookmarks: p, Progress: p, TimeLogs: p, Focus: p, ShareTokens: p, Quizzes: p, Anomalies: p, Users: p, Outbox: p, close: db.Close}, nil
+}
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+func (p *postgresStore) ListNotes(ctx context.Context, userID, planID string) ([]Note, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT note_id, user_id, plan_id, milestone_id, body, created_at, updated_at
+		FROM notes
+		WHERE user_id = $1 AND ($2 = '' OR plan_id = $2)
+		ORDER BY created_at`, userID, planID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := []Note{}
+	for rows.Next() {
+		var note Note
+		if err := rows.Scan(&note.ID, &note.UserID, &note.PlanID, &note.MilestoneID, &note.Body, &note.CreatedAt, &note.UpdatedAt); err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	return notes, rows.Err()
+}
+
+func (p *postgresStore) CreateNote(ctx context.Context, note Note) (Note, error) {
+	note.ID = uuid.NewString()
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO notes (note_id, user_id, plan_id, milestone_id, body)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, updated_at`,
+		note.ID, note.UserID, note.PlanID, note.MilestoneID, note.Body,
+	).Scan(&note.CreatedAt, &note.UpdatedAt)
+	return note, err
+}
+
+func (p *postgresStore) DeleteNote(ctx context.Context, userID, noteID string) error {
+	return p.deleteOwned(ctx, `DELETE FROM notes WHERE note_id = $1 AND user_id = $2`, noteID, userID)
+}
+
+func (p *postgresStore) ListBookmarks(ctx context.Context, userID string) ([]Bookmark, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT bookmark_id, user_id, resource_id, plan_id, created_at
+		FROM bookmarks
+		WHERE user_id = $1
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookmarks := []Bookmark{}
+	for rows.Next() {
+		var bookmark Bookmark
+		if err := rows.Scan(&bookmark.ID, &bookmark.UserID, &bookmark.ResourceID, &bookmark.PlanID, &bookmark.CreatedAt); err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+	return bookmarks, rows.Err()
+}
+
+func (p *postgresStore) AddBookmark(ctx context.Context, bookmark Bookmark) (Bookmark, error) {
+	// Bookmarking the same resource twice returns the existing bookmark
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO bookmarks (bookmark_id, user_id, resource_id, plan_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, resource_id) DO UPDATE SET resource_id = EXCLUDED.resource_id
+		RETURNING bookmark_id, plan_id, created_at`,
+		uuid.NewString(), bookmark.UserID, bookmark.ResourceID, bookmark.PlanID,
+	).Scan(&bookmark.ID, &bookmark.PlanID, &bookmark.CreatedAt)
+	return bookmark, err
+}
+
+func (p *postgresStore) RemoveBookmark(ctx context.Context, userID, bookmarkID string) error {
+	return p.deleteOwned(ctx, `DELETE FROM bookmarks WHERE bookmark_id = $1 AND user_id = $2`, bookmarkID, userID)
+}
+
+func (p *postgresStore) RecordProgress(ctx context.Context, progress Progress) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO milestone_progress (user_id, plan_id, milestone_id, completed, hours_spent, updated_at)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6, now()))
+		ON CONFLICT (user_id, plan_id, milestone_id)
+		DO UPDATE SET completed = EXCLUDED.completed, hours_spent = EXCLUDED.hours_spent, updated_at = EXCLUDED.updated_at`,
+		progress.UserID, progress.PlanID, progress.MilestoneID, progress.Completed, progress.HoursSpent, nullTime(progress.UpdatedAt),
+	); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO user_activity (user_id, day)
+		VALUES ($1, (now() AT TIME ZONE 'UTC')::date)
+		ON CONFLICT DO NOTHING`, progress.UserID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p *postgresStore) ListProgress(ctx context.Context, userID, planID string) ([]Progress, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT user_id, plan_id, milestone_id, completed, hours_spent, updated_at
+		FROM milestone_progress
+		WHERE user_id = $1 AND ($2 = '' OR plan_id = $2)
+		ORDER BY milestone_id`, userID, planID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Progress{}
+	for rows.Next() {
+		var progress Progress
+		if err := rows.Scan(&progress.UserID, &progress.PlanID, &progress.MilestoneID, &progress.Completed, &progress.HoursSpent, &progress.UpdatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, progress)
+	}
+	return entries, rows.Err()
+}
+
+func (p *postgresStore) ActiveDays(ctx context.Context, userID string) ([]time.Time, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT day FROM user_activity WHERE user_id = $1 ORDER BY day DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []time.Time
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		days = append(days, day.UTC().Truncate(24*time.Hour))
+	}
+	return days, rows.Err()
+}
+
+func (p *postgresStore) RecordActivity(ctx context.Context, userID string, at time.Time) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO user_activity (user_id, day)
+		VALUES ($1, $2::date)
+		ON CONFLICT DO NOTHING`, userID, at.UTC().Format("2006-01-02"))
+	return err
+}
+
+func (p *postgresStore) ActiveUsers(ctx context.Context, since time.Time) ([]string, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT DISTINCT user_id FROM user_activity WHERE day >= $1::date ORDER BY user_id`,
+		since.UTC().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		users = append(users, userID)
+	}
+	return users, rows.Err()
+}
+
+// quizAttemptColumns are read by scanQuizAttempt
+const quizAttemptColumns = `attempt_uuid, user_id, quiz_id, status, submitted_at, graded_at, score, results, grading_attempts`
+
+func (p *postgresStore) RecordQuizAttempt(ctx context.Context, attempt QuizAttempt) error {
+	if attempt.ID == "" {
+		attempt.ID = uuid.NewString()
+	}
+	if attempt.Status == "" {
+		attempt.Status = QuizAttemptGraded
+	}
+	results, err := marshalOutcomes(attempt.Results)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, `
+		INSERT INTO quiz_attempts (attempt_uuid, user_id, quiz_id, status, submitted_at, score, results)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		attempt.ID, attempt.UserID, attempt.QuizID, attempt.Status, attempt.SubmittedAt, attempt.Score, results)
+	return err
+}
+
+func (p *postgresStore) CountQuizAttempts(ctx context.Context, userID string, since time.Time) (int, error) {
+	var n int
+	err := p.db.QueryRowContext(ctx, `
+		SELECT count(*) FROM quiz_attempts WHERE user_id = $1 AND submitted_at >= $2`,
+		userID, since).Scan(&n)
+	return n, err
+}
+
+func (p *postgresStore) ListQuizAttempts(ctx context.Context, userID string) ([]QuizAttempt, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT `+quizAttemptColumns+` FROM quiz_attempts
+		WHERE user_id = $1 ORDER BY submitted_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []QuizAttempt
+	for rows.Next() {
+		attempt, err := scanQuizAttempt(rows)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
+
+func (p *postgresStore) GetQuizAttempt(ctx context.Context, userID, attemptID string) (QuizAttempt, error) {
+	if _, err := uuid.Parse(attemptID); err != nil {
+		return QuizAttempt{}, ErrNotFound
+	}
+	attempt, err := scanQuizAttempt(p.db.QueryRowContext(ctx, `
+		SELECT `+quizAttemptColumns+` FROM quiz_attempts
+		WHERE attempt_uuid = $1 AND user_id = $2`, attemptID, userID))
+	if err == sql.ErrNoRows {
+		return QuizAttempt{}, ErrNotFound
+	}
+	return attempt, err
+}
+
+func (p *postgresStore) ClaimPendingQuizAttempts(ctx context.Context, limit int, lease time.Duration) ([]QuizAttempt, error) {
+	// SKIP LOCKED keeps concurrent graders from claiming the same rows
+	rows, err := p.db.QueryContext(ctx, `
+		UPDATE quiz_attempts
+		SET leased_until = now() + $2 * interval '1 millisecond', grading_attempts = grading_attempts + 1
+		WHERE attempt_id IN (
+			SELECT attempt_id FROM quiz_attempts
+			WHERE status = 'pending' AND (leased_until IS NULL OR leased_until < now())
+			ORDER BY submitted_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+quizAttemptColumns,
+		limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []QuizAttempt
+	for rows.Next() {
+		attempt, err := scanQuizAttempt(rows)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+	// RETURNING order is unspecified
+	sort.Slice(attempts, func(i, j int) bool { return attempts[i].SubmittedAt.Before(attempts[j].SubmittedAt) })
+	return attempts, rows.Err()
+}
+
+func (p *postgresStore) CompleteQuizAttempt(ctx context.Context, attempt QuizAttempt) error {
+	results, err := marshalOutcomes(attempt.Results)
+	if err != nil {
+		return err
+	}
+	result, err := p.db.ExecContext(ctx, `
+		UPDATE quiz_attempts
+		SET status = 'graded', graded_at = now(), score = $2, results = $3, leased_until = NULL
+		WHERE attempt_uuid = $1`,
+		attempt.ID, attempt.Score, results)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// timeLogColumns are the columns scanTimeLog reads, in order
+const timeLogColumns = `time_log_id, user_id, plan_id, resource_id, started_at, ended_at, minutes`
+
+func scanTimeLog(row interface{ Scan(...any) error }) (TimeLog, error) {
+	var entry TimeLog
+	err := row.Scan(&entry.ID, &entry.UserID, &entry.PlanID, &entry.ResourceID, &entry.StartedAt, &entry.EndedAt, &entry.Minutes)
+	return entry, err
+}
+
+func (p *postgresStore) StartTimer(ctx context.Context, entry TimeLog) (TimeLog, error) {
+	entry.ID = uuid.NewString()
+	entry.EndedAt, entry.Minutes = nil, 0
+	// The partial unique index allows one running timer per resource
+	result, err := p.db.ExecContext(ctx, `
+		INSERT INTO time_logs (time_log_id, user_id, plan_id, resource_id, started_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING`,
+		entry.ID, entry.UserID, entry.PlanID, entry.ResourceID, entry.StartedAt)
+	if err != nil {
+		return TimeLog{}, err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return TimeLog{}, err
+	} else if n == 0 {
+		return TimeLog{}, ErrTimerRunning
+	}
+	return entry, nil
+}
+
+func (p *postgresStore) StopTimer(ctx context.Context, userID, planID, resourceID string, endedAt time.Time, limit time.Duration) (TimeLog, error) {
+	entry, err := scanTimeLog(p.db.QueryRowContext(ctx, `
+		WITH stop AS (
+			SELECT time_log_id, GREATEST(started_at, LEAST($4::timestamptz, started_at + $5 * interval '1 millisecond')) AS ended_at
+			FROM time_logs
+			WHERE user_id = $1 AND plan_id = $2 AND resource_id = $3 AND ended_at IS NULL
+		)
+		UPDATE time_logs t
+		SET ended_at = stop.ended_at, minutes = EXTRACT(EPOCH FROM stop.ended_at - t.started_at) / 60
+		FROM stop WHERE t.time_log_id = stop.time_log_id
+		RETURNING t.time_log_id, t.user_id, t.plan_id, t.resource_id, t.started_at, t.ended_at, t.minutes`,
+		userID, planID, resourceID, endedAt, limit.Milliseconds()))
+	if err == sql.ErrNoRows {
+		return TimeLog{}, ErrNotFound
+	}
+	return entry, err
+}
+
+func (p *postgresStore) AddTimeLog(ctx context.Context, entry TimeLog) (TimeLog, error) {
+	entry.ID = uuid.NewString()
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO time_logs (`+timeLogColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		entry.ID, entry.UserID, entry.PlanID, entry.ResourceID, entry.StartedAt, entry.EndedAt, entry.Minutes)
+	if err != nil {
+		return TimeLog{}, err
+	}
+	return entry, nil
+}
+
+func (p *postgresStore) ListTimeLogs(ctx context.Context, userID, planID string) ([]TimeLog, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT `+timeLogColumns+` FROM time_logs
+		WHERE user_id = $1 AND ($2 = '' OR plan_id = $2)
+		ORDER BY started_at`, userID, planID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []TimeLog{}
+	for rows.Next() {
+		entry, err := scanTimeLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// focusSessionColumns are the columns scanFocusSession reads, in order
+const focusSessionColumns = `session_id, user_id, plan_id, resource_id, status, planned_minutes,
+	focused_minutes, pauses, started_at, last_active_at, ended_at`
+
+func scanFocusSession(row interface{ Scan(...any) error }) (FocusSession, error) {
+	var s FocusSession
+	err := row.Scan(&s.ID, &s.UserID, &s.PlanID, &s.ResourceID, &s.Status, &s.PlannedMinutes,
+		&s.FocusedMinutes, &s.Pauses, &s.StartedAt, &s.LastActiveAt, &s.EndedAt)
+	if err == sql.ErrNoRows {
+		return FocusSession{}, ErrNotFound
+	}
+	return s, err
+}
+
+func (p *postgresStore) StartFocusSession(ctx context.Context, s FocusSession) (FocusSession, error) {
+	s.ID = uuid.NewString()
+	// The partial unique index allows one open session per user
+	result, err := p.db.ExecContext(ctx, `
+		INSERT INTO focus_sessions (`+focusSessionColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT DO NOTHING`,
+		s.ID, s.UserID, s.PlanID, s.ResourceID, s.Status, s.PlannedMinutes,
+		s.FocusedMinutes, s.Pauses, s.StartedAt, s.LastActiveAt, s.EndedAt)
+	if err != nil {
+		return FocusSession{}, err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return FocusSession{}, err
+	} else if n == 0 {
+		return FocusSession{}, ErrFocusSessionOpen
+	}
+	return s, nil
+}
+
+func (p *postgresStore) GetFocusSession(ctx context.Context, userID, sessionID string) (FocusSession, error) {
+	return scanFocusSession(p.db.QueryRowContext(ctx, `
+		SELECT `+focusSessionColumns+` FROM focus_sessions
+		WHERE session_id = $1 AND user_id = $2`, sessionID, userID))
+}
+
+func (p *postgresStore) OpenFocusSession(ctx context.Context, userID string) (FocusSession, error) {
+	return scanFocusSession(p.db.QueryRowContext(ctx, `
+		SELECT `+focusSessionColumns+` FROM focus_sessions
+		WHERE user_id = $1 AND ended_at IS NULL`, userID))
+}
+
+func (p *postgresStore) UpdateFocusSession(ctx context.Context, s FocusSession) error {
+	result, err := p.db.ExecContext(ctx, `
+		UPDATE focus_sessions
+		SET status = $3, focused_minutes = $4, pauses = $5, last_active_at = $6, ended_at = $7
+		WHERE session_id = $1 AND user_id = $2`,
+		s.ID, s.UserID, s.Status, s.FocusedMinutes, s.Pauses, s.LastActiveAt, s.EndedAt)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (p *postgresStore) ListFocusSessions(ctx context.Context, userID string, from, to time.Time) ([]FocusSession, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT `+focusSessionColumns+` FROM focus_sessions
+		WHERE user_id = $1 AND started_at >= $2 AND started_at < $3
+		ORDER BY started_at`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []FocusSession{}
+	for rows.Next() {
+		s, err := scanFocusSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+func (p *postgresStore) RecordQuizAnomaly(ctx context.Context, anomaly QuizAnomaly) error {
+	if anomaly.ID == "" {
+		anomaly.ID = uuid.NewString()
+	}
+	if anomaly.CreatedAt.IsZero() {
+		anomaly.CreatedAt = time.Now().UTC()
+	}
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO quiz_anomalies (anomaly_id, user_id, quiz_id, kind, detail, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		anomaly.ID, anomaly.UserID, anomaly.QuizID, anomaly.Kind, anomaly.Detail, anomaly.CreatedAt)
+	return err
+}
+
+func (p *postgresStore) ListQuizAnomalies(ctx context.Context, filter QuizAnomalyFilter) ([]QuizAnomaly, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = math.MaxInt32
+	}
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT anomaly_id, user_id, quiz_id, kind, detail, created_at FROM quiz_anomalies
+		WHERE ($1 = '' OR user_id = $1) AND ($2 = '' OR quiz_id = $2)
+		ORDER BY created_at DESC
+		LIMIT $3`,
+		filter.UserID, filter.QuizID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	anomalies := []QuizAnomaly{}
+	for rows.Next() {
+		var anomaly QuizAnomaly
+		if err := rows.Scan(&anomaly.ID, &anomaly.UserID, &anomaly.QuizID, &anomaly.Kind, &anomaly.Detail, &anomaly.CreatedAt); err != nil {
+			return nil, err
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+	return anomalies, rows.Err()
+}
+
+// scanQuizAttempt reads the quizAttemptColumns of a row
+func scanQuizAttempt(row interface{ Scan(...any) error }) (QuizAttempt, error) {
+	var attempt QuizAttempt
+	var results []byte
+	if err := row.Scan(&attempt.ID, &attempt.UserID, &attempt.QuizID, &attempt.Status, &attempt.SubmittedAt,
+		&attempt.GradedAt, &attempt.Score, &results, &attempt.GradingAttempts); err != nil {
+		return QuizAttempt{}, err
+	}
+	if results != nil {
+		if err := json.Unmarshal(results, &attempt.Results); err != nil {
+			return QuizAttempt{}, fmt.Errorf("quiz attempt results: %w", err)
+		}
+	}
+	return attempt, nil
+}
+
+// marshalOutcomes maps nil results to NULL
+func marshalOutcomes(results []QuestionOutcome) ([]byte, error) {
+	if results == nil {
+		return nil, nil
+	}
+	return json.Marshal(results)
+}
+
+func (p *postgresStore) CreateShareToken(ctx context.Context, token ShareToken) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO share_tokens (token, user_id, plan_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		token.Token, token.UserID, token.PlanID, token.CreatedAt, token.ExpiresAt)
+	return err
+}
+
+func (p *postgresStore) GetShareToken(ctx context.Context, token string) (ShareToken, error) {
+	var shareToken ShareToken
+	err := p.db.QueryRowContext(ctx, `
+		SELECT token, user_id, plan_id, created_at, expires_at
+		FROM share_tokens WHERE token = $1`, token,
+	).Scan(&shareToken.Token, &shareToken.UserID, &shareToken.PlanID, &shareToken.CreatedAt, &shareToken.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return ShareToken{}, ErrNotFound
+	}
+	return shareToken, err
+}
+
+func (p *postgresStore) RevokeShareToken(ctx context.Context, userID, token string) error {
+	return p.deleteOwned(ctx, `DELETE FROM share_tokens WHERE token = $1 AND user_id = $2`, token, userID)
+}
+
+func (p *postgresStore) MergeUser(ctx context.Context, fromUserID, toUserID string) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Rows the target already has (bookmarks, progress days) are merged
+	// before the source's are deleted
+	moves := []string{
+		`UPDATE notes SET user_id = $2 WHERE user_id = $1`,
+		`UPDATE bookmarks b SET user_id = $2
+		WHERE user_id = $1 AND NOT EXISTS (
+			SELECT 1 FROM bookmarks WHERE user_id = $2 AND resource_id = b.resource_id
+		)`,
+		`INSERT INTO milestone_progress (user_id, plan_id, milestone_id, completed, hours_spent, updated_at)
+		SELECT $2, plan_id, milestone_id, completed, hours_spent, updated_at
+		FROM milestone_progress WHERE user_id = $1
+		ON CONFLICT (user_id, plan_id, milestone_id)
+		DO UPDATE SET completed = EXCLUDED.completed, hours_spent = EXCLUDED.hours_spent, updated_at = EXCLUDED.updated_at
+		WHERE milestone_progress.updated_at < EXCLUDED.updated_at`,
+		`INSERT INTO user_activity (user_id, day)
+		SELECT $2, day FROM user_activity WHERE user_id = $1
+		ON CONFLICT DO NOTHING`,
+		`UPDATE quiz_attempts SET user_id = $2 WHERE user_id = $1`,
+		`DELETE FROM time_logs t
+		WHERE user_id = $1 AND ended_at IS NULL AND EXISTS (
+			SELECT 1 FROM time_logs
+			WHERE user_id = $2 AND plan_id = t.plan_id AND resource_id = t.resource_id AND ended_at IS NULL
+		)`,
+		`UPDATE time_logs SET user_id = $2 WHERE user_id = $1`,
+		`DELETE FROM focus_sessions
+		WHERE user_id = $1 AND ended_at IS NULL AND EXISTS (
+			SELECT 1 FROM focus_sessions WHERE user_id = $2 AND ended_at IS NULL
+		)`,
+		`UPDATE focus_sessions SET user_id = $2 WHERE user_id = $1`,
+		`UPDATE share_tokens SET user_id = $2 WHERE user_id = $1`,
+	}
+	for _, query := range moves {
+		if _, err := tx.ExecContext(ctx, query, fromUserID, toUserID); err != nil {
+			return err
+		}
+	}
+	for _, table := range []string{"bookmarks", "milestone_progress", "user_activity"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE user_id = $1`, fromUserID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (p *postgresStore) Enqueue(ctx context.Context, event OutboxEvent) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO outbox (event_id, event_type, payload, created_at)
+		VALUES ($1, $2, $3, COALESCE($4, now()))`,
+		event.ID, event.Type, event.Payload, nullTime(event.CreatedAt))
+	return err
+}
+
+func (p *postgresStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]OutboxEvent, error) {
+	// SKIP LOCKED keeps concurrent relays from claiming the same rows
+	rows, err := p.db.QueryContext(ctx, `
+		UPDATE outbox
+		SET leased_until = now() + $2 * interval '1 millisecond', attempts = attempts + 1
+		WHERE event_id IN (
+			SELECT event_id FROM outbox
+			WHERE leased_until IS NULL OR leased_until < now()
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING event_id, event_type, payload, created_at, attempts`,
+		limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []OutboxEvent
+	for rows.Next() {
+		var event OutboxEvent
+		if err := rows.Scan(&event.ID, &event.Type, &event.Payload, &event.CreatedAt, &event.Attempts); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	// RETURNING order is unspecified
+	sort.Slice(events, func(i, j int) bool { return events[i].CreatedAt.Before(events[j].CreatedAt) })
+	return events, rows.Err()
+}
+
+func (p *postgresStore) Ack(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		if _, err := p.db.ExecContext(ctx, `DELETE FROM outbox WHERE event_id = $1`, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nullTime maps the zero time to NULL
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// deleteOwned runs a DELETE scoped to the owner, mapping "no rows" to
+// ErrNotFound
+func (p *postgresStore) deleteOwned(ctx context.Context, query, id, userID string) error {
+	result, err := p.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when a record does not exist or belongs to another
+// user.
+var ErrNotFound = errors.New("not found")
+
+// ErrTimerRunning is returned when starting a timer on a resource the user
+// is already timing
+var ErrTimerRunning = errors.New("timer already running")
+
+// ErrFocusSessionOpen is returned when starting a focus session while the
+// user has one that hasn't ended
+var ErrFocusSessionOpen = errors.New("focus session already open")
+
+// Note is a learner's free-text note on a plan or milestone
+type Note struct {
+	ID          string    `json:"note_id"`
+	UserID      string    `json:"user_id"`
+	PlanID      string    `json:"plan_id"`
+	MilestoneID string    `json:"milestone_id,omitempty"`
+	Body        string    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Bookmark is a saved learning resource
+type Bookmark struct {
+	ID         string    `json:"bookmark_id"`
+	UserID     string    `json:"user_id"`
+	ResourceID string    `json:"resource_id"`
+	PlanID     string    `json:"plan_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Progress is a learner's progress on one milestone of a plan
+type Progress struct {
+	UserID      string    `json:"user_id"`
+	PlanID      string    `json:"plan_id"`
+	MilestoneID string    `json:"milestone_id"`
+	Completed   bool      `json:"completed"`
+	HoursSpent  float64   `json:"hours_spent"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Streak counts consecutive days with recorded progress
+type Streak struct {
+	Current    int        `json:"current"`
+	Longest    int        `json:"longest"`
+	LastActive *time.Time `json:"last_active,omitempty"`
+}
+
+// TimeLog is time a learner spent on a resource of a plan, timed with a
+// start and stop or logged as minutes. A running timer has no EndedAt.
+type TimeLog struct {
+	ID         string     `json:"time_log_id"`
+	UserID     string     `json:"user_id"`
+	PlanID     string     `json:"plan_id"`
+	ResourceID string     `json:"resource_id"`
+	StartedAt  time.Time  `json:"started_at"`
+	EndedAt    *time.Time `json:"ended_at,omitempty"`
+	Minutes    float64    `json:"minutes"` // 0 while running
+}
+
+// Focus session statuses
+const (
+	FocusActive = "active"
+	FocusPaused = "paused" // The learner went idle
+	FocusEnded  = "ended"
+)
+
+// FocusSession is a timed stretch of focus on a resource of a plan, such
+// as a Pomodoro. Focused minutes exclude the time the session was paused.
+type FocusSession struct {
+	ID             string     `json:"session_id"`
+	UserID         string     `json:"user_id"`
+	PlanID         string     `json:"plan_id"`
+	ResourceID     string     `json:"resource_id"`
+	Status         string     `json:"status"`
+	PlannedMinutes int        `json:"planned_minutes"`
+	FocusedMinutes float64    `json:"focused_minutes"`
+	Pauses         int        `json:"pauses"`
+	StartedAt      time.Time  `json:"started_at"`
+	LastActiveAt   time.Time  `json:"last_active_at"` // Focused time is counted up to here
+	EndedAt        *time.Time `json:"ended_at,omitempty"`
+}
+
+// ShareToken grants read access to a plan without signing in
+type ShareToken struct {
+	Token     string     `json:"token"`
+	UserID    string     `json:"user_id"`
+	PlanID    string     `json:"plan_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired reports whether the token can no longer be used
+func (t ShareToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && now.After(*t.ExpiresAt)
+}
+
+// Quiz attempt statuses. An attempt with short answers is pending until
+// the grading worker has graded every one of them.
+const (
+	QuizAttemptPending = "pending"
+	QuizAttemptGraded  = "graded"
+)
+
+// QuizAttempt records a learner submitting a quiz
+type QuizAttempt struct {
+	ID          string            `json:"attempt_id"`
+	UserID      string            `json:"user_id"`
+	QuizID      string            `json:"quiz_id"`
+	Status      string            `json:"status"`
+	SubmittedAt time.Time         `json:"submitted_at"`
+	GradedAt    *time.Time        `json:"graded_at,omitempty"`
+	Score       *float64          `json:"score,omitempty"` // Percentage; nil for attempts recorded before scores were
+	Results     []QuestionOutcome `json:"results,omitempty"`
+
+	GradingAttempts int `json:"-"` // Grading runs so far, including the current claim
+}
+
+// QuestionOutcome is whether one question of an attempt was answered
+// correctly, and the resource it was drawn from
+type QuestionOutcome struct {
+	QuestionID string `json:"question_id"`
+	ResourceID string `json:"resource_id,omitempty"`
+	Correct    bool   `json:"correct"`
+	Pending    bool   `json:"pending,omitempty"`     // Short answer not graded yet
+	AnswerText string `json:"answer_text,omitempty"` // Short answers only
+	Feedback   string `json:"feedback,omitempty"`
+}
+
+// QuizAnomaly is a suspicious quiz submission, such as one naming options
+// the quiz doesn't have or arriving faster than the questions can be read
+type QuizAnomaly struct {
+	ID        string    `json:"anomaly_id"`
+	UserID    string    `json:"user_id,omitempty"` // Empty for anonymous learners
+	QuizID    string    `json:"quiz_id"`
+	Kind      string    `json:"kind"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// QuizAnomalyFilter narrows a listing of anomalies; zero fields match all
+type QuizAnomalyFilter struct {
+	UserID string
+	QuizID string
+	Limit  int
+}
+
+// OutboxEvent is a serialized domain event waiting to be published
+type OutboxEvent struct {
+	ID        string
+	Type      string
+	Payload   []byte
+	CreatedAt time.Time
+	Attempts  int // Delivery attempts so far, including the current claim
+}
+
+// NoteRepository stores notes
+type NoteRepository interface {
+	ListNotes(ctx context.Context, userID, planID string) ([]Note, error)
+	CreateNote(ctx context.Context, note Note) (Note, error)
+	DeleteNote(ctx context.Context, userID, noteID string) error
+}
+
+// BookmarkRepository stores bookmarks
+type BookmarkRepository interface {
+	ListBookmarks(ctx context.Context, userID string) ([]Bookmark, error)
+	AddBookmark(ctx context.Context, bookmark Bookmark) (Bookmark, error)
+	RemoveBookmark(ctx context.Context, userID, bookmarkID string) error
+}
+
+// ProgressRepository stores milestone progress and the daily activity that
+// streaks are derived from
+type ProgressRepository interface {
+	RecordProgress(ctx context.Context, progress Progress) error
+	ListProgress(ctx context.Context, userID, planID string) ([]Progress, error)
+	// ActiveDays returns the distinct UTC days with progress, newest first
+	ActiveDays(ctx context.Context, userID string) ([]time.Time, error)
+	// ActiveUsers returns the users with progress on or after since
+	ActiveUsers(ctx context.Context, since time.Time) ([]string, error)
+	// RecordActivity marks the UTC day of at active, without progress on a
+	// milestone, such as for a focus session
+	RecordActivity(ctx context.Context, userID string, at time.Time) error
+}
+
+// TimeLogRepository stores time spent on resources
+type TimeLogRepository interface {
+	// StartTimer records a running timer, returning ErrTimerRunning if the
+	// user is already timing the resource
+	StartTimer(ctx context.Context, entry TimeLog) (TimeLog, error)
+	// StopTimer ends the user's running timer on a resource at endedAt,
+	// or once it has run for limit if that is sooner. It returns
+	// ErrNotFound if there is no running timer.
+	StopTimer(ctx context.Context, userID, planID, resourceID string, endedAt time.Time, limit time.Duration) (TimeLog, error)
+	// AddTimeLog records minutes spent, ending at entry.EndedAt
+	AddTimeLog(ctx context.Context, entry TimeLog) (TimeLog, error)
+	// ListTimeLogs returns a user's logs on a plan, oldest first
+	ListTimeLogs(ctx context.Context, userID, planID string) ([]TimeLog, error)
+}
+
+// FocusSessionRepository stores focus sessions
+type FocusSessionRepository interface {
+	// StartFocusSession records a new session, returning
+	// ErrFocusSessionOpen if the user has one that hasn't ended
+	StartFocusSession(ctx context.Context, session FocusSession) (FocusSession, error)
+	GetFocusSession(ctx context.Context, userID, sessionID string) (FocusSession, error)
+	// OpenFocusSession returns the user's session that hasn't ended, or
+	// ErrNotFound
+	OpenFocusSession(ctx context.Context, userID string) (FocusSession, error)
+	UpdateFocusSession(ctx context.Context, session FocusSession) error
+	// ListFocusSessions returns the user's sessions started in [from, to),
+	// oldest first
+	ListFocusSessions(ctx context.Context, userID string, from, to time.Time) ([]FocusSession, error)
+}
+
+// QuizAttemptRepository stores quiz submissions
+type QuizAttemptRepository interface {
+	RecordQuizAttempt(ctx context.Context, attempt QuizAttempt) error
+	CountQuizAttempts(ctx context.Context, userID string, since time.Time) (int, error)
+	// ListQuizAttempts returns a user's attempts, oldest first
+	ListQuizAttempts(ctx context.Context, userID string) ([]QuizAttempt, error)
+	GetQuizAttempt(ctx context.Context, userID, attemptID string) (QuizAttempt, error)
+	// ClaimPendingQuizAttempts leases up to limit attempts awaiting
+	// grading, oldest first
+	ClaimPendingQuizAttempts(ctx context.Context, limit int, lease time.Duration) ([]QuizAttempt, error)
+	// CompleteQuizAttempt stores a graded attempt's score and results and
+	// marks it graded
+	CompleteQuizAttempt(ctx context.Context, attempt QuizAttempt) error
+}
+
+// QuizAnomalyRepository stores suspicious quiz submissions
+type QuizAnomalyRepository interface {
+	RecordQuizAnomaly(ctx context.Context, anomaly QuizAnomaly) error
+	// ListQuizAnomalies returns matching anomalies, newest first
+	ListQuizAnomalies(ctx context.Context, filter QuizAnomalyFilter) ([]QuizAnomaly, error)
+}
+
+// ShareTokenRepository stores plan share tokens
+type ShareTokenRepository interface {
+	CreateShareToken(ctx context.Context, token ShareToken) error
+	GetShareToken(ctx context.Context, token string) (ShareToken, error)
+	RevokeShareToken(ctx context.Context, userID, token string) error
+}
+
+// UserRepository moves learner data between users
+type UserRepository interface {
+	// MergeUser reassigns everything recorded for fromUserID to toUserID,
+	// such as a guest's progress once they sign up. Where both have
+	// progress on a milestone, the more recent wins.
+	MergeUser(ctx context.Context, fromUserID, toUserID string) error
+}
+
+// OutboxRepository holds domain events until the relay has published them.
+// Claims are leased so several replicas can relay the same outbox; an event
+// whose publish fails is retried once its lease runs out.
+type OutboxRepository interface {
+	Enqueue(ctx context.Context, event OutboxEvent) error
+	// Claim leases up to limit unpublished events, oldest first
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]OutboxEvent, error)
+	// Ack removes published events
+	Ack(ctx context.Context, ids []string) error
+}
+
+// Repositories bundles the gateway-owned datastores handed to handlers
+type Repositories struct {
+	Notes       NoteRepository
+	Bookmarks   BookmarkRepository
+	Progress    ProgressRepository
+	TimeLogs    TimeLogRepository
+	Focus       FocusSessionRepository
+	ShareTokens ShareTokenRepository
+	Quizzes     QuizAttemptRepository
+	Anomalies   QuizAnomalyRepository
+	Users       UserRepository
+	Outbox      OutboxRepository
+
+	close func() error
+}
+
+// Close releases the underlying database, if any
+func (r *Repositories) Close() error {
+	if r.close == nil {
+		return nil
+	}
+	return r.close()
+}
+
+// ComputeStreak derives the current and longest streak from active days
+// (newest first) as of now
+func ComputeStreak(days []time.Time, now time.Time) Streak {
+	var streak Streak
+	if len(days) == 0 {
+		return streak
+	}
+
+	last := days[0]
+	streak.LastActive = &last
+
+	run := 1
+	for i := 1; i < len(days); i++ {
+		if days[i-1].Sub(days[i]) == 24*time.Hour {
+			run++
+			continue
+		}
+		streak.Longest = max(streak.Longest, run)
+		if streak.Current == 0 {
+			streak.Current = run
+		}
+		run = 1
+	}
+	streak.Longest = max(streak.Longest, run)
+	if streak.Current == 0 {
+		streak.Current = run
+	}
+
+	// The current streak only counts if the learner was active today or
+	// yesterday
+	today := now.UTC().Truncate(24 * time.Hour)
+	if today.Sub(last) > 24*time.Hour {
+		streak.Current = 0
+	}
+	return streak
+}
@@ -0,0 +1,189 @@
+package retakes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+)
+
+// retention keeps retake records for a year after the last retake
+const retention = 365 * 24 * time.Hour
+
+// Denial is a retake the policy refuses
+type Denial struct {
+	Status     int
+	Code       string
+	Message    string
+	RetryAfter time.Duration // For cooldowns
+}
+
+func (d *Denial) Error() string {
+	return d.Message
+}
+
+// Reservation is a retake allowed by the policy. Release it if the variant
+// cannot be served.
+type Reservation struct {
+	UserID    string
+	QuizID    string // The original quiz; variants are regenerated from it
+	Count     int    // Retakes including this one
+	Remaining int    // -1 when unlimited
+}
+
+// Policy limits how often learners retake a quiz. A quiz and the variants
+// served for its retakes share one count and cooldown; records are kept in
+// the shared store so every replica enforces the same limits.
+type Policy struct {
+	cfg      config.RetakeConfig
+	store    storage.KeyValue
+	attempts repository.QuizAttemptRepository
+}
+
+// New creates a retake policy
+func New(cfg config.RetakeConfig, store storage.KeyValue, attempts repository.QuizAttemptRepository) *Policy {
+	return &Policy{cfg: cfg, store: store, attempts: attempts}
+}
+
+// Limits returns a tenant's retake limit and cooldown: the global ones,
+// replaced by the tenant's where it sets them
+func (p *Policy) Limits(tenantID string) (int, time.Duration) {
+	maxRetakes, cooldown := p.cfg.MaxRetakes, p.cfg.Cooldown
+	if override, ok := p.cfg.Tenants[tenantID]; ok {
+		if override.MaxRetakes != nil {
+			maxRetakes = *override.MaxRetakes
+		}
+		if override.Cooldown != nil {
+			cooldown = *override.Cooldown
+		}
+	}
+	return maxRetakes, cooldown
+}
+
+// Reserve claims a retake of quizID, or of the quiz it is a variant of, for
+// a learner. It is denied once the tenant's limit is used up, or while the
+// learner's last submission of the quiz or a variant is within the
+// cooldown.
+func (p *Policy) Reserve(ctx context.Context, tenantID, userID, quizID string) (*Reservation, error) {
+	maxRetakes, cooldown := p.Limits(tenantID)
+	root, err := p.root(ctx, quizID)
+	if err != nil {
+		return nil, err
+	}
+	variants, err := p.variants(ctx, userID, root)
+	if err != nil {
+		return nil, err
+	}
+
+	if cooldown > 0 {
+		attempts, err := p.attempts.ListQuizAttempts(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("list quiz attempts: %w", err)
+		}
+		var last time.Time
+		for _, attempt := range attempts {
+			if (attempt.QuizID == root || slices.Contains(variants, attempt.QuizID)) && attempt.SubmittedAt.After(last) {
+				last = attempt.SubmittedAt
+			}
+		}
+		if wait := time.Until(last.Add(cooldown)); !last.IsZero() && wait > 0 {
+			return nil, &Denial{
+				Status:     http.StatusTooManyRequests,
+				Code:       "retake_cooldown",
+				Message:    fmt.Sprintf("This quiz can be retaken in %s", wait.Round(time.Second)),
+				RetryAfter: wait,
+			}
+		}
+	}
+
+	// The counter is claimed atomically so concurrent retakes can't
+	// overshoot the limit
+	count, err := p.store.Incr(ctx, countKey(userID, root), retention)
+	if err != nil {
+		return nil, fmt.Errorf("count retakes: %w", err)
+	}
+	reservation := &Reservation{UserID: userID, QuizID: root, Count: int(count), Remaining: -1}
+	if maxRetakes > 0 {
+		if int(count) > maxRetakes {
+			p.Release(ctx, reservation)
+			return nil, &Denial{
+				Status:  http.StatusForbidden,
+				Code:    "retake_limit_reached",
+				Message: fmt.Sprintf("This quiz can be retaken at most %d times", maxRetakes),
+			}
+		}
+		reservation.Remaining = maxRetakes - int(count)
+	}
+	return reservation, nil
+}
+
+// Release gives back a reserved retake
+func (p *Policy) Release(ctx context.Context, reservation *Reservation) {
+	if _, err := p.store.IncrBy(ctx, countKey(reservation.UserID, reservation.QuizID), -1, retention); err != nil {
+		log.Printf("Failed to release retake of %s: %v", reservation.QuizID, err)
+	}
+}
+
+// Record links the variant served for a reservation to its original quiz
+func (p *Policy) Record(ctx context.Context, reservation *Reservation, variantID string) error {
+	if err := p.store.Set(ctx, variantKey(variantID), []byte(reservation.QuizID), retention); err != nil {
+		return err
+	}
+	variants, err := p.variants(ctx, reservation.UserID, reservation.QuizID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(append(variants, variantID))
+	if err != nil {
+		return err
+	}
+	return p.store.Set(ctx, variantsKey(reservation.UserID, reservation.QuizID), data, retention)
+}
+
+// root returns the original quiz of a variant, or quizID itself
+func (p *Policy) root(ctx context.Context, quizID string) (string, error) {
+	root, ok, err := p.store.Get(ctx, variantKey(quizID))
+	if err != nil {
+		return "", fmt.Errorf("load quiz variant: %w", err)
+	}
+	if !ok {
+		return quizID, nil
+	}
+	return string(root), nil
+}
+
+// variants returns the variants of a quiz served to a learner
+func (p *Policy) variants(ctx context.Context, userID, root string) ([]string, error) {
+	data, ok, err := p.store.Get(ctx, variantsKey(userID, root))
+	if err != nil {
+		return nil, fmt.Errorf("load quiz variants: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	var variants []string
+	if err := json.Unmarshal(data, &variants); err != nil {
+		return nil, errors.Join(errors.New("decode quiz variants"), err)
+	}
+	return variants, nil
+}
+
+func countKey(userID, root string) string {
+	return "retakes:" + userID + ":" + root + ":count"
+}
+
+func variantsKey(userID, root string) string {
+	return "retakes:" + userID + ":" + root + ":variants"
+}
+
+func variantKey(variantID string) string {
+	return "retakes:variant:" + variantID
+}
@@ -0,0 +1,258 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+)
+
+// historySize is the number of runs kept per job
+const historySize = 20
+
+var (
+	// ErrUnknownJob is returned for a job name that was never added
+	ErrUnknownJob = errors.New("unknown job")
+	// ErrJobRunning is returned when triggering a job that is still running
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// Func is the work a job does on each run
+type Func func(ctx context.Context) error
+
+// Run records one execution of a job
+type Run struct {
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Trigger    string    `json:"trigger"` // schedule or manual
+	Error      string    `json:"error,omitempty"`
+}
+
+// JobStatus is a job's configuration, next run and recent history
+type JobStatus struct {
+	Name     string     `json:"name"`
+	Schedule string     `json:"schedule"`
+	Enabled  bool       `json:"enabled"`
+	Running  bool       `json:"running"`
+	NextRun  *time.Time `json:"next_run,omitempty"`
+	History  []Run      `json:"history"` // Newest first
+}
+
+// Scheduler runs recurring jobs inside the gateway. Each scheduled run is
+// delayed by a random jitter so replicas don't hit backends in lockstep,
+// and, given a shared store, claimed by only one replica.
+type Scheduler struct {
+	maxJitter time.Duration
+	lock      storage.KeyValue
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+type job struct {
+	name     string
+	spec     string
+	schedule Schedule
+	fn       Func
+	enabled  bool
+	running  bool
+	next     time.Time
+	history  []Run
+}
+
+// New creates a scheduler. lock may be nil; with a shared store each
+// scheduled run executes on one replica only.
+func New(maxJitter time.Duration, lock storage.KeyValue) *Scheduler {
+	return &Scheduler{
+		maxJitter: maxJitter,
+		lock:      lock,
+		jobs:      map[string]*job{},
+	}
+}
+
+// Add registers a job. It must be called before Run.
+func (s *Scheduler) Add(name, spec string, enabled bool, fn Func) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[name] = &job{name: name, spec: spec, schedule: schedule, fn: fn, enabled: enabled}
+	return nil
+}
+
+// SetEnabled turns a job on or off at runtime
+func (s *Scheduler) SetEnabled(name string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[name]
+	if !ok {
+		return ErrUnknownJob
+	}
+	j.enabled = enabled
+	return nil
+}
+
+// Run starts every job's loop and blocks until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mu.Lock()
+	var wg sync.WaitGroup
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+	s.mu.Unlock()
+	wg.Wait()
+}
+
+// Trigger runs a job now, in the background, regardless of its schedule
+// and enable flag
+func (s *Scheduler) Trigger(ctx context.Context, name string) error {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	if !ok {
+		s.mu.Unlock()
+		return ErrUnknownJob
+	}
+	if j.running {
+		s.mu.Unlock()
+		return ErrJobRunning
+	}
+	j.running = true
+	s.mu.Unlock()
+
+	go s.execute(ctx, j, "manual")
+	return nil
+}
+
+// Snapshot returns the status of every job, sorted by name
+func (s *Scheduler) Snapshot() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		status := JobStatus{
+			Name:     j.name,
+			Schedule: j.spec,
+			Enabled:  j.enabled,
+			Running:  j.running,
+			History:  make([]Run, 0, len(j.history)),
+		}
+		if j.enabled && !j.next.IsZero() {
+			next := j.next
+			status.NextRun = &next
+		}
+		for i := len(j.history) - 1; i >= 0; i-- {
+			status.History = append(status.History, j.history[i])
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// loop waits for each scheduled time and runs the job if it is enabled
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	for {
+		scheduled := j.schedule.Next(time.Now())
+		if scheduled.IsZero() {
+			log.Printf("scheduler: job %s has no future runs", j.name)
+			return
+		}
+		at := scheduled.Add(s.jitter())
+
+		s.mu.Lock()
+		j.next = at
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(at))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.mu.Lock()
+		if !j.enabled || j.running {
+			s.mu.Unlock()
+			continue
+		}
+		j.running = true
+		s.mu.Unlock()
+
+		if !s.claim(ctx, j, scheduled) {
+			s.mu.Lock()
+			j.running = false
+			s.mu.Unlock()
+			continue
+		}
+		s.execute(ctx, j, "schedule")
+	}
+}
+
+// claim reports whether this replica should run the scheduled slot
+func (s *Scheduler) claim(ctx context.Context, j *job, scheduled time.Time) bool {
+	if s.lock == nil {
+		return true
+	}
+	key := "scheduler:" + j.name + ":" + strconv.FormatInt(scheduled.UnixMilli(), 10)
+	ok, err := s.lock.SetNX(ctx, key, []byte("1"), time.Hour)
+	if err != nil {
+		// Running twice beats not running at all
+		log.Printf("scheduler: failed to claim %s, running anyway: %v", j.name, err)
+		return true
+	}
+	return ok
+}
+
+// execute runs the job, which must already be marked running, and records
+// the outcome
+func (s *Scheduler) execute(ctx context.Context, j *job, trigger string) {
+	run := Run{StartedAt: time.Now().UTC(), Trigger: trigger}
+	err := safeCall(ctx, j.fn)
+	run.DurationMs = time.Since(run.StartedAt).Milliseconds()
+	if err != nil {
+		run.Error = err.Error()
+		log.Printf("scheduler: job %s failed: %v", j.name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j.running = false
+	j.history = append(j.history, run)
+	if len(j.history) > historySize {
+		j.history = j.history[len(j.history)-historySize:]
+	}
+}
+
+func (s *Scheduler) jitter() time.Duration {
+	if s.maxJitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(s.maxJitter)))
+}
+
+// safeCall turns a panicking job into a failed run
+func safeCall(ctx context.Context, fn Func) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
@@ -0,0 +1,210 @@
+// Package scorm packages a learning path as a SCORM 1.2 or 2004 content
+// package, so it can be imported into an LMS. Each milestone becomes a SCO
+// listing its resources, and each milestone quiz a SCO scored in the
+// browser; both report completion to the LMS through its SCORM API.
+package scorm
+
+import (
+	"archive/zip"
+	"bytes"
+	"embed"
+	"encoding/xml"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"regexp"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/branding"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/google/uuid"
+)
+
+// SCORM versions
+const (
+	Version12   = "1.2"
+	Version2004 = "2004"
+)
+
+// DefaultMasteryScore is the quiz score, in percent, that passes
+const DefaultMasteryScore = 70
+
+//go:embed templates
+var templates embed.FS
+
+var (
+	manifests = template.Must(template.New("").Funcs(template.FuncMap{"xml": escapeXML}).ParseFS(templates, "templates/*.xml"))
+	pages     = htmltemplate.Must(htmltemplate.ParseFS(templates, "templates/*.html"))
+)
+
+// Package is what goes into a content package
+type Package struct {
+	Plan    *models.LearningPath
+	Quizzes map[uuid.UUID]*models.Quiz // By milestone ID; milestones without one get no quiz SCO
+	Version string                     // Version12 or Version2004
+	// MasteryScore is the quiz score, in percent, that passes; 0 means
+	// DefaultMasteryScore
+	MasteryScore int
+	// Branding styles the pages: its colors, and a header with its logo
+	// and sender name
+	Branding branding.Branding
+}
+
+// hexColor matches the colors put into the brand stylesheet
+var hexColor = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}){1,2}$`)
+
+// sco is a milestone's resources or quiz, as a SCO in the manifest
+type sco struct {
+	ID    string
+	Title string
+	Href  string
+}
+
+// milestonePage is the data of a milestone's SCO
+type milestonePage struct {
+	Brand     branding.Branding
+	Goal      string
+	Milestone models.Milestone
+	Quiz      bool
+}
+
+// quizPage is the data of a quiz SCO. Questions and answers are embedded:
+// the quiz is scored in the browser, as the LMS expects.
+type quizPage struct {
+	Brand        branding.Branding
+	Goal         string
+	Milestone    string
+	Quiz         *models.Quiz
+	MasteryScore int
+}
+
+// manifest is the data of imsmanifest.xml
+type manifest struct {
+	ID           string
+	Title        string
+	Items        []manifestItem
+	MasteryScore int
+}
+
+// MinNormalizedMeasure is the mastery score as SCORM 2004 scales it, 0-1
+func (m manifest) MinNormalizedMeasure() string {
+	return strconv.FormatFloat(float64(m.MasteryScore)/100, 'f', -1, 64)
+}
+
+type manifestItem struct {
+	ID        string
+	Title     string
+	Resources sco
+	Quiz      *sco
+}
+
+// SCOs are the item's resources and quiz
+func (i manifestItem) SCOs() []sco {
+	if i.Quiz == nil {
+		return []sco{i.Resources}
+	}
+	return []sco{i.Resources, *i.Quiz}
+}
+
+// Build writes p as a zip archive to w
+func Build(w io.Writer, p Package) error {
+	if p.Version != Version12 && p.Version != Version2004 {
+		return fmt.Errorf("scorm: unsupported version %q", p.Version)
+	}
+	if p.MasteryScore <= 0 {
+		p.MasteryScore = DefaultMasteryScore
+	}
+
+	var files []file
+	add := func(name string, write func(io.Writer) error) {
+		files = append(files, file{name: name, write: write})
+	}
+	// Written once the items below are listed
+	var m manifest
+	add("imsmanifest.xml", func(w io.Writer) error {
+		return manifests.ExecuteTemplate(w, "manifest"+p.Version+".xml", m)
+	})
+	for _, asset := range []string{"scorm.js", "style.css"} {
+		asset := asset
+		add("shared/"+asset, func(w io.Writer) error {
+			data, err := templates.ReadFile("templates/" + asset)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		})
+	}
+	add("shared/brand.css", func(w io.Writer) error {
+		return writeBrandCSS(w, p.Branding)
+	})
+
+	m = manifest{ID: "plan-" + p.Plan.PlanID.String(), Title: p.Plan.Goal, MasteryScore: p.MasteryScore}
+	for i, milestone := range p.Plan.Milestones {
+		milestone := milestone
+		dir := fmt.Sprintf("milestone-%02d", i+1)
+		quiz := p.Quizzes[milestone.MilestoneID]
+
+		item := manifestItem{
+			ID:        fmt.Sprintf("item-%02d", i+1),
+			Title:     milestone.Title,
+			Resources: sco{ID: fmt.Sprintf("sco-%02d", i+1), Title: milestone.Title, Href: dir + "/index.html"},
+		}
+		add(item.Resources.Href, func(w io.Writer) error {
+			return pages.ExecuteTemplate(w, "milestone.html", milestonePage{Brand: p.Branding, Goal: p.Plan.Goal, Milestone: milestone, Quiz: quiz != nil})
+		})
+		if quiz != nil {
+			item.Quiz = &sco{ID: fmt.Sprintf("sco-%02d-quiz", i+1), Title: "Quiz: " + milestone.Title, Href: dir + "/quiz.html"}
+			add(item.Quiz.Href, func(w io.Writer) error {
+				return pages.ExecuteTemplate(w, "quiz.html", quizPage{Brand: p.Branding, Goal: p.Plan.Goal, Milestone: milestone.Title, Quiz: quiz, MasteryScore: p.MasteryScore})
+			})
+		}
+		m.Items = append(m.Items, item)
+	}
+
+	modified := p.Plan.UpdatedAt
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	archive := zip.NewWriter(w)
+	for _, f := range files {
+		entry, err := archive.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		if err := f.write(entry); err != nil {
+			return fmt.Errorf("scorm: writing %s: %w", f.name, err)
+		}
+	}
+	return archive.Close()
+}
+
+// writeBrandCSS writes the custom properties style.css takes its colors
+// from; colors that aren't hex are left to its defaults
+func writeBrandCSS(w io.Writer, b branding.Branding) error {
+	var buf bytes.Buffer
+	buf.WriteString(":root {\n")
+	if hexColor.MatchString(b.PrimaryColor) {
+		fmt.Fprintf(&buf, "  --brand-primary: %s;\n", b.PrimaryColor)
+	}
+	if hexColor.MatchString(b.AccentColor) {
+		fmt.Fprintf(&buf, "  --brand-accent: %s;\n", b.AccentColor)
+	}
+	buf.WriteString("}\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// file is an entry of the archive
+type file struct {
+	name  string
+	write func(io.Writer) error
+}
+
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,122 @@
+// Talks to the LMS through its SCORM API: API_1484_11 for SCORM 2004, API
+// for SCORM 1.2, found in a parent or opener window. Pages call start once
+// loaded, and still work outside an LMS, without tracking.
+var SCORM = (function () {
+  var api = null;
+  var version = null;
+  var finished = false;
+
+  function find(win) {
+    for (var depth = 0; win && depth < 10; depth++) {
+      if (win.API_1484_11) {
+        version = "2004";
+        return win.API_1484_11;
+      }
+      if (win.API) {
+        version = "1.2";
+        return win.API;
+      }
+      if (win.parent === win) {
+        break;
+      }
+      win = win.parent;
+    }
+    return null;
+  }
+
+  function set(name12, name2004, value) {
+    if (!api) {
+      return;
+    }
+    if (version === "2004") {
+      api.SetValue(name2004, String(value));
+    } else if (name12) {
+      api.LMSSetValue(name12, String(value));
+    }
+  }
+
+  function get(name12, name2004) {
+    if (!api) {
+      return "";
+    }
+    return version === "2004" ? api.GetValue(name2004) : api.LMSGetValue(name12);
+  }
+
+  function commit() {
+    if (!api) {
+      return;
+    }
+    if (version === "2004") {
+      api.Commit("");
+    } else {
+      api.LMSCommit("");
+    }
+  }
+
+  return {
+    // start initializes the session and marks the SCO as begun
+    start: function () {
+      api = find(window) || (window.opener ? find(window.opener) : null);
+      if (!api) {
+        return;
+      }
+      if (version === "2004") {
+        api.Initialize("");
+      } else {
+        api.LMSInitialize("");
+      }
+      var status = get("cmi.core.lesson_status", "cmi.completion_status");
+      if (status === "not attempted" || status === "unknown" || status === "") {
+        set("cmi.core.lesson_status", "cmi.completion_status", "incomplete");
+      }
+      commit();
+    },
+
+    // progress keeps state to resume from, such as the resources opened
+    progress: function (state) {
+      set("cmi.suspend_data", "cmi.suspend_data", state);
+      commit();
+    },
+
+    resume: function () {
+      return get("cmi.suspend_data", "cmi.suspend_data");
+    },
+
+    complete: function () {
+      set("cmi.core.lesson_status", "cmi.completion_status", "completed");
+      set("", "cmi.progress_measure", 1);
+      commit();
+    },
+
+    // score records a quiz result out of max, passing at mastery percent
+    score: function (raw, max, mastery) {
+      var percent = max > 0 ? Math.round((raw / max) * 100) : 0;
+      var passed = percent >= mastery;
+      set("cmi.core.score.raw", "cmi.score.raw", percent);
+      set("cmi.core.score.min", "cmi.score.min", 0);
+      set("cmi.core.score.max", "cmi.score.max", 100);
+      set("", "cmi.score.scaled", percent / 100);
+      set("cmi.core.lesson_status", "cmi.success_status", passed ? "passed" : "failed");
+      set("", "cmi.completion_status", "completed");
+      commit();
+      return passed;
+    },
+
+    finish: function () {
+      if (!api || finished) {
+        return;
+      }
+      finished = true;
+      if (version === "2004") {
+        api.SetValue("cmi.exit", "suspend");
+        api.Terminate("");
+      } else {
+        api.LMSSetValue("cmi.core.exit", "suspend");
+        api.LMSFinish("");
+      }
+    }
+  };
+})();
+
+window.addEventListener("pagehide", SCORM.finish);
+window.addEventListener("beforeunload", SCORM.finish);
@@ -0,0 +1,128 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AWSOptions configures the AWS Secrets Manager provider
+type AWSOptions struct {
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string // For temporary credentials
+	SecretID     string // Name or ARN of the secret
+	Timeout      time.Duration
+}
+
+// AWS reads secrets from one AWS Secrets Manager secret whose value is a
+// JSON object of secret names to values, signing requests with AWS
+// Signature Version 4
+type AWS struct {
+	opts   AWSOptions
+	client *http.Client
+}
+
+// NewAWS creates an AWS Secrets Manager provider
+func NewAWS(opts AWSOptions) *AWS {
+	return &AWS{opts: opts, client: &http.Client{Timeout: opts.Timeout}}
+}
+
+func (a *AWS) Fetch(ctx context.Context, names []string) (map[string]string, error) {
+	payload, err := json.Marshal(map[string]string{"SecretId": a.opts.SecretID})
+	if err != nil {
+		return nil, err
+	}
+	endpoint := fmt.Sprintf("https://secretsmanager.%s.amazonaws.com/", a.opts.Region)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	a.sign(req, payload, time.Now().UTC())
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("secrets manager: status %d: %s", resp.StatusCode, body)
+	}
+
+	var out struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("secrets manager: decode response: %w", err)
+	}
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(out.SecretString), &data); err != nil {
+		return nil, fmt.Errorf("secrets manager: secret %s is not a JSON object", a.opts.SecretID)
+	}
+	return pick(data, names), nil
+}
+
+// sign adds a SigV4 Authorization header covering the host, target and
+// date
+func (a *AWS) sign(req *http.Request, payload []byte, now time.Time) {
+	sum := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(sum[:])
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	headers := []string{
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + req.URL.Host,
+		"x-amz-date:" + amzDate,
+	}
+	signedHeaders := "content-type;host;x-amz-date"
+	if a.opts.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.opts.SessionToken)
+		headers = append(headers, "x-amz-security-token:"+a.opts.SessionToken)
+		signedHeaders += ";x-amz-security-token"
+	}
+	headers = append(headers, "x-amz-target:"+req.Header.Get("X-Amz-Target"))
+	signedHeaders += ";x-amz-target"
+
+	canonical := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		strings.Join(headers, "\n"),
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonical))
+
+	scope := day + "/" + a.opts.Region + "/secretsmanager/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+a.opts.SecretKey), day)
+	key = hmacSHA256(key, a.opts.Region)
+	key = hmacSHA256(key, "secretsmanager")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.opts.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,199 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+)
+
+// Provider fetches secrets by name from a secret store. Names the store
+// doesn't have are left out of the result.
+type Provider interface {
+	Fetch(ctx context.Context, names []string) (map[string]string, error)
+}
+
+// bindings maps secret names, the same as their environment variables, to
+// the settings they fill
+func bindings(cfg *config.Config) map[string]*string {
+	return map[string]*string{
+		"SUPABASE_JWT_SECRET":   &cfg.SupabaseJWTSecret,
+		"SUPABASE_ANON_KEY":     &cfg.SupabaseAnonKey,
+		"ADMIN_TOKEN":           &cfg.AdminToken,
+		"GUEST_SESSION_SECRET":  &cfg.Guests.Secret,
+		"MODERATION_API_KEY":    &cfg.Moderation.APIKey,
+		"AWS_ACCESS_KEY_ID":     &cfg.Uploads.S3AccessKey,
+		"AWS_SECRET_ACCESS_KEY": &cfg.Uploads.S3SecretKey,
+		"GATEWAY_DATABASE_URL":  &cfg.Database.URL,
+		"REDIS_URL":             &cfg.Storage.RedisURL,
+		"UPSTREAM_TLS_CERT":     &cfg.UpstreamTLS.CertFile,
+		"UPSTREAM_TLS_KEY":      &cfg.UpstreamTLS.KeyFile,
+		"EVENTS_URL":            &cfg.Events.URL,
+		"SENTRY_DSN":            &cfg.ErrorReporting.DSN,
+		"INTERNAL_TOKEN_SECRET": &cfg.InternalToken.Secret,
+	}
+}
+
+// Names lists the secrets the gateway looks up
+func Names() []string {
+	var names []string
+	for name := range bindings(&config.Config{}) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Manager caches secrets from the configured store and refreshes them in
+// the background. With the env backend there is nothing to fetch: settings
+// keep the values read from the environment.
+type Manager struct {
+	provider Provider // nil for the env backend
+	cfg      config.SecretsConfig
+
+	mu       sync.RWMutex
+	values   map[string]string
+	handlers []func(name string)
+}
+
+// New creates a manager for the configured backend
+func New(cfg config.SecretsConfig) (*Manager, error) {
+	m := &Manager{cfg: cfg, values: map[string]string{}}
+	switch strings.ToLower(cfg.Backend) {
+	case "", "env":
+	case "file":
+		m.provider = Dir(cfg.Dir)
+	case "vault":
+		if cfg.VaultAddr == "" || cfg.VaultPath == "" {
+			return nil, errors.New("secrets: vault needs VAULT_ADDR and VAULT_SECRET_PATH")
+		}
+		m.provider = NewVault(cfg.VaultAddr, cfg.VaultToken, cfg.VaultPath, cfg.Timeout)
+	case "aws":
+		if cfg.AWSRegion == "" || cfg.AWSSecretID == "" {
+			return nil, errors.New("secrets: aws needs AWS_REGION and AWS_SECRET_ID")
+		}
+		m.provider = NewAWS(AWSOptions{
+			Region:       cfg.AWSRegion,
+			AccessKey:    cfg.AWSAccessKey,
+			SecretKey:    cfg.AWSSecretKey,
+			SessionToken: cfg.AWSSessionToken,
+			SecretID:     cfg.AWSSecretID,
+			Timeout:      cfg.Timeout,
+		})
+	default:
+		return nil, fmt.Errorf("secrets: unknown backend %q", cfg.Backend)
+	}
+	return m, nil
+}
+
+// Load fetches every secret into the cache, telling OnRotate handlers
+// which changed
+func (m *Manager) Load(ctx context.Context) error {
+	if m.provider == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+
+	values, err := m.provider.Fetch(ctx, Names())
+	if err != nil {
+		return fmt.Errorf("secrets: fetch from %s: %w", m.cfg.Backend, err)
+	}
+
+	m.mu.Lock()
+	var rotated []string
+	for name, value := range values {
+		if old, ok := m.values[name]; ok && old != value {
+			rotated = append(rotated, name)
+		}
+	}
+	m.values = values
+	handlers := append([]func(string){}, m.handlers...)
+	m.mu.Unlock()
+
+	for _, name := range rotated {
+		for _, fn := range handlers {
+			fn(name)
+		}
+	}
+	return nil
+}
+
+// Fill sets the settings backed by a cached secret. Settings the store has
+// no secret for keep their environment or config file value.
+func (m *Manager) Fill(cfg *config.Config) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for name, field := range bindings(cfg) {
+		if value, ok := m.values[name]; ok && value != "" {
+			*field = value
+		}
+	}
+}
+
+// Value returns a cached secret, or fallback when the store has none. Use
+// it for settings that should follow rotation without a restart.
+func (m *Manager) Value(name, fallback string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if value := m.values[name]; value != "" {
+		return value
+	}
+	return fallback
+}
+
+// OnRotate registers fn to be called with the name of each secret whose
+// value changed on refresh
+func (m *Manager) OnRotate(fn func(name string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers = append(m.handlers, fn)
+}
+
+// Run refreshes the secrets every RefreshInterval until ctx is done. A
+// failed refresh keeps the cached values.
+func (m *Manager) Run(ctx context.Context) {
+	if m.provider == nil || m.cfg.RefreshInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(m.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Load(ctx); err != nil {
+				log.Printf("%v; keeping cached secrets", err)
+			}
+		}
+	}
+}
+
+// Dir reads each secret from a file named after it, as mounted by Docker
+// and Kubernetes secrets
+type Dir string
+
+func (d Dir) Fetch(_ context.Context, names []string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(string(d), name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[name] = strings.TrimRight(string(data), "\r\n")
+	}
+	return values, nil
+}
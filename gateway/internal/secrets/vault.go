@@ -0,0 +1,76 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Vault reads secrets from one path of a HashiCorp Vault KV engine. Each
+// key at the path is a secret.
+type Vault struct {
+	addr   string
+	token  string
+	path   string
+	client *http.Client
+}
+
+// NewVault creates a Vault provider. For KV version 2 the path includes
+// data/, e.g. secret/data/learnpath.
+func NewVault(addr, token, path string, timeout time.Duration) *Vault {
+	return &Vault{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		path:   strings.Trim(path, "/"),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (v *Vault) Fetch(ctx context.Context, names []string) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.addr+"/v1/"+v.path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("vault: status %d: %s", resp.StatusCode, body)
+	}
+
+	// KV version 2 nests the secrets one level deeper than version 1
+	var out struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("vault: decode response: %w", err)
+	}
+	data := out.Data
+	if nested, ok := out.Data["data"]; ok {
+		if err := json.Unmarshal(nested, &data); err != nil {
+			return nil, fmt.Errorf("vault: decode secrets: %w", err)
+		}
+	}
+	return pick(data, names), nil
+}
+
+// pick keeps the string values of the named secrets
+func pick(data map[string]json.RawMessage, names []string) map[string]string {
+	values := map[string]string{}
+	for _, name := range names {
+		var value string
+		if raw, ok := data[name]; ok && json.Unmarshal(raw, &value) == nil {
+			values[name] = value
+		}
+	}
+	return values
+}
@@ -0,0 +1,173 @@
+package seed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/common"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/ownership"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+)
+
+// doneKey marks a seeded deployment, so startup seeding runs once even
+// across restarts and replicas sharing a store
+const doneKey = "seed:done"
+
+const (
+	// startupAttempts and startupRetryWait give backends that start
+	// alongside the gateway time to come up
+	startupAttempts  = 5
+	startupRetryWait = 15 * time.Second
+)
+
+// ErrInProgress is returned while another run is seeding
+var ErrInProgress = errors.New("seeding is in progress")
+
+// Result describes the demo data a seed run created
+type Result struct {
+	UserID    string    `json:"user_id"`
+	Ingested  int       `json:"ingested"`
+	PlanID    string    `json:"plan_id"`
+	QuizID    string    `json:"quiz_id,omitempty"`
+	SeededAt  time.Time `json:"seeded_at"`
+	Duplicate bool      `json:"duplicate,omitempty"` // An earlier run's result
+}
+
+// Seeder loads demo data through the orchestrator
+type Seeder struct {
+	cfg    config.SeedConfig
+	orch   orchestrator.Orchestrator
+	store  storage.KeyValue
+	owners *ownership.Registry
+}
+
+// New creates a seeder. The demo plan and quiz are recorded as the demo
+// user's in owners.
+func New(cfg config.SeedConfig, orch orchestrator.Orchestrator, store storage.KeyValue, owners *ownership.Registry) *Seeder {
+	return &Seeder{cfg: cfg, orch: orch, store: store, owners: owners}
+}
+
+// Run ingests the configured resources, then creates the example plan with
+// a quiz for the demo user. Unless force is set, a deployment that was
+// already seeded returns the earlier result instead.
+func (s *Seeder) Run(ctx context.Context, force bool) (*Result, error) {
+	if !force {
+		claimed, err := s.store.SetNX(ctx, doneKey, []byte("{}"), 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check seed marker: %w", err)
+		}
+		if !claimed {
+			return s.previous(ctx)
+		}
+	}
+
+	result, err := s.seed(ctx)
+	if err != nil {
+		if !force {
+			// Let the next attempt try again
+			if err := s.store.Delete(context.WithoutCancel(ctx), doneKey); err != nil {
+				log.Printf("seed: failed to clear marker: %v", err)
+			}
+		}
+		return nil, err
+	}
+
+	if encoded, err := json.Marshal(result); err == nil {
+		if err := s.store.Set(context.WithoutCancel(ctx), doneKey, encoded, 0); err != nil {
+			log.Printf("seed: failed to record result: %v", err)
+		}
+	}
+	return result, nil
+}
+
+func (s *Seeder) seed(ctx context.Context) (*Result, error) {
+	userID := s.cfg.UserID
+	ctx = common.WithVerifiedUserID(ctx, userID) // Configured, so trusted
+	ctx = common.WithTenantID(ctx, "global")
+
+	result := &Result{UserID: userID}
+	if len(s.cfg.URLs) > 0 {
+		if _, err := s.orch.IngestContent(ctx, models.IngestRequest{URLs: s.cfg.URLs}); err != nil {
+			return nil, fmt.Errorf("failed to ingest demo resources: %w", err)
+		}
+		result.Ingested = len(s.cfg.URLs)
+	}
+
+	plan, err := s.orch.OrchestrateFullFlow(ctx, models.OrchestrateFullFlowRequest{
+		PlanLearningPathRequest: models.PlanLearningPathRequest{
+			Goal:            s.cfg.Goal,
+			CurrentSkills:   []string{},
+			TimeBudgetHours: 40,
+			HoursPerWeek:    5,
+			UserID:          &userID,
+		},
+		GenerateQuiz:   true,
+		NumQuestions:   5,
+		QuizDifficulty: "medium",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create demo plan: %w", err)
+	}
+	result.PlanID = plan.LearningPath.PlanID.String()
+	if plan.Quiz != nil {
+		result.QuizID = plan.Quiz.QuizID
+	}
+	s.claim(ctx, ownership.Plan, result.PlanID)
+	s.claim(ctx, ownership.Quiz, result.QuizID)
+	result.SeededAt = time.Now().UTC()
+	return result, nil
+}
+
+// claim records the demo user as the owner of a seeded plan or quiz.
+// Failures are logged; the resource is then not found by anyone.
+func (s *Seeder) claim(ctx context.Context, kind, id string) {
+	owner := ownership.Owner{UserID: s.cfg.UserID, TenantID: "global"}
+	if err := s.owners.Claim(ctx, kind, id, owner); err != nil {
+		log.Printf("seed: failed to record owner of %s %s: %v", kind, id, err)
+	}
+}
+
+// previous returns the result recorded by an earlier or in-progress run
+func (s *Seeder) previous(ctx context.Context) (*Result, error) {
+	stored, _, err := s.store.Get(ctx, doneKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed marker: %w", err)
+	}
+	result := &Result{UserID: s.cfg.UserID}
+	if err := json.Unmarshal(stored, result); err != nil {
+		return nil, fmt.Errorf("failed to decode seed marker: %w", err)
+	}
+	if result.PlanID == "" {
+		return nil, ErrInProgress
+	}
+	result.Duplicate = true
+	return result, nil
+}
+
+// RunOnStartup seeds a fresh deployment in the background, retrying while
+// the backends come up
+func (s *Seeder) RunOnStartup(ctx context.Context) {
+	for attempt := 1; attempt <= startupAttempts; attempt++ {
+		result, err := s.Run(ctx, false)
+		if err == nil {
+			if !result.Duplicate {
+				log.Printf("seed: created demo plan %s for %s", result.PlanID, result.UserID)
+			}
+			return
+		}
+		log.Printf("seed: attempt %d/%d failed: %v", attempt, startupAttempts, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(startupRetryWait):
+		}
+	}
+}
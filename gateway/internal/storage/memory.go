@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many writes pass between sweeps of expired keys
+const sweepEvery = 1024
+
+// MemoryStore is a process-local KeyValue
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	writes  int
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time // Zero for no expiry
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// NewMemory creates an empty in-memory store
+func NewMemory() *MemoryStore {
+	return &MemoryStore{entries: map[string]memoryEntry{}}
+}
+
+func (m *MemoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.lookup(key)
+	if !ok {
+		return nil, false, nil
+	}
+	return append([]byte(nil), entry.value...), true, nil
+}
+
+func (m *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store(key, value, ttl)
+	return nil
+}
+
+func (m *MemoryStore) SetNX(_ context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.lookup(key); ok {
+		return false, nil
+	}
+	m.store(key, value, ttl)
+	return true, nil
+}
+
+func (m *MemoryStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *MemoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return m.IncrBy(ctx, key, 1, ttl)
+}
+
+func (m *MemoryStore) IncrBy(_ context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.lookup(key)
+	if !ok {
+		m.store(key, []byte(strconv.FormatInt(n, 10)), ttl)
+		return n, nil
+	}
+
+	v, err := strconv.ParseInt(string(entry.value), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	v += n
+	entry.value = []byte(strconv.FormatInt(v, 10))
+	m.entries[key] = entry
+	return v, nil
+}
+
+func (m *MemoryStore) Append(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, _ := m.lookup(key)
+	m.store(key, append(entry.value, value...), ttl)
+	return nil
+}
+
+func (m *MemoryStore) Close() error {
+	return nil
+}
+
+// lookup returns a live entry, dropping it if expired. Must be called with
+// mu held.
+func (m *MemoryStore) lookup(key string) (memoryEntry, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if entry.expired(time.Now()) {
+		delete(m.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, true
+}
+
+// store writes an entry and periodically sweeps expired ones. Must be called
+// with mu held.
+func (m *MemoryStore) store(key string, value []byte, ttl time.Duration) {
+	entry := memoryEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	m.entries[key] = entry
+
+	m.writes++
+	if m.writes%sweepEvery == 0 {
+		now := time.Now()
+		for k, e := range m.entries {
+			if e.expired(now) {
+				delete(m.entries, k)
+			}
+		}
+	}
+}
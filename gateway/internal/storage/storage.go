@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// KeyValue is the gateway's shared state store. Backed by Redis it lets rate
+// limits, idempotency records, caches, quiz sessions and share tokens work
+// across replicas; the in-memory store suits single-instance deployments.
+type KeyValue interface {
+	// Get returns the value of key and whether it exists.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key. A ttl of 0 keeps it until deleted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetNX stores value only if key does not exist, reporting whether it
+	// was stored.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// Delete removes key.
+	Delete(ctx context.Context, key string) error
+	// Incr atomically increments the counter at key, starting the ttl when
+	// the counter is created.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// IncrBy is Incr adding n instead of one.
+	IncrBy(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error)
+	// Append atomically adds value to the end of key, creating it if
+	// needed, and restarts its ttl.
+	Append(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Close releases the store's resources.
+	Close() error
+}
+
+// Backends
+const (
+	Memory = "memory"
+	Redis  = "redis"
+)
+
+// Options selects and configures a store
+type Options struct {
+	Backend   string // memory or redis
+	RedisURL  string // redis://[user:password@]host:port/db, or rediss:// for TLS
+	KeyPrefix string // Prepended to every key, to share a Redis between apps
+}
+
+// New creates the configured store
+func New(opts Options) (KeyValue, error) {
+	var kv KeyValue
+	switch opts.Backend {
+	case "", Memory:
+		kv = NewMemory()
+	case Redis:
+		r, err := NewRedis(opts.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+		kv = r
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", opts.Backend)
+	}
+
+	if opts.KeyPrefix != "" {
+		kv = &prefixed{KeyValue: kv, prefix: opts.KeyPrefix}
+	}
+	return kv, nil
+}
+
+// prefixed namespaces every key of the wrapped store
+type prefixed struct {
+	KeyValue
+	prefix string
+}
+
+func (p *prefixed) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return p.KeyValue.Get(ctx, p.prefix+key)
+}
+
+func (p *prefixed) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return p.KeyValue.Set(ctx, p.prefix+key, value, ttl)
+}
+
+func (p *prefixed) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return p.KeyValue.SetNX(ctx, p.prefix+key, value, ttl)
+}
+
+func (p *prefixed) Delete(ctx context.Context, key string) error {
+	return p.KeyValue.Delete(ctx, p.prefix+key)
+}
+
+func (p *prefixed) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return p.KeyValue.Incr(ctx, p.prefix+key, ttl)
+}
+
+func (p *prefixed) IncrBy(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	return p.KeyValue.IncrBy(ctx, p.prefix+key, n, ttl)
+}
+
+func (p *prefixed) Append(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return p.KeyValue.Append(ctx, p.prefix+key, value, ttl)
+}
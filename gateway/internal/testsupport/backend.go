@@ -0,0 +1,100 @@
+package testsupport
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// Request is a request a fake backend received
+type Request struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Query  string          `json:"query,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"` // nil when the request had no body
+}
+
+// Backend is a fake backend serving a service's recorded exchanges. A
+// request no fixture matches gets a 404, like an unknown FastAPI route.
+type Backend struct {
+	Service string
+	server  *httptest.Server
+
+	mu        sync.Mutex
+	exchanges []Exchange
+	requests  []Request
+}
+
+// NewBackend starts a fake backend for service, closed when the test ends
+func NewBackend(tb TB, service string) *Backend {
+	tb.Helper()
+	exchanges, err := Fixtures(service)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	b := &Backend{Service: service, exchanges: exchanges}
+	b.server = httptest.NewServer(http.HandlerFunc(b.serve))
+	tb.Cleanup(b.server.Close)
+	return b
+}
+
+// URL is the base URL to point the service's client at
+func (b *Backend) URL() string {
+	return b.server.URL
+}
+
+// Override replaces the response for a route, e.g. to replay an error
+func (b *Backend) Override(exchange Exchange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.exchanges = append([]Exchange{exchange}, b.exchanges...)
+}
+
+// Requests returns the requests received so far, oldest first
+func (b *Backend) Requests() []Request {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Request(nil), b.requests...)
+}
+
+// LastRequest returns the most recent request, or false if none was made
+func (b *Backend) LastRequest() (Request, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.requests) == 0 {
+		return Request{}, false
+	}
+	return b.requests[len(b.requests)-1], true
+}
+
+func (b *Backend) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	received := Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery}
+	if len(body) > 0 {
+		received.Body = body
+	}
+
+	b.mu.Lock()
+	b.requests = append(b.requests, received)
+	var exchange *Exchange
+	for i := range b.exchanges {
+		if b.exchanges[i].matches(r.Method, r.URL.Path) {
+			exchange = &b.exchanges[i]
+			break
+		}
+	}
+	b.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if exchange == nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"detail":"Not Found"}`))
+		return
+	}
+	w.WriteHeader(exchange.Status)
+	if exchange.Status != http.StatusNoContent && len(exchange.Response) > 0 {
+		w.Write(exchange.Response)
+	}
+}
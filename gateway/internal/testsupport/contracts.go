@@ -0,0 +1,162 @@
+package testsupport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/clients"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/google/uuid"
+)
+
+// Clients are the gateway's backend clients wired to fake backends
+type Clients struct {
+	RAG     clients.RAGClient
+	Planner clients.PlannerClient
+	Quiz    clients.QuizClient
+
+	Backends map[string]*Backend
+}
+
+// NewClients starts a fake backend per service and points a client at each
+func NewClients(tb TB) *Clients {
+	tb.Helper()
+	c := &Clients{Backends: map[string]*Backend{}}
+	for _, service := range Services {
+		c.Backends[service] = NewBackend(tb, service)
+	}
+	opts := func(service string) clients.Options {
+		return clients.Options{Service: service, BaseURL: c.Backends[service].URL(), Timeout: 5 * time.Second}
+	}
+	transport := http.DefaultTransport
+	c.RAG = clients.NewRAGClient(transport, opts("rag"))
+	c.Planner = clients.NewPlannerClient(transport, opts("planner"))
+	c.Quiz = clients.NewQuizClient(transport, opts("quiz"))
+	return c
+}
+
+// Contract is a client method called against a fixture
+type Contract struct {
+	Name    string // Golden file name, <service>/<method>
+	Service string
+	Call    func(ctx context.Context, c *Clients) (any, error)
+}
+
+var (
+	planID = uuid.MustParse("3f1c2b8e-5d4a-4e21-9a3b-7c6d5e4f3a21")
+	userID = "user-123"
+)
+
+// Contracts covers every client method
+var Contracts = []Contract{
+	{"rag/search", "rag", func(ctx context.Context, c *Clients) (any, error) {
+		return c.RAG.Search(ctx, clients.SearchRequest{Query: "learn go concurrency", TopK: 2, TenantID: "global"})
+	}},
+	{"rag/ingest_resources", "rag", func(ctx context.Context, c *Clients) (any, error) {
+		return c.RAG.IngestResources(ctx, []clients.IngestResource{
+			{Title: "A Tour of Go", URL: "https://go.dev/tour", RespectRobotsTxt: true},
+			{Title: "Effective Go", URL: "https://go.dev/doc/effective_go", RespectRobotsTxt: true},
+		})
+	}},
+	{"rag/ingest_document", "rag", func(ctx context.Context, c *Clients) (any, error) {
+		return c.RAG.IngestDocument(ctx, clients.IngestResource{
+			Title: "Notes", URL: "https://uploads.example.com/notes.pdf", MediaType: "document", Content: "Goroutines are cheap.",
+		})
+	}},
+	{"planner/create_plan", "planner", func(ctx context.Context, c *Clients) (any, error) {
+		return c.Planner.CreatePlan(ctx, models.PlanLearningPathRequest{
+			Goal: "Learn Go concurrency", CurrentSkills: []string{"python"}, TimeBudgetHours: 10, HoursPerWeek: 5, UserID: &userID,
+		})
+	}},
+	{"planner/get_plan", "planner", func(ctx context.Context, c *Clients) (any, error) {
+		return c.Planner.GetPlan(ctx, planID)
+	}},
+	{"planner/get_user_plans", "planner", func(ctx context.Context, c *Clients) (any, error) {
+		return c.Planner.GetUserPlans(ctx, userID)
+	}},
+	{"planner/replan", "planner", func(ctx context.Context, c *Clients) (any, error) {
+		return c.Planner.Replan(ctx, planID, models.ReplanRequest{
+			CompletedResources: []uuid.UUID{uuid.MustParse("9b8a7c6d-0001-4e5f-9a0b-1c2d3e4f5a6b")}, TimeSpentHours: 1.5,
+		})
+	}},
+	{"planner/set_plan_status", "planner", func(ctx context.Context, c *Clients) (any, error) {
+		return nil, c.Planner.SetPlanStatus(ctx, planID, "draft")
+	}},
+	{"planner/delete_plan", "planner", func(ctx context.Context, c *Clients) (any, error) {
+		return nil, c.Planner.DeletePlan(ctx, planID)
+	}},
+	{"planner/transfer_plans", "planner", func(ctx context.Context, c *Clients) (any, error) {
+		return c.Planner.TransferPlans(ctx, "guest-42", userID)
+	}},
+	{"planner/decompose_goal", "planner", func(ctx context.Context, c *Clients) (any, error) {
+		return c.Planner.DecomposeGoal(ctx, models.DecomposeGoalRequest{Goal: "Become a backend engineer", MaxSubGoals: 2})
+	}},
+	{"quiz/generate_quiz", "quiz", func(ctx context.Context, c *Clients) (any, error) {
+		return c.Quiz.GenerateQuiz(ctx, models.GenerateQuizRequest{
+			ResourceIDs: []string{"9b8a7c6d-0001-4e5f-9a0b-1c2d3e4f5a6b"}, NumQuestions: 2, Difficulty: "medium", ShortAnswers: 1,
+		})
+	}},
+	{"quiz/submit_quiz", "quiz", func(ctx context.Context, c *Clients) (any, error) {
+		return c.Quiz.SubmitQuiz(ctx, clients.QuizSubmitRequest{QuizID: "quiz-7d3e9a1c", Answers: []clients.QuizAnswer{
+			{QuestionID: "q1", SelectedOptionID: "a"},
+			{QuestionID: "q2", AnswerText: "So receivers know no more values are coming"},
+		}})
+	}},
+	{"quiz/retake_quiz", "quiz", func(ctx context.Context, c *Clients) (any, error) {
+		return c.Quiz.RetakeQuiz(ctx, models.RetakeQuizRequest{QuizID: "quiz-7d3e9a1c"})
+	}},
+	{"quiz/list_questions", "quiz", func(ctx context.Context, c *Clients) (any, error) {
+		return c.Quiz.ListQuestions(ctx, models.QuestionBankFilter{Skill: "go-basics", Limit: 10})
+	}},
+	{"quiz/compose_quiz", "quiz", func(ctx context.Context, c *Clients) (any, error) {
+		return c.Quiz.ComposeQuiz(ctx, models.ComposeQuizRequest{Title: "Review", Questions: []models.QuestionRef{{QuizID: "quiz-7d3e9a1c", QuestionID: "q1"}}})
+	}},
+	{"quiz/calibrate", "quiz", func(ctx context.Context, c *Clients) (any, error) {
+		return nil, c.Quiz.Calibrate(ctx, models.CalibrationRequest{TenantID: "global", Questions: []models.QuestionCalibration{
+			{QuizID: "quiz-7d3e9a1c", QuestionID: "q1", Answered: 4, Correct: 3, CorrectRate: 0.75},
+		}})
+	}},
+	{"quiz/grade_answer", "quiz", func(ctx context.Context, c *Clients) (any, error) {
+		return c.Quiz.GradeAnswer(ctx, models.GradeAnswerRequest{QuizID: "quiz-7d3e9a1c", QuestionID: "q2", AnswerText: "So receivers know no more values are coming"})
+	}},
+}
+
+// contractResult is what a contract's golden file records: the request the
+// client sent and what it decoded from the recorded response
+type contractResult struct {
+	Request  Request `json:"request"`
+	Response any     `json:"response"`
+}
+
+// Runner is a TB that runs subtests, such as *testing.T
+type Runner[T any] interface {
+	TB
+	Run(name string, f func(T)) bool
+}
+
+// RunContracts calls every client method against the recorded fixtures and
+// compares the request sent and response decoded with their golden files,
+// each in a subtest of t. A backend schema change shows up as a failing
+// fixture or golden diff.
+func RunContracts[T Runner[T]](t T) {
+	for _, contract := range Contracts {
+		contract := contract
+		t.Run(contract.Name, func(t T) {
+			c := NewClients(t)
+			got, err := contract.Call(context.Background(), c)
+			if err != nil {
+				t.Fatalf("%s: %v", contract.Name, err)
+			}
+			req, ok := c.Backends[contract.Service].LastRequest()
+			if !ok {
+				t.Fatalf("%s: no request reached the %s backend", contract.Name, contract.Service)
+			}
+			if req.Body != nil && !json.Valid(req.Body) {
+				t.Fatalf("%s: request body is not JSON: %s", contract.Name, req.Body)
+			}
+			Golden(t, contract.Name, contractResult{Request: req, Response: got})
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package testsupport_test
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/amirhf/learnpath-gateway/internal/testsupport"
+)
+
+func init() {
+	flag.BoolVar(&testsupport.UpdateGolden, "update-golden", false, "rewrite golden files with the current output")
+}
+
+func TestContracts(t *testing.T) {
+	testsupport.RunContracts(t)
+}
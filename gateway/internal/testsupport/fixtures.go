@@ -0,0 +1,86 @@
+// Package testsupport provides fake RAG, Planner and Quiz services that
+// replay responses recorded from the real ones, so the clients can be
+// checked against the backends' contracts without running them.
+package testsupport
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Services the gateway has fixtures for
+var Services = []string{"rag", "planner", "quiz"}
+
+//go:embed fixtures/*/*.json
+var fixtures embed.FS
+
+// Exchange is one recorded request and the backend's response. Path is a
+// route pattern; {name} segments match any value.
+type Exchange struct {
+	Name     string          `json:"-"`
+	Method   string          `json:"method"`
+	Path     string          `json:"path"`
+	Status   int             `json:"status"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// Fixtures loads every recorded exchange of a service, ordered by name
+func Fixtures(service string) ([]Exchange, error) {
+	entries, err := fixtures.ReadDir(path.Join("fixtures", service))
+	if err != nil {
+		return nil, fmt.Errorf("testsupport: no fixtures for %q: %w", service, err)
+	}
+	exchanges := make([]Exchange, 0, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		exchange, err := Fixture(service, name)
+		if err != nil {
+			return nil, err
+		}
+		exchanges = append(exchanges, exchange)
+	}
+	sort.Slice(exchanges, func(i, j int) bool { return exchanges[i].Name < exchanges[j].Name })
+	return exchanges, nil
+}
+
+// Fixture loads one recorded exchange, e.g. Fixture("planner", "get_plan")
+func Fixture(service, name string) (Exchange, error) {
+	data, err := fixtures.ReadFile(path.Join("fixtures", service, name+".json"))
+	if err != nil {
+		return Exchange{}, fmt.Errorf("testsupport: fixture %s/%s: %w", service, name, err)
+	}
+	var exchange Exchange
+	if err := json.Unmarshal(data, &exchange); err != nil {
+		return Exchange{}, fmt.Errorf("testsupport: decode fixture %s/%s: %w", service, name, err)
+	}
+	if exchange.Status == 0 {
+		exchange.Status = 200
+	}
+	exchange.Name = name
+	return exchange, nil
+}
+
+// matches reports whether a request's method and path fit the exchange
+func (e Exchange) matches(method, urlPath string) bool {
+	if !strings.EqualFold(e.Method, method) {
+		return false
+	}
+	pattern := strings.Split(strings.Trim(e.Path, "/"), "/")
+	segments := strings.Split(strings.Trim(urlPath, "/"), "/")
+	if len(pattern) != len(segments) {
+		return false
+	}
+	for i, part := range pattern {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			continue
+		}
+		if part != segments[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package testsupport
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// UpdateGolden makes Golden rewrite golden files with the current output.
+// Tests set it from their -update-golden flag.
+var UpdateGolden bool
+
+// TB is the part of testing.TB the helpers use. Taking it instead keeps
+// the testing package, and its flags, out of this one.
+type TB interface {
+	Helper()
+	Cleanup(func())
+	Errorf(format string, args ...any)
+	Fatal(args ...any)
+	Fatalf(format string, args ...any)
+}
+
+// goldenDir is where golden files live, next to this file whichever
+// package's tests use them
+func goldenDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "testdata", "golden")
+}
+
+// Golden compares got, as indented JSON, with the golden file name. Run the
+// tests with -update-golden to accept a change.
+func Golden(tb TB, name string, got any) {
+	tb.Helper()
+	actual, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		tb.Fatalf("golden %s: marshal: %v", name, err)
+	}
+	actual = append(actual, '\n')
+
+	file := filepath.Join(goldenDir(), name+".json")
+	if UpdateGolden {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(file, actual, 0o644); err != nil {
+			tb.Fatal(err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(file)
+	if err != nil {
+		tb.Fatalf("golden %s: %v (run with -update-golden to create it)", name, err)
+	}
+	if !bytes.Equal(expected, actual) {
+		tb.Errorf("golden %s differs (run with -update-golden to accept)\n--- want\n%s\n--- got\n%s", name, expected, actual)
+	}
+}
@@ -0,0 +1,669 @@
+package testsupport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/bufpool"
+	"github.com/amirhf/learnpath-gateway/internal/clients"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/models"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/google/uuid"
+)
+
+var _ orchestrator.Orchestrator = (*FakeOrchestrator)(nil)
+
+// FakeOrchestrator is an in-memory Orchestrator for handler tests. It keeps
+// the resources, plans and quizzes it creates, so a plan created by one
+// call is found by the next. Fail and Delay program a method's behavior;
+// Calls reports how often it ran. For checking individual arguments use
+// mocks.OrchestratorMock instead.
+type FakeOrchestrator struct {
+	mu        sync.Mutex
+	resources []models.ResourceResult
+	plans     map[uuid.UUID]models.LearningPath
+	owners    map[uuid.UUID]string // Plan -> user
+	quizzes   map[string]models.Quiz
+	failures  map[string]error
+	delays    map[string]time.Duration
+	calls     map[string]int
+	endpoints map[string][]string
+	config    *config.Config
+}
+
+// NewFakeOrchestrator creates a fake whose corpus holds resources
+func NewFakeOrchestrator(resources ...models.ResourceResult) *FakeOrchestrator {
+	return &FakeOrchestrator{
+		resources: resources,
+		plans:     map[uuid.UUID]models.LearningPath{},
+		owners:    map[uuid.UUID]string{},
+		quizzes:   map[string]models.Quiz{},
+		failures:  map[string]error{},
+		delays:    map[string]time.Duration{},
+		calls:     map[string]int{},
+		endpoints: map[string][]string{},
+	}
+}
+
+// Fail makes a method, e.g. "GetPlan", return err until Fail is called
+// again with nil. Use NotFound or Unavailable for errors handlers map to a
+// backend status.
+func (f *FakeOrchestrator) Fail(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.failures, method)
+		return
+	}
+	f.failures[method] = err
+}
+
+// Delay makes a method wait d before answering, or until its context ends
+func (f *FakeOrchestrator) Delay(method string, d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delays[method] = d
+}
+
+// Calls returns how many times a method was called
+func (f *FakeOrchestrator) Calls(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+// AddPlan stores a plan owned by userID, assigning an ID when it has none
+func (f *FakeOrchestrator) AddPlan(userID string, plan models.LearningPath) uuid.UUID {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if plan.PlanID == uuid.Nil {
+		plan.PlanID = uuid.New()
+	}
+	f.plans[plan.PlanID] = plan
+	f.owners[plan.PlanID] = userID
+	return plan.PlanID
+}
+
+// AddQuiz stores a quiz, e.g. one with known correct options to submit
+func (f *FakeOrchestrator) AddQuiz(quiz models.Quiz) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.quizzes[quiz.QuizID] = quiz
+}
+
+// Endpoints returns the replicas last set for a service
+func (f *FakeOrchestrator) Endpoints(service string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.endpoints[service]
+}
+
+// NotFound is the error a backend returns for a missing plan or quiz
+func NotFound(service, op string) error {
+	return &clients.UpstreamError{Service: service, Op: op, StatusCode: http.StatusNotFound, Code: "not_found", Message: "Not Found"}
+}
+
+// Unavailable is the error for a backend that can't be reached
+func Unavailable(service, op string) error {
+	return &clients.UpstreamError{Service: service, Op: op, Code: clients.CodeUnavailable, Retryable: true}
+}
+
+// begin records a call and applies the method's programmed behavior. The
+// lock is held on success and must be released by the caller.
+func (f *FakeOrchestrator) begin(ctx context.Context, method string) error {
+	f.mu.Lock()
+	f.calls[method]++
+	delay, err := f.delays[method], f.failures[method]
+	f.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	return nil
+}
+
+func (f *FakeOrchestrator) Search(ctx context.Context, req clients.SearchRequest) (*models.SearchResponse, error) {
+	if err := f.begin(ctx, "Search"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	results := f.search(req.Query, req.TopK)
+	return &models.SearchResponse{Results: results, Query: req.Query, TotalFound: len(results)}, nil
+}
+
+// search returns resources whose title or skills mention a word of query,
+// or the whole corpus when none does
+func (f *FakeOrchestrator) search(query string, limit int) []models.ResourceResult {
+	var results []models.ResourceResult
+	for _, resource := range f.resources {
+		text := strings.ToLower(resource.Title + " " + strings.Join(resource.Skills, " "))
+		for _, word := range strings.Fields(strings.ToLower(query)) {
+			if strings.Contains(text, word) {
+				results = append(results, resource)
+				break
+			}
+		}
+	}
+	if len(results) == 0 {
+		results = append(results, f.resources...)
+	}
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func (f *FakeOrchestrator) PlanLearningPath(ctx context.Context, req models.PlanLearningPathRequest) (*models.LearningPath, error) {
+	if err := f.begin(ctx, "PlanLearningPath"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	plan := f.plan(req)
+	return &plan, nil
+}
+
+// plan builds and stores a single-milestone plan over the resources
+// matching the goal
+func (f *FakeOrchestrator) plan(req models.PlanLearningPathRequest) models.LearningPath {
+	now := time.Now().UTC()
+	milestone := models.Milestone{MilestoneID: uuid.New(), Title: req.Goal, Order: 1}
+	for i, resource := range f.search(req.Goal, 0) {
+		item := models.ResourceItem{
+			ResourceID: resource.ID,
+			Title:      resource.Title,
+			URL:        resource.URL,
+			Level:      resource.Level,
+			Skills:     resource.Skills,
+			Order:      i + 1,
+		}
+		if resource.DurationMin != nil {
+			item.DurationMin = *resource.DurationMin
+		}
+		milestone.Resources = append(milestone.Resources, item)
+		milestone.EstimatedHours += float64(item.DurationMin) / 60
+		milestone.SkillsGained = append(milestone.SkillsGained, resource.Skills...)
+	}
+	plan := models.LearningPath{
+		PlanID:           uuid.New(),
+		Goal:             req.Goal,
+		TotalHours:       milestone.EstimatedHours,
+		EstimatedWeeks:   1,
+		Milestones:       []models.Milestone{milestone},
+		PrerequisitesMet: true,
+		Status:           models.PlanActive,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	f.plans[plan.PlanID] = plan
+	if req.UserID != nil {
+		f.owners[plan.PlanID] = *req.UserID
+	}
+	return plan
+}
+
+func (f *FakeOrchestrator) GetPlan(ctx context.Context, planID uuid.UUID) (*models.LearningPath, error) {
+	if err := f.begin(ctx, "GetPlan"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	plan, ok := f.plans[planID]
+	if !ok {
+		return nil, NotFound("planner", "get plan")
+	}
+	return &plan, nil
+}
+
+func (f *FakeOrchestrator) GetUserPlans(ctx context.Context, userID string) ([]models.LearningPath, error) {
+	if err := f.begin(ctx, "GetUserPlans"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	plans := []models.LearningPath{}
+	for id, owner := range f.owners {
+		if owner == userID {
+			plans = append(plans, f.plans[id])
+		}
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].CreatedAt.After(plans[j].CreatedAt) })
+	return plans, nil
+}
+
+func (f *FakeOrchestrator) TransferPlans(ctx context.Context, fromUserID, toUserID string) (int, error) {
+	if err := f.begin(ctx, "TransferPlans"); err != nil {
+		return 0, err
+	}
+	defer f.mu.Unlock()
+	moved := 0
+	for id, owner := range f.owners {
+		if owner == fromUserID {
+			f.owners[id] = toUserID
+			moved++
+		}
+	}
+	return moved, nil
+}
+
+func (f *FakeOrchestrator) Replan(ctx context.Context, planID uuid.UUID, req models.ReplanRequest) (*models.LearningPath, error) {
+	if err := f.begin(ctx, "Replan"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	plan, ok := f.plans[planID]
+	if !ok {
+		return nil, NotFound("planner", "replan")
+	}
+	completed := map[uuid.UUID]bool{}
+	for _, id := range req.CompletedResources {
+		completed[id] = true
+	}
+	var milestones []models.Milestone
+	plan.TotalHours = 0
+	for _, milestone := range plan.Milestones {
+		var remaining []models.ResourceItem
+		for _, item := range milestone.Resources {
+			if !completed[item.ResourceID] {
+				remaining = append(remaining, item)
+			}
+		}
+		if len(remaining) > 0 {
+			milestone.Resources = remaining
+			milestones = append(milestones, milestone)
+			plan.TotalHours += milestone.EstimatedHours
+		}
+	}
+	plan.Milestones = milestones
+	plan.UpdatedAt = time.Now().UTC()
+	f.plans[planID] = plan
+	return &plan, nil
+}
+
+func (f *FakeOrchestrator) GenerateQuiz(ctx context.Context, req models.GenerateQuizRequest) (*models.Quiz, error) {
+	if err := f.begin(ctx, "GenerateQuiz"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	quiz := f.quiz(req.ResourceIDs, req.NumQuestions)
+	return &quiz, nil
+}
+
+// quiz builds and stores a quiz of n multiple-choice questions cycling
+// through resourceIDs; option "a" is always correct
+func (f *FakeOrchestrator) quiz(resourceIDs []string, n int) models.Quiz {
+	quiz := models.Quiz{QuizID: "quiz-" + uuid.NewString()[:8], CreatedAt: time.Now().UTC()}
+	for i := 0; i < n; i++ {
+		source := ""
+		if len(resourceIDs) > 0 {
+			source = resourceIDs[i%len(resourceIDs)]
+		}
+		quiz.Questions = append(quiz.Questions, models.QuizQuestion{
+			QuestionID:   fmt.Sprintf("q%d", i+1),
+			QuestionType: models.QuestionMultipleChoice,
+			QuestionText: fmt.Sprintf("Question %d", i+1),
+			Options: []models.QuizOption{
+				{OptionID: "a", Text: "Right", IsCorrect: true},
+				{OptionID: "b", Text: "Wrong"},
+			},
+			SourceResourceID: source,
+		})
+	}
+	quiz.TotalQuestions = len(quiz.Questions)
+	f.quizzes[quiz.QuizID] = quiz
+	return quiz
+}
+
+func (f *FakeOrchestrator) GenerateQuizzes(ctx context.Context, reqs []models.GenerateQuizRequest) ([]*models.Quiz, []error) {
+	quizzes, errs := make([]*models.Quiz, len(reqs)), make([]error, len(reqs))
+	for i, req := range reqs {
+		quizzes[i], errs[i] = f.GenerateQuiz(ctx, req)
+	}
+	return quizzes, errs
+}
+
+func (f *FakeOrchestrator) RetakeQuiz(ctx context.Context, req models.RetakeQuizRequest) (*models.Quiz, error) {
+	if err := f.begin(ctx, "RetakeQuiz"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	original, ok := f.quizzes[req.QuizID]
+	if !ok {
+		return nil, NotFound("quiz", "retake quiz")
+	}
+	variant := original
+	variant.QuizID = "quiz-" + uuid.NewString()[:8]
+	variant.VariantOf = original.QuizID
+	variant.CreatedAt = time.Now().UTC()
+	f.quizzes[variant.QuizID] = variant
+	return &variant, nil
+}
+
+func (f *FakeOrchestrator) ListQuestions(ctx context.Context, filter models.QuestionBankFilter) ([]models.BankQuestion, error) {
+	if err := f.begin(ctx, "ListQuestions"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	questions := []models.BankQuestion{}
+	for _, quiz := range f.quizzes {
+		for _, question := range quiz.Questions {
+			if filter.ResourceID != "" && question.SourceResourceID != filter.ResourceID {
+				continue
+			}
+			public := models.PublicQuizQuestion{
+				QuestionID:       question.QuestionID,
+				QuestionType:     question.QuestionType,
+				QuestionText:     question.QuestionText,
+				SourceResourceID: question.SourceResourceID,
+				Citation:         question.Citation,
+			}
+			for _, option := range question.Options {
+				public.Options = append(public.Options, models.PublicQuizOption{OptionID: option.OptionID, Text: option.Text})
+			}
+			questions = append(questions, models.BankQuestion{QuizID: quiz.QuizID, PublicQuizQuestion: public, CreatedAt: quiz.CreatedAt})
+		}
+	}
+	sort.Slice(questions, func(i, j int) bool { return questions[i].CreatedAt.After(questions[j].CreatedAt) })
+	if filter.Limit > 0 && len(questions) > filter.Limit {
+		questions = questions[:filter.Limit]
+	}
+	return questions, nil
+}
+
+func (f *FakeOrchestrator) ComposeQuiz(ctx context.Context, req models.ComposeQuizRequest) (*models.Quiz, error) {
+	if err := f.begin(ctx, "ComposeQuiz"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	quiz := models.Quiz{QuizID: "quiz-" + uuid.NewString()[:8], CreatedAt: time.Now().UTC()}
+	if req.Title != "" {
+		quiz.Title = &req.Title
+	}
+	for _, ref := range req.Questions {
+		question, ok := f.question(ref.QuizID, ref.QuestionID)
+		if !ok {
+			return nil, NotFound("quiz", "compose quiz")
+		}
+		quiz.Questions = append(quiz.Questions, question)
+	}
+	quiz.TotalQuestions = len(quiz.Questions)
+	f.quizzes[quiz.QuizID] = quiz
+	return &quiz, nil
+}
+
+func (f *FakeOrchestrator) question(quizID, questionID string) (models.QuizQuestion, bool) {
+	for _, question := range f.quizzes[quizID].Questions {
+		if question.QuestionID == questionID {
+			return question, true
+		}
+	}
+	return models.QuizQuestion{}, false
+}
+
+func (f *FakeOrchestrator) SubmitQuiz(ctx context.Context, req clients.QuizSubmitRequest) (*clients.QuizSubmitResponse, error) {
+	if err := f.begin(ctx, "SubmitQuiz"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	quiz, ok := f.quizzes[req.QuizID]
+	if !ok {
+		return nil, NotFound("quiz", "submit quiz")
+	}
+	resp := &clients.QuizSubmitResponse{QuizID: quiz.QuizID, TotalQuestions: quiz.TotalQuestions}
+	for _, answer := range req.Answers {
+		question, ok := f.question(quiz.QuizID, answer.QuestionID)
+		if !ok {
+			continue
+		}
+		result := models.QuestionResult{
+			QuestionID:       question.QuestionID,
+			SelectedOptionID: answer.SelectedOptionID,
+			Explanation:      question.Explanation,
+			Citation:         question.Citation,
+			SourceResourceID: question.SourceResourceID,
+			QuestionType:     question.QuestionType,
+			AnswerText:       answer.AnswerText,
+		}
+		if question.QuestionType == models.QuestionShortAnswer {
+			result.Status = "pending"
+			resp.PendingQuestions++
+		}
+		for _, option := range question.Options {
+			if option.IsCorrect {
+				result.CorrectOptionID = option.OptionID
+				result.Correct = option.OptionID == answer.SelectedOptionID
+			}
+		}
+		if result.Correct {
+			resp.CorrectAnswers++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	if resp.TotalQuestions > 0 {
+		resp.Score = 100 * float64(resp.CorrectAnswers) / float64(resp.TotalQuestions)
+	}
+	return resp, nil
+}
+
+func (f *FakeOrchestrator) CalibrateQuiz(ctx context.Context, req models.CalibrationRequest) error {
+	if err := f.begin(ctx, "CalibrateQuiz"); err != nil {
+		return err
+	}
+	f.mu.Unlock()
+	return nil
+}
+
+// GradeAnswer gives full credit to any non-empty answer
+func (f *FakeOrchestrator) GradeAnswer(ctx context.Context, req models.GradeAnswerRequest) (*models.GradeAnswerResponse, error) {
+	if err := f.begin(ctx, "GradeAnswer"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	if _, ok := f.question(req.QuizID, req.QuestionID); !ok {
+		return nil, NotFound("quiz", "grade answer")
+	}
+	resp := &models.GradeAnswerResponse{QuizID: req.QuizID, QuestionID: req.QuestionID}
+	if strings.TrimSpace(req.AnswerText) != "" {
+		resp.Correct, resp.Score = true, 1
+	}
+	return resp, nil
+}
+
+func (f *FakeOrchestrator) OrchestrateFullFlow(ctx context.Context, req models.OrchestrateFullFlowRequest) (*models.LearningPathWithQuiz, error) {
+	if err := f.begin(ctx, "OrchestrateFullFlow"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	result := &models.LearningPathWithQuiz{LearningPath: f.plan(req.PlanLearningPathRequest)}
+	if !req.GenerateQuiz {
+		return result, nil
+	}
+	for _, milestone := range result.LearningPath.Milestones {
+		var resourceIDs []string
+		for _, item := range milestone.Resources {
+			resourceIDs = append(resourceIDs, item.ResourceID.String())
+		}
+		quiz := f.quiz(resourceIDs, max(req.NumQuestions, 1))
+		if !req.QuizPerMilestone {
+			result.Quiz = &quiz
+			break
+		}
+		result.MilestoneQuizzes = append(result.MilestoneQuizzes, models.MilestoneQuiz{MilestoneID: milestone.MilestoneID, Quiz: &quiz})
+	}
+	return result, nil
+}
+
+func (f *FakeOrchestrator) RemediatePlan(ctx context.Context, req models.RemediationRequest) (*models.Remediation, error) {
+	if err := f.begin(ctx, "RemediatePlan"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	plan, ok := f.plans[req.PlanID]
+	if !ok {
+		return nil, NotFound("planner", "replan")
+	}
+	inPlan := map[string]bool{}
+	for _, milestone := range plan.Milestones {
+		for _, item := range milestone.Resources {
+			inPlan[item.ResourceID.String()] = true
+		}
+	}
+	remediation := &models.Remediation{Plan: &plan, ReviewResources: []uuid.UUID{}, WeakSkills: []string{}}
+	for _, id := range req.MissedResources {
+		if req.MaxResources > 0 && len(remediation.ReviewResources) == req.MaxResources {
+			break
+		}
+		if inPlan[id] {
+			remediation.ReviewResources = append(remediation.ReviewResources, uuid.MustParse(id))
+		}
+	}
+	return remediation, nil
+}
+
+func (f *FakeOrchestrator) EstimatePlan(ctx context.Context, req models.OrchestrateFullFlowRequest) (*models.PlanEstimate, error) {
+	if err := f.begin(ctx, "EstimatePlan"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	resources := f.search(req.Goal, 0)
+	estimate := &models.PlanEstimate{
+		ResourcesConsidered: len(resources),
+		ResourcesSelected:   len(resources),
+		Milestones:          1,
+		EstimatedWeeks:      1,
+	}
+	for _, resource := range resources {
+		if resource.DurationMin != nil {
+			estimate.ContentHours += float64(*resource.DurationMin) / 60
+		}
+	}
+	if req.GenerateQuiz {
+		estimate.QuizCalls = 1
+	}
+	return estimate, nil
+}
+
+// DecomposeGoal suggests the goal itself as its only sub-goal
+func (f *FakeOrchestrator) DecomposeGoal(ctx context.Context, req models.DecomposeGoalRequest) (*models.GoalDecomposition, error) {
+	if err := f.begin(ctx, "DecomposeGoal"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	return &models.GoalDecomposition{
+		Goal:                req.Goal,
+		SubGoals:            []models.SubGoal{{Title: req.Goal, EstimatedHours: 1, Order: 1}},
+		TotalEstimatedHours: 1,
+	}, nil
+}
+
+// IngestContent adds a resource per URL to the corpus
+func (f *FakeOrchestrator) IngestContent(ctx context.Context, req models.IngestRequest) ([]string, error) {
+	if err := f.begin(ctx, "IngestContent"); err != nil {
+		return nil, err
+	}
+	defer f.mu.Unlock()
+	ids := make([]string, 0, len(req.URLs))
+	for _, url := range req.URLs {
+		resource := models.ResourceResult{ID: uuid.New(), Title: url, URL: url, Skills: []string{}}
+		f.resources = append(f.resources, resource)
+		ids = append(ids, resource.ID.String())
+	}
+	return ids, nil
+}
+
+func (f *FakeOrchestrator) IngestDocument(ctx context.Context, req models.IngestDocumentRequest) error {
+	if err := f.begin(ctx, "IngestDocument"); err != nil {
+		return err
+	}
+	defer f.mu.Unlock()
+	resource := models.ResourceResult{ID: uuid.New(), Title: req.Title, URL: req.URL, Skills: []string{}}
+	if req.MediaType != "" {
+		resource.MediaType = &req.MediaType
+	}
+	f.resources = append(f.resources, resource)
+	return nil
+}
+
+func (f *FakeOrchestrator) ApplyConfig(cfg *config.Config) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["ApplyConfig"]++
+	f.config = cfg
+}
+
+// Forward answers the proxy handlers from the fake's resources and plans:
+// searches and a user's plans. Backend errors come back as responses with
+// their status.
+func (f *FakeOrchestrator) Forward(ctx context.Context, service, method, path string, body *bufpool.Body) (*http.Response, error) {
+	var result any
+	var err error
+	switch {
+	case service == "rag" && path == "/search":
+		var req clients.SearchRequest
+		if body != nil {
+			httpReq, reqErr := body.NewRequest(ctx, method, path)
+			if reqErr != nil {
+				return nil, reqErr
+			}
+			defer httpReq.Body.Close()
+			if err := json.NewDecoder(httpReq.Body).Decode(&req); err != nil {
+				return nil, err
+			}
+		}
+		result, err = f.Search(ctx, req)
+	case service == "planner" && strings.HasPrefix(path, "/user/") && strings.HasSuffix(path, "/plans"):
+		userID, _ := url.PathUnescape(strings.TrimSuffix(strings.TrimPrefix(path, "/user/"), "/plans"))
+		var plans []models.LearningPath
+		plans, err = f.GetUserPlans(ctx, userID)
+		result = map[string]any{"plans": plans}
+	default:
+		return nil, fmt.Errorf("testsupport: no fake for %s %s %s", service, method, path)
+	}
+
+	status := http.StatusOK
+	if upstream, ok := clients.AsUpstreamError(err); ok && upstream.StatusCode != 0 {
+		status, result = upstream.StatusCode, map[string]string{"detail": upstream.Message}
+	} else if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+	}, nil
+}
+
+func (f *FakeOrchestrator) SetEndpoints(service string, urls []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["SetEndpoints"]++
+	f.endpoints[service] = urls
+}
+
+func (f *FakeOrchestrator) BreakerStates() map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["BreakerStates"]++
+	return map[string]string{"rag": clients.BreakerClosed, "planner": clients.BreakerClosed, "quiz": clients.BreakerClosed}
+}
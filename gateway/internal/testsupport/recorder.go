@@ -0,0 +1,82 @@
+package testsupport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Recorder is a RoundTripper that saves the responses of real backends as
+// fixtures, for re-recording them after a backend changes. Point a client
+// at a running service through it, call each method once, then review the
+// diff: recorded paths are literal and IDs need replacing with {name}
+// segments where the fixture should match any value.
+type Recorder struct {
+	Next    http.RoundTripper // nil for http.DefaultTransport
+	Service string
+	Dir     string // Fixtures root, e.g. internal/testsupport/fixtures
+
+	mu    sync.Mutex
+	names map[string]int
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := r.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	exchange := Exchange{Method: req.Method, Path: req.URL.Path, Status: resp.StatusCode}
+	if json.Valid(body) {
+		var indented bytes.Buffer
+		json.Indent(&indented, body, "", "  ")
+		exchange.Response = indented.Bytes()
+	}
+	if err := r.save(exchange); err != nil {
+		return nil, fmt.Errorf("testsupport: record %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	return resp, nil
+}
+
+func (r *Recorder) save(exchange Exchange) error {
+	data, err := json.MarshalIndent(exchange, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(r.Dir, r.Service)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, r.name(exchange)+".json"), append(data, '\n'), 0o644)
+}
+
+// name derives a file name from the method and path, numbering repeats
+func (r *Recorder) name(exchange Exchange) string {
+	name := strings.ToLower(exchange.Method) + strings.NewReplacer("/", "_", "{", "", "}", "").Replace(exchange.Path)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names == nil {
+		r.names = map[string]int{}
+	}
+	r.names[name]++
+	if n := r.names[name]; n > 1 {
+		name = fmt.Sprintf("%s_%d", name, n)
+	}
+	return name
+}
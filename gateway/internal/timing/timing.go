@@ -0,0 +1,87 @@
+// Package timing breaks down where a request's time went by the backends
+// it called.
+package timing
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Breakdown collects the time one request spent in each backend. Calls
+// made concurrently each count in full, so the total may exceed the
+// request's own latency.
+type Breakdown struct {
+	mu    sync.Mutex
+	spent map[string]time.Duration
+	calls map[string]int
+}
+
+type breakdownKey struct{}
+
+// WithBreakdown returns a context whose backend calls are timed on the
+// returned breakdown
+func WithBreakdown(ctx context.Context) (context.Context, *Breakdown) {
+	b := &Breakdown{spent: map[string]time.Duration{}, calls: map[string]int{}}
+	return context.WithValue(ctx, breakdownKey{}, b), b
+}
+
+// FromContext returns the context's breakdown, or nil when it has none
+func FromContext(ctx context.Context) *Breakdown {
+	b, _ := ctx.Value(breakdownKey{}).(*Breakdown)
+	return b
+}
+
+// Record adds a call to service taking d to the context's breakdown, if any
+func Record(ctx context.Context, service string, d time.Duration) {
+	b := FromContext(ctx)
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.spent[service] += d
+	b.calls[service]++
+}
+
+// Spent returns the time spent in each backend called so far
+func (b *Breakdown) Spent() map[string]time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	spent := make(map[string]time.Duration, len(b.spent))
+	for service, d := range b.spent {
+		spent[service] = d
+	}
+	return spent
+}
+
+// Calls returns how many calls were made to service
+func (b *Breakdown) Calls(service string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.calls[service]
+}
+
+// Services lists the backends called so far, sorted
+func (b *Breakdown) Services() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	services := make([]string, 0, len(b.spent))
+	for service := range b.spent {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// Total is the time spent in all backends
+func (b *Breakdown) Total() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var total time.Duration
+	for _, d := range b.spent {
+		total += d
+	}
+	return total
+}
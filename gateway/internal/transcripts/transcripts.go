@@ -0,0 +1,307 @@
+// Package transcripts fetches time-coded transcripts of video resources at
+// ingestion, from YouTube captions or a Whisper transcription service, and
+// keeps them for the resource viewer
+package transcripts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+	"github.com/google/uuid"
+)
+
+// keyPrefix namespaces stored transcripts by resource ID
+const keyPrefix = "transcript:"
+
+// Transcript sources
+const (
+	SourceYouTube = "youtube"
+	SourceWhisper = "whisper"
+)
+
+// ErrNotVideo is returned for URLs that aren't videos
+var ErrNotVideo = errors.New("not a video URL")
+
+// ErrNoTranscript is returned when no source has a transcript
+var ErrNoTranscript = errors.New("no transcript available")
+
+// mediaExtensions are direct links to audio or video files, which only
+// Whisper can transcribe
+var mediaExtensions = map[string]bool{
+	".mp4": true, ".webm": true, ".mov": true, ".mkv": true,
+	".mp3": true, ".m4a": true, ".wav": true, ".ogg": true,
+}
+
+// Segment is one timed line of a transcript
+type Segment struct {
+	Start float64 `json:"start"` // Seconds from the start of the video
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Transcript is the time-coded text of a video resource
+type Transcript struct {
+	ResourceID string    `json:"resource_id"`
+	URL        string    `json:"url"`
+	Title      string    `json:"title,omitempty"`
+	Source     string    `json:"source"` // youtube or whisper
+	Language   string    `json:"language,omitempty"`
+	Segments   []Segment `json:"segments"`
+	FetchedAt  time.Time `json:"fetched_at"`
+}
+
+// Duration is the end of the last segment
+func (t *Transcript) Duration() time.Duration {
+	if len(t.Segments) == 0 {
+		return 0
+	}
+	return time.Duration(t.Segments[len(t.Segments)-1].End * float64(time.Second))
+}
+
+// Text renders the transcript one segment per line, each prefixed with its
+// [mm:ss] timestamp so quiz citations can point into the video
+func (t *Transcript) Text() string {
+	var b strings.Builder
+	for _, s := range t.Segments {
+		b.WriteString("[" + Timestamp(s.Start) + "] " + s.Text + "\n")
+	}
+	return b.String()
+}
+
+// Timestamp formats seconds as mm:ss, or h:mm:ss past an hour
+func Timestamp(seconds float64) string {
+	total := int(math.Max(seconds, 0))
+	h, m, s := total/3600, total/60%60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
+
+// ResourceID is the ID a video resource is ingested under, derived from
+// its canonical URL so the transcript can be found again
+func ResourceID(rawURL string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(rawURL)).String()
+}
+
+// Service fetches and stores transcripts
+type Service struct {
+	cfg    config.TranscriptConfig
+	store  storage.KeyValue
+	client *http.Client
+}
+
+// New creates a transcript service
+func New(cfg config.TranscriptConfig, store storage.KeyValue) *Service {
+	return &Service{cfg: cfg, store: store, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+// Enabled reports whether transcripts are fetched at ingestion
+func (s *Service) Enabled() bool {
+	return s != nil && s.cfg.Enabled
+}
+
+// Transcribe fetches the transcript of a video URL and stores it. It
+// returns ErrNotVideo for other URLs and ErrNoTranscript when neither
+// captions nor Whisper produce one.
+func (s *Service) Transcribe(ctx context.Context, rawURL string) (*Transcript, error) {
+	videoID, youtube := YouTubeID(rawURL)
+	if !youtube && !mediaExtensions[strings.ToLower(path.Ext(urlPath(rawURL)))] {
+		return nil, ErrNotVideo
+	}
+
+	t := &Transcript{ResourceID: ResourceID(rawURL), URL: rawURL}
+	var err error
+	if youtube {
+		t.Source = SourceYouTube
+		t.Language, t.Segments, err = s.captions(ctx, videoID)
+	}
+	// Videos without captions, or direct media links, need transcribing
+	if len(t.Segments) == 0 && s.cfg.WhisperURL != "" {
+		t.Source = SourceWhisper
+		t.Language, t.Segments, err = s.whisper(ctx, rawURL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Segments) == 0 {
+		return nil, ErrNoTranscript
+	}
+	if youtube {
+		t.Title = s.youTubeTitle(ctx, rawURL)
+	}
+	t.FetchedAt = time.Now().UTC()
+
+	encoded, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Set(ctx, keyPrefix+t.ResourceID, encoded, 0); err != nil {
+		return nil, fmt.Errorf("failed to store transcript: %w", err)
+	}
+	return t, nil
+}
+
+// Get returns the stored transcript of a resource, if any
+func (s *Service) Get(ctx context.Context, resourceID string) (*Transcript, bool, error) {
+	data, ok, err := s.store.Get(ctx, keyPrefix+resourceID)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	var t Transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, false, fmt.Errorf("failed to decode transcript: %w", err)
+	}
+	return &t, true, nil
+}
+
+// YouTubeID extracts the video ID from youtube.com and youtu.be links
+func YouTubeID(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	var id string
+	switch host {
+	case "youtu.be":
+		id = strings.Trim(u.Path, "/")
+	case "youtube.com":
+		if u.Path == "/watch" {
+			id = u.Query().Get("v")
+		} else if rest, ok := strings.CutPrefix(u.Path, "/shorts/"); ok {
+			id = rest
+		} else if rest, ok := strings.CutPrefix(u.Path, "/embed/"); ok {
+			id = rest
+		}
+	}
+	id, _, _ = strings.Cut(id, "/")
+	return id, id != ""
+}
+
+func urlPath(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.Path
+	}
+	return ""
+}
+
+// captions reads YouTube's timed text track in the first configured
+// language that has one
+func (s *Service) captions(ctx context.Context, videoID string) (string, []Segment, error) {
+	for _, lang := range s.cfg.Languages {
+		target := "https://www.youtube.com/api/timedtext?" + url.Values{"v": {videoID}, "lang": {lang}}.Encode()
+		body, err := s.get(ctx, target)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(bytes.TrimSpace(body)) == 0 {
+			continue // No track in this language
+		}
+
+		var track struct {
+			Texts []struct {
+				Start float64 `xml:"start,attr"`
+				Dur   float64 `xml:"dur,attr"`
+				Text  string  `xml:",chardata"`
+			} `xml:"text"`
+		}
+		if err := xml.Unmarshal(body, &track); err != nil {
+			return "", nil, fmt.Errorf("failed to decode captions: %w", err)
+		}
+		segments := make([]Segment, 0, len(track.Texts))
+		for _, t := range track.Texts {
+			// Caption text is HTML-escaped inside the XML
+			text := strings.Join(strings.Fields(html.UnescapeString(t.Text)), " ")
+			if text != "" {
+				segments = append(segments, Segment{Start: t.Start, End: t.Start + t.Dur, Text: text})
+			}
+		}
+		if len(segments) > 0 {
+			return lang, segments, nil
+		}
+	}
+	return "", nil, nil
+}
+
+// youTubeTitle looks the video's title up over oEmbed; failures leave it
+// for the RAG service to fill in
+func (s *Service) youTubeTitle(ctx context.Context, rawURL string) string {
+	body, err := s.get(ctx, "https://www.youtube.com/oembed?"+url.Values{"url": {rawURL}, "format": {"json"}}.Encode())
+	if err != nil {
+		return ""
+	}
+	var meta struct {
+		Title string `json:"title"`
+	}
+	if json.Unmarshal(body, &meta) != nil {
+		return ""
+	}
+	return meta.Title
+}
+
+// whisper asks the transcription service for a video's transcript. It takes
+// {"url", "languages"} and answers {"language", "segments": [{"start",
+// "end", "text"}]}.
+func (s *Service) whisper(ctx context.Context, rawURL string) (string, []Segment, error) {
+	payload, err := json.Marshal(map[string]any{"url": rawURL, "languages": s.cfg.Languages})
+	if err != nil {
+		return "", nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.WhisperURL, bytes.NewReader(payload))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create transcription request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("transcription request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("transcription service returned %d", resp.StatusCode)
+	}
+	var result struct {
+		Language string    `json:"language"`
+		Segments []Segment `json:"segments"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", nil, fmt.Errorf("failed to decode transcription: %w", err)
+	}
+	return result.Language, result.Segments, nil
+}
+
+func (s *Service) get(ctx context.Context, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", req.URL.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", req.URL.Host, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+}
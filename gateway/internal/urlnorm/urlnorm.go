@@ -0,0 +1,124 @@
+// Package urlnorm validates and canonicalizes URLs submitted for ingestion,
+// so the same page isn't ingested twice under different spellings
+package urlnorm
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+)
+
+// defaultPorts are dropped from canonical URLs
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// Problem is a URL that was rejected
+type Problem struct {
+	Index   int    // Position in the submitted list
+	Rule    string // url or scheme
+	Message string
+}
+
+// Result is a submitted list after normalization
+type Result struct {
+	URLs       []string // Canonical and deduplicated, in submission order
+	Duplicates int      // Submitted URLs dropped as duplicates
+	Problems   []Problem
+}
+
+// NormalizeAll canonicalizes urls, dropping duplicates and reporting the
+// ones that are invalid or use a scheme that isn't allowed
+func NormalizeAll(urls []string, cfg config.IngestionConfig) Result {
+	var result Result
+	seen := make(map[string]bool, len(urls))
+	for i, raw := range urls {
+		canonical, rule, err := Normalize(raw, cfg)
+		if err != nil {
+			result.Problems = append(result.Problems, Problem{Index: i, Rule: rule, Message: err.Error()})
+			continue
+		}
+		if seen[canonical] {
+			result.Duplicates++
+			continue
+		}
+		seen[canonical] = true
+		result.URLs = append(result.URLs, canonical)
+	}
+	return result
+}
+
+// Normalize returns the canonical form of raw: lower-case scheme and host,
+// no default port, fragment, tracking parameters or trailing slash, and
+// sorted query parameters. On failure it also returns the rule broken.
+func Normalize(raw string, cfg config.IngestionConfig) (string, string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" || u.Opaque != "" {
+		return "", "url", errors.New("must be an absolute URL")
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if !allowedScheme(u.Scheme, cfg.AllowedSchemes) {
+		return "", "scheme", fmt.Errorf("scheme must be one of %s", strings.Join(cfg.AllowedSchemes, ", "))
+	}
+	if u.User != nil {
+		return "", "url", errors.New("must not contain credentials")
+	}
+
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6 literal
+	}
+	u.Host = host
+	u.Fragment, u.RawFragment = "", ""
+
+	if len(u.Path) > 1 {
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = strings.TrimRight(u.RawPath, "/")
+	}
+	if u.Path == "/" {
+		u.Path, u.RawPath = "", ""
+	}
+
+	query := u.Query()
+	for key := range query {
+		if tracking(key, cfg.StripParams) {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+	return u.String(), "", nil
+}
+
+func allowedScheme(scheme string, allowed []string) bool {
+	for _, s := range allowed {
+		if strings.EqualFold(s, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// tracking reports whether a query parameter is one to strip; patterns
+// ending in * match by prefix, e.g. utm_*
+func tracking(key string, patterns []string) bool {
+	key = strings.ToLower(key)
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == p {
+			return true
+		}
+	}
+	return false
+}
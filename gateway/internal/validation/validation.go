@@ -0,0 +1,183 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError is one problem with a request field
+type FieldError struct {
+	Field   string `json:"field"`   // JSON path, e.g. answers[0].question_id; empty for the whole body
+	Rule    string `json:"rule"`    // Rule that failed, e.g. required, min, uuid
+	Message string `json:"message"` // Human-readable, without the field name
+}
+
+// UseJSONNames makes binding errors report fields by their JSON names
+// rather than Go struct field names
+func UseJSONNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+}
+
+// Translate converts an error from binding a request body into field
+// errors
+func Translate(err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrs):
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{
+				Field:   fieldPath(fe.Namespace()),
+				Rule:    fe.Tag(),
+				Message: message(fe),
+			})
+		}
+		return fields
+	case errors.As(err, &typeErr):
+		return []FieldError{{Field: typeErr.Field, Rule: "type", Message: "must be " + kindName(typeErr.Type.Kind().String())}}
+	case errors.As(err, &syntaxErr):
+		return []FieldError{{Rule: "json", Message: fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset)}}
+	case errors.Is(err, io.EOF):
+		return []FieldError{{Rule: "required", Message: "request body is required"}}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return []FieldError{{Rule: "json", Message: "request body is truncated"}}
+	}
+	return []FieldError{{Rule: "invalid", Message: err.Error()}}
+}
+
+// FromFastAPI converts the detail list of a FastAPI validation error
+// ({"loc": ["body", "goal"], "msg": ..., "type": ...}) into field errors
+func FromFastAPI(detail json.RawMessage) []FieldError {
+	var items []struct {
+		Loc  []any  `json:"loc"`
+		Msg  string `json:"msg"`
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(detail, &items); err != nil {
+		return nil
+	}
+
+	fields := make([]FieldError, 0, len(items))
+	for _, item := range items {
+		var path strings.Builder
+		for i, part := range item.Loc {
+			switch part := part.(type) {
+			case float64:
+				fmt.Fprintf(&path, "[%d]", int(part))
+			case string:
+				if i == 0 && (part == "body" || part == "query" || part == "path") {
+					continue
+				}
+				if path.Len() > 0 {
+					path.WriteByte('.')
+				}
+				path.WriteString(part)
+			}
+		}
+		fields = append(fields, FieldError{
+			Field:   path.String(),
+			Rule:    strings.TrimPrefix(item.Type, "value_error."),
+			Message: item.Msg,
+		})
+	}
+	return fields
+}
+
+// fieldPath drops the struct name from a validator namespace such as
+// PlanRequest.goal
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+func message(fe validator.FieldError) string {
+	param := fe.Param()
+	kind := fe.Kind()
+	countable := kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		if kind == reflect.String {
+			return "must be at least " + param + " characters"
+		}
+		if countable {
+			return "must have at least " + param + " items"
+		}
+		return "must be at least " + param
+	case "max", "lte":
+		if kind == reflect.String {
+			return "must be at most " + param + " characters"
+		}
+		if countable {
+			return "must have at most " + param + " items"
+		}
+		return "must be at most " + param
+	case "gt":
+		return "must be greater than " + param
+	case "lt":
+		return "must be less than " + param
+	case "len":
+		if countable {
+			return "must have exactly " + param + " items"
+		}
+		return "must be exactly " + param + " characters"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "email":
+		return "must be a valid email address"
+	case "hexcolor":
+		return "must be a hex color, e.g. #2680c2"
+	case "uuid", "uuid4":
+		return "must be a UUID"
+	case "timezone":
+		return "must be an IANA time zone, e.g. Europe/Berlin"
+	case "datetime":
+		if param == "15:04" {
+			return "must be a 24-hour time, e.g. 19:30"
+		}
+		return "must match the layout " + param
+	}
+	return "failed the " + fe.Tag() + " rule"
+}
+
+// kindName names a JSON value kind for a type error, e.g. "a string"
+func kindName(kind string) string {
+	switch kind {
+	case "string":
+		return "a string"
+	case "bool":
+		return "a boolean"
+	case "slice", "array":
+		return "an array"
+	case "map", "struct":
+		return "an object"
+	}
+	return "a number"
+}
@@ -0,0 +1,80 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+)
+
+// Group runs tasks on a bounded number of goroutines. The first task to fail
+// cancels the context shared by the others, and its error is returned from
+// Wait.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	slots  chan struct{}
+	wg     sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+}
+
+// New creates a group running at most limit tasks at once (1 if limit < 1).
+// Tasks receive the returned context, which is cancelled when a task fails,
+// ctx is cancelled, or Wait returns.
+func New(ctx context.Context, limit int) (*Group, context.Context) {
+	if limit < 1 {
+		limit = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{ctx: ctx, cancel: cancel, slots: make(chan struct{}, limit)}, ctx
+}
+
+// Go schedules fn, blocking while the pool is full. Once the group's context
+// is cancelled new tasks are skipped.
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	select {
+	case g.slots <- struct{}{}:
+	case <-g.ctx.Done():
+		g.fail(g.ctx.Err())
+		return
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer func() {
+			<-g.slots
+			g.wg.Done()
+		}()
+		if err := fn(g.ctx); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+// Wait blocks until every scheduled task has finished and returns the first
+// error.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
+
+func (g *Group) fail(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel()
+	})
+}
+
+// ForEach calls fn for every index in [0, n) with at most limit calls in
+// flight, stopping early on the first error.
+func ForEach(ctx context.Context, limit, n int, fn func(ctx context.Context, i int) error) error {
+	g, _ := New(ctx, limit)
+	for i := 0; i < n; i++ {
+		i := i
+		g.Go(func(ctx context.Context) error {
+			return fn(ctx, i)
+		})
+	}
+	return g.Wait()
+}
@@ -1,14 +1,55 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"time"
 
+	"github.com/amirhf/learnpath-gateway/internal/abuse"
+	"github.com/amirhf/learnpath-gateway/internal/accesslog"
+	"github.com/amirhf/learnpath-gateway/internal/admission"
+	"github.com/amirhf/learnpath-gateway/internal/calibration"
+	"github.com/amirhf/learnpath-gateway/internal/capture"
+	"github.com/amirhf/learnpath-gateway/internal/branding"
+	"github.com/amirhf/learnpath-gateway/internal/clients"
 	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/costs"
+	"github.com/amirhf/learnpath-gateway/internal/digest"
+	"github.com/amirhf/learnpath-gateway/internal/discovery"
+	"github.com/amirhf/learnpath-gateway/internal/documents"
+	"github.com/amirhf/learnpath-gateway/internal/drafts"
+	"github.com/amirhf/learnpath-gateway/internal/estimate"
+	"github.com/amirhf/learnpath-gateway/internal/errorreport"
+	"github.com/amirhf/learnpath-gateway/internal/events"
+	"github.com/amirhf/learnpath-gateway/internal/features"
+	"github.com/amirhf/learnpath-gateway/internal/focus"
+	"github.com/amirhf/learnpath-gateway/internal/grading"
+	"github.com/amirhf/learnpath-gateway/internal/guests"
 	"github.com/amirhf/learnpath-gateway/internal/handlers"
+	"github.com/amirhf/learnpath-gateway/internal/health"
+	"github.com/amirhf/learnpath-gateway/internal/hooks"
+	"github.com/amirhf/learnpath-gateway/internal/jobs"
 	"github.com/amirhf/learnpath-gateway/internal/middleware"
+	"github.com/amirhf/learnpath-gateway/internal/mockbackend"
+	"github.com/amirhf/learnpath-gateway/internal/moderation"
+	"github.com/amirhf/learnpath-gateway/internal/notify"
 	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
-	"github.com/gin-contrib/cors"
+	"github.com/amirhf/learnpath-gateway/internal/ownership"
+	"github.com/amirhf/learnpath-gateway/internal/preview"
+	"github.com/amirhf/learnpath-gateway/internal/quizsession"
+	"github.com/amirhf/learnpath-gateway/internal/ratelimit"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/retakes"
+	"github.com/amirhf/learnpath-gateway/internal/scheduler"
+	"github.com/amirhf/learnpath-gateway/internal/secrets"
+	"github.com/amirhf/learnpath-gateway/internal/seed"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+	"github.com/amirhf/learnpath-gateway/internal/transcripts"
+	"github.com/amirhf/learnpath-gateway/internal/validation"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
@@ -22,36 +63,253 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	// Credentials from Vault, AWS Secrets Manager or mounted files override
+	// the environment
+	secretStore, err := secrets.New(cfg.Secrets)
+	if err != nil {
+		log.Fatalf("Failed to initialize secrets: %v", err)
+	}
+	if err := secretStore.Load(context.Background()); err != nil {
+		log.Fatalf("Failed to load secrets: %v", err)
+	}
+	secretStore.Fill(cfg)
+	secretStore.OnRotate(func(name string) {
+		if name != "ADMIN_TOKEN" {
+			log.Printf("Secret %s rotated; restart to apply", name)
+		}
+	})
+	go secretStore.Run(context.Background())
+
 	// Set Gin mode
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Client certificates for mutual TLS with the backends, reloaded so
+	// they can be rotated without a restart
+	var credentials *clients.Credentials
+	if cfg.UpstreamTLS.Enabled() {
+		credentials, err = clients.NewCredentials(mtlsOptions(cfg))
+		if err != nil {
+			log.Fatalf("Failed to load upstream TLS certificates: %v", err)
+		}
+		go credentials.Run(context.Background())
+	}
+
+	// Shared connection pool for every upstream call
+	transportOpts := transportOptions(cfg.Transport)
+	transportOpts.Credentials = credentials
+	var transport http.RoundTripper = clients.NewTransport(transportOpts)
+	if cfg.FeatureEnabled("mock_backends") {
+		log.Println("Mock backends enabled: serving RAG, Planner and Quiz responses in-process")
+		transport = mockbackend.NewTransport()
+	}
+
+	// LLM and embedding usage reported by the backends, charged per tenant
+	transport = costs.Transport(transport)
+
+	// Failed upstream calls, kept for inspection and replay
+	var recorder *capture.Recorder
+	if cfg.Capture.Enabled {
+		recorder = capture.NewRecorder(transport, cfg.Capture.MaxRequests, cfg.Capture.MaxBodyBytes)
+		transport = recorder
+	}
+
+	// The caller's request ID, Idempotency-Key, language and identity on
+	// every upstream call, including those handlers proxy themselves
+	transport = clients.Propagate(transport, clients.NewIdentity(cfg.InternalToken.Secret, cfg.InternalToken.TTL))
+
+	// Feature flags, kill switches and maintenance mode
+	switches := features.NewSwitches(cfg)
+
+	// Shared gateway state (memory, or Redis across replicas)
+	store, err := storage.New(storage.Options{
+		Backend:   cfg.Storage.Backend,
+		RedisURL:  cfg.Storage.RedisURL,
+		KeyPrefix: cfg.Storage.KeyPrefix,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	defer store.Close()
+	if cfg.Ownership.Enabled && cfg.Storage.Backend != storage.Redis {
+		log.Println("Plan and quiz owners are kept in memory: other replicas and restarts won't find them, so those resources answer 404; set STORAGE_BACKEND=redis when running several replicas")
+	}
+
+	// Gateway-owned learner data (Postgres, or memory when unconfigured)
+	repos := repository.NewMemory()
+	if cfg.Database.URL != "" {
+		repos, err = repository.NewPostgres(context.Background(), repository.PostgresOptions{
+			Driver:       cfg.Database.Driver,
+			URL:          cfg.Database.URL,
+			MaxOpenConns: cfg.Database.MaxOpenConns,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+	}
+	defer repos.Close()
+
+	// Originals of uploaded documents (local disk or S3)
+	uploads, err := documents.NewStore(cfg.Uploads)
+	if err != nil {
+		log.Fatalf("Failed to initialize upload storage: %v", err)
+	}
+
+	// Domain events, relayed from the outbox to NATS or Kafka
+	publisher, err := events.NewPublisher(events.Options{
+		Backend: cfg.Events.Backend,
+		URL:     cfg.Events.URL,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize event bus: %v", err)
+	}
+	bus := events.NewBus(repos.Outbox, publisher, cfg.Events.SubjectPrefix)
+	defer bus.Close()
+	go bus.Run(context.Background(), cfg.Events.PollInterval, cfg.Events.BatchSize)
+
+	// Per-tenant spend and monthly budgets
+	tracker := costs.New(cfg.Costs, store)
+
+	// Per-tenant correct rates of quiz questions
+	quizStats := calibration.New(cfg.Calibration, store)
+
+	// Priority queueing and load shedding for API requests
+	admit := admission.New(admissionOptions(cfg.Admission))
+
+	// Bans for callers who keep sending failing requests
+	detector := abuse.New(store, abuseOptions(cfg.Abuse))
+
+	// Throttling of anonymous callers by client IP
+	ipLimiter, err := ratelimit.New(store, ipRateLimitOptions(cfg.IPRateLimit))
+	if err != nil {
+		log.Fatalf("Failed to set up IP rate limiting: %v", err)
+	}
+
+	// Throttling of signed-in users and guests, per minute and in bursts
+	userLimiter, err := ratelimit.New(store, userRateLimitOptions(cfg.RateLimit))
+	if err != nil {
+		log.Fatalf("Failed to set up user rate limiting: %v", err)
+	}
+	burstLimiter, err := ratelimit.New(store, burstRateLimitOptions(cfg.RateLimit))
+	if err != nil {
+		log.Fatalf("Failed to set up user rate limiting: %v", err)
+	}
+
 	// Initialize Orchestrator
-	// Note: config.Config needs to be checked if it has these exact field names.
-	// Assuming config has RAGServiceURL, PlannerServiceURL, QuizServiceURL based on previous file reads.
-	orch := orchestrator.NewOrchestrator(cfg.RAGServiceURL, cfg.PlannerServiceURL, cfg.QuizServiceURL)
+	videos := transcripts.New(cfg.Transcripts, store)
+	previews := preview.New(cfg.Previews, store)
+	durations := estimate.New(cfg.Durations, previews)
+	orch := orchestrator.NewOrchestrator(cfg, transport, switches, videos, durations)
+
+	// Watch the config file so timeouts/retries can be tuned without a redeploy
+	watcher := config.NewWatcher(cfg, 10*time.Second)
+	watcher.OnReload(secretStore.Fill)
+	watcher.OnReload(orch.ApplyConfig)
+	watcher.OnReload(switches.ApplyConfig)
+	watcher.OnReload(func(cfg *config.Config) { admit.Configure(admissionOptions(cfg.Admission)) })
+	watcher.OnReload(func(cfg *config.Config) { detector.Configure(abuseOptions(cfg.Abuse)) })
+	watcher.OnReload(func(cfg *config.Config) {
+		if err := ipLimiter.Configure(ipRateLimitOptions(cfg.IPRateLimit)); err != nil {
+			log.Printf("Keeping IP rate limits: %v", err)
+		}
+		if err := userLimiter.Configure(userRateLimitOptions(cfg.RateLimit)); err != nil {
+			log.Printf("Keeping user rate limits: %v", err)
+		}
+		if err := burstLimiter.Configure(burstRateLimitOptions(cfg.RateLimit)); err != nil {
+			log.Printf("Keeping user rate limits: %v", err)
+		}
+	})
+	go watcher.Run(context.Background())
+
+	// Asynchronous grading of short-answer quiz questions
+	grader := grading.New(cfg.Grading, repos.Quizzes, orch, bus)
+	go grader.Run(context.Background())
+
+	// Resolve backend replicas dynamically when service discovery is enabled
+	startDiscovery(cfg, orch)
+
+	// Tenants' logos, colors and sender names for exports and notifications
+	brands := branding.New(cfg.Branding, store)
+
+	// Quiz results and milestone reminders posted to Teams and Slack
+	notifier, err := notify.New(cfg.Notifications, transport, store, brands)
+	if err != nil {
+		log.Fatalf("Failed to set up notifications: %v", err)
+	}
+
+	// REST hook subscriptions to learning events, for Zapier and Make
+	subscribers := hooks.New(cfg.Hooks, store)
+
+	// Recurring background jobs
+	digests := digest.NewBuilder(repos, orch)
+	sched := newScheduler(cfg, store, orch, transport, repos, digests, bus, notifier, brands)
+	watcher.OnReload(func(cfg *config.Config) {
+		for name, job := range cfg.Scheduler.Jobs {
+			sched.SetEnabled(name, job.Enabled)
+		}
+	})
+	if cfg.Scheduler.Enabled {
+		go sched.Run(context.Background())
+	}
+
+	// Who owns each plan and quiz
+	owners := ownership.New(cfg.Ownership, store, repos.ShareTokens)
+
+	// Demo data for new deployments
+	seeder := seed.New(cfg.Seed, orch, store, owners)
+	if cfg.Seed.OnStartup {
+		go seeder.RunOnStartup(context.Background())
+	}
+
+	// Signed guest tokens for visitors trying the product before signing up
+	guestSessions := guests.New(cfg.Guests, store)
+
+	accessLog, err := accesslog.New(cfg.AccessLog)
+	if err != nil {
+		log.Fatalf("Failed to set up access log: %v", err)
+	}
+	go accessLog.Run(context.Background())
+
+	reporter, err := errorreport.New(cfg.ErrorReporting, cfg.Environment)
+	if err != nil {
+		log.Fatalf("Failed to set up error reporting: %v", err)
+	}
 
 	// Create router
 	r := gin.Default()
+	validation.UseJSONNames()
+
+	// Client IPs come from forwarding headers only when set by a trusted
+	// load balancer
+	r.RemoteIPHeaders = cfg.Server.RemoteIPHeaders
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
 
 	// CORS configuration
-	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowAllOrigins = true
-	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Accept", "X-Request-ID"}
-	corsConfig.ExposeHeaders = []string{"Content-Length"}
-	corsConfig.AllowCredentials = false // Must be false when AllowAllOrigins is true
-	r.Use(cors.New(corsConfig))
+	r.Use(middleware.CORS(cfg))
 
 	// Middleware
 	r.Use(middleware.RequestID())
-	r.Use(middleware.Logger())
-	r.Use(middleware.Recovery())
-	r.Use(middleware.Auth(cfg))
+	r.Use(middleware.MaxBody(cfg.BodyLimits.Largest()))
+	r.Use(middleware.Logger(accessLog))
+	r.Use(middleware.SlowRequests(cfg.SlowRequests))
+	r.Use(middleware.Timings(cfg.Timing.Header, func() string { return secretStore.Value("ADMIN_TOKEN", cfg.AdminToken) }))
+	r.Use(middleware.Recovery(reporter))
+	r.Use(middleware.AbuseWatch(detector))
+	r.Use(middleware.Auth(cfg, guestSessions))
+	r.Use(middleware.IdempotencyKey(store, cfg.Storage.IdempotencyTTL))
+	r.Use(middleware.TenantOverride(func() string { return secretStore.Value("ADMIN_TOKEN", cfg.AdminToken) }))
+	r.Use(middleware.IPRateLimit(ipLimiter))
+	r.Use(middleware.UserRateLimit(userLimiter, burstLimiter))
+	r.Use(middleware.Language(cfg))
+	r.Use(middleware.Maintenance(switches))
+	r.Use(middleware.Mirror(cfg, transport))
 
 	// Root endpoint - API info
-	r.GET("/", func(c *gin.Context) {
+	started := time.Now()
+	r.GET("/", middleware.CacheStatic(cfg.Caching.InfoTTL, started), func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"service": "Learning Path Designer Gateway",
 			"version": "1.0.0",
@@ -60,11 +318,15 @@ func main() {
 			"endpoints": gin.H{
 				"info":         "GET /",
 				"health":       "GET /health",
-				"search":       "POST /api/search",
-				"plan":         "POST /api/plan",
-				"replan":       "POST /api/plan/:id/replan",
-				"quiz_generate": "POST /api/quiz/generate",
-				"quiz_submit":   "POST /api/quiz/submit",
+				"search":       "POST /api/v1/search",
+				"plan":         "POST /api/v1/plan",
+				"replan":       "POST /api/v1/plan/:id/replan",
+				"quiz_generate": "POST /api/v1/quiz/generate",
+				"quiz_submit":   "POST /api/v1/quiz/submit",
+			},
+			"api_versions": gin.H{
+				"v1": "/api/v1 (also served at /api)",
+				"v2": "/api/v2 (error envelope, answer-free quizzes)",
 			},
 			"services": gin.H{
 				"rag":     cfg.RAGServiceURL + " (port 8001)",
@@ -79,29 +341,71 @@ func main() {
 		})
 	})
 
-	// Health check
+	// Health check; readiness is answered from background checks of the
+	// backends, which are also recorded for the public status page
+	backends := health.New(cfg.Health, cfg, transport, health.NewHistory(store))
+	go backends.Run(context.Background())
 	r.GET("/health", handlers.HealthCheck(cfg))
+	r.GET("/health/ready", handlers.ReadinessCheck(backends))
+	r.GET("/health/dependencies", handlers.DependencyHealth(backends))
+	r.GET("/status", handlers.Status(backends, orch))
 
-	// API routes
-	api := r.Group("/api")
-	{
-		// RAG Service
-		api.POST("/search", handlers.Search(cfg))
-		
-		// Planner Service
-		// Passing orchestrator to CreatePlan. Other handlers might just use config for now or need updating.
-		api.POST("/plan", handlers.CreatePlan(cfg, orch))
-		api.GET("/plan/:id", handlers.GetPlan(cfg))
-		api.GET("/plan/user/:user_id/plans", handlers.GetUserPlans(cfg))
-		api.POST("/plan/:id/replan", handlers.Replan(cfg))
-		
-		// Quiz Service
-		api.POST("/quiz/generate", handlers.GenerateQuiz(cfg, orch))
-		api.POST("/quiz/submit", handlers.SubmitQuiz(cfg))
-
-		// Content Ingestion (BYO Content)
-		api.POST("/content/ingest", handlers.IngestContent(cfg, orch))
-	}
+	// Per-variant upstream metrics for canary rollouts
+	r.GET("/metrics/variants", handlers.VariantMetrics())
+
+	// Admission queue depth and load shedding
+	r.GET("/metrics/admission", handlers.AdmissionMetrics(admit))
+
+	// Requests abandoned by the client mid-flight
+	r.GET("/metrics/cancellations", handlers.CancellationMetrics())
+
+	// API routes (/api/v1, /api/v2 and the legacy /api alias)
+	registerAPIRoutes(r, apiDeps{
+		cfg:       cfg,
+		orch:      orch,
+		switches:  switches,
+		admit:     admit,
+		repos:     repos,
+		bus:       bus,
+		digests:   digests,
+		moderator: moderation.New(cfg.Moderation),
+		uploads:   uploads,
+		videos:    videos,
+		previews:  previews,
+		costs:     tracker,
+		quizStats: quizStats,
+		grader:    grader,
+		guard:     quizsession.New(cfg.QuizIntegrity, store, repos.Anomalies),
+		retakes:   retakes.New(cfg.Retakes, store, repos.Quizzes),
+		drafts:    drafts.New(cfg.Drafts, store),
+		owners:    owners,
+		guests:    guestSessions,
+		versions:  health.NewVersions(cfg.Health, cfg, transport),
+		router:    r,
+		abuse:     detector,
+		store:     store,
+		notifier:  notifier,
+		hooks:     subscribers,
+		brands:    brands,
+		focus:     focus.New(cfg.Focus, repos),
+		started:   started,
+	})
+
+	// Operator endpoints (kill switches, maintenance mode)
+	registerAdminRoutes(r, adminDeps{
+		cfg:       cfg,
+		switches:  switches,
+		sched:     sched,
+		seeder:    seeder,
+		recorder:  recorder,
+		orch:      orch,
+		durations: durations,
+		costs:     tracker,
+		repos:     repos,
+		secrets:   secretStore,
+		abuse:     detector,
+		brands:    brands,
+	})
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -109,8 +413,148 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Starting gateway on port %s", port)
-	if err := r.Run(":" + port); err != nil {
+	if err := serve(r, cfg, ":"+port); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// transportOptions converts the transport config into client options
+func transportOptions(cfg config.TransportConfig) clients.TransportOptions {
+	minVersion := uint16(tls.VersionTLS12)
+	if cfg.TLSMinVersion == "1.3" {
+		minVersion = tls.VersionTLS13
+	}
+
+	return clients.TransportOptions{
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		DisableKeepAlives:     cfg.DisableKeepAlives,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		TLSInsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+		TLSMinVersion:         minVersion,
+		HTTP2:                 cfg.HTTP2,
+		H2C:                   cfg.H2C,
+	}
+}
+
+// mtlsOptions converts the upstream TLS config into credential options,
+// pinning the hosts of each backend's replicas to that backend's CA
+func mtlsOptions(cfg *config.Config) clients.MTLSOptions {
+	replicas := map[string][]string{
+		"rag":     cfg.RAGServiceURLs,
+		"planner": cfg.PlannerServiceURLs,
+		"quiz":    cfg.QuizServiceURLs,
+	}
+	hostCAs := map[string]string{}
+	for service, caFile := range cfg.UpstreamTLS.BackendCAFiles {
+		for _, replica := range replicas[service] {
+			if u, err := url.Parse(replica); err == nil && u.Hostname() != "" {
+				hostCAs[u.Hostname()] = caFile
+			}
+		}
+	}
+
+	return clients.MTLSOptions{
+		CertFile:       cfg.UpstreamTLS.CertFile,
+		KeyFile:        cfg.UpstreamTLS.KeyFile,
+		CAFile:         cfg.UpstreamTLS.CAFile,
+		HostCAFiles:    hostCAs,
+		ReloadInterval: cfg.UpstreamTLS.ReloadInterval,
+	}
+}
+
+// admissionOptions converts the admission config into controller options
+func admissionOptions(cfg config.AdmissionConfig) admission.Options {
+	return admission.Options{
+		MaxInFlight:       cfg.MaxInFlight,
+		MaxQueue:          cfg.MaxQueue,
+		QueueTimeout:      cfg.QueueTimeout,
+		SaturationCooloff: cfg.SaturationCooloff,
+		RetryAfter:        cfg.RetryAfter,
+	}
+}
+
+// abuseOptions converts the abuse config into detector options
+func abuseOptions(cfg config.AbuseConfig) abuse.Options {
+	return abuse.Options{
+		Threshold:  cfg.Threshold,
+		Window:     cfg.Window,
+		BaseBan:    cfg.Ban,
+		MaxBan:     cfg.MaxBan,
+		OffenseTTL: cfg.OffenseTTL,
+	}
+}
+
+// ipRateLimitOptions converts the IP rate limit config into limiter options
+func ipRateLimitOptions(cfg config.IPRateLimitConfig) ratelimit.Options {
+	return ratelimit.Options{
+		Limit:     cfg.RequestsPerMinute,
+		Window:    time.Minute,
+		Allowlist: cfg.Allowlist,
+	}
+}
+
+// userRateLimitOptions converts the user rate limit config into limiter
+// options
+func userRateLimitOptions(cfg config.RateLimitConfig) ratelimit.Options {
+	return ratelimit.Options{
+		Limit:  cfg.RequestsPerMinute,
+		Window: time.Minute,
+	}
+}
+
+// burstRateLimitOptions caps users' requests in any one second
+func burstRateLimitOptions(cfg config.RateLimitConfig) ratelimit.Options {
+	return ratelimit.Options{
+		Limit:  cfg.Burst,
+		Window: time.Second,
+	}
+}
+
+// newScheduler registers the recurring jobs. Runs are claimed through the
+// shared store so each fires on one replica.
+func newScheduler(cfg *config.Config, store storage.KeyValue, orch orchestrator.Orchestrator, transport http.RoundTripper, repos *repository.Repositories, digests *digest.Builder, bus *events.Bus, notifier *notify.Dispatcher, brands *branding.Store) *scheduler.Scheduler {
+	sched := scheduler.New(cfg.Scheduler.MaxJitter, store)
+	funcs := map[string]scheduler.Func{
+		config.JobHealthPoll:         jobs.HealthPoll(cfg, transport),
+		config.JobFeedReingest:       jobs.FeedReingest(orch, cfg.Scheduler.Feeds),
+		config.JobDeadLinkCheck:      jobs.DeadLinkCheck(orch, transport, cfg.Scheduler.WarmupQueries),
+		config.JobCacheWarmup:        jobs.CacheWarmup(orch, cfg.Scheduler.WarmupQueries),
+		config.JobWeeklyDigest:       digest.Job(digests, bus, notifier, brands),
+		config.JobMilestoneReminders: notify.ReminderJob(notifier, repos, digests),
+	}
+	for name, fn := range funcs {
+		job := cfg.Scheduler.Jobs[name]
+		if err := sched.Add(name, job.Schedule, job.Enabled, fn); err != nil {
+			log.Fatalf("Failed to schedule jobs: %v", err)
+		}
+	}
+	return sched
+}
+
+// startDiscovery keeps the orchestrator's backend replicas in sync with the
+// configured service discovery backend
+func startDiscovery(cfg *config.Config, orch orchestrator.Orchestrator) {
+	resolver, err := discovery.NewResolver(discovery.Options{
+		Mode:          cfg.Discovery.Mode,
+		Scheme:        cfg.Discovery.Scheme,
+		ConsulAddr:    cfg.Discovery.ConsulAddr,
+		KubeNamespace: cfg.Discovery.KubeNamespace,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize service discovery: %v", err)
+	}
+	if resolver == nil {
+		return
+	}
+
+	targets := []discovery.Target{
+		{Name: cfg.Discovery.RAGService, Update: func(urls []string) { orch.SetEndpoints("rag", urls) }},
+		{Name: cfg.Discovery.PlannerService, Update: func(urls []string) { orch.SetEndpoints("planner", urls) }},
+		{Name: cfg.Discovery.QuizService, Update: func(urls []string) { orch.SetEndpoints("quiz", urls) }},
+	}
+	go discovery.Run(context.Background(), resolver, cfg.Discovery.RefreshInterval, targets)
+	log.Printf("Service discovery enabled (%s)", cfg.Discovery.Mode)
+}
@@ -0,0 +1,745 @@
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SearchRequest is a resource search
+type SearchRequest struct {
+	Query      string         `json:"query"`
+	TopK       int            `json:"top_k,omitempty"`
+	Rerank     bool           `json:"rerank,omitempty"`
+	RerankTopN int            `json:"rerank_top_n,omitempty"`
+	Filters    *SearchFilters `json:"filters,omitempty"`
+	Diversity  *Diversity     `json:"diversity,omitempty"`
+}
+
+// Diversity caps how many results may share a provider or media type
+type Diversity struct {
+	MaxPerProvider  int `json:"max_per_provider,omitempty"`
+	MaxPerMediaType int `json:"max_per_media_type,omitempty"`
+}
+
+// SearchFilters narrows a search
+type SearchFilters struct {
+	Level          *int     `json:"level,omitempty"`
+	MaxDurationMin *int     `json:"max_duration_min,omitempty"`
+	Skills         []string `json:"skills,omitempty"`
+	MediaType      *string  `json:"media_type,omitempty"`
+	Provider       *string  `json:"provider,omitempty"`
+}
+
+// SearchResponse lists matching resources, best first
+type SearchResponse struct {
+	Results    []Resource `json:"results"`
+	Query      string     `json:"query"`
+	TotalFound int        `json:"total_found"`
+	Reranked   bool       `json:"reranked"`
+}
+
+// Resource is a search result
+type Resource struct {
+	ResourceID  string   `json:"resource_id"`
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	Provider    *string  `json:"provider,omitempty"`
+	License     *string  `json:"license,omitempty"`
+	DurationMin *int     `json:"duration_min,omitempty"`
+	Level       *int     `json:"level,omitempty"`
+	Skills      []string `json:"skills"`
+	MediaType   *string  `json:"media_type,omitempty"`
+	Score       float64  `json:"score"`
+	WhyRelevant *string  `json:"why_relevant,omitempty"`
+}
+
+// PlanRequest asks for a new learning plan, optionally with quizzes
+type PlanRequest struct {
+	Goal             string       `json:"goal"`
+	CurrentSkills    []string     `json:"current_skills,omitempty"`
+	TimeBudgetHours  int          `json:"time_budget_hours"`
+	HoursPerWeek     int          `json:"hours_per_week"`
+	Preferences      *Preferences `json:"preferences,omitempty"`
+	UserID           string       `json:"user_id,omitempty"`
+	GenerateQuiz     bool         `json:"generate_quiz,omitempty"`
+	QuizPerMilestone bool         `json:"quiz_per_milestone,omitempty"`
+	NumQuestions     int          `json:"num_questions,omitempty"`
+	QuizDifficulty   string       `json:"quiz_difficulty,omitempty"`
+	Language         string       `json:"language,omitempty"`  // See Languages
+	SubGoals         []string     `json:"sub_goals,omitempty"` // Picked from DecomposeGoal
+}
+
+// Preferences shape a learning plan
+type Preferences struct {
+	MediaTypes          []string   `json:"media_types,omitempty"`
+	Providers           []string   `json:"providers,omitempty"`
+	MaxResourceDuration int        `json:"max_resource_duration,omitempty"` // Minutes per resource
+	Language            string     `json:"language,omitempty"`
+	Budget              *float64   `json:"budget,omitempty"` // USD
+	Licenses            []string   `json:"licenses,omitempty"`
+	FreeOnly            bool       `json:"free_only,omitempty"`
+	Diversity           *Diversity `json:"diversity,omitempty"`
+	Timezone            string     `json:"timezone,omitempty"`    // IANA name
+	StudyDays           []string   `json:"study_days,omitempty"`  // mon ... sun
+	StudyTimes          []string   `json:"study_times,omitempty"` // HH:MM local
+}
+
+// PlanResult is a created plan and any quizzes generated with it
+type PlanResult struct {
+	LearningPath     Plan            `json:"learning_path"`
+	Quiz             *Quiz           `json:"quiz,omitempty"`
+	MilestoneQuizzes []MilestoneQuiz `json:"milestone_quizzes,omitempty"`
+}
+
+// PlanEstimate previews generating a plan
+type PlanEstimate struct {
+	ResourcesConsidered int     `json:"resources_considered"`
+	ResourcesSelected   int     `json:"resources_selected"`
+	ContentHours        float64 `json:"content_hours"`
+	Milestones          int     `json:"milestones"`
+	EstimatedWeeks      int     `json:"estimated_weeks"`
+	QuizCalls           int     `json:"quiz_calls"`
+	ExpectedSeconds     float64 `json:"expected_generation_seconds"`
+	EstimatedCostUSD    float64 `json:"estimated_cost_usd"`
+}
+
+// DecomposeGoalRequest asks for the sub-goals of a broad goal
+type DecomposeGoalRequest struct {
+	Goal          string   `json:"goal"`
+	CurrentSkills []string `json:"current_skills,omitempty"`
+	MaxSubGoals   int      `json:"max_sub_goals,omitempty"`
+	Language      string   `json:"language,omitempty"`
+}
+
+// SubGoal is one suggested part of a broad goal
+type SubGoal struct {
+	Title          string   `json:"title"`
+	Description    string   `json:"description"`
+	EstimatedHours float64  `json:"estimated_hours"`
+	Skills         []string `json:"skills,omitempty"`
+	Order          int      `json:"order"`
+}
+
+// GoalDecomposition is a goal broken into sub-goals, in learning order
+type GoalDecomposition struct {
+	Goal                string    `json:"goal"`
+	SubGoals            []SubGoal `json:"sub_goals"`
+	TotalEstimatedHours float64   `json:"total_estimated_hours"`
+}
+
+// PlanDraft is a plan request built up step by step before it is committed
+type PlanDraft struct {
+	DraftID          string        `json:"draft_id"`
+	Goal             string        `json:"goal"`
+	CurrentSkills    []string      `json:"current_skills,omitempty"`
+	TimeBudgetHours  int           `json:"time_budget_hours,omitempty"`
+	HoursPerWeek     int           `json:"hours_per_week,omitempty"`
+	Preferences      Preferences   `json:"preferences"`
+	Language         string        `json:"language,omitempty"`
+	GenerateQuiz     bool          `json:"generate_quiz"`
+	QuizPerMilestone bool          `json:"quiz_per_milestone"`
+	NumQuestions     int           `json:"num_questions,omitempty"`
+	QuizDifficulty   string        `json:"quiz_difficulty,omitempty"`
+	Step             int           `json:"step"`
+	Preview          *PlanEstimate `json:"preview,omitempty"`
+	ExpiresAt        time.Time     `json:"expires_at"`
+}
+
+// PlanDraftUpdate refines a draft; nil fields are left unchanged
+type PlanDraftUpdate struct {
+	Goal             *string      `json:"goal,omitempty"`
+	CurrentSkills    []string     `json:"current_skills,omitempty"`
+	TimeBudgetHours  *int         `json:"time_budget_hours,omitempty"`
+	HoursPerWeek     *int         `json:"hours_per_week,omitempty"`
+	Preferences      *Preferences `json:"preferences,omitempty"`
+	Language         *string      `json:"language,omitempty"`
+	GenerateQuiz     *bool        `json:"generate_quiz,omitempty"`
+	QuizPerMilestone *bool        `json:"quiz_per_milestone,omitempty"`
+	NumQuestions     *int         `json:"num_questions,omitempty"`
+	QuizDifficulty   *string      `json:"quiz_difficulty,omitempty"`
+	Preview          bool         `json:"preview,omitempty"` // Estimate the refined draft
+}
+
+// MilestoneQuiz is the quiz for one milestone
+type MilestoneQuiz struct {
+	MilestoneID string `json:"milestone_id"`
+	Quiz        *Quiz  `json:"quiz"`
+}
+
+// Plan is a learning plan
+type Plan struct {
+	PlanID           string      `json:"plan_id"`
+	Goal             string      `json:"goal"`
+	TotalHours       float64     `json:"total_hours"`
+	EstimatedWeeks   int         `json:"estimated_weeks"`
+	Milestones       []Milestone `json:"milestones"`
+	PrerequisitesMet bool        `json:"prerequisites_met"`
+	Reasoning        string      `json:"reasoning"`
+	Status           string      `json:"status,omitempty"` // active, or draft when kept after its quizzes failed
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        time.Time   `json:"updated_at"`
+}
+
+// Milestone is a stage of a plan
+type Milestone struct {
+	MilestoneID    string         `json:"milestone_id"`
+	Title          string         `json:"title"`
+	Description    string         `json:"description"`
+	Resources      []PlanResource `json:"resources"`
+	EstimatedHours float64        `json:"estimated_hours"`
+	SkillsGained   []string       `json:"skills_gained"`
+	Order          int            `json:"order"`
+}
+
+// PlanResource is a resource scheduled in a milestone
+type PlanResource struct {
+	ResourceID  string   `json:"resource_id"`
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	DurationMin int      `json:"duration_min"`
+	Level       *int     `json:"level,omitempty"`
+	Skills      []string `json:"skills"`
+	WhyIncluded string   `json:"why_included"`
+	Order       int      `json:"order"`
+	License     *string  `json:"license,omitempty"`
+	Cost        string   `json:"cost,omitempty"` // free or paid
+}
+
+// UserPlans lists a user's plans
+type UserPlans struct {
+	UserID string        `json:"user_id"`
+	Plans  []PlanSummary `json:"plans"`
+	Total  int           `json:"total"`
+}
+
+// PlanSummary is a plan without its milestones
+type PlanSummary struct {
+	PlanID         string    `json:"plan_id"`
+	UserID         string    `json:"user_id"`
+	Goal           string    `json:"goal"`
+	TotalHours     float64   `json:"total_hours"`
+	EstimatedWeeks int       `json:"estimated_weeks"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ReplanRequest reports progress so the remaining plan can be adjusted
+type ReplanRequest struct {
+	CompletedResources []string `json:"completed_resources"`
+	TimeSpentHours     float64  `json:"time_spent_hours,omitempty"`
+	RemainingTimeHours *float64 `json:"remaining_time_hours,omitempty"`
+	Feedback           string   `json:"feedback,omitempty"`
+}
+
+// Remediation is a plan replanned to review what a failed quiz attempt
+// missed
+type Remediation struct {
+	AttemptID       string   `json:"attempt_id"`
+	Score           float64  `json:"score"`
+	Threshold       float64  `json:"threshold"`
+	Plan            *Plan    `json:"plan"`
+	ReviewResources []string `json:"review_resources"`
+	WeakSkills      []string `json:"weak_skills"`
+}
+
+// QuizRequest asks for a quiz over resources
+type QuizRequest struct {
+	ResourceIDs  []string `json:"resource_ids"`
+	NumQuestions int      `json:"num_questions,omitempty"`
+	Difficulty   string   `json:"difficulty,omitempty"`
+	Language     string   `json:"language,omitempty"` // See Languages
+	ShortAnswers int      `json:"short_answer_questions,omitempty"`
+}
+
+// QuizBatchItem is one quiz of a batch, named by a key unique within it
+type QuizBatchItem struct {
+	Key string `json:"key"`
+	QuizRequest
+}
+
+// QuizBatch holds the quizzes of a batch and the errors of those that
+// failed, by item key
+type QuizBatch struct {
+	Quizzes map[string]Quiz           `json:"quizzes"`
+	Errors  map[string]QuizBatchError `json:"errors"`
+}
+
+// QuizBatchError is why one quiz of a batch failed
+type QuizBatchError struct {
+	Code    string `json:"error"`
+	Message string `json:"message"`
+}
+
+// Quiz is a generated quiz; answers are never included
+type Quiz struct {
+	QuizID         string     `json:"quiz_id"`
+	Title          *string    `json:"title,omitempty"`
+	Questions      []Question `json:"questions"`
+	TotalQuestions int        `json:"total_questions"`
+	VariantOf      string     `json:"variant_of,omitempty"` // Original quiz of a retake
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Question is a multiple-choice or short-answer quiz question
+type Question struct {
+	QuestionID       string   `json:"question_id"`
+	QuestionType     string   `json:"question_type"` // multiple_choice or short_answer
+	QuestionText     string   `json:"question_text"`
+	Options          []Option `json:"options"` // Empty for short-answer questions
+	SourceResourceID string   `json:"source_resource_id"`
+	Citation         string   `json:"citation"`
+}
+
+// Option is an answer choice
+type Option struct {
+	OptionID string `json:"option_id"`
+	Text     string `json:"text"`
+}
+
+// BankQuestion is a previously generated question in the question bank
+type BankQuestion struct {
+	QuizID string `json:"quiz_id"`
+	Question
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// QuestionFilter narrows a question bank listing
+type QuestionFilter struct {
+	ResourceID string
+	Skill      string // Slug or ID
+	Limit      int    // Default 50, at most 200
+}
+
+// QuestionRef names a question of the bank
+type QuestionRef struct {
+	QuizID     string `json:"quiz_id"`
+	QuestionID string `json:"question_id"`
+}
+
+// QuizSubmission answers a quiz
+type QuizSubmission struct {
+	QuizID  string   `json:"quiz_id"`
+	Answers []Answer `json:"answers"`
+}
+
+// Answer selects an option for a question, or answers a short-answer
+// question in AnswerText
+type Answer struct {
+	QuestionID       string `json:"question_id"`
+	SelectedOptionID string `json:"selected_option_id"`
+	AnswerText       string `json:"answer_text,omitempty"`
+}
+
+// QuizResult is a graded submission
+type QuizResult struct {
+	QuizID         string           `json:"quiz_id"`
+	Score          float64          `json:"score"`
+	TotalQuestions int              `json:"total_questions"`
+	CorrectAnswers int              `json:"correct_answers"`
+	Results        []QuestionResult `json:"results"`
+	PendingAnswers int              `json:"pending_questions"`    // Short answers graded later; see GetQuizAttempt
+	AttemptID      string           `json:"attempt_id,omitempty"` // Signed-in learners only
+}
+
+// QuestionResult grades one answer
+type QuestionResult struct {
+	QuestionID       string `json:"question_id"`
+	Correct          bool   `json:"correct"`
+	SelectedOptionID string `json:"selected_option_id"`
+	CorrectOptionID  string `json:"correct_option_id"`
+	Explanation      string `json:"explanation"`
+	Citation         string `json:"citation"`
+	Status           string `json:"status"` // pending until a short answer is graded
+}
+
+// QuizAttempt is one of the caller's quiz submissions. Attempts with short
+// answers move from pending to graded once the answers are graded.
+type QuizAttempt struct {
+	AttemptID   string            `json:"attempt_id"`
+	QuizID      string            `json:"quiz_id"`
+	Status      string            `json:"status"` // pending or graded
+	SubmittedAt time.Time         `json:"submitted_at"`
+	GradedAt    *time.Time        `json:"graded_at,omitempty"`
+	Score       *float64          `json:"score,omitempty"`
+	Results     []QuestionOutcome `json:"results,omitempty"`
+}
+
+// QuestionOutcome is how one question of an attempt was answered
+type QuestionOutcome struct {
+	QuestionID string `json:"question_id"`
+	ResourceID string `json:"resource_id,omitempty"`
+	Correct    bool   `json:"correct"`
+	Pending    bool   `json:"pending,omitempty"`
+	AnswerText string `json:"answer_text,omitempty"`
+	Feedback   string `json:"feedback,omitempty"`
+}
+
+// Progress is the caller's progress on one milestone
+type Progress struct {
+	UserID      string    `json:"user_id"`
+	PlanID      string    `json:"plan_id"`
+	MilestoneID string    `json:"milestone_id"`
+	Completed   bool      `json:"completed"`
+	HoursSpent  float64   `json:"hours_spent"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// QuizAnalytics is how well the caller answers questions drawn from a plan
+type QuizAnalytics struct {
+	PlanID            string           `json:"plan_id"`
+	Attempts          int              `json:"attempts"`
+	QuestionsAnswered int              `json:"questions_answered"`
+	CorrectRate       *float64         `json:"correct_rate"`
+	Milestones        []MilestoneScore `json:"milestones"` // In plan order
+	Skills            []SkillScore     `json:"skills"`     // Weakest first
+}
+
+// MilestoneScore aggregates the answers to one milestone's questions
+type MilestoneScore struct {
+	MilestoneID string   `json:"milestone_id"`
+	Title       string   `json:"title"`
+	Order       int      `json:"order"`
+	Answered    int      `json:"answered"`
+	Correct     int      `json:"correct"`
+	CorrectRate *float64 `json:"correct_rate"` // 0-1; nil when none were answered
+}
+
+// SkillScore aggregates the answers to questions on one skill
+type SkillScore struct {
+	Skill       string   `json:"skill"`
+	Answered    int      `json:"answered"`
+	Correct     int      `json:"correct"`
+	CorrectRate *float64 `json:"correct_rate"`
+}
+
+// ProgressUpdate records progress on a milestone
+type ProgressUpdate struct {
+	Completed  bool    `json:"completed"`
+	HoursSpent float64 `json:"hours_spent"`
+}
+
+// IngestResult acknowledges an ingestion request; ingestion runs in the
+// background
+type IngestResult struct {
+	Message    string `json:"message"`
+	Count      int    `json:"count"`      // URLs queued after deduplication
+	Duplicates int    `json:"duplicates"` // Submitted URLs that duplicated another
+}
+
+// Transcript is the time-coded transcript of a video resource
+type Transcript struct {
+	ResourceID string              `json:"resource_id"`
+	URL        string              `json:"url"`
+	Title      string              `json:"title"`
+	Source     string              `json:"source"` // youtube or whisper
+	Language   string              `json:"language"`
+	Segments   []TranscriptSegment `json:"segments"`
+	FetchedAt  time.Time           `json:"fetched_at"`
+}
+
+// TranscriptSegment is one timed line of a transcript
+type TranscriptSegment struct {
+	Start float64 `json:"start"` // Seconds from the start of the video
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Search finds learning resources
+func (c *Client) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	var resp SearchResponse
+	if err := c.do(ctx, http.MethodPost, "/search", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreatePlan generates a learning plan
+func (c *Client) CreatePlan(ctx context.Context, req PlanRequest) (*PlanResult, error) {
+	var resp PlanResult
+	if err := c.do(ctx, http.MethodPost, "/plan", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreatePlanFromContent ingests urls and generates a plan from only those
+// resources
+func (c *Client) CreatePlanFromContent(ctx context.Context, urls []string, req PlanRequest) (*PlanResult, error) {
+	body := struct {
+		URLs []string    `json:"urls"`
+		Plan PlanRequest `json:"plan"`
+	}{urls, req}
+
+	var resp PlanResult
+	if err := c.do(ctx, http.MethodPost, "/plan/from-content", body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// EstimatePlan previews what generating a plan for req would take and cost
+func (c *Client) EstimatePlan(ctx context.Context, req PlanRequest) (*PlanEstimate, error) {
+	var resp PlanEstimate
+	if err := c.do(ctx, http.MethodPost, "/plan/estimate", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DecomposeGoal suggests sub-goals of a broad goal; pass the chosen titles
+// as PlanRequest.SubGoals
+func (c *Client) DecomposeGoal(ctx context.Context, req DecomposeGoalRequest) (*GoalDecomposition, error) {
+	var resp GoalDecomposition
+	if err := c.do(ctx, http.MethodPost, "/goal/decompose", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreatePlanDraft starts a plan draft from a goal
+func (c *Client) CreatePlanDraft(ctx context.Context, goal string) (*PlanDraft, error) {
+	var resp PlanDraft
+	if err := c.do(ctx, http.MethodPost, "/plan/draft", map[string]string{"goal": goal}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetPlanDraft fetches one of the caller's drafts
+func (c *Client) GetPlanDraft(ctx context.Context, draftID string) (*PlanDraft, error) {
+	var resp PlanDraft
+	if err := c.do(ctx, http.MethodGet, "/plan/draft/"+url.PathEscape(draftID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdatePlanDraft applies one refinement to a draft
+func (c *Client) UpdatePlanDraft(ctx context.Context, draftID string, update PlanDraftUpdate) (*PlanDraft, error) {
+	var resp PlanDraft
+	if err := c.do(ctx, http.MethodPatch, "/plan/draft/"+url.PathEscape(draftID), update, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeletePlanDraft discards a draft
+func (c *Client) DeletePlanDraft(ctx context.Context, draftID string) error {
+	return c.do(ctx, http.MethodDelete, "/plan/draft/"+url.PathEscape(draftID), nil, nil)
+}
+
+// CommitPlanDraft generates the plan a draft describes; the draft is
+// discarded once the plan exists
+func (c *Client) CommitPlanDraft(ctx context.Context, draftID string) (*PlanResult, error) {
+	var resp PlanResult
+	if err := c.do(ctx, http.MethodPost, "/plan/draft/"+url.PathEscape(draftID)+"/commit", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetPlan fetches a plan by ID
+func (c *Client) GetPlan(ctx context.Context, planID string) (*Plan, error) {
+	var resp Plan
+	if err := c.do(ctx, http.MethodGet, "/plan/"+url.PathEscape(planID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListUserPlans lists a user's plans
+func (c *Client) ListUserPlans(ctx context.Context, userID string) (*UserPlans, error) {
+	var resp UserPlans
+	if err := c.do(ctx, http.MethodGet, "/plan/user/"+url.PathEscape(userID)+"/plans", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Replan adjusts a plan to reported progress
+func (c *Client) Replan(ctx context.Context, planID string, req ReplanRequest) (*Plan, error) {
+	var resp Plan
+	if err := c.do(ctx, http.MethodPost, "/plan/"+url.PathEscape(planID)+"/replan", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RemediatePlan adds a review milestone to a plan after a failed quiz
+// attempt; an empty attemptID uses the caller's latest graded attempt
+func (c *Client) RemediatePlan(ctx context.Context, planID, attemptID string) (*Remediation, error) {
+	body := struct {
+		AttemptID string `json:"attempt_id,omitempty"`
+	}{attemptID}
+
+	var resp Remediation
+	if err := c.do(ctx, http.MethodPost, "/plan/"+url.PathEscape(planID)+"/remediate", body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GenerateQuiz generates a quiz over resources
+func (c *Client) GenerateQuiz(ctx context.Context, req QuizRequest) (*Quiz, error) {
+	var resp Quiz
+	if err := c.do(ctx, http.MethodPost, "/quiz/generate", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GenerateQuizzes generates up to 20 quizzes concurrently; each fails on
+// its own
+func (c *Client) GenerateQuizzes(ctx context.Context, items []QuizBatchItem) (*QuizBatch, error) {
+	var resp QuizBatch
+	if err := c.do(ctx, http.MethodPost, "/quiz/generate/batch", map[string][]QuizBatchItem{"items": items}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListQuestions browses the question bank, newest first
+func (c *Client) ListQuestions(ctx context.Context, filter QuestionFilter) ([]BankQuestion, error) {
+	query := url.Values{}
+	if filter.ResourceID != "" {
+		query.Set("resource_id", filter.ResourceID)
+	}
+	if filter.Skill != "" {
+		query.Set("skill", filter.Skill)
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	var resp struct {
+		Questions []BankQuestion `json:"questions"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/quiz/questions?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Questions, nil
+}
+
+// ComposeQuiz assembles a quiz from bank questions without generating any
+func (c *Client) ComposeQuiz(ctx context.Context, title string, questions []QuestionRef) (*Quiz, error) {
+	req := struct {
+		Title     string        `json:"title,omitempty"`
+		Questions []QuestionRef `json:"questions"`
+	}{title, questions}
+	var resp Quiz
+	if err := c.do(ctx, http.MethodPost, "/quiz/compose", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RetakeQuiz returns a regenerated variant of a quiz to retake
+func (c *Client) RetakeQuiz(ctx context.Context, quizID string) (*Quiz, error) {
+	var resp Quiz
+	if err := c.do(ctx, http.MethodPost, "/quiz/"+url.PathEscape(quizID)+"/retake", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SubmitQuiz grades quiz answers
+func (c *Client) SubmitQuiz(ctx context.Context, req QuizSubmission) (*QuizResult, error) {
+	var resp QuizResult
+	if err := c.do(ctx, http.MethodPost, "/quiz/submit", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListQuizAttempts lists the caller's quiz attempts, oldest first
+func (c *Client) ListQuizAttempts(ctx context.Context) ([]QuizAttempt, error) {
+	var resp struct {
+		Attempts []QuizAttempt `json:"attempts"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/quiz/attempts", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Attempts, nil
+}
+
+// GetQuizAttempt returns one of the caller's quiz attempts
+func (c *Client) GetQuizAttempt(ctx context.Context, attemptID string) (*QuizAttempt, error) {
+	var resp QuizAttempt
+	if err := c.do(ctx, http.MethodGet, "/quiz/attempts/"+url.PathEscape(attemptID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetProgress lists the caller's progress on a plan
+func (c *Client) GetProgress(ctx context.Context, planID string) ([]Progress, error) {
+	var resp struct {
+		Progress []Progress `json:"progress"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/plan/"+url.PathEscape(planID)+"/progress", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Progress, nil
+}
+
+// RecordProgress records the caller's progress on a milestone
+func (c *Client) RecordProgress(ctx context.Context, planID, milestoneID string, update ProgressUpdate) (*Progress, error) {
+	var resp Progress
+	path := "/plan/" + url.PathEscape(planID) + "/progress/" + url.PathEscape(milestoneID)
+	if err := c.do(ctx, http.MethodPut, path, update, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetQuizAnalytics returns the caller's quiz scores on a plan by milestone
+// and skill
+func (c *Client) GetQuizAnalytics(ctx context.Context, planID string) (*QuizAnalytics, error) {
+	var resp QuizAnalytics
+	if err := c.do(ctx, http.MethodGet, "/plan/"+url.PathEscape(planID)+"/quiz-analytics", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Ingest queues URLs for ingestion into the caller's content library
+func (c *Client) Ingest(ctx context.Context, urls []string) (*IngestResult, error) {
+	var resp IngestResult
+	if err := c.do(ctx, http.MethodPost, "/content/ingest", map[string][]string{"urls": urls}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Language is a language plans and quizzes can be generated in
+type Language struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	NativeName string `json:"native_name"`
+}
+
+// Languages lists the languages plans and quizzes can be generated in
+type Languages struct {
+	Default   string     `json:"default"`
+	Languages []Language `json:"languages"`
+}
+
+// ListLanguages returns the supported generation languages
+func (c *Client) ListLanguages(ctx context.Context) (*Languages, error) {
+	var resp Languages
+	if err := c.do(ctx, http.MethodGet, "/languages", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetTranscript returns the transcript of a video resource
+func (c *Client) GetTranscript(ctx context.Context, resourceID string) (*Transcript, error) {
+	var resp Transcript
+	if err := c.do(ctx, http.MethodGet, "/resource/"+url.PathEscape(resourceID)+"/transcript", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,280 @@
+// Package sdk is a Go client for the Learning Path Designer gateway API.
+//
+//	client := sdk.New(sdk.Options{BaseURL: "https://api.example.com", Token: token})
+//	plan, err := client.CreatePlan(ctx, sdk.PlanRequest{Goal: "Learn Go", TimeBudgetHours: 20, HoursPerWeek: 5})
+//
+// Requests use the v2 API. Failed calls return an *Error carrying the
+// gateway's error code; transient failures (connection errors, 429, 502,
+// 503 and 504) are retried with backoff, honouring Retry-After. POSTs carry
+// an Idempotency-Key that is reused across retries, so the gateway replays
+// rather than repeats a request that already went through.
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Defaults
+const (
+	DefaultTimeout    = 60 * time.Second
+	DefaultMaxRetries = 3
+	defaultBaseWait   = 200 * time.Millisecond
+	defaultMaxWait    = 5 * time.Second
+)
+
+// TokenSource supplies the bearer token for each request. It is called
+// again after a 401, so implementations that cache tokens should refresh
+// an expired one there.
+type TokenSource func(ctx context.Context) (string, error)
+
+// Options configures a Client
+type Options struct {
+	BaseURL string // Gateway root, e.g. http://localhost:8080
+
+	// Token is a static bearer token; TokenSource takes precedence
+	Token       string
+	TokenSource TokenSource
+
+	HTTPClient *http.Client // Defaults to a client with DefaultTimeout
+	MaxRetries int          // Retries after the first attempt; negative disables
+	BaseWait   time.Duration
+	MaxWait    time.Duration
+	UserAgent  string
+}
+
+// Client calls the gateway API. It is safe for concurrent use.
+type Client struct {
+	baseURL string
+	opts    Options
+	http    *http.Client
+}
+
+// New creates a client
+func New(opts Options) *Client {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultMaxRetries
+	}
+	if opts.BaseWait <= 0 {
+		opts.BaseWait = defaultBaseWait
+	}
+	if opts.MaxWait <= 0 {
+		opts.MaxWait = defaultMaxWait
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = "learnpath-sdk-go"
+	}
+	return &Client{
+		baseURL: strings.TrimSuffix(opts.BaseURL, "/") + "/api/v2",
+		opts:    opts,
+		http:    opts.HTTPClient,
+	}
+}
+
+// Error is a non-2xx response from the gateway
+type Error struct {
+	StatusCode int
+	Code       string // e.g. invalid_request, not_found
+	Message    string
+	Errors     []FieldError // Per-field problems with an invalid request
+	RequestID  string
+	RetryAfter time.Duration
+}
+
+// FieldError is one problem with a request field
+type FieldError struct {
+	Field   string `json:"field"` // JSON path, e.g. answers[0].question_id
+	Rule    string `json:"rule"`  // e.g. required, min, uuid
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("learnpath: %d %s", e.StatusCode, e.Code)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	for _, f := range e.Errors {
+		msg += "; " + strings.TrimPrefix(f.Field+" "+f.Message, " ")
+	}
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// IsNotFound reports whether err is a 404 from the gateway
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do sends a JSON request, retrying transient failures, and decodes a
+// successful response into out (when non-nil)
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("learnpath: failed to encode request: %w", err)
+		}
+	}
+
+	var idempotencyKey string
+	if method == http.MethodPost {
+		idempotencyKey = uuid.NewString()
+	}
+
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		err := c.attempt(ctx, method, path, body, idempotencyKey, out)
+		if err == nil {
+			return nil
+		}
+
+		var apiErr *Error
+		isAPIErr := errors.As(err, &apiErr)
+		if isAPIErr && apiErr.StatusCode == http.StatusUnauthorized && c.opts.TokenSource != nil && !refreshed {
+			// Give the token source one chance to refresh
+			refreshed = true
+			continue
+		}
+		if attempt >= c.opts.MaxRetries || ctx.Err() != nil || (isAPIErr && !retryableStatus(apiErr.StatusCode)) {
+			return err
+		}
+
+		wait := c.backoff(attempt + 1)
+		if isAPIErr && apiErr.RetryAfter > wait {
+			wait = apiErr.RetryAfter
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method, path string, body []byte, idempotencyKey string, out any) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("learnpath: failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	token, err := c.token(ctx)
+	if err != nil {
+		return fmt.Errorf("learnpath: failed to get token: %w", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("learnpath: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("learnpath: failed to read response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp, data)
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("learnpath: failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) token(ctx context.Context) (string, error) {
+	if c.opts.TokenSource != nil {
+		return c.opts.TokenSource(ctx)
+	}
+	return c.opts.Token, nil
+}
+
+// decodeError parses the v2 error envelope or, for errors raised before
+// versioning applies (such as auth), the v1 {"error", "message"} body
+func decodeError(resp *http.Response, data []byte) *Error {
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		Code:       http.StatusText(resp.StatusCode),
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+	var envelope struct {
+		Error struct {
+			Code      string       `json:"code"`
+			Message   string       `json:"message"`
+			Errors    []FieldError `json:"errors"`
+			RequestID string       `json:"request_id"`
+		} `json:"error"`
+	}
+	var v1 struct {
+		Error   string       `json:"error"`
+		Message string       `json:"message"`
+		Errors  []FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &envelope); err == nil && envelope.Error.Code != "" {
+		apiErr.Code = envelope.Error.Code
+		apiErr.Message = envelope.Error.Message
+		apiErr.Errors = envelope.Error.Errors
+		if envelope.Error.RequestID != "" {
+			apiErr.RequestID = envelope.Error.RequestID
+		}
+	} else if err := json.Unmarshal(data, &v1); err == nil && v1.Error != "" {
+		apiErr.Code = v1.Error
+		apiErr.Message = v1.Message
+		apiErr.Errors = v1.Errors
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
+
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the wait before the given retry (1-based): exponential,
+// capped at MaxWait, with half of it randomised
+func (c *Client) backoff(retry int) time.Duration {
+	wait := math.Min(float64(c.opts.BaseWait)*math.Pow(2, float64(retry-1)), float64(c.opts.MaxWait))
+	return time.Duration(wait * (0.5 + 0.5*rand.Float64()))
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/amirhf/learnpath-gateway/internal/abuse"
+	"github.com/amirhf/learnpath-gateway/internal/admission"
+	"github.com/amirhf/learnpath-gateway/internal/branding"
+	"github.com/amirhf/learnpath-gateway/internal/calibration"
+	"github.com/amirhf/learnpath-gateway/internal/capture"
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"github.com/amirhf/learnpath-gateway/internal/costs"
+	"github.com/amirhf/learnpath-gateway/internal/digest"
+	"github.com/amirhf/learnpath-gateway/internal/documents"
+	"github.com/amirhf/learnpath-gateway/internal/drafts"
+	"github.com/amirhf/learnpath-gateway/internal/estimate"
+	"github.com/amirhf/learnpath-gateway/internal/events"
+	"github.com/amirhf/learnpath-gateway/internal/features"
+	"github.com/amirhf/learnpath-gateway/internal/focus"
+	"github.com/amirhf/learnpath-gateway/internal/grading"
+	"github.com/amirhf/learnpath-gateway/internal/guests"
+	"github.com/amirhf/learnpath-gateway/internal/handlers"
+	"github.com/amirhf/learnpath-gateway/internal/health"
+	"github.com/amirhf/learnpath-gateway/internal/hooks"
+	"github.com/amirhf/learnpath-gateway/internal/middleware"
+	"github.com/amirhf/learnpath-gateway/internal/moderation"
+	"github.com/amirhf/learnpath-gateway/internal/notify"
+	"github.com/amirhf/learnpath-gateway/internal/orchestrator"
+	"github.com/amirhf/learnpath-gateway/internal/ownership"
+	"github.com/amirhf/learnpath-gateway/internal/preview"
+	"github.com/amirhf/learnpath-gateway/internal/quizsession"
+	"github.com/amirhf/learnpath-gateway/internal/repository"
+	"github.com/amirhf/learnpath-gateway/internal/retakes"
+	"github.com/amirhf/learnpath-gateway/internal/scheduler"
+	"github.com/amirhf/learnpath-gateway/internal/secrets"
+	"github.com/amirhf/learnpath-gateway/internal/seed"
+	"github.com/amirhf/learnpath-gateway/internal/storage"
+	"github.com/amirhf/learnpath-gateway/internal/transcripts"
+	"github.com/gin-gonic/gin"
+)
+
+// apiDeps are the services API handlers are built from
+type apiDeps struct {
+	cfg       *config.Config
+	orch      orchestrator.Orchestrator
+	switches  *features.Switches
+	admit     *admission.Controller
+	repos     *repository.Repositories
+	bus       *events.Bus
+	digests   *digest.Builder
+	moderator *moderation.Moderator
+	uploads   documents.Store
+	videos    *transcripts.Service
+	previews  *preview.Fetcher
+	costs     *costs.Tracker
+	quizStats *calibration.Calibrator
+	grader    *grading.Worker
+	guard     *quizsession.Guard
+	retakes   *retakes.Policy
+	drafts    *drafts.Store
+	owners    *ownership.Registry
+	guests    *guests.Sessions
+	versions  *health.Versions
+	router    http.Handler // Serves batch sub-requests
+	started   time.Time    // Last-Modified of responses that change on deploy
+	abuse     *abuse.Detector
+	store     storage.KeyValue // Remembers which clients were told of deprecations
+	notifier  *notify.Dispatcher
+	hooks     *hooks.Registry // REST hook subscriptions to learning events
+	brands    *branding.Store
+	focus     *focus.Tracker
+}
+
+// registerAPIRoutes mounts the API under /api/v1, /api/v2 and the legacy /api
+// alias. All versions share handlers; v2 differs only in response shape
+// (error envelope, sanitized quiz DTOs). Every version answers in
+// MessagePack when asked to.
+func registerAPIRoutes(r *gin.Engine, deps apiDeps) {
+	// Legacy unversioned routes behave like v1
+	addAPIRoutes(r.Group("/api", middleware.APIVersion(middleware.APIv1), middleware.MessagePack()), deps)
+	addAPIRoutes(r.Group("/api/v1", middleware.APIVersion(middleware.APIv1), middleware.MessagePack()), deps)
+	addAPIRoutes(r.Group("/api/v2", middleware.APIVersion(middleware.APIv2), middleware.MessagePack(), middleware.ErrorEnvelope()), deps)
+}
+
+func addAPIRoutes(api *gin.RouterGroup, deps apiDeps) {
+	cfg, orch, switches, admit, repos, bus := deps.cfg, deps.orch, deps.switches, deps.admit, deps.repos, deps.bus
+
+	// JSON:API documents on request, wrapping the ?fields= projection
+	api.Use(middleware.JSONAPI(cfg.JSONAPI.Tenants))
+
+	// ?fields= response shaping for every endpoint
+	api.Use(middleware.SparseFieldsets())
+
+	// Private, revalidated GET responses unless a route says otherwise
+	api.Use(middleware.Caching())
+
+	// Deprecation and Sunset headers on deprecated routes and fields
+	api.Use(middleware.Deprecations(cfg.Deprecation, deps.store))
+
+	// Admission priorities: interactive search and reads first, then
+	// planning, then ingestion
+	interactive := middleware.Admission(admit, admission.Interactive)
+	planning := middleware.Admission(admit, admission.Planning)
+	background := middleware.Admission(admit, admission.Background)
+
+	// End-to-end budgets for routes that call the backends
+	deadline := func(route string) gin.HandlerFunc {
+		return middleware.Deadline(cfg.Deadlines.For(route))
+	}
+
+	// Body size and JSON nesting limits; "" is the default limit
+	body := func(route string) gin.HandlerFunc {
+		return middleware.BodyLimit(cfg.BodyLimits.For(route), cfg.BodyLimits.MaxJSONDepth)
+	}
+
+	// Routes that need a signed-in user, per AUTH_REQUIRED
+	auth := func(route string) gin.HandlerFunc {
+		return middleware.RequireAuth(cfg.Auth.For(route))
+	}
+
+	// Plans and quizzes named by :id are only served to their owners
+	owned := func(kind string, access ownership.Access) gin.HandlerFunc {
+		return middleware.Owned(deps.owners, kind, "id", access)
+	}
+	readPlan, writePlan := owned(ownership.Plan, ownership.Read), owned(ownership.Plan, ownership.Write)
+
+	// Guests may create a limited number of plans and quizzes
+	guestPlans := middleware.GuestQuota(deps.guests, guests.Plans)
+	guestQuizzes := middleware.GuestQuota(deps.guests, guests.Quizzes)
+
+	// Per-tenant cost accounting for routes that reach the LLM backends
+	metered := middleware.CostBudget(deps.costs)
+
+	// Callers banned for repeated failed requests can't generate plans or
+	// quizzes
+	notBanned := middleware.NotBanned(deps.abuse)
+
+	// RAG Service
+	api.POST("/search", auth(config.RouteSearch), body(config.RouteSearch), metered, deadline(config.RouteSearch), interactive, middleware.CacheFor(cfg.Caching.SearchTTL), handlers.Search(cfg, orch, switches))
+
+	// Planner Service
+	api.POST("/plan", auth(config.RoutePlan), notBanned, body(config.RoutePlan), guestPlans, metered, deadline(config.RoutePlan), planning, handlers.CreatePlan(cfg, orch, bus, deps.hooks, deps.moderator, deps.guard, deps.owners))
+	api.POST("/plan/from-content", auth(config.RoutePlanFromContent), notBanned, middleware.KillSwitch(switches, features.KillIngestion), body(config.RoutePlanFromContent), guestPlans, metered, deadline(config.RoutePlanFromContent), planning, handlers.PlanFromContent(cfg, orch, bus, deps.hooks, deps.moderator, deps.guard, deps.owners))
+	api.POST("/goal/decompose", auth(config.RouteGoalDecompose), body(config.RoutePlan), metered, deadline(config.RouteGoalDecompose), interactive, handlers.DecomposeGoal(cfg, orch, deps.moderator))
+	api.POST("/plan/estimate", auth(config.RoutePlan), body(config.RoutePlan), metered, deadline(config.RouteSearch), interactive, handlers.EstimatePlan(orch, deps.costs, deps.moderator))
+	draftID := middleware.UUIDParams("draft_id")
+	api.POST("/plan/draft", body(""), interactive, handlers.CreateDraft(deps.drafts, deps.moderator))
+	api.GET("/plan/draft/:draft_id", draftID, interactive, handlers.GetDraft(deps.drafts))
+	api.PATCH("/plan/draft/:draft_id", draftID, body(config.RoutePlan), metered, deadline(config.RouteSearch), interactive, handlers.UpdateDraft(deps.drafts, orch, deps.costs, deps.moderator))
+	api.DELETE("/plan/draft/:draft_id", draftID, interactive, handlers.DeleteDraft(deps.drafts))
+	api.POST("/plan/draft/:draft_id/commit", draftID, notBanned, guestPlans, metered, deadline(config.RoutePlan), planning, handlers.CommitDraft(cfg, deps.drafts, orch, bus, deps.hooks, deps.guard, deps.owners))
+	planID := middleware.UUIDParams("id")
+	api.GET("/plan/:id", auth(config.RouteGetPlan), planID, readPlan, deadline(config.RouteGetPlan), interactive, middleware.Revalidate(), handlers.GetPlan(orch))
+	api.GET("/plan/user/:user_id/plans", auth(config.RouteUserPlans), middleware.Self(deps.owners, "user_id"), deadline(config.RouteUserPlans), interactive, handlers.GetUserPlans(orch))
+	api.POST("/plan/:id/remediate", auth(config.RouteReplan), planID, writePlan, body(config.RouteReplan), metered, deadline(config.RouteReplan), planning, handlers.RemediatePlan(cfg, orch, repos, bus))
+	api.POST("/plan/:id/replan", auth(config.RouteReplan), planID, writePlan, body(config.RouteReplan), metered, deadline(config.RouteReplan), planning, handlers.Replan(orch, repos))
+	// SCORM package for LMSes; generates the milestone quizzes
+	api.GET("/plan/:id/export/scorm", auth(config.RouteGetPlan), planID, readPlan, notBanned, metered, deadline(config.RouteQuizGenerate), planning, handlers.ExportSCORM(cfg, orch, switches, deps.brands))
+	api.GET("/plan/:id/export/bundle", auth(config.RouteGetPlan), planID, readPlan, notBanned, metered, deadline(config.RouteQuizGenerate), planning, handlers.ExportBundle(cfg, orch, switches, repos))
+	api.GET("/plan/import/schema/:version", handlers.GetImportSchema())
+	api.POST("/plan/import", auth(config.RoutePlanImport), notBanned, body(config.RoutePlanImport), guestPlans, metered, deadline(config.RoutePlanImport), planning, handlers.ImportPlan(cfg, orch, repos, bus, deps.hooks, deps.moderator, deps.guard, deps.owners))
+
+	// Quiz Service
+	api.POST("/quiz/generate", auth(config.RouteQuizGenerate), notBanned, middleware.KillSwitch(switches, features.KillQuizGeneration), body(config.RouteQuizGenerate), guestQuizzes, metered, deadline(config.RouteQuizGenerate), planning, handlers.GenerateQuiz(cfg, orch, bus, deps.guard, deps.owners))
+	api.POST("/quiz/generate/batch", auth(config.RouteQuizGenerate), notBanned, middleware.KillSwitch(switches, features.KillQuizGeneration), body(config.RouteQuizGenerate), guestQuizzes, metered, deadline(config.RouteQuizGenerate), planning, handlers.GenerateQuizBatch(cfg, orch, bus, deps.guard, deps.owners))
+	api.GET("/quiz/questions", interactive, handlers.ListBankQuestions(orch))
+	api.POST("/quiz/compose", auth(config.RouteQuizGenerate), notBanned, body(config.RouteQuizGenerate), guestQuizzes, metered, deadline(config.RouteQuizSubmit), interactive, handlers.ComposeQuiz(orch, bus, deps.guard, deps.owners))
+	api.POST("/quiz/:id/retake", auth(config.RouteQuizGenerate), notBanned, owned(ownership.Quiz, ownership.Write), middleware.KillSwitch(switches, features.KillQuizGeneration), body(config.RouteQuizGenerate), guestQuizzes, metered, deadline(config.RouteQuizGenerate), planning, handlers.RetakeQuiz(cfg, orch, bus, deps.retakes, deps.guard, deps.owners))
+	api.POST("/quiz/submit", auth(config.RouteQuizSubmit), body(config.RouteQuizSubmit), deadline(config.RouteQuizSubmit), interactive, handlers.SubmitQuiz(orch, repos, bus, deps.hooks, deps.quizStats, deps.grader, deps.guard, deps.owners, deps.notifier))
+	api.GET("/quiz/attempts", interactive, handlers.ListQuizAttempts(repos))
+	api.GET("/quiz/attempts/:attempt_id", interactive, handlers.GetQuizAttempt(repos))
+
+	// Content Ingestion (BYO Content)
+	api.POST("/content/ingest", auth(config.RouteContentIngest), middleware.KillSwitch(switches, features.KillIngestion), body(config.RouteContentIngest), metered, deadline(config.RouteContentIngest), background, handlers.IngestContent(cfg, orch, deps.moderator))
+	api.POST("/content/upload", auth(config.RouteContentUpload), middleware.KillSwitch(switches, features.KillIngestion), body(config.RouteContentUpload), metered, deadline(config.RouteContentUpload), background, handlers.UploadDocument(orch, deps.uploads))
+
+	// The same ingestion for signed-in users only, whatever AUTH_REQUIRED
+	// says: ingested content belongs to the caller's tenant
+	ingest := api.Group("/ingest", middleware.RequireAuth(true), middleware.NoGuests(), middleware.KillSwitch(switches, features.KillIngestion))
+	ingest.POST("", body(config.RouteContentIngest), metered, deadline(config.RouteContentIngest), background, handlers.IngestContent(cfg, orch, deps.moderator))
+	ingest.POST("/upload", body(config.RouteContentUpload), metered, deadline(config.RouteContentUpload), background, handlers.UploadDocument(orch, deps.uploads))
+	api.GET("/resource/preview", interactive, handlers.GetPreview(cfg, deps.previews))
+	api.GET("/resource/:id/transcript", middleware.UUIDParams("id"), interactive, handlers.GetTranscript(deps.videos))
+
+	// Backend versions and schema drift
+	api.GET("/system/versions", interactive, handlers.SystemVersions(deps.versions))
+
+	// Languages plans and quizzes can be generated in
+	api.GET("/languages", middleware.CacheStatic(cfg.Caching.InfoTTL, deps.started), handlers.ListLanguages(cfg))
+
+	// Learner data owned by the gateway
+	api.GET("/plan/:id/notes", planID, readPlan, interactive, handlers.ListNotes(repos))
+	api.POST("/plan/:id/notes", planID, readPlan, body(""), interactive, handlers.CreateNote(repos))
+	api.DELETE("/notes/:note_id", middleware.UUIDParams("note_id"), interactive, handlers.DeleteNote(repos))
+	api.GET("/bookmarks", interactive, handlers.ListBookmarks(repos))
+	api.POST("/bookmarks", body(""), interactive, handlers.AddBookmark(repos))
+	api.DELETE("/bookmarks/:bookmark_id", middleware.UUIDParams("bookmark_id"), interactive, handlers.RemoveBookmark(repos))
+	api.GET("/plan/:id/progress", planID, readPlan, interactive, handlers.GetProgress(repos))
+	api.PUT("/plan/:id/progress/:milestone_id", middleware.UUIDParams("id", "milestone_id"), readPlan, body(""), interactive, handlers.RecordProgress(repos, bus, deps.hooks, deps.notifier))
+	api.GET("/plan/:id/time-log", planID, readPlan, interactive, handlers.GetTimeSpent(repos))
+	api.POST("/plan/:id/resource/:rid/time-log", middleware.UUIDParams("id", "rid"), readPlan, body(""), interactive, handlers.LogTime(repos))
+	api.POST("/plan/:id/resource/:rid/focus", middleware.UUIDParams("id", "rid"), readPlan, body(""), interactive, handlers.StartFocus(deps.focus))
+	api.POST("/focus/sessions/:session_id/heartbeat", middleware.UUIDParams("session_id"), body(""), interactive, handlers.FocusHeartbeat(deps.focus))
+	api.POST("/focus/sessions/:session_id/end", middleware.UUIDParams("session_id"), body(""), interactive, handlers.EndFocus(deps.focus))
+	api.GET("/focus/summary", interactive, handlers.GetFocusSummary(deps.focus))
+	api.GET("/plan/:id/quiz-analytics", planID, readPlan, interactive, handlers.GetQuizAnalytics(orch, repos))
+	api.GET("/user/streak", interactive, handlers.GetStreak(repos))
+	api.GET("/user/:id/digest", interactive, handlers.GetDigest(deps.digests, deps.brands))
+
+	// Triggers for automation platforms such as Zapier and Make: polling
+	// listings with ?since cursors, and REST hook subscriptions
+	api.GET("/triggers/new-plans", deadline(config.RouteUserPlans), interactive, handlers.ListNewPlans(orch))
+	api.GET("/triggers/completed-milestones", interactive, handlers.ListCompletedMilestones(repos))
+	api.GET("/triggers/quiz-submissions", interactive, handlers.ListQuizSubmissions(repos))
+	api.GET("/hooks", interactive, handlers.ListHooks(deps.hooks))
+	api.POST("/hooks", body(""), interactive, handlers.SubscribeHook(deps.hooks))
+	api.DELETE("/hooks/:hook_id", middleware.UUIDParams("hook_id"), interactive, handlers.UnsubscribeHook(deps.hooks))
+
+	// Guest sessions, and adopting a guest's data after signing up
+	api.POST("/guest/session", interactive, handlers.CreateGuestSession(cfg, deps.guests))
+	api.POST("/user/merge-guest", middleware.RequireAuth(true), middleware.NoGuests(), body(""), deadline(config.RouteReplan), interactive, handlers.MergeGuest(deps.guests, orch, deps.owners, repos))
+
+	// Several API requests in one round trip, run through the router
+	api.POST("/batch", body(""), handlers.Batch(cfg, deps.router))
+
+	// Plan sharing
+	api.POST("/plan/:id/share", planID, writePlan, body(""), interactive, handlers.CreateShareToken(repos))
+	api.GET("/share/:token", interactive, handlers.ResolveShareToken(repos))
+	api.DELETE("/share/:token", interactive, handlers.RevokeShareToken(repos))
+}
+
+// adminDeps are the services admin handlers are built from
+type adminDeps struct {
+	cfg       *config.Config
+	switches  *features.Switches
+	sched     *scheduler.Scheduler
+	seeder    *seed.Seeder
+	recorder  *capture.Recorder // nil when capture is disabled
+	orch      orchestrator.Orchestrator
+	durations *estimate.Estimator
+	costs     *costs.Tracker
+	repos     *repository.Repositories
+	secrets   *secrets.Manager
+	abuse     *abuse.Detector
+	brands    *branding.Store
+}
+
+// registerAdminRoutes mounts operator endpoints guarded by the admin token,
+// which follows rotation in the secret store
+func registerAdminRoutes(r *gin.Engine, deps adminDeps) {
+	adminToken := func() string { return deps.secrets.Value("ADMIN_TOKEN", deps.cfg.AdminToken) }
+	admin := r.Group("/admin", middleware.AdminAuth(adminToken))
+	{
+		admin.GET("/switches", handlers.ListSwitches(deps.switches))
+		admin.PUT("/switches/:name", handlers.SetSwitch(deps.switches))
+		admin.GET("/jobs", handlers.ListJobs(deps.sched))
+		admin.POST("/jobs/:name/run", handlers.RunJob(deps.sched))
+		admin.POST("/seed", handlers.SeedDemo(deps.seeder))
+		admin.GET("/requests/:request_id", handlers.GetCapturedRequest(deps.recorder))
+		admin.POST("/requests/:request_id", handlers.ReplayRequest(deps.recorder))
+		admin.POST("/resources/durations", handlers.RecalculateDurations(deps.cfg, deps.orch, deps.durations))
+		admin.GET("/tenants/:id/costs", handlers.GetTenantCosts(deps.costs))
+		admin.GET("/tenants/:id/branding", handlers.GetTenantBranding(deps.brands))
+		admin.PUT("/tenants/:id/branding", handlers.SetTenantBranding(deps.brands))
+		admin.DELETE("/tenants/:id/branding", handlers.ResetTenantBranding(deps.brands))
+		admin.GET("/quiz-anomalies", handlers.ListQuizAnomalies(deps.repos))
+		admin.GET("/bans", handlers.ListBans(deps.abuse))
+		admin.DELETE("/bans/:key", handlers.LiftBan(deps.abuse))
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/tls"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/amirhf/learnpath-gateway/internal/config"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// serve runs the HTTP server on addr, terminating TLS itself when configured
+func serve(handler http.Handler, appCfg *config.Config, addr string) error {
+	cfg := appCfg.TLS
+	server := newServer(addr, handler, appCfg.Server)
+	if !appCfg.Server.HTTP2 {
+		// A non-nil empty map disables HTTP/2 over TLS
+		server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+
+	if !cfg.Enabled() {
+		if appCfg.Server.H2C {
+			server.Handler = h2c.NewHandler(handler, &http2.Server{})
+		}
+		log.Printf("Starting gateway on %s", addr)
+		return server.ListenAndServe()
+	}
+
+	server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.Autocert() {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.AutocertCache),
+			Email:      cfg.AutocertEmail,
+		}
+		server.TLSConfig.GetCertificate = manager.GetCertificate
+		// net/http adds h2 and http/1.1 itself
+		server.TLSConfig.NextProtos = []string{acme.ALPNProto}
+
+		// The plain HTTP listener is always needed for HTTP-01 challenges
+		var fallback http.Handler
+		if cfg.RedirectHTTP {
+			fallback = redirectToHTTPS(addr)
+		} else {
+			fallback = handler
+		}
+		go listenHTTP(newServer(cfg.HTTPAddr, manager.HTTPHandler(fallback), appCfg.Server))
+
+		log.Printf("Starting gateway on %s with automatic certificates for %v", addr, cfg.AutocertDomains)
+		return server.ListenAndServeTLS("", "")
+	}
+
+	if cfg.RedirectHTTP {
+		go listenHTTP(newServer(cfg.HTTPAddr, redirectToHTTPS(addr), appCfg.Server))
+	}
+
+	log.Printf("Starting gateway on %s with TLS", addr)
+	return server.ListenAndServeTLS(cfg.CertFile, cfg.KeyFile)
+}
+
+// newServer creates a server for handler on addr with the configured
+// timeouts and limits
+func newServer(addr string, handler http.Handler, cfg config.ServerConfig) *http.Server {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+	server.SetKeepAlivesEnabled(cfg.KeepAlives)
+	return server
+}
+
+// listenHTTP serves the plain HTTP side (redirects, ACME challenges)
+func listenHTTP(server *http.Server) {
+	log.Printf("Serving plain HTTP on %s", server.Addr)
+	if err := server.ListenAndServe(); err != nil {
+		log.Printf("HTTP listener on %s stopped: %v", server.Addr, err)
+	}
+}
+
+// redirectToHTTPS sends plain HTTP requests to the same URL on the HTTPS
+// listener at tlsAddr
+func redirectToHTTPS(tlsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(tlsAddr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
@@ -22,6 +22,9 @@ class Settings(BaseSettings):
     # RAG Service
     rag_service_url: str = "http://localhost:8001"
     
+    # Licenses of paid resources, excluded from free-only plans (comma-separated)
+    paid_licenses: str = "proprietary,paid,subscription,commercial"
+    
     class Config:
         env_file = ".env.local"
         case_sensitive = False
@@ -137,7 +137,7 @@ class DatabaseClient:
             self.ensure_connection()
             with self.conn.cursor() as cur:
                 cur.execute("""
-                    SELECT plan_id, user_id, goal, total_hours, estimated_weeks, created_at, updated_at
+                    SELECT plan_id, user_id, goal, total_hours, estimated_weeks, status, created_at, updated_at
                     FROM learning_plans 
                     WHERE user_id = %s
                     ORDER BY created_at DESC
@@ -177,6 +177,56 @@ class DatabaseClient:
             self.conn.rollback()
             logger.error(f"Error updating plan: {e}")
             raise
+    
+    def set_plan_status(self, plan_id: str, status: str) -> bool:
+        """Set a plan's status; returns False when there is no such plan"""
+        try:
+            self.ensure_connection()
+            with self.conn.cursor() as cur:
+                cur.execute("""
+                    UPDATE learning_plans
+                    SET status = %s, updated_at = %s
+                    WHERE plan_id = %s
+                """, (status, datetime.utcnow(), plan_id))
+                self.conn.commit()
+                logger.info(f"Set plan {plan_id} status to {status}")
+                return cur.rowcount > 0
+        except Exception as e:
+            self.conn.rollback()
+            logger.error(f"Error setting plan status: {e}")
+            raise
+    
+    def transfer_plans(self, from_user_id: str, to_user_id: str) -> int:
+        """Reassign a user's plans to another user; returns how many moved"""
+        try:
+            self.ensure_connection()
+            with self.conn.cursor() as cur:
+                cur.execute("""
+                    UPDATE learning_plans
+                    SET user_id = %s, updated_at = %s
+                    WHERE user_id = %s
+                """, (to_user_id, datetime.utcnow(), from_user_id))
+                self.conn.commit()
+                logger.info(f"Transferred {cur.rowcount} plans from {from_user_id} to {to_user_id}")
+                return cur.rowcount
+        except Exception as e:
+            self.conn.rollback()
+            logger.error(f"Error transferring plans: {e}")
+            raise
+    
+    def delete_plan(self, plan_id: str) -> bool:
+        """Delete a plan; returns False when there is no such plan"""
+        try:
+            self.ensure_connection()
+            with self.conn.cursor() as cur:
+                cur.execute("DELETE FROM learning_plans WHERE plan_id = %s", (plan_id,))
+                self.conn.commit()
+                logger.info(f"Deleted plan {plan_id}")
+                return cur.rowcount > 0
+        except Exception as e:
+            self.conn.rollback()
+            logger.error(f"Error deleting plan: {e}")
+            raise
 
 
 _db_client = None
@@ -29,6 +29,16 @@ class LLMPlanResponse(BaseModel):
     milestones: List[LLMMilestone]
     reasoning: str
 
+class LLMSubGoal(BaseModel):
+    title: str
+    description: str
+    estimated_hours: float = Field(..., ge=0)
+    skills: List[str] = []
+    order: int
+
+class LLMDecompositionResponse(BaseModel):
+    sub_goals: List[LLMSubGoal] = Field(..., min_length=1)
+
 # ------------------------------------------
 
 class LLMClient:
@@ -48,7 +58,9 @@ class LLMClient:
         available_resources: List[Dict[str, Any]],
         time_budget_hours: int,
         hours_per_week: int,
-        preferences: Dict[str, Any] = None
+        preferences: Dict[str, Any] = None,
+        language: str = None,
+        sub_goals: List[str] = None
     ) -> Dict[str, Any]:
         """
         Generate a learning plan using LLM with retries and validation
@@ -60,6 +72,8 @@ class LLMClient:
             time_budget_hours: Total time budget
             hours_per_week: Hours per week available
             preferences: User preferences
+            language: BCP 47 tag of the language to write the plan in
+            sub_goals: Parts of the goal the learner picked, if any
             
         Returns:
             Structured plan as dict
@@ -67,7 +81,7 @@ class LLMClient:
         # Build prompt
         initial_prompt = self._build_plan_prompt(
             goal, current_skills, available_resources,
-            time_budget_hours, hours_per_week, preferences
+            time_budget_hours, hours_per_week, preferences, language, sub_goals
         )
         
         messages = [
@@ -129,19 +143,27 @@ class LLMClient:
         resources: List[Dict[str, Any]],
         time_budget: int,
         hours_per_week: int,
-        preferences: Dict[str, Any] = None
+        preferences: Dict[str, Any] = None,
+        language: str = None,
+        sub_goals: List[str] = None
     ) -> str:
         """Build the prompt for plan generation"""
         
         resources_text = "\n".join([
-            f"- [{r['resource_id']}] {r['title']} ({r.get('duration_min', 0)} min, Level: {r.get('level', 'N/A')})\n  URL: {r['url']}\n  Skills: {', '.join(r.get('skills', []))}"
+            f"- [{r['resource_id']}] {r['title']} ({r.get('duration_min', 0)} min, Level: {r.get('level', 'N/A')}, License: {r.get('license') or 'unknown'})\n  URL: {r['url']}\n  Skills: {', '.join(r.get('skills', []))}"
             for r in resources[:30]  # Limit to top 30 resources
         ])
         
+        free_instruction = "\n- Use only free resources; never include a resource behind a paywall or subscription" if preferences and preferences.get("free_only") else ""
+        
+        language_instruction = f"\n6. Write titles, descriptions, explanations and reasoning in the language with BCP 47 tag '{language}'; keep resource_id values unchanged" if language and language != "en" else ""
+        
+        sub_goals_text = "\nFOCUS ONLY ON THESE PARTS OF THE GOAL, in this order:\n" + "\n".join(f"- {s}" for s in sub_goals) + "\n" if sub_goals else ""
+        
         prompt = f"""Create a learning plan for the following goal:
 
 GOAL: {goal}
-
+{sub_goals_text}
 CURRENT SKILLS: {', '.join(current_skills) if current_skills else 'None specified'}
 
 TIME BUDGET: {time_budget} hours total, {hours_per_week} hours per week
@@ -150,13 +172,13 @@ AVAILABLE RESOURCES:
 {resources_text}
 
 PREFERENCES: {preferences if preferences else 'None specified'}
-
+{self._schedule_text(preferences)}
 Create a structured learning plan with the following:
 1. Break the goal into 3-5 milestones
 2. Assign resources to each milestone in logical order
 3. Respect prerequisites (beginner → intermediate → advanced)
 4. Stay within the time budget
-5. Explain why each resource is included
+5. Explain why each resource is included{free_instruction}{language_instruction}
 
 Format your response as strictly valid JSON with this exact structure:
 {{
@@ -182,26 +204,119 @@ Ensure the total duration of selected resources fits within {time_budget} hours.
 """
         return prompt
     
+    def decompose_goal(
+        self,
+        goal: str,
+        current_skills: List[str],
+        max_sub_goals: int = None,
+        language: str = None
+    ) -> List[Dict[str, Any]]:
+        """
+        Break a broad goal into sub-goals with estimated hours, retrying
+        responses that don't validate
+        """
+        count = f"at most {max_sub_goals}" if max_sub_goals else "3-7"
+        language_instruction = f"\n- Write titles and descriptions in the language with BCP 47 tag '{language}'" if language and language != "en" else ""
+        prompt = f"""Break the following learning goal into {count} sub-goals a learner could pick from.
+
+GOAL: {goal}
+
+CURRENT SKILLS: {', '.join(current_skills) if current_skills else 'None specified'}
+
+Rules:
+- Each sub-goal is a coherent area that could be learned on its own
+- Order sub-goals so prerequisites come first
+- Estimate the hours a learner with the current skills needs for each
+- Skip what the current skills already cover{language_instruction}
+
+Format your response as strictly valid JSON with this exact structure:
+{{
+  "sub_goals": [
+    {{
+      "title": "Sub-goal name",
+      "description": "What it covers",
+      "estimated_hours": 20,
+      "skills": ["skill1", "skill2"],
+      "order": 1
+    }}
+  ]
+}}
+
+Do not wrap the JSON in markdown code blocks.
+"""
+        messages = [
+            {
+                "role": "system",
+                "content": "You are an expert learning path designer. Break broad goals into realistic, well-ordered sub-goals."
+            },
+            {
+                "role": "user",
+                "content": prompt
+            }
+        ]
+        
+        max_retries = 3
+        last_error = None
+        
+        for attempt in range(max_retries):
+            try:
+                response = self.client.chat.completions.create(
+                    model=self.settings.default_model,
+                    messages=messages,
+                    temperature=0.5,
+                    max_tokens=1500,
+                )
+                text = response.choices[0].message.content
+                data = json.loads(self._extract_json(text))
+                sub_goals = LLMDecompositionResponse(**data).model_dump()["sub_goals"]
+                sub_goals.sort(key=lambda s: s["order"])
+                if max_sub_goals:
+                    sub_goals = sub_goals[:max_sub_goals]
+                return sub_goals
+            
+            except (json.JSONDecodeError, ValidationError) as e:
+                logger.warning(f"Decomposition validation failed on attempt {attempt + 1}: {e}")
+                last_error = e
+                messages.append({"role": "assistant", "content": text})
+                messages.append({"role": "user", "content": f"The previous response was invalid. Error: {str(e)}. Please correct the JSON to match the schema exactly."})
+            
+            except Exception as e:
+                logger.error(f"LLM decomposition error: {e}")
+                raise
+        
+        logger.error(f"Failed to decompose goal after {max_retries} attempts")
+        raise last_error
+    
+    def _schedule_text(self, preferences: Dict[str, Any] = None) -> str:
+        """Describe the learner's study schedule so sessions fit it"""
+        schedule = {k: v for k, v in (preferences or {}).items() if k in ("timezone", "study_days", "study_times") and v}
+        if not schedule:
+            return ""
+        days = ", ".join(schedule.get("study_days") or []) or "any day"
+        times = ", ".join(schedule.get("study_times") or []) or "any time"
+        timezone = schedule.get("timezone") or "their local time"
+        return f"\nSTUDY SCHEDULE: {days} at {times} ({timezone}); size milestones so each fits a whole number of these sessions\n"
+    
     def _parse_and_validate_response(self, response_text: str) -> Dict[str, Any]:
         """Parse LLM response text and validate against schema"""
         
-        # Extract JSON from response (handle markdown code blocks if present)
-        json_match = re.search(r'```json\s*(.*?)\s*```', response_text, re.DOTALL)
-        if json_match:
-            json_text = json_match.group(1)
-        else:
-            # Try to find JSON object directly
-            json_match = re.search(r'\{.*\}', response_text, re.DOTALL)
-            json_text = json_match.group(0) if json_match else response_text
-        
         # Parse JSON (will raise JSONDecodeError if invalid)
-        data = json.loads(json_text)
+        data = json.loads(self._extract_json(response_text))
         
         # Validate against Pydantic model (will raise ValidationError if invalid)
         validated_model = LLMPlanResponse(**data)
         
         return validated_model.model_dump()
 
+    def _extract_json(self, response_text: str) -> str:
+        """Extract JSON from response (handle markdown code blocks if present)"""
+        json_match = re.search(r'```json\s*(.*?)\s*```', response_text, re.DOTALL)
+        if json_match:
+            return json_match.group(1)
+        # Try to find JSON object directly
+        json_match = re.search(r'\{.*\}', response_text, re.DOTALL)
+        return json_match.group(0) if json_match else response_text
+
     def _enrich_plan_data(self, plan_data: Dict[str, Any], available_resources: List[Dict[str, Any]]) -> Dict[str, Any]:
         """Enrich validated plan data with full resource details"""
         # Enrich with full resource data
@@ -217,7 +332,8 @@ Ensure the total duration of selected resources fits within {time_budget} hours.
                         'url': full_resource['url'],
                         'duration_min': full_resource.get('duration_min') or 0,
                         'level': full_resource.get('level'),
-                        'skills': full_resource.get('skills', [])
+                        'skills': full_resource.get('skills', []),
+                        'license': full_resource.get('license')
                     })
         return plan_data
     
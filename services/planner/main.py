@@ -8,6 +8,7 @@ import os
 from contextlib import asynccontextmanager
 from fastapi import FastAPI, HTTPException
 from fastapi.middleware.cors import CORSMiddleware
+from fastapi.routing import APIRoute
 import httpx
 
 # OpenTelemetry Imports
@@ -21,7 +22,8 @@ from opentelemetry.instrumentation.fastapi import FastAPIInstrumentor
 from config import get_settings
 from models import (
     PlanRequest, PlanResponse, Milestone, ResourceItem,
-    ReplanRequest, ReplanResponse,
+    ReplanRequest, ReplanResponse, PlanStatusUpdate, PlanTransfer,
+    DecomposeRequest, DecomposeResponse, SubGoal,
     HealthResponse
 )
 from llm_client import get_llm_client
@@ -149,7 +151,8 @@ async def get_plan(plan_id: str):
                     level=res_data.get('level'),
                     skills=res_data.get('skills', []),
                     why_included=res_data.get('why_included', 'Relevant to milestone'),
-                    order=j + 1
+                    order=j + 1,
+                    license=res_data.get('license')
                 ))
             
             calculated_total_hours += milestone_hours
@@ -171,7 +174,8 @@ async def get_plan(plan_id: str):
             estimated_weeks=plan_data.get('estimated_weeks', 1),
             milestones=milestones,
             prerequisites_met=True,
-            reasoning=stored_plan.get('reasoning', 'Learning plan retrieved successfully')
+            reasoning=stored_plan.get('reasoning', 'Learning plan retrieved successfully'),
+            status=plan_data.get('status') or 'active'
         )
     
     except HTTPException:
@@ -181,6 +185,54 @@ async def get_plan(plan_id: str):
         raise HTTPException(status_code=500, detail=str(e))
 
 
+@app.patch("/plan/{plan_id}")
+async def update_plan_status(plan_id: str, request: PlanStatusUpdate):
+    """
+    Change a plan's status, e.g. to draft when its quizzes could not be generated
+    """
+    try:
+        if not get_db_client().set_plan_status(plan_id, request.status):
+            raise HTTPException(status_code=404, detail="Plan not found")
+        return {"plan_id": plan_id, "status": request.status}
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Error updating plan status: {e}")
+        raise HTTPException(status_code=500, detail=str(e))
+
+
+@app.delete("/plan/{plan_id}", status_code=204)
+async def delete_plan(plan_id: str):
+    """
+    Delete a plan, e.g. one whose quizzes could not be generated
+    """
+    try:
+        if not get_db_client().delete_plan(plan_id):
+            raise HTTPException(status_code=404, detail="Plan not found")
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Error deleting plan: {e}")
+        raise HTTPException(status_code=500, detail=str(e))
+
+
+def diversify(resources: list, diversity) -> list:
+    """Keep resources in rank order, skipping any whose provider or media type has reached its cap"""
+    providers, media_types, kept = {}, {}, []
+    for r in resources:
+        provider, media_type = r.get('provider'), r.get('media_type')
+        if provider and diversity.max_per_provider and providers.get(provider, 0) >= diversity.max_per_provider:
+            continue
+        if media_type and diversity.max_per_media_type and media_types.get(media_type, 0) >= diversity.max_per_media_type:
+            continue
+        if provider:
+            providers[provider] = providers.get(provider, 0) + 1
+        if media_type:
+            media_types[media_type] = media_types.get(media_type, 0) + 1
+        kept.append(r)
+    return kept
+
+
 @app.post("/plan", response_model=PlanResponse)
 async def generate_plan(request: PlanRequest):
     """
@@ -194,20 +246,32 @@ async def generate_plan(request: PlanRequest):
         current_skill_names = db_client.get_skill_names(request.current_skills)
         
         # Search for relevant resources via RAG service
+        search_filters = {}
+        if request.preferences.licenses:
+            search_filters["licenses"] = request.preferences.licenses
+        if request.preferences.free_only:
+            search_filters["exclude_licenses"] = [l.strip() for l in settings.paid_licenses.split(",") if l.strip()]
+        if request.resource_ids:
+            search_filters["resource_ids"] = request.resource_ids
+        diversity = request.preferences.diversity
         async with httpx.AsyncClient() as client:
             search_response = await client.post(
                 f"{settings.rag_service_url}/search",
                 json={
-                    "query": request.goal,
-                    "top_k": 30,
+                    "query": f"{request.goal}: {'; '.join(request.sub_goals)}" if request.sub_goals else request.goal,
+                    "top_k": 50 if diversity else 30,  # Oversample when some will be dropped
                     "rerank": False,  # Disabled for now due to model loading time
-                    "rerank_top_n": 20
+                    "rerank_top_n": 20,
+                    "filters": search_filters or None,
+                    "tenant_id": request.tenant_id or "global"
                 },
                 timeout=60.0  # Increased timeout for model loading
             )
             search_response.raise_for_status()
             search_data = search_response.json()
             available_resources = search_data.get('results', [])
+        if diversity:
+            available_resources = diversify(available_resources, diversity)[:30]
         
         if not available_resources:
             raise HTTPException(
@@ -222,7 +286,9 @@ async def generate_plan(request: PlanRequest):
             available_resources=available_resources,
             time_budget_hours=request.time_budget_hours,
             hours_per_week=request.hours_per_week,
-            preferences=request.preferences
+            preferences=request.preferences.model_dump(exclude_defaults=True),
+            language=request.language,
+            sub_goals=request.sub_goals
         )
         
         # Calculate totals
@@ -249,7 +315,8 @@ async def generate_plan(request: PlanRequest):
                     level=res_data.get('level'),
                     skills=res_data.get('skills', []),
                     why_included=res_data.get('why_included', 'Relevant to milestone'),
-                    order=j + 1
+                    order=j + 1,
+                    license=res_data.get('license')
                 ))
             
             total_hours += milestone_hours
@@ -294,6 +361,34 @@ async def generate_plan(request: PlanRequest):
         raise HTTPException(status_code=500, detail=str(e))
 
 
+@app.post("/decompose", response_model=DecomposeResponse)
+async def decompose_goal(request: DecomposeRequest):
+    """
+    Break a broad goal into sub-goals the learner can pick from before planning
+    """
+    try:
+        db_client = get_db_client()
+        llm_client = get_llm_client()
+        
+        current_skill_names = db_client.get_skill_names(request.current_skills)
+        sub_goals = [SubGoal(**s) for s in llm_client.decompose_goal(
+            goal=request.goal,
+            current_skills=current_skill_names,
+            max_sub_goals=request.max_sub_goals,
+            language=request.language
+        )]
+        
+        return DecomposeResponse(
+            goal=request.goal,
+            sub_goals=sub_goals,
+            total_estimated_hours=round(sum(s.estimated_hours for s in sub_goals), 2)
+        )
+    
+    except Exception as e:
+        logger.error(f"Goal decomposition error: {e}")
+        raise HTTPException(status_code=500, detail=str(e))
+
+
 @app.get("/user/{user_id}/plans")
 async def get_user_plans(user_id: str):
     """
@@ -313,6 +408,102 @@ async def get_user_plans(user_id: str):
         raise HTTPException(status_code=500, detail=str(e))
 
 
+@app.post("/user/{user_id}/plans/transfer")
+async def transfer_user_plans(user_id: str, request: PlanTransfer):
+    """
+    Move all of a user's plans to another user
+    """
+    try:
+        transferred = get_db_client().transfer_plans(user_id, request.to_user_id)
+        return {
+            "user_id": request.to_user_id,
+            "transferred": transferred
+        }
+    except Exception as e:
+        logger.error(f"Error transferring user plans: {e}")
+        raise HTTPException(status_code=500, detail=str(e))
+
+
+def replan_milestones(plan_data: dict, request: ReplanRequest) -> list:
+    """Drop completed resources from a stored plan and insert the review milestone, if any"""
+    milestones = []
+    
+    for i, milestone_data in enumerate(plan_data.get('milestones', [])):
+        resources = []
+        
+        for j, res_data in enumerate(milestone_data.get('resources', [])):
+            # Skip completed resources
+            if res_data['resource_id'] in request.completed_resources:
+                continue
+            
+            resources.append(ResourceItem(
+                resource_id=res_data['resource_id'],
+                title=res_data.get('title', 'Unknown'),
+                url=res_data.get('url', ''),
+                duration_min=res_data.get('duration_min', 0),
+                level=res_data.get('level'),
+                skills=res_data.get('skills', []),
+                why_included=res_data.get('why_included', 'Relevant to milestone'),
+                order=len(resources) + 1,
+                license=res_data.get('license')
+            ))
+        
+        if resources:  # Only include milestones with remaining resources
+            milestones.append(Milestone(
+                milestone_id=milestone_data.get('milestone_id') or str(uuid.uuid4()),
+                title=milestone_data.get('title', f'Milestone {i+1}'),
+                description=milestone_data.get('description', ''),
+                resources=resources,
+                estimated_hours=milestone_data.get('estimated_hours', 0),
+                skills_gained=milestone_data.get('skills_gained', []),
+                order=i + 1
+            ))
+    
+    # Review comes right after the milestone the failed quiz covered, so
+    # the learner revisits it before advancing
+    review = [
+        ResourceItem(
+            resource_id=res_data['resource_id'],
+            title=res_data.get('title', 'Unknown'),
+            url=res_data.get('url', ''),
+            duration_min=res_data.get('duration_min', 0),
+            level=res_data.get('level'),
+            skills=res_data.get('skills', []),
+            why_included="Review: a quiz showed this material needs another look",
+            order=n + 1,
+            license=res_data.get('license')
+        )
+        for n, res_data in enumerate(
+            r for m in plan_data.get('milestones', []) for r in m.get('resources', [])
+            if r['resource_id'] in request.review_resources
+        )
+    ]
+    if review:
+        at = next((i + 1 for i, m in enumerate(milestones) if m.milestone_id == request.review_after_milestone_id), 0)
+        topics = ", ".join(request.weak_skills) or "this material"
+        milestones.insert(at, Milestone(
+            milestone_id=str(uuid.uuid4()),
+            title="Review",
+            description=f"Revisit {topics} before moving on.",
+            resources=review,
+            estimated_hours=round(sum(r.duration_min for r in review) / 60, 2),
+            skills_gained=request.weak_skills,
+            order=at + 1
+        ))
+    
+    for i, milestone in enumerate(milestones):
+        milestone.order = i + 1
+    return milestones
+
+
+def replan_changes(request: ReplanRequest) -> str:
+    """Describe what a replan changed"""
+    changes = f"Removed {len(request.completed_resources)} completed resources"
+    if request.review_resources:
+        changes += f"; added {len(request.review_resources)} resources to review"
+    return changes
+
+
 @app.post("/replan", response_model=ReplanResponse)
 async def replan(request: ReplanRequest):
     """
@@ -326,41 +517,7 @@ async def replan(request: ReplanRequest):
         if not existing_plan:
             raise HTTPException(status_code=404, detail="Plan not found")
         
-        # TODO: Implement replanning logic
-        # For now, return the existing plan with a message
-        
-        plan_data = existing_plan['plan_data']
-        milestones = []
-        
-        for i, milestone_data in enumerate(plan_data.get('milestones', [])):
-            resources = []
-            
-            for j, res_data in enumerate(milestone_data.get('resources', [])):
-                # Skip completed resources
-                if res_data['resource_id'] in request.completed_resources:
-                    continue
-                
-                resources.append(ResourceItem(
-                    resource_id=res_data['resource_id'],
-                    title=res_data.get('title', 'Unknown'),
-                    url=res_data.get('url', ''),
-                    duration_min=res_data.get('duration_min', 0),
-                    level=res_data.get('level'),
-                    skills=res_data.get('skills', []),
-                    why_included=res_data.get('why_included', 'Relevant to milestone'),
-                    order=j + 1
-                ))
-            
-            if resources:  # Only include milestones with remaining resources
-                milestones.append(Milestone(
-                    milestone_id=str(uuid.uuid4()),
-                    title=milestone_data.get('title', f'Milestone {i+1}'),
-                    description=milestone_data.get('description', ''),
-                    resources=resources,
-                    estimated_hours=milestone_data.get('estimated_hours', 0),
-                    skills_gained=milestone_data.get('skills_gained', []),
-                    order=i + 1
-                ))
+        milestones = replan_milestones(existing_plan['plan_data'], request)
         
         # Recalculate totals
         total_hours = sum(m.estimated_hours for m in milestones)
@@ -371,14 +528,78 @@ async def replan(request: ReplanRequest):
             updated_milestones=milestones,
             total_hours=round(total_hours, 2),
             estimated_weeks=estimated_weeks,
-            changes_made=f"Removed {len(request.completed_resources)} completed resources"
+            changes_made=replan_changes(request)
         )
     
+    except HTTPException:
+        raise
     except Exception as e:
         logger.error(f"Replan error: {e}")
         raise HTTPException(status_code=500, detail=str(e))
 
 
+@app.post("/plan/{plan_id}/replan", response_model=PlanResponse)
+async def replan_plan(plan_id: str, request: ReplanRequest):
+    """
+    Replan a stored plan and save the result, returning the updated plan
+    """
+    try:
+        db_client = get_db_client()
+        request.plan_id = plan_id
+        
+        existing_plan = db_client.get_plan(plan_id)
+        if not existing_plan:
+            raise HTTPException(status_code=404, detail="Plan not found")
+        
+        plan_data = existing_plan['plan_data']
+        milestones = replan_milestones(plan_data, request)
+        total_hours = round(sum(m.estimated_hours for m in milestones), 2)
+        estimated_weeks = max(1, int(total_hours / 10))  # Assume 10 hours/week default
+        
+        db_client.update_plan(
+            plan_id=plan_id,
+            plan_data={**plan_data, 'milestones': [m.model_dump() for m in milestones]},
+            total_hours=total_hours,
+            estimated_weeks=estimated_weeks
+        )
+        
+        return PlanResponse(
+            plan_id=plan_id,
+            goal=existing_plan['goal'],
+            total_hours=total_hours,
+            estimated_weeks=estimated_weeks,
+            milestones=milestones,
+            prerequisites_met=True,
+            reasoning=replan_changes(request)
+        )
+    
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Replan error: {e}")
+        raise HTTPException(status_code=500, detail=str(e))
+
+
+def schema_fields(model):
+    """Wire names of a request model's fields, and which are required"""
+    names = {name: field.alias or name for name, field in model.model_fields.items()}
+    return {
+        "fields": sorted(names.values()),
+        "required": sorted(names[name] for name, field in model.model_fields.items() if field.is_required()),
+    }
+
+
+@app.get("/version")
+async def version():
+    """Version, routes and request schemas, for the gateway's compatibility check"""
+    return {
+        "service": settings.service_name,
+        "version": "1.0.0",
+        "routes": sorted({route.path for route in app.routes if isinstance(route, APIRoute)}),
+        "schemas": {model.__name__: schema_fields(model) for model in (PlanRequest, ReplanRequest, DecomposeRequest, PlanTransfer)},
+    }
+
+
 @app.get("/")
 async def root():
     """Root endpoint"""
@@ -2,18 +2,43 @@
 Pydantic models for Planner service
 """
 from pydantic import BaseModel, Field
-from typing import List, Optional
+from typing import List, Literal, Optional
 from datetime import datetime
 
 
+class Diversity(BaseModel):
+    """Caps on resources sharing a provider or media type, resolved by the gateway"""
+    max_per_provider: Optional[int] = Field(None, gt=0)
+    max_per_media_type: Optional[int] = Field(None, gt=0)
+
+
+class Preferences(BaseModel):
+    """Learner preferences, validated by the gateway"""
+    media_types: List[str] = Field(default=[], description="Preferred media types, e.g. video, article")
+    providers: List[str] = Field(default=[], description="Preferred resource providers")
+    max_resource_duration: Optional[int] = Field(None, gt=0, description="Longest resource to include, in minutes")
+    language: Optional[str] = Field(None, description="BCP 47 tag of the preferred language")
+    budget: Optional[float] = Field(None, ge=0, description="USD to spend on paid resources")
+    licenses: List[str] = Field(default=[], description="Only include resources under these licenses")
+    free_only: bool = Field(False, description="Only include free resources")
+    diversity: Optional[Diversity] = Field(None, description="Caps on resources per provider and media type")
+    timezone: Optional[str] = Field(None, description="IANA time zone, e.g. Europe/Berlin")
+    study_days: List[str] = Field(default=[], description="Preferred days: mon ... sun")
+    study_times: List[str] = Field(default=[], description="Session start times, HH:MM local")
+
+
 class PlanRequest(BaseModel):
     """Request to generate a learning plan"""
     goal: str = Field(..., min_length=1, description="Learning goal description")
     current_skills: List[str] = Field(default=[], description="Current skill UUIDs")
     time_budget_hours: int = Field(..., gt=0, le=1000, description="Total time budget in hours")
     hours_per_week: int = Field(..., gt=0, le=168, description="Hours available per week")
-    preferences: Optional[dict] = Field(None, description="Learning preferences (media types, providers, etc.)")
+    preferences: Preferences = Field(default_factory=Preferences, description="Learning preferences")
     user_id: Optional[str] = Field(None, description="User ID for plan ownership")
+    language: Optional[str] = Field(None, description="BCP 47 tag of the language to write the plan in")
+    resource_ids: List[str] = Field(default=[], description="Only plan with these resources, e.g. ones just ingested")
+    tenant_id: Optional[str] = Field(None, description="Tenant whose ingested content may be searched besides the global corpus")
+    sub_goals: List[str] = Field(default=[], description="Parts of the goal to plan for, picked from its decomposition")
 
 
 class ResourceItem(BaseModel):
@@ -26,6 +51,7 @@ class ResourceItem(BaseModel):
     skills: List[str] = []
     why_included: str = Field(..., description="Explanation of why this resource is included")
     order: int = Field(..., description="Order in the learning path")
+    license: Optional[str] = None
 
 
 class Milestone(BaseModel):
@@ -48,15 +74,29 @@ class PlanResponse(BaseModel):
     milestones: List[Milestone]
     prerequisites_met: bool
     reasoning: str = Field(..., description="Explanation of the plan structure")
+    status: str = Field("active", description="active, or draft when kept after its quizzes failed")
+
+
+class PlanStatusUpdate(BaseModel):
+    """Request to change a plan's status"""
+    status: Literal["active", "draft"]
+
+
+class PlanTransfer(BaseModel):
+    """Request to move a user's plans to another user, e.g. a guest's after sign-up"""
+    to_user_id: str = Field(..., min_length=1)
 
 
 class ReplanRequest(BaseModel):
     """Request to replan based on progress"""
-    plan_id: str
+    plan_id: Optional[str] = Field(None, description="Taken from the path on /plan/{plan_id}/replan")
     completed_resources: List[str] = Field(..., description="List of completed resource IDs")
     time_spent_hours: float = Field(..., ge=0, description="Time spent so far")
     remaining_time_hours: Optional[float] = Field(None, ge=0, description="Remaining time budget")
     feedback: Optional[str] = Field(None, description="User feedback on difficulty/pace")
+    review_resources: List[str] = Field(default_factory=list, description="Resources to review after a failed quiz")
+    weak_skills: List[str] = Field(default_factory=list, description="Skills the failed quiz showed are weak")
+    review_after_milestone_id: Optional[str] = Field(None, description="Milestone the review follows; the start of the plan if unset")
 
 
 class ReplanResponse(BaseModel):
@@ -68,6 +108,30 @@ class ReplanResponse(BaseModel):
     changes_made: str = Field(..., description="Explanation of changes")
 
 
+class DecomposeRequest(BaseModel):
+    """Request to break a broad goal into sub-goals"""
+    goal: str = Field(..., min_length=1, description="Broad learning goal")
+    current_skills: List[str] = Field(default=[], description="Current skill UUIDs")
+    max_sub_goals: Optional[int] = Field(None, ge=2, le=10, description="Most sub-goals to suggest")
+    language: Optional[str] = Field(None, description="BCP 47 tag of the language to write the sub-goals in")
+
+
+class SubGoal(BaseModel):
+    """One suggested part of a broad goal"""
+    title: str
+    description: str
+    estimated_hours: float = Field(..., ge=0)
+    skills: List[str] = []
+    order: int
+
+
+class DecomposeResponse(BaseModel):
+    """A goal broken into sub-goals, in learning order"""
+    goal: str
+    sub_goals: List[SubGoal]
+    total_estimated_hours: float
+
+
 class HealthResponse(BaseModel):
     """Health check response"""
     status: str
@@ -0,0 +1,65 @@
+"""
+Question calibration reported by the gateway, used to adjust the difficulty
+of new quizzes on the same resources
+"""
+from threading import Lock
+from typing import Dict, List, Optional, Tuple
+
+from models import CalibrationRequest
+
+LEVELS = ["easy", "medium", "hard"]
+
+# Answers on a tenant's resources needed before difficulty is adjusted
+MIN_ANSWERS = 20
+
+# Correct rates outside which generated quizzes move a level up or down
+EASY_ABOVE = 0.8
+HARD_BELOW = 0.4
+
+
+class CalibrationStore:
+    """Latest per-question totals, by tenant and resource. Totals replace
+    earlier ones, so a restart loses nothing the next report won't restore."""
+
+    def __init__(self):
+        self._lock = Lock()
+        self._questions: Dict[Tuple[str, str], Dict[str, Tuple[int, int]]] = {}
+
+    def update(self, request: CalibrationRequest) -> int:
+        """Store reported totals; returns how many questions were stored"""
+        stored = 0
+        with self._lock:
+            for q in request.questions:
+                if not q.resource_id:
+                    continue
+                key = (request.tenant_id, q.resource_id)
+                self._questions.setdefault(key, {})[f"{q.quiz_id}/{q.question_id}"] = (q.answered, q.correct)
+                stored += 1
+        return stored
+
+    def correct_rate(self, tenant_id: str, resource_ids: List[str]) -> Tuple[Optional[float], int]:
+        """Correct rate across the questions drawn from resources, and the answers it is based on"""
+        answered = correct = 0
+        with self._lock:
+            for resource_id in resource_ids:
+                for a, c in self._questions.get((tenant_id, resource_id), {}).values():
+                    answered += a
+                    correct += c
+        if answered == 0:
+            return None, 0
+        return correct / answered, answered
+
+    def difficulty(self, requested: Optional[str], tenant_id: str, resource_ids: List[str]) -> Optional[str]:
+        """Move the requested difficulty a level when learners find these resources' questions too easy or hard"""
+        rate, answered = self.correct_rate(tenant_id, resource_ids)
+        if rate is None or answered < MIN_ANSWERS or requested not in LEVELS:
+            return requested
+        level = LEVELS.index(requested)
+        if rate > EASY_ABOVE:
+            level = min(level + 1, len(LEVELS) - 1)
+        elif rate < HARD_BELOW:
+            level = max(level - 1, 0)
+        return LEVELS[level]
+
+
+calibration_store = CalibrationStore()
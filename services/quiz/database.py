@@ -85,16 +85,22 @@ class DatabaseClient:
         self,
         quiz_id: str,
         resource_ids: List[str],
-        questions: List[Dict[str, Any]]
+        questions: List[Dict[str, Any]],
+        source: Optional[Dict[str, Any]] = None
     ):
-        """Save quiz to database"""
+        """Save quiz to database
+        
+        source marks quizzes reusing earlier questions (variant_of or
+        composed); the question bank lists only generated ones.
+        """
         try:
             self.ensure_connection()
             with self.conn.cursor() as cur:
                 # Store in original quiz table with items JSONB field
                 quiz_data = {
                     'resource_ids': resource_ids,
-                    'questions': questions
+                    'questions': questions,
+                    **(source or {})
                 }
                 cur.execute("""
                     INSERT INTO quiz (id, lesson_id, items, created_at)
@@ -124,6 +130,7 @@ class DatabaseClient:
                     # Extract questions from items JSONB
                     return {
                         'quiz_id': str(result['id']),
+                        'resource_ids': result['items'].get('resource_ids', []),
                         'questions': result['items'].get('questions', [])
                     }
                 return None
@@ -131,6 +138,33 @@ class DatabaseClient:
             logger.error(f"Error fetching quiz: {e}")
             return None
     
+    def list_questions(
+        self,
+        resource_id: Optional[str] = None,
+        skill: Optional[str] = None,
+        limit: int = 50
+    ) -> List[Dict[str, Any]]:
+        """List questions of generated quizzes, newest first
+        
+        skill is a skill slug or ID of the question's resource.
+        """
+        self.ensure_connection()
+        with self.conn.cursor() as cur:
+            cur.execute("""
+                SELECT q.id::text AS quiz_id, q.created_at, question
+                FROM quiz q, jsonb_array_elements(q.items->'questions') AS question
+                WHERE NOT (q.items ? 'variant_of' OR q.items ? 'composed')
+                  AND (%(resource_id)s::text IS NULL OR question->>'source_resource_id' = %(resource_id)s)
+                  AND (%(skill)s::text IS NULL OR question->>'source_resource_id' IN (
+                      SELECT r.id::text FROM resource r
+                      JOIN skill s ON s.id = ANY(r.skills)
+                      WHERE s.slug = %(skill)s OR s.id::text = %(skill)s
+                  ))
+                ORDER BY q.created_at DESC
+                LIMIT %(limit)s
+            """, {'resource_id': resource_id, 'skill': skill, 'limit': limit})
+            return [dict(row) for row in cur.fetchall()]
+    
     def save_quiz_attempt(
         self,
         quiz_id: str,
@@ -4,7 +4,7 @@ LLM client for quiz generation using OpenRouter
 import logging
 import json
 import re
-from typing import List, Dict, Any
+from typing import List, Dict, Any, Optional
 from openai import OpenAI
 from pydantic import BaseModel, Field, ValidationError
 from config import get_settings
@@ -18,9 +18,11 @@ class LLMQuizOption(BaseModel):
     text: str
 
 class LLMQuizQuestion(BaseModel):
+    question_type: str = "multiple_choice"
     question_text: str
-    options: List[LLMQuizOption]
-    correct_option: str
+    options: List[LLMQuizOption] = []
+    correct_option: Optional[str] = None
+    reference_answer: Optional[str] = None
     explanation: str
     source_resource_id: str
     citation: str
@@ -28,6 +30,11 @@ class LLMQuizQuestion(BaseModel):
 class LLMQuizResponse(BaseModel):
     questions: List[LLMQuizQuestion]
 
+class LLMGrade(BaseModel):
+    correct: bool
+    score: float = Field(..., ge=0, le=1)
+    feedback: str
+
 # ------------------------------------------
 
 class LLMClient:
@@ -44,7 +51,9 @@ class LLMClient:
         self,
         resource_snippets: List[Dict[str, Any]],
         num_questions: int = 5,
-        difficulty: str = None
+        difficulty: str = None,
+        language: str = None,
+        short_answer_questions: int = 0
     ) -> List[Dict[str, Any]]:
         """
         Generate quiz questions from resource snippets
@@ -53,11 +62,13 @@ class LLMClient:
             resource_snippets: List of resource snippets with content
             num_questions: Number of questions to generate
             difficulty: Difficulty level (easy, medium, hard)
+            language: BCP 47 tag of the language to write the questions in
+            short_answer_questions: How many of the questions are short-answer
             
         Returns:
             List of quiz questions with citations
         """
-        initial_prompt = self._build_quiz_prompt(resource_snippets, num_questions, difficulty)
+        initial_prompt = self._build_quiz_prompt(resource_snippets, num_questions, difficulty, language, short_answer_questions)
         
         messages = [
             {
@@ -114,7 +125,9 @@ class LLMClient:
         self,
         snippets: List[Dict[str, Any]],
         num_questions: int,
-        difficulty: str = None
+        difficulty: str = None,
+        language: str = None,
+        short_answer_questions: int = 0
     ) -> str:
         """Build prompt for quiz generation"""
         
@@ -124,8 +137,17 @@ class LLMClient:
         ])
         
         difficulty_instruction = f"\nDifficulty level: {difficulty}" if difficulty else ""
+        language_instruction = f"\n6. Write questions, options and explanations in the language with BCP 47 tag '{language}'; quote citations in their original language" if language and language != "en" else ""
+        short_answer_instruction = ""
+        if short_answer_questions:
+            step = 7 if language_instruction else 6
+            short_answer_instruction = (
+                f"\n{step}. Make exactly {short_answer_questions} of the questions short-answer instead: set \"question_type\" to \"short_answer\", "
+                "leave \"options\" empty and \"correct_option\" null, and give a model answer of one to three sentences in \"reference_answer\""
+            )
+        question_kind = "" if short_answer_questions else "multiple-choice "
         
-        prompt = f"""Generate {num_questions} multiple-choice quiz questions based on the following learning resources.
+        prompt = f"""Generate {num_questions} {question_kind}quiz questions based on the following learning resources.
 
 RESOURCES:
 {snippets_text}
@@ -134,13 +156,14 @@ REQUIREMENTS:
 1. Each question must have 4 options (A, B, C, D)
 2. Only ONE option should be correct
 3. Include a clear explanation for the correct answer
-4. CRITICAL: Include a specific citation (quote or reference) from the source material
-5. Questions should test understanding, not just memorization{difficulty_instruction}
+4. CRITICAL: Include a specific citation (quote or reference) from the source material; for video transcripts, begin it with the [mm:ss] timestamp of the quoted line
+5. Questions should test understanding, not just memorization{language_instruction}{short_answer_instruction}{difficulty_instruction}
 
 Format your response as strictly valid JSON with this exact structure:
 {{
   "questions": [
     {{
+      "question_type": "multiple_choice",
       "question_text": "What is...",
       "options": [
         {{"id": "A", "text": "Option A"}},
@@ -160,8 +183,80 @@ Generate exactly {num_questions} questions. Do not wrap the JSON in markdown cod
 """
         return prompt
     
+    def grade_short_answer(
+        self,
+        question_text: str,
+        reference_answer: str,
+        answer_text: str,
+        citation: str = None
+    ) -> Dict[str, Any]:
+        """
+        Grade a learner's short answer against the question's reference answer
+        
+        Returns:
+            Dict with correct, score (0-1) and feedback
+        """
+        citation_line = f"\nSOURCE: {citation}" if citation else ""
+        prompt = f"""Grade the learner's answer to this quiz question.
+
+QUESTION: {question_text}
+REFERENCE ANSWER: {reference_answer}{citation_line}
+LEARNER'S ANSWER: {answer_text}
+
+Judge whether the learner's answer shows the understanding the reference answer does; wording may differ.
+Respond with strictly valid JSON: {{"correct": true, "score": 0.0-1.0, "feedback": "One or two sentences for the learner"}}
+Write the feedback in the language of the question. Do not wrap the JSON in markdown code blocks.
+"""
+        response = self.client.chat.completions.create(
+            model=self.settings.default_model,
+            messages=[
+                {"role": "system", "content": "You are a fair, consistent grader of short quiz answers."},
+                {"role": "user", "content": prompt}
+            ],
+            temperature=0,
+            max_tokens=300,
+        )
+        grade = LLMGrade(**json.loads(self._extract_json(response.choices[0].message.content)))
+        return grade.model_dump()
+    
+    def paraphrase_questions(self, question_texts: List[str], language: str = None) -> List[str]:
+        """
+        Reword quiz questions for a retake without changing what they ask
+        
+        Returns:
+            The paraphrased questions, in the same order
+        """
+        language_instruction = f"Write each in the language with BCP 47 tag '{language}'." if language else "Write each in the language it is in."
+        numbered = "\n".join(f"{i + 1}. {text}" for i, text in enumerate(question_texts))
+        prompt = f"""Paraphrase each of these quiz questions for a learner retaking the quiz.
+
+QUESTIONS:
+{numbered}
+
+Keep each question's meaning, answer and difficulty; change only the wording. {language_instruction}
+Respond with strictly valid JSON: {{"questions": ["Paraphrased question 1", "..."]}} with exactly {len(question_texts)} entries, in order.
+Do not wrap the JSON in markdown code blocks.
+"""
+        response = self.client.chat.completions.create(
+            model=self.settings.default_model,
+            messages=[
+                {"role": "system", "content": "You are an expert educational content creator."},
+                {"role": "user", "content": prompt}
+            ],
+            temperature=0.7,
+            max_tokens=2000,
+        )
+        paraphrased = json.loads(self._extract_json(response.choices[0].message.content)).get("questions", [])
+        if len(paraphrased) != len(question_texts) or not all(isinstance(p, str) and p.strip() for p in paraphrased):
+            raise ValueError("LLM returned the wrong number of paraphrased questions")
+        return paraphrased
+    
     def _parse_and_validate_response(self, response_text: str) -> LLMQuizResponse:
         """Parse LLM response into structured quiz questions"""
+        return LLMQuizResponse(**json.loads(self._extract_json(response_text)))
+    
+    def _extract_json(self, response_text: str) -> str:
+        """Extract the JSON object from an LLM response"""
         
         if not response_text or not response_text.strip():
             raise ValueError("Empty response from LLM")
@@ -173,12 +268,7 @@ Generate exactly {num_questions} questions. Do not wrap the JSON in markdown cod
         else:
             json_match = re.search(r'\{.*\}', response_text, re.DOTALL)
             json_text = json_match.group(0) if json_match else response_text
-        
-        # Parse JSON
-        data = json.loads(json_text)
-        
-        # Validate against Pydantic model
-        return LLMQuizResponse(**data)
+        return json_text
     
     def health_check(self) -> bool:
         """Check if LLM service is available"""
@@ -3,11 +3,14 @@ Quiz Service - FastAPI application
 Handles quiz generation and grading with 100% citation requirement
 """
 import logging
+import random
 import uuid
 import os
 from contextlib import asynccontextmanager
-from fastapi import FastAPI, HTTPException
+from typing import Optional
+from fastapi import FastAPI, HTTPException, Query
 from fastapi.middleware.cors import CORSMiddleware
+from fastapi.routing import APIRoute
 
 # OpenTelemetry Imports
 from opentelemetry import trace
@@ -20,8 +23,11 @@ from opentelemetry.instrumentation.fastapi import FastAPIInstrumentor
 from config import get_settings
 from models import (
     QuizGenerateRequest, QuizResponse, QuizSubmitRequest, QuizSubmitResponse,
-    QuizOption, QuizQuestion, QuestionResult, HealthResponse
+    QuizOption, QuizQuestion, QuestionResult, HealthResponse, CalibrationRequest,
+    GradeRequest, GradeResponse, RetakeRequest, BankQuestion, QuestionBankResponse,
+    ComposeRequest
 )
+from calibration import calibration_store
 from llm_client import get_llm_client
 from database import get_db_client
 from s3_client import get_s3_client
@@ -165,11 +171,16 @@ async def generate_quiz(request: QuizGenerateRequest):
                 detail="No content available for quiz generation"
             )
         
-        # Generate quiz using LLM
+        # Generate quiz using LLM, at a difficulty calibrated by past answers
+        difficulty = calibration_store.difficulty(request.difficulty, request.tenant_id, request.resource_ids)
+        if difficulty != request.difficulty:
+            logger.info(f"Calibrated quiz difficulty from {request.difficulty} to {difficulty}")
         quiz_questions = llm_client.generate_quiz(
             resource_snippets=resource_snippets,
             num_questions=request.num_questions,
-            difficulty=request.difficulty
+            difficulty=difficulty,
+            language=request.language,
+            short_answer_questions=request.short_answer_questions
         )
         
         if not quiz_questions:
@@ -202,12 +213,14 @@ async def generate_quiz(request: QuizGenerateRequest):
                     is_correct=is_correct
                 ))
             
-            # Store correct answer for grading (not exposed in response)
+            # Store correct answer for grading (not exposed in response);
+            # short answers are graded against reference_answer via /grade
             q['correct_option_id'] = correct_option_id
             q['question_id'] = question_id
             
             questions.append(QuizQuestion(
                 question_id=question_id,
+                question_type=q.get('question_type') or 'multiple_choice',
                 question_text=q.get('question_text', ''),
                 options=options,
                 explanation=q.get('explanation', ''),
@@ -242,6 +255,180 @@ async def generate_quiz(request: QuizGenerateRequest):
         raise HTTPException(status_code=500, detail=str(e))
 
 
+@app.post("/retake", response_model=QuizResponse)
+async def retake_quiz(request: RetakeRequest):
+    """
+    Regenerate a quiz as a variant for a retake: the same material with
+    paraphrased questions and shuffled questions and options
+    """
+    try:
+        db_client = get_db_client()
+        llm_client = get_llm_client()
+        
+        quiz = db_client.get_quiz(request.quiz_id)
+        if not quiz:
+            raise HTTPException(status_code=404, detail="Quiz not found")
+        
+        original = quiz['questions']
+        try:
+            texts = llm_client.paraphrase_questions([q.get('question_text', '') for q in original], request.language)
+        except Exception as e:
+            # A retake with the original wording still shuffles
+            logger.warning(f"Paraphrasing quiz {request.quiz_id} failed, keeping its wording: {e}")
+            texts = [q.get('question_text', '') for q in original]
+        
+        quiz_id = str(uuid.uuid4())
+        variant_questions = []
+        questions = []
+        
+        for q, text in random.sample(list(zip(original, texts)), len(original)):
+            # New IDs keep answers to the variant apart from the original's
+            question_id = str(uuid.uuid4())
+            option_ids = {}
+            stored_options = []
+            options = []
+            
+            for opt in random.sample(q.get('options', []), len(q.get('options', []))):
+                option_id = str(uuid.uuid4())
+                option_ids[opt.get('id')] = option_id
+                stored_options.append({**opt, 'id': option_id})
+                options.append(QuizOption(option_id=option_id, text=opt.get('text', '')))
+            
+            correct_option = option_ids.get(q.get('correct_option_id') or q.get('correct_option'))
+            variant_questions.append({
+                **q,
+                'question_id': question_id,
+                'question_text': text,
+                'options': stored_options,
+                'correct_option': correct_option,
+                'correct_option_id': correct_option
+            })
+            questions.append(QuizQuestion(
+                question_id=question_id,
+                question_type=q.get('question_type') or 'multiple_choice',
+                question_text=text,
+                options=options,
+                explanation=q.get('explanation', ''),
+                source_resource_id=q.get('source_resource_id', ''),
+                citation=q.get('citation', 'No citation provided')
+            ))
+        
+        db_client.save_quiz(
+            quiz_id=quiz_id,
+            resource_ids=quiz.get('resource_ids', []),
+            questions=variant_questions,
+            source={'variant_of': request.quiz_id}
+        )
+        
+        return QuizResponse(
+            quiz_id=quiz_id,
+            title="Quiz Retake",
+            questions=questions,
+            total_questions=len(questions),
+            variant_of=request.quiz_id
+        )
+    
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Quiz retake error: {e}")
+        raise HTTPException(status_code=500, detail=str(e))
+
+
+@app.get("/questions", response_model=QuestionBankResponse)
+async def list_questions(
+    resource_id: Optional[str] = None,
+    skill: Optional[str] = None,
+    limit: int = Query(default=50, ge=1, le=200)
+):
+    """
+    Browse the questions of previously generated quizzes, newest first.
+    Answers and explanations are left out.
+    """
+    try:
+        db_client = get_db_client()
+        rows = db_client.list_questions(resource_id=resource_id, skill=skill, limit=limit)
+        return QuestionBankResponse(questions=[
+            BankQuestion(
+                quiz_id=row['quiz_id'],
+                question_id=row['question'].get('question_id', ''),
+                question_type=row['question'].get('question_type') or 'multiple_choice',
+                question_text=row['question'].get('question_text', ''),
+                options=[
+                    QuizOption(option_id=opt.get('id', ''), text=opt.get('text', ''))
+                    for opt in row['question'].get('options', [])
+                ],
+                source_resource_id=row['question'].get('source_resource_id', ''),
+                citation=row['question'].get('citation', ''),
+                created_at=row['created_at']
+            )
+            for row in rows
+        ])
+    
+    except Exception as e:
+        logger.error(f"Question bank error: {e}")
+        raise HTTPException(status_code=500, detail=str(e))
+
+
+@app.post("/compose", response_model=QuizResponse)
+async def compose_quiz(request: ComposeRequest):
+    """
+    Assemble a quiz from questions of earlier quizzes, in the order given,
+    without calling the LLM
+    """
+    try:
+        db_client = get_db_client()
+        
+        quizzes = {}
+        stored_questions = []
+        questions = []
+        for ref in request.questions:
+            if ref.quiz_id not in quizzes:
+                quizzes[ref.quiz_id] = db_client.get_quiz(ref.quiz_id)
+            quiz = quizzes[ref.quiz_id]
+            q = next((q for q in quiz['questions'] if q.get('question_id') == ref.question_id), None) if quiz else None
+            if not q:
+                raise HTTPException(
+                    status_code=404,
+                    detail=f"Question {ref.question_id} of quiz {ref.quiz_id} not found"
+                )
+            
+            # The same question may be composed into several quizzes
+            question_id = str(uuid.uuid4())
+            stored_questions.append({**q, 'question_id': question_id})
+            questions.append(QuizQuestion(
+                question_id=question_id,
+                question_type=q.get('question_type') or 'multiple_choice',
+                question_text=q.get('question_text', ''),
+                options=[QuizOption(option_id=opt.get('id', ''), text=opt.get('text', '')) for opt in q.get('options', [])],
+                explanation=q.get('explanation', ''),
+                source_resource_id=q.get('source_resource_id', ''),
+                citation=q.get('citation', 'No citation provided')
+            ))
+        
+        quiz_id = str(uuid.uuid4())
+        resource_ids = list(dict.fromkeys(q.get('source_resource_id') for q in stored_questions if q.get('source_resource_id')))
+        db_client.save_quiz(
+            quiz_id=quiz_id,
+            resource_ids=resource_ids,
+            questions=stored_questions,
+            source={'composed': True}
+        )
+        
+        return QuizResponse(
+            quiz_id=quiz_id,
+            title=request.title or "Composed Quiz",
+            questions=questions,
+            total_questions=len(questions)
+        )
+    
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Quiz composition error: {e}")
+        raise HTTPException(status_code=500, detail=str(e))
+
+
 @app.post("/submit", response_model=QuizSubmitResponse)
 async def submit_quiz(request: QuizSubmitRequest):
     """
@@ -262,9 +449,29 @@ async def submit_quiz(request: QuizSubmitRequest):
         
         # Create answer map
         answer_map = {a.question_id: a.selected_option_id for a in request.answers}
+        text_map = {a.question_id: a.answer_text for a in request.answers}
+        pending_count = 0
         
         for q in questions:
             question_id = q.get('question_id')
+            
+            # Short answers are graded asynchronously via /grade
+            if q.get('question_type') == 'short_answer':
+                pending_count += 1
+                results.append(QuestionResult(
+                    question_id=question_id,
+                    correct=False,
+                    selected_option_id='',
+                    correct_option_id='',
+                    explanation='',
+                    citation=q.get('citation', ''),
+                    source_resource_id=q.get('source_resource_id'),
+                    question_type='short_answer',
+                    answer_text=text_map.get(question_id) or '',
+                    status='pending'
+                ))
+                continue
+            
             correct_option_id = q.get('correct_option_id')
             selected_option_id = answer_map.get(question_id, '')
             
@@ -278,7 +485,8 @@ async def submit_quiz(request: QuizSubmitRequest):
                 selected_option_id=selected_option_id,
                 correct_option_id=correct_option_id,
                 explanation=q.get('explanation', ''),
-                citation=q.get('citation', '')
+                citation=q.get('citation', ''),
+                source_resource_id=q.get('source_resource_id')
             ))
         
         total_questions = len(questions)
@@ -297,7 +505,8 @@ async def submit_quiz(request: QuizSubmitRequest):
             score=round(score, 2),
             total_questions=total_questions,
             correct_answers=correct_count,
-            results=results
+            results=results,
+            pending_questions=pending_count
         )
     
     except HTTPException:
@@ -307,6 +516,76 @@ async def submit_quiz(request: QuizSubmitRequest):
         raise HTTPException(status_code=500, detail=str(e))
 
 
+@app.post("/grade", response_model=GradeResponse)
+async def grade_answer(request: GradeRequest):
+    """
+    Grade a short answer against the question's reference answer
+    """
+    try:
+        db_client = get_db_client()
+        llm_client = get_llm_client()
+        
+        quiz = db_client.get_quiz(request.quiz_id)
+        if not quiz:
+            raise HTTPException(status_code=404, detail="Quiz not found")
+        
+        question = next((q for q in quiz['questions'] if q.get('question_id') == request.question_id), None)
+        if not question or question.get('question_type') != 'short_answer':
+            raise HTTPException(status_code=404, detail="Short-answer question not found")
+        
+        if not request.answer_text.strip():
+            return GradeResponse(
+                quiz_id=request.quiz_id,
+                question_id=request.question_id,
+                correct=False,
+                score=0,
+                feedback="No answer was given."
+            )
+        
+        grade = llm_client.grade_short_answer(
+            question_text=question.get('question_text', ''),
+            reference_answer=question.get('reference_answer') or question.get('explanation', ''),
+            answer_text=request.answer_text,
+            citation=question.get('citation')
+        )
+        return GradeResponse(quiz_id=request.quiz_id, question_id=request.question_id, **grade)
+    
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Short answer grading error: {e}")
+        raise HTTPException(status_code=500, detail=str(e))
+
+
+@app.post("/calibrate")
+async def calibrate(request: CalibrationRequest):
+    """
+    Store question statistics reported by the gateway
+    """
+    stored = calibration_store.update(request)
+    return {"status": "ok", "questions": stored}
+
+
+def schema_fields(model):
+    """Wire names of a request model's fields, and which are required"""
+    names = {name: field.alias or name for name, field in model.model_fields.items()}
+    return {
+        "fields": sorted(names.values()),
+        "required": sorted(names[name] for name, field in model.model_fields.items() if field.is_required()),
+    }
+
+
+@app.get("/version")
+async def version():
+    """Version, routes and request schemas, for the gateway's compatibility check"""
+    return {
+        "service": settings.service_name,
+        "version": "1.0.0",
+        "routes": sorted({route.path for route in app.routes if isinstance(route, APIRoute)}),
+        "schemas": {model.__name__: schema_fields(model) for model in (QuizGenerateRequest, RetakeRequest, ComposeRequest, QuizSubmitRequest, GradeRequest, CalibrationRequest)},
+    }
+
+
 @app.get("/")
 async def root():
     """Root endpoint"""
@@ -11,6 +11,9 @@ class QuizGenerateRequest(BaseModel):
     resource_ids: List[str] = Field(..., min_length=1, description="Resource IDs to generate quiz from")
     num_questions: int = Field(default=5, ge=1, le=20, description="Number of questions to generate")
     difficulty: Optional[str] = Field(None, description="Difficulty level: easy, medium, hard")
+    language: Optional[str] = Field(None, description="BCP 47 tag of the language to write the questions in")
+    tenant_id: str = Field(default="global", description="Tenant whose calibration applies")
+    short_answer_questions: int = Field(default=0, ge=0, le=20, description="How many of the questions are short-answer")
 
 
 class QuizOption(BaseModel):
@@ -23,8 +26,9 @@ class QuizOption(BaseModel):
 class QuizQuestion(BaseModel):
     """A quiz question"""
     question_id: str
+    question_type: str = Field(default="multiple_choice", description="multiple_choice or short_answer")
     question_text: str
-    options: List[QuizOption]
+    options: List[QuizOption] = Field(default_factory=list, description="Empty for short-answer questions")
     explanation: str = Field(..., description="Explanation of the correct answer")
     source_resource_id: str = Field(..., description="Resource this question is based on")
     citation: str = Field(..., description="Specific citation from the resource")
@@ -36,12 +40,51 @@ class QuizResponse(BaseModel):
     title: Optional[str] = Field(None, description="Quiz title")
     questions: List[QuizQuestion]
     total_questions: int
+    variant_of: Optional[str] = Field(None, description="Quiz this one was regenerated from for a retake")
+
+
+class RetakeRequest(BaseModel):
+    """Request a variant of a quiz for a retake"""
+    quiz_id: str
+    language: Optional[str] = Field(None, description="BCP 47 tag of the language to write the questions in")
+    tenant_id: str = Field(default="global")
+
+
+class BankQuestion(BaseModel):
+    """A previously generated question, without its answer or explanation"""
+    quiz_id: str
+    question_id: str
+    question_type: str = "multiple_choice"
+    question_text: str
+    options: List[QuizOption] = Field(default_factory=list)
+    source_resource_id: str = ""
+    citation: str = ""
+    created_at: datetime
+
+
+class QuestionBankResponse(BaseModel):
+    """Questions found in the question bank"""
+    questions: List[BankQuestion]
+
+
+class QuestionRef(BaseModel):
+    """A question of the bank"""
+    quiz_id: str
+    question_id: str
+
+
+class ComposeRequest(BaseModel):
+    """Assemble a quiz from bank questions"""
+    title: Optional[str] = Field(None, max_length=200)
+    questions: List[QuestionRef] = Field(..., min_length=1, max_length=50)
+    tenant_id: str = Field(default="global")
 
 
 class QuizAnswer(BaseModel):
     """User's answer to a question"""
     question_id: str
-    selected_option_id: str
+    selected_option_id: str = ""
+    answer_text: Optional[str] = Field(None, max_length=4000, description="Answer to a short-answer question")
 
 
 class QuizSubmitRequest(BaseModel):
@@ -58,6 +101,10 @@ class QuestionResult(BaseModel):
     correct_option_id: str
     explanation: str
     citation: str
+    source_resource_id: Optional[str] = Field(None, description="Resource the question was drawn from")
+    question_type: str = "multiple_choice"
+    answer_text: Optional[str] = None
+    status: str = Field(default="graded", description="pending until a short answer is graded via /grade")
 
 
 class QuizSubmitResponse(BaseModel):
@@ -67,6 +114,40 @@ class QuizSubmitResponse(BaseModel):
     total_questions: int
     correct_answers: int
     results: List[QuestionResult]
+    pending_questions: int = Field(default=0, description="Short answers awaiting grading")
+
+
+class GradeRequest(BaseModel):
+    """Request to grade one short answer"""
+    quiz_id: str
+    question_id: str
+    answer_text: str = Field(..., max_length=4000)
+
+
+class GradeResponse(BaseModel):
+    """Verdict on a short answer"""
+    quiz_id: str
+    question_id: str
+    correct: bool
+    score: float = Field(..., ge=0, le=1, description="Credit for a partially correct answer")
+    feedback: str
+
+
+class QuestionCalibration(BaseModel):
+    """How often a question has been answered correctly within a tenant"""
+    quiz_id: str
+    question_id: str
+    resource_id: Optional[str] = None
+    answered: int = Field(..., ge=0)
+    correct: int = Field(..., ge=0)
+    correct_rate: float = Field(..., ge=0, le=1)
+    difficulty: Optional[str] = None
+
+
+class CalibrationRequest(BaseModel):
+    """Question statistics reported by the gateway; counts are totals"""
+    tenant_id: str = "global"
+    questions: List[QuestionCalibration]
 
 
 class HealthResponse(BaseModel):
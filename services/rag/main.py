@@ -16,6 +16,7 @@ import psycopg2
 from psycopg2.extras import RealDictCursor
 from fastapi import FastAPI, HTTPException
 from fastapi.middleware.cors import CORSMiddleware
+from fastapi.routing import APIRoute
 from pydantic import BaseModel, Field
 
 from config import get_settings
@@ -274,6 +275,26 @@ async def rerank_documents(request: RerankRequest):
         raise HTTPException(status_code=500, detail=str(e))
 
 
+def schema_fields(model):
+    """Wire names of a request model's fields, and which are required"""
+    names = {name: field.alias or name for name, field in model.model_fields.items()}
+    return {
+        "fields": sorted(names.values()),
+        "required": sorted(names[name] for name, field in model.model_fields.items() if field.is_required()),
+    }
+
+
+@app.get("/version")
+async def version():
+    """Version, routes and request schemas, for the gateway's compatibility check"""
+    return {
+        "service": settings.service_name,
+        "version": "1.0.0",
+        "routes": sorted({route.path for route in app.routes if isinstance(route, APIRoute)}),
+        "schemas": {model.__name__: schema_fields(model) for model in (SearchRequest, IngestResourcesRequest, Resource)},
+    }
+
+
 @app.get("/")
 async def root():
     """Root endpoint"""
@@ -298,6 +319,7 @@ class Skill(BaseModel):
 
 
 class Resource(BaseModel):
+    id: Optional[str] = Field(None, description="Resource ID chosen by the caller; generated when omitted")
     title: str
     url: str
     provider: Optional[str] = None
@@ -308,6 +330,7 @@ class Resource(BaseModel):
     media_type: Optional[str] = None
     description: Optional[str] = None
     tenant_id: str = Field(default="global", description="Tenant ID for this resource")
+    content: Optional[str] = Field(None, description="Full text of an uploaded document or video transcript; used instead of fetching the URL")
 
 
 class IngestSkillsRequest(BaseModel):
@@ -325,6 +348,7 @@ class IngestResponse(BaseModel):
     failed: int
     total: int
     errors: List[str] = Field(default_factory=list)
+    resource_ids: List[str] = Field(default_factory=list, description="IDs of the resources ingested, in request order")
 
 
 def get_db_connection():
@@ -521,6 +545,7 @@ async def ingest_resources(request: IngestResourcesRequest):
     success_count = 0
     failed_count = 0
     errors = []
+    resource_ids = []
     
     try:
         with conn.cursor() as cur:
@@ -530,11 +555,14 @@ async def ingest_resources(request: IngestResourcesRequest):
             
             for resource in request.resources:
                 try:
-                    resource_id = str(uuid.uuid4())
+                    resource_id = resource.id or str(uuid.uuid4())
                     s3_key = None
                     
+                    # Uploaded documents arrive with their text; there is no URL to fetch
+                    if resource.content:
+                        s3_key = upload_to_s3(resource.content, resource_id)
                     # Extract content first (if requested) to get metadata
-                    if request.extract_content:
+                    elif request.extract_content:
                         extracted = extract_content_from_url(resource.url)
                         
                         # Update title if missing or just URL
@@ -646,6 +674,7 @@ async def ingest_resources(request: IngestResourcesRequest):
                             # Don't fail the whole ingestion if embedding fails
                     
                     success_count += 1
+                    resource_ids.append(resource_id)
                     
                 except Exception as e:
                     failed_count += 1
@@ -671,5 +700,6 @@ async def ingest_resources(request: IngestResourcesRequest):
         success=success_count,
         failed=failed_count,
         total=len(request.resources),
-        errors=errors
+        errors=errors,
+        resource_ids=resource_ids
     )
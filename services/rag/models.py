@@ -25,6 +25,9 @@ class SearchFilter(BaseModel):
     skills: Optional[List[str]] = Field(None, description="Required skill UUIDs")
     media_type: Optional[str] = Field(None, description="Media type filter")
     provider: Optional[str] = Field(None, description="Provider filter")
+    licenses: Optional[List[str]] = Field(None, description="Only resources under these licenses")
+    exclude_licenses: Optional[List[str]] = Field(None, description="No resources under these licenses")
+    resource_ids: Optional[List[str]] = Field(None, description="Only these resources")
     tenant_id: Optional[str] = Field(None, description="Filter by tenant ID")
 
 
@@ -4,7 +4,7 @@ Qdrant search functionality
 import logging
 from typing import List, Optional, Dict, Any
 from qdrant_client import QdrantClient
-from qdrant_client.models import Filter, FieldCondition, MatchValue, Range, PointStruct
+from qdrant_client.models import Filter, FieldCondition, MatchAny, MatchValue, Range, PointStruct
 import uuid
 
 from config import get_settings
@@ -97,10 +97,41 @@ class SearchService:
                 )
             )
         
-        if not conditions:
+        # Resource filter, e.g. a plan built from just-ingested content
+        if search_filter.get("resource_ids"):
+            conditions.append(
+                FieldCondition(
+                    key="resource_id",
+                    match=MatchAny(any=search_filter["resource_ids"])
+                )
+            )
+        
+        # License filters; resources without a license pass the exclusion
+        excluded = []
+        if search_filter.get("licenses"):
+            conditions.append(
+                FieldCondition(
+                    key="license",
+                    match=MatchAny(any=self._license_variants(search_filter["licenses"]))
+                )
+            )
+        if search_filter.get("exclude_licenses"):
+            excluded.append(
+                FieldCondition(
+                    key="license",
+                    match=MatchAny(any=self._license_variants(search_filter["exclude_licenses"]))
+                )
+            )
+        
+        if not conditions and not excluded:
             return None
         
-        return Filter(must=conditions)
+        return Filter(must=conditions, must_not=excluded or None)
+    
+    @staticmethod
+    def _license_variants(licenses: List[str]) -> List[str]:
+        """Spellings of each license to match, as stored payloads vary in case"""
+        return sorted({v for l in licenses for v in (l, l.lower(), l.upper(), l.capitalize())})
     
     def search(
         self,
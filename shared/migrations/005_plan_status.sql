@@ -0,0 +1,6 @@
+-- Learning Path Designer - Plan Status
+-- Version: 005
+-- Description: Plans whose quizzes could not be generated after creation
+-- can be kept as drafts instead of being deleted
+
+ALTER TABLE learning_plans ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active';